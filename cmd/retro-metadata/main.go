@@ -0,0 +1,553 @@
+// Command retro-metadata scans, verifies and identifies ROM collections.
+//
+// Usage:
+//
+//	retro-metadata scan [-json] <dir>
+//	retro-metadata verify -dat <datfile-or-dir> [-json] <dir>
+//	retro-metadata audit -dat <datfile> [-out <collection.dat>] [-json] <dir>
+//	retro-metadata rename -config <config.json> [-provider <name>] [-keep-titles] [-canonical] [-apply [-journal <file>]] [-json] <dir>
+//	retro-metadata rename -dat <datfile-or-dir> [-apply [-journal <file>]] [-json] <dir>
+//	retro-metadata rename -undo <journal> <dir>
+//	retro-metadata dedupe -config <config.json> [-json] <dir>
+//	retro-metadata artwork -config <config.json> [-plan] [-out <dir>] [-types cover,screenshots] [-format extended|simple] [-json] <dir>
+//	retro-metadata serve -config <config.json> [-addr :8080] [-api-key <key>] [-webhook <url>] [-record|-replay <cassette.json>]
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/josegonzalez/retro-metadata/pkg/artwork"
+	"github.com/josegonzalez/retro-metadata/pkg/events"
+	"github.com/josegonzalez/retro-metadata/pkg/identify"
+	"github.com/josegonzalez/retro-metadata/pkg/progress"
+	"github.com/josegonzalez/retro-metadata/pkg/provider/datfile"
+	"github.com/josegonzalez/retro-metadata/pkg/provider/screenscraper"
+	"github.com/josegonzalez/retro-metadata/pkg/recorder"
+	"github.com/josegonzalez/retro-metadata/pkg/retrometadata"
+	"github.com/josegonzalez/retro-metadata/pkg/scanner"
+	"github.com/josegonzalez/retro-metadata/pkg/server"
+	"github.com/josegonzalez/retro-metadata/pkg/verify"
+
+	// Providers register themselves with the client when imported
+	_ "github.com/josegonzalez/retro-metadata/pkg/provider/hasheous"
+	_ "github.com/josegonzalez/retro-metadata/pkg/provider/igdb"
+	_ "github.com/josegonzalez/retro-metadata/pkg/provider/mobygames"
+	_ "github.com/josegonzalez/retro-metadata/pkg/provider/retroachievements"
+)
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+		os.Exit(2)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	var err error
+	switch os.Args[1] {
+	case "scan":
+		err = runScan(ctx, os.Args[2:])
+	case "verify":
+		err = runVerify(ctx, os.Args[2:])
+	case "audit":
+		err = runAudit(ctx, os.Args[2:])
+	case "rename":
+		err = runRename(ctx, os.Args[2:])
+	case "dedupe":
+		err = runDedupe(ctx, os.Args[2:])
+	case "artwork":
+		err = runArtwork(ctx, os.Args[2:])
+	case "serve":
+		err = runServe(ctx, os.Args[2:])
+	case "-h", "-help", "--help", "help":
+		usage()
+		return
+	default:
+		fmt.Fprintf(os.Stderr, "unknown command: %s\n\n", os.Args[1])
+		usage()
+		os.Exit(2)
+	}
+
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "Usage: retro-metadata <command> [flags]")
+	fmt.Fprintln(os.Stderr)
+	fmt.Fprintln(os.Stderr, "Commands:")
+	fmt.Fprintln(os.Stderr, "  scan     find and hash ROM files in a directory")
+	fmt.Fprintln(os.Stderr, "  verify   verify ROM files against No-Intro/Redump/TOSEC datfiles")
+	fmt.Fprintln(os.Stderr, "  audit    list the games of a datfile a collection has and misses")
+	fmt.Fprintln(os.Stderr, "  rename   rename identified ROM files to tagged canonical names")
+	fmt.Fprintln(os.Stderr, "  dedupe   find identical ROMs and games with several releases")
+	fmt.Fprintln(os.Stderr, "  artwork  plan or download artwork for identified ROM files")
+	fmt.Fprintln(os.Stderr, "  serve    serve the configured providers over an HTTP API")
+}
+
+// newProgress returns a terminal progress bar on stderr, or a no-op when quiet.
+func newProgress(quiet bool) progress.Progress {
+	if quiet {
+		return progress.Noop{}
+	}
+	return newTerminalProgress(os.Stderr)
+}
+
+func runScan(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("scan", flag.ExitOnError)
+	asJSON := fs.Bool("json", false, "print results as JSON")
+	noHash := fs.Bool("no-hash", false, "skip hashing files")
+	quiet := fs.Bool("quiet", false, "don't show progress")
+	fs.Parse(args)
+
+	if fs.NArg() != 1 {
+		return fmt.Errorf("usage: retro-metadata scan [flags] <dir>")
+	}
+
+	s := scanner.New(
+		scanner.WithHashing(!*noHash),
+		scanner.WithProgress(newProgress(*quiet)),
+	)
+	groups, err := s.Scan(ctx, fs.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	if *asJSON {
+		return writeJSON(os.Stdout, groups)
+	}
+
+	for _, group := range groups {
+		md5 := ""
+		if group.Hashes != nil {
+			md5 = group.Hashes.MD5
+		}
+		fmt.Printf("%-32s %s\n", md5, group.Filename)
+		for _, disc := range group.Discs {
+			fmt.Printf("%-32s   disc %d: %s\n", "", disc.Number, disc.Filename)
+		}
+	}
+	return nil
+}
+
+func runVerify(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("verify", flag.ExitOnError)
+	datPath := fs.String("dat", "", "datfile or directory of datfiles")
+	asJSON := fs.Bool("json", false, "print the report as JSON")
+	quiet := fs.Bool("quiet", false, "don't show progress")
+	fs.Parse(args)
+
+	if *datPath == "" || fs.NArg() != 1 {
+		return fmt.Errorf("usage: retro-metadata verify -dat <datfile-or-dir> [flags] <dir>")
+	}
+
+	report, err := verifyDir(ctx, *datPath, fs.Arg(0), *quiet)
+	if err != nil {
+		return err
+	}
+
+	if *asJSON {
+		return writeJSON(os.Stdout, report)
+	}
+
+	for _, result := range report.Results {
+		switch {
+		case result.Error != "":
+			fmt.Printf("%-10s %s: %s\n", "error", result.Path, result.Error)
+		case result.IsGoodDump():
+			fmt.Printf("%-10s %s (%s: %s)\n", result.Status, result.Path, result.Source, result.MatchedName)
+		default:
+			fmt.Printf("%-10s %s: %s\n", result.Status, result.Path, result.Reason)
+		}
+	}
+	return nil
+}
+
+// verifyDir verifies the ROM files in dir against the datfiles at datPath.
+func verifyDir(ctx context.Context, datPath, dir string, quiet bool) (*verify.Report, error) {
+	matcher := datfile.New(&retrometadata.ProviderConfig{
+		Enabled: true,
+		Options: map[string]any{"dat_paths": []string{datPath}},
+	})
+	if err := matcher.LoadDatfiles(ctx); err != nil {
+		return nil, err
+	}
+
+	groups, err := scanner.New(scanner.WithHashing(false)).Scan(ctx, dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var paths []string
+	for _, group := range groups {
+		if len(group.Discs) == 0 {
+			paths = append(paths, group.Filename)
+		}
+		for _, disc := range group.Discs {
+			paths = append(paths, disc.Filename)
+		}
+	}
+
+	verifier := verify.NewVerifier(matcher)
+	verifier.SetProgress(newProgress(quiet))
+	return verifier.VerifyFiles(ctx, paths)
+}
+
+func runAudit(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("audit", flag.ExitOnError)
+	datPath := fs.String("dat", "", "official datfile to audit against")
+	outPath := fs.String("out", "", "write the collection as a datfile to this path")
+	asJSON := fs.Bool("json", false, "print the report as JSON")
+	quiet := fs.Bool("quiet", false, "don't show progress")
+	fs.Parse(args)
+
+	if *datPath == "" || fs.NArg() != 1 {
+		return fmt.Errorf("usage: retro-metadata audit -dat <datfile> [flags] <dir>")
+	}
+
+	official, err := datfile.ParseFile(*datPath)
+	if err != nil {
+		return err
+	}
+	groups, err := scanner.New(scanner.WithProgress(newProgress(*quiet))).Scan(ctx, fs.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	collection := datfile.FromGroups(datfile.Header{
+		Name:        official.Header.Name + " (Collection)",
+		Description: "Collection of " + fs.Arg(0),
+		Version:     time.Now().Format("20060102-150405"),
+		Author:      "retro-metadata",
+	}, groups)
+	if *outPath != "" {
+		if err := collection.WriteFile(*outPath); err != nil {
+			return err
+		}
+	}
+
+	audit := datfile.Compare(official, collection)
+	if *asJSON {
+		return writeJSON(os.Stdout, audit)
+	}
+
+	for _, name := range audit.Have {
+		fmt.Printf("%-8s %s\n", "have", name)
+	}
+	for _, name := range audit.Partial {
+		fmt.Printf("%-8s %s\n", "partial", name)
+	}
+	for _, name := range audit.Missing {
+		fmt.Printf("%-8s %s\n", "missing", name)
+	}
+	for _, name := range audit.Unknown {
+		fmt.Printf("%-8s %s\n", "unknown", name)
+	}
+	fmt.Printf("\n%d have, %d partial, %d missing, %d unknown\n", len(audit.Have), len(audit.Partial), len(audit.Missing), len(audit.Unknown))
+	return nil
+}
+
+func runRename(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("rename", flag.ExitOnError)
+	configPath := fs.String("config", "config.json", "path to the JSON configuration file")
+	datPath := fs.String("dat", "", "rename verified files to their names in this datfile or directory of datfiles")
+	providerName := fs.String("provider", "", "provider whose ID tags the files (default: the one each game was identified by)")
+	keepTitles := fs.Bool("keep-titles", false, "keep the titles of the filenames instead of the games' names")
+	canonical := fs.Bool("canonical", false, "rename files to the No-Intro names of confidently matched games instead of tagging them")
+	apply := fs.Bool("apply", false, "rename the files instead of printing the plan")
+	journalPath := fs.String("journal", "", "record the renames made with -apply in this file, for -undo")
+	undoPath := fs.String("undo", "", "revert the renames of files in <dir> recorded in this journal file")
+	asJSON := fs.Bool("json", false, "print the plan as JSON")
+	quiet := fs.Bool("quiet", false, "don't show progress")
+	fs.Parse(args)
+
+	if fs.NArg() != 1 {
+		return fmt.Errorf("usage: retro-metadata rename [-config <config.json> | -dat <datfile-or-dir> | -undo <journal>] [flags] <dir>")
+	}
+	if *undoPath != "" {
+		return scanner.UndoJournal(*undoPath, fs.Arg(0))
+	}
+
+	var plan *scanner.RenamePlan
+	if *datPath != "" {
+		report, err := verifyDir(ctx, *datPath, fs.Arg(0), *quiet)
+		if err != nil {
+			return err
+		}
+		plan = scanner.PlanVerifiedRenames(report.Results, scanner.CanonicalOptions{})
+	} else {
+		config, err := retrometadata.LoadConfig(*configPath)
+		if err != nil {
+			return err
+		}
+		client, err := retrometadata.NewClient(retrometadata.WithConfig(config))
+		if err != nil {
+			return err
+		}
+		defer client.Close()
+
+		groups, err := scanner.New(scanner.WithProgress(newProgress(*quiet))).Scan(ctx, fs.Arg(0))
+		if err != nil {
+			return err
+		}
+		results := identify.DefaultPipeline().IdentifyBatchWithClient(ctx, client, groups, identify.BatchOptions{
+			Concurrency: 4,
+			Progress:    newProgress(*quiet),
+		})
+		if *canonical {
+			plan = scanner.PlanCanonicalRenames(results, scanner.CanonicalOptions{})
+		} else {
+			plan = scanner.PlanRenames(results, scanner.RenameOptions{Provider: *providerName, KeepTitles: *keepTitles})
+		}
+	}
+
+	if *asJSON {
+		if err := writeJSON(os.Stdout, plan); err != nil {
+			return err
+		}
+	} else if err := plan.Print(os.Stdout); err != nil {
+		return err
+	}
+	switch {
+	case !*apply:
+		return nil
+	case *journalPath != "":
+		return plan.ApplyWithJournal(*journalPath)
+	default:
+		return plan.Apply()
+	}
+}
+
+func runDedupe(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("dedupe", flag.ExitOnError)
+	configPath := fs.String("config", "config.json", "path to the JSON configuration file")
+	asJSON := fs.Bool("json", false, "print the report as JSON")
+	quiet := fs.Bool("quiet", false, "don't show progress")
+	fs.Parse(args)
+
+	if fs.NArg() != 1 {
+		return fmt.Errorf("usage: retro-metadata dedupe -config <config.json> [flags] <dir>")
+	}
+
+	config, err := retrometadata.LoadConfig(*configPath)
+	if err != nil {
+		return err
+	}
+	client, err := retrometadata.NewClient(retrometadata.WithConfig(config))
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	groups, err := scanner.New(scanner.WithProgress(newProgress(*quiet))).Scan(ctx, fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	results := identify.DefaultPipeline().IdentifyBatchWithClient(ctx, client, groups, identify.BatchOptions{
+		Concurrency: 4,
+		Progress:    newProgress(*quiet),
+	})
+	report, err := scanner.Deduplicate(ctx, results)
+	if err != nil {
+		return err
+	}
+
+	if *asJSON {
+		return writeJSON(os.Stdout, report)
+	}
+	return report.Print(os.Stdout)
+}
+
+func runArtwork(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("artwork", flag.ExitOnError)
+	configPath := fs.String("config", "config.json", "path to the JSON configuration file")
+	plan := fs.Bool("plan", false, "print the planned downloads instead of downloading them")
+	outputDir := fs.String("out", "artwork", "directory to write artwork to")
+	types := fs.String("types", artwork.TypeCover, "comma-separated artwork types")
+	format := fs.String("format", artwork.FormatExtended, "output filename format (extended or simple)")
+	sizes := fs.Bool("sizes", true, "estimate sizes with HEAD requests")
+	asJSON := fs.Bool("json", false, "print the plan as JSON")
+	quiet := fs.Bool("quiet", false, "don't show progress")
+	fs.Parse(args)
+
+	if fs.NArg() != 1 {
+		return fmt.Errorf("usage: retro-metadata artwork -config <config.json> [flags] <dir>")
+	}
+
+	config, err := retrometadata.LoadConfig(*configPath)
+	if err != nil {
+		return err
+	}
+	client, err := retrometadata.NewClient(retrometadata.WithConfig(config))
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	// ScreenScraper media downloads count against the account's quota
+	httpClient := &http.Client{Timeout: time.Duration(config.DefaultTimeout) * time.Second}
+	if p, ok := client.GetProvider("screenscraper"); ok {
+		if ss, ok := p.(*screenscraper.Provider); ok {
+			httpClient.Transport = ss.MediaTransport(nil)
+		}
+	}
+	planner, err := artwork.NewPlanner(*outputDir,
+		artwork.WithHTTPClient(httpClient),
+		artwork.WithTypes(strings.Split(*types, ",")...),
+		artwork.WithFilenameFormat(*format),
+		artwork.WithSizeEstimates(*sizes),
+		artwork.WithProgress(newProgress(*quiet)),
+	)
+	if err != nil {
+		return err
+	}
+
+	groups, err := scanner.New(scanner.WithProgress(newProgress(*quiet))).Scan(ctx, fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	results := identify.DefaultPipeline().IdentifyBatchWithClient(ctx, client, groups, identify.BatchOptions{
+		Concurrency: 4,
+		Progress:    newProgress(*quiet),
+	})
+	var targets []artwork.Target
+	for _, result := range results {
+		if result.Err == nil && result.Result != nil {
+			targets = append(targets, artwork.Target{Game: result.Result, ROMFilename: filepath.Base(result.Group.Filename)})
+		}
+	}
+
+	artworkPlan, err := planner.PlanGames(ctx, targets)
+	if err != nil {
+		return err
+	}
+	if !*plan {
+		return planner.DownloadPlan(ctx, artworkPlan)
+	}
+	if *asJSON {
+		return writeJSON(os.Stdout, artworkPlan)
+	}
+	return artworkPlan.Print(os.Stdout)
+}
+
+func runServe(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("serve", flag.ExitOnError)
+	configPath := fs.String("config", "config.json", "path to the JSON configuration file, reloaded on SIGHUP")
+	addr := fs.String("addr", "127.0.0.1:8080", "address to listen on")
+	apiKey := fs.String("api-key", os.Getenv("RETRO_METADATA_API_KEY"), "API key clients must send (default $RETRO_METADATA_API_KEY)")
+	webhookURL := fs.String("webhook", "", "URL events are POSTed to")
+	webhookSecret := fs.String("webhook-secret", os.Getenv("RETRO_METADATA_WEBHOOK_SECRET"), "secret webhook deliveries are signed with (default $RETRO_METADATA_WEBHOOK_SECRET)")
+	recordPath := fs.String("record", "", "record provider requests to a cassette file, written on shutdown")
+	replayPath := fs.String("replay", "", "answer provider requests from a recorded cassette file")
+	fs.Parse(args)
+
+	config, err := retrometadata.LoadConfig(*configPath)
+	if err != nil {
+		return err
+	}
+
+	clientOpts := []retrometadata.Option{retrometadata.WithConfig(config)}
+	switch {
+	case *recordPath != "" && *replayPath != "":
+		return fmt.Errorf("-record and -replay can't be used together")
+	case *recordPath != "":
+		rec, err := recorder.New(*recordPath, recorder.Record)
+		if err != nil {
+			return err
+		}
+		defer func() {
+			if err := rec.Save(); err != nil {
+				log.Printf("Saving %s: %v", *recordPath, err)
+			}
+		}()
+		clientOpts = append(clientOpts, retrometadata.WithTransport(rec))
+	case *replayPath != "":
+		rec, err := recorder.New(*replayPath, recorder.Replay)
+		if err != nil {
+			return err
+		}
+		clientOpts = append(clientOpts, retrometadata.WithTransport(rec))
+	}
+
+	client, err := retrometadata.NewClient(clientOpts...)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+	client.OnProviderChange(func(change retrometadata.ProviderChange) {
+		log.Printf("Provider %s %s", change.Provider, change.State)
+	})
+
+	// SIGHUP reloads the configuration, e.g. after rotating credentials
+	reload := make(chan os.Signal, 1)
+	signal.Notify(reload, syscall.SIGHUP)
+	defer signal.Stop(reload)
+	go func() {
+		for range reload {
+			config, err := retrometadata.LoadConfig(*configPath)
+			if err == nil {
+				err = client.UpdateConfig(append([]retrometadata.Option{retrometadata.WithConfig(config)}, clientOpts[1:]...)...)
+			}
+			if err != nil {
+				log.Printf("Reloading %s: %v", *configPath, err)
+			}
+		}
+	}()
+
+	opts := []server.Option{server.WithAPIKeys(*apiKey)}
+	if *webhookURL != "" {
+		bus := events.NewBus()
+		webhook := events.NewWebhook(*webhookURL, events.WithSecret(*webhookSecret))
+		bus.Subscribe(webhook.Handle)
+		defer func() {
+			closeCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			defer cancel()
+			_ = webhook.Close(closeCtx)
+		}()
+		opts = append(opts, server.WithEvents(bus))
+	}
+
+	if *apiKey == "" {
+		log.Print("No API key set; every request is accepted")
+	}
+	srv := &http.Server{
+		Addr:              *addr,
+		Handler:           server.New(client, opts...),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		_ = srv.Shutdown(shutdownCtx)
+	}()
+
+	log.Printf("Serving %v on %s", config.GetEnabledProviders(), *addr)
+	if err := srv.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+func writeJSON(w io.Writer, v any) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(v)
+}
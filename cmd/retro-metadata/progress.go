@@ -0,0 +1,89 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+)
+
+// terminalProgress renders a single-line progress bar to a terminal.
+// It is safe for concurrent use.
+type terminalProgress struct {
+	mu        sync.Mutex
+	out       io.Writer
+	width     int
+	total     int
+	completed int
+	status    string
+	started   time.Time
+	lastDraw  time.Time
+}
+
+// newTerminalProgress creates a progress bar writing to out.
+func newTerminalProgress(out io.Writer) *terminalProgress {
+	return &terminalProgress{out: out, width: 30}
+}
+
+// Start begins a new operation.
+func (p *terminalProgress) Start(total int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.total = total
+	p.completed = 0
+	p.status = ""
+	p.started = time.Now()
+	p.draw(true)
+}
+
+// Increment marks n more items as completed.
+func (p *terminalProgress) Increment(n int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.completed += n
+	p.draw(p.completed == p.total)
+}
+
+// SetStatus sets the current item description.
+func (p *terminalProgress) SetStatus(status string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.status = status
+	p.draw(false)
+}
+
+// Done finishes the progress line.
+func (p *terminalProgress) Done() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.status = fmt.Sprintf("done in %s", time.Since(p.started).Round(time.Millisecond))
+	p.draw(true)
+	fmt.Fprintln(p.out)
+}
+
+// draw redraws the line, throttled to avoid flooding slow terminals.
+func (p *terminalProgress) draw(force bool) {
+	now := time.Now()
+	if !force && now.Sub(p.lastDraw) < 100*time.Millisecond {
+		return
+	}
+	p.lastDraw = now
+
+	var bar string
+	if p.total > 0 {
+		filled := p.width * p.completed / p.total
+		if filled > p.width {
+			filled = p.width
+		}
+		bar = fmt.Sprintf("[%s%s] %d/%d", strings.Repeat("=", filled), strings.Repeat(" ", p.width-filled), p.completed, p.total)
+	} else {
+		bar = fmt.Sprintf("%d", p.completed)
+	}
+
+	status := p.status
+	if len(status) > 40 {
+		status = status[:37] + "..."
+	}
+	fmt.Fprintf(p.out, "\r\033[K%s %s", bar, status)
+}
@@ -0,0 +1,96 @@
+// Example: Artwork Plan
+//
+// This example demonstrates how to plan artwork downloads for a ROM without
+// downloading anything, listing every image URL, its type, estimated size and
+// destination path.
+//
+// To run:
+//
+//	export IGDB_CLIENT_ID="your_client_id"
+//	export IGDB_CLIENT_SECRET="your_client_secret"
+//	go run main.go -platform 19 -types cover,screenshots "Super Mario World (USA).sfc"
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/josegonzalez/retro-metadata/pkg/artwork"
+	"github.com/josegonzalez/retro-metadata/pkg/provider/igdb"
+	"github.com/josegonzalez/retro-metadata/pkg/retrometadata"
+)
+
+func main() {
+	outputDir := flag.String("out", "artwork", "directory artwork would be written to")
+	types := flag.String("types", artwork.TypeCover, "comma-separated artwork types")
+	format := flag.String("format", artwork.FormatExtended, "output filename format (extended or simple)")
+	platformID := flag.Int("platform", 19, "IGDB platform ID (19 = SNES)")
+	asJSON := flag.Bool("json", false, "print the plan as JSON")
+	flag.Parse()
+
+	if flag.NArg() < 1 {
+		fmt.Println("Usage: go run main.go [flags] <filename>")
+		flag.PrintDefaults()
+		os.Exit(1)
+	}
+	romFilename := flag.Arg(0)
+
+	clientID := os.Getenv("IGDB_CLIENT_ID")
+	clientSecret := os.Getenv("IGDB_CLIENT_SECRET")
+	if clientID == "" || clientSecret == "" {
+		log.Fatal("Please set IGDB_CLIENT_ID and IGDB_CLIENT_SECRET environment variables")
+	}
+
+	provider, err := igdb.NewProvider(retrometadata.ProviderConfig{
+		Enabled: true,
+		Credentials: map[string]string{
+			"client_id":     clientID,
+			"client_secret": clientSecret,
+		},
+		Timeout: 30,
+	}, nil)
+	if err != nil {
+		log.Fatalf("Failed to create provider: %v", err)
+	}
+
+	ctx := context.Background()
+	game, err := provider.Identify(ctx, romFilename, retrometadata.IdentifyOptions{PlatformID: platformID})
+	if err != nil {
+		log.Fatalf("Identify failed: %v", err)
+	}
+	if game == nil {
+		log.Fatalf("No match found for %s", romFilename)
+	}
+
+	planner, err := artwork.NewPlanner(*outputDir,
+		artwork.WithTypes(strings.Split(*types, ",")...),
+		artwork.WithFilenameFormat(*format),
+	)
+	if err != nil {
+		log.Fatalf("Invalid options: %v", err)
+	}
+
+	plan, err := planner.PlanGame(ctx, game, romFilename)
+	if err != nil {
+		log.Fatalf("Planning failed: %v", err)
+	}
+
+	if *asJSON {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(plan); err != nil {
+			log.Fatalf("Failed to encode plan: %v", err)
+		}
+		return
+	}
+
+	fmt.Printf("Artwork plan for %s:\n\n", game.Name)
+	if err := plan.Print(os.Stdout); err != nil {
+		log.Fatalf("Failed to print plan: %v", err)
+	}
+}
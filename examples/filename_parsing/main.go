@@ -53,13 +53,12 @@ func main() {
 			fmt.Printf("  Note: This is a BIOS file\n")
 		}
 
-		// Check if it's a demo
-		if filename.IsDemoFile(romFilename) {
-			fmt.Printf("  Note: This is a demo/beta file\n")
+		// Check release status
+		parsed := filename.ParseNoIntroFilename(romFilename)
+		if parsed.IsPrerelease() {
+			fmt.Printf("  Note: This is a %s file\n", parsed.DevStatus)
 		}
-
-		// Check if it's unlicensed
-		if filename.IsUnlicensed(romFilename) {
+		if !parsed.Licensed {
 			fmt.Printf("  Note: This is an unlicensed ROM\n")
 		}
 
@@ -74,6 +73,7 @@ func main() {
 	noIntroExamples := []string{
 		"Super Mario World (USA).sfc",
 		"Legend of Zelda, The - A Link to the Past (USA, Europe) (Rev 1).sfc",
+		"Final Fantasy VII (Europe) (En,Fr,De) (Disc 2) [!].bin",
 	}
 
 	for _, romFilename := range noIntroExamples {
@@ -87,8 +87,17 @@ func main() {
 		if len(parsed.Tags) > 0 {
 			fmt.Printf("  Tags: %v\n", parsed.Tags)
 		}
+		if parsed.Revision != "" {
+			fmt.Printf("  Revision: %s\n", parsed.Revision)
+		}
 		if len(parsed.Languages) > 0 {
 			fmt.Printf("  Languages: %v\n", parsed.Languages)
 		}
+		if parsed.Disc > 0 {
+			fmt.Printf("  Disc: %d\n", parsed.Disc)
+		}
+		if parsed.Dump.Verified {
+			fmt.Printf("  Dump: verified\n")
+		}
 	}
 }
@@ -0,0 +1,254 @@
+// Example: Headless Scraper Daemon
+//
+// This example shows the full intended flow for an unattended scraper, such as
+// one running on a NAS or a handheld:
+//
+//  1. Load the configuration from a JSON file
+//  2. Construct a client with every configured provider
+//  3. Watch a ROM directory, rescanning it on an interval
+//  4. Identify new or changed ROMs in a batch
+//  5. Download their artwork
+//  6. Export the library
+//
+// To run:
+//
+//	go run main.go -config config.example.json -roms ~/roms -out ~/scraped
+//
+// Pass -once to scan a single time and exit instead of watching.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sort"
+	"syscall"
+	"time"
+
+	"github.com/josegonzalez/retro-metadata/pkg/artwork"
+	"github.com/josegonzalez/retro-metadata/pkg/export"
+	"github.com/josegonzalez/retro-metadata/pkg/identify"
+	"github.com/josegonzalez/retro-metadata/pkg/retrometadata"
+	"github.com/josegonzalez/retro-metadata/pkg/scanner"
+
+	// Providers register themselves with the client when imported
+	_ "github.com/josegonzalez/retro-metadata/pkg/provider/hasheous"
+	_ "github.com/josegonzalez/retro-metadata/pkg/provider/igdb"
+	_ "github.com/josegonzalez/retro-metadata/pkg/provider/mobygames"
+	_ "github.com/josegonzalez/retro-metadata/pkg/provider/retroachievements"
+	"github.com/josegonzalez/retro-metadata/pkg/provider/screenscraper"
+)
+
+func main() {
+	configPath := flag.String("config", "config.json", "path to the JSON configuration file")
+	romsDir := flag.String("roms", "roms", "ROM directory to watch")
+	outputDir := flag.String("out", "scraped", "directory for artwork and the exported library")
+	interval := flag.Duration("interval", 5*time.Minute, "time between scans")
+	concurrency := flag.Int("concurrency", 4, "number of ROMs identified at once")
+	once := flag.Bool("once", false, "scan once and exit")
+	flag.Parse()
+
+	// Stop cleanly on Ctrl+C or when the service manager stops the daemon
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	config, err := retrometadata.LoadConfig(*configPath)
+	if err != nil {
+		log.Fatalf("Failed to load config: %v", err)
+	}
+
+	d, err := newDaemon(config, *outputDir, *concurrency)
+	if err != nil {
+		log.Fatalf("Failed to start: %v", err)
+	}
+	defer d.client.Close()
+
+	log.Printf("Providers: %v", config.GetEnabledProviders())
+
+	if *once {
+		if _, err := d.runOnce(ctx, *romsDir); err != nil {
+			log.Fatalf("Scan failed: %v", err)
+		}
+		return
+	}
+
+	if err := d.watch(ctx, *romsDir, *interval); err != nil && ctx.Err() == nil {
+		log.Fatalf("Watch failed: %v", err)
+	}
+	log.Print("Stopped")
+}
+
+// daemon identifies and scrapes the ROMs in a directory.
+type daemon struct {
+	client      *retrometadata.Client
+	scanner     *scanner.Scanner
+	pipeline    *identify.Pipeline
+	planner     *artwork.Planner
+	outputDir   string
+	concurrency int
+
+	// identified maps each ROM to its modification time when it was identified
+	identified map[string]time.Time
+	// entries is every identified ROM, exported after each scan
+	entries map[string]export.Entry
+}
+
+// newDaemon creates a daemon writing into outputDir.
+func newDaemon(config retrometadata.Config, outputDir string, concurrency int) (*daemon, error) {
+	client, err := retrometadata.NewClient(retrometadata.WithConfig(config))
+	if err != nil {
+		return nil, fmt.Errorf("creating client: %w", err)
+	}
+
+	// ScreenScraper media downloads count against the account's quota
+	httpClient := &http.Client{Timeout: time.Duration(config.DefaultTimeout) * time.Second}
+	if p, ok := client.GetProvider("screenscraper"); ok {
+		if ss, ok := p.(*screenscraper.Provider); ok {
+			httpClient.Transport = ss.MediaTransport(nil)
+		}
+	}
+
+	planner, err := artwork.NewPlanner(filepath.Join(outputDir, "media"),
+		artwork.WithTypes(artwork.TypeCover, artwork.TypeScreenshots),
+		artwork.WithFilenameFormat(artwork.FormatSimple),
+		artwork.WithUserAgent(config.UserAgent),
+		artwork.WithHTTPClient(httpClient),
+		// Images are downloaded right away, so there's no need to estimate sizes
+		artwork.WithSizeEstimates(false),
+	)
+	if err != nil {
+		client.Close()
+		return nil, fmt.Errorf("creating artwork planner: %w", err)
+	}
+
+	return &daemon{
+		client:      client,
+		scanner:     scanner.New(),
+		pipeline:    identify.DefaultPipeline(),
+		planner:     planner,
+		outputDir:   outputDir,
+		concurrency: concurrency,
+		identified:  make(map[string]time.Time),
+		entries:     make(map[string]export.Entry),
+	}, nil
+}
+
+// watch scans root every interval until ctx is cancelled.
+func (d *daemon) watch(ctx context.Context, root string, interval time.Duration) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := d.runOnce(ctx, root); err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			log.Printf("Scan failed: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// runOnce scans root, identifies and scrapes every new or changed ROM, and
+// exports the library. It returns the number of ROMs identified.
+func (d *daemon) runOnce(ctx context.Context, root string) (int, error) {
+	groups, err := d.scanner.Scan(ctx, root)
+	if err != nil {
+		return 0, fmt.Errorf("scanning %s: %w", root, err)
+	}
+
+	var pending []identify.DiscGroup
+	for _, group := range groups {
+		if d.changed(group.Filename) {
+			pending = append(pending, group)
+		}
+	}
+	if len(pending) == 0 {
+		return 0, nil
+	}
+	log.Printf("Identifying %d new or changed ROMs", len(pending))
+
+	results := d.pipeline.IdentifyBatchWithClient(ctx, d.client, pending, identify.BatchOptions{
+		Concurrency: d.concurrency,
+	})
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	identified := 0
+	for _, entry := range export.EntriesFromBatch(results) {
+		d.entries[entry.Path] = entry
+		d.identified[entry.Path] = modTime(entry.Path)
+		if entry.Game == nil {
+			continue
+		}
+		identified++
+
+		if err := d.downloadArtwork(ctx, entry); err != nil {
+			log.Printf("Artwork for %s: %v", filepath.Base(entry.Path), err)
+		}
+	}
+
+	if err := d.export(ctx); err != nil {
+		return identified, err
+	}
+	log.Printf("Identified %d of %d ROMs", identified, len(pending))
+	return identified, nil
+}
+
+// changed reports whether a ROM is new or was modified since it was identified.
+func (d *daemon) changed(path string) bool {
+	previous, ok := d.identified[path]
+	return !ok || !modTime(path).Equal(previous)
+}
+
+// downloadArtwork downloads the planned artwork for an identified ROM,
+// skipping images that were already downloaded.
+func (d *daemon) downloadArtwork(ctx context.Context, entry export.Entry) error {
+	plan, err := d.planner.PlanGame(ctx, entry.Game, filepath.Base(entry.Path))
+	if err != nil {
+		return err
+	}
+	return d.planner.DownloadPlan(ctx, plan)
+}
+
+// export writes every identified ROM to games.json in the output directory.
+func (d *daemon) export(ctx context.Context) error {
+	paths := make([]string, 0, len(d.entries))
+	for path := range d.entries {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	entries := make([]export.Entry, len(paths))
+	for i, path := range paths {
+		entries[i] = d.entries[path]
+	}
+
+	report, err := export.WriteFile(ctx, filepath.Join(d.outputDir, "games.json"), export.NewJSONExporter(), entries)
+	if err != nil {
+		return fmt.Errorf("exporting: %w", err)
+	}
+	log.Printf("Exported %d games to %s (%d not identified)", report.Written, report.Output, len(report.Skipped))
+	return nil
+}
+
+// modTime returns the modification time of a file, or the zero time if it
+// can't be read.
+func modTime(path string) time.Time {
+	info, err := os.Stat(path)
+	if err != nil {
+		return time.Time{}
+	}
+	return info.ModTime()
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/josegonzalez/retro-metadata/pkg/cache"
+	"github.com/josegonzalez/retro-metadata/pkg/retrometadata"
+)
+
+// fakeProvider identifies every ROM by its cleaned filename.
+type fakeProvider struct {
+	coverURL string
+}
+
+func (p *fakeProvider) Name() string { return "mobygames" }
+
+func (p *fakeProvider) Search(context.Context, string, retrometadata.SearchOptions) ([]retrometadata.SearchResult, error) {
+	return nil, nil
+}
+
+func (p *fakeProvider) GetByID(context.Context, int) (*retrometadata.GameResult, error) {
+	return nil, nil
+}
+
+func (p *fakeProvider) Identify(_ context.Context, filename string, _ retrometadata.IdentifyOptions) (*retrometadata.GameResult, error) {
+	name := strings.TrimSuffix(filepath.Base(filename), filepath.Ext(filename))
+	return &retrometadata.GameResult{
+		Name:     name,
+		Provider: p.Name(),
+		Artwork:  retrometadata.Artwork{CoverURL: p.coverURL},
+	}, nil
+}
+
+func (p *fakeProvider) Heartbeat(context.Context) error { return nil }
+
+func (p *fakeProvider) Close() error { return nil }
+
+// TestDaemonRunOnce runs the whole scan, identify, download and export flow
+// against a fake provider and image server.
+func TestDaemonRunOnce(t *testing.T) {
+	images := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("png"))
+	}))
+	defer images.Close()
+
+	retrometadata.RegisterProvider("mobygames", func(retrometadata.ProviderConfig, cache.Cache) (retrometadata.Provider, error) {
+		return &fakeProvider{coverURL: images.URL + "/cover.png"}, nil
+	})
+
+	root := t.TempDir()
+	out := t.TempDir()
+	rom := filepath.Join(root, "snes", "Super Mario World.sfc")
+	if err := os.MkdirAll(filepath.Dir(rom), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(rom, []byte("rom"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	config := retrometadata.DefaultConfig()
+	config.MobyGames.Enabled = true
+	d, err := newDaemon(config, out, 2)
+	if err != nil {
+		t.Fatalf("newDaemon() error: %v", err)
+	}
+	defer d.client.Close()
+
+	ctx := context.Background()
+	if n, err := d.runOnce(ctx, root); err != nil || n != 1 {
+		t.Fatalf("runOnce() = (%d, %v), expected 1 identified", n, err)
+	}
+
+	if _, err := os.Stat(filepath.Join(out, "media", "Super Mario World.cover.png")); err != nil {
+		t.Errorf("Expected cover to be downloaded: %v", err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(out, "games.json"))
+	if err != nil {
+		t.Fatalf("Expected export: %v", err)
+	}
+	var exported []struct {
+		Path string                   `json:"path"`
+		Game retrometadata.GameResult `json:"game"`
+	}
+	if err := json.Unmarshal(data, &exported); err != nil {
+		t.Fatalf("Invalid export: %v", err)
+	}
+	if len(exported) != 1 || exported[0].Game.Name != "Super Mario World" {
+		t.Errorf("Unexpected export: %s", data)
+	}
+
+	// Unchanged ROMs aren't identified again
+	if n, err := d.runOnce(ctx, root); err != nil || n != 0 {
+		t.Errorf("second runOnce() = (%d, %v), expected nothing new", n, err)
+	}
+}
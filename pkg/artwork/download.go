@@ -0,0 +1,130 @@
+package artwork
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Download downloads a planned item to its destination. Items whose
+// destination already exists are skipped. The image is written to a temporary
+// file first, so an interrupted download never leaves a truncated image behind.
+// Videos are downloaded with the video downloader, if one is set. Manuals,
+// maps and videos, which can be large, are resumable: an interrupted download
+// is kept and continued by the next one.
+func (p *Planner) Download(ctx context.Context, item Item) error {
+	if _, err := os.Stat(item.Destination); err == nil {
+		return nil
+	}
+	if p.downloadVideo != nil && strings.HasPrefix(item.Type, "video_") {
+		return p.downloadVideo(ctx, item)
+	}
+	if resumable(item.Type) {
+		return p.downloadResumable(ctx, item)
+	}
+
+	resp, err := p.get(ctx, item.URL, 0)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("HTTP %d", resp.StatusCode)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(item.Destination), 0o755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(item.Destination), ".download-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	_, err = io.Copy(tmp, resp.Body)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), item.Destination)
+}
+
+// resumable reports whether downloads of an artwork type are resumed.
+func resumable(artworkType string) bool {
+	return artworkType == TypeManual || strings.HasPrefix(artworkType, "map_") || strings.HasPrefix(artworkType, "video_")
+}
+
+// downloadResumable downloads an item to a ".part" file next to its
+// destination, continuing a previous partial download with a range request.
+// Servers ignoring the range send the whole file, which replaces the part.
+func (p *Planner) downloadResumable(ctx context.Context, item Item) error {
+	part := item.Destination + ".part"
+	var offset int64
+	if info, err := os.Stat(part); err == nil {
+		offset = info.Size()
+	}
+
+	resp, err := p.get(ctx, item.URL, offset)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	flags := os.O_CREATE | os.O_WRONLY
+	switch {
+	case resp.StatusCode == http.StatusPartialContent && strings.HasPrefix(resp.Header.Get("Content-Range"), fmt.Sprintf("bytes %d-", offset)):
+		flags |= os.O_APPEND
+	case resp.StatusCode == http.StatusOK:
+		flags |= os.O_TRUNC
+	case resp.StatusCode == http.StatusRequestedRangeNotSatisfiable && offset > 0:
+		// The part is the whole file
+		return os.Rename(part, item.Destination)
+	default:
+		return fmt.Errorf("HTTP %d", resp.StatusCode)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(item.Destination), 0o755); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(part, flags, 0o644)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(f, resp.Body)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+	return os.Rename(part, item.Destination)
+}
+
+// get requests a URL, from offset onwards if it isn't 0.
+func (p *Planner) get(ctx context.Context, url string, offset int64) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", p.userAgent)
+	if offset > 0 {
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+	}
+	return p.httpClient.Do(req)
+}
+
+// DownloadPlan downloads every item of a plan, stopping at the first failure.
+func (p *Planner) DownloadPlan(ctx context.Context, plan *Plan) error {
+	for _, item := range plan.Items {
+		if err := p.Download(ctx, item); err != nil {
+			return fmt.Errorf("%s: %w", item.Type, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,483 @@
+// Package artwork plans artwork downloads for identified games.
+//
+// A Plan lists every image that would be downloaded, its type, estimated size
+// and destination path, so it can be reviewed (e.g. by `artwork --plan`) before
+// any bandwidth or disk space is committed.
+package artwork
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/josegonzalez/retro-metadata/pkg/progress"
+	"github.com/josegonzalez/retro-metadata/pkg/retrometadata"
+)
+
+// Artwork types.
+const (
+	TypeCover       = "cover"
+	TypeScreenshots = "screenshots"
+	TypeBanner      = "banner"
+	TypeIcon        = "icon"
+	TypeLogo        = "logo"
+	TypeBackground  = "background"
+	TypeVideos      = "videos"
+	TypeManual      = "manual"
+	TypeMaps        = "maps"
+)
+
+// Filename formats.
+const (
+	// FormatExtended keeps the ROM extension: "Game (USA).sfc.cover.png"
+	FormatExtended = "extended"
+	// FormatSimple drops the ROM extension: "Game (USA).cover.png"
+	FormatSimple = "simple"
+)
+
+// UnknownSize is reported when the size of an image couldn't be determined.
+const UnknownSize int64 = -1
+
+// validTypes is the set of supported artwork types.
+var validTypes = map[string]bool{
+	TypeCover:       true,
+	TypeScreenshots: true,
+	TypeBanner:      true,
+	TypeIcon:        true,
+	TypeLogo:        true,
+	TypeBackground:  true,
+	TypeVideos:      true,
+	TypeManual:      true,
+	TypeMaps:        true,
+}
+
+// imageExtensions are the extensions kept when deriving a filename from a URL.
+var imageExtensions = map[string]bool{
+	".png": true, ".jpg": true, ".jpeg": true, ".gif": true, ".webp": true, ".bmp": true,
+}
+
+// videoExtensions are the extensions kept when deriving a video filename
+// from a URL.
+var videoExtensions = map[string]bool{
+	".mp4": true, ".webm": true, ".mkv": true, ".avi": true, ".mov": true,
+}
+
+// manualExtensions are the extensions kept when deriving a manual filename
+// from a URL.
+var manualExtensions = map[string]bool{
+	".pdf": true, ".cbz": true, ".zip": true,
+}
+
+// VideoDownloader downloads a video item to its destination, e.g. by running
+// a YouTube downloader. Videos are downloaded over HTTP without one.
+type VideoDownloader func(ctx context.Context, item Item) error
+
+// Item is a single planned artwork download.
+type Item struct {
+	// Game is the name of the game the artwork belongs to
+	Game string `json:"game"`
+	// Provider is the provider the artwork URL came from
+	Provider string `json:"provider,omitempty"`
+	// Type is the artwork type (cover, screenshot_1, banner, etc.)
+	Type string `json:"type"`
+	// URL is the image URL
+	URL string `json:"url"`
+	// Destination is the path the image would be written to
+	Destination string `json:"destination"`
+	// Size is the estimated size in bytes from a HEAD request, or UnknownSize
+	Size int64 `json:"size"`
+	// ContentType is the content type reported by the server, if known
+	ContentType string `json:"content_type,omitempty"`
+	// Error contains the error message if the HEAD request failed
+	Error string `json:"error,omitempty"`
+}
+
+// Plan is the list of planned artwork downloads.
+type Plan struct {
+	// Items is every planned download
+	Items []Item `json:"items"`
+	// TotalSize is the sum of all known item sizes in bytes
+	TotalSize int64 `json:"total_size"`
+	// UnknownSizes is the number of items whose size couldn't be determined
+	UnknownSizes int `json:"unknown_sizes"`
+}
+
+// add appends an item and updates the totals.
+func (p *Plan) add(item Item) {
+	p.Items = append(p.Items, item)
+	if item.Size == UnknownSize {
+		p.UnknownSizes++
+	} else {
+		p.TotalSize += item.Size
+	}
+}
+
+// Merge appends the items of another plan.
+func (p *Plan) Merge(other *Plan) {
+	if other == nil {
+		return
+	}
+	for _, item := range other.Items {
+		p.add(item)
+	}
+}
+
+// Print writes the plan for review, two lines per item: its type, URL and
+// size, then its destination, followed by the totals.
+func (p *Plan) Print(w io.Writer) error {
+	for _, item := range p.Items {
+		size := "unknown size"
+		if item.Size != UnknownSize {
+			size = fmt.Sprintf("%d bytes", item.Size)
+		}
+		if _, err := fmt.Fprintf(w, "%-14s %s (%s)\n%-14s -> %s\n", item.Type, item.URL, size, "", item.Destination); err != nil {
+			return err
+		}
+	}
+	total := fmt.Sprintf("%d files, %d bytes", len(p.Items), p.TotalSize)
+	if p.UnknownSizes > 0 {
+		total += fmt.Sprintf(" (+%d of unknown size)", p.UnknownSizes)
+	}
+	_, err := fmt.Fprintln(w, total)
+	return err
+}
+
+// Planner builds artwork download plans.
+type Planner struct {
+	httpClient     *http.Client
+	userAgent      string
+	outputDir      string
+	filenameFormat string
+	types          []string
+	estimateSizes  bool
+	progress       progress.Progress
+	downloadVideo  VideoDownloader
+}
+
+// PlannerOption is a functional option for Planner.
+type PlannerOption func(*Planner)
+
+// WithHTTPClient sets the HTTP client used for HEAD requests.
+func WithHTTPClient(client *http.Client) PlannerOption {
+	return func(p *Planner) {
+		p.httpClient = client
+	}
+}
+
+// WithUserAgent sets the user agent for HEAD requests.
+func WithUserAgent(userAgent string) PlannerOption {
+	return func(p *Planner) {
+		p.userAgent = userAgent
+	}
+}
+
+// WithFilenameFormat sets the output filename format (FormatExtended or FormatSimple).
+func WithFilenameFormat(format string) PlannerOption {
+	return func(p *Planner) {
+		p.filenameFormat = format
+	}
+}
+
+// WithTypes sets the artwork types to plan for.
+func WithTypes(types ...string) PlannerOption {
+	return func(p *Planner) {
+		p.types = types
+	}
+}
+
+// WithSizeEstimates enables or disables HEAD requests for size estimates.
+func WithSizeEstimates(enabled bool) PlannerOption {
+	return func(p *Planner) {
+		p.estimateSizes = enabled
+	}
+}
+
+// WithProgress sets the progress reporter used by PlanGames.
+func WithProgress(prog progress.Progress) PlannerOption {
+	return func(p *Planner) {
+		p.progress = prog
+	}
+}
+
+// WithVideoDownloader sets the downloader used for videos. YouTube videos
+// are only planned with one, as their URLs are web pages.
+func WithVideoDownloader(download VideoDownloader) PlannerOption {
+	return func(p *Planner) {
+		p.downloadVideo = download
+	}
+}
+
+// NewPlanner creates a planner writing artwork into outputDir.
+// By default only covers are planned and sizes are estimated with HEAD requests.
+func NewPlanner(outputDir string, opts ...PlannerOption) (*Planner, error) {
+	p := &Planner{
+		httpClient:     &http.Client{Timeout: 30 * time.Second},
+		userAgent:      "retro-metadata/1.0",
+		outputDir:      outputDir,
+		filenameFormat: FormatExtended,
+		types:          []string{TypeCover},
+		estimateSizes:  true,
+	}
+
+	for _, opt := range opts {
+		opt(p)
+	}
+	p.progress = progress.OrNoop(p.progress)
+
+	for _, t := range p.types {
+		if !validTypes[t] {
+			return nil, fmt.Errorf("invalid artwork type: %s", t)
+		}
+	}
+	if p.filenameFormat != FormatExtended && p.filenameFormat != FormatSimple {
+		return nil, fmt.Errorf("invalid filename format: %s", p.filenameFormat)
+	}
+
+	return p, nil
+}
+
+// PlanGame plans the artwork downloads for a single game.
+// romFilename is used to name the output files; the game name is used if empty.
+func (p *Planner) PlanGame(ctx context.Context, game *retrometadata.GameResult, romFilename string) (*Plan, error) {
+	plan := &Plan{}
+	if game == nil {
+		return plan, nil
+	}
+
+	base := romFilename
+	if base == "" {
+		base = SanitizeFilename(game.Name)
+	} else {
+		base = filepath.Base(base)
+	}
+
+	for _, t := range p.types {
+		urls := artworkURLs(game.Artwork, t)
+		if t == TypeVideos {
+			urls = p.videoURLs(game.Metadata.Videos)
+		}
+		for _, a := range urls {
+			if err := ctx.Err(); err != nil {
+				return nil, err
+			}
+
+			item := Item{
+				Game:     game.Name,
+				Provider: game.Provider,
+				Type:     a.artworkType,
+				URL:      a.url,
+				Size:     UnknownSize,
+			}
+
+			ext := extensionFromURL(a.url)
+			switch t {
+			case TypeVideos:
+				ext = fileExtension(a.url, videoExtensions, ".mp4")
+			case TypeManual:
+				ext = fileExtension(a.url, manualExtensions, ".pdf")
+			}
+			if p.estimateSizes && !a.page {
+				p.head(ctx, &item)
+				if item.ContentType != "" {
+					ext = extensionFromContentType(item.ContentType, ext)
+				}
+			}
+			item.Destination = filepath.Join(p.outputDir, OutputFilename(base, a.artworkType, ext, p.filenameFormat))
+
+			plan.add(item)
+		}
+	}
+
+	return plan, nil
+}
+
+// Target is a game to plan artwork for.
+type Target struct {
+	// Game is the identified game
+	Game *retrometadata.GameResult
+	// ROMFilename is used to name the output files; the game name is used if empty
+	ROMFilename string
+}
+
+// PlanGames plans the artwork downloads for many games as a single plan.
+func (p *Planner) PlanGames(ctx context.Context, targets []Target) (*Plan, error) {
+	p.progress.Start(len(targets))
+	defer p.progress.Done()
+
+	plan := &Plan{}
+	for _, target := range targets {
+		if target.Game != nil {
+			p.progress.SetStatus(target.Game.Name)
+		}
+
+		gamePlan, err := p.PlanGame(ctx, target.Game, target.ROMFilename)
+		if err != nil {
+			return nil, err
+		}
+		plan.Merge(gamePlan)
+		p.progress.Increment(1)
+	}
+
+	return plan, nil
+}
+
+// head fills in the item's size and content type from a HEAD request.
+func (p *Planner) head(ctx context.Context, item *Item) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, item.URL, nil)
+	if err != nil {
+		item.Error = err.Error()
+		return
+	}
+	req.Header.Set("User-Agent", p.userAgent)
+
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		item.Error = err.Error()
+		return
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		item.Error = fmt.Sprintf("HTTP %d", resp.StatusCode)
+		return
+	}
+
+	item.ContentType = resp.Header.Get("Content-Type")
+	if resp.ContentLength >= 0 {
+		item.Size = resp.ContentLength
+	}
+}
+
+// typedURL is an artwork URL with its output artwork type.
+type typedURL struct {
+	artworkType string
+	url         string
+	page        bool // a web page rather than the file, like a YouTube video
+}
+
+// artworkURLs returns the URLs for an artwork type.
+// Screenshots are numbered screenshot_1, screenshot_2, etc.
+func artworkURLs(art retrometadata.Artwork, artworkType string) []typedURL {
+	var single string
+	switch artworkType {
+	case TypeCover:
+		single = art.CoverURL
+	case TypeBanner:
+		single = art.BannerURL
+	case TypeIcon:
+		single = art.IconURL
+	case TypeLogo:
+		single = art.LogoURL
+	case TypeBackground:
+		single = art.BackgroundURL
+	case TypeManual:
+		single = art.ManualURL
+	case TypeMaps:
+		var urls []typedURL
+		for i, u := range art.MapURLs {
+			if u != "" {
+				urls = append(urls, typedURL{artworkType: fmt.Sprintf("map_%d", i+1), url: u})
+			}
+		}
+		return urls
+	case TypeScreenshots:
+		var urls []typedURL
+		for i, u := range art.ScreenshotURLs {
+			if u != "" {
+				urls = append(urls, typedURL{artworkType: fmt.Sprintf("screenshot_%d", i+1), url: u})
+			}
+		}
+		return urls
+	}
+
+	if single == "" {
+		return nil
+	}
+	return []typedURL{{artworkType: artworkType, url: single}}
+}
+
+// videoURLs returns the URLs of videos, numbered video_1, video_2, etc.
+// YouTube videos are left out without a video downloader.
+func (p *Planner) videoURLs(videos []retrometadata.Video) []typedURL {
+	var urls []typedURL
+	for _, v := range videos {
+		if v.URL == "" || (v.YouTubeID != "" && p.downloadVideo == nil) {
+			continue
+		}
+		urls = append(urls, typedURL{
+			artworkType: fmt.Sprintf("video_%d", len(urls)+1),
+			url:         v.URL,
+			page:        v.YouTubeID != "",
+		})
+	}
+	return urls
+}
+
+// OutputFilename generates the output filename for an artwork file.
+//   - extended: "Super Mario World (USA).sfc.cover.png"
+//   - simple: "Super Mario World (USA).cover.png"
+func OutputFilename(romFilename, artworkType, extension, format string) string {
+	if !strings.HasPrefix(extension, ".") {
+		extension = "." + extension
+	}
+	if format == FormatSimple {
+		romFilename = strings.TrimSuffix(romFilename, filepath.Ext(romFilename))
+	}
+	return romFilename + "." + artworkType + extension
+}
+
+// SanitizeFilename replaces characters that aren't allowed in filenames.
+func SanitizeFilename(name string) string {
+	for _, char := range `<>:"/\|?*` {
+		name = strings.ReplaceAll(name, string(char), "_")
+	}
+	name = strings.Trim(name, ". ")
+	if name == "" {
+		return "unnamed"
+	}
+	return name
+}
+
+// extensionFromURL returns the image extension of a URL, defaulting to ".jpg".
+func extensionFromURL(rawURL string) string {
+	return fileExtension(rawURL, imageExtensions, ".jpg")
+}
+
+// fileExtension returns the extension of a URL if it's one of allowed, or
+// fallback.
+func fileExtension(rawURL string, allowed map[string]bool, fallback string) string {
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return fallback
+	}
+	ext := strings.ToLower(path.Ext(parsed.Path))
+	if allowed[ext] {
+		return ext
+	}
+	return fallback
+}
+
+// extensionFromContentType returns the image extension for a content type,
+// or fallback if the content type isn't a known image type.
+func extensionFromContentType(contentType, fallback string) string {
+	switch strings.TrimSpace(strings.ToLower(strings.Split(contentType, ";")[0])) {
+	case "image/png":
+		return ".png"
+	case "image/jpeg", "image/jpg":
+		return ".jpg"
+	case "image/gif":
+		return ".gif"
+	case "image/webp":
+		return ".webp"
+	case "image/bmp":
+		return ".bmp"
+	default:
+		return fallback
+	}
+}
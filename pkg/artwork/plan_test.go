@@ -0,0 +1,234 @@
+package artwork
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/josegonzalez/retro-metadata/pkg/retrometadata"
+)
+
+func TestPlanGame(t *testing.T) {
+	var gets int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodHead {
+			gets++
+		}
+		switch r.URL.Path {
+		case "/cover":
+			w.Header().Set("Content-Type", "image/png")
+			w.Header().Set("Content-Length", "1234")
+		case "/shot1.jpg":
+			w.Header().Set("Content-Length", "500")
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	game := &retrometadata.GameResult{
+		Name:     "Super Mario World",
+		Provider: "igdb",
+		Artwork: retrometadata.Artwork{
+			CoverURL:       server.URL + "/cover",
+			ScreenshotURLs: []string{server.URL + "/shot1.jpg", server.URL + "/missing.webp"},
+		},
+	}
+
+	planner, err := NewPlanner("/artwork", WithTypes(TypeCover, TypeScreenshots, TypeLogo), WithFilenameFormat(FormatSimple))
+	if err != nil {
+		t.Fatalf("NewPlanner() error: %v", err)
+	}
+
+	plan, err := planner.PlanGame(context.Background(), game, "/roms/Super Mario World (USA).sfc")
+	if err != nil {
+		t.Fatalf("PlanGame() error: %v", err)
+	}
+
+	if gets != 0 {
+		t.Errorf("Expected only HEAD requests, got %d other requests", gets)
+	}
+	if len(plan.Items) != 3 {
+		t.Fatalf("Expected 3 items, got %d: %+v", len(plan.Items), plan.Items)
+	}
+
+	expected := []struct {
+		artworkType string
+		destination string
+		size        int64
+	}{
+		{"cover", "Super Mario World (USA).cover.png", 1234},
+		{"screenshot_1", "Super Mario World (USA).screenshot_1.jpg", 500},
+		{"screenshot_2", "Super Mario World (USA).screenshot_2.webp", UnknownSize},
+	}
+	for i, e := range expected {
+		item := plan.Items[i]
+		if item.Type != e.artworkType || item.Destination != filepath.Join("/artwork", e.destination) || item.Size != e.size {
+			t.Errorf("Item %d = %+v, expected type %q, destination %q, size %d", i, item, e.artworkType, e.destination, e.size)
+		}
+	}
+
+	if plan.TotalSize != 1734 || plan.UnknownSizes != 1 {
+		t.Errorf("Expected total size 1734 with 1 unknown, got %d with %d unknown", plan.TotalSize, plan.UnknownSizes)
+	}
+	var out strings.Builder
+	if err := plan.Print(&out); err != nil {
+		t.Fatalf("Print() error: %v", err)
+	}
+	for _, want := range []string{
+		"cover          " + server.URL + "/cover (1234 bytes)\n",
+		"screenshot_2   " + server.URL + "/missing.webp (unknown size)\n",
+		"-> " + filepath.Join("/artwork", "Super Mario World (USA).cover.png") + "\n",
+		"3 files, 1734 bytes (+1 of unknown size)\n",
+	} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("Print() = %q, missing %q", out.String(), want)
+		}
+	}
+}
+
+func TestNewPlannerInvalidType(t *testing.T) {
+	if _, err := NewPlanner("/artwork", WithTypes("poster")); err == nil {
+		t.Error("Expected error for invalid artwork type")
+	}
+}
+
+func TestOutputFilename(t *testing.T) {
+	tests := []struct {
+		format   string
+		expected string
+	}{
+		{FormatExtended, "Super Mario World (USA).sfc.cover.png"},
+		{FormatSimple, "Super Mario World (USA).cover.png"},
+	}
+
+	for _, tt := range tests {
+		if result := OutputFilename("Super Mario World (USA).sfc", "cover", "png", tt.format); result != tt.expected {
+			t.Errorf("OutputFilename(%s) = %q, expected %q", tt.format, result, tt.expected)
+		}
+	}
+}
+
+func TestDownload(t *testing.T) {
+	var gets int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gets++
+		if r.URL.Path != "/cover.png" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_, _ = w.Write([]byte("image"))
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	planner, err := NewPlanner(dir)
+	if err != nil {
+		t.Fatalf("NewPlanner() error: %v", err)
+	}
+	ctx := context.Background()
+
+	item := Item{Type: TypeCover, URL: server.URL + "/cover.png", Destination: filepath.Join(dir, "Game.cover.png")}
+	if err := planner.Download(ctx, item); err != nil {
+		t.Fatalf("Download() error: %v", err)
+	}
+	if data, err := os.ReadFile(item.Destination); err != nil || string(data) != "image" {
+		t.Errorf("Downloaded %q, %v; expected the image", data, err)
+	}
+
+	// Existing files aren't downloaded again
+	if err := planner.Download(ctx, item); err != nil || gets != 1 {
+		t.Errorf("Download() of an existing file = %v with %d requests, expected a skip", err, gets)
+	}
+
+	missing := Item{Type: TypeCover, URL: server.URL + "/missing.png", Destination: filepath.Join(dir, "Missing.cover.png")}
+	if err := planner.DownloadPlan(ctx, &Plan{Items: []Item{missing}}); err == nil {
+		t.Error("Expected an error for a missing image")
+	}
+	if _, err := os.Stat(missing.Destination); err == nil {
+		t.Error("Expected no file for a failed download")
+	}
+}
+
+func TestPlanVideos(t *testing.T) {
+	game := &retrometadata.GameResult{
+		Name: "Sonic the Hedgehog",
+		Metadata: retrometadata.GameMetadata{Videos: []retrometadata.Video{
+			{Type: retrometadata.VideoTrailer, URL: retrometadata.YouTubeURL("abc"), YouTubeID: "abc"},
+			{Type: retrometadata.VideoGameplay, URL: "https://example.com/video.webm?ssid=x"},
+		}},
+	}
+	ctx := context.Background()
+
+	// YouTube videos need a video downloader
+	planner, err := NewPlanner("/videos", WithTypes(TypeVideos), WithSizeEstimates(false))
+	if err != nil {
+		t.Fatalf("NewPlanner() error: %v", err)
+	}
+	plan, _ := planner.PlanGame(ctx, game, "Sonic.md")
+	if len(plan.Items) != 1 || plan.Items[0].Destination != filepath.Join("/videos", "Sonic.md.video_1.webm") {
+		t.Fatalf("PlanGame() = %+v, want the direct video only", plan.Items)
+	}
+
+	var downloaded []string
+	planner, _ = NewPlanner(t.TempDir(), WithTypes(TypeVideos), WithSizeEstimates(false), WithVideoDownloader(func(_ context.Context, item Item) error {
+		downloaded = append(downloaded, item.URL)
+		return nil
+	}))
+	plan, _ = planner.PlanGame(ctx, game, "Sonic.md")
+	if len(plan.Items) != 2 || plan.Items[0].Type != "video_1" || filepath.Ext(plan.Items[0].Destination) != ".mp4" {
+		t.Fatalf("PlanGame() = %+v", plan.Items)
+	}
+	if err := planner.DownloadPlan(ctx, plan); err != nil || len(downloaded) != 2 || downloaded[0] != "https://www.youtube.com/watch?v=abc" {
+		t.Errorf("DownloadPlan() = %v, downloaded %q", err, downloaded)
+	}
+}
+
+func TestDownloadResumable(t *testing.T) {
+	const manual = "0123456789"
+	var ranges []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ranges = append(ranges, r.Header.Get("Range"))
+		http.ServeContent(w, r, "manual.pdf", time.Time{}, strings.NewReader(manual))
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	planner, err := NewPlanner(dir, WithTypes(TypeManual, TypeMaps), WithSizeEstimates(false))
+	if err != nil {
+		t.Fatalf("NewPlanner() error: %v", err)
+	}
+	ctx := context.Background()
+
+	game := &retrometadata.GameResult{Name: "Game", Artwork: retrometadata.Artwork{
+		ManualURL: server.URL + "/manual",
+		MapURLs:   []string{server.URL + "/level1.png"},
+	}}
+	plan, _ := planner.PlanGame(ctx, game, "Game.sfc")
+	if len(plan.Items) != 2 || plan.Items[0].Destination != filepath.Join(dir, "Game.sfc.manual.pdf") || plan.Items[1].Type != "map_1" {
+		t.Fatalf("PlanGame() = %+v", plan.Items)
+	}
+
+	// An interrupted download is continued
+	item := plan.Items[0]
+	if err := os.WriteFile(item.Destination+".part", []byte(manual[:4]), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := planner.Download(ctx, item); err != nil {
+		t.Fatalf("Download() error: %v", err)
+	}
+	if data, _ := os.ReadFile(item.Destination); string(data) != manual {
+		t.Errorf("Downloaded %q, want %q", data, manual)
+	}
+	if len(ranges) != 1 || ranges[0] != "bytes=4-" {
+		t.Errorf("Range headers = %q, want the rest of the part", ranges)
+	}
+	if _, err := os.Stat(item.Destination + ".part"); err == nil {
+		t.Error("the part file was left behind")
+	}
+}
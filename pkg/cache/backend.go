@@ -0,0 +1,81 @@
+package cache
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// ErrBackendUnavailable is returned when a cache backend isn't compiled in.
+var ErrBackendUnavailable = errors.New("cache backend not available")
+
+// BackendOptions configures a cache backend created by name.
+type BackendOptions struct {
+	// TTL is the default time-to-live
+	TTL time.Duration
+	// MaxSize is the maximum number of entries, for backends that bound it
+	MaxSize int
+	// MaxBytes is the maximum approximate memory of the entries, for
+	// backends that bound it
+	MaxBytes int64
+	// ConnectionString is the connection string or path for external backends
+	ConnectionString string
+	// Options contains additional backend-specific options
+	Options map[string]any
+}
+
+// BackendFactory creates a cache backend.
+type BackendFactory func(opts BackendOptions) (Cache, error)
+
+// backends holds the registered backend factories.
+var backends = struct {
+	mu        sync.RWMutex
+	factories map[string]BackendFactory
+}{
+	factories: map[string]BackendFactory{
+		"memory": func(opts BackendOptions) (Cache, error) {
+			return NewMemoryCache(WithMaxSize(opts.MaxSize), WithMaxBytes(opts.MaxBytes), WithDefaultTTL(opts.TTL)), nil
+		},
+		"null": func(BackendOptions) (Cache, error) {
+			return NewNullCache(), nil
+		},
+	},
+}
+
+// RegisterBackend registers a cache backend factory.
+//
+// Backends with heavy dependencies (Redis, SQLite) register themselves from
+// their own packages, so programs that don't import them stay small.
+func RegisterBackend(name string, factory BackendFactory) {
+	backends.mu.Lock()
+	defer backends.mu.Unlock()
+	backends.factories[name] = factory
+}
+
+// Backends returns the names of the cache backends compiled into this build.
+func Backends() []string {
+	backends.mu.RLock()
+	defer backends.mu.RUnlock()
+
+	names := make([]string, 0, len(backends.factories))
+	for name := range backends.factories {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// NewBackend creates a cache backend by name.
+// Returns ErrBackendUnavailable if the backend isn't compiled into this build.
+func NewBackend(name string, opts BackendOptions) (Cache, error) {
+	backends.mu.RLock()
+	factory, ok := backends.factories[name]
+	backends.mu.RUnlock()
+
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrBackendUnavailable, name)
+	}
+	return factory(opts)
+}
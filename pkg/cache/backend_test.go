@@ -0,0 +1,36 @@
+package cache
+
+import (
+	"errors"
+	"slices"
+	"testing"
+)
+
+func TestNewBackend(t *testing.T) {
+	if _, ok := mustBackend(t, "memory").(*MemoryCache); !ok {
+		t.Error("Expected memory backend to be a MemoryCache")
+	}
+	if _, ok := mustBackend(t, "null").(*NullCache); !ok {
+		t.Error("Expected null backend to be a NullCache")
+	}
+
+	if _, err := NewBackend("redis", BackendOptions{}); !errors.Is(err, ErrBackendUnavailable) {
+		t.Errorf("NewBackend(redis) error = %v, expected ErrBackendUnavailable", err)
+	}
+
+	RegisterBackend("test", func(BackendOptions) (Cache, error) { return NewNullCache(), nil })
+	if !slices.Contains(Backends(), "test") {
+		t.Errorf("Backends() = %v, expected registered backend", Backends())
+	}
+	mustBackend(t, "test")
+}
+
+func mustBackend(t *testing.T, name string) Cache {
+	t.Helper()
+	c, err := NewBackend(name, BackendOptions{MaxSize: 10})
+	if err != nil {
+		t.Fatalf("NewBackend(%s) error: %v", name, err)
+	}
+	t.Cleanup(func() { c.Close() })
+	return c
+}
@@ -58,12 +58,21 @@ type Stats struct {
 	Size int `json:"size"`
 	// MaxSize is the maximum number of entries (for memory cache)
 	MaxSize int `json:"max_size,omitempty"`
+	// Bytes is the approximate memory the entries take (for memory cache)
+	Bytes int64 `json:"bytes,omitempty"`
+	// MaxBytes is the maximum approximate memory of the entries (for
+	// memory cache)
+	MaxBytes int64 `json:"max_bytes,omitempty"`
 	// ExpiredCount is the number of expired entries
 	ExpiredCount int `json:"expired_count,omitempty"`
 	// Hits is the number of cache hits
 	Hits int64 `json:"hits,omitempty"`
 	// Misses is the number of cache misses
 	Misses int64 `json:"misses,omitempty"`
+	// Evictions is the number of entries evicted to make room for others
+	Evictions int64 `json:"evictions,omitempty"`
+	// Expirations is the number of entries removed once their TTL passed
+	Expirations int64 `json:"expirations,omitempty"`
 }
 
 // NullCache is a cache that doesn't cache anything.
@@ -148,6 +157,11 @@ func (c *PrefixedCache) Clear(ctx context.Context) error {
 	return c.cache.Clear(ctx)
 }
 
+// Codec returns the underlying cache's codec, for GetTyped and SetTyped.
+func (c *PrefixedCache) Codec() Codec {
+	return codecOf(c.cache)
+}
+
 // Close closes the underlying cache.
 func (c *PrefixedCache) Close() error {
 	return c.cache.Close()
@@ -13,6 +13,8 @@ type entry struct {
 	key       string
 	value     any
 	expiresAt time.Time
+	// size is the approximate memory the entry takes, from entrySize
+	size int64
 }
 
 func (e *entry) isExpired() bool {
@@ -23,28 +25,44 @@ func (e *entry) isExpired() bool {
 }
 
 // MemoryCache is an in-memory LRU cache with TTL support.
+//
+// When it's full, by entry count or by approximate memory, the least
+// recently read or written entries are evicted first.
 type MemoryCache struct {
 	mu              sync.RWMutex
 	cache           map[string]*list.Element
 	lru             *list.List
 	maxSize         int
+	maxBytes        int64
+	bytes           int64
 	defaultTTL      time.Duration
 	cleanupInterval time.Duration
 	stopCleanup     chan struct{}
 	hits            atomic.Int64
 	misses          atomic.Int64
+	evictions       atomic.Int64
+	expirations     atomic.Int64
 }
 
 // MemoryCacheOption is a functional option for MemoryCache.
 type MemoryCacheOption func(*MemoryCache)
 
-// WithMaxSize sets the maximum number of entries.
+// WithMaxSize sets the maximum number of entries (0 = unbounded).
 func WithMaxSize(size int) MemoryCacheOption {
 	return func(c *MemoryCache) {
 		c.maxSize = size
 	}
 }
 
+// WithMaxBytes bounds the approximate memory the entries take (0 =
+// unbounded). Sizes are estimated from the keys and values, including what
+// the values reference, when they're set.
+func WithMaxBytes(maxBytes int64) MemoryCacheOption {
+	return func(c *MemoryCache) {
+		c.maxBytes = maxBytes
+	}
+}
+
 // WithDefaultTTL sets the default TTL for entries.
 func WithDefaultTTL(ttl time.Duration) MemoryCacheOption {
 	return func(c *MemoryCache) {
@@ -100,23 +118,36 @@ func (c *MemoryCache) cleanupExpired() {
 
 	for elem := c.lru.Front(); elem != nil; {
 		next := elem.Next()
-		e := elem.Value.(*entry)
-		if e.isExpired() {
-			c.lru.Remove(elem)
-			delete(c.cache, e.key)
+		if elem.Value.(*entry).isExpired() {
+			c.remove(elem)
+			c.expirations.Add(1)
 		}
 		elem = next
 	}
 }
 
-func (c *MemoryCache) evictIfNeeded() {
-	for c.lru.Len() >= c.maxSize {
-		oldest := c.lru.Front()
-		if oldest != nil {
-			e := oldest.Value.(*entry)
-			c.lru.Remove(oldest)
-			delete(c.cache, e.key)
+// remove removes an entry. Callers must hold c.mu.
+func (c *MemoryCache) remove(elem *list.Element) {
+	e := c.lru.Remove(elem).(*entry)
+	delete(c.cache, e.key)
+	c.bytes -= e.size
+}
+
+// evictIfNeeded evicts the least recently used entries until there's room
+// for an entry of the given size, other than keep. Callers must hold c.mu.
+func (c *MemoryCache) evictIfNeeded(size int64, keep *list.Element) {
+	for oldest := c.lru.Front(); oldest != nil; oldest = c.lru.Front() {
+		if oldest == keep {
+			if oldest = oldest.Next(); oldest == nil {
+				return
+			}
+		}
+		full := c.maxSize > 0 && c.lru.Len() >= c.maxSize && keep == nil
+		if !full && (c.maxBytes <= 0 || c.bytes+size <= c.maxBytes) {
+			return
 		}
+		c.remove(oldest)
+		c.evictions.Add(1)
 	}
 }
 
@@ -133,8 +164,8 @@ func (c *MemoryCache) Get(_ context.Context, key string) (any, error) {
 
 	e := elem.Value.(*entry)
 	if e.isExpired() {
-		c.lru.Remove(elem)
-		delete(c.cache, key)
+		c.remove(elem)
+		c.expirations.Add(1)
 		c.misses.Add(1)
 		return nil, nil
 	}
@@ -159,26 +190,41 @@ func (c *MemoryCache) Set(_ context.Context, key string, value any, ttl time.Dur
 		expiresAt = time.Now().Add(ttl)
 	}
 
+	size := entrySize(key, value)
+	if c.maxBytes > 0 && size > c.maxBytes {
+		// Entries that can't fit aren't kept, rather than evicting
+		// everything else for them
+		if elem, ok := c.cache[key]; ok {
+			c.remove(elem)
+		}
+		return nil
+	}
+
 	// Check if key already exists
 	if elem, ok := c.cache[key]; ok {
 		c.lru.MoveToBack(elem)
 		e := elem.Value.(*entry)
+		c.bytes += size - e.size
 		e.value = value
 		e.expiresAt = expiresAt
+		e.size = size
+		c.evictIfNeeded(0, elem)
 		return nil
 	}
 
 	// Evict if at capacity
-	c.evictIfNeeded()
+	c.evictIfNeeded(size, nil)
 
 	// Add new entry
 	e := &entry{
 		key:       key,
 		value:     value,
 		expiresAt: expiresAt,
+		size:      size,
 	}
 	elem := c.lru.PushBack(e)
 	c.cache[key] = elem
+	c.bytes += size
 
 	return nil
 }
@@ -193,8 +239,7 @@ func (c *MemoryCache) Delete(_ context.Context, key string) (bool, error) {
 		return false, nil
 	}
 
-	c.lru.Remove(elem)
-	delete(c.cache, key)
+	c.remove(elem)
 	return true, nil
 }
 
@@ -223,6 +268,7 @@ func (c *MemoryCache) Clear(_ context.Context) error {
 
 	c.cache = make(map[string]*list.Element)
 	c.lru.Init()
+	c.bytes = 0
 	return nil
 }
 
@@ -255,9 +301,13 @@ func (c *MemoryCache) Stats(_ context.Context) (Stats, error) {
 	return Stats{
 		Size:         c.lru.Len(),
 		MaxSize:      c.maxSize,
+		Bytes:        c.bytes,
+		MaxBytes:     c.maxBytes,
 		ExpiredCount: expiredCount,
 		Hits:         c.hits.Load(),
 		Misses:       c.misses.Load(),
+		Evictions:    c.evictions.Load(),
+		Expirations:  c.expirations.Load(),
 	}, nil
 }
 
@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"reflect"
+	"strings"
 	"testing"
 	"time"
 
@@ -374,3 +375,93 @@ func TestMemoryCacheLRUEviction(t *testing.T) {
 		}
 	}
 }
+
+func TestMemoryCacheMaxBytes(t *testing.T) {
+	value := strings.Repeat("x", 1000)
+	size := entrySize("key1", value)
+	cache := NewMemoryCache(WithMaxSize(0), WithMaxBytes(3*size), WithCleanupInterval(time.Hour))
+	defer cache.Close()
+
+	ctx := context.Background()
+
+	_ = cache.Set(ctx, "key1", value, 0)
+	_ = cache.Set(ctx, "key2", value, 0)
+	_ = cache.Set(ctx, "key3", value, 0)
+	_, _ = cache.Get(ctx, "key1")
+
+	// key4 only fits once key2, the least recently used, is evicted
+	_ = cache.Set(ctx, "key4", value, 0)
+	if val, _ := cache.Get(ctx, "key2"); val != nil {
+		t.Error("key2 should be evicted")
+	}
+	for _, key := range []string{"key1", "key3", "key4"} {
+		if val, _ := cache.Get(ctx, key); val == nil {
+			t.Errorf("%s should exist", key)
+		}
+	}
+
+	// Growing an entry evicts others, but not the entry
+	_ = cache.Set(ctx, "key4", value+value, 0)
+	if val, _ := cache.Get(ctx, "key4"); val == nil || cache.Size() != 2 {
+		t.Errorf("after growing key4: key4 = %v, size %d; want key4 and one other entry", val != nil, cache.Size())
+	}
+
+	// Entries larger than the bound aren't kept
+	_ = cache.Set(ctx, "huge", strings.Repeat("x", int(4*size)), 0)
+	if val, _ := cache.Get(ctx, "huge"); val != nil || cache.Size() != 2 {
+		t.Errorf("huge entry was kept, size %d", cache.Size())
+	}
+
+	stats, _ := cache.Stats(ctx)
+	if stats.Evictions != 2 || stats.MaxBytes != 3*size || stats.Bytes > stats.MaxBytes {
+		t.Errorf("Stats = %+v, want 2 evictions within %d bytes", stats, 3*size)
+	}
+
+	_ = cache.Clear(ctx)
+	if stats, _ := cache.Stats(ctx); stats.Bytes != 0 {
+		t.Errorf("Stats.Bytes after Clear = %d, want 0", stats.Bytes)
+	}
+}
+
+func TestMemoryCacheExpirations(t *testing.T) {
+	cache := NewMemoryCache(WithCleanupInterval(time.Hour))
+	defer cache.Close()
+
+	ctx := context.Background()
+	_ = cache.Set(ctx, "key1", "value1", time.Millisecond)
+	_ = cache.Set(ctx, "key2", "value2", time.Millisecond)
+	time.Sleep(5 * time.Millisecond)
+
+	_, _ = cache.Get(ctx, "key1")
+	cache.cleanupExpired()
+
+	stats, _ := cache.Stats(ctx)
+	if stats.Expirations != 2 || stats.Evictions != 0 || stats.Bytes != 0 {
+		t.Errorf("Stats = %+v, want 2 expirations and no entries", stats)
+	}
+}
+
+func TestEntrySize(t *testing.T) {
+	type game struct {
+		Name   string
+		Genres []string
+		IDs    map[string]int
+	}
+	small := entrySize("k", &game{Name: "Tetris"})
+	large := entrySize("k", &game{
+		Name:   strings.Repeat("x", 1000),
+		Genres: []string{"Puzzle", "Arcade"},
+		IDs:    map[string]int{"igdb": 1, "mobygames": 2},
+	})
+	if large-small < 1000 {
+		t.Errorf("entrySize() grew by %d for 1000 more bytes of strings", large-small)
+	}
+
+	// Cycles end at the depth limit
+	type node struct{ next *node }
+	n := &node{}
+	n.next = n
+	if size := entrySize("k", n); size <= 0 {
+		t.Errorf("entrySize() of a cycle = %d", size)
+	}
+}
@@ -0,0 +1,83 @@
+package cache
+
+import "reflect"
+
+// Approximate sizes of the parts of Go values, on 64-bit platforms.
+const (
+	// entryOverhead is the size of an entry, its list element and its map
+	// slot
+	entryOverhead = 128
+	// headerSize is the size of a string, slice or interface header
+	headerSize = 16
+	// mapEntryOverhead is the bookkeeping of a map entry
+	mapEntryOverhead = 16
+	// maxSizeDepth bounds how deep sizeOf follows pointers, which also stops
+	// it at cycles
+	maxSizeDepth = 32
+)
+
+// entrySize returns the approximate memory a cache entry takes: the key,
+// the value and everything it references. It's meant for bounding the
+// cache's memory, not for exact accounting; values shared between entries
+// are counted for each.
+func entrySize(key string, value any) int64 {
+	size := entryOverhead + int64(len(key)) + headerSize
+	if v := reflect.ValueOf(value); v.IsValid() {
+		size += int64(v.Type().Size()) + sizeOf(v, 0)
+	}
+	return size
+}
+
+// sizeOf returns the approximate memory a value references, beyond its own
+// size as a field or element.
+func sizeOf(v reflect.Value, depth int) int64 {
+	if !v.IsValid() || depth > maxSizeDepth {
+		return 0
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		return int64(v.Len())
+	case reflect.Pointer, reflect.Interface:
+		if v.IsNil() {
+			return 0
+		}
+		elem := v.Elem()
+		return int64(elem.Type().Size()) + sizeOf(elem, depth+1)
+	case reflect.Slice:
+		if v.IsNil() {
+			return 0
+		}
+		size := int64(v.Cap()) * int64(v.Type().Elem().Size())
+		for i := range v.Len() {
+			size += sizeOf(v.Index(i), depth+1)
+		}
+		return size
+	case reflect.Array:
+		var size int64
+		for i := range v.Len() {
+			size += sizeOf(v.Index(i), depth+1)
+		}
+		return size
+	case reflect.Map:
+		if v.IsNil() {
+			return 0
+		}
+		perEntry := int64(v.Type().Key().Size()+v.Type().Elem().Size()) + mapEntryOverhead
+		size := int64(v.Len()) * perEntry
+		for iter := v.MapRange(); iter.Next(); {
+			size += sizeOf(iter.Key(), depth+1) + sizeOf(iter.Value(), depth+1)
+		}
+		return size
+	case reflect.Struct:
+		var size int64
+		for i := range v.NumField() {
+			size += sizeOf(v.Field(i), depth+1)
+		}
+		return size
+	default:
+		// Numbers and booleans are the size of their field or element;
+		// channels and functions aren't followed
+		return 0
+	}
+}
@@ -0,0 +1,119 @@
+package cache
+
+import (
+	"bytes"
+	"context"
+	"encoding/gob"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// Codec encodes values for backends that store bytes.
+type Codec interface {
+	// Marshal encodes a value.
+	Marshal(v any) ([]byte, error)
+	// Unmarshal decodes data into the value v points to.
+	Unmarshal(data []byte, v any) error
+}
+
+// Codecs for Serializer backends.
+var (
+	// JSONCodec encodes values as JSON, readable by other languages
+	JSONCodec Codec = jsonCodec{}
+	// GobCodec encodes values with encoding/gob, which keeps Go types that
+	// JSON doesn't, like interface fields holding registered types
+	GobCodec Codec = gobCodec{}
+)
+
+type jsonCodec struct{}
+
+func (jsonCodec) Marshal(v any) ([]byte, error)      { return json.Marshal(v) }
+func (jsonCodec) Unmarshal(data []byte, v any) error { return json.Unmarshal(data, v) }
+
+type gobCodec struct{}
+
+func (gobCodec) Marshal(v any) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(v); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (gobCodec) Unmarshal(data []byte, v any) error {
+	return gob.NewDecoder(bytes.NewReader(data)).Decode(v)
+}
+
+// Serializer is implemented by backends that store values as bytes, like
+// Redis or SQLite, rather than as Go values. SetTyped encodes values with
+// their codec before storing them, and GetTyped decodes them.
+type Serializer interface {
+	// Codec returns the codec values are stored with, or nil if the
+	// backend stores Go values.
+	Codec() Codec
+}
+
+// codecOf returns the codec of a backend, or nil for backends storing Go
+// values.
+func codecOf(c Cache) Codec {
+	if s, ok := c.(Serializer); ok {
+		return s.Codec()
+	}
+	return nil
+}
+
+// GetTyped retrieves a value of type T from the cache. Values stored as Go
+// values are returned as they are; values stored encoded, by a Serializer
+// backend or as JSON, are decoded into a T. It returns false if the key
+// isn't found, and an error if the value can't be converted to a T, which
+// callers can treat as a miss.
+func GetTyped[T any](ctx context.Context, c Cache, key string) (T, bool, error) {
+	var value T
+	cached, err := c.Get(ctx, key)
+	if err != nil || cached == nil {
+		return value, false, err
+	}
+	codec := codecOf(c)
+	var data []byte
+	switch v := cached.(type) {
+	case []byte:
+		data = v
+	case string:
+		data = []byte(v)
+	}
+	if typed, ok := cached.(T); ok && (codec == nil || data == nil) {
+		return typed, true, nil
+	}
+
+	switch {
+	case data == nil:
+		// Generic values, like the maps a JSON backend decodes to, are
+		// converted through JSON
+		codec = JSONCodec
+		if data, err = json.Marshal(cached); err != nil {
+			return value, false, fmt.Errorf("converting cached %s: %w", key, err)
+		}
+	case codec == nil:
+		codec = JSONCodec
+	}
+	if err := codec.Unmarshal(data, &value); err != nil {
+		return value, false, fmt.Errorf("decoding cached %s: %w", key, err)
+	}
+	return value, true, nil
+}
+
+// SetTyped stores a value of type T in the cache, encoded with the
+// backend's codec for Serializer backends. If ttl is 0, the default TTL is
+// used.
+func SetTyped[T any](ctx context.Context, c Cache, key string, value T, ttl time.Duration) error {
+	codec := codecOf(c)
+	if codec == nil {
+		return c.Set(ctx, key, value, ttl)
+	}
+	data, err := codec.Marshal(value)
+	if err != nil {
+		return fmt.Errorf("encoding %s for the cache: %w", key, err)
+	}
+	return c.Set(ctx, key, data, ttl)
+}
@@ -0,0 +1,114 @@
+package cache
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+// bytesCache is a MemoryCache storing values encoded with a codec, like a
+// persistent backend would.
+type bytesCache struct {
+	*MemoryCache
+	codec Codec
+}
+
+func (c *bytesCache) Codec() Codec {
+	return c.codec
+}
+
+type typedGame struct {
+	ID       int               `json:"id"`
+	Name     string            `json:"name"`
+	Genres   []string          `json:"genres"`
+	Rating   *float64          `json:"rating"`
+	Provider map[string]string `json:"provider"`
+}
+
+func TestTypedRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	rating := 87.5
+	game := typedGame{
+		ID:       1020,
+		Name:     "Chrono Trigger",
+		Genres:   []string{"RPG"},
+		Rating:   &rating,
+		Provider: map[string]string{"igdb": "1020"},
+	}
+
+	backends := map[string]Cache{
+		"memory": NewMemoryCache(),
+		"json":   &bytesCache{MemoryCache: NewMemoryCache(), codec: JSONCodec},
+		"gob":    &bytesCache{MemoryCache: NewMemoryCache(), codec: GobCodec},
+		"prefix": NewPrefixedCache(&bytesCache{MemoryCache: NewMemoryCache(), codec: GobCodec}, "p"),
+	}
+	for name, c := range backends {
+		t.Run(name, func(t *testing.T) {
+			if err := SetTyped(ctx, c, "game", []typedGame{game}, 0); err != nil {
+				t.Fatalf("SetTyped() error = %v", err)
+			}
+			if name != "memory" {
+				raw, _ := c.Get(ctx, "game")
+				if _, ok := raw.([]byte); !ok {
+					t.Errorf("stored value = %T, want []byte", raw)
+				}
+			}
+
+			got, ok, err := GetTyped[[]typedGame](ctx, c, "game")
+			if err != nil || !ok {
+				t.Fatalf("GetTyped() = %v, %v", ok, err)
+			}
+			if !reflect.DeepEqual(got, []typedGame{game}) {
+				t.Errorf("GetTyped() = %+v, want %+v", got, []typedGame{game})
+			}
+
+			if _, ok, err := GetTyped[[]typedGame](ctx, c, "missing"); ok || err != nil {
+				t.Errorf("GetTyped(missing) = %v, %v, want a miss", ok, err)
+			}
+		})
+	}
+}
+
+func TestGetTypedConvertsGenericValues(t *testing.T) {
+	ctx := context.Background()
+	c := NewMemoryCache()
+
+	// Values a JSON backend decoded without knowing their type
+	_ = c.Set(ctx, "game", map[string]any{"id": float64(7), "name": "Super Metroid", "genres": []any{"Action"}}, 0)
+	got, ok, err := GetTyped[typedGame](ctx, c, "game")
+	if err != nil || !ok {
+		t.Fatalf("GetTyped() = %v, %v", ok, err)
+	}
+	if got.ID != 7 || got.Name != "Super Metroid" || !reflect.DeepEqual(got.Genres, []string{"Action"}) {
+		t.Errorf("GetTyped() = %+v", got)
+	}
+
+	// JSON stored in a backend without a codec
+	_ = c.Set(ctx, "json", `{"id": 8, "name": "Metroid"}`, 0)
+	if got, ok, err := GetTyped[typedGame](ctx, c, "json"); err != nil || !ok || got.Name != "Metroid" {
+		t.Errorf("GetTyped(json) = %+v, %v, %v", got, ok, err)
+	}
+
+	// Strings are returned as they are without a codec
+	_ = c.Set(ctx, "token", "abc", 0)
+	if got, ok, err := GetTyped[string](ctx, c, "token"); err != nil || !ok || got != "abc" {
+		t.Errorf("GetTyped(token) = %q, %v, %v", got, ok, err)
+	}
+
+	_ = c.Set(ctx, "bad", 42, 0)
+	if _, ok, err := GetTyped[typedGame](ctx, c, "bad"); ok || err == nil {
+		t.Errorf("GetTyped(bad) = %v, %v, want an error", ok, err)
+	}
+}
+
+func TestSetTypedStringWithCodec(t *testing.T) {
+	ctx := context.Background()
+	c := &bytesCache{MemoryCache: NewMemoryCache(), codec: JSONCodec}
+
+	if err := SetTyped(ctx, c, "token", "abc", 0); err != nil {
+		t.Fatalf("SetTyped() error = %v", err)
+	}
+	if got, ok, err := GetTyped[string](ctx, c, "token"); err != nil || !ok || got != "abc" {
+		t.Errorf("GetTyped() = %q, %v, %v", got, ok, err)
+	}
+}
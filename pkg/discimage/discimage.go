@@ -0,0 +1,126 @@
+// Package discimage reads the serials and titles disc images record about
+// themselves, for identifying games whose filenames don't carry a serial.
+//
+// Supported images:
+//   - PlayStation and PS2: the boot executable named in SYSTEM.CNF, from
+//     .iso images and .bin images with 2352 byte sectors (directly or
+//     through a .cue sheet)
+//   - PSP: the disc ID and title in PSP_GAME/PARAM.SFO, from .iso images
+//   - GameCube and Wii: the game ID and title in the disc header, from
+//     .iso/.gcm images and .rvz/.wia images
+//
+// CHD images are compressed with codecs outside the standard library, and
+// aren't supported; Read returns ErrUnsupported for them.
+package discimage
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/josegonzalez/retro-metadata/pkg/platform"
+)
+
+var (
+	// ErrUnsupported is returned for files that aren't disc images of a
+	// supported format.
+	ErrUnsupported = errors.New("unsupported disc image")
+
+	// ErrNoSerial is returned for disc images that don't record a serial.
+	ErrNoSerial = errors.New("disc image has no serial")
+)
+
+// Info is what a disc image records about itself.
+type Info struct {
+	// Platform is the platform the disc is for
+	Platform platform.Slug `json:"platform"`
+	// Serial is the disc's serial, in the form serials.Extract returns
+	Serial string `json:"serial"`
+	// Title is the game title, for images that record one
+	Title string `json:"title,omitempty"`
+}
+
+// extensions are the file extensions of the images Read supports.
+var extensions = map[string]bool{
+	".iso": true, ".bin": true, ".img": true, ".cue": true,
+	".gcm": true, ".rvz": true, ".wia": true,
+}
+
+// IsDiscImage reports whether path has the extension of an image Read
+// supports.
+func IsDiscImage(path string) bool {
+	return extensions[strings.ToLower(filepath.Ext(path))]
+}
+
+// Read returns what the disc image at path records about itself. .cue
+// sheets are read through their first data track.
+func Read(path string) (*Info, error) {
+	if !IsDiscImage(path) {
+		return nil, ErrUnsupported
+	}
+	if strings.EqualFold(filepath.Ext(path), ".cue") {
+		track, err := cueDataTrack(path)
+		if err != nil {
+			return nil, err
+		}
+		path = track
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return ReadFrom(file)
+}
+
+// ReadFrom returns what a disc image records about itself, detecting its
+// format from its contents.
+func ReadFrom(r io.ReaderAt) (*Info, error) {
+	if info, err := readNintendo(r); !errors.Is(err, ErrUnsupported) {
+		return info, err
+	}
+	return readISO9660(r)
+}
+
+// cueFileRegex matches FILE lines in cue sheets: FILE "Game (Track 1).bin" BINARY
+var cueFileRegex = regexp.MustCompile(`(?i)^\s*FILE\s+(?:"([^"]+)"|(\S+))`)
+
+// cueTrackRegex matches TRACK lines in cue sheets: TRACK 01 MODE2/2352
+var cueTrackRegex = regexp.MustCompile(`(?i)^\s*TRACK\s+\d+\s+(\S+)`)
+
+// cueDataTrack returns the path of the file holding the first data track of
+// a cue sheet.
+func cueDataTrack(path string) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	var current string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimPrefix(scanner.Text(), "\ufeff")
+		if match := cueFileRegex.FindStringSubmatch(line); match != nil {
+			current = match[1] + match[2]
+			continue
+		}
+		match := cueTrackRegex.FindStringSubmatch(line)
+		if match != nil && current != "" && strings.HasPrefix(strings.ToUpper(match[1]), "MODE") {
+			if filepath.IsAbs(current) {
+				return current, nil
+			}
+			return filepath.Join(filepath.Dir(path), current), nil
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return "", err
+	}
+	return "", fmt.Errorf("%w: %s has no data track", ErrUnsupported, path)
+}
@@ -0,0 +1,184 @@
+package discimage
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/josegonzalez/retro-metadata/pkg/platform"
+)
+
+// isoFile is a file, or a directory if files is set, in a test image.
+type isoFile struct {
+	name  string
+	data  []byte
+	files []isoFile
+}
+
+// buildISO builds an ISO 9660 image with cooked sectors holding files.
+func buildISO(files []isoFile) []byte {
+	sectors := make([][]byte, pvdSector+1)
+	for i := range sectors {
+		sectors[i] = make([]byte, sectorSize)
+	}
+
+	record := func(name string, sector, size int, dir bool) []byte {
+		rec := make([]byte, 33+len(name)+(len(name)+1)%2)
+		rec[0] = byte(len(rec))
+		binary.LittleEndian.PutUint32(rec[2:], uint32(sector))
+		binary.LittleEndian.PutUint32(rec[10:], uint32(size))
+		if dir {
+			rec[25] = 2
+		}
+		rec[32] = byte(len(name))
+		copy(rec[33:], name)
+		return rec
+	}
+
+	var build func(name string, files []isoFile) []byte
+	build = func(name string, files []isoFile) []byte {
+		dirSector := len(sectors)
+		sectors = append(sectors, make([]byte, sectorSize))
+		listing := append(record("\x00", dirSector, sectorSize, true), record("\x01", dirSector, sectorSize, true)...)
+		for _, file := range files {
+			if file.files != nil {
+				listing = append(listing, build(file.name, file.files)...)
+				continue
+			}
+			data := make([]byte, sectorSize)
+			copy(data, file.data)
+			listing = append(listing, record(file.name+";1", len(sectors), len(file.data), false)...)
+			sectors = append(sectors, data)
+		}
+		copy(sectors[dirSector], listing)
+		return record(name, dirSector, sectorSize, true)
+	}
+
+	root := build("\x00", files)
+	pvd := sectors[pvdSector]
+	pvd[0] = 1
+	copy(pvd[1:], "CD001")
+	copy(pvd[rootRecordOffset:], root)
+	return bytes.Join(sectors, nil)
+}
+
+// rawMode2 converts a cooked image to raw mode 2 sectors, as in .bin images.
+func rawMode2(image []byte) []byte {
+	var raw []byte
+	for i := 0; i < len(image); i += sectorSize {
+		sector := make([]byte, rawSectorSize)
+		copy(sector, cdSync)
+		sector[15] = 2
+		copy(sector[24:], image[i:i+sectorSize])
+		raw = append(raw, sector...)
+	}
+	return raw
+}
+
+// buildParamSFO builds a PARAM.SFO holding string values.
+func buildParamSFO(values [][2]string) []byte {
+	header := make([]byte, 20+16*len(values))
+	var keys, data []byte
+	for i, kv := range values {
+		entry := header[20+16*i:]
+		binary.LittleEndian.PutUint16(entry, uint16(len(keys)))
+		binary.LittleEndian.PutUint32(entry[4:], uint32(len(kv[1])+1))
+		binary.LittleEndian.PutUint32(entry[12:], uint32(len(data)))
+		keys = append(append(keys, kv[0]...), 0)
+		data = append(append(data, kv[1]...), 0)
+	}
+	copy(header, "\x00PSF")
+	binary.LittleEndian.PutUint32(header[8:], uint32(len(header)))
+	binary.LittleEndian.PutUint32(header[12:], uint32(len(header)+len(keys)))
+	binary.LittleEndian.PutUint32(header[16:], uint32(len(values)))
+	return append(append(header, keys...), data...)
+}
+
+// buildDiscHeader builds a GameCube or Wii disc header.
+func buildDiscHeader(id, title string, wii bool) []byte {
+	header := make([]byte, 0x440)
+	copy(header, id)
+	if wii {
+		binary.BigEndian.PutUint32(header[wiiMagicOffset:], wiiMagic)
+	} else {
+		binary.BigEndian.PutUint32(header[gameCubeMagicOffset:], gameCubeMagic)
+	}
+	copy(header[titleOffset:], title)
+	return header
+}
+
+func TestReadFrom(t *testing.T) {
+	ps2 := buildISO([]isoFile{
+		{name: "SYSTEM.CNF", data: []byte("BOOT2 = cdrom0:\\SLUS_203.12;1\r\nVER = 1.00\r\n")},
+	})
+	psx := buildISO([]isoFile{
+		{name: "SYSTEM.CNF", data: []byte("BOOT = cdrom:\\SCUS_944.55;1\r\n")},
+	})
+	psp := buildISO([]isoFile{
+		{name: "PSP_GAME", files: []isoFile{
+			{name: "PARAM.SFO", data: buildParamSFO([][2]string{{"DISC_ID", "ULUS10041"}, {"TITLE", "Lumines"}})},
+		}},
+	})
+	rvz := append([]byte("RVZ\x01"), make([]byte, wiaDiscHeaderOffset-4)...)
+	rvz = append(rvz, buildDiscHeader("RMGE01", "Super Mario Galaxy", true)...)
+
+	tests := []struct {
+		name    string
+		image   []byte
+		want    *Info
+		wantErr error
+	}{
+		{"ps2 iso", ps2, &Info{Platform: platform.SlugPS2, Serial: "SLUS-20312"}, nil},
+		{"psx raw bin", rawMode2(psx), &Info{Platform: platform.SlugPSX, Serial: "SCUS-94455"}, nil},
+		{"psp iso", psp, &Info{Platform: platform.SlugPSP, Serial: "ULUS-10041", Title: "Lumines"}, nil},
+		{"gamecube", buildDiscHeader("GZLE01", "The Legend of Zelda: The Wind Waker", false), &Info{Platform: platform.SlugNGC, Serial: "GZLE01", Title: "The Legend of Zelda: The Wind Waker"}, nil},
+		{"rvz", rvz, &Info{Platform: platform.SlugWii, Serial: "RMGE01", Title: "Super Mario Galaxy"}, nil},
+		{"iso without serial", buildISO([]isoFile{{name: "README.TXT", data: []byte("hi")}}), nil, ErrNoSerial},
+		{"not an image", make([]byte, 64*1024), nil, ErrUnsupported},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ReadFrom(bytes.NewReader(tt.image))
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("ReadFrom() error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.want == nil {
+				return
+			}
+			if *got != *tt.want {
+				t.Errorf("ReadFrom() = %+v, want %+v", *got, *tt.want)
+			}
+		})
+	}
+}
+
+func TestReadCue(t *testing.T) {
+	dir := t.TempDir()
+	image := rawMode2(buildISO([]isoFile{
+		{name: "SYSTEM.CNF", data: []byte("BOOT = cdrom:\\SLUS_007.00;1\r\n")},
+	}))
+	if err := os.WriteFile(filepath.Join(dir, "Game (Track 1).bin"), image, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	cue := "FILE \"Game (Track 1).bin\" BINARY\n  TRACK 01 MODE2/2352\n    INDEX 01 00:00:00\n"
+	cuePath := filepath.Join(dir, "Game.cue")
+	if err := os.WriteFile(cuePath, []byte(cue), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	info, err := Read(cuePath)
+	if err != nil {
+		t.Fatalf("Read() error = %v", err)
+	}
+	if info.Serial != "SLUS-00700" || info.Platform != platform.SlugPSX {
+		t.Errorf("Read() = %+v, want SLUS-00700 for psx", *info)
+	}
+
+	if _, err := Read(filepath.Join(dir, "Game.chd")); !errors.Is(err, ErrUnsupported) {
+		t.Errorf("Read(.chd) error = %v, want ErrUnsupported", err)
+	}
+}
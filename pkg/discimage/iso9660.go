@@ -0,0 +1,268 @@
+package discimage
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"io"
+	"strings"
+
+	"github.com/josegonzalez/retro-metadata/pkg/platform"
+	"github.com/josegonzalez/retro-metadata/pkg/serials"
+)
+
+const (
+	// sectorSize is the size of an ISO 9660 logical sector
+	sectorSize = 2048
+	// rawSectorSize is the size of a raw CD sector, as in .bin images
+	rawSectorSize = 2352
+	// pvdSector is the sector of the primary volume descriptor
+	pvdSector = 16
+	// rootRecordOffset is the offset of the root directory record in the
+	// primary volume descriptor
+	rootRecordOffset = 156
+	// maxFileSize caps the directories and files read from an image
+	maxFileSize = 1 << 20
+)
+
+// cdSync is the sync pattern at the start of every raw CD sector.
+var cdSync = []byte{0x00, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0x00}
+
+// volume reads the logical sectors of an ISO 9660 image, with cooked 2048
+// byte sectors or raw 2352 byte ones.
+type volume struct {
+	r io.ReaderAt
+	// stride is the size of a sector in the image
+	stride int64
+	// offset is the offset of the user data in each sector
+	offset int64
+}
+
+// dirEntry is a file or directory in an ISO 9660 directory.
+type dirEntry struct {
+	name   string
+	sector uint32
+	size   uint32
+	dir    bool
+}
+
+// openVolume detects the sector layout of an ISO 9660 image from its
+// primary volume descriptor. It returns ErrUnsupported for other images.
+func openVolume(r io.ReaderAt) (*volume, error) {
+	candidates := []*volume{{r: r, stride: sectorSize}}
+	header := make([]byte, 16)
+	if _, err := r.ReadAt(header, pvdSector*rawSectorSize); err == nil && bytes.Equal(header[:12], cdSync) {
+		// Mode 1 data follows the 16 byte header; mode 2 form 1 data also
+		// follows an 8 byte subheader
+		offset := int64(16)
+		if header[15] == 2 {
+			offset = 24
+		}
+		candidates = append(candidates, &volume{r: r, stride: rawSectorSize, offset: offset})
+	}
+
+	for _, v := range candidates {
+		pvd, err := v.read(pvdSector, sectorSize)
+		if err == nil && pvd[0] == 1 && string(pvd[1:6]) == "CD001" {
+			return v, nil
+		}
+	}
+	return nil, ErrUnsupported
+}
+
+// read returns size bytes starting at a logical sector.
+func (v *volume) read(sector uint32, size int64) ([]byte, error) {
+	data := make([]byte, 0, size)
+	buf := make([]byte, sectorSize)
+	for int64(len(data)) < size {
+		n, err := v.r.ReadAt(buf, int64(sector)*v.stride+v.offset)
+		if n < len(buf) {
+			if err == nil || err == io.EOF {
+				err = io.ErrUnexpectedEOF
+			}
+			return nil, err
+		}
+		data = append(data, buf[:min(int64(n), size-int64(len(data)))]...)
+		sector++
+	}
+	return data, nil
+}
+
+// root returns the root directory.
+func (v *volume) root() ([]dirEntry, error) {
+	pvd, err := v.read(pvdSector, sectorSize)
+	if err != nil {
+		return nil, err
+	}
+	root, ok := parseRecord(pvd[rootRecordOffset:])
+	if !ok {
+		return nil, ErrUnsupported
+	}
+	return v.readDir(root)
+}
+
+// readDir returns the entries of a directory.
+func (v *volume) readDir(dir dirEntry) ([]dirEntry, error) {
+	data, err := v.read(dir.sector, min(int64(dir.size), maxFileSize))
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []dirEntry
+	for i := 0; i < len(data); {
+		length := int(data[i])
+		if length == 0 {
+			// Records don't cross sectors; the rest of this one is padding
+			i = (i/sectorSize + 1) * sectorSize
+			continue
+		}
+		if i+length > len(data) {
+			break
+		}
+		if entry, ok := parseRecord(data[i : i+length]); ok && entry.name != "" {
+			entries = append(entries, entry)
+		}
+		i += length
+	}
+	return entries, nil
+}
+
+// readFile returns the contents of the file at a path of names, matched
+// case-insensitively, from the root directory.
+func (v *volume) readFile(entries []dirEntry, path ...string) ([]byte, bool) {
+	for i, name := range path {
+		var found *dirEntry
+		for j := range entries {
+			if strings.EqualFold(entries[j].name, name) {
+				found = &entries[j]
+				break
+			}
+		}
+		if found == nil {
+			return nil, false
+		}
+		if i == len(path)-1 {
+			data, err := v.read(found.sector, min(int64(found.size), maxFileSize))
+			return data, err == nil
+		}
+		if !found.dir {
+			return nil, false
+		}
+		var err error
+		if entries, err = v.readDir(*found); err != nil {
+			return nil, false
+		}
+	}
+	return nil, false
+}
+
+// parseRecord parses a directory record. The current and parent directory
+// records get an empty name.
+func parseRecord(record []byte) (dirEntry, bool) {
+	if len(record) < 34 || int(record[0]) > len(record) {
+		return dirEntry{}, false
+	}
+	nameLength := int(record[32])
+	if 33+nameLength > int(record[0]) {
+		return dirEntry{}, false
+	}
+	name := string(record[33 : 33+nameLength])
+	if name == "\x00" || name == "\x01" {
+		name = ""
+	}
+	if i := strings.IndexByte(name, ';'); i >= 0 {
+		name = name[:i]
+	}
+	return dirEntry{
+		name:   name,
+		sector: binary.LittleEndian.Uint32(record[2:6]),
+		size:   binary.LittleEndian.Uint32(record[10:14]),
+		dir:    record[25]&2 != 0,
+	}, true
+}
+
+// readISO9660 reads the serial of a PlayStation, PS2 or PSP image.
+func readISO9660(r io.ReaderAt) (*Info, error) {
+	v, err := openVolume(r)
+	if err != nil {
+		return nil, err
+	}
+	root, err := v.root()
+	if err != nil {
+		return nil, err
+	}
+
+	if data, ok := v.readFile(root, "SYSTEM.CNF"); ok {
+		return parseSystemCNF(data)
+	}
+	if data, ok := v.readFile(root, "PSP_GAME", "PARAM.SFO"); ok {
+		return parseParamSFO(data)
+	}
+	if data, ok := v.readFile(root, "UMD_DATA.BIN"); ok {
+		id, _, _ := strings.Cut(string(data), "|")
+		if serial := serials.Extract(id, platform.SlugPSP); serial != "" {
+			return &Info{Platform: platform.SlugPSP, Serial: serial}, nil
+		}
+	}
+	return nil, ErrNoSerial
+}
+
+// parseSystemCNF reads the serial from the boot executable named in a
+// PlayStation or PS2 SYSTEM.CNF, like BOOT2 = cdrom0:\SLUS_203.12;1.
+func parseSystemCNF(data []byte) (*Info, error) {
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		key, value, ok := strings.Cut(scanner.Text(), "=")
+		if !ok {
+			continue
+		}
+		slug := platform.SlugPSX
+		switch strings.ToUpper(strings.TrimSpace(key)) {
+		case "BOOT":
+		case "BOOT2":
+			slug = platform.SlugPS2
+		default:
+			continue
+		}
+		if serial := serials.Extract(value, slug); serial != "" {
+			return &Info{Platform: slug, Serial: serial}, nil
+		}
+	}
+	return nil, ErrNoSerial
+}
+
+// parseParamSFO reads the disc ID and title from a PSP PARAM.SFO.
+func parseParamSFO(data []byte) (*Info, error) {
+	if len(data) < 20 || string(data[:4]) != "\x00PSF" {
+		return nil, ErrNoSerial
+	}
+	keyTable := binary.LittleEndian.Uint32(data[8:12])
+	dataTable := binary.LittleEndian.Uint32(data[12:16])
+	count := binary.LittleEndian.Uint32(data[16:20])
+
+	values := make(map[string]string)
+	for i := uint32(0); i < count; i++ {
+		entry := 20 + int(i)*16
+		if entry+16 > len(data) {
+			break
+		}
+		keyStart := int(keyTable) + int(binary.LittleEndian.Uint16(data[entry:]))
+		valueStart := int(dataTable) + int(binary.LittleEndian.Uint32(data[entry+12:]))
+		valueEnd := valueStart + int(binary.LittleEndian.Uint32(data[entry+4:]))
+		if keyStart >= len(data) || valueStart > valueEnd || valueEnd > len(data) {
+			continue
+		}
+		values[cString(data[keyStart:])] = cString(data[valueStart:valueEnd])
+	}
+
+	// DISC_ID is the serial without its dash, like ULUS10041
+	id := values["DISC_ID"]
+	if len(id) > 4 {
+		id = id[:4] + "-" + id[4:]
+	}
+	serial := serials.Extract(id, platform.SlugPSP)
+	if serial == "" {
+		return nil, ErrNoSerial
+	}
+	return &Info{Platform: platform.SlugPSP, Serial: serial, Title: values["TITLE"]}, nil
+}
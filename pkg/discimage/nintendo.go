@@ -0,0 +1,81 @@
+package discimage
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"strings"
+
+	"github.com/josegonzalez/retro-metadata/pkg/platform"
+)
+
+const (
+	// wiiMagic is at wiiMagicOffset in Wii disc headers
+	wiiMagic       = 0x5D1C9EA3
+	wiiMagicOffset = 0x18
+	// gameCubeMagic is at gameCubeMagicOffset in GameCube disc headers
+	gameCubeMagic       = 0xC2339F3D
+	gameCubeMagicOffset = 0x1C
+
+	// discHeaderSize is the part of the disc header read: the game ID, the
+	// magic numbers and the title
+	discHeaderSize = 0x80
+	// titleOffset is the offset of the title in the disc header
+	titleOffset = 0x20
+
+	// wiaDiscHeaderOffset is the offset of the copy of the disc header in
+	// WIA and RVZ files, after the 0x48 byte file header and the first 16
+	// bytes of the disc struct
+	wiaDiscHeaderOffset = 0x58
+)
+
+// wiaMagics are the signatures of WIA and RVZ files.
+var wiaMagics = [][]byte{[]byte("WIA\x01"), []byte("RVZ\x01")}
+
+// readNintendo reads the disc header of a GameCube or Wii image, plain or
+// in a WIA or RVZ file. It returns ErrUnsupported for other images.
+func readNintendo(r io.ReaderAt) (*Info, error) {
+	magic := make([]byte, 4)
+	if _, err := r.ReadAt(magic, 0); err != nil {
+		return nil, ErrUnsupported
+	}
+	var offset int64
+	for _, wia := range wiaMagics {
+		if bytes.Equal(magic, wia) {
+			offset = wiaDiscHeaderOffset
+		}
+	}
+
+	header := make([]byte, discHeaderSize)
+	if _, err := r.ReadAt(header, offset); err != nil {
+		return nil, ErrUnsupported
+	}
+
+	var slug platform.Slug
+	switch {
+	case binary.BigEndian.Uint32(header[wiiMagicOffset:]) == wiiMagic:
+		slug = platform.SlugWii
+	case binary.BigEndian.Uint32(header[gameCubeMagicOffset:]) == gameCubeMagic:
+		slug = platform.SlugNGC
+	default:
+		return nil, ErrUnsupported
+	}
+
+	id := string(header[:6])
+	if strings.Trim(id, "ABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789") != "" {
+		return nil, ErrNoSerial
+	}
+	return &Info{
+		Platform: slug,
+		Serial:   id,
+		Title:    cString(header[titleOffset:]),
+	}, nil
+}
+
+// cString returns the NUL terminated string at the start of b, trimmed.
+func cString(b []byte) string {
+	if i := bytes.IndexByte(b, 0); i >= 0 {
+		b = b[:i]
+	}
+	return strings.TrimSpace(string(b))
+}
@@ -0,0 +1,144 @@
+// Package events publishes notifications from long-running operations, such
+// as a game being identified or a scan finishing, so downstream systems can
+// react without polling.
+//
+// Handlers subscribe to a Bus; Webhook is a handler that delivers events to
+// an HTTP endpoint.
+package events
+
+import (
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/josegonzalez/retro-metadata/pkg/retrometadata"
+)
+
+// Type is the kind of an event.
+type Type string
+
+// Event types.
+const (
+	// GameIdentified is published when a ROM is identified. Data is a Game.
+	GameIdentified Type = "game.identified"
+	// ScanFinished is published when a scan of a ROM directory finishes.
+	// Data is the operation's report.
+	ScanFinished Type = "scan.finished"
+	// ProviderFailing is published when a provider fails several times in a
+	// row. Data is a ProviderFailure.
+	ProviderFailing Type = "provider.failing"
+	// ProviderChanged is published when a provider is enabled, disabled or
+	// reconfigured while running. Data is a retrometadata.ProviderChange.
+	ProviderChanged Type = "provider.changed"
+)
+
+// Event is a single notification.
+type Event struct {
+	// Type is the kind of event
+	Type Type `json:"type"`
+	// Time is when the event happened
+	Time time.Time `json:"time"`
+	// Data describes the event; its type depends on Type
+	Data any `json:"data,omitempty"`
+}
+
+// New creates an event of a type that happened now.
+func New(eventType Type, data any) Event {
+	return Event{Type: eventType, Time: time.Now().UTC(), Data: data}
+}
+
+// Handler receives events. Handlers are called synchronously by Publish, so
+// slow work such as network delivery should be queued.
+type Handler func(Event)
+
+// subscription is a handler and the event types it receives.
+type subscription struct {
+	id      int
+	handler Handler
+	types   []Type
+}
+
+// Bus delivers events to subscribed handlers. A nil *Bus discards events, so
+// publishers don't need to check whether events are enabled.
+type Bus struct {
+	mu     sync.RWMutex
+	subs   []subscription
+	nextID int
+}
+
+// NewBus creates an event bus.
+func NewBus() *Bus {
+	return &Bus{}
+}
+
+// Subscribe registers a handler for events of the given types, or of every
+// type if none are given. It returns a function that removes the handler.
+func (b *Bus) Subscribe(handler Handler, types ...Type) (unsubscribe func()) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.nextID++
+	id := b.nextID
+	b.subs = append(b.subs, subscription{id: id, handler: handler, types: types})
+
+	return func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		b.subs = slices.DeleteFunc(b.subs, func(s subscription) bool { return s.id == id })
+	}
+}
+
+// Publish delivers an event to every handler subscribed to its type.
+func (b *Bus) Publish(event Event) {
+	if b == nil {
+		return
+	}
+
+	b.mu.RLock()
+	subs := slices.Clone(b.subs)
+	b.mu.RUnlock()
+
+	for _, s := range subs {
+		if len(s.types) == 0 || slices.Contains(s.types, event.Type) {
+			s.handler(event)
+		}
+	}
+}
+
+// Game is the data of a GameIdentified event.
+type Game struct {
+	// Path is the ROM file path
+	Path string `json:"path"`
+	// Name is the identified game name
+	Name string `json:"name"`
+	// Provider is the provider that identified the game
+	Provider string `json:"provider"`
+	// ProviderID is the game's ID from the provider, if it has an integer ID
+	ProviderID *int `json:"provider_id,omitempty"`
+	// ProviderUID is the game's ID from the provider, if it has a string ID
+	ProviderUID string `json:"provider_uid,omitempty"`
+	// MatchType is the identification step that matched
+	MatchType string `json:"match_type,omitempty"`
+}
+
+// ProviderFailure is the data of a ProviderFailing event.
+type ProviderFailure struct {
+	// Provider is the failing provider
+	Provider string `json:"provider"`
+	// Failures is the number of failures in a row
+	Failures int `json:"failures"`
+	// Error is the most recent error
+	Error string `json:"error"`
+}
+
+// GameFromResult builds the data of a GameIdentified event.
+func GameFromResult(path string, result *retrometadata.GameResult) Game {
+	return Game{
+		Path:        path,
+		Name:        result.Name,
+		Provider:    result.Provider,
+		ProviderID:  result.ProviderID,
+		ProviderUID: result.ProviderUID,
+		MatchType:   result.MatchType,
+	}
+}
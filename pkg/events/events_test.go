@@ -0,0 +1,102 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestBusSubscribe(t *testing.T) {
+	bus := NewBus()
+
+	var all, games int
+	bus.Subscribe(func(Event) { all++ })
+	unsubscribe := bus.Subscribe(func(Event) { games++ }, GameIdentified)
+
+	bus.Publish(New(GameIdentified, nil))
+	bus.Publish(New(ScanFinished, nil))
+	unsubscribe()
+	bus.Publish(New(GameIdentified, nil))
+
+	if all != 3 || games != 1 {
+		t.Errorf("Handled all=%d games=%d, expected 3 and 1", all, games)
+	}
+
+	// A nil bus discards events
+	var nilBus *Bus
+	nilBus.Publish(New(ScanFinished, nil))
+}
+
+func TestProviderMonitor(t *testing.T) {
+	bus := NewBus()
+	var failures []ProviderFailure
+	bus.Subscribe(func(e Event) { failures = append(failures, e.Data.(ProviderFailure)) }, ProviderFailing)
+
+	monitor := NewProviderMonitor(bus, 2)
+	failure := errors.New("connection refused")
+	monitor.Observe("hash", "igdb", failure)
+	monitor.Observe("hash", "igdb", context.Canceled)
+	if len(failures) != 0 {
+		t.Fatalf("Published %v before the threshold", failures)
+	}
+	monitor.Observe("hash", "igdb", failure)
+	monitor.Observe("hash", "igdb", failure)
+	if len(failures) != 1 || failures[0].Provider != "igdb" || failures[0].Failures != 2 {
+		t.Errorf("Published %+v, expected a single igdb failure", failures)
+	}
+
+	monitor.Observe("filename", "igdb", nil)
+	if n := monitor.Failures("igdb"); n != 0 {
+		t.Errorf("Failures() = %d after a success, expected 0", n)
+	}
+}
+
+func TestWebhook(t *testing.T) {
+	var attempts atomic.Int32
+	received := make(chan *http.Request, 1)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if attempts.Add(1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		if got, want := r.Header.Get(HeaderSignature), Sign([]byte("secret"), body); got != want {
+			t.Errorf("Signature = %q, expected %q", got, want)
+		}
+		var event Event
+		if err := json.Unmarshal(body, &event); err != nil || event.Type != ScanFinished {
+			t.Errorf("Unexpected body %s", body)
+		}
+		received <- r
+	}))
+	defer ts.Close()
+
+	webhook := NewWebhook(ts.URL, WithSecret("secret"), WithRetries(2, time.Millisecond))
+	webhook.Handle(New(ScanFinished, map[string]int{"scanned": 3}))
+	if err := webhook.Close(context.Background()); err != nil {
+		t.Fatalf("Close() error: %v", err)
+	}
+
+	select {
+	case r := <-received:
+		if r.Header.Get(HeaderEvent) != string(ScanFinished) {
+			t.Errorf("%s = %q", HeaderEvent, r.Header.Get(HeaderEvent))
+		}
+	default:
+		t.Fatal("Expected the event to be delivered after a retry")
+	}
+	if webhook.Failed() != 0 {
+		t.Errorf("Failed() = %d, expected 0", webhook.Failed())
+	}
+
+	webhook.Handle(New(ScanFinished, nil))
+	if webhook.Dropped() != 1 {
+		t.Errorf("Dropped() = %d, expected events after Close to be dropped", webhook.Dropped())
+	}
+}
@@ -0,0 +1,64 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+// DefaultFailureThreshold is the number of failures in a row after which a
+// provider is reported as failing.
+const DefaultFailureThreshold = 5
+
+// ProviderMonitor counts provider failures in a row and publishes a
+// ProviderFailing event when a provider reaches the threshold. The event is
+// published once until the provider succeeds again.
+type ProviderMonitor struct {
+	bus       *Bus
+	threshold int
+	mu        sync.Mutex
+	failures  map[string]int
+}
+
+// NewProviderMonitor creates a monitor publishing to bus. A threshold below
+// 1 uses DefaultFailureThreshold.
+func NewProviderMonitor(bus *Bus, threshold int) *ProviderMonitor {
+	if threshold < 1 {
+		threshold = DefaultFailureThreshold
+	}
+	return &ProviderMonitor{bus: bus, threshold: threshold, failures: make(map[string]int)}
+}
+
+// Observe records the outcome of a provider call: a failure if err is set,
+// a success otherwise. Cancellations aren't counted. Its signature matches
+// identify.Observer, so it can observe an identification pipeline.
+func (m *ProviderMonitor) Observe(_, provider string, err error) {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return
+	}
+
+	m.mu.Lock()
+	if err == nil {
+		delete(m.failures, provider)
+		m.mu.Unlock()
+		return
+	}
+	m.failures[provider]++
+	failures := m.failures[provider]
+	m.mu.Unlock()
+
+	if failures == m.threshold {
+		m.bus.Publish(New(ProviderFailing, ProviderFailure{
+			Provider: provider,
+			Failures: failures,
+			Error:    err.Error(),
+		}))
+	}
+}
+
+// Failures returns the number of failures in a row of a provider.
+func (m *ProviderMonitor) Failures(provider string) int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.failures[provider]
+}
@@ -0,0 +1,238 @@
+package events
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Webhook headers.
+const (
+	// HeaderEvent is the event type of a delivery
+	HeaderEvent = "X-Retro-Metadata-Event"
+	// HeaderSignature is "sha256=" and the hex HMAC-SHA256 of the body,
+	// keyed with the webhook secret. It's only sent if a secret is set.
+	HeaderSignature = "X-Retro-Metadata-Signature"
+)
+
+// DefaultQueueSize is the number of events a webhook holds for delivery.
+const DefaultQueueSize = 256
+
+// Webhook delivers events to an HTTP endpoint as JSON POST requests.
+//
+// Events are queued and delivered in order by a background goroutine, so
+// Handle never blocks the publisher. Failed deliveries (network errors and
+// 5xx responses) are retried with backoff; events are dropped if the queue
+// is full or every attempt fails.
+type Webhook struct {
+	url        string
+	secret     []byte
+	httpClient *http.Client
+	userAgent  string
+	attempts   int
+	backoff    time.Duration
+
+	mu      sync.RWMutex
+	closed  bool
+	queue   chan Event
+	done    chan struct{}
+	ctx     context.Context
+	cancel  context.CancelFunc
+	dropped atomic.Int64
+	failed  atomic.Int64
+}
+
+// WebhookOption is a functional option for Webhook.
+type WebhookOption func(*Webhook)
+
+// WithSecret sets the secret used to sign deliveries.
+func WithSecret(secret string) WebhookOption {
+	return func(w *Webhook) {
+		w.secret = []byte(secret)
+	}
+}
+
+// WithHTTPClient sets the HTTP client used for deliveries.
+func WithHTTPClient(client *http.Client) WebhookOption {
+	return func(w *Webhook) {
+		w.httpClient = client
+	}
+}
+
+// WithUserAgent sets the user agent of deliveries.
+func WithUserAgent(userAgent string) WebhookOption {
+	return func(w *Webhook) {
+		w.userAgent = userAgent
+	}
+}
+
+// WithRetries sets the number of attempts per event and the delay before
+// the first retry, which doubles for each later retry.
+func WithRetries(attempts int, backoff time.Duration) WebhookOption {
+	return func(w *Webhook) {
+		w.attempts = max(attempts, 1)
+		w.backoff = backoff
+	}
+}
+
+// WithQueueSize sets the number of events held for delivery.
+func WithQueueSize(size int) WebhookOption {
+	return func(w *Webhook) {
+		w.queue = make(chan Event, max(size, 1))
+	}
+}
+
+// NewWebhook creates a webhook delivering to url and starts its delivery
+// goroutine. By default each event is attempted 3 times, one second apart
+// and then two. Close the webhook to deliver queued events and stop it.
+func NewWebhook(url string, opts ...WebhookOption) *Webhook {
+	w := &Webhook{
+		url:        url,
+		httpClient: &http.Client{Timeout: 10 * time.Second},
+		userAgent:  "retro-metadata/1.0",
+		attempts:   3,
+		backoff:    time.Second,
+		queue:      make(chan Event, DefaultQueueSize),
+		done:       make(chan struct{}),
+	}
+	for _, opt := range opts {
+		opt(w)
+	}
+	w.ctx, w.cancel = context.WithCancel(context.Background())
+
+	go w.run()
+	return w
+}
+
+// Handle queues an event for delivery. It can be subscribed to a Bus.
+// Events handled after Close are dropped.
+func (w *Webhook) Handle(event Event) {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+
+	if w.closed {
+		w.dropped.Add(1)
+		return
+	}
+	select {
+	case w.queue <- event:
+	default:
+		w.dropped.Add(1)
+	}
+}
+
+// Close stops accepting events and waits for the queued events to be
+// delivered, or for ctx to be done, in which case delivery is abandoned.
+func (w *Webhook) Close(ctx context.Context) error {
+	w.mu.Lock()
+	if !w.closed {
+		w.closed = true
+		close(w.queue)
+	}
+	w.mu.Unlock()
+
+	select {
+	case <-w.done:
+		return nil
+	case <-ctx.Done():
+		w.cancel()
+		<-w.done
+		return ctx.Err()
+	}
+}
+
+// Dropped returns the number of events dropped because the queue was full
+// or the webhook was closed.
+func (w *Webhook) Dropped() int64 {
+	return w.dropped.Load()
+}
+
+// Failed returns the number of events that couldn't be delivered.
+func (w *Webhook) Failed() int64 {
+	return w.failed.Load()
+}
+
+// run delivers queued events until the queue is closed.
+func (w *Webhook) run() {
+	defer close(w.done)
+	defer w.cancel()
+
+	for event := range w.queue {
+		if w.ctx.Err() != nil {
+			w.dropped.Add(1)
+			continue
+		}
+		if err := w.deliver(w.ctx, event); err != nil {
+			w.failed.Add(1)
+		}
+	}
+}
+
+// deliver sends an event, retrying failed attempts.
+func (w *Webhook) deliver(ctx context.Context, event Event) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	backoff := w.backoff
+	for attempt := 1; ; attempt++ {
+		retry, err := w.send(ctx, event.Type, body)
+		if err == nil || !retry || attempt >= w.attempts {
+			return err
+		}
+
+		select {
+		case <-time.After(backoff):
+			backoff *= 2
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// send makes a single delivery attempt, and reports whether a failure
+// should be retried.
+func (w *Webhook) send(ctx context.Context, eventType Type, body []byte) (retry bool, err error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.url, bytes.NewReader(body))
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", w.userAgent)
+	req.Header.Set(HeaderEvent, string(eventType))
+	if len(w.secret) > 0 {
+		req.Header.Set(HeaderSignature, Sign(w.secret, body))
+	}
+
+	resp, err := w.httpClient.Do(req)
+	if err != nil {
+		return true, err
+	}
+	resp.Body.Close()
+
+	switch {
+	case resp.StatusCode >= 200 && resp.StatusCode < 300:
+		return false, nil
+	case resp.StatusCode >= 500 || resp.StatusCode == http.StatusTooManyRequests:
+		return true, fmt.Errorf("webhook returned HTTP %d", resp.StatusCode)
+	default:
+		return false, fmt.Errorf("webhook returned HTTP %d", resp.StatusCode)
+	}
+}
+
+// Sign returns the HeaderSignature value for a body, so receivers can verify
+// deliveries with hmac.Equal.
+func Sign(secret, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
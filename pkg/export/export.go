@@ -0,0 +1,186 @@
+// Package export writes identified games to frontend and collection formats.
+//
+// Exports tolerate partial failure: an entry that can't be exported is skipped
+// and recorded in the ExportReport with its reason, and the output for every
+// other entry is still written as a valid file.
+package export
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/josegonzalez/retro-metadata/pkg/identify"
+	"github.com/josegonzalez/retro-metadata/pkg/retrometadata"
+)
+
+// Entry is a single ROM and its identification result.
+type Entry struct {
+	// Path is the ROM file path
+	Path string
+	// Game is the identified game, or nil if identification failed
+	Game *retrometadata.GameResult
+	// Err is the identification error, if any
+	Err error
+	// Hashes are the ROM's hashes, if known
+	Hashes *retrometadata.FileHashes
+}
+
+// Exporter writes entries in a specific output format.
+type Exporter interface {
+	// Name returns the exporter name (e.g., "json", "gamelist").
+	Name() string
+
+	// Begin writes anything that precedes the entries, such as a header.
+	Begin(w io.Writer) error
+
+	// WriteEntry writes a single entry. index is the number of entries written
+	// before this one. An error skips the entry; anything written for it is discarded.
+	WriteEntry(w io.Writer, index int, entry Entry) error
+
+	// End writes anything that follows the entries, such as a footer.
+	End(w io.Writer) error
+}
+
+// SkippedEntry is an entry that wasn't exported.
+type SkippedEntry struct {
+	// Path is the ROM file path
+	Path string `json:"path"`
+	// Reason explains why the entry was skipped
+	Reason string `json:"reason"`
+}
+
+// ExportReport summarizes an export.
+type ExportReport struct {
+	// Exporter is the exporter name
+	Exporter string `json:"exporter"`
+	// Output is the output file path, if written to a file
+	Output string `json:"output,omitempty"`
+	// Written is the number of entries exported
+	Written int `json:"written"`
+	// Skipped is every entry that wasn't exported, with its reason
+	Skipped []SkippedEntry `json:"skipped,omitempty"`
+}
+
+// HasFailures returns true if any entry was skipped.
+func (r *ExportReport) HasFailures() bool {
+	return len(r.Skipped) > 0
+}
+
+// skip records a skipped entry.
+func (r *ExportReport) skip(path, reason string) {
+	r.Skipped = append(r.Skipped, SkippedEntry{Path: path, Reason: reason})
+}
+
+// Write exports entries to w, skipping entries that fail.
+// An error is only returned if the output itself can't be written.
+func Write(ctx context.Context, w io.Writer, exporter Exporter, entries []Entry) (*ExportReport, error) {
+	report := &ExportReport{Exporter: exporter.Name()}
+
+	if err := exporter.Begin(w); err != nil {
+		return report, fmt.Errorf("writing %s header: %w", exporter.Name(), err)
+	}
+
+	var buf bytes.Buffer
+	for _, entry := range entries {
+		if err := ctx.Err(); err != nil {
+			return report, err
+		}
+
+		if reason := skipReason(entry); reason != "" {
+			report.skip(entry.Path, reason)
+			continue
+		}
+
+		// Entries are rendered into a buffer first so a failure part way
+		// through an entry never leaves partial output behind.
+		buf.Reset()
+		if err := exporter.WriteEntry(&buf, report.Written, entry); err != nil {
+			report.skip(entry.Path, err.Error())
+			continue
+		}
+		if _, err := w.Write(buf.Bytes()); err != nil {
+			return report, fmt.Errorf("writing %s entry: %w", exporter.Name(), err)
+		}
+		report.Written++
+	}
+
+	if err := exporter.End(w); err != nil {
+		return report, fmt.Errorf("writing %s footer: %w", exporter.Name(), err)
+	}
+
+	return report, nil
+}
+
+// skipReason returns why an entry can't be exported, or an empty string if it can.
+func skipReason(entry Entry) string {
+	if entry.Err != nil {
+		return fmt.Sprintf("not identified: %v", entry.Err)
+	}
+	if entry.Game == nil {
+		return "not identified"
+	}
+	return ""
+}
+
+// WriteFile exports entries to a file. The file is written to a temporary
+// path and renamed into place, so an existing file is never left half-written.
+func WriteFile(ctx context.Context, path string, exporter Exporter, entries []Entry) (*ExportReport, error) {
+	var report *ExportReport
+	err := writeFileAtomic(path, func(w io.Writer) error {
+		var err error
+		report, err = Write(ctx, w, exporter, entries)
+		return err
+	})
+	if err != nil {
+		return report, err
+	}
+
+	report.Output = path
+	return report, nil
+}
+
+// writeFileAtomic writes a file through write, via a temporary file that's
+// renamed into place once write succeeds.
+func writeFileAtomic(path string, write func(w io.Writer) error) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("creating output directory: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*")
+	if err != nil {
+		return fmt.Errorf("creating output file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	err = write(tmp)
+	if closeErr := tmp.Close(); err == nil && closeErr != nil {
+		err = fmt.Errorf("closing output file: %w", closeErr)
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("replacing output file: %w", err)
+	}
+	return nil
+}
+
+// EntriesFromBatch converts batch identification results into export entries.
+// Multi-disc groups are exported under their group filename.
+func EntriesFromBatch(results []identify.BatchResult) []Entry {
+	entries := make([]Entry, len(results))
+	for i, r := range results {
+		entries[i] = Entry{
+			Path:   r.Group.Filename,
+			Game:   r.Result,
+			Err:    r.Err,
+			Hashes: r.Group.Hashes,
+		}
+	}
+	return entries
+}
@@ -0,0 +1,130 @@
+package export
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/josegonzalez/retro-metadata/pkg/retrometadata"
+)
+
+// failingExporter writes partial output and then fails for games named "bad".
+type failingExporter struct{}
+
+func (failingExporter) Name() string          { return "failing" }
+func (failingExporter) Begin(io.Writer) error { return nil }
+func (failingExporter) End(io.Writer) error   { return nil }
+func (failingExporter) WriteEntry(w io.Writer, _ int, entry Entry) error {
+	io.WriteString(w, entry.Game.Name)
+	if entry.Game.Name == "bad" {
+		return errors.New("cannot export bad")
+	}
+	io.WriteString(w, "\n")
+	return nil
+}
+
+func testEntries() []Entry {
+	return []Entry{
+		{Path: "a.sfc", Game: &retrometadata.GameResult{Name: "Super Mario World"}},
+		{Path: "b.sfc", Game: &retrometadata.GameResult{Name: ""}},
+		{Path: "c.sfc", Err: errors.New("no match")},
+		{Path: "d.sfc"},
+		{Path: "e.sfc", Game: &retrometadata.GameResult{Name: "Chrono Trigger"}},
+	}
+}
+
+func TestWriteSkipsFailedEntries(t *testing.T) {
+	var buf bytes.Buffer
+	report, err := Write(context.Background(), &buf, NewJSONExporter(), testEntries())
+	if err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+
+	if report.Written != 2 {
+		t.Errorf("Written = %d, want 2", report.Written)
+	}
+	if !report.HasFailures() || len(report.Skipped) != 3 {
+		t.Fatalf("Skipped = %+v, want 3 entries", report.Skipped)
+	}
+	wantSkipped := map[string]string{
+		"b.sfc": "game has no name",
+		"c.sfc": "not identified: no match",
+		"d.sfc": "not identified",
+	}
+	for _, s := range report.Skipped {
+		if wantSkipped[s.Path] != s.Reason {
+			t.Errorf("Skipped[%s].Reason = %q, want %q", s.Path, s.Reason, wantSkipped[s.Path])
+		}
+	}
+
+	var decoded []struct {
+		Path string `json:"path"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &decoded); err != nil {
+		t.Fatalf("output is not valid JSON: %v\n%s", err, buf.String())
+	}
+	if len(decoded) != 2 || decoded[0].Path != "a.sfc" || decoded[1].Path != "e.sfc" {
+		t.Errorf("decoded = %+v", decoded)
+	}
+}
+
+func TestWriteDiscardsPartialEntryOutput(t *testing.T) {
+	entries := []Entry{
+		{Path: "1", Game: &retrometadata.GameResult{Name: "good"}},
+		{Path: "2", Game: &retrometadata.GameResult{Name: "bad"}},
+		{Path: "3", Game: &retrometadata.GameResult{Name: "also good"}},
+	}
+
+	var buf bytes.Buffer
+	report, err := Write(context.Background(), &buf, failingExporter{}, entries)
+	if err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	if got, want := buf.String(), "good\nalso good\n"; got != want {
+		t.Errorf("output = %q, want %q", got, want)
+	}
+	if len(report.Skipped) != 1 || report.Skipped[0].Reason != "cannot export bad" {
+		t.Errorf("Skipped = %+v", report.Skipped)
+	}
+}
+
+func TestWriteEmptyJSON(t *testing.T) {
+	var buf bytes.Buffer
+	if _, err := Write(context.Background(), &buf, NewJSONExporter(), nil); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	var decoded []any
+	if err := json.Unmarshal(buf.Bytes(), &decoded); err != nil {
+		t.Fatalf("output is not valid JSON: %v\n%s", err, buf.String())
+	}
+}
+
+func TestWriteFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "out", "games.json")
+
+	report, err := WriteFile(context.Background(), path, NewJSONExporter(), testEntries())
+	if err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+	if report.Output != path {
+		t.Errorf("Output = %q, want %q", report.Output, path)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !json.Valid(data) {
+		t.Errorf("output is not valid JSON:\n%s", data)
+	}
+
+	matches, _ := filepath.Glob(filepath.Join(filepath.Dir(path), ".*"))
+	if len(matches) != 0 {
+		t.Errorf("temporary files left behind: %v", matches)
+	}
+}
@@ -0,0 +1,76 @@
+package export
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"path/filepath"
+	"testing"
+
+	"github.com/josegonzalez/retro-metadata/pkg/retrometadata"
+)
+
+func TestPegasusExporter(t *testing.T) {
+	romDir := t.TempDir()
+	entry := gamelistEntry(romDir)
+	entry.Game.Summary = "Samus returns to Zebes.\n\nThe third Metroid game."
+
+	var buf bytes.Buffer
+	exporter := NewPegasusExporter("Super Nintendo", romDir)
+	exporter.Launch = "retroarch -L snes9x_libretro.so {file.path}"
+	report, err := Write(context.Background(), &buf, exporter, []Entry{entry, entry})
+	if err != nil || report.Written != 2 {
+		t.Fatalf("Write() = %+v, %v", report, err)
+	}
+
+	header := "collection: Super Nintendo\nlaunch: retroarch -L snes9x_libretro.so {file.path}\n"
+	game := `game: Super Metroid
+file: Super Metroid (USA).sfc
+developer: Nintendo R&D1
+genre: Action, Platform
+players: 1
+release: 1994
+rating: 87%
+description: Samus returns to Zebes.
+  .
+  The third Metroid game.
+`
+	if want := header + "\n" + game + "\n" + game; buf.String() != want {
+		t.Errorf("output:\n%s\nwant:\n%s", buf.String(), want)
+	}
+}
+
+func TestRetroArchExporter(t *testing.T) {
+	romDir := t.TempDir()
+	entries := []Entry{
+		gamelistEntry(romDir),
+		{Path: filepath.Join(romDir, "Chrono Trigger (USA).sfc"), Game: &retrometadata.GameResult{Name: "Chrono Trigger"}},
+	}
+	entries[0].Hashes = &retrometadata.FileHashes{CRC32: "d63ed5f8"}
+
+	var buf bytes.Buffer
+	exporter := NewRetroArchExporter("Nintendo - Super Nintendo Entertainment System.lpl")
+	report, err := Write(context.Background(), &buf, exporter, entries)
+	if err != nil || report.Written != 2 {
+		t.Fatalf("Write() = %+v, %v", report, err)
+	}
+
+	var playlist struct {
+		Version string          `json:"version"`
+		Items   []retroArchItem `json:"items"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &playlist); err != nil {
+		t.Fatalf("playlist is not valid JSON: %v\n%s", err, buf.String())
+	}
+	if playlist.Version != "1.5" || len(playlist.Items) != 2 {
+		t.Fatalf("playlist = %+v", playlist)
+	}
+	first := playlist.Items[0]
+	if first.Label != "Super Metroid" || first.CRC32 != "D63ED5F8|crc" || first.CorePath != "DETECT" ||
+		first.DBName != "Nintendo - Super Nintendo Entertainment System.lpl" || first.Path != entries[0].Path {
+		t.Errorf("first item = %+v", first)
+	}
+	if playlist.Items[1].CRC32 != "DETECT" {
+		t.Errorf("item without hashes has CRC %q, want DETECT", playlist.Items[1].CRC32)
+	}
+}
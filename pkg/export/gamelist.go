@@ -0,0 +1,307 @@
+package export
+
+import (
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/josegonzalez/retro-metadata/pkg/retrometadata"
+)
+
+// Media layouts for gamelist exports.
+const (
+	// LayoutES references media from <image>, <marquee>, etc. tags, with
+	// files named "<rom>-<tag>.<ext>" in the media directory
+	LayoutES = "es"
+	// LayoutESDE doesn't write media tags; ES-DE finds media by ROM name in
+	// per-type folders ("covers/<rom>.<ext>") of its downloaded_media directory
+	LayoutESDE = "es-de"
+)
+
+// gamelistMedia maps artwork types to gamelist tags and ES-DE media folders.
+var gamelistMedia = []struct {
+	artworkType string
+	tag         string
+	folder      string
+}{
+	{"cover", "image", "covers"},
+	{"screenshot", "screenshot", "screenshots"},
+	{"logo", "marquee", "marquees"},
+	{"background", "fanart", "fanart"},
+}
+
+// mediaExtensions are the extensions looked for when referencing downloaded media.
+var mediaExtensions = []string{".png", ".jpg", ".jpeg", ".gif", ".webp"}
+
+// GamelistExporter writes entries as an EmulationStation gamelist.xml.
+//
+// Write and WriteFile produce a new gamelist; UpdateGamelist merges entries
+// into an existing one.
+type GamelistExporter struct {
+	// RomDir is the system's ROM directory. ROM and media paths inside it are
+	// written relative to it ("./Game.sfc"), as EmulationStation expects.
+	RomDir string
+	// MediaDir is where media for the system is stored. For LayoutESDE it's
+	// the system's folder in downloaded_media.
+	MediaDir string
+	// Layout is LayoutES (the default) or LayoutESDE
+	Layout string
+}
+
+// NewGamelistExporter creates a gamelist exporter for a ROM directory, with
+// media in its "media" subdirectory.
+func NewGamelistExporter(romDir string) *GamelistExporter {
+	return &GamelistExporter{RomDir: romDir, MediaDir: filepath.Join(romDir, "media"), Layout: LayoutES}
+}
+
+// gamelistFile is a gamelist.xml document. Elements other than games, such
+// as folders, are kept as they are.
+type gamelistFile struct {
+	XMLName xml.Name       `xml:"gameList"`
+	Games   []gamelistGame `xml:"game"`
+	Other   []rawElement   `xml:",any"`
+}
+
+// gamelistGame is a <game> entry. Tags the exporter doesn't write, such as
+// play counts and favorites, are kept in Other.
+type gamelistGame struct {
+	XMLName     xml.Name     `xml:"game"`
+	Attrs       []xml.Attr   `xml:",any,attr"`
+	Path        string       `xml:"path"`
+	Name        string       `xml:"name,omitempty"`
+	Desc        string       `xml:"desc,omitempty"`
+	Image       string       `xml:"image,omitempty"`
+	Screenshot  string       `xml:"screenshot,omitempty"`
+	Marquee     string       `xml:"marquee,omitempty"`
+	Fanart      string       `xml:"fanart,omitempty"`
+	Rating      string       `xml:"rating,omitempty"`
+	ReleaseDate string       `xml:"releasedate,omitempty"`
+	Developer   string       `xml:"developer,omitempty"`
+	Publisher   string       `xml:"publisher,omitempty"`
+	Genre       string       `xml:"genre,omitempty"`
+	Players     string       `xml:"players,omitempty"`
+	Other       []rawElement `xml:",any"`
+}
+
+// rawElement is an element that's copied through unchanged.
+type rawElement struct {
+	XMLName xml.Name
+	Attrs   []xml.Attr `xml:",any,attr"`
+	Inner   string     `xml:",innerxml"`
+}
+
+// Name returns "gamelist".
+func (e *GamelistExporter) Name() string {
+	return "gamelist"
+}
+
+// Begin writes the XML header and opens the game list.
+func (e *GamelistExporter) Begin(w io.Writer) error {
+	_, err := io.WriteString(w, xml.Header+"<gameList>\n")
+	return err
+}
+
+// WriteEntry writes a single <game> element.
+func (e *GamelistExporter) WriteEntry(w io.Writer, _ int, entry Entry) error {
+	game, err := e.game(entry)
+	if err != nil {
+		return err
+	}
+	data, err := xml.MarshalIndent(game, "\t", "\t")
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(data); err != nil {
+		return err
+	}
+	_, err = io.WriteString(w, "\n")
+	return err
+}
+
+// End closes the game list.
+func (e *GamelistExporter) End(w io.Writer) error {
+	_, err := io.WriteString(w, "</gameList>\n")
+	return err
+}
+
+// MediaPath returns where media of an artwork type ("cover", "screenshot",
+// "logo" or "background") for a ROM is stored in the exporter's layout.
+// Returns an empty string for other types.
+func (e *GamelistExporter) MediaPath(romPath, artworkType, extension string) string {
+	base := strings.TrimSuffix(filepath.Base(romPath), filepath.Ext(romPath))
+	for _, m := range gamelistMedia {
+		if m.artworkType != artworkType {
+			continue
+		}
+		if e.Layout == LayoutESDE {
+			return filepath.Join(e.MediaDir, m.folder, base+extension)
+		}
+		return filepath.Join(e.MediaDir, base+"-"+m.tag+extension)
+	}
+	return ""
+}
+
+// game builds the <game> element for an entry.
+func (e *GamelistExporter) game(entry Entry) (*gamelistGame, error) {
+	result := entry.Game
+	if result.Name == "" {
+		return nil, errors.New("game has no name")
+	}
+
+	game := &gamelistGame{
+		Path:      e.relativePath(entry.Path),
+		Name:      result.Name,
+		Desc:      result.Summary,
+		Developer: result.Metadata.Developer,
+		Publisher: result.Metadata.Publisher,
+		Genre:     strings.Join(result.Metadata.Genres, ", "),
+		Players:   result.Metadata.PlayerCount,
+	}
+	if rating := result.Metadata.TotalRating; rating != nil {
+		// Gamelist ratings are from 0 to 1
+		game.Rating = strconv.FormatFloat(math.Round(*rating)/100, 'f', -1, 64)
+	}
+	game.ReleaseDate = releaseDate(result.Metadata)
+
+	if e.Layout != LayoutESDE {
+		game.Image = e.mediaTag(entry.Path, "cover")
+		game.Screenshot = e.mediaTag(entry.Path, "screenshot")
+		game.Marquee = e.mediaTag(entry.Path, "logo")
+		game.Fanart = e.mediaTag(entry.Path, "background")
+	}
+	return game, nil
+}
+
+// mediaTag returns the path to write for downloaded media of an artwork
+// type, or an empty string if it hasn't been downloaded.
+func (e *GamelistExporter) mediaTag(romPath, artworkType string) string {
+	for _, ext := range mediaExtensions {
+		path := e.MediaPath(romPath, artworkType, ext)
+		if _, err := os.Stat(path); err == nil {
+			return e.relativePath(path)
+		}
+	}
+	return ""
+}
+
+// relativePath returns path relative to the ROM directory in gamelist form
+// ("./sub/Game.sfc"), or unchanged if it's outside the ROM directory.
+func (e *GamelistExporter) relativePath(path string) string {
+	if e.RomDir == "" {
+		return filepath.ToSlash(path)
+	}
+	rel, err := filepath.Rel(e.RomDir, path)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return filepath.ToSlash(path)
+	}
+	return "./" + filepath.ToSlash(rel)
+}
+
+// releaseDate formats a game's release date the way EmulationStation stores it.
+func releaseDate(metadata retrometadata.GameMetadata) string {
+	if metadata.FirstReleaseDate != nil {
+		return time.Unix(*metadata.FirstReleaseDate, 0).UTC().Format("20060102T150405")
+	}
+	if metadata.ReleaseYear != nil {
+		return fmt.Sprintf("%04d0101T000000", *metadata.ReleaseYear)
+	}
+	return ""
+}
+
+// merge updates an existing entry with the fields of game that are set.
+func (g *gamelistGame) merge(game *gamelistGame) {
+	set := func(dst *string, value string) {
+		if value != "" {
+			*dst = value
+		}
+	}
+	set(&g.Name, game.Name)
+	set(&g.Desc, game.Desc)
+	set(&g.Image, game.Image)
+	set(&g.Screenshot, game.Screenshot)
+	set(&g.Marquee, game.Marquee)
+	set(&g.Fanart, game.Fanart)
+	set(&g.Rating, game.Rating)
+	set(&g.ReleaseDate, game.ReleaseDate)
+	set(&g.Developer, game.Developer)
+	set(&g.Publisher, game.Publisher)
+	set(&g.Genre, game.Genre)
+	set(&g.Players, game.Players)
+}
+
+// gamelistKey normalizes a gamelist path for matching entries.
+func gamelistKey(path string) string {
+	return filepath.ToSlash(filepath.Clean(filepath.FromSlash(path)))
+}
+
+// UpdateGamelist merges entries into the gamelist.xml at path, creating it
+// if it doesn't exist. Existing games are updated in place: fields the
+// entry has replace the old values, and tags the exporter doesn't write
+// (play counts, favorites, etc.) and other games are kept.
+func UpdateGamelist(ctx context.Context, path string, exporter *GamelistExporter, entries []Entry) (*ExportReport, error) {
+	doc := &gamelistFile{}
+	data, err := os.ReadFile(path)
+	switch {
+	case err == nil:
+		if err := xml.Unmarshal(data, doc); err != nil {
+			return nil, fmt.Errorf("reading %s: %w", path, err)
+		}
+	case !errors.Is(err, os.ErrNotExist):
+		return nil, err
+	}
+
+	byPath := make(map[string]int, len(doc.Games))
+	for i, game := range doc.Games {
+		byPath[gamelistKey(game.Path)] = i
+	}
+
+	report := &ExportReport{Exporter: exporter.Name()}
+	for _, entry := range entries {
+		if err := ctx.Err(); err != nil {
+			return report, err
+		}
+		if reason := skipReason(entry); reason != "" {
+			report.skip(entry.Path, reason)
+			continue
+		}
+
+		game, err := exporter.game(entry)
+		if err != nil {
+			report.skip(entry.Path, err.Error())
+			continue
+		}
+		if i, ok := byPath[gamelistKey(game.Path)]; ok {
+			doc.Games[i].merge(game)
+		} else {
+			byPath[gamelistKey(game.Path)] = len(doc.Games)
+			doc.Games = append(doc.Games, *game)
+		}
+		report.Written++
+	}
+
+	err = writeFileAtomic(path, func(w io.Writer) error {
+		if _, err := io.WriteString(w, xml.Header); err != nil {
+			return err
+		}
+		enc := xml.NewEncoder(w)
+		enc.Indent("", "\t")
+		if err := enc.Encode(doc); err != nil {
+			return err
+		}
+		_, err := io.WriteString(w, "\n")
+		return err
+	})
+	if err != nil {
+		return report, err
+	}
+	report.Output = path
+	return report, nil
+}
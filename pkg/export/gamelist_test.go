@@ -0,0 +1,145 @@
+package export
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/josegonzalez/retro-metadata/pkg/retrometadata"
+)
+
+func gamelistEntry(romDir string) Entry {
+	rating := 87.4
+	year := 1994
+	return Entry{
+		Path: filepath.Join(romDir, "Super Metroid (USA).sfc"),
+		Game: &retrometadata.GameResult{
+			Name:    "Super Metroid",
+			Summary: "Samus returns to Zebes.",
+			Metadata: retrometadata.GameMetadata{
+				TotalRating: &rating,
+				ReleaseYear: &year,
+				Genres:      []string{"Action", "Platform"},
+				Developer:   "Nintendo R&D1",
+				PlayerCount: "1",
+			},
+		},
+	}
+}
+
+func TestGamelistExporter(t *testing.T) {
+	romDir := t.TempDir()
+	exporter := NewGamelistExporter(romDir)
+
+	// Only media that has been downloaded is referenced
+	cover := exporter.MediaPath(filepath.Join(romDir, "Super Metroid (USA).sfc"), "cover", ".png")
+	if err := os.MkdirAll(filepath.Dir(cover), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(cover, []byte("png"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	entries := []Entry{gamelistEntry(romDir), {Path: filepath.Join(romDir, "Unknown.sfc")}}
+	report, err := Write(context.Background(), &buf, exporter, entries)
+	if err != nil || report.Written != 1 || len(report.Skipped) != 1 {
+		t.Fatalf("Write() = %+v, %v", report, err)
+	}
+
+	var doc gamelistFile
+	if err := xml.Unmarshal(buf.Bytes(), &doc); err != nil {
+		t.Fatalf("output is not valid XML: %v\n%s", err, buf.String())
+	}
+	if len(doc.Games) != 1 {
+		t.Fatalf("got %d games, want 1", len(doc.Games))
+	}
+	game := doc.Games[0]
+	want := gamelistGame{
+		Path:        "./Super Metroid (USA).sfc",
+		Name:        "Super Metroid",
+		Desc:        "Samus returns to Zebes.",
+		Image:       "./media/Super Metroid (USA)-image.png",
+		Rating:      "0.87",
+		ReleaseDate: "19940101T000000",
+		Developer:   "Nintendo R&D1",
+		Genre:       "Action, Platform",
+		Players:     "1",
+	}
+	if game.Path != want.Path || game.Name != want.Name || game.Desc != want.Desc || game.Image != want.Image ||
+		game.Rating != want.Rating || game.ReleaseDate != want.ReleaseDate || game.Developer != want.Developer ||
+		game.Genre != want.Genre || game.Players != want.Players || game.Marquee != "" {
+		t.Errorf("game = %+v, want %+v", game, want)
+	}
+}
+
+func TestUpdateGamelist(t *testing.T) {
+	romDir := t.TempDir()
+	path := filepath.Join(romDir, "gamelist.xml")
+	existing := `<?xml version="1.0"?>
+<gameList>
+	<folder><path>./Hacks</path><name>Hacks</name></folder>
+	<game id="123" source="ScreenScraper.fr">
+		<path>./Super Metroid (USA).sfc</path>
+		<name>Super Metroid (edited)</name>
+		<rating>0.5</rating>
+		<playcount>12</playcount>
+		<favorite>true</favorite>
+	</game>
+	<game>
+		<path>./Chrono Trigger (USA).sfc</path>
+		<name>Chrono Trigger</name>
+	</game>
+</gameList>
+`
+	if err := os.WriteFile(path, []byte(existing), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	exporter := &GamelistExporter{RomDir: romDir, MediaDir: filepath.Join(romDir, "downloaded_media", "snes"), Layout: LayoutESDE}
+	entries := []Entry{
+		gamelistEntry(romDir),
+		{Path: filepath.Join(romDir, "Hacks", "Metroid Rogue.sfc"), Game: &retrometadata.GameResult{Name: "Metroid Rogue"}},
+	}
+	report, err := UpdateGamelist(context.Background(), path, exporter, entries)
+	if err != nil || report.Written != 2 {
+		t.Fatalf("UpdateGamelist() = %+v, %v", report, err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var doc gamelistFile
+	if err := xml.Unmarshal(data, &doc); err != nil {
+		t.Fatalf("updated gamelist is not valid XML: %v\n%s", err, data)
+	}
+	if len(doc.Games) != 3 || len(doc.Other) != 1 || doc.Other[0].XMLName.Local != "folder" {
+		t.Fatalf("updated gamelist has %d games and %v, want 3 games and the folder:\n%s", len(doc.Games), doc.Other, data)
+	}
+
+	updated := doc.Games[0]
+	if updated.Name != "Super Metroid" || updated.Rating != "0.87" || updated.Genre != "Action, Platform" {
+		t.Errorf("updated game = %+v", updated)
+	}
+	if updated.Image != "" {
+		t.Errorf("ES-DE layout wrote an image tag %q", updated.Image)
+	}
+	for _, tag := range []string{"<playcount>12</playcount>", "<favorite>true</favorite>", `id="123"`, `source="ScreenScraper.fr"`} {
+		if !strings.Contains(string(data), tag) {
+			t.Errorf("updated gamelist lost %s:\n%s", tag, data)
+		}
+	}
+	if doc.Games[1].Name != "Chrono Trigger" || doc.Games[2].Path != "./Hacks/Metroid Rogue.sfc" {
+		t.Errorf("games = %+v", doc.Games)
+	}
+
+	wantMedia := filepath.Join(romDir, "downloaded_media", "snes", "covers", "Super Metroid (USA).png")
+	if got := exporter.MediaPath(entries[0].Path, "cover", ".png"); got != wantMedia {
+		t.Errorf("MediaPath() = %q, want %q", got, wantMedia)
+	}
+}
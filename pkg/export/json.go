@@ -0,0 +1,72 @@
+package export
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+)
+
+// JSONExporter writes entries as a JSON array of {"path", "game"} objects.
+type JSONExporter struct {
+	// Indent indents the output when non-empty
+	Indent string
+}
+
+// jsonEntry is the serialized form of an Entry.
+type jsonEntry struct {
+	Path string `json:"path"`
+	Game any    `json:"game"`
+}
+
+// NewJSONExporter creates a JSON exporter with two-space indentation.
+func NewJSONExporter() *JSONExporter {
+	return &JSONExporter{Indent: "  "}
+}
+
+// Name returns "json".
+func (e *JSONExporter) Name() string {
+	return "json"
+}
+
+// Begin opens the JSON array.
+func (e *JSONExporter) Begin(w io.Writer) error {
+	_, err := io.WriteString(w, "[")
+	return err
+}
+
+// WriteEntry writes a single array element.
+func (e *JSONExporter) WriteEntry(w io.Writer, index int, entry Entry) error {
+	if entry.Game.Name == "" {
+		return errors.New("game has no name")
+	}
+
+	var data []byte
+	var err error
+	if e.Indent != "" {
+		data, err = json.MarshalIndent(jsonEntry{Path: entry.Path, Game: entry.Game}, e.Indent, e.Indent)
+	} else {
+		data, err = json.Marshal(jsonEntry{Path: entry.Path, Game: entry.Game})
+	}
+	if err != nil {
+		return err
+	}
+
+	if index > 0 {
+		if _, err := io.WriteString(w, ","); err != nil {
+			return err
+		}
+	}
+	if e.Indent != "" {
+		if _, err := io.WriteString(w, "\n"+e.Indent); err != nil {
+			return err
+		}
+	}
+	_, err = w.Write(data)
+	return err
+}
+
+// End closes the JSON array.
+func (e *JSONExporter) End(w io.Writer) error {
+	_, err := io.WriteString(w, "\n]\n")
+	return err
+}
@@ -0,0 +1,114 @@
+package export
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// PegasusExporter writes entries as a Pegasus frontend metadata.txt file.
+type PegasusExporter struct {
+	// Collection is the collection name written in the header; no header
+	// is written if it's empty
+	Collection string
+	// Launch is the collection's launch command, e.g. "retroarch -L core.so {file.path}"
+	Launch string
+	// RomDir is the directory the metadata file is in. ROM paths inside it
+	// are written relative to it.
+	RomDir string
+}
+
+// NewPegasusExporter creates a Pegasus exporter for a collection whose
+// metadata file is in romDir.
+func NewPegasusExporter(collection, romDir string) *PegasusExporter {
+	return &PegasusExporter{Collection: collection, RomDir: romDir}
+}
+
+// Name returns "pegasus".
+func (e *PegasusExporter) Name() string {
+	return "pegasus"
+}
+
+// Begin writes the collection header.
+func (e *PegasusExporter) Begin(w io.Writer) error {
+	if e.Collection == "" {
+		return nil
+	}
+	var b strings.Builder
+	writePegasusField(&b, "collection", e.Collection)
+	writePegasusField(&b, "launch", e.Launch)
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// WriteEntry writes a game block.
+func (e *PegasusExporter) WriteEntry(w io.Writer, index int, entry Entry) error {
+	game := entry.Game
+	if game.Name == "" {
+		return errors.New("game has no name")
+	}
+
+	var b strings.Builder
+	if index > 0 || e.Collection != "" {
+		b.WriteString("\n")
+	}
+	writePegasusField(&b, "game", game.Name)
+	writePegasusField(&b, "file", e.relativePath(entry.Path))
+	writePegasusField(&b, "developer", game.Metadata.Developer)
+	writePegasusField(&b, "publisher", game.Metadata.Publisher)
+	writePegasusField(&b, "genre", strings.Join(game.Metadata.Genres, ", "))
+	writePegasusField(&b, "players", game.Metadata.PlayerCount)
+	if game.Metadata.FirstReleaseDate != nil {
+		writePegasusField(&b, "release", time.Unix(*game.Metadata.FirstReleaseDate, 0).UTC().Format("2006-01-02"))
+	} else if game.Metadata.ReleaseYear != nil {
+		writePegasusField(&b, "release", fmt.Sprintf("%04d", *game.Metadata.ReleaseYear))
+	}
+	if rating := game.Metadata.TotalRating; rating != nil {
+		writePegasusField(&b, "rating", fmt.Sprintf("%d%%", int(math.Round(*rating))))
+	}
+	writePegasusField(&b, "description", game.Summary)
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// End does nothing; metadata files have no footer.
+func (e *PegasusExporter) End(io.Writer) error {
+	return nil
+}
+
+// relativePath returns path relative to the ROM directory, or unchanged if
+// it's outside the ROM directory.
+func (e *PegasusExporter) relativePath(path string) string {
+	if e.RomDir == "" {
+		return filepath.ToSlash(path)
+	}
+	rel, err := filepath.Rel(e.RomDir, path)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return filepath.ToSlash(path)
+	}
+	return filepath.ToSlash(rel)
+}
+
+// writePegasusField writes a "key: value" line, skipping empty values.
+// Multi-line values continue on indented lines, with blank lines written
+// as "." as the format requires.
+func writePegasusField(b *strings.Builder, key, value string) {
+	value = strings.TrimSpace(strings.ReplaceAll(value, "\r\n", "\n"))
+	if value == "" {
+		return
+	}
+	lines := strings.Split(value, "\n")
+	b.WriteString(key + ": " + strings.TrimSpace(lines[0]) + "\n")
+	for _, line := range lines[1:] {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			line = "."
+		}
+		b.WriteString("  " + line + "\n")
+	}
+}
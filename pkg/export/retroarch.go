@@ -0,0 +1,111 @@
+package export
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// detect tells RetroArch to pick the core or CRC itself.
+const detect = "DETECT"
+
+// RetroArchExporter writes entries as a RetroArch playlist (.lpl).
+type RetroArchExporter struct {
+	// DBName is the database the playlist belongs to, which RetroArch uses
+	// to find thumbnails, e.g. "Nintendo - Super Nintendo Entertainment System.lpl"
+	DBName string
+	// CorePath is the core games are launched with; empty lets RetroArch ask
+	CorePath string
+	// CoreName is the display name of the core
+	CoreName string
+}
+
+// NewRetroArchExporter creates a RetroArch playlist exporter for a database.
+func NewRetroArchExporter(dbName string) *RetroArchExporter {
+	return &RetroArchExporter{DBName: dbName}
+}
+
+// retroArchHeader is the playlist fields that precede the items.
+type retroArchHeader struct {
+	Version            string `json:"version"`
+	DefaultCorePath    string `json:"default_core_path"`
+	DefaultCoreName    string `json:"default_core_name"`
+	LabelDisplayMode   int    `json:"label_display_mode"`
+	RightThumbnailMode int    `json:"right_thumbnail_mode"`
+	LeftThumbnailMode  int    `json:"left_thumbnail_mode"`
+	SortMode           int    `json:"sort_mode"`
+}
+
+// retroArchItem is a playlist entry.
+type retroArchItem struct {
+	Path     string `json:"path"`
+	Label    string `json:"label"`
+	CorePath string `json:"core_path"`
+	CoreName string `json:"core_name"`
+	CRC32    string `json:"crc32"`
+	DBName   string `json:"db_name"`
+}
+
+// Name returns "retroarch".
+func (e *RetroArchExporter) Name() string {
+	return "retroarch"
+}
+
+// Begin writes the playlist header and opens the item list.
+func (e *RetroArchExporter) Begin(w io.Writer) error {
+	header, err := json.MarshalIndent(retroArchHeader{
+		Version:         "1.5",
+		DefaultCorePath: e.CorePath,
+		DefaultCoreName: e.CoreName,
+	}, "", "  ")
+	if err != nil {
+		return err
+	}
+	// Reopen the header object to add the items to it
+	_, err = fmt.Fprintf(w, "%s,\n  \"items\": [", strings.TrimSuffix(string(header), "\n}"))
+	return err
+}
+
+// WriteEntry writes a single playlist item.
+func (e *RetroArchExporter) WriteEntry(w io.Writer, index int, entry Entry) error {
+	if entry.Game.Name == "" {
+		return errors.New("game has no name")
+	}
+
+	item := retroArchItem{
+		Path:     entry.Path,
+		Label:    entry.Game.Name,
+		CorePath: detect,
+		CoreName: detect,
+		CRC32:    detect,
+		DBName:   e.DBName,
+	}
+	if e.CorePath != "" {
+		item.CorePath, item.CoreName = e.CorePath, e.CoreName
+	}
+	if entry.Hashes != nil && entry.Hashes.CRC32 != "" {
+		item.CRC32 = strings.ToUpper(entry.Hashes.CRC32) + "|crc"
+	}
+
+	data, err := json.MarshalIndent(item, "    ", "  ")
+	if err != nil {
+		return err
+	}
+	separator := "\n    "
+	if index > 0 {
+		separator = "," + separator
+	}
+	if _, err := io.WriteString(w, separator); err != nil {
+		return err
+	}
+	_, err = w.Write(data)
+	return err
+}
+
+// End closes the item list and the playlist.
+func (e *RetroArchExporter) End(w io.Writer) error {
+	_, err := io.WriteString(w, "\n  ]\n}\n")
+	return err
+}
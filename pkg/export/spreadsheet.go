@@ -0,0 +1,241 @@
+package export
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/csv"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/josegonzalez/retro-metadata/pkg/filename"
+	"github.com/josegonzalez/retro-metadata/pkg/platform"
+)
+
+// SpreadsheetColumns are the columns of collection spreadsheets.
+var SpreadsheetColumns = []string{
+	"Title", "Platform", "Region", "Year", "Developer", "Genres", "Rating",
+	"Completion Time (Hours)", "Cover URL", "File",
+}
+
+// numericColumns are the indexes of the SpreadsheetColumns holding numbers.
+var numericColumns = map[int]bool{3: true, 6: true, 7: true}
+
+// spreadsheetRow returns an entry's cells in SpreadsheetColumns order.
+// Cells without a value are empty.
+func spreadsheetRow(entry Entry) ([]string, error) {
+	game := entry.Game
+	if game.Name == "" {
+		return nil, errors.New("game has no name")
+	}
+
+	var regions []string
+	for _, region := range filename.ExtractRegions(filepath.Base(entry.Path)) {
+		regions = append(regions, strings.ToUpper(string(region)))
+	}
+
+	var year, rating, hours string
+	if game.Metadata.ReleaseYear != nil {
+		year = strconv.Itoa(*game.Metadata.ReleaseYear)
+	} else if game.Metadata.FirstReleaseDate != nil {
+		year = strconv.Itoa(time.Unix(*game.Metadata.FirstReleaseDate, 0).UTC().Year())
+	}
+	if game.Metadata.TotalRating != nil {
+		rating = strconv.Itoa(int(math.Round(*game.Metadata.TotalRating)))
+	}
+	// HowLongToBeat's main story time, in hours
+	if main, ok := game.Metadata.RawData["main_story"].(float64); ok && main > 0 {
+		hours = strconv.FormatFloat(math.Round(main*10)/10, 'f', -1, 64)
+	}
+
+	return []string{
+		game.Name,
+		platformName(entry),
+		strings.Join(regions, ", "),
+		year,
+		game.Metadata.Developer,
+		strings.Join(game.Metadata.Genres, ", "),
+		rating,
+		hours,
+		game.Artwork.CoverURL,
+		filepath.ToSlash(entry.Path),
+	}, nil
+}
+
+// platformName returns the name of the platform an entry's ROM is for,
+// detected from its path, or the game's only platform.
+func platformName(entry Entry) string {
+	if slug := platform.DetectFromPath(entry.Path); slug.IsValid() {
+		return slug.Name()
+	}
+	if platforms := entry.Game.Metadata.Platforms; len(platforms) == 1 {
+		return platforms[0].Name
+	}
+	return ""
+}
+
+// CSVExporter writes entries as a CSV collection spreadsheet with the
+// SpreadsheetColumns.
+type CSVExporter struct {
+	// Comma is the field delimiter (0 = ',')
+	Comma rune
+}
+
+// NewCSVExporter creates a comma-separated CSV exporter.
+func NewCSVExporter() *CSVExporter {
+	return &CSVExporter{}
+}
+
+// Name returns "csv".
+func (e *CSVExporter) Name() string {
+	return "csv"
+}
+
+// Begin writes the header row.
+func (e *CSVExporter) Begin(w io.Writer) error {
+	return e.write(w, SpreadsheetColumns)
+}
+
+// WriteEntry writes a row.
+func (e *CSVExporter) WriteEntry(w io.Writer, _ int, entry Entry) error {
+	row, err := spreadsheetRow(entry)
+	if err != nil {
+		return err
+	}
+	return e.write(w, row)
+}
+
+// End does nothing; CSV files have no footer.
+func (e *CSVExporter) End(io.Writer) error {
+	return nil
+}
+
+// write writes a CSV record.
+func (e *CSVExporter) write(w io.Writer, record []string) error {
+	writer := csv.NewWriter(w)
+	if e.Comma != 0 {
+		writer.Comma = e.Comma
+	}
+	if err := writer.Write(record); err != nil {
+		return err
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// XLSXExporter writes entries as an Excel workbook with one sheet, with the
+// SpreadsheetColumns. Rows are kept until End writes the workbook, as the
+// workbook is a zip archive.
+type XLSXExporter struct {
+	// SheetName is the worksheet name (empty = "Collection")
+	SheetName string
+
+	rows bytes.Buffer
+	row  int
+}
+
+// NewXLSXExporter creates an XLSX exporter.
+func NewXLSXExporter() *XLSXExporter {
+	return &XLSXExporter{}
+}
+
+// Name returns "xlsx".
+func (e *XLSXExporter) Name() string {
+	return "xlsx"
+}
+
+// Begin starts the rows with the header row.
+func (e *XLSXExporter) Begin(io.Writer) error {
+	e.rows.Reset()
+	e.row = 0
+	e.writeRow(SpreadsheetColumns, nil)
+	return nil
+}
+
+// WriteEntry adds a row.
+func (e *XLSXExporter) WriteEntry(_ io.Writer, _ int, entry Entry) error {
+	row, err := spreadsheetRow(entry)
+	if err != nil {
+		return err
+	}
+	e.writeRow(row, numericColumns)
+	return nil
+}
+
+// writeRow adds a row of cells, as numbers in the numeric columns and
+// inline strings otherwise.
+func (e *XLSXExporter) writeRow(cells []string, numeric map[int]bool) {
+	e.row++
+	fmt.Fprintf(&e.rows, `<row r="%d">`, e.row)
+	for i, value := range cells {
+		if value == "" {
+			continue
+		}
+		ref := columnName(i) + strconv.Itoa(e.row)
+		if numeric[i] {
+			fmt.Fprintf(&e.rows, `<c r="%s"><v>%s</v></c>`, ref, value)
+			continue
+		}
+		fmt.Fprintf(&e.rows, `<c r="%s" t="inlineStr"><is><t xml:space="preserve">`, ref)
+		_ = xml.EscapeText(&e.rows, []byte(value))
+		e.rows.WriteString(`</t></is></c>`)
+	}
+	e.rows.WriteString(`</row>`)
+}
+
+// End writes the workbook.
+func (e *XLSXExporter) End(w io.Writer) error {
+	sheetName := e.SheetName
+	if sheetName == "" {
+		sheetName = "Collection"
+	}
+	var escapedName bytes.Buffer
+	_ = xml.EscapeText(&escapedName, []byte(sheetName))
+
+	parts := []struct{ name, content string }{
+		{"[Content_Types].xml", xml.Header + `<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">` +
+			`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>` +
+			`<Default Extension="xml" ContentType="application/xml"/>` +
+			`<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>` +
+			`<Override PartName="/xl/worksheets/sheet1.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>` +
+			`</Types>`},
+		{"_rels/.rels", xml.Header + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+			`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>` +
+			`</Relationships>`},
+		{"xl/workbook.xml", xml.Header + `<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">` +
+			`<sheets><sheet name="` + escapedName.String() + `" sheetId="1" r:id="rId1"/></sheets></workbook>`},
+		{"xl/_rels/workbook.xml.rels", xml.Header + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+			`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/>` +
+			`</Relationships>`},
+		{"xl/worksheets/sheet1.xml", xml.Header + `<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">` +
+			`<sheetData>` + e.rows.String() + `</sheetData></worksheet>`},
+	}
+
+	archive := zip.NewWriter(w)
+	for _, part := range parts {
+		f, err := archive.Create(part.name)
+		if err != nil {
+			return err
+		}
+		if _, err := io.WriteString(f, part.content); err != nil {
+			return err
+		}
+	}
+	return archive.Close()
+}
+
+// columnName returns the spreadsheet name of a zero-based column index,
+// e.g. "A" for 0 and "AA" for 26.
+func columnName(index int) string {
+	name := ""
+	for index++; index > 0; index = (index - 1) / 26 {
+		name = string(rune('A'+(index-1)%26)) + name
+	}
+	return name
+}
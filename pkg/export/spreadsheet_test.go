@@ -0,0 +1,106 @@
+package export
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/csv"
+	"io"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/josegonzalez/retro-metadata/pkg/retrometadata"
+)
+
+func spreadsheetEntries(romDir string) []Entry {
+	entry := gamelistEntry(filepath.Join(romDir, "snes"))
+	entry.Game.Artwork.CoverURL = "https://example.com/cover.jpg"
+	entry.Game.Metadata.RawData = map[string]any{"main_story": 7.53}
+	return []Entry{
+		entry,
+		{Path: filepath.Join(romDir, "Unknown.bin"), Game: &retrometadata.GameResult{Name: `Tom & "Jerry"`}},
+		{Path: filepath.Join(romDir, "Nameless.bin"), Game: &retrometadata.GameResult{}},
+	}
+}
+
+func TestCSVExporter(t *testing.T) {
+	romDir := t.TempDir()
+	var buf bytes.Buffer
+	report, err := Write(context.Background(), &buf, NewCSVExporter(), spreadsheetEntries(romDir))
+	if err != nil || report.Written != 2 || len(report.Skipped) != 1 {
+		t.Fatalf("Write() = %+v, %v", report, err)
+	}
+
+	records, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatalf("reading CSV: %v", err)
+	}
+	if len(records) != 3 || strings.Join(records[0], "|") != strings.Join(SpreadsheetColumns, "|") {
+		t.Fatalf("records = %q", records)
+	}
+	want := []string{
+		"Super Metroid", "Super Nintendo", "US", "1994", "Nintendo R&D1",
+		"Action, Platform", "87", "7.5", "https://example.com/cover.jpg",
+		filepath.ToSlash(filepath.Join(romDir, "snes", "Super Metroid (USA).sfc")),
+	}
+	if got := strings.Join(records[1], "|"); got != strings.Join(want, "|") {
+		t.Errorf("row = %s, want %s", got, strings.Join(want, "|"))
+	}
+	if records[2][0] != `Tom & "Jerry"` || records[2][1] != "" {
+		t.Errorf("row without metadata = %q", records[2])
+	}
+}
+
+func TestXLSXExporter(t *testing.T) {
+	romDir := t.TempDir()
+	var buf bytes.Buffer
+	report, err := Write(context.Background(), &buf, NewXLSXExporter(), spreadsheetEntries(romDir))
+	if err != nil || report.Written != 2 {
+		t.Fatalf("Write() = %+v, %v", report, err)
+	}
+
+	archive, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatalf("opening workbook: %v", err)
+	}
+	var sheet string
+	for _, f := range archive.File {
+		if f.Name != "xl/worksheets/sheet1.xml" {
+			continue
+		}
+		r, err := f.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, _ := io.ReadAll(r)
+		r.Close()
+		sheet = string(data)
+	}
+	if len(archive.File) != 5 || sheet == "" {
+		t.Fatalf("workbook has %d parts, sheet %q", len(archive.File), sheet)
+	}
+
+	for _, cell := range []string{
+		`<c r="J1" t="inlineStr"><is><t xml:space="preserve">File</t></is></c>`,
+		`<c r="A2" t="inlineStr"><is><t xml:space="preserve">Super Metroid</t></is></c>`,
+		`<c r="D2"><v>1994</v></c>`,
+		`<c r="H2"><v>7.5</v></c>`,
+		`<t xml:space="preserve">Tom &amp; &#34;Jerry&#34;</t>`,
+	} {
+		if !strings.Contains(sheet, cell) {
+			t.Errorf("sheet is missing %s:\n%s", cell, sheet)
+		}
+	}
+	if strings.Contains(sheet, `r="4"`) {
+		t.Errorf("sheet has a row for the skipped entry:\n%s", sheet)
+	}
+}
+
+func TestColumnName(t *testing.T) {
+	for index, want := range map[int]string{0: "A", 25: "Z", 26: "AA", 701: "ZZ", 702: "AAA"} {
+		if got := columnName(index); got != want {
+			t.Errorf("columnName(%d) = %q, want %q", index, got, want)
+		}
+	}
+}
@@ -0,0 +1,355 @@
+package filename
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	// bracketTagPattern matches tags in square brackets
+	bracketTagPattern = regexp.MustCompile(`\[([^\]]+)\]`)
+
+	// parenTagPattern matches tags in parentheses
+	parenTagPattern = regexp.MustCompile(`\(([^\)]+)\)`)
+
+	// goodToolsVersionPattern matches GoodTools version tags like (V1.1), (PRG1) or (REV 1.0)
+	goodToolsVersionPattern = regexp.MustCompile(`(?i)^(v\s?\d[\w.]*|prg\s?\d+|rev\s?[\w.]+)$`)
+
+	// tosecVersionPattern matches the version that follows a TOSEC title, like "v1.1" or "Rev 1"
+	tosecVersionPattern = regexp.MustCompile(`(?i)\s+(v\d[\w.]*|rev\s[\w.]+)$`)
+
+	// tosecDatePattern matches TOSEC dates like 1986, 199x, 1990-05 or 1990-05-12
+	tosecDatePattern = regexp.MustCompile(`^(19|20)[\dx]{2}(-[\dx]{2}(-[\dx]{2})?)?$`)
+
+	// tosecCountryPattern matches TOSEC country codes like US or US-EU
+	tosecCountryPattern = regexp.MustCompile(`^[A-Z]{2}(-[A-Z]{2})*$`)
+
+	// tosecLanguagePattern matches TOSEC language codes like en or en-de
+	tosecLanguagePattern = regexp.MustCompile(`^[a-z]{2}(-[a-z]{2})*$`)
+
+	// tosecMediaPattern matches TOSEC media tags like (Disc 1 of 2) or (Side A)
+	tosecMediaPattern = regexp.MustCompile(`(?i)^(disc|disk|side|tape|file|part)\s`)
+
+	// dumpFlagPatterns classify GoodTools and TOSEC dump flags
+	verifiedFlagPattern    = regexp.MustCompile(`^!$`)
+	badDumpFlagPattern     = regexp.MustCompile(`^b\d*(\s.*)?$`)
+	overdumpFlagPattern    = regexp.MustCompile(`^o\d*(\s.*)?$`)
+	underdumpFlagPattern   = regexp.MustCompile(`^u\d*(\s.*)?$`)
+	hackFlagPattern        = regexp.MustCompile(`^h[\dA-Z]*(\s.*)?$`)
+	alternateFlagPattern   = regexp.MustCompile(`^a\d*(\s.*)?$`)
+	fixedFlagPattern       = regexp.MustCompile(`^f\d*(\s.*)?$`)
+	trainerFlagPattern     = regexp.MustCompile(`^t\d*(\s.*)?$`)
+	translationFlagPattern = regexp.MustCompile(`^(T[+-].*|tr(\s.*)?)$`)
+	pirateFlagPattern      = regexp.MustCompile(`^p\d*(\s.*)?$`)
+	crackedFlagPattern     = regexp.MustCompile(`^cr(\s.*)?$`)
+	modifiedFlagPattern    = regexp.MustCompile(`^m\d*(\s.*)?$`)
+)
+
+// goodToolsRegions maps GoodTools country codes to normalized region codes.
+var goodToolsRegions = map[string]string{
+	"U":  "us",
+	"4":  "us",
+	"E":  "eu",
+	"J":  "jp",
+	"1":  "jp",
+	"W":  "wor",
+	"A":  "au",
+	"B":  "br",
+	"C":  "cn",
+	"F":  "fr",
+	"G":  "de",
+	"H":  "nl",
+	"NL": "nl",
+	"I":  "it",
+	"K":  "kr",
+	"S":  "es",
+	"Sw": "se",
+	"HK": "as",
+}
+
+// tosecCopyrights are the TOSEC copyright status codes.
+var tosecCopyrights = map[string]bool{
+	"CW": true, "CW-R": true, "FW": true, "GW": true, "GW-R": true,
+	"LW": true, "PD": true, "SW": true, "SW-R": true,
+}
+
+// tosecDevStatuses are the TOSEC development status tags.
+var tosecDevStatuses = map[string]bool{
+	"alpha": true, "beta": true, "preview": true, "pre-release": true, "proto": true,
+}
+
+// tosecVideoModes are the TOSEC video mode tags.
+var tosecVideoModes = map[string]bool{
+	"CGA": true, "EGA": true, "HGC": true, "MCGA": true, "MDA": true, "NTSC": true,
+	"NTSC-PAL": true, "PAL": true, "PAL-60": true, "PAL-NTSC": true, "SVGA": true, "VGA": true, "XGA": true,
+}
+
+// DumpFlags contains the dump quality flags from GoodTools and TOSEC filenames.
+type DumpFlags struct {
+	// Verified indicates a verified good dump [!]
+	Verified bool `json:"verified"`
+	// BadDump indicates a bad dump [b], [b1]
+	BadDump bool `json:"bad_dump"`
+	// Overdump indicates an overdump [o], [o1]
+	Overdump bool `json:"overdump"`
+	// Underdump indicates an underdump [u]
+	Underdump bool `json:"underdump"`
+	// Hack indicates a hack [h], [h1C], [hI]
+	Hack bool `json:"hack"`
+	// Alternate indicates an alternate dump [a], [a1]
+	Alternate bool `json:"alternate"`
+	// Fixed indicates a fixed dump [f], [f1]
+	Fixed bool `json:"fixed"`
+	// Trainer indicates a trainer was added [t], [t1]
+	Trainer bool `json:"trainer"`
+	// Translated indicates a translation [T+Eng], [T-Ger], [tr fr]
+	Translated bool `json:"translated"`
+	// Pirate indicates a pirate copy [p], [p1]
+	Pirate bool `json:"pirate"`
+	// Cracked indicates a cracked dump [cr]
+	Cracked bool `json:"cracked"`
+	// Modified indicates a modified dump [m]
+	Modified bool `json:"modified"`
+	// Codes is every recognized flag code, in filename order
+	Codes []string `json:"codes"`
+}
+
+// IsGoodDump returns true if the flags indicate an unmodified, complete dump.
+func (f DumpFlags) IsGoodDump() bool {
+	return !f.BadDump && !f.Overdump && !f.Underdump && !f.Hack && !f.Fixed &&
+		!f.Trainer && !f.Translated && !f.Pirate && !f.Cracked && !f.Modified
+}
+
+// ParseDumpFlags extracts the GoodTools/TOSEC dump flags from the bracketed tags of a filename.
+func ParseDumpFlags(filename string) DumpFlags {
+	var flags DumpFlags
+	for _, match := range bracketTagPattern.FindAllStringSubmatch(filename, -1) {
+		code := strings.TrimSpace(match[1])
+		if parseDumpFlag(code, &flags) {
+			flags.Codes = append(flags.Codes, code)
+		}
+	}
+	return flags
+}
+
+// parseDumpFlag sets the flag for a single code and reports whether it was recognized.
+func parseDumpFlag(code string, flags *DumpFlags) bool {
+	switch {
+	case verifiedFlagPattern.MatchString(code):
+		flags.Verified = true
+	case badDumpFlagPattern.MatchString(code):
+		flags.BadDump = true
+	case overdumpFlagPattern.MatchString(code):
+		flags.Overdump = true
+	case underdumpFlagPattern.MatchString(code):
+		flags.Underdump = true
+	case translationFlagPattern.MatchString(code):
+		flags.Translated = true
+	case crackedFlagPattern.MatchString(code):
+		flags.Cracked = true
+	case hackFlagPattern.MatchString(code):
+		flags.Hack = true
+	case alternateFlagPattern.MatchString(code):
+		flags.Alternate = true
+	case fixedFlagPattern.MatchString(code):
+		flags.Fixed = true
+	case trainerFlagPattern.MatchString(code):
+		flags.Trainer = true
+	case pirateFlagPattern.MatchString(code):
+		flags.Pirate = true
+	case modifiedFlagPattern.MatchString(code):
+		flags.Modified = true
+	default:
+		return false
+	}
+	return true
+}
+
+// ParsedGoodToolsFilename contains components parsed from a GoodTools filename.
+type ParsedGoodToolsFilename struct {
+	// Name is the cleaned game name
+	Name string `json:"name"`
+	// Region is the normalized region code (us, eu, jp, etc.)
+	Region string `json:"region"`
+	// Version is the version tag if found (e.g., "V1.1", "PRG1")
+	Version string `json:"version"`
+	// Unlicensed indicates an unlicensed release (Unl)
+	Unlicensed bool `json:"unlicensed"`
+	// PublicDomain indicates a public domain release (PD)
+	PublicDomain bool `json:"public_domain"`
+	// Dump contains the dump quality flags
+	Dump DumpFlags `json:"dump"`
+	// Extension is the file extension
+	Extension string `json:"extension"`
+	// Tags is all extracted tags
+	Tags []string `json:"tags"`
+}
+
+// ParseGoodToolsFilename parses a GoodTools naming convention filename.
+// GoodTools filenames follow the format: Title (Country) (Version) [Flags],
+// e.g. "Super Mario Bros. 3 (U) (PRG1) [!].nes".
+func ParseGoodToolsFilename(filename string) ParsedGoodToolsFilename {
+	parsed := ParsedGoodToolsFilename{
+		Name:      CleanFilename(filename, true),
+		Extension: GetFileExtension(filename),
+		Tags:      ExtractTags(filename),
+		Dump:      ParseDumpFlags(filename),
+	}
+
+	for _, match := range parenTagPattern.FindAllStringSubmatch(filename, -1) {
+		tag := strings.TrimSpace(match[1])
+		switch {
+		case strings.EqualFold(tag, "unl"):
+			parsed.Unlicensed = true
+		case strings.EqualFold(tag, "pd"):
+			parsed.PublicDomain = true
+		case parsed.Version == "" && goodToolsVersionPattern.MatchString(tag):
+			parsed.Version = tag
+		case parsed.Region == "":
+			parsed.Region = goodToolsRegion(tag)
+		}
+	}
+
+	if parsed.Region == "" {
+		parsed.Region = ExtractRegion(filename)
+	}
+
+	return parsed
+}
+
+// goodToolsRegion converts a GoodTools country code to a region code.
+// Multi-country codes like (JU) or (UE) use the first recognized country.
+func goodToolsRegion(code string) string {
+	if region, ok := goodToolsRegions[code]; ok {
+		return region
+	}
+	if len(code) > 3 {
+		return ""
+	}
+	for _, c := range code {
+		if region, ok := goodToolsRegions[string(c)]; ok {
+			return region
+		}
+	}
+	return ""
+}
+
+// ParsedTOSECFilename contains components parsed from a TOSEC filename.
+type ParsedTOSECFilename struct {
+	// Name is the cleaned game name, without version
+	Name string `json:"name"`
+	// Version is the version following the title (e.g., "v1.1", "Rev 1")
+	Version string `json:"version"`
+	// Demo is the demo type if the file is a demo (e.g., "demo-playable")
+	Demo string `json:"demo"`
+	// Date is the release date as written (e.g., "1986", "199x", "1990-05-12")
+	Date string `json:"date"`
+	// Year is the release year, or 0 if unknown or partial
+	Year int `json:"year"`
+	// Publisher is the publisher, or empty if unknown
+	Publisher string `json:"publisher"`
+	// System is the system or sub-system (e.g., "A500")
+	System string `json:"system"`
+	// Video is the video mode (e.g., "PAL", "NTSC")
+	Video string `json:"video"`
+	// Region is the normalized region code of the first country
+	Region string `json:"region"`
+	// Countries is every country code, lowercased
+	Countries []string `json:"countries"`
+	// Languages is every language code
+	Languages []string `json:"languages"`
+	// Copyright is the copyright status (e.g., "PD", "SW")
+	Copyright string `json:"copyright"`
+	// DevStatus is the development status (alpha, beta, preview, pre-release, proto)
+	DevStatus string `json:"dev_status"`
+	// Media is the media tag (e.g., "Disc 1 of 2", "Side A")
+	Media string `json:"media"`
+	// Dump contains the dump quality flags
+	Dump DumpFlags `json:"dump"`
+	// Extension is the file extension
+	Extension string `json:"extension"`
+	// Tags is all extracted tags
+	Tags []string `json:"tags"`
+}
+
+// ParseTOSECFilename parses a TOSEC naming convention filename.
+// TOSEC filenames follow the format:
+// Title version (demo) (Date)(Publisher)(System)(Video)(Country)(Language)(Copyright)(Devstatus)(Media)[Flags],
+// e.g. "Legend of Zelda, The v1.1 (1986)(Nintendo)(US)[!].nes".
+func ParseTOSECFilename(filename string) ParsedTOSECFilename {
+	base := extensionPattern.ReplaceAllString(baseName(filename), "")
+
+	parsed := ParsedTOSECFilename{
+		Extension: GetFileExtension(filename),
+		Tags:      ExtractTags(filename),
+		Dump:      ParseDumpFlags(base),
+	}
+
+	title := base
+	if i := strings.IndexAny(base, "(["); i >= 0 {
+		title = base[:i]
+	}
+	title = strings.TrimSpace(title)
+	if match := tosecVersionPattern.FindStringSubmatchIndex(title); match != nil {
+		parsed.Version = title[match[2]:match[3]]
+		title = strings.TrimSpace(title[:match[0]])
+	}
+	parsed.Name = title
+
+	fields := parenTagPattern.FindAllStringSubmatch(base, -1)
+	i := 0
+	if i < len(fields) && strings.HasPrefix(strings.ToLower(fields[i][1]), "demo") {
+		parsed.Demo = fields[i][1]
+		i++
+	}
+	if i < len(fields) && tosecDatePattern.MatchString(fields[i][1]) {
+		parsed.Date = fields[i][1]
+		if year, err := strconv.Atoi(parsed.Date[:4]); err == nil {
+			parsed.Year = year
+		}
+		i++
+		if i < len(fields) {
+			if publisher := fields[i][1]; publisher != "-" {
+				parsed.Publisher = publisher
+			}
+			i++
+		}
+	}
+
+	for _, field := range fields[i:] {
+		tag := field[1]
+		switch {
+		case tosecVideoModes[tag]:
+			parsed.Video = tag
+		case tosecCopyrights[tag]:
+			parsed.Copyright = tag
+		case tosecDevStatuses[strings.ToLower(tag)]:
+			parsed.DevStatus = tag
+		case tosecMediaPattern.MatchString(tag):
+			parsed.Media = tag
+		case parsed.Countries == nil && tosecCountryPattern.MatchString(tag):
+			for _, country := range strings.Split(tag, "-") {
+				parsed.Countries = append(parsed.Countries, strings.ToLower(country))
+			}
+			parsed.Region = parsed.Countries[0]
+			if region, ok := RegionTags[parsed.Region]; ok {
+				parsed.Region = region
+			}
+		case parsed.Languages == nil && tosecLanguagePattern.MatchString(tag):
+			parsed.Languages = strings.Split(tag, "-")
+		case parsed.System == "" && parsed.Countries == nil && parsed.Video == "":
+			parsed.System = tag
+		}
+	}
+
+	return parsed
+}
+
+// baseName returns the last path element, accepting both / and \ separators.
+func baseName(path string) string {
+	if i := strings.LastIndexAny(path, `/\`); i >= 0 {
+		return path[i+1:]
+	}
+	return path
+}
@@ -0,0 +1,83 @@
+package filename
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseDumpFlags(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected DumpFlags
+	}{
+		{"Super Mario Bros. 3 (U) (PRG1) [!].nes", DumpFlags{Verified: true, Codes: []string{"!"}}},
+		{"Sonic (E) [b1][o2].md", DumpFlags{BadDump: true, Overdump: true, Codes: []string{"b1", "o2"}}},
+		{"Zelda (J) [T+Eng1.0][h1C].sfc", DumpFlags{Translated: true, Hack: true, Codes: []string{"T+Eng1.0", "h1C"}}},
+		{"Elite (1985)(Acornsoft)[cr][t +2][a].ssd", DumpFlags{Cracked: true, Trainer: true, Alternate: true, Codes: []string{"cr", "t +2", "a"}}},
+		{"Game [BIOS].bin", DumpFlags{}},
+	}
+
+	for _, tt := range tests {
+		result := ParseDumpFlags(tt.input)
+		if !reflect.DeepEqual(result, tt.expected) {
+			t.Errorf("ParseDumpFlags(%q) = %+v, want %+v", tt.input, result, tt.expected)
+		}
+	}
+
+	if !ParseDumpFlags("Game (U) [!][a1].nes").IsGoodDump() {
+		t.Error("Expected verified alternate dump to be a good dump")
+	}
+	if ParseDumpFlags("Game (U) [b1].nes").IsGoodDump() {
+		t.Error("Expected bad dump not to be a good dump")
+	}
+}
+
+func TestParseGoodToolsFilename(t *testing.T) {
+	result := ParseGoodToolsFilename("Super Mario Bros. 3 (U) (PRG1) [!].nes")
+	if result.Name != "Super Mario Bros. 3" || result.Region != "us" || result.Version != "PRG1" || !result.Dump.Verified || result.Extension != "nes" {
+		t.Errorf("ParseGoodToolsFilename() = %+v", result)
+	}
+
+	result = ParseGoodToolsFilename("Tetris (JU) (V1.1) [b2].gb")
+	if result.Region != "jp" || result.Version != "V1.1" || !result.Dump.BadDump {
+		t.Errorf("ParseGoodToolsFilename() = %+v", result)
+	}
+
+	result = ParseGoodToolsFilename("Action 52 (Unl) [o1].nes")
+	if !result.Unlicensed || !result.Dump.Overdump || result.Region != "" {
+		t.Errorf("ParseGoodToolsFilename() = %+v", result)
+	}
+}
+
+func TestParseTOSECFilename(t *testing.T) {
+	result := ParseTOSECFilename("Legend of Zelda, The v1.1 (1986)(Nintendo)(US)[!].nes")
+	expected := ParsedTOSECFilename{
+		Name:      "Legend of Zelda, The",
+		Version:   "v1.1",
+		Date:      "1986",
+		Year:      1986,
+		Publisher: "Nintendo",
+		Region:    "us",
+		Countries: []string{"us"},
+		Dump:      DumpFlags{Verified: true, Codes: []string{"!"}},
+		Extension: "nes",
+		Tags:      []string{"1986", "Nintendo", "US", "!"},
+	}
+	if !reflect.DeepEqual(result, expected) {
+		t.Errorf("ParseTOSECFilename() = %+v, want %+v", result, expected)
+	}
+
+	result = ParseTOSECFilename("/dats/Lemmings (demo-playable) (199x)(-)(A500)(PAL)(DE-FR)(de-fr)(PD)(beta)(Disk 1 of 2)[cr][b].adf")
+	if result.Name != "Lemmings" || result.Demo != "demo-playable" || result.Date != "199x" || result.Year != 0 || result.Publisher != "" {
+		t.Errorf("ParseTOSECFilename() header fields = %+v", result)
+	}
+	if result.System != "A500" || result.Video != "PAL" || result.Region != "de" || !reflect.DeepEqual(result.Countries, []string{"de", "fr"}) {
+		t.Errorf("ParseTOSECFilename() system fields = %+v", result)
+	}
+	if !reflect.DeepEqual(result.Languages, []string{"de", "fr"}) || result.Copyright != "PD" || result.DevStatus != "beta" || result.Media != "Disk 1 of 2" {
+		t.Errorf("ParseTOSECFilename() trailing fields = %+v", result)
+	}
+	if !result.Dump.Cracked || !result.Dump.BadDump {
+		t.Errorf("ParseTOSECFilename() dump flags = %+v", result.Dump)
+	}
+}
@@ -4,7 +4,11 @@ package filename
 import (
 	"path/filepath"
 	"regexp"
+	"strconv"
 	"strings"
+	"unicode"
+
+	"github.com/josegonzalez/retro-metadata/pkg/retrometadata"
 )
 
 // RegionTags maps region indicators to normalized region codes.
@@ -66,6 +70,10 @@ var (
 	// extensionPattern matches file extensions
 	extensionPattern = regexp.MustCompile(`\.([a-zA-Z0-9]+)$`)
 
+	// discPattern matches disc tags like (Disc 1), (Disc 2 of 3), [CD1] or
+	// (Disk A). Only discs and disks are lettered, so (CDi) isn't a disc.
+	discPattern = regexp.MustCompile(`(?i)\s*[\(\[](?:(?:disc|disk)\s*([0-9]+|[a-z])|cd\s*([0-9]+))(?:\s*of\s*([0-9]+))?[\)\]]`)
+
 	// demoTags are tags that indicate a demo/prototype file
 	demoTags = map[string]bool{
 		"demo":      true,
@@ -78,6 +86,12 @@ var (
 		"alpha":     true,
 	}
 
+	// versionTagPattern matches version tags like "Rev 1", "Rev A", "v1.1" or "Version 2"
+	versionTagPattern = regexp.MustCompile(`^(rev\s|v\d|version\s)`)
+
+	// revisionPattern extracts the revision from a "Rev" tag
+	revisionPattern = regexp.MustCompile(`(?i)^rev\s+([\w.]+)$`)
+
 	// unlicensedTags are tags that indicate an unlicensed game
 	unlicensedTags = map[string]bool{
 		"unl":        true,
@@ -129,6 +143,35 @@ func ExtractRegion(filename string) string {
 	return ""
 }
 
+// ExtractRegions extracts every region from a filename, in the order they appear.
+// A tag like "(USA, Europe)" yields [us, eu]; tags containing anything other
+// than region names are ignored.
+func ExtractRegions(filename string) []retrometadata.Region {
+	var regions []retrometadata.Region
+	seen := make(map[retrometadata.Region]bool)
+
+	for _, tag := range ExtractTags(filename) {
+		var tagRegions []retrometadata.Region
+		for _, part := range strings.Split(tag, ",") {
+			region, ok := retrometadata.ParseRegion(part)
+			if !ok {
+				tagRegions = nil
+				break
+			}
+			tagRegions = append(tagRegions, region)
+		}
+
+		for _, region := range tagRegions {
+			if !seen[region] {
+				seen[region] = true
+				regions = append(regions, region)
+			}
+		}
+	}
+
+	return regions
+}
+
 // CleanFilename cleans a filename by removing tags and optionally the extension.
 func CleanFilename(filename string, removeExtension bool) string {
 	// Get just the filename if a path was provided
@@ -160,63 +203,178 @@ func CleanFilename(filename string, removeExtension bool) string {
 	return strings.TrimSpace(name)
 }
 
+// Development statuses reported in ParsedFilename.DevStatus.
+const (
+	DevStatusAlpha   = "alpha"
+	DevStatusBeta    = "beta"
+	DevStatusProto   = "proto"
+	DevStatusDemo    = "demo"
+	DevStatusSample  = "sample"
+	DevStatusPreview = "preview"
+	DevStatusTrial   = "trial"
+)
+
 // ParsedFilename contains components parsed from a No-Intro filename.
 type ParsedFilename struct {
 	// Name is the cleaned game name
 	Name string `json:"name"`
 	// Region is the normalized region code (us, eu, jp, etc.)
 	Region string `json:"region"`
+	// Regions is every region in the filename (e.g., "USA, Europe" yields [us, eu])
+	Regions []retrometadata.Region `json:"regions,omitempty"`
 	// Version is the version tag if found (e.g., "Rev 1", "v1.1")
 	Version string `json:"version"`
-	// Languages is a list of language tags
-	Languages []string `json:"languages"`
+	// Revision is the revision from a "Rev" tag (e.g., "1" or "A"), if any
+	Revision string `json:"revision,omitempty"`
+	// Languages is a list of normalized language codes (e.g., "en", "fr")
+	Languages []retrometadata.Language `json:"languages"`
+	// DevStatus is the development status (beta, proto, demo, sample, etc.), empty for final releases
+	DevStatus string `json:"dev_status,omitempty"`
+	// Licensed is false for unlicensed, pirate and hacked releases
+	Licensed bool `json:"licensed"`
+	// Disc is the disc number, or 0 if the filename has no disc tag
+	Disc int `json:"disc,omitempty"`
+	// DiscTotal is the number of discs in the set, if the filename includes it
+	DiscTotal int `json:"disc_total,omitempty"`
+	// Dump is the set of dump flags from bracketed tags
+	Dump DumpFlags `json:"dump"`
 	// Extension is the file extension
 	Extension string `json:"extension"`
 	// Tags is all extracted tags
 	Tags []string `json:"tags"`
 }
 
+// IsPrerelease returns true if the file is a demo, prototype, beta, or other pre-release.
+func (p ParsedFilename) IsPrerelease() bool {
+	return p.DevStatus != ""
+}
+
 // ParseNoIntroFilename parses a No-Intro naming convention filename.
 // No-Intro filenames follow the format: Title (Region) (Language) (Version) (Other Tags)
 func ParseNoIntroFilename(filename string) ParsedFilename {
-	name := CleanFilename(filename, true)
 	tags := ExtractTags(filename)
-	region := ExtractRegion(filename)
-	extension := GetFileExtension(filename)
+	parsed := ParsedFilename{
+		Name:      CleanFilename(filename, true),
+		Region:    ExtractRegion(filename),
+		Regions:   ExtractRegions(filename),
+		Licensed:  true,
+		Dump:      ParseDumpFlags(filename),
+		Extension: GetFileExtension(filename),
+		Tags:      tags,
+	}
+
+	if disc, ok := ParseDiscInfo(filename); ok {
+		parsed.Disc = disc.Number
+		parsed.DiscTotal = disc.Total
+	}
 
-	// Try to identify version
-	var version string
 	for _, tag := range tags {
-		tagLower := strings.ToLower(tag)
-		if strings.HasPrefix(tagLower, "rev ") ||
-			strings.HasPrefix(tagLower, "v") ||
-			strings.HasPrefix(tagLower, "version") {
-			version = tag
-			break
+		tagLower := strings.ToLower(strings.TrimSpace(tag))
+
+		if parsed.Version == "" && versionTagPattern.MatchString(tagLower) {
+			parsed.Version = tag
+			if match := revisionPattern.FindStringSubmatch(tag); match != nil {
+				parsed.Revision = match[1]
+			}
+		}
+
+		if parsed.DevStatus == "" {
+			parsed.DevStatus = devStatus(tagLower)
+		}
+
+		if unlicensedTags[tagLower] {
+			parsed.Licensed = false
+		}
+
+		if languages, ok := parseLanguages(tagLower); ok {
+			parsed.Languages = append(parsed.Languages, languages...)
 		}
 	}
 
-	// Try to identify languages
-	languageCodes := map[string]bool{
-		"en": true, "ja": true, "de": true, "fr": true, "es": true,
-		"it": true, "nl": true, "pt": true, "sv": true, "ko": true, "zh": true,
+	return parsed
+}
+
+// devStatus returns the development status for a tag like "Beta 2" or "Prototype".
+func devStatus(tag string) string {
+	word := tag
+	if i := strings.IndexAny(tag, " -"); i > 0 {
+		word = tag[:i]
+	}
+	if !demoTags[word] {
+		return ""
+	}
+	if word == "prototype" {
+		return DevStatusProto
+	}
+	return word
+}
+
+// parseLanguages splits a language tag like "En,Fr,De" or "En+Ja" into
+// language codes. Returns false if any part isn't a language code.
+func parseLanguages(tag string) ([]retrometadata.Language, bool) {
+	parts := strings.FieldsFunc(tag, func(r rune) bool {
+		return r == ',' || r == '+'
+	})
+	if len(parts) == 0 {
+		return nil, false
 	}
-	var languages []string
-	for _, tag := range tags {
-		tagLower := strings.ToLower(tag)
-		if languageCodes[tagLower] || strings.Contains(tagLower, "+") {
-			languages = append(languages, tag)
+
+	languages := make([]retrometadata.Language, 0, len(parts))
+	for _, part := range parts {
+		part = strings.TrimSpace(part)
+		// No-Intro only uses two letter codes, so "(Spanish)" isn't a language tag
+		language, ok := retrometadata.ParseLanguage(part)
+		if len(part) != 2 || !ok {
+			return nil, false
 		}
+		languages = append(languages, language)
 	}
+	return languages, true
+}
 
-	return ParsedFilename{
-		Name:      name,
-		Region:    region,
-		Version:   version,
-		Languages: languages,
-		Extension: extension,
-		Tags:      tags,
+// DiscInfo contains the disc number parsed from a multi-disc filename.
+type DiscInfo struct {
+	// Number is the disc number, starting at 1
+	Number int `json:"number"`
+	// Total is the number of discs in the set, if the filename includes it
+	Total int `json:"total,omitempty"`
+	// Tag is the original disc tag (e.g., "Disc 1")
+	Tag string `json:"tag"`
+	// BaseName is the filename with the disc tag removed
+	BaseName string `json:"base_name"`
+}
+
+// ParseDiscInfo extracts the disc number from a filename like
+// "Final Fantasy VII (USA) (Disc 1).bin". Lettered discs (Disk A, Disk B)
+// are numbered from 1. Returns false if the filename has no disc tag.
+func ParseDiscInfo(filename string) (DiscInfo, bool) {
+	name := filepath.Base(filename)
+	loc := discPattern.FindStringSubmatchIndex(name)
+	if loc == nil {
+		return DiscInfo{}, false
+	}
+
+	// Disc and disk numbers are the first group, CD numbers the second
+	start, end := loc[2], loc[3]
+	if start < 0 {
+		start, end = loc[4], loc[5]
+	}
+	number := name[start:end]
+	info := DiscInfo{
+		Tag:      strings.Trim(strings.TrimSpace(name[loc[0]:loc[1]]), "()[]"),
+		BaseName: name[:loc[0]] + name[loc[1]:],
 	}
+
+	if n, err := strconv.Atoi(number); err == nil {
+		info.Number = n
+	} else {
+		info.Number = int(unicode.ToLower(rune(number[0]))-'a') + 1
+	}
+	if loc[6] >= 0 {
+		info.Total, _ = strconv.Atoi(name[loc[6]:loc[7]])
+	}
+
+	return info, true
 }
 
 // IsBiosFile checks if a filename appears to be a BIOS file.
@@ -232,23 +390,15 @@ func IsBiosFile(filename string) bool {
 }
 
 // IsDemoFile checks if a filename appears to be a demo, prototype, or beta.
+//
+// Deprecated: Use ParseNoIntroFilename(filename).IsPrerelease() instead.
 func IsDemoFile(filename string) bool {
-	tags := ExtractTags(filename)
-	for _, tag := range tags {
-		if demoTags[strings.ToLower(tag)] {
-			return true
-		}
-	}
-	return false
+	return ParseNoIntroFilename(filename).IsPrerelease()
 }
 
 // IsUnlicensed checks if a filename indicates an unlicensed game.
+//
+// Deprecated: Use !ParseNoIntroFilename(filename).Licensed instead.
 func IsUnlicensed(filename string) bool {
-	tags := ExtractTags(filename)
-	for _, tag := range tags {
-		if unlicensedTags[strings.ToLower(tag)] {
-			return true
-		}
-	}
-	return false
+	return !ParseNoIntroFilename(filename).Licensed
 }
@@ -4,6 +4,7 @@ import (
 	"reflect"
 	"testing"
 
+	"github.com/josegonzalez/retro-metadata/pkg/retrometadata"
 	"github.com/josegonzalez/retro-metadata/pkg/testutil"
 )
 
@@ -324,3 +325,119 @@ func TestParseNoIntroFilename(t *testing.T) {
 		})
 	}
 }
+
+func TestParseDiscInfo(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected DiscInfo
+		ok       bool
+	}{
+		{"Final Fantasy VII (USA) (Disc 1).bin", DiscInfo{Number: 1, Tag: "Disc 1", BaseName: "Final Fantasy VII (USA).bin"}, true},
+		{"Riven (1997)(Broderbund)(Disc 3 of 5).cue", DiscInfo{Number: 3, Total: 5, Tag: "Disc 3 of 5", BaseName: "Riven (1997)(Broderbund).cue"}, true},
+		{"/roms/Metal Gear Solid [CD2].chd", DiscInfo{Number: 2, Tag: "CD2", BaseName: "Metal Gear Solid.chd"}, true},
+		{"Monkey Island 2 (Disk B).adf", DiscInfo{Number: 2, Tag: "Disk B", BaseName: "Monkey Island 2.adf"}, true},
+		{"Super Mario World (USA).sfc", DiscInfo{}, false},
+		{"Burn:Cycle (Europe) (CDi).chd", DiscInfo{}, false},
+		{"Hotel Mario (USA) [CDi].chd", DiscInfo{}, false},
+	}
+
+	for _, tt := range tests {
+		result, ok := ParseDiscInfo(tt.input)
+		if ok != tt.ok || !reflect.DeepEqual(result, tt.expected) {
+			t.Errorf("ParseDiscInfo(%q) = (%+v, %v), want (%+v, %v)", tt.input, result, ok, tt.expected, tt.ok)
+		}
+	}
+}
+
+func TestParseNoIntroFilenameDetails(t *testing.T) {
+	tests := []struct {
+		input     string
+		version   string
+		revision  string
+		languages []retrometadata.Language
+		devStatus string
+		licensed  bool
+		disc      int
+		verified  bool
+	}{
+		{"Super Mario World (USA) (Rev 1).sfc", "Rev 1", "1", nil, "", true, 0, false},
+		{"Game (Europe) (En,Fr,De,Es,It) (Rev A) [!].sfc", "Rev A", "A", []retrometadata.Language{"en", "fr", "de", "es", "it"}, "", true, 0, true},
+		{"Game (Japan) (En+Ja) (Beta 2).sfc", "", "", []retrometadata.Language{"en", "ja"}, DevStatusBeta, true, 0, false},
+		{"Game (USA) (Prototype).nes", "", "", nil, DevStatusProto, true, 0, false},
+		{"Game (USA) (Sample).gba", "", "", nil, DevStatusSample, true, 0, false},
+		{"Game (Asia) (Unl).nes", "", "", nil, "", false, 0, false},
+		{"Final Fantasy VII (USA) (Disc 2 of 3).bin", "", "", nil, "", true, 2, false},
+		{"Virtua Fighter (Japan) (Virtual Console).iso", "", "", nil, "", true, 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			result := ParseNoIntroFilename(tt.input)
+			if result.Version != tt.version {
+				t.Errorf("Version = %q, want %q", result.Version, tt.version)
+			}
+			if result.Revision != tt.revision {
+				t.Errorf("Revision = %q, want %q", result.Revision, tt.revision)
+			}
+			if !reflect.DeepEqual(result.Languages, tt.languages) {
+				t.Errorf("Languages = %v, want %v", result.Languages, tt.languages)
+			}
+			if result.DevStatus != tt.devStatus {
+				t.Errorf("DevStatus = %q, want %q", result.DevStatus, tt.devStatus)
+			}
+			if result.IsPrerelease() != (tt.devStatus != "") {
+				t.Errorf("IsPrerelease() = %v", result.IsPrerelease())
+			}
+			if result.Licensed != tt.licensed {
+				t.Errorf("Licensed = %v, want %v", result.Licensed, tt.licensed)
+			}
+			if result.Disc != tt.disc {
+				t.Errorf("Disc = %d, want %d", result.Disc, tt.disc)
+			}
+			if result.Dump.Verified != tt.verified {
+				t.Errorf("Dump.Verified = %v, want %v", result.Dump.Verified, tt.verified)
+			}
+		})
+	}
+}
+
+func TestExtractRegions(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected []retrometadata.Region
+	}{
+		{"Game (USA, Europe).sfc", []retrometadata.Region{retrometadata.RegionUSA, retrometadata.RegionEurope}},
+		{"Game (Japan) (En,Ja) (Rev 1).sfc", []retrometadata.Region{retrometadata.RegionJapan}},
+		{"Game (World) (USA).sfc", []retrometadata.Region{retrometadata.RegionWorld, retrometadata.RegionUSA}},
+		{"Game (USA, Asia) (USA).sfc", []retrometadata.Region{retrometadata.RegionUSA, retrometadata.RegionAsia}},
+		{"Game (Proto).sfc", nil},
+	}
+
+	for _, tt := range tests {
+		result := ExtractRegions(tt.input)
+		if !reflect.DeepEqual(result, tt.expected) {
+			t.Errorf("ExtractRegions(%q) = %v, want %v", tt.input, result, tt.expected)
+		}
+	}
+}
+
+func TestSelectPreferredRegion(t *testing.T) {
+	priority := retrometadata.ParseRegions([]string{"us", "wor", "eu", "jp"})
+
+	tests := []struct {
+		input    string
+		expected retrometadata.Region
+	}{
+		{"Game (Europe, USA).sfc", retrometadata.RegionUSA},
+		{"Game (Japan, Europe).sfc", retrometadata.RegionEurope},
+		{"Game (Korea, Brazil).sfc", retrometadata.RegionKorea},
+		{"Game.sfc", retrometadata.RegionUnknown},
+	}
+
+	for _, tt := range tests {
+		result := retrometadata.SelectPreferred(ExtractRegions(tt.input), priority)
+		if result != tt.expected {
+			t.Errorf("SelectPreferred(%q) = %q, want %q", tt.input, result, tt.expected)
+		}
+	}
+}
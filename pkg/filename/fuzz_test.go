@@ -0,0 +1,78 @@
+package filename
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+// fuzzSeeds are filenames in each naming convention, plus some malformed ones.
+var fuzzSeeds = []string{
+	"Super Mario World (USA).sfc",
+	"Legend of Zelda, The - A Link to the Past (USA, Europe) (Rev 1).sfc",
+	"Pokemon - Red Version (USA, Europe) (SGB Enhanced).gb",
+	"Final Fantasy VII (USA) (Disc 1 of 3).bin",
+	"Chrono Trigger (U) [!].smc",
+	"Sonic the Hedgehog (JUE) [b1][h2C].md",
+	"Street Fighter II (1992)(Capcom)(US)[cr CPS].zip",
+	"Monkey Island (Disk A).adf",
+	"Game (En,Fr,De+Ja) (Beta 2) (Unl)",
+	"(((([[[[",
+	")]).(",
+	"[CD",
+	"",
+}
+
+func FuzzParseNoIntroFilename(f *testing.F) {
+	for _, seed := range fuzzSeeds {
+		f.Add(seed)
+	}
+	f.Fuzz(func(t *testing.T, name string) {
+		parsed := ParseNoIntroFilename(name)
+		if utf8.ValidString(name) && !utf8.ValidString(parsed.Name) {
+			t.Errorf("ParseNoIntroFilename(%q).Name = %q is not valid UTF-8", name, parsed.Name)
+		}
+		for _, tag := range parsed.Tags {
+			if !strings.Contains(name, tag) {
+				t.Errorf("ParseNoIntroFilename(%q) returned tag %q not in the filename", name, tag)
+			}
+		}
+	})
+}
+
+func FuzzParseGoodToolsFilename(f *testing.F) {
+	for _, seed := range fuzzSeeds {
+		f.Add(seed)
+	}
+	f.Fuzz(func(t *testing.T, name string) {
+		ParseGoodToolsFilename(name)
+		ParseDumpFlags(name)
+	})
+}
+
+func FuzzParseTOSECFilename(f *testing.F) {
+	for _, seed := range fuzzSeeds {
+		f.Add(seed)
+	}
+	f.Fuzz(func(t *testing.T, name string) {
+		ParseTOSECFilename(name)
+	})
+}
+
+func FuzzParseDiscInfo(f *testing.F) {
+	for _, seed := range fuzzSeeds {
+		f.Add(seed)
+	}
+	f.Fuzz(func(t *testing.T, name string) {
+		info, ok := ParseDiscInfo(name)
+		if !ok {
+			return
+		}
+		if info.Number < 0 || info.Total < 0 {
+			t.Errorf("ParseDiscInfo(%q) = %+v has a negative disc number", name, info)
+		}
+		if len(info.BaseName) >= len(name) {
+			t.Errorf("ParseDiscInfo(%q).BaseName = %q didn't remove the disc tag", name, info.BaseName)
+		}
+	})
+}
@@ -0,0 +1,103 @@
+package filename
+
+import (
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/josegonzalez/retro-metadata/pkg/retrometadata"
+)
+
+// ProviderTagPrefixes maps provider names to the prefix of the ID tags they
+// read from filenames, e.g. "moby" for (moby-1234). Providers that aren't
+// listed use their name.
+var ProviderTagPrefixes = map[string]string{
+	"igdb":              "igdb",
+	"mobygames":         "moby",
+	"screenscraper":     "ssfr",
+	"retroachievements": "ra",
+	"steamgriddb":       "sgdb",
+	"thegamesdb":        "tgdb",
+	"hltb":              "hltb",
+	"launchbox":         "launchbox",
+	"hasheous":          "hasheous",
+	"flashpoint":        "fp",
+}
+
+// unsafeTitleChars are characters that aren't allowed in filenames on some
+// systems, replaced in titles by CleanAndTag.
+var unsafeTitleChars = strings.NewReplacer(
+	": ", " - ", ":", "-", "/", "-", "\\", "-", "|", "-",
+	"<", "", ">", "", "\"", "", "?", "", "*", "",
+)
+
+// ProviderTag returns the ID tag providers read from filenames, like
+// "(igdb-1234)".
+func ProviderTag(provider, id string) string {
+	prefix, ok := ProviderTagPrefixes[provider]
+	if !ok {
+		prefix = provider
+	}
+	return "(" + prefix + "-" + id + ")"
+}
+
+// WithProviderTag returns name, a filename or path, tagged with a provider's
+// ID: "Super Metroid (USA).sfc" becomes "Super Metroid (USA) (igdb-1103).sfc".
+// Any other tag for the provider is replaced.
+func WithProviderTag(name, provider, id string) string {
+	dir, base := filepath.Split(name)
+	ext := extensionPattern.FindString(base)
+	stem := strings.TrimSpace(removeProviderTags(strings.TrimSuffix(base, ext), provider))
+	return dir + stem + " " + ProviderTag(provider, id) + ext
+}
+
+// removeProviderTags removes a provider's ID tags from a filename.
+func removeProviderTags(name, provider string) string {
+	prefix, ok := ProviderTagPrefixes[provider]
+	if !ok {
+		prefix = provider
+	}
+	pattern := regexp.MustCompile(`(?i)\s*\(` + regexp.QuoteMeta(prefix) + `-[^)]+\)`)
+	return pattern.ReplaceAllString(name, "")
+}
+
+// CleanAndTag returns the canonical name of a ROM file tagged with a
+// provider's ID, "Clean Name (Region) (igdb-1234).ext": title, or the
+// filename's own title if it's empty, followed by the filename's region and
+// disc tags, if any, and the provider tag. The directory of name is kept;
+// characters that aren't allowed in filenames are replaced in title.
+func CleanAndTag(name, title, provider, id string) string {
+	dir, base := filepath.Split(name)
+	ext := extensionPattern.FindString(base)
+	if title == "" {
+		title = CleanFilename(base, true)
+	}
+
+	parts := []string{strings.Join(strings.Fields(unsafeTitleChars.Replace(title)), " ")}
+	if region := regionTag(base); region != "" {
+		parts = append(parts, "("+region+")")
+	}
+	if disc, ok := ParseDiscInfo(base); ok {
+		parts = append(parts, "("+disc.Tag+")")
+	}
+	parts = append(parts, ProviderTag(provider, id))
+	return dir + strings.Join(parts, " ") + ext
+}
+
+// regionTag returns the first tag of a filename made only of regions, like
+// "USA, Europe", or "" if there's none.
+func regionTag(name string) string {
+	for _, tag := range ExtractTags(name) {
+		isRegion := true
+		for _, part := range strings.Split(tag, ",") {
+			if _, ok := retrometadata.ParseRegion(part); !ok {
+				isRegion = false
+				break
+			}
+		}
+		if isRegion {
+			return tag
+		}
+	}
+	return ""
+}
@@ -0,0 +1,42 @@
+package filename
+
+import "testing"
+
+func TestWithProviderTag(t *testing.T) {
+	tests := []struct {
+		name, provider, id string
+		expected           string
+	}{
+		{"Super Metroid (USA).sfc", "igdb", "1103", "Super Metroid (USA) (igdb-1103).sfc"},
+		{"/roms/snes/Super Metroid (USA) (igdb-1).sfc", "igdb", "1103", "/roms/snes/Super Metroid (USA) (igdb-1103).sfc"},
+		{"Super Metroid (USA) (MOBY-5).sfc", "mobygames", "7", "Super Metroid (USA) (moby-7).sfc"},
+		{"Super Metroid (USA) (igdb-1103).sfc", "screenscraper", "1018", "Super Metroid (USA) (igdb-1103) (ssfr-1018).sfc"},
+		{"Alien Hominid", "flashpoint", "6e6bb2e4-5a1d-4b5d-9a08-2c3d9e0ef1a2", "Alien Hominid (fp-6e6bb2e4-5a1d-4b5d-9a08-2c3d9e0ef1a2)"},
+		{"Game.zip", "custom", "42", "Game (custom-42).zip"},
+	}
+
+	for _, tt := range tests {
+		if result := WithProviderTag(tt.name, tt.provider, tt.id); result != tt.expected {
+			t.Errorf("WithProviderTag(%q, %q, %q) = %q, want %q", tt.name, tt.provider, tt.id, result, tt.expected)
+		}
+	}
+}
+
+func TestCleanAndTag(t *testing.T) {
+	tests := []struct {
+		name, title string
+		expected    string
+	}{
+		{"/roms/Super Metroid (USA, Europe) (Rev 1) [!].sfc", "", "/roms/Super Metroid (USA, Europe) (igdb-1103).sfc"},
+		{"smetroid (U).sfc", "Super Metroid", "Super Metroid (U) (igdb-1103).sfc"},
+		{"Zelda (Japan) (moby-3).sfc", "The Legend of Zelda: A Link to the Past", "The Legend of Zelda - A Link to the Past (Japan) (igdb-1103).sfc"},
+		{"Final Fantasy VII (USA) (Disc 2).bin", "Final Fantasy VII", "Final Fantasy VII (USA) (Disc 2) (igdb-1103).bin"},
+		{"Tetris.gb", "Tetris/DX?", "Tetris-DX (igdb-1103).gb"},
+	}
+
+	for _, tt := range tests {
+		if result := CleanAndTag(tt.name, tt.title, "igdb", "1103"); result != tt.expected {
+			t.Errorf("CleanAndTag(%q, %q) = %q, want %q", tt.name, tt.title, result, tt.expected)
+		}
+	}
+}
@@ -0,0 +1,99 @@
+package identify
+
+import (
+	"context"
+	"path/filepath"
+	"sync"
+
+	"github.com/josegonzalez/retro-metadata/pkg/progress"
+	"github.com/josegonzalez/retro-metadata/pkg/retrometadata"
+)
+
+// BatchOptions contains options for IdentifyBatch.
+type BatchOptions struct {
+	// Options are passed through to the providers
+	Options retrometadata.IdentifyOptions
+	// Concurrency is the number of groups identified at once (default 1)
+	Concurrency int
+	// Progress receives an update as each group is identified
+	Progress progress.Progress
+	// Trace records the fuzzy matches run for each group in
+	// BatchResult.Trace
+	Trace bool
+}
+
+// BatchResult is the identification result for a single group.
+type BatchResult struct {
+	// Group is the group that was identified
+	Group DiscGroup
+	// Result is the identified game, or nil if identification failed
+	Result *retrometadata.GameResult
+	// Err is the identification error, if any
+	Err error
+	// Trace explains the fuzzy matches run for the group, if
+	// BatchOptions.Trace is set
+	Trace *retrometadata.MatchTrace
+}
+
+// IdentifyBatch identifies many groups, optionally in parallel.
+// Results are returned in the same order as groups.
+func (p *Pipeline) IdentifyBatch(ctx context.Context, providers []retrometadata.Provider, groups []DiscGroup, opts BatchOptions) []BatchResult {
+	return p.identifyBatch(ctx, groups, opts, func(ctx context.Context, group DiscGroup) (*retrometadata.GameResult, error) {
+		return p.IdentifyGroup(ctx, providers, group, opts.Options)
+	})
+}
+
+// IdentifyBatchWithClient identifies many groups like IdentifyBatch, with
+// the client's providers and under its policy (see
+// retrometadata.Client.IdentifyWith).
+func (p *Pipeline) IdentifyBatchWithClient(ctx context.Context, client *retrometadata.Client, groups []DiscGroup, opts BatchOptions) []BatchResult {
+	return p.identifyBatch(ctx, groups, opts, func(ctx context.Context, group DiscGroup) (*retrometadata.GameResult, error) {
+		return p.IdentifyGroupWithClient(ctx, client, group, opts.Options)
+	})
+}
+
+// identifyBatch implements IdentifyBatch and IdentifyBatchWithClient,
+// identifying each group with identifyGroup.
+func (p *Pipeline) identifyBatch(ctx context.Context, groups []DiscGroup, opts BatchOptions, identifyGroup func(context.Context, DiscGroup) (*retrometadata.GameResult, error)) []BatchResult {
+	prog := progress.OrNoop(opts.Progress)
+	concurrency := opts.Concurrency
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	prog.Start(len(groups))
+	defer prog.Done()
+
+	results := make([]BatchResult, len(groups))
+	sem := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+
+	for i, group := range groups {
+		results[i].Group = group
+
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+			results[i].Err = ctx.Err()
+			continue
+		}
+
+		wg.Add(1)
+		go func(i int, group DiscGroup) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			groupCtx := ctx
+			if opts.Trace {
+				results[i].Trace = &retrometadata.MatchTrace{Filename: group.Filename}
+				groupCtx = retrometadata.WithMatchTrace(ctx, results[i].Trace)
+			}
+			prog.SetStatus(filepath.Base(group.Filename))
+			results[i].Result, results[i].Err = identifyGroup(groupCtx, group)
+			prog.Increment(1)
+		}(i, group)
+	}
+
+	wg.Wait()
+	return results
+}
@@ -0,0 +1,115 @@
+package identify
+
+import (
+	"context"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/josegonzalez/retro-metadata/pkg/filename"
+	"github.com/josegonzalez/retro-metadata/pkg/retrometadata"
+)
+
+// File is a ROM file to be identified.
+type File struct {
+	// Path is the file path
+	Path string
+	// Hashes contains the file hashes, if known
+	Hashes *retrometadata.FileHashes
+}
+
+// DiscGroup is a game made up of one or more files.
+// Multi-disc sets are collapsed into a single group with one Disc per file.
+type DiscGroup struct {
+	// Filename is the file path with any disc tag removed
+	Filename string
+	// Hashes contains the hashes of the first disc (or the single file)
+	Hashes *retrometadata.FileHashes
+	// Discs contains every disc in the set, ordered by disc number.
+	// Empty for files that aren't part of a multi-disc set.
+	Discs []retrometadata.Disc
+}
+
+// IsMultiDisc returns true if the group is a multi-disc set.
+func (g DiscGroup) IsMultiDisc() bool {
+	return len(g.Discs) > 0
+}
+
+// Request builds the identification request for the group.
+func (g DiscGroup) Request(opts retrometadata.IdentifyOptions) Request {
+	return Request{
+		Filename: g.Filename,
+		Hashes:   g.Hashes,
+		Options:  opts,
+	}
+}
+
+// GroupDiscs collapses multi-disc sets like "Game (Disc 1).bin" and
+// "Game (Disc 2).bin" into a single group. Files without a disc tag become
+// their own group. Groups are returned in the order they first appear.
+func GroupDiscs(files []File) []DiscGroup {
+	var groups []DiscGroup
+	index := make(map[string]int)
+
+	for _, file := range files {
+		info, ok := filename.ParseDiscInfo(file.Path)
+		if !ok {
+			groups = append(groups, DiscGroup{Filename: file.Path, Hashes: file.Hashes})
+			continue
+		}
+
+		groupPath := filepath.Join(filepath.Dir(file.Path), info.BaseName)
+		key := strings.ToLower(strings.TrimSuffix(groupPath, filepath.Ext(groupPath)))
+
+		i, ok := index[key]
+		if !ok {
+			i = len(groups)
+			index[key] = i
+			groups = append(groups, DiscGroup{Filename: groupPath})
+		}
+		groups[i].Discs = append(groups[i].Discs, retrometadata.Disc{
+			Number:   info.Number,
+			Filename: file.Path,
+			Hashes:   file.Hashes,
+		})
+	}
+
+	for i := range groups {
+		discs := groups[i].Discs
+		if len(discs) == 0 {
+			continue
+		}
+		sort.SliceStable(discs, func(a, b int) bool { return discs[a].Number < discs[b].Number })
+		groups[i].Hashes = discs[0].Hashes
+	}
+
+	return groups
+}
+
+// IdentifyGroup identifies a disc group as a single game and attaches the
+// per-disc metadata to the result.
+func (p *Pipeline) IdentifyGroup(ctx context.Context, providers []retrometadata.Provider, group DiscGroup, opts retrometadata.IdentifyOptions) (*retrometadata.GameResult, error) {
+	result, err := p.Identify(ctx, providers, group.Request(opts))
+	if err != nil {
+		return nil, err
+	}
+	if group.IsMultiDisc() {
+		result.Discs = group.Discs
+	}
+	return result, nil
+}
+
+// IdentifyGroupWithClient identifies a disc group like IdentifyGroup, with
+// the client's providers and under its policy (see
+// retrometadata.Client.IdentifyWith). Groups with an override are
+// identified by it, by their name or the hashes of their first disc.
+func (p *Pipeline) IdentifyGroupWithClient(ctx context.Context, client *retrometadata.Client, group DiscGroup, opts retrometadata.IdentifyOptions) (*retrometadata.GameResult, error) {
+	result, err := p.IdentifyWithClient(ctx, client, group.Request(opts))
+	if err != nil {
+		return nil, err
+	}
+	if group.IsMultiDisc() {
+		result.Discs = group.Discs
+	}
+	return result, nil
+}
@@ -0,0 +1,111 @@
+package identify
+
+import (
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func FuzzExtractSerial(f *testing.F) {
+	for _, seed := range []string{
+		"Final Fantasy VII (USA) [SCUS-94163].bin",
+		"SLUS_123.45 Game.iso",
+		"sles-00001",
+		"SCUS-9",
+		"",
+	} {
+		f.Add(seed)
+	}
+	f.Fuzz(func(t *testing.T, name string) {
+		serial := ExtractSerial(name)
+		if serial != "" && !strings.Contains(serial, "-") {
+			t.Errorf("ExtractSerial(%q) = %q has no separator", name, serial)
+		}
+	})
+}
+
+// fuzzCHD builds a v5 CHD header with a single metadata entry holding meta.
+func fuzzCHD(tag string, meta []byte) []byte {
+	header := make([]byte, 124)
+	copy(header, chdMagic)
+	binary.BigEndian.PutUint32(header[12:16], 5)
+	binary.BigEndian.PutUint64(header[32:40], 1<<20)
+	binary.BigEndian.PutUint64(header[48:56], uint64(len(header)))
+
+	entry := make([]byte, 16)
+	copy(entry, tag)
+	entry[5], entry[6], entry[7] = byte(len(meta)>>16), byte(len(meta)>>8), byte(len(meta))
+	return append(append(header, entry...), meta...)
+}
+
+func FuzzCHDDataSize(f *testing.F) {
+	f.Add(fuzzCHD("CHT2", []byte("TRACK:1 TYPE:MODE2_RAW SUBTYPE:NONE FRAMES:230025 PREGAP:150 PGTYPE:VMODE2_RAW\x00")))
+	f.Add(fuzzCHD("CHTR", []byte("TRACK:1 TYPE:MODE1 SUBTYPE:NONE FRAMES:1000")))
+	f.Add(fuzzCHD("GDDD", nil))
+	f.Add(chdMagic)
+	f.Add([]byte{})
+
+	dir := f.TempDir()
+	f.Fuzz(func(t *testing.T, data []byte) {
+		path := filepath.Join(dir, "fuzz.chd")
+		if err := os.WriteFile(path, data, 0o644); err != nil {
+			t.Fatal(err)
+		}
+		size, err := CHDDataSize(path)
+		if err == nil && size < 0 {
+			t.Errorf("CHDDataSize returned a negative size %d", size)
+		}
+	})
+}
+
+func FuzzCSOSize(f *testing.F) {
+	header := make([]byte, 24)
+	copy(header, csoMagic)
+	binary.LittleEndian.PutUint64(header[8:16], 1<<30)
+	f.Add(header)
+	f.Add(csoMagic)
+	f.Add([]byte{})
+
+	dir := f.TempDir()
+	f.Fuzz(func(t *testing.T, data []byte) {
+		path := filepath.Join(dir, "fuzz.cso")
+		if err := os.WriteFile(path, data, 0o644); err != nil {
+			t.Fatal(err)
+		}
+		size, err := CSOSize(path)
+		if err == nil && size < 0 {
+			t.Errorf("CSOSize returned a negative size %d", size)
+		}
+	})
+}
+
+func FuzzParsePlaylist(f *testing.F) {
+	f.Add(".cue", []byte("FILE \"Game (Track 1).bin\" BINARY\n  TRACK 01 MODE2/2352\n"))
+	f.Add(".gdi", []byte("3\n1 0 4 2352 track01.bin 0\n2 756 0 2352 \"track 02.raw\" 0\n"))
+	f.Add(".m3u", []byte("#EXTM3U\nGame (Disc 1).cue\r\nGame (Disc 2).cue\n"))
+	f.Add(".cue", []byte("\ufeffFILE"))
+
+	dir := f.TempDir()
+	f.Fuzz(func(t *testing.T, ext string, data []byte) {
+		switch ext {
+		case ".cue", ".gdi", ".m3u", ".m3u8":
+		default:
+			return
+		}
+		path := filepath.Join(dir, "fuzz"+ext)
+		if err := os.WriteFile(path, data, 0o644); err != nil {
+			t.Fatal(err)
+		}
+		files, err := ParsePlaylist(path)
+		if err != nil {
+			return
+		}
+		for _, file := range files {
+			if file == "" || file == dir {
+				t.Errorf("ParsePlaylist returned an empty reference: %q", files)
+			}
+		}
+	})
+}
@@ -0,0 +1,474 @@
+// Package identify provides a configurable game identification pipeline.
+//
+// Identification runs as a sequence of steps (tag lookup, hash lookup, serial
+// lookup, filename search, fuzzy match and internal title match by
+// default). Each step is tried
+// against every provider in order and the first step to produce a result wins.
+// Steps can be reordered, removed or extended with custom Step implementations.
+//
+// Files on disk can be identified with IdentifyFile, which resolves m3u, cue
+// and gdi playlists to the game data they reference before hashing.
+package identify
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/josegonzalez/retro-metadata/pkg/discimage"
+	"github.com/josegonzalez/retro-metadata/pkg/filename"
+	"github.com/josegonzalez/retro-metadata/pkg/matching"
+	"github.com/josegonzalez/retro-metadata/pkg/platform"
+	"github.com/josegonzalez/retro-metadata/pkg/provider"
+	"github.com/josegonzalez/retro-metadata/pkg/retrometadata"
+	"github.com/josegonzalez/retro-metadata/pkg/romheader"
+	"github.com/josegonzalez/retro-metadata/pkg/serials"
+	"github.com/josegonzalez/retro-metadata/pkg/titleinfo"
+)
+
+// Built-in step names.
+const (
+	StepTag      = "tag"
+	StepHash     = "hash"
+	StepSerial   = "serial"
+	StepFilename = "filename"
+	StepFuzzy    = "fuzzy"
+	StepHeader   = "header"
+)
+
+var (
+	// providerTagRegex matches provider ID tags in filenames like (igdb-1234)
+	providerTagRegex = regexp.MustCompile(`(?i)\(([a-z]+)-(\d+)\)`)
+)
+
+// Request is a single identification request.
+type Request struct {
+	// Filename is the ROM filename
+	Filename string
+	// Hashes contains the file hashes, if known
+	Hashes *retrometadata.FileHashes
+	// Serial is the game serial code; taken from Options.Serial, the
+	// filename, the disc image header or the Switch or 3DS title
+	// container, in that order, if empty
+	Serial string
+	// Platform is the platform slug; detected from the file path if empty
+	Platform platform.Slug
+	// Options are passed through to the providers. If Options.PlatformID is
+	// nil, each provider receives its own ID for Platform.
+	Options retrometadata.IdentifyOptions
+	// NameOnly identifies the request from its filename, hashes and serial
+	// without opening the file, e.g. for filenames sent by untrusted
+	// clients. Steps reading the file must skip such requests.
+	NameOnly bool
+
+	// title caches the file's internal title across the copies of the
+	// request passed to each provider
+	title *fileTitle
+}
+
+// fileTitle is the title a file records about itself, read at most once.
+type fileTitle struct {
+	once     sync.Once
+	name     string
+	platform platform.Slug
+}
+
+// internalTitle returns the file's internal title and platform, reading
+// them once per Identify call.
+func (r Request) internalTitle() (string, platform.Slug) {
+	if r.title == nil {
+		return internalTitle(r.Filename)
+	}
+	r.title.once.Do(func() {
+		r.title.name, r.title.platform = internalTitle(r.Filename)
+	})
+	return r.title.name, r.title.platform
+}
+
+// Step is a single stage of the identification pipeline.
+type Step interface {
+	// Name returns the step name.
+	Name() string
+
+	// Identify attempts to identify the request using a single provider.
+	// A nil result with a nil error means the step found nothing.
+	Identify(ctx context.Context, p retrometadata.Provider, req Request) (*retrometadata.GameResult, error)
+}
+
+// SerialProvider is an optional interface for providers that support serial-based identification.
+type SerialProvider interface {
+	retrometadata.Provider
+
+	// IdentifyBySerial identifies a game using its serial code.
+	IdentifyBySerial(ctx context.Context, serial string, opts retrometadata.IdentifyOptions) (*retrometadata.GameResult, error)
+}
+
+// Observer is called after each step that got a result or an error from a
+// provider, with a nil err for results. Steps that found nothing aren't
+// reported. Observers may be called from several goroutines at once.
+type Observer func(step, provider string, err error)
+
+// Pipeline is an ordered list of identification steps.
+type Pipeline struct {
+	steps     []Step
+	observers []Observer
+}
+
+// NewPipeline creates a pipeline running the given steps in order.
+func NewPipeline(steps ...Step) *Pipeline {
+	return &Pipeline{steps: steps}
+}
+
+// DefaultPipeline returns the default pipeline:
+// tag lookup, hash lookup, serial lookup, filename search, fuzzy match and
+// internal title match.
+func DefaultPipeline() *Pipeline {
+	return NewPipeline(
+		TagStep{},
+		HashStep{},
+		SerialStep{},
+		FilenameStep{},
+		FuzzyStep{MinScore: matching.DefaultMinSimilarity},
+		HeaderStep{MinScore: matching.DefaultMinSimilarity},
+	)
+}
+
+// Steps returns a copy of the pipeline steps.
+func (p *Pipeline) Steps() []Step {
+	steps := make([]Step, len(p.steps))
+	copy(steps, p.steps)
+	return steps
+}
+
+// Clone returns a copy of the pipeline that can be changed independently.
+func (p *Pipeline) Clone() *Pipeline {
+	return &Pipeline{steps: p.Steps(), observers: append([]Observer(nil), p.observers...)}
+}
+
+// Observe adds an observer of the provider results and errors of each step.
+func (p *Pipeline) Observe(observer Observer) *Pipeline {
+	p.observers = append(p.observers, observer)
+	return p
+}
+
+// StepNames returns the names of the pipeline steps in order.
+func (p *Pipeline) StepNames() []string {
+	names := make([]string, len(p.steps))
+	for i, step := range p.steps {
+		names[i] = step.Name()
+	}
+	return names
+}
+
+// Append adds steps to the end of the pipeline.
+func (p *Pipeline) Append(steps ...Step) *Pipeline {
+	p.steps = append(p.steps, steps...)
+	return p
+}
+
+// InsertBefore inserts a step before the named step.
+// The step is appended if no step has the given name.
+func (p *Pipeline) InsertBefore(name string, step Step) *Pipeline {
+	for i, s := range p.steps {
+		if s.Name() == name {
+			p.steps = append(p.steps[:i], append([]Step{step}, p.steps[i:]...)...)
+			return p
+		}
+	}
+	return p.Append(step)
+}
+
+// Remove removes every step with the given name.
+func (p *Pipeline) Remove(name string) *Pipeline {
+	steps := p.steps[:0]
+	for _, s := range p.steps {
+		if s.Name() != name {
+			steps = append(steps, s)
+		}
+	}
+	p.steps = steps
+	return p
+}
+
+// Reorder rearranges the steps to match the given names.
+// Steps not named are dropped; unknown names return an error.
+func (p *Pipeline) Reorder(names ...string) error {
+	byName := make(map[string]Step, len(p.steps))
+	for _, s := range p.steps {
+		byName[s.Name()] = s
+	}
+
+	steps := make([]Step, 0, len(names))
+	for _, name := range names {
+		step, ok := byName[name]
+		if !ok {
+			return fmt.Errorf("unknown identify step: %s", name)
+		}
+		steps = append(steps, step)
+	}
+
+	p.steps = steps
+	return nil
+}
+
+// Identify runs the pipeline against the providers, in order.
+// Each step is tried against every provider before moving on to the next step.
+// Provider errors are skipped so a failing provider doesn't stop the pipeline.
+func (p *Pipeline) Identify(ctx context.Context, providers []retrometadata.Provider, req Request) (*retrometadata.GameResult, error) {
+	if req.Hashes == nil {
+		req.Hashes = req.Options.Hashes
+	}
+	if req.Platform == "" {
+		req.Platform = platform.DetectFromPath(req.Filename)
+	}
+	if req.Serial == "" {
+		req.Serial = req.Options.Serial
+	}
+	if req.Serial == "" {
+		req.Serial = serials.Extract(req.Filename, req.Platform)
+	}
+	if req.Serial == "" && !req.NameOnly && discimage.IsDiscImage(req.Filename) {
+		// Disc images record their serial even when the filename doesn't
+		if info, err := discimage.Read(req.Filename); err == nil {
+			req.Serial = info.Serial
+			if req.Platform == "" {
+				req.Platform = info.Platform
+			}
+		}
+	}
+	if req.Serial == "" && !req.NameOnly && titleinfo.IsContainer(req.Filename) {
+		// Switch and 3DS dumps are often named by hash or scene release
+		if info, err := titleinfo.Read(req.Filename); err == nil {
+			req.Serial = info.Serial()
+			if req.Platform == "" {
+				req.Platform = info.Platform
+			}
+		}
+	}
+	req.Options.Serial = req.Serial
+	req.title = &fileTitle{}
+
+	for _, step := range p.steps {
+		for _, provider := range providers {
+			if err := ctx.Err(); err != nil {
+				return nil, err
+			}
+
+			providerReq := req
+			if providerReq.Options.PlatformID == nil && req.Platform != "" {
+				providerReq.Options.PlatformID = platform.GetPlatformID(provider.Name(), req.Platform)
+			}
+
+			result, err := step.Identify(ctx, provider, providerReq)
+			if err != nil || result != nil {
+				for _, observe := range p.observers {
+					observe(step.Name(), provider.Name(), err)
+				}
+			}
+			if err != nil || result == nil {
+				continue
+			}
+			if result.MatchType == "" {
+				result.MatchType = step.Name()
+			}
+			return result, nil
+		}
+	}
+
+	return nil, &retrometadata.GameNotFoundError{
+		SearchTerm: req.Filename,
+	}
+}
+
+// IdentifyWithClient identifies a request like Identify, with the client's
+// providers and under its policy (see retrometadata.Client.IdentifyWith).
+// Requests with an override are identified by it, by their filename or
+// hashes.
+func (p *Pipeline) IdentifyWithClient(ctx context.Context, client *retrometadata.Client, req Request) (*retrometadata.GameResult, error) {
+	opts := req.Options
+	if req.Hashes != nil {
+		opts.Hashes = req.Hashes
+	}
+	return client.IdentifyWith(ctx, req.Filename, opts, func(ctx context.Context, providers []retrometadata.Provider) (*retrometadata.GameResult, error) {
+		return p.Identify(ctx, providers, req)
+	})
+}
+
+// ExtractSerial extracts a serial code (e.g. SLUS-12345) from a filename
+// whose platform isn't known. Returns an empty string if the filename has no
+// serial. See serials.Extract.
+func ExtractSerial(name string) string {
+	return serials.Extract(name, "")
+}
+
+// TagStep looks up provider ID tags in the filename, like (igdb-1234).
+type TagStep struct{}
+
+// Name returns the step name.
+func (TagStep) Name() string { return StepTag }
+
+// Identify gets the game by ID if the filename has a tag for the provider.
+func (TagStep) Identify(ctx context.Context, p retrometadata.Provider, req Request) (*retrometadata.GameResult, error) {
+	for _, match := range providerTagRegex.FindAllStringSubmatch(req.Filename, -1) {
+		if !strings.EqualFold(match[1], p.Name()) {
+			continue
+		}
+		id, err := strconv.Atoi(match[2])
+		if err != nil {
+			continue
+		}
+		return p.GetByID(ctx, id)
+	}
+	return nil, nil
+}
+
+// HashStep identifies games by file hashes using providers implementing HashProvider.
+type HashStep struct{}
+
+// Name returns the step name.
+func (HashStep) Name() string { return StepHash }
+
+// Identify identifies the game by its hashes.
+func (HashStep) Identify(ctx context.Context, p retrometadata.Provider, req Request) (*retrometadata.GameResult, error) {
+	hashProvider, ok := p.(retrometadata.HashProvider)
+	if !ok || req.Hashes == nil {
+		return nil, nil
+	}
+	return hashProvider.IdentifyByHash(ctx, *req.Hashes, req.Options)
+}
+
+// SerialStep identifies games by serial code using providers implementing SerialProvider.
+type SerialStep struct{}
+
+// Name returns the step name.
+func (SerialStep) Name() string { return StepSerial }
+
+// Identify identifies the game by its serial code.
+func (SerialStep) Identify(ctx context.Context, p retrometadata.Provider, req Request) (*retrometadata.GameResult, error) {
+	serialProvider, ok := p.(SerialProvider)
+	if !ok || req.Serial == "" {
+		return nil, nil
+	}
+	return serialProvider.IdentifyBySerial(ctx, req.Serial, req.Options)
+}
+
+// FilenameStep uses the provider's own filename identification.
+type FilenameStep struct{}
+
+// Name returns the step name.
+func (FilenameStep) Name() string { return StepFilename }
+
+// Identify identifies the game from its filename.
+func (FilenameStep) Identify(ctx context.Context, p retrometadata.Provider, req Request) (*retrometadata.GameResult, error) {
+	return p.Identify(ctx, req.Filename, req.Options)
+}
+
+// FuzzyStep searches for the cleaned filename and fuzzy matches the results.
+type FuzzyStep struct {
+	// MinScore is the minimum similarity score for a match
+	MinScore float64
+	// Scorer is the similarity function (defaults to Jaro-Winkler)
+	Scorer matching.Scorer
+}
+
+// Name returns the step name.
+func (FuzzyStep) Name() string { return StepFuzzy }
+
+// Identify searches the provider and returns the best fuzzy match.
+func (s FuzzyStep) Identify(ctx context.Context, p retrometadata.Provider, req Request) (*retrometadata.GameResult, error) {
+	searchTerm := filename.CleanFilename(req.Filename, true)
+	if searchTerm == "" {
+		return nil, nil
+	}
+	return fuzzyMatch(ctx, p, searchTerm, req.Options.PlatformID, s.MinScore, s.Scorer, StepFuzzy)
+}
+
+// HeaderStep searches for the internal title in the ROM's header, or the
+// name in a Switch or 3DS title container, for files whose names don't
+// match. See romheader.Read and titleinfo.Read.
+type HeaderStep struct {
+	// MinScore is the minimum similarity score for a match
+	MinScore float64
+	// Scorer is the similarity function (defaults to Jaro-Winkler)
+	Scorer matching.Scorer
+}
+
+// Name returns the step name.
+func (HeaderStep) Name() string { return StepHeader }
+
+// Identify searches the provider for the file's internal title and returns
+// the best fuzzy match. NameOnly requests are skipped.
+func (s HeaderStep) Identify(ctx context.Context, p retrometadata.Provider, req Request) (*retrometadata.GameResult, error) {
+	if req.NameOnly {
+		return nil, nil
+	}
+	title, slug := req.internalTitle()
+	// The fuzzy step already searched for titles matching the filename
+	if title == "" || strings.EqualFold(title, filename.CleanFilename(req.Filename, true)) {
+		return nil, nil
+	}
+
+	platformID := req.Options.PlatformID
+	if platformID == nil {
+		platformID = platform.GetPlatformID(p.Name(), slug)
+	}
+	return fuzzyMatch(ctx, p, title, platformID, s.MinScore, s.Scorer, StepHeader)
+}
+
+// internalTitle returns the title a file records about itself and the
+// platform it's for, or "" if it records none.
+func internalTitle(path string) (string, platform.Slug) {
+	if titleinfo.IsContainer(path) {
+		if info, err := titleinfo.Read(path); err == nil {
+			return info.Name, info.Platform
+		}
+		return "", ""
+	}
+	if header, err := romheader.Read(path); err == nil {
+		return header.Title, header.Platform
+	}
+	return "", ""
+}
+
+// fuzzyMatch searches the provider for searchTerm and returns the best
+// fuzzy match, with its score and matchType.
+func fuzzyMatch(ctx context.Context, p retrometadata.Provider, searchTerm string, platformID *int, minScore float64, scorer matching.Scorer, matchType string) (*retrometadata.GameResult, error) {
+	results, err := p.Search(ctx, searchTerm, retrometadata.SearchOptions{
+		PlatformID: platformID,
+		Limit:      20,
+	})
+	if err != nil || len(results) == 0 {
+		return nil, err
+	}
+
+	names := make([]string, 0, len(results))
+	byName := make(map[string]retrometadata.SearchResult, len(results))
+	for _, r := range results {
+		if _, ok := byName[r.Name]; !ok {
+			names = append(names, r.Name)
+			byName[r.Name] = r
+		}
+	}
+
+	opts := matching.DefaultFindBestMatchOptions()
+	if minScore > 0 {
+		opts.MinSimilarityScore = minScore
+	}
+	opts.Scorer = scorer
+
+	bestMatch, score := provider.FindBestMatch(ctx, p.Name(), searchTerm, names, opts)
+	if bestMatch == "" {
+		return nil, nil
+	}
+
+	result, err := p.GetByID(ctx, byName[bestMatch].ProviderID)
+	if err != nil || result == nil {
+		return nil, err
+	}
+	result.MatchScore = score
+	result.MatchType = matchType
+	return result, nil
+}
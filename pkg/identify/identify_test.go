@@ -0,0 +1,548 @@
+package identify
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/josegonzalez/retro-metadata/pkg/progress"
+	"github.com/josegonzalez/retro-metadata/pkg/retrometadata"
+)
+
+type fakeProvider struct {
+	games map[int]string
+	md5   map[string]int
+}
+
+func (p *fakeProvider) Name() string { return "fake" }
+
+func (p *fakeProvider) Search(_ context.Context, _ string, _ retrometadata.SearchOptions) ([]retrometadata.SearchResult, error) {
+	var results []retrometadata.SearchResult
+	for id, name := range p.games {
+		results = append(results, retrometadata.SearchResult{Name: name, Provider: p.Name(), ProviderID: id})
+	}
+	return results, nil
+}
+
+func (p *fakeProvider) GetByID(_ context.Context, id int) (*retrometadata.GameResult, error) {
+	name, ok := p.games[id]
+	if !ok {
+		return nil, nil
+	}
+	return &retrometadata.GameResult{Name: name, Provider: p.Name(), ProviderID: &id}, nil
+}
+
+func (p *fakeProvider) Identify(_ context.Context, _ string, _ retrometadata.IdentifyOptions) (*retrometadata.GameResult, error) {
+	return nil, nil
+}
+
+func (p *fakeProvider) IdentifyByHash(ctx context.Context, hashes retrometadata.FileHashes, _ retrometadata.IdentifyOptions) (*retrometadata.GameResult, error) {
+	if id, ok := p.md5[hashes.MD5]; ok {
+		return p.GetByID(ctx, id)
+	}
+	return nil, nil
+}
+
+func (p *fakeProvider) Heartbeat(_ context.Context) error { return nil }
+
+func (p *fakeProvider) Close() error { return nil }
+
+type stubStep struct{}
+
+func (stubStep) Name() string { return "stub" }
+
+func (stubStep) Identify(_ context.Context, _ retrometadata.Provider, req Request) (*retrometadata.GameResult, error) {
+	return &retrometadata.GameResult{Name: "Stub"}, nil
+}
+
+func TestPipelineIdentify(t *testing.T) {
+	provider := &fakeProvider{
+		games: map[int]string{1: "Super Mario World", 2: "Final Fantasy VII"},
+		md5:   map[string]int{"abc": 1},
+	}
+	providers := []retrometadata.Provider{provider}
+	ctx := context.Background()
+
+	tests := []struct {
+		name      string
+		req       Request
+		expected  string
+		matchType string
+	}{
+		{"tag", Request{Filename: "Anything (fake-2).bin"}, "Final Fantasy VII", StepTag},
+		{"hash", Request{Filename: "Unknown.sfc", Hashes: &retrometadata.FileHashes{MD5: "abc"}}, "Super Mario World", StepHash},
+		{"fuzzy", Request{Filename: "Final Fantasy 7 (USA) (Disc 1).bin"}, "Final Fantasy VII", StepFuzzy},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := DefaultPipeline().Identify(ctx, providers, tt.req)
+			if err != nil {
+				t.Fatalf("Identify() error: %v", err)
+			}
+			if result.Name != tt.expected || result.MatchType != tt.matchType {
+				t.Errorf("Identify() = (%q, %q), expected (%q, %q)", result.Name, result.MatchType, tt.expected, tt.matchType)
+			}
+		})
+	}
+
+	_, err := NewPipeline(TagStep{}).Identify(ctx, providers, Request{Filename: "Zelda.sfc"})
+	if _, ok := err.(*retrometadata.GameNotFoundError); !ok {
+		t.Errorf("Expected GameNotFoundError, got %v", err)
+	}
+}
+
+// namedProvider overrides the name of a fakeProvider.
+type namedProvider struct {
+	*fakeProvider
+	name string
+}
+
+func (p namedProvider) Name() string { return p.name }
+
+// platformStep records the platform ID each provider receives.
+type platformStep struct {
+	seen map[string]*int
+}
+
+func (platformStep) Name() string { return "platform" }
+
+func (s platformStep) Identify(_ context.Context, p retrometadata.Provider, req Request) (*retrometadata.GameResult, error) {
+	s.seen[p.Name()] = req.Options.PlatformID
+	return nil, nil
+}
+
+func TestPipelinePlatformDetection(t *testing.T) {
+	providers := []retrometadata.Provider{
+		namedProvider{&fakeProvider{}, "igdb"},
+		namedProvider{&fakeProvider{}, "screenscraper"},
+		&fakeProvider{},
+	}
+
+	step := platformStep{seen: make(map[string]*int)}
+	_, _ = NewPipeline(step).Identify(context.Background(), providers, Request{Filename: "/roms/megadrive/Sonic.bin"})
+
+	if id := step.seen["igdb"]; id == nil || *id != 29 {
+		t.Errorf("igdb PlatformID = %v, expected 29", id)
+	}
+	if id := step.seen["screenscraper"]; id == nil || *id != 1 {
+		t.Errorf("screenscraper PlatformID = %v, expected 1", id)
+	}
+	if id := step.seen["fake"]; id != nil {
+		t.Errorf("fake PlatformID = %d, expected nil", *id)
+	}
+
+	// An explicit PlatformID is passed through unchanged
+	explicit := 19
+	_, _ = NewPipeline(step).Identify(context.Background(), providers, Request{
+		Filename: "/roms/megadrive/Sonic.bin",
+		Options:  retrometadata.IdentifyOptions{PlatformID: &explicit},
+	})
+	if id := step.seen["igdb"]; id == nil || *id != explicit {
+		t.Errorf("igdb PlatformID = %v, expected %d", id, explicit)
+	}
+}
+
+// requestStep records the request it receives.
+type requestStep struct {
+	seen *Request
+}
+
+func (requestStep) Name() string { return "request" }
+
+func (s requestStep) Identify(_ context.Context, _ retrometadata.Provider, req Request) (*retrometadata.GameResult, error) {
+	*s.seen = req
+	return nil, nil
+}
+
+func TestPipelineDiscImageSerial(t *testing.T) {
+	// A GameCube disc header: the game ID, then the magic number at 0x1C
+	header := make([]byte, 0x440)
+	copy(header, "GZLE01")
+	binary.BigEndian.PutUint32(header[0x1C:], 0xC2339F3D)
+	path := filepath.Join(t.TempDir(), "Zelda.iso")
+	if err := os.WriteFile(path, header, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	var seen Request
+	_, _ = NewPipeline(requestStep{&seen}).Identify(context.Background(), []retrometadata.Provider{&fakeProvider{}}, Request{Filename: path})
+	if seen.Serial != "GZLE01" || seen.Platform != "ngc" {
+		t.Errorf("Request = (%q, %q), expected (GZLE01, ngc)", seen.Serial, seen.Platform)
+	}
+	if seen.Options.Serial != "GZLE01" {
+		t.Errorf("Options.Serial = %q, expected GZLE01", seen.Options.Serial)
+	}
+}
+
+func TestPipelineTitleContainerSerial(t *testing.T) {
+	// An NSP (a PFS0 partition) holding only a ticket named by rights ID
+	name := "01007ef00011e0000000000000000004.tik\x00"
+	nsp := make([]byte, 0x10+0x18)
+	copy(nsp, "PFS0")
+	binary.LittleEndian.PutUint32(nsp[4:], 1)
+	binary.LittleEndian.PutUint32(nsp[8:], uint32(len(name)))
+	nsp = append(nsp, name...)
+	path := filepath.Join(t.TempDir(), "a1b2c3d4.nsp")
+	if err := os.WriteFile(path, nsp, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	var seen Request
+	_, _ = NewPipeline(requestStep{&seen}).Identify(context.Background(), []retrometadata.Provider{&fakeProvider{}}, Request{Filename: path})
+	if seen.Serial != "01007EF00011E000" || seen.Platform != "switch" {
+		t.Errorf("Request = (%q, %q), expected (01007EF00011E000, switch)", seen.Serial, seen.Platform)
+	}
+}
+
+func TestPipelineHeaderTitle(t *testing.T) {
+	// A big-endian N64 ROM header titled SUPER MARIO 64
+	rom := make([]byte, 0x40)
+	binary.BigEndian.PutUint32(rom, 0x80371240)
+	copy(rom[0x20:], "SUPER MARIO 64")
+	path := filepath.Join(t.TempDir(), "rom001.z64")
+	if err := os.WriteFile(path, rom, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	provider := &fakeProvider{games: map[int]string{1: "Super Mario 64"}}
+	result, err := DefaultPipeline().Identify(context.Background(), []retrometadata.Provider{provider}, Request{Filename: path})
+	if err != nil {
+		t.Fatalf("Identify() error: %v", err)
+	}
+	if result.Name != "Super Mario 64" || result.MatchType != StepHeader {
+		t.Errorf("Identify() = (%q, %q), expected (Super Mario 64, %q)", result.Name, result.MatchType, StepHeader)
+	}
+
+	// NameOnly requests don't read the header
+	result, err = NewPipeline(HeaderStep{}).Identify(context.Background(), []retrometadata.Provider{provider}, Request{Filename: path, NameOnly: true})
+	if result != nil || !errors.Is(err, retrometadata.ErrGameNotFound) {
+		t.Errorf("Identify() of a NameOnly request = %+v, %v; expected no match", result, err)
+	}
+}
+
+// removingProvider removes a file when searched.
+type removingProvider struct {
+	*fakeProvider
+	path string
+}
+
+func (p removingProvider) Search(ctx context.Context, query string, opts retrometadata.SearchOptions) ([]retrometadata.SearchResult, error) {
+	if err := os.Remove(p.path); err != nil {
+		return nil, err
+	}
+	return p.fakeProvider.Search(ctx, query, opts)
+}
+
+func TestPipelineHeaderReadOnce(t *testing.T) {
+	rom := make([]byte, 0x40)
+	binary.BigEndian.PutUint32(rom, 0x80371240)
+	copy(rom[0x20:], "SUPER MARIO 64")
+	path := filepath.Join(t.TempDir(), "rom001.z64")
+	if err := os.WriteFile(path, rom, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	// The header read for the first provider is reused for the second,
+	// even though the file is gone by then
+	providers := []retrometadata.Provider{
+		removingProvider{&fakeProvider{}, path},
+		&fakeProvider{games: map[int]string{1: "Super Mario 64"}},
+	}
+	result, err := NewPipeline(HeaderStep{}).Identify(context.Background(), providers, Request{Filename: path})
+	if err != nil {
+		t.Fatalf("Identify() error: %v", err)
+	}
+	if result.Name != "Super Mario 64" {
+		t.Errorf("Identify() = %q, expected Super Mario 64", result.Name)
+	}
+}
+
+func TestPipelineSteps(t *testing.T) {
+	pipeline := DefaultPipeline().Remove(StepSerial).InsertBefore(StepHash, stubStep{})
+
+	expected := []string{StepTag, "stub", StepHash, StepFilename, StepFuzzy, StepHeader}
+	if names := pipeline.StepNames(); !reflect.DeepEqual(names, expected) {
+		t.Errorf("StepNames() = %v, expected %v", names, expected)
+	}
+
+	if err := pipeline.Reorder(StepFuzzy, StepTag); err != nil {
+		t.Fatalf("Reorder() error: %v", err)
+	}
+	if names := pipeline.StepNames(); !reflect.DeepEqual(names, []string{StepFuzzy, StepTag}) {
+		t.Errorf("StepNames() after Reorder = %v", names)
+	}
+
+	if err := pipeline.Reorder("missing"); err == nil {
+		t.Error("Expected error reordering with unknown step")
+	}
+}
+
+// errorStep fails for every provider.
+type errorStep struct{}
+
+func (errorStep) Name() string { return "error" }
+
+func (errorStep) Identify(context.Context, retrometadata.Provider, Request) (*retrometadata.GameResult, error) {
+	return nil, errors.New("unavailable")
+}
+
+func TestPipelineObserve(t *testing.T) {
+	var observed []string
+	pipeline := NewPipeline(errorStep{}, TagStep{}, stubStep{})
+	clone := pipeline.Clone().Observe(func(step, provider string, err error) {
+		observed = append(observed, fmt.Sprintf("%s/%s/%v", step, provider, err))
+	})
+
+	providers := []retrometadata.Provider{&fakeProvider{}}
+	if _, err := clone.Identify(context.Background(), providers, Request{Filename: "Game.sfc"}); err != nil {
+		t.Fatalf("Identify() error: %v", err)
+	}
+
+	// The tag step found nothing, so it isn't reported
+	expected := []string{"error/fake/unavailable", "stub/fake/<nil>"}
+	if !reflect.DeepEqual(observed, expected) {
+		t.Errorf("observed %v, expected %v", observed, expected)
+	}
+
+	observed = nil
+	_, _ = pipeline.Identify(context.Background(), providers, Request{Filename: "Game.sfc"})
+	if len(observed) != 0 {
+		t.Errorf("Expected the original pipeline to have no observers, got %v", observed)
+	}
+}
+
+func TestExtractSerial(t *testing.T) {
+	tests := map[string]string{
+		"SLUS_123.45.Game.iso":    "SLUS-12345",
+		"Game (SCUS-97328).bin":   "SCUS-97328",
+		"Super Mario World (USA)": "",
+	}
+
+	for input, expected := range tests {
+		if result := ExtractSerial(input); result != expected {
+			t.Errorf("ExtractSerial(%q) = %q, expected %q", input, result, expected)
+		}
+	}
+}
+
+func TestGroupDiscs(t *testing.T) {
+	files := []File{
+		{Path: "/roms/Final Fantasy VII (USA) (Disc 2).bin", Hashes: &retrometadata.FileHashes{MD5: "disc2"}},
+		{Path: "/roms/Super Mario World (USA).sfc", Hashes: &retrometadata.FileHashes{MD5: "smw"}},
+		{Path: "/roms/Final Fantasy VII (USA) (Disc 1).bin", Hashes: &retrometadata.FileHashes{MD5: "disc1"}},
+		{Path: "/roms/Burn:Cycle (Europe) (CDi).chd", Hashes: &retrometadata.FileHashes{MD5: "burncycle"}},
+	}
+
+	groups := GroupDiscs(files)
+	if len(groups) != 3 {
+		t.Fatalf("GroupDiscs() returned %d groups, expected 3", len(groups))
+	}
+
+	ff7 := groups[0]
+	if ff7.Filename != "/roms/Final Fantasy VII (USA).bin" || !ff7.IsMultiDisc() {
+		t.Errorf("Unexpected multi-disc group: %+v", ff7)
+	}
+	if len(ff7.Discs) != 2 || ff7.Discs[0].Number != 1 || ff7.Discs[0].Hashes.MD5 != "disc1" || ff7.Hashes.MD5 != "disc1" {
+		t.Errorf("Expected discs ordered by number with per-disc hashes, got %+v", ff7.Discs)
+	}
+
+	if groups[1].IsMultiDisc() || groups[1].Hashes.MD5 != "smw" {
+		t.Errorf("Unexpected single-file group: %+v", groups[1])
+	}
+	if groups[2].IsMultiDisc() || groups[2].Filename != "/roms/Burn:Cycle (Europe) (CDi).chd" {
+		t.Errorf("Unexpected CD-i group: %+v", groups[2])
+	}
+
+	provider := &fakeProvider{games: map[int]string{7: "Final Fantasy VII"}, md5: map[string]int{"disc1": 7}}
+	result, err := DefaultPipeline().IdentifyGroup(context.Background(), []retrometadata.Provider{provider}, ff7, retrometadata.IdentifyOptions{})
+	if err != nil {
+		t.Fatalf("IdentifyGroup() error: %v", err)
+	}
+	if result.Name != "Final Fantasy VII" || len(result.Discs) != 2 {
+		t.Errorf("IdentifyGroup() = %+v, expected Final Fantasy VII with 2 discs", result)
+	}
+}
+
+func TestResolvePlaylist(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+		return path
+	}
+
+	write("Game (Disc 1) (Track 1).bin", "disc one data track")
+	write("Game (Disc 1) (Track 2).bin", "audio")
+	write("Game (Disc 1).cue", "FILE \"Game (Disc 1) (Track 1).bin\" BINARY\n  TRACK 01 MODE2/2352\nFILE \"Game (Disc 1) (Track 2).bin\" BINARY\n  TRACK 02 AUDIO\n")
+	write("track01.bin", "boot")
+	write("track03.bin", "disc two high density area")
+	write("Game (Disc 2).gdi", "2\n1 0 4 2352 track01.bin 0\n3 45000 4 2352 \"track03.bin\" 0\n")
+	m3u := write("Game.m3u", "#EXTM3U\nGame (Disc 1).cue\nGame (Disc 2).gdi\n")
+
+	tracks, err := ParsePlaylist(filepath.Join(dir, "Game (Disc 2).gdi"))
+	if err != nil {
+		t.Fatalf("ParsePlaylist() error: %v", err)
+	}
+	if len(tracks) != 2 || tracks[1] != filepath.Join(dir, "track03.bin") {
+		t.Errorf("ParsePlaylist(gdi) = %v", tracks)
+	}
+
+	group, err := ResolvePlaylist(m3u)
+	if err != nil {
+		t.Fatalf("ResolvePlaylist() error: %v", err)
+	}
+	if group.Filename != m3u || len(group.Discs) != 2 {
+		t.Fatalf("ResolvePlaylist() = %+v, expected 2 discs", group)
+	}
+
+	disc1, _ := hashFile(filepath.Join(dir, "Game (Disc 1) (Track 1).bin"))
+	disc2, _ := hashFile(filepath.Join(dir, "track03.bin"))
+	if group.Discs[0].Hashes.MD5 != disc1.MD5 || group.Hashes.MD5 != disc1.MD5 {
+		t.Errorf("Expected disc 1 to be hashed from its data track")
+	}
+	if group.Discs[1].Hashes.MD5 != disc2.MD5 {
+		t.Errorf("Expected disc 2 to be hashed from its largest track")
+	}
+
+	provider := &fakeProvider{games: map[int]string{1: "Game"}, md5: map[string]int{disc1.MD5: 1}}
+	result, err := DefaultPipeline().IdentifyFile(context.Background(), []retrometadata.Provider{provider}, m3u, retrometadata.IdentifyOptions{})
+	if err != nil {
+		t.Fatalf("IdentifyFile() error: %v", err)
+	}
+	if result.Name != "Game" || result.MatchType != StepHash || len(result.Discs) != 2 {
+		t.Errorf("IdentifyFile() = %+v, expected hash match with 2 discs", result)
+	}
+}
+
+func TestIdentifyBatch(t *testing.T) {
+	provider := &fakeProvider{
+		games: map[int]string{1: "Super Mario World", 2: "Final Fantasy VII"},
+		md5:   map[string]int{"smw": 1},
+	}
+	groups := []DiscGroup{
+		{Filename: "Super Mario World (USA).sfc", Hashes: &retrometadata.FileHashes{MD5: "smw"}},
+		{Filename: "Unknown Game.bin"},
+		{Filename: "Game (fake-2).bin"},
+	}
+
+	tracker := progress.NewTracker()
+	results := DefaultPipeline().IdentifyBatch(context.Background(), []retrometadata.Provider{provider}, groups, BatchOptions{
+		Concurrency: 2,
+		Progress:    tracker,
+	})
+
+	if len(results) != 3 {
+		t.Fatalf("IdentifyBatch() returned %d results, expected 3", len(results))
+	}
+	if results[0].Result == nil || results[0].Result.Name != "Super Mario World" {
+		t.Errorf("Expected first group to match Super Mario World, got %+v", results[0])
+	}
+	if results[1].Err == nil {
+		t.Errorf("Expected error for unknown game, got %+v", results[1])
+	}
+	if results[2].Result == nil || results[2].Result.Name != "Final Fantasy VII" {
+		t.Errorf("Expected third group to match Final Fantasy VII, got %+v", results[2])
+	}
+
+	if snapshot := tracker.Snapshot(); snapshot.Total != 3 || snapshot.Completed != 3 || !snapshot.Done {
+		t.Errorf("Unexpected progress snapshot: %+v", snapshot)
+	}
+}
+
+// writeCHD writes a minimal v5 CHD header with a single metadata entry.
+func writeCHD(t *testing.T, path, tag, meta string, logicalBytes uint64) {
+	t.Helper()
+
+	header := make([]byte, 124)
+	copy(header, "MComprHD")
+	binary.BigEndian.PutUint32(header[8:], 124)
+	binary.BigEndian.PutUint32(header[12:], 5)
+	binary.BigEndian.PutUint64(header[32:], logicalBytes)
+	binary.BigEndian.PutUint64(header[48:], uint64(len(header)))
+
+	entry := make([]byte, 16)
+	copy(entry, tag)
+	entry[7] = byte(len(meta))
+
+	data := append(append(header, entry...), append([]byte(meta), 0)...)
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestExpectedSize(t *testing.T) {
+	dir := t.TempDir()
+
+	cd := filepath.Join(dir, "cd.chd")
+	writeCHD(t, cd, "CHT2", "TRACK:1 TYPE:MODE2_RAW SUBTYPE:NONE FRAMES:1000 PREGAP:0 PGTYPE:MODE1 PGSUB:RW POSTGAP:0", 999999)
+
+	dvd := filepath.Join(dir, "dvd.chd")
+	writeCHD(t, dvd, "DVD ", "", 4700000)
+
+	cso := filepath.Join(dir, "game.cso")
+	csoHeader := make([]byte, 24)
+	copy(csoHeader, "CISO")
+	binary.LittleEndian.PutUint64(csoHeader[8:], 1800000)
+	if err := os.WriteFile(cso, csoHeader, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	writeFile := func(name string, size int) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, make([]byte, size), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	writeFile("Game (Track 1).bin", 3000)
+	writeFile("Game (Track 2).bin", 500)
+	cue := filepath.Join(dir, "Game.cue")
+	cueSheet := "FILE \"Game (Track 1).bin\" BINARY\n  TRACK 01 MODE2/2352\nFILE \"Game (Track 2).bin\" BINARY\n  TRACK 02 AUDIO\n"
+	if err := os.WriteFile(cue, []byte(cueSheet), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	rom := writeFile("Game.sfc", 1234)
+
+	tests := []struct {
+		path     string
+		expected int64
+	}{
+		{cd, 1000 * 2352},
+		{dvd, 4700000},
+		{cso, 1800000},
+		{cue, 3500},
+		{rom, 1234},
+	}
+
+	for _, tt := range tests {
+		size, err := ExpectedSize(tt.path)
+		if err != nil {
+			t.Errorf("ExpectedSize(%s) error: %v", filepath.Base(tt.path), err)
+			continue
+		}
+		if size != tt.expected {
+			t.Errorf("ExpectedSize(%s) = %d, expected %d", filepath.Base(tt.path), size, tt.expected)
+		}
+	}
+
+	// Cue sheets are hashed by their data track, so the size matches that track
+	group, err := ResolvePlaylist(cue)
+	if err != nil {
+		t.Fatalf("ResolvePlaylist() error: %v", err)
+	}
+	if group.Hashes.Size != 3000 {
+		t.Errorf("ResolvePlaylist().Hashes.Size = %d, expected 3000", group.Hashes.Size)
+	}
+
+	if _, err := CHDDataSize(rom); err == nil {
+		t.Error("Expected error reading a non-CHD file")
+	}
+}
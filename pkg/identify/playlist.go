@@ -0,0 +1,205 @@
+package identify
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/josegonzalez/retro-metadata/pkg/internal/hashing"
+	"github.com/josegonzalez/retro-metadata/pkg/retrometadata"
+)
+
+var (
+	// cueFileRegex matches FILE lines in cue sheets: FILE "Game (Track 1).bin" BINARY
+	cueFileRegex = regexp.MustCompile(`(?i)^\s*FILE\s+(?:"([^"]+)"|(\S+))`)
+
+	// gdiTrackRegex matches track lines in gdi files: 3 45000 4 2352 "track03.bin" 0
+	gdiTrackRegex = regexp.MustCompile(`^\s*\d+\s+\d+\s+\d+\s+\d+\s+(?:"([^"]+)"|(\S+))`)
+)
+
+// IsPlaylist returns true if the file is an m3u, cue or gdi playlist.
+func IsPlaylist(path string) bool {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".m3u", ".m3u8", ".cue", ".gdi":
+		return true
+	default:
+		return false
+	}
+}
+
+// ParsePlaylist returns the files referenced by an m3u, cue or gdi playlist.
+// Relative references are resolved against the playlist's directory.
+func ParsePlaylist(path string) ([]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("opening playlist: %w", err)
+	}
+	defer file.Close()
+
+	ext := strings.ToLower(filepath.Ext(path))
+	dir := filepath.Dir(path)
+
+	var files []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(strings.TrimPrefix(scanner.Text(), "\ufeff"))
+
+		var ref string
+		switch ext {
+		case ".m3u", ".m3u8":
+			if line != "" && !strings.HasPrefix(line, "#") {
+				ref = line
+			}
+		case ".cue":
+			if match := cueFileRegex.FindStringSubmatch(line); match != nil {
+				ref = match[1] + match[2]
+			}
+		case ".gdi":
+			// The first line is the track count and doesn't match
+			if match := gdiTrackRegex.FindStringSubmatch(line); match != nil {
+				ref = match[1] + match[2]
+			}
+		default:
+			return nil, fmt.Errorf("unsupported playlist: %s", path)
+		}
+
+		if ref == "" {
+			continue
+		}
+		ref = filepath.Clean(filepath.FromSlash(strings.ReplaceAll(ref, `\`, "/")))
+		if ref == "." {
+			continue
+		}
+		if !filepath.IsAbs(ref) {
+			ref = filepath.Join(dir, ref)
+		}
+		files = append(files, ref)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("reading playlist: %w", err)
+	}
+
+	return files, nil
+}
+
+// ResolvePlaylist parses a playlist and hashes the game data it references.
+//
+// An m3u playlist becomes a multi-disc group with one disc per entry. Cue and
+// gdi sheets (including those referenced from an m3u) are hashed using their
+// largest track, which holds the game data rather than audio or the small
+// boot track.
+func ResolvePlaylist(path string) (DiscGroup, error) {
+	ext := strings.ToLower(filepath.Ext(path))
+	if ext != ".m3u" && ext != ".m3u8" {
+		hashes, err := hashDataTrack(path)
+		if err != nil {
+			return DiscGroup{}, err
+		}
+		return DiscGroup{Filename: path, Hashes: hashes}, nil
+	}
+
+	entries, err := ParsePlaylist(path)
+	if err != nil {
+		return DiscGroup{}, err
+	}
+	if len(entries) == 0 {
+		return DiscGroup{}, fmt.Errorf("playlist has no entries: %s", path)
+	}
+
+	group := DiscGroup{Filename: path}
+	for i, entry := range entries {
+		var hashes *retrometadata.FileHashes
+		if IsPlaylist(entry) {
+			hashes, err = hashDataTrack(entry)
+		} else {
+			hashes, err = hashFile(entry)
+		}
+		if err != nil {
+			return DiscGroup{}, err
+		}
+
+		group.Discs = append(group.Discs, retrometadata.Disc{
+			Number:   i + 1,
+			Filename: entry,
+			Hashes:   hashes,
+		})
+	}
+	group.Hashes = group.Discs[0].Hashes
+
+	// A single-entry m3u is just an indirection to one disc
+	if len(group.Discs) == 1 {
+		group.Discs = nil
+	}
+
+	return group, nil
+}
+
+// IdentifyFile identifies a ROM file on disk.
+// Playlists are resolved to the game data they reference before identification;
+// other files are hashed directly.
+func (p *Pipeline) IdentifyFile(ctx context.Context, providers []retrometadata.Provider, path string, opts retrometadata.IdentifyOptions) (*retrometadata.GameResult, error) {
+	if IsPlaylist(path) {
+		group, err := ResolvePlaylist(path)
+		if err != nil {
+			return nil, err
+		}
+		return p.IdentifyGroup(ctx, providers, group, opts)
+	}
+
+	hashes, err := hashFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return p.Identify(ctx, providers, Request{Filename: path, Hashes: hashes, Options: opts})
+}
+
+// hashDataTrack hashes the largest track referenced by a cue or gdi sheet.
+// The reported size is that of the hashed track, so it matches the hashes.
+func hashDataTrack(path string) (*retrometadata.FileHashes, error) {
+	tracks, err := ParsePlaylist(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var largest string
+	var largestSize int64 = -1
+	for _, track := range tracks {
+		info, err := os.Stat(track)
+		if err != nil {
+			return nil, fmt.Errorf("stat track: %w", err)
+		}
+		if info.Size() > largestSize {
+			largest = track
+			largestSize = info.Size()
+		}
+	}
+
+	if largest == "" {
+		return nil, fmt.Errorf("playlist has no tracks: %s", path)
+	}
+	return hashFile(largest)
+}
+
+// hashFile computes the hashes of a file.
+func hashFile(path string) (*retrometadata.FileHashes, error) {
+	fileHashes, err := hashing.ComputeFileHashes(path)
+	if err != nil {
+		return nil, err
+	}
+
+	hashes := &retrometadata.FileHashes{
+		MD5:    fileHashes.MD5,
+		SHA1:   fileHashes.SHA1,
+		CRC32:  fileHashes.CRC32,
+		SHA256: fileHashes.SHA256,
+	}
+	if size, err := ExpectedSize(path); err == nil {
+		hashes.Size = size
+	}
+	return hashes, nil
+}
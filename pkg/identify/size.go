@@ -0,0 +1,224 @@
+package identify
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	// chdMagic is the signature at the start of every CHD file
+	chdMagic = []byte("MComprHD")
+
+	// csoMagic is the signature at the start of a compressed ISO
+	csoMagic = []byte("CISO")
+
+	// chdTrackRegex matches CHD CD track metadata:
+	// TRACK:1 TYPE:MODE2_RAW SUBTYPE:NONE FRAMES:230025 PREGAP:0 PGTYPE:MODE1 ...
+	chdTrackRegex = regexp.MustCompile(`TRACK:(\d+) TYPE:(\S+) SUBTYPE:\S+ FRAMES:(\d+)(?: PREGAP:(\d+) PGTYPE:(\S+))?`)
+)
+
+// chdTrackTags are the CHD metadata tags holding text CD track information.
+var chdTrackTags = map[string]bool{
+	"CHTR": true, // CD track (v3)
+	"CHT2": true, // CD track with pregap (v4/v5)
+	"CHGT": true, // GD-ROM track
+	"CHGD": true, // GD-ROM track (v5)
+}
+
+// chdSectorSizes are the bytes per frame stored in a bin file for each CHD track type.
+var chdSectorSizes = map[string]int64{
+	"MODE1":          2048,
+	"MODE1/2048":     2048,
+	"MODE1_RAW":      2352,
+	"MODE1/2352":     2352,
+	"MODE2":          2336,
+	"MODE2/2336":     2336,
+	"MODE2_FORM1":    2048,
+	"MODE2/2048":     2048,
+	"MODE2_FORM2":    2324,
+	"MODE2/2324":     2324,
+	"MODE2_FORM_MIX": 2336,
+	"MODE2_RAW":      2352,
+	"MODE2/2352":     2352,
+	"AUDIO":          2352,
+}
+
+// ExpectedSize returns the size of the game data in a ROM file, as recorded
+// by databases like ScreenScraper (romtaille) rather than the container size:
+//   - .chd: the size of the first data track as a bin file, or the
+//     uncompressed size for DVD and hard disk images
+//   - .cso: the uncompressed ISO size
+//   - .cue and .gdi: the combined size of every referenced track
+//   - anything else: the file size
+func ExpectedSize(path string) (int64, error) {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".chd":
+		return CHDDataSize(path)
+	case ".cso":
+		return CSOSize(path)
+	case ".cue", ".gdi":
+		return PlaylistSize(path)
+	default:
+		info, err := os.Stat(path)
+		if err != nil {
+			return 0, err
+		}
+		return info.Size(), nil
+	}
+}
+
+// PlaylistSize returns the combined size of every file referenced by a cue or gdi sheet.
+func PlaylistSize(path string) (int64, error) {
+	tracks, err := ParsePlaylist(path)
+	if err != nil {
+		return 0, err
+	}
+	if len(tracks) == 0 {
+		return 0, fmt.Errorf("playlist has no tracks: %s", path)
+	}
+
+	var total int64
+	for _, track := range tracks {
+		info, err := os.Stat(track)
+		if err != nil {
+			return 0, fmt.Errorf("stat track: %w", err)
+		}
+		total += info.Size()
+	}
+	return total, nil
+}
+
+// CSOSize returns the uncompressed size of a CSO (compressed ISO) image.
+func CSOSize(path string) (int64, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer file.Close()
+
+	header := make([]byte, 16)
+	if _, err := io.ReadFull(file, header); err != nil {
+		return 0, fmt.Errorf("reading cso header: %w", err)
+	}
+	if !bytes.Equal(header[:4], csoMagic) {
+		return 0, fmt.Errorf("not a cso file: %s", path)
+	}
+	size := binary.LittleEndian.Uint64(header[8:16])
+	if size > math.MaxInt64 {
+		return 0, fmt.Errorf("invalid cso size: %s", path)
+	}
+	return int64(size), nil
+}
+
+// CHDDataSize returns the size of the game data in a CHD image.
+//
+// For CD images this is the size the first track would have as a bin file
+// (frames × bytes per sector), since the CHD's logical size includes subcode
+// and padding. DVD and hard disk images return their logical size.
+func CHDDataSize(path string) (int64, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer file.Close()
+
+	header := make([]byte, 64)
+	if _, err := io.ReadFull(file, header); err != nil {
+		return 0, fmt.Errorf("reading chd header: %w", err)
+	}
+	if !bytes.Equal(header[:8], chdMagic) {
+		return 0, fmt.Errorf("not a chd file: %s", path)
+	}
+
+	var logicalBytes, metaOffset uint64
+	switch version := binary.BigEndian.Uint32(header[12:16]); version {
+	case 3, 4:
+		logicalBytes = binary.BigEndian.Uint64(header[28:36])
+		metaOffset = binary.BigEndian.Uint64(header[36:44])
+	case 5:
+		logicalBytes = binary.BigEndian.Uint64(header[32:40])
+		metaOffset = binary.BigEndian.Uint64(header[48:56])
+	default:
+		return 0, fmt.Errorf("unsupported chd version %d: %s", version, path)
+	}
+
+	size, ok, err := chdFirstTrackSize(file, metaOffset)
+	if err != nil {
+		return 0, err
+	}
+	if ok {
+		return size, nil
+	}
+	if logicalBytes > math.MaxInt64 {
+		return 0, fmt.Errorf("invalid chd size: %s", path)
+	}
+	return int64(logicalBytes), nil
+}
+
+// chdFirstTrackSize walks the CHD metadata list and returns the bin size of
+// the first track. Returns false if the image has no CD track metadata.
+func chdFirstTrackSize(r io.ReaderAt, offset uint64) (int64, bool, error) {
+	entry := make([]byte, 16)
+	seen := make(map[uint64]bool)
+
+	for offset != 0 && !seen[offset] {
+		seen[offset] = true
+
+		if _, err := r.ReadAt(entry, int64(offset)); err != nil {
+			return 0, false, fmt.Errorf("reading chd metadata: %w", err)
+		}
+		tag := string(entry[:4])
+		length := uint32(entry[5])<<16 | uint32(entry[6])<<8 | uint32(entry[7])
+		next := binary.BigEndian.Uint64(entry[8:16])
+
+		if chdTrackTags[tag] {
+			data := make([]byte, length)
+			if _, err := r.ReadAt(data, int64(offset)+16); err != nil {
+				return 0, false, fmt.Errorf("reading chd track metadata: %w", err)
+			}
+			if size, ok := parseCHDTrack(data); ok {
+				return size, true, nil
+			}
+		}
+
+		offset = next
+	}
+
+	return 0, false, nil
+}
+
+// parseCHDTrack returns the bin size of track 1 from CHD text track metadata.
+func parseCHDTrack(data []byte) (int64, bool) {
+	match := chdTrackRegex.FindSubmatch(bytes.TrimRight(data, "\x00"))
+	if match == nil || string(match[1]) != "1" {
+		return 0, false
+	}
+
+	sectorSize, ok := chdSectorSizes[string(match[2])]
+	if !ok {
+		return 0, false
+	}
+	frames, err := strconv.ParseInt(string(match[3]), 10, 64)
+	if err != nil {
+		return 0, false
+	}
+
+	// A pregap stored in the image ("V" pgtype) isn't part of the bin track
+	if len(match[4]) > 0 && strings.HasPrefix(string(match[5]), "V") {
+		pregap, _ := strconv.ParseInt(string(match[4]), 10, 64)
+		frames -= pregap
+	}
+	if frames < 0 || frames > math.MaxInt64/sectorSize {
+		return 0, false
+	}
+
+	return frames * sectorSize, true
+}
@@ -2,6 +2,7 @@
 package hashing
 
 import (
+	"context"
 	"crypto/md5"
 	"crypto/sha1"
 	"crypto/sha256"
@@ -31,6 +32,36 @@ func ComputeFileHashes(path string) (*FileHashes, error) {
 	return ComputeReaderHashes(file)
 }
 
+// ComputeFileHashesContext computes all hashes for a file, stopping as soon as
+// ctx is cancelled rather than after the whole file has been read.
+func ComputeFileHashesContext(ctx context.Context, path string) (*FileHashes, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("opening file: %w", err)
+	}
+	defer file.Close()
+
+	hashes, err := ComputeReaderHashes(&contextReader{ctx: ctx, r: file})
+	if err != nil && ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+	return hashes, err
+}
+
+// contextReader is a reader that fails once its context is cancelled.
+type contextReader struct {
+	ctx context.Context
+	r   io.Reader
+}
+
+// Read reads from the underlying reader unless the context is done.
+func (r *contextReader) Read(p []byte) (int, error) {
+	if err := r.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return r.r.Read(p)
+}
+
 // ComputeReaderHashes computes all hashes from a reader.
 func ComputeReaderHashes(r io.Reader) (*FileHashes, error) {
 	md5Hash := md5.New()
@@ -0,0 +1,82 @@
+package index
+
+import (
+	"encoding/gob"
+	"os"
+	"path/filepath"
+	"slices"
+)
+
+// fileHeader precedes the data of a saved index.
+type fileHeader struct {
+	Version int
+	Sources []sourceVersion
+}
+
+// sourceVersion identifies the contents of a file an index was built from.
+type sourceVersion struct {
+	Path    string
+	Size    int64
+	ModTime int64
+}
+
+// statSources returns the versions of source files. Missing files have
+// the zero size and modification time.
+func statSources(paths []string) []sourceVersion {
+	versions := make([]sourceVersion, len(paths))
+	for i, path := range paths {
+		versions[i].Path = path
+		if info, err := os.Stat(path); err == nil {
+			versions[i].Size = info.Size()
+			versions[i].ModTime = info.ModTime().UnixNano()
+		}
+	}
+	return versions
+}
+
+// Save writes an index built from the source files to path, gob encoded,
+// so a later Load can skip rebuilding it. version identifies the format of
+// data and must change whenever it does. The file is written to a temporary
+// file first, so an interrupted save never leaves a truncated index behind.
+func Save(path string, version int, sources []string, data any) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".index-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	encoder := gob.NewEncoder(tmp)
+	err = encoder.Encode(fileHeader{Version: version, Sources: statSources(sources)})
+	if err == nil {
+		err = encoder.Encode(data)
+	}
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// Load reads an index saved with Save into data, which must be a pointer.
+// It returns false, leaving data untouched or partially filled in, if there's
+// no saved index, it has another version, or any of the source files
+// changed since it was saved.
+func Load(path string, version int, sources []string, data any) bool {
+	file, err := os.Open(path)
+	if err != nil {
+		return false
+	}
+	defer file.Close()
+
+	decoder := gob.NewDecoder(file)
+	var header fileHeader
+	if err := decoder.Decode(&header); err != nil {
+		return false
+	}
+	if header.Version != version || !slices.Equal(header.Sources, statSources(sources)) {
+		return false
+	}
+	return decoder.Decode(data) == nil
+}
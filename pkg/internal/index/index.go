@@ -0,0 +1,121 @@
+// Package index provides compact lookup tables for large local databases.
+//
+// Providers that load hundreds of thousands of entries (LaunchBox metadata,
+// datfiles) index them with sorted slices and binary search instead of nested
+// maps, and intern repeated strings, so the whole index fits in tens of
+// megabytes on low-memory devices like handhelds.
+package index
+
+import (
+	"cmp"
+	"encoding/hex"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// Sorted maps keys to integer values, typically positions in a slice of
+// records. Keys may repeat. Add entries, then call Build before looking up.
+//
+// A Sorted index is safe for concurrent lookups once built.
+type Sorted[K cmp.Ordered] struct {
+	keys   []K
+	values []int32
+}
+
+// Add adds a key and its value. The index must be rebuilt before lookups.
+func (s *Sorted[K]) Add(key K, value int) {
+	s.keys = append(s.keys, key)
+	s.values = append(s.values, int32(value))
+}
+
+// Build sorts the index and releases unused capacity. Values with equal keys
+// keep the order they were added in.
+func (s *Sorted[K]) Build() {
+	sort.Stable(sortable[K]{s})
+	s.keys = slices.Clip(s.keys)
+	s.values = slices.Clip(s.values)
+}
+
+// Lookup returns the values for a key, in the order they were added.
+// The returned slice is shared with the index and must not be modified.
+func (s *Sorted[K]) Lookup(key K) []int32 {
+	lo, _ := slices.BinarySearch(s.keys, key)
+	hi := lo
+	for hi < len(s.keys) && s.keys[hi] == key {
+		hi++
+	}
+	return s.values[lo:hi]
+}
+
+// Get returns the first value added for a key.
+func (s *Sorted[K]) Get(key K) (int, bool) {
+	values := s.Lookup(key)
+	if len(values) == 0 {
+		return 0, false
+	}
+	return int(values[0]), true
+}
+
+// Len returns the number of entries in the index.
+func (s *Sorted[K]) Len() int {
+	return len(s.keys)
+}
+
+// Key returns the i-th key in sorted order.
+func (s *Sorted[K]) Key(i int) K {
+	return s.keys[i]
+}
+
+// Value returns the i-th value in key order.
+func (s *Sorted[K]) Value(i int) int {
+	return int(s.values[i])
+}
+
+// Reset removes every entry.
+func (s *Sorted[K]) Reset() {
+	s.keys = nil
+	s.values = nil
+}
+
+// sortable sorts the keys and values of an index together.
+type sortable[K cmp.Ordered] struct {
+	s *Sorted[K]
+}
+
+func (x sortable[K]) Len() int           { return len(x.s.keys) }
+func (x sortable[K]) Less(i, j int) bool { return x.s.keys[i] < x.s.keys[j] }
+func (x sortable[K]) Swap(i, j int) {
+	x.s.keys[i], x.s.keys[j] = x.s.keys[j], x.s.keys[i]
+	x.s.values[i], x.s.values[j] = x.s.values[j], x.s.values[i]
+}
+
+// Interner deduplicates strings, so values repeated across many records
+// (field names, platforms, genres, publishers) are stored once.
+type Interner struct {
+	strings map[string]string
+}
+
+// NewInterner creates an empty Interner.
+func NewInterner() *Interner {
+	return &Interner{strings: make(map[string]string)}
+}
+
+// Intern returns a canonical copy of s.
+func (in *Interner) Intern(s string) string {
+	if interned, ok := in.strings[s]; ok {
+		return interned
+	}
+	in.strings[s] = s
+	return s
+}
+
+// HashKey converts a hex-encoded hash into a compact index key: the raw bytes,
+// half the size of the hex string. Values that aren't valid hex are lowercased instead.
+func HashKey(hash string) string {
+	decoded, err := hex.DecodeString(hash)
+	if err != nil {
+		return strings.ToLower(hash)
+	}
+	return string(decoded)
+}
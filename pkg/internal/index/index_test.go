@@ -0,0 +1,82 @@
+package index
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+	"unsafe"
+)
+
+func TestSorted(t *testing.T) {
+	var s Sorted[string]
+	s.Add("zelda", 0)
+	s.Add("mario", 1)
+	s.Add("zelda", 2)
+	s.Add("metroid", 3)
+	s.Build()
+
+	if got := s.Lookup("zelda"); !slices.Equal(got, []int32{0, 2}) {
+		t.Errorf("Lookup(zelda) = %v, expected [0 2]", got)
+	}
+	if got := s.Lookup("kirby"); len(got) != 0 {
+		t.Errorf("Lookup(kirby) = %v, expected none", got)
+	}
+	if v, ok := s.Get("metroid"); !ok || v != 3 {
+		t.Errorf("Get(metroid) = (%d, %v), expected (3, true)", v, ok)
+	}
+	if s.Len() != 4 || s.Key(0) != "mario" || s.Value(0) != 1 {
+		t.Errorf("Unexpected sorted order: first key %q value %d", s.Key(0), s.Value(0))
+	}
+}
+
+func TestInterner(t *testing.T) {
+	in := NewInterner()
+	a := in.Intern(string([]byte("Nintendo Entertainment System")))
+	b := in.Intern(string([]byte("Nintendo Entertainment System")))
+	if unsafe.StringData(a) != unsafe.StringData(b) {
+		t.Fatal("Expected interned strings to share storage")
+	}
+	if len(in.strings) != 1 {
+		t.Errorf("Expected 1 interned string, got %d", len(in.strings))
+	}
+}
+
+func TestHashKey(t *testing.T) {
+	if key := HashKey("D41D8CD98F00B204E9800998ECF8427E"); len(key) != 16 || key != HashKey("d41d8cd98f00b204e9800998ecf8427e") {
+		t.Errorf("HashKey() = %q, expected 16 raw bytes independent of case", key)
+	}
+	if key := HashKey("NotHex"); key != "nothex" {
+		t.Errorf("HashKey(NotHex) = %q, expected lowercased fallback", key)
+	}
+}
+
+func TestSaveLoad(t *testing.T) {
+	dir := t.TempDir()
+	source := filepath.Join(dir, "games.dat")
+	if err := os.WriteFile(source, []byte("games"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	path := source + ".index"
+	if err := Save(path, 1, []string{source}, []string{"mario", "zelda"}); err != nil {
+		t.Fatalf("Save() error: %v", err)
+	}
+
+	var names []string
+	if !Load(path, 1, []string{source}, &names) || !slices.Equal(names, []string{"mario", "zelda"}) {
+		t.Fatalf("Load() = %v, want the saved index", names)
+	}
+	if Load(path, 2, []string{source}, &names) {
+		t.Error("Load() read an index of another version")
+	}
+
+	if err := os.WriteFile(source, []byte("more games"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	later := time.Now().Add(time.Minute)
+	_ = os.Chtimes(source, later, later)
+	if Load(path, 1, []string{source}, &names) {
+		t.Error("Load() read an index whose source changed")
+	}
+}
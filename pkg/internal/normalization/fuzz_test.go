@@ -0,0 +1,32 @@
+package normalization
+
+import (
+	"testing"
+	"unicode/utf8"
+)
+
+func FuzzNormalizeGameName(f *testing.F) {
+	for _, seed := range []string{
+		"Legend of Zelda, The - A Link to the Past",
+		"The Legend of Zelda: A Link to the Past",
+		"Final Fantasy VII",
+		"Pokémon Rouge & Bleu",
+		"__the__",
+		"~-:",
+		"",
+	} {
+		f.Add(seed)
+	}
+	f.Fuzz(func(t *testing.T, name string) {
+		if !utf8.ValidString(name) {
+			return
+		}
+		normalized := NormalizeGameName(name)
+		if !utf8.ValidString(normalized) {
+			t.Errorf("NormalizeGameName(%q) = %q is not valid UTF-8", name, normalized)
+		}
+		NormalizeSearchTerm(name, false, false)
+		SplitSearchTerm(name)
+		NormalizeForAPI(name)
+	})
+}
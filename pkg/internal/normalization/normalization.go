@@ -29,6 +29,21 @@ var (
 	// searchTermNormalizer normalizes colon/dash patterns
 	searchTermNormalizer = regexp.MustCompile(`\s*[:-]\s+`)
 
+	// subtitleSeparatorPattern splits a title into its subtitle segments
+	subtitleSeparatorPattern = regexp.MustCompile(`\s*:\s*|\s+-\s+|\s+~\s+`)
+
+	// trailingArticlePattern matches a trailing comma-separated article ("Zelda, The")
+	trailingArticlePattern = regexp.MustCompile(`(?i),\s*(a|an|the)\s*$`)
+
+	// romanNumerals maps roman numeral tokens to digits. "i" and "x" are left
+	// alone since they are commonly words or part of a title ("Mega Man X").
+	romanNumerals = map[string]string{
+		"ii": "2", "iii": "3", "iv": "4", "v": "5", "vi": "6", "vii": "7",
+		"viii": "8", "ix": "9", "xi": "11", "xii": "12", "xiii": "13",
+		"xiv": "14", "xv": "15", "xvi": "16", "xvii": "17", "xviii": "18",
+		"xix": "19", "xx": "20",
+	}
+
 	// sensitiveKeys is the set of keys that should be masked in URLs
 	sensitiveKeys = map[string]bool{
 		"authorization":  true,
@@ -45,6 +60,18 @@ var (
 	}
 )
 
+// Normalization steps reported by NormalizeGameNameSteps.
+const (
+	StepLowercase     = "lowercase"
+	StepUnderscores   = "underscores"
+	StepAmpersand     = "ampersand"
+	StepSubtitles     = "subtitle separators"
+	StepArticles      = "articles"
+	StepPunctuation   = "punctuation"
+	StepAccents       = "accents"
+	StepRomanNumerals = "roman numerals"
+)
+
 // NormalizeSearchTerm normalizes a search term for comparison.
 // It performs the following transformations:
 // - Converts to lowercase
@@ -53,25 +80,31 @@ var (
 // - Optionally removes punctuation
 // - Normalizes Unicode characters and removes accents
 func NormalizeSearchTerm(name string, removeArticles, removePunctuation bool) string {
+	return normalizeSearchTerm(name, removeArticles, removePunctuation, nil)
+}
+
+// normalizeSearchTerm is NormalizeSearchTerm, calling fired with the name of
+// every step that changes the term if it isn't nil.
+func normalizeSearchTerm(name string, removeArticles, removePunctuation bool, fired func(step string)) string {
+	step := stepper(fired)
+
 	// Lowercase and replace underscores
-	name = strings.ToLower(name)
-	name = strings.ReplaceAll(name, "_", " ")
+	name = step(StepLowercase, name, strings.ToLower(name))
+	name = step(StepUnderscores, name, strings.ReplaceAll(name, "_", " "))
 
 	// Remove articles
 	if removeArticles {
-		name = leadingArticlePattern.ReplaceAllString(name, "")
-		name = commaArticlePattern.ReplaceAllString(name, "")
+		name = step(StepArticles, name, commaArticlePattern.ReplaceAllString(leadingArticlePattern.ReplaceAllString(name, ""), ""))
 	}
 
 	// Remove punctuation and normalize spaces
 	if removePunctuation {
-		name = nonWordSpacePattern.ReplaceAllString(name, " ")
-		name = multipleSpacePattern.ReplaceAllString(name, " ")
+		name = step(StepPunctuation, name, multipleSpacePattern.ReplaceAllString(nonWordSpacePattern.ReplaceAllString(name, " "), " "))
 	}
 
 	// Unicode normalization and accent removal
 	if hasNonASCII(name) {
-		name = removeAccents(name)
+		name = step(StepAccents, name, removeAccents(name))
 	}
 
 	return strings.TrimSpace(name)
@@ -82,6 +115,70 @@ func NormalizeSearchTermDefault(name string) string {
 	return NormalizeSearchTerm(name, true, true)
 }
 
+// NormalizeGameName normalizes a game title for fuzzy matching.
+// In addition to NormalizeSearchTermDefault it:
+// - Strips leading and trailing articles from every subtitle segment, so
+//   "Legend of Zelda, The - A Link to the Past" and
+//   "The Legend of Zelda: A Link to the Past" normalize identically
+// - Treats "-", ":" and "~" subtitle separators as equivalent
+// - Replaces "&" with "and"
+// - Converts roman numerals to digits ("Final Fantasy VII" -> "final fantasy 7")
+func NormalizeGameName(name string) string {
+	return normalizeGameName(name, nil)
+}
+
+// NormalizeGameNameSteps returns NormalizeGameName(name) and the steps that
+// changed it, in the order they first fired, for explaining matches.
+func NormalizeGameNameSteps(name string) (string, []string) {
+	var steps []string
+	normalized := normalizeGameName(name, func(step string) {
+		for _, s := range steps {
+			if s == step {
+				return
+			}
+		}
+		steps = append(steps, step)
+	})
+	return normalized, steps
+}
+
+// normalizeGameName is NormalizeGameName, calling fired with the name of
+// every step that changes the name if it isn't nil.
+func normalizeGameName(name string, fired func(step string)) string {
+	step := stepper(fired)
+	name = step(StepUnderscores, name, strings.ReplaceAll(name, "_", " "))
+	name = step(StepAmpersand, name, strings.ReplaceAll(name, "&", " and "))
+
+	segments := subtitleSeparatorPattern.Split(strings.TrimSpace(name), -1)
+	if len(segments) > 1 && fired != nil {
+		fired(StepSubtitles)
+	}
+	for i, segment := range segments {
+		segment = step(StepArticles, segment, trailingArticlePattern.ReplaceAllString(segment, ""))
+		segments[i] = normalizeSearchTerm(segment, true, true, fired)
+	}
+
+	tokens := strings.Fields(strings.Join(segments, " "))
+	for i, token := range tokens {
+		if digit, ok := romanNumerals[token]; ok {
+			tokens[i] = step(StepRomanNumerals, token, digit)
+		}
+	}
+
+	return strings.Join(tokens, " ")
+}
+
+// stepper returns a function returning the result of a step, and calling
+// fired with its name if it changed the input.
+func stepper(fired func(step string)) func(step, before, after string) string {
+	return func(step, before, after string) string {
+		if fired != nil && before != after {
+			fired(step)
+		}
+		return after
+	}
+}
+
 // hasNonASCII checks if the string contains non-ASCII characters.
 func hasNonASCII(s string) bool {
 	for _, r := range s {
@@ -75,6 +75,27 @@ func TestNormalizeSearchTermDefault(t *testing.T) {
 	}
 }
 
+func TestNormalizeGameName(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"Legend of Zelda, The - A Link to the Past", "legend of zelda link to the past"},
+		{"The Legend of Zelda: A Link to the Past", "legend of zelda link to the past"},
+		{"Final Fantasy VII", "final fantasy 7"},
+		{"Mega Man X", "mega man x"},
+		{"Ratchet & Clank", "ratchet and clank"},
+		{"Spider-Man", "spider man"},
+	}
+
+	for _, tt := range tests {
+		result := NormalizeGameName(tt.input)
+		if result != tt.expected {
+			t.Errorf("NormalizeGameName(%q) = %q, expected %q", tt.input, result, tt.expected)
+		}
+	}
+}
+
 func TestNormalizeCoverURL(t *testing.T) {
 	tests := []struct {
 		input    string
@@ -210,3 +231,18 @@ func TestHasNonASCII(t *testing.T) {
 		}
 	}
 }
+
+func TestNormalizeGameNameSteps(t *testing.T) {
+	name, steps := NormalizeGameNameSteps("Legend of Zelda, The - Link's Awakening DX")
+	if name != NormalizeGameName("Legend of Zelda, The - Link's Awakening DX") {
+		t.Errorf("NormalizeGameNameSteps() = %q, expected NormalizeGameName's result", name)
+	}
+	expected := []string{StepSubtitles, StepArticles, StepLowercase, StepPunctuation}
+	if strings.Join(steps, ",") != strings.Join(expected, ",") {
+		t.Errorf("NormalizeGameNameSteps() steps = %v, expected %v", steps, expected)
+	}
+
+	if _, steps := NormalizeGameNameSteps("tetris"); len(steps) != 0 {
+		t.Errorf("NormalizeGameNameSteps(tetris) steps = %v, expected none", steps)
+	}
+}
@@ -0,0 +1,356 @@
+// Package library keeps a local database of a ROM collection: every file, its
+// hashes, what it was identified as and when.
+//
+// A Library is backed by a Store. NewSQLStore persists it with database/sql;
+// its SQL is written for SQLite. The library doesn't register a driver; the
+// program imports one and opens the library with the name it registers, such
+// as the pure-Go modernc.org/sqlite ("sqlite"):
+//
+//	import _ "modernc.org/sqlite"
+//
+//	lib, err := library.Open("sqlite", "library.db")
+//
+// or the cgo-based github.com/mattn/go-sqlite3 ("sqlite3").
+//
+// Rescans are compared against the library with Diff, which reports new,
+// changed, moved and deleted files, so only new and changed files need to be
+// identified again.
+package library
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/josegonzalez/retro-metadata/pkg/export"
+	"github.com/josegonzalez/retro-metadata/pkg/identify"
+	"github.com/josegonzalez/retro-metadata/pkg/platform"
+	"github.com/josegonzalez/retro-metadata/pkg/retrometadata"
+)
+
+// Game is a file in the library and what it was identified as.
+type Game struct {
+	// ID is the library's ID for the game
+	ID int64 `json:"id"`
+	// Path is the ROM file path (the group filename for multi-disc sets)
+	Path string `json:"path"`
+	// Size is the file size in bytes
+	Size int64 `json:"size"`
+	// ModTime is the file's modification time when it was recorded
+	ModTime time.Time `json:"mod_time"`
+	// Hashes are the file's hashes, lowercase
+	Hashes retrometadata.FileHashes `json:"hashes"`
+	// Platform is the platform slug detected from the path
+	Platform string `json:"platform,omitempty"`
+	// Name is the identified game name, or empty if it wasn't identified
+	Name string `json:"name,omitempty"`
+	// Provider is the provider the game was identified by
+	Provider string `json:"provider,omitempty"`
+	// ProviderIDs maps provider names to the game's IDs, integer IDs included
+	ProviderIDs map[string]string `json:"provider_ids,omitempty"`
+	// Result is the full identification result
+	Result *retrometadata.GameResult `json:"result,omitempty"`
+	// Error is the identification error, if identification failed
+	Error string `json:"error,omitempty"`
+	// AddedAt is when the file was first added to the library
+	AddedAt time.Time `json:"added_at"`
+	// UpdatedAt is when the game was last recorded
+	UpdatedAt time.Time `json:"updated_at"`
+	// IdentifiedAt is when the game was last identified, or zero
+	IdentifiedAt time.Time `json:"identified_at,omitempty"`
+}
+
+// Identified returns true if the game was identified.
+func (g *Game) Identified() bool {
+	return g.Result != nil
+}
+
+// clone returns a copy of the game that doesn't share its maps.
+func (g *Game) clone() *Game {
+	c := *g
+	c.ProviderIDs = maps.Clone(g.ProviderIDs)
+	return &c
+}
+
+// Library is a local database of a ROM collection.
+type Library struct {
+	store Store
+	now   func() time.Time
+}
+
+// New creates a library backed by store.
+func New(store Store) *Library {
+	return &Library{store: store, now: time.Now}
+}
+
+// Close closes the library's store.
+func (l *Library) Close() error {
+	return l.store.Close()
+}
+
+// Record adds or updates the library entry for an identification result.
+// The time the file was first added is kept.
+func (l *Library) Record(ctx context.Context, entry export.Entry) error {
+	now := l.now()
+	game := &Game{
+		Path:        entry.Path,
+		Platform:    string(platform.DetectFromPath(entry.Path)),
+		ProviderIDs: map[string]string{},
+		AddedAt:     now,
+		UpdatedAt:   now,
+	}
+	game.ModTime, game.Size = stat(entry.Path)
+	if entry.Hashes != nil {
+		game.Hashes = normalizeHashes(*entry.Hashes)
+	}
+
+	switch {
+	case entry.Game != nil:
+		result := entry.Game
+		game.Name = result.Name
+		game.Provider = result.Provider
+		game.Result = result
+		game.IdentifiedAt = now
+		for name, id := range result.ProviderIDs {
+			game.ProviderIDs[name] = strconv.Itoa(id)
+		}
+		for name, uid := range result.ProviderUIDs {
+			game.ProviderIDs[name] = uid
+		}
+		if result.ProviderID != nil {
+			game.ProviderIDs[result.Provider] = strconv.Itoa(*result.ProviderID)
+		} else if result.ProviderUID != "" {
+			game.ProviderIDs[result.Provider] = result.ProviderUID
+		}
+	case entry.Err != nil:
+		game.Error = entry.Err.Error()
+	}
+
+	existing, err := l.store.Get(ctx, entry.Path)
+	switch {
+	case err == nil:
+		game.AddedAt = existing.AddedAt
+		if game.Result == nil && game.Error == "" {
+			// Nothing was identified this time; keep the previous result
+			game.Name, game.Provider, game.Result = existing.Name, existing.Provider, existing.Result
+			game.ProviderIDs, game.IdentifiedAt = existing.ProviderIDs, existing.IdentifiedAt
+		}
+	case !errors.Is(err, ErrNotFound):
+		return err
+	}
+
+	return l.store.Put(ctx, game)
+}
+
+// RecordBatch records every result of a batch identification.
+func (l *Library) RecordBatch(ctx context.Context, results []identify.BatchResult) error {
+	for _, entry := range export.EntriesFromBatch(results) {
+		if err := l.Record(ctx, entry); err != nil {
+			return fmt.Errorf("recording %s: %w", entry.Path, err)
+		}
+	}
+	return nil
+}
+
+// Name returns "library", so a Library can be used as a scrape.Sink.
+func (l *Library) Name() string {
+	return "library"
+}
+
+// Write records entries, so a Library can be used as a scrape.Sink.
+func (l *Library) Write(ctx context.Context, entries []export.Entry) (*export.ExportReport, error) {
+	report := &export.ExportReport{Exporter: l.Name()}
+	for _, entry := range entries {
+		if err := l.Record(ctx, entry); err != nil {
+			return report, fmt.Errorf("recording %s: %w", entry.Path, err)
+		}
+		report.Written++
+	}
+	return report, nil
+}
+
+// Get returns the game at path, or ErrNotFound.
+func (l *Library) Get(ctx context.Context, path string) (*Game, error) {
+	return l.store.Get(ctx, path)
+}
+
+// Games returns the games matching a query, ordered by path.
+func (l *Library) Games(ctx context.Context, query Query) ([]Game, error) {
+	return l.store.List(ctx, query)
+}
+
+// FindByHash returns the games with an MD5, SHA1 or CRC32 hash.
+func (l *Library) FindByHash(ctx context.Context, hash string) ([]Game, error) {
+	return l.store.List(ctx, Query{Hash: hash})
+}
+
+// FindByProviderID returns the games with an ID from a provider.
+func (l *Library) FindByProviderID(ctx context.Context, provider, id string) ([]Game, error) {
+	return l.store.List(ctx, Query{Provider: provider, ProviderID: id})
+}
+
+// Move is a file that was moved or renamed.
+type Move struct {
+	// From is the path in the library
+	From string `json:"from"`
+	// To is the path the file was found at
+	To string `json:"to"`
+}
+
+// Diff compares a scan with the library.
+type Diff struct {
+	// New are the scanned files the library doesn't have
+	New []identify.DiscGroup
+	// Changed are the scanned files whose size or modification time changed
+	Changed []identify.DiscGroup
+	// Moved are the library files found at another path
+	Moved []Move
+	// Deleted are the library paths that weren't found in the scan
+	Deleted []string
+	// Unchanged is the number of scanned files that haven't changed
+	Unchanged int
+}
+
+// Diff compares the groups of a scan of root with the library games in root.
+//
+// A new file with the same hashes as a deleted one, or, if the scan didn't
+// hash it, the same filename and size in another directory, is reported as
+// moved. The
+// library isn't modified; use Apply to record the moves and deletions.
+func (l *Library) Diff(ctx context.Context, root string, groups []identify.DiscGroup) (*Diff, error) {
+	games, err := l.store.List(ctx, Query{Root: root})
+	if err != nil {
+		return nil, err
+	}
+
+	byPath := make(map[string]*Game, len(games))
+	for i := range games {
+		byPath[games[i].Path] = &games[i]
+	}
+
+	diff := &Diff{}
+	var added []identify.DiscGroup
+	for _, group := range groups {
+		game, ok := byPath[group.Filename]
+		if !ok {
+			added = append(added, group)
+			continue
+		}
+		delete(byPath, group.Filename)
+
+		modTime, size := stat(group.Filename)
+		if !modTime.Equal(game.ModTime) || size != game.Size {
+			diff.Changed = append(diff.Changed, group)
+		} else {
+			diff.Unchanged++
+		}
+	}
+
+	// Whatever is left in byPath wasn't found; match it against the new
+	// files, sorted so that games sharing a key are matched in path order
+	missing := make(map[string][]string, 2*len(byPath))
+	for path, game := range byPath {
+		if key := hashKey(game.Size, game.Hashes); key != "" {
+			missing[key] = append(missing[key], path)
+		}
+		key := nameKey(path, game.Size)
+		missing[key] = append(missing[key], path)
+	}
+	for _, paths := range missing {
+		sort.Strings(paths)
+	}
+	for _, group := range added {
+		_, size := stat(group.Filename)
+		key := nameKey(group.Filename, size)
+		if group.Hashes != nil {
+			if k := hashKey(size, normalizeHashes(*group.Hashes)); k != "" {
+				key = k
+			}
+		}
+		if from, ok := takeMissing(missing[key], byPath); ok {
+			diff.Moved = append(diff.Moved, Move{From: from, To: group.Filename})
+			continue
+		}
+		diff.New = append(diff.New, group)
+	}
+
+	for path := range byPath {
+		diff.Deleted = append(diff.Deleted, path)
+	}
+	sort.Strings(diff.Deleted)
+
+	return diff, nil
+}
+
+// Apply records the moves and deletions of a diff in the library. New and
+// changed files are left to be identified and recorded.
+func (l *Library) Apply(ctx context.Context, diff *Diff) error {
+	for _, move := range diff.Moved {
+		if err := l.store.Move(ctx, move.From, move.To); err != nil {
+			return fmt.Errorf("moving %s: %w", move.From, err)
+		}
+	}
+	for _, path := range diff.Deleted {
+		if err := l.store.Delete(ctx, path); err != nil {
+			return fmt.Errorf("deleting %s: %w", path, err)
+		}
+	}
+	return nil
+}
+
+// hashKey identifies a file across moves by its strongest hash, or returns
+// "" if it has none.
+func hashKey(size int64, hashes retrometadata.FileHashes) string {
+	switch {
+	case hashes.SHA1 != "":
+		return "sha1:" + hashes.SHA1
+	case hashes.MD5 != "":
+		return "md5:" + hashes.MD5
+	case hashes.CRC32 != "":
+		return fmt.Sprintf("crc32:%s:%d", hashes.CRC32, size)
+	}
+	return ""
+}
+
+// nameKey identifies a file across moves by its filename and size.
+func nameKey(path string, size int64) string {
+	return fmt.Sprintf("name:%s:%d", filepath.Base(path), size)
+}
+
+// takeMissing returns the first of paths that's still missing, removing it
+// from missing.
+func takeMissing(paths []string, missing map[string]*Game) (string, bool) {
+	for _, path := range paths {
+		if _, ok := missing[path]; ok {
+			delete(missing, path)
+			return path, true
+		}
+	}
+	return "", false
+}
+
+// normalizeHashes returns hashes in lowercase.
+func normalizeHashes(h retrometadata.FileHashes) retrometadata.FileHashes {
+	h.MD5 = strings.ToLower(h.MD5)
+	h.SHA1 = strings.ToLower(h.SHA1)
+	h.CRC32 = strings.ToLower(h.CRC32)
+	h.SHA256 = strings.ToLower(h.SHA256)
+	return h
+}
+
+// stat returns the modification time and size of a file, or zero values if
+// it can't be read (such as the group filename of a multi-disc set).
+func stat(path string) (time.Time, int64) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return time.Time{}, 0
+	}
+	return info.ModTime(), info.Size()
+}
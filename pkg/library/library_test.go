@@ -0,0 +1,181 @@
+package library
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/josegonzalez/retro-metadata/pkg/export"
+	"github.com/josegonzalez/retro-metadata/pkg/identify"
+	"github.com/josegonzalez/retro-metadata/pkg/retrometadata"
+)
+
+func writeROM(t *testing.T, path, data string) identify.DiscGroup {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return identify.DiscGroup{Filename: path}
+}
+
+func testLibrary(t *testing.T, lib *Library) {
+	ctx := context.Background()
+	root := t.TempDir()
+
+	mario := writeROM(t, filepath.Join(root, "snes", "Mario.sfc"), "mario")
+	mario.Hashes = &retrometadata.FileHashes{MD5: "AAAA", Size: 5}
+	zelda := writeROM(t, filepath.Join(root, "snes", "Zelda.sfc"), "zelda")
+	tetris := writeROM(t, filepath.Join(root, "gb", "Tetris.gb"), "tetris")
+	unknown := writeROM(t, filepath.Join(root, "gb", "Unknown.gb"), "unknown")
+
+	id := 1018
+	entries := []export.Entry{
+		{Path: mario.Filename, Hashes: mario.Hashes, Game: &retrometadata.GameResult{
+			Name: "Super Mario World", Provider: "igdb", ProviderID: &id, ProviderIDs: map[string]int{"igdb": id, "mobygames": 42},
+		}},
+		{Path: zelda.Filename, Game: &retrometadata.GameResult{Name: "Zelda", Provider: "igdb"}},
+		{Path: tetris.Filename, Game: &retrometadata.GameResult{Name: "Tetris", Provider: "flashpoint", ProviderUID: "uuid-1"}},
+		{Path: unknown.Filename, Err: errors.New("no match")},
+	}
+	report, err := lib.Write(ctx, entries)
+	if err != nil || report.Written != 4 {
+		t.Fatalf("Write() = %+v, %v; expected 4 games recorded", report, err)
+	}
+
+	game, err := lib.Get(ctx, mario.Filename)
+	if err != nil {
+		t.Fatalf("Get() error: %v", err)
+	}
+	if game.Platform != "snes" || game.Hashes.MD5 != "aaaa" || game.Size != 5 || game.AddedAt.IsZero() {
+		t.Errorf("Unexpected game: %+v", game)
+	}
+	if game.Result == nil || game.Result.Name != "Super Mario World" {
+		t.Errorf("Result = %+v, expected the identification result", game.Result)
+	}
+
+	queries := []struct {
+		name     string
+		query    Query
+		expected []string
+	}{
+		{"platform", Query{Platform: "gb"}, []string{tetris.Filename, unknown.Filename}},
+		{"name", Query{Name: "mario"}, []string{mario.Filename}},
+		{"hash", Query{Hash: "AAAA"}, []string{mario.Filename}},
+		{"provider", Query{Provider: "igdb"}, []string{mario.Filename, zelda.Filename}},
+		{"provider ID", Query{Provider: "mobygames", ProviderID: "42"}, []string{mario.Filename}},
+		{"provider UID", Query{Provider: "flashpoint", ProviderID: "uuid-1"}, []string{tetris.Filename}},
+		{"unidentified", Query{Unidentified: true}, []string{unknown.Filename}},
+		{"root", Query{Root: filepath.Join(root, "snes"), Limit: 1}, []string{mario.Filename}},
+	}
+	for _, tt := range queries {
+		games, err := lib.Games(ctx, tt.query)
+		if err != nil {
+			t.Fatalf("%s: Games() error: %v", tt.name, err)
+		}
+		var paths []string
+		for _, g := range games {
+			paths = append(paths, g.Path)
+		}
+		if !slices.Equal(paths, tt.expected) {
+			t.Errorf("%s: Games() = %v, expected %v", tt.name, paths, tt.expected)
+		}
+	}
+
+	// Rescan without hashing: Mario moved, Zelda changed, Tetris deleted, and
+	// a new game
+	moved := writeROM(t, filepath.Join(root, "snes", "Nintendo", "Mario.sfc"), "mario")
+	if err := os.Remove(mario.Filename); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Remove(tetris.Filename); err != nil {
+		t.Fatal(err)
+	}
+	later := time.Now().Add(time.Hour)
+	if err := os.Chtimes(zelda.Filename, later, later); err != nil {
+		t.Fatal(err)
+	}
+	added := writeROM(t, filepath.Join(root, "gb", "Kirby.gb"), "kirby")
+
+	diff, err := lib.Diff(ctx, root, []identify.DiscGroup{moved, zelda, unknown, added})
+	if err != nil {
+		t.Fatalf("Diff() error: %v", err)
+	}
+	if len(diff.New) != 1 || diff.New[0].Filename != added.Filename {
+		t.Errorf("New = %+v, expected %s", diff.New, added.Filename)
+	}
+	if len(diff.Changed) != 1 || diff.Changed[0].Filename != zelda.Filename {
+		t.Errorf("Changed = %+v, expected %s", diff.Changed, zelda.Filename)
+	}
+	if len(diff.Moved) != 1 || diff.Moved[0] != (Move{From: mario.Filename, To: moved.Filename}) {
+		t.Errorf("Moved = %+v, expected %s to move", diff.Moved, mario.Filename)
+	}
+	if !slices.Equal(diff.Deleted, []string{tetris.Filename}) || diff.Unchanged != 1 {
+		t.Errorf("Deleted = %v, Unchanged = %d; expected %s deleted and 1 unchanged", diff.Deleted, diff.Unchanged, tetris.Filename)
+	}
+
+	if err := lib.Apply(ctx, diff); err != nil {
+		t.Fatalf("Apply() error: %v", err)
+	}
+	if game, err := lib.Get(ctx, moved.Filename); err != nil || game.Name != "Super Mario World" {
+		t.Errorf("Get() of the moved game = %+v, %v", game, err)
+	}
+	if _, err := lib.Get(ctx, tetris.Filename); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Get() of a deleted game = %v, expected ErrNotFound", err)
+	}
+	if games, _ := lib.FindByProviderID(ctx, "igdb", "1018"); len(games) != 1 || games[0].Path != moved.Filename {
+		t.Errorf("FindByProviderID() = %+v, expected the moved game", games)
+	}
+}
+
+func TestLibraryDiffMoves(t *testing.T) {
+	ctx := context.Background()
+	lib := New(NewMemoryStore())
+	root := t.TempDir()
+
+	mario := writeROM(t, filepath.Join(root, "snes", "Mario.sfc"), "mario")
+	mario.Hashes = &retrometadata.FileHashes{MD5: "AAAA", Size: 5}
+	tetris1 := writeROM(t, filepath.Join(root, "gb", "a", "Tetris.gb"), "tetris")
+	tetris2 := writeROM(t, filepath.Join(root, "gb", "b", "Tetris.gb"), "tetris")
+	entries := []export.Entry{
+		{Path: mario.Filename, Hashes: mario.Hashes, Game: &retrometadata.GameResult{Name: "Super Mario World"}},
+		{Path: tetris1.Filename, Game: &retrometadata.GameResult{Name: "Tetris"}},
+		{Path: tetris2.Filename, Game: &retrometadata.GameResult{Name: "Tetris"}},
+	}
+	if _, err := lib.Write(ctx, entries); err != nil {
+		t.Fatal(err)
+	}
+	for _, group := range []identify.DiscGroup{mario, tetris1, tetris2} {
+		if err := os.Remove(group.Filename); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// A hashed scan finds renames; both deleted Tetris copies are candidates
+	// for the one found again
+	renamed := writeROM(t, filepath.Join(root, "snes", "Super Mario World.sfc"), "mario")
+	renamed.Hashes = &retrometadata.FileHashes{MD5: "aaaa"}
+	found := writeROM(t, filepath.Join(root, "gb", "Tetris.gb"), "tetris")
+
+	diff, err := lib.Diff(ctx, root, []identify.DiscGroup{renamed, found})
+	if err != nil {
+		t.Fatalf("Diff() error: %v", err)
+	}
+	wantMoved := []Move{{From: mario.Filename, To: renamed.Filename}, {From: tetris1.Filename, To: found.Filename}}
+	if !slices.Equal(diff.Moved, wantMoved) || len(diff.New) != 0 {
+		t.Errorf("Moved = %+v, New = %+v; want %+v", diff.Moved, diff.New, wantMoved)
+	}
+	if !slices.Equal(diff.Deleted, []string{tetris2.Filename}) {
+		t.Errorf("Deleted = %v, want %s", diff.Deleted, tetris2.Filename)
+	}
+}
+
+func TestLibraryMemoryStore(t *testing.T) {
+	testLibrary(t, New(NewMemoryStore()))
+}
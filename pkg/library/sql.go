@@ -0,0 +1,316 @@
+package library
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/josegonzalez/retro-metadata/pkg/retrometadata"
+)
+
+// sqlSchema creates the library tables. Times are stored as Unix nanoseconds,
+// with 0 for the zero time.
+const sqlSchema = `
+CREATE TABLE IF NOT EXISTS games (
+	id            INTEGER PRIMARY KEY AUTOINCREMENT,
+	path          TEXT NOT NULL UNIQUE,
+	size          INTEGER NOT NULL DEFAULT 0,
+	mod_time      INTEGER NOT NULL DEFAULT 0,
+	md5           TEXT NOT NULL DEFAULT '',
+	sha1          TEXT NOT NULL DEFAULT '',
+	crc32         TEXT NOT NULL DEFAULT '',
+	sha256        TEXT NOT NULL DEFAULT '',
+	platform      TEXT NOT NULL DEFAULT '',
+	name          TEXT NOT NULL DEFAULT '',
+	provider      TEXT NOT NULL DEFAULT '',
+	result        TEXT,
+	error         TEXT NOT NULL DEFAULT '',
+	added_at      INTEGER NOT NULL DEFAULT 0,
+	updated_at    INTEGER NOT NULL DEFAULT 0,
+	identified_at INTEGER NOT NULL DEFAULT 0
+);
+CREATE INDEX IF NOT EXISTS games_md5 ON games (md5);
+CREATE INDEX IF NOT EXISTS games_sha1 ON games (sha1);
+CREATE INDEX IF NOT EXISTS games_crc32 ON games (crc32);
+CREATE INDEX IF NOT EXISTS games_platform ON games (platform);
+CREATE TABLE IF NOT EXISTS provider_ids (
+	game_id     INTEGER NOT NULL,
+	provider    TEXT NOT NULL,
+	provider_id TEXT NOT NULL,
+	PRIMARY KEY (game_id, provider)
+);
+CREATE INDEX IF NOT EXISTS provider_ids_lookup ON provider_ids (provider, provider_id);
+`
+
+// gameColumns are the games columns read by scanGame, in order.
+const gameColumns = `id, path, size, mod_time, md5, sha1, crc32, sha256, platform, name, provider, result, error, added_at, updated_at, identified_at`
+
+// Open opens a library in a SQL database, creating its tables if needed.
+// The driver must be a SQLite driver registered by the program, such as
+// modernc.org/sqlite ("sqlite") or github.com/mattn/go-sqlite3 ("sqlite3").
+func Open(driverName, dataSourceName string) (*Library, error) {
+	db, err := sql.Open(driverName, dataSourceName)
+	if err != nil {
+		return nil, err
+	}
+	store, err := NewSQLStore(db)
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	return New(store), nil
+}
+
+// sqlStore keeps games in a SQL database.
+type sqlStore struct {
+	db *sql.DB
+}
+
+// NewSQLStore creates a Store in a SQLite database, creating its tables if
+// needed. The store owns db and closes it on Close.
+func NewSQLStore(db *sql.DB) (Store, error) {
+	if _, err := db.Exec(sqlSchema); err != nil {
+		return nil, fmt.Errorf("creating library tables: %w", err)
+	}
+	return &sqlStore{db: db}, nil
+}
+
+// Get returns the game at path.
+func (s *sqlStore) Get(ctx context.Context, path string) (*Game, error) {
+	games, err := s.query(ctx, `SELECT `+gameColumns+` FROM games WHERE path = ?`, path)
+	if err != nil {
+		return nil, err
+	}
+	if len(games) == 0 {
+		return nil, ErrNotFound
+	}
+	return &games[0], nil
+}
+
+// Put adds or replaces the game at game.Path.
+func (s *sqlStore) Put(ctx context.Context, game *Game) error {
+	var result sql.NullString
+	if game.Result != nil {
+		data, err := json.Marshal(game.Result)
+		if err != nil {
+			return err
+		}
+		result = sql.NullString{String: string(data), Valid: true}
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO games (path, size, mod_time, md5, sha1, crc32, sha256, platform, name, provider, result, error, added_at, updated_at, identified_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT (path) DO UPDATE SET
+			size = excluded.size, mod_time = excluded.mod_time,
+			md5 = excluded.md5, sha1 = excluded.sha1, crc32 = excluded.crc32, sha256 = excluded.sha256,
+			platform = excluded.platform, name = excluded.name, provider = excluded.provider,
+			result = excluded.result, error = excluded.error,
+			added_at = excluded.added_at, updated_at = excluded.updated_at, identified_at = excluded.identified_at`,
+		game.Path, game.Size, unixNano(game.ModTime),
+		game.Hashes.MD5, game.Hashes.SHA1, game.Hashes.CRC32, game.Hashes.SHA256,
+		game.Platform, game.Name, game.Provider, result, game.Error,
+		unixNano(game.AddedAt), unixNano(game.UpdatedAt), unixNano(game.IdentifiedAt))
+	if err != nil {
+		return err
+	}
+
+	if err := tx.QueryRowContext(ctx, `SELECT id FROM games WHERE path = ?`, game.Path).Scan(&game.ID); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM provider_ids WHERE game_id = ?`, game.ID); err != nil {
+		return err
+	}
+	for provider, id := range game.ProviderIDs {
+		if _, err := tx.ExecContext(ctx, `INSERT INTO provider_ids (game_id, provider, provider_id) VALUES (?, ?, ?)`, game.ID, provider, id); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// Move changes the path of a game.
+func (s *sqlStore) Move(ctx context.Context, from, to string) error {
+	res, err := s.db.ExecContext(ctx, `UPDATE games SET path = ? WHERE path = ?`, to, from)
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// Delete removes the game at path.
+func (s *sqlStore) Delete(ctx context.Context, path string) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM provider_ids WHERE game_id IN (SELECT id FROM games WHERE path = ?)`, path); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM games WHERE path = ?`, path); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// List returns the games matching a query.
+func (s *sqlStore) List(ctx context.Context, query Query) ([]Game, error) {
+	var where []string
+	var args []any
+
+	if query.Root != "" {
+		prefix := strings.TrimSuffix(query.Root, string(filepath.Separator)) + string(filepath.Separator)
+		where = append(where, `path LIKE ? ESCAPE '\'`)
+		args = append(args, escapeLike(prefix)+"%")
+	}
+	if query.Platform != "" {
+		where = append(where, `platform = ?`)
+		args = append(args, query.Platform)
+	}
+	if query.Name != "" {
+		where = append(where, `LOWER(name) LIKE ? ESCAPE '\'`)
+		args = append(args, "%"+escapeLike(strings.ToLower(query.Name))+"%")
+	}
+	if query.Hash != "" {
+		hash := strings.ToLower(query.Hash)
+		where = append(where, `(md5 = ? OR sha1 = ? OR crc32 = ?)`)
+		args = append(args, hash, hash, hash)
+	}
+	if query.ProviderID != "" {
+		where = append(where, `id IN (SELECT game_id FROM provider_ids WHERE provider = ? AND provider_id = ?)`)
+		args = append(args, query.Provider, query.ProviderID)
+	} else if query.Provider != "" {
+		where = append(where, `provider = ?`)
+		args = append(args, query.Provider)
+	}
+	if query.Unidentified {
+		where = append(where, `result IS NULL`)
+	}
+
+	stmt := `SELECT ` + gameColumns + ` FROM games`
+	if len(where) > 0 {
+		stmt += ` WHERE ` + strings.Join(where, ` AND `)
+	}
+	stmt += ` ORDER BY path`
+	if query.Limit > 0 {
+		stmt += fmt.Sprintf(` LIMIT %d`, query.Limit)
+	}
+
+	return s.query(ctx, stmt, args...)
+}
+
+// Close closes the database.
+func (s *sqlStore) Close() error {
+	return s.db.Close()
+}
+
+// query runs a games query and loads the provider IDs of the results.
+func (s *sqlStore) query(ctx context.Context, stmt string, args ...any) ([]Game, error) {
+	rows, err := s.db.QueryContext(ctx, stmt, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var games []Game
+	for rows.Next() {
+		game, err := scanGame(rows)
+		if err != nil {
+			return nil, err
+		}
+		games = append(games, *game)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	rows.Close()
+
+	for i := range games {
+		if games[i].ProviderIDs, err = s.providerIDs(ctx, games[i].ID); err != nil {
+			return nil, err
+		}
+	}
+	return games, nil
+}
+
+// providerIDs returns the provider IDs of a game.
+func (s *sqlStore) providerIDs(ctx context.Context, gameID int64) (map[string]string, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT provider, provider_id FROM provider_ids WHERE game_id = ?`, gameID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ids := make(map[string]string)
+	for rows.Next() {
+		var provider, id string
+		if err := rows.Scan(&provider, &id); err != nil {
+			return nil, err
+		}
+		ids[provider] = id
+	}
+	return ids, rows.Err()
+}
+
+// scanGame reads a row of gameColumns.
+func scanGame(rows *sql.Rows) (*Game, error) {
+	var game Game
+	var modTime, addedAt, updatedAt, identifiedAt int64
+	var result sql.NullString
+	err := rows.Scan(&game.ID, &game.Path, &game.Size, &modTime,
+		&game.Hashes.MD5, &game.Hashes.SHA1, &game.Hashes.CRC32, &game.Hashes.SHA256,
+		&game.Platform, &game.Name, &game.Provider, &result, &game.Error,
+		&addedAt, &updatedAt, &identifiedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	game.ModTime = fromUnixNano(modTime)
+	game.AddedAt = fromUnixNano(addedAt)
+	game.UpdatedAt = fromUnixNano(updatedAt)
+	game.IdentifiedAt = fromUnixNano(identifiedAt)
+	if result.Valid {
+		game.Result = &retrometadata.GameResult{}
+		if err := json.Unmarshal([]byte(result.String), game.Result); err != nil {
+			return nil, fmt.Errorf("reading result of %s: %w", game.Path, err)
+		}
+	}
+	return &game, nil
+}
+
+// unixNano returns t in Unix nanoseconds, or 0 for the zero time.
+func unixNano(t time.Time) int64 {
+	if t.IsZero() {
+		return 0
+	}
+	return t.UnixNano()
+}
+
+// fromUnixNano is the inverse of unixNano.
+func fromUnixNano(n int64) time.Time {
+	if n == 0 {
+		return time.Time{}
+	}
+	return time.Unix(0, n)
+}
+
+// escapeLike escapes the LIKE wildcards in s.
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
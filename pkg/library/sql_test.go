@@ -0,0 +1,29 @@
+package library
+
+import (
+	"database/sql"
+	"path/filepath"
+	"testing"
+)
+
+// TestLibrarySQLStore runs the library tests against SQLite, when a driver
+// is compiled into the test binary. The module doesn't depend on one; CI
+// registers modernc.org/sqlite from a driver_test.go file it writes.
+func TestLibrarySQLStore(t *testing.T) {
+	var driver string
+	for _, name := range sql.Drivers() {
+		if name == "sqlite" || name == "sqlite3" {
+			driver = name
+		}
+	}
+	if driver == "" {
+		t.Skip("no SQLite driver registered")
+	}
+
+	lib, err := Open(driver, filepath.Join(t.TempDir(), "library.db"))
+	if err != nil {
+		t.Fatalf("Open() error: %v", err)
+	}
+	defer lib.Close()
+	testLibrary(t, lib)
+}
@@ -0,0 +1,179 @@
+package library
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// ErrNotFound is returned when a game isn't in the library.
+var ErrNotFound = errors.New("game not found in library")
+
+// Store persists library games.
+type Store interface {
+	// Get returns the game at path, or ErrNotFound.
+	Get(ctx context.Context, path string) (*Game, error)
+
+	// Put adds or replaces the game at game.Path, setting its ID.
+	Put(ctx context.Context, game *Game) error
+
+	// Move changes the path of the game at from to to.
+	Move(ctx context.Context, from, to string) error
+
+	// Delete removes the game at path. Deleting a missing game is not an error.
+	Delete(ctx context.Context, path string) error
+
+	// List returns the games matching a query, ordered by path.
+	List(ctx context.Context, query Query) ([]Game, error)
+
+	// Close releases the store's resources.
+	Close() error
+}
+
+// Query filters the games returned by List. Empty fields match every game.
+type Query struct {
+	// Root matches games inside a directory
+	Root string
+	// Platform matches games on a platform slug
+	Platform string
+	// Name matches games whose name contains it, ignoring case
+	Name string
+	// Hash matches games with an MD5, SHA1 or CRC32 hash, ignoring case
+	Hash string
+	// Provider matches games identified by a provider, or with an ID from
+	// it if ProviderID is set
+	Provider string
+	// ProviderID matches games with this ID from Provider
+	ProviderID string
+	// Unidentified matches only games that weren't identified
+	Unidentified bool
+	// Limit is the maximum number of games returned (0 for no limit)
+	Limit int
+}
+
+// matches reports whether a game matches the query.
+func (q Query) matches(game *Game) bool {
+	if q.Root != "" && !inRoot(game.Path, q.Root) {
+		return false
+	}
+	if q.Platform != "" && game.Platform != q.Platform {
+		return false
+	}
+	if q.Name != "" && !strings.Contains(strings.ToLower(game.Name), strings.ToLower(q.Name)) {
+		return false
+	}
+	if q.Hash != "" {
+		hash := strings.ToLower(q.Hash)
+		if hash != game.Hashes.MD5 && hash != game.Hashes.SHA1 && hash != game.Hashes.CRC32 {
+			return false
+		}
+	}
+	if q.ProviderID != "" {
+		if game.ProviderIDs[q.Provider] != q.ProviderID {
+			return false
+		}
+	} else if q.Provider != "" && game.Provider != q.Provider {
+		return false
+	}
+	if q.Unidentified && game.Identified() {
+		return false
+	}
+	return true
+}
+
+// inRoot reports whether path is inside the directory root.
+func inRoot(path, root string) bool {
+	rel, err := filepath.Rel(root, path)
+	return err == nil && rel != "." && !strings.HasPrefix(rel, "..")
+}
+
+// memoryStore keeps games in memory.
+type memoryStore struct {
+	mu     sync.RWMutex
+	games  map[string]*Game
+	nextID int64
+}
+
+// NewMemoryStore creates a Store that keeps games in memory, for tools that
+// don't need the library to persist.
+func NewMemoryStore() Store {
+	return &memoryStore{games: make(map[string]*Game)}
+}
+
+// Get returns the game at path.
+func (s *memoryStore) Get(_ context.Context, path string) (*Game, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	game, ok := s.games[path]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return game.clone(), nil
+}
+
+// Put adds or replaces the game at game.Path.
+func (s *memoryStore) Put(_ context.Context, game *Game) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if existing, ok := s.games[game.Path]; ok {
+		game.ID = existing.ID
+	} else {
+		s.nextID++
+		game.ID = s.nextID
+	}
+	s.games[game.Path] = game.clone()
+	return nil
+}
+
+// Move changes the path of a game.
+func (s *memoryStore) Move(_ context.Context, from, to string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	game, ok := s.games[from]
+	if !ok {
+		return ErrNotFound
+	}
+	delete(s.games, from)
+	game.Path = to
+	s.games[to] = game
+	return nil
+}
+
+// Delete removes the game at path.
+func (s *memoryStore) Delete(_ context.Context, path string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.games, path)
+	return nil
+}
+
+// List returns the games matching a query.
+func (s *memoryStore) List(_ context.Context, query Query) ([]Game, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var games []Game
+	for _, game := range s.games {
+		if query.matches(game) {
+			games = append(games, *game.clone())
+		}
+	}
+	sort.Slice(games, func(i, j int) bool { return games[i].Path < games[j].Path })
+
+	if query.Limit > 0 && len(games) > query.Limit {
+		games = games[:query.Limit]
+	}
+	return games, nil
+}
+
+// Close does nothing.
+func (s *memoryStore) Close() error {
+	return nil
+}
@@ -0,0 +1,24 @@
+package matching
+
+import "testing"
+
+func FuzzFindBestMatch(f *testing.F) {
+	f.Add("Super Mario World", "Super Mario World 2: Yoshi's Island")
+	f.Add("Zelda", "The Legend of Zelda")
+	f.Add("", "")
+	f.Add("ポケモン", "Pokémon")
+	f.Fuzz(func(t *testing.T, a, b string) {
+		for _, score := range []float64{
+			JaroWinklerSimilarity(a, b),
+			LevenshteinSimilarity(a, b),
+			TokenSetRatio(a, b),
+		} {
+			if score < 0 || score > 1 {
+				t.Errorf("similarity of %q and %q out of range: %v", a, b, score)
+			}
+		}
+		if _, score := FindBestMatchSimple(a, []string{b}); score < 0 || score > 1 {
+			t.Errorf("FindBestMatchSimple(%q, %q) score out of range: %v", a, b, score)
+		}
+	})
+}
@@ -0,0 +1,251 @@
+package matching
+
+import (
+	"slices"
+
+	"github.com/josegonzalez/retro-metadata/pkg/internal/normalization"
+)
+
+// IndexShortlist is the number of candidates an Index scores per search.
+const IndexShortlist = 64
+
+// indexGatherLimit is the number of candidates gathered from the postings of
+// a search term's rarest trigrams, after which no more postings are walked.
+const indexGatherLimit = 1024
+
+// Index is a pre-built index of normalized candidate names, for matching
+// many search terms against a large, fixed set of candidates. Exact
+// normalized matches are found with a map lookup; otherwise only the
+// IndexShortlist candidates sharing the most trigrams with the search term
+// are scored, instead of every candidate.
+//
+// An Index is safe for concurrent use once built.
+type Index struct {
+	names      []string
+	normalized []string
+	exact      map[string]int32   // normalized name -> first candidate
+	postings   map[uint32][]int32 // trigram -> candidates containing it
+	grams      []uint32           // each candidate's sorted, unique trigrams
+	offsets    []int32            // candidate -> start of its trigrams in grams
+}
+
+// NewIndex builds an index of candidate names.
+func NewIndex(candidates []string) *Index {
+	ix := &Index{
+		names:      candidates,
+		normalized: make([]string, len(candidates)),
+		exact:      make(map[string]int32, len(candidates)),
+		postings:   make(map[uint32][]int32),
+		offsets:    make([]int32, 1, len(candidates)+1),
+	}
+	for i, name := range candidates {
+		normalized := normalization.NormalizeGameName(name)
+		ix.normalized[i] = normalized
+		if _, ok := ix.exact[normalized]; !ok {
+			ix.exact[normalized] = int32(i)
+		}
+		grams := dedupe(trigrams(normalized))
+		slices.Sort(grams)
+		for _, gram := range grams {
+			ix.postings[gram] = append(ix.postings[gram], int32(i))
+		}
+		ix.grams = append(ix.grams, grams...)
+		ix.offsets = append(ix.offsets, int32(len(ix.grams)))
+	}
+	return ix
+}
+
+// Len returns the number of candidates in the index.
+func (ix *Index) Len() int {
+	return len(ix.names)
+}
+
+// Names returns the indexed candidate names.
+func (ix *Index) Names() []string {
+	return ix.names
+}
+
+// FindBestMatch finds the best matching candidate like the package's
+// FindBestMatch with opts.Normalize set, scoring only the shortlisted
+// candidates when the index is larger than IndexShortlist. Normalize,
+// SplitCandidateName and FirstNOnly are ignored.
+func (ix *Index) FindBestMatch(searchTerm string, opts FindBestMatchOptions) (string, float64) {
+	normalized, steps := normalization.NormalizeGameNameSteps(searchTerm)
+
+	scorer := opts.Scorer
+	if scorer == nil {
+		scorer = JaroWinklerSimilarity
+	}
+
+	var bestMatch string
+	var bestScore float64
+	if i, ok := ix.exact[normalized]; ok && opts.Trace == nil {
+		bestMatch, bestScore = ix.names[i], scorer(normalized, ix.normalized[i])
+	} else {
+		for _, i := range ix.shortlist(normalized) {
+			score := scorer(normalized, ix.normalized[i])
+			if opts.Trace != nil {
+				opts.Trace.Candidates = append(opts.Trace.Candidates, CandidateScore{Name: ix.names[i], Normalized: ix.normalized[i], Score: score})
+			}
+			if score > bestScore {
+				bestMatch, bestScore = ix.names[i], score
+			}
+		}
+	}
+
+	if bestScore < opts.MinSimilarityScore {
+		bestMatch, bestScore = "", 0.0
+	}
+	if opts.Trace != nil {
+		opts.Trace.finish(searchTerm, normalized, steps, opts.MinSimilarityScore, bestMatch, bestScore)
+	}
+	return bestMatch, bestScore
+}
+
+// FindAllMatches finds the shortlisted candidates scoring at least minScore,
+// like the package's FindAllMatches.
+func (ix *Index) FindAllMatches(searchTerm string, minScore float64, maxResults int) []MatchResult {
+	normalized := normalization.NormalizeGameName(searchTerm)
+
+	var matches []MatchResult
+	for _, i := range ix.shortlist(normalized) {
+		if score := JaroWinklerSimilarity(normalized, ix.normalized[i]); score >= minScore {
+			matches = append(matches, MatchResult{Name: ix.names[i], Score: score})
+		}
+	}
+
+	// Sort by score descending, keeping candidate order for equal scores
+	for i := 1; i < len(matches); i++ {
+		j := i
+		for j > 0 && matches[j].Score > matches[j-1].Score {
+			matches[j], matches[j-1] = matches[j-1], matches[j]
+			j--
+		}
+	}
+
+	if maxResults > 0 && len(matches) > maxResults {
+		matches = matches[:maxResults]
+	}
+	return matches
+}
+
+// shortlist returns the candidates to score for a normalized search term,
+// in candidate order: all of them for a small index, and otherwise those
+// sharing the most trigrams with the term, closest in length first on ties.
+// Candidates are gathered from the postings of the term's rarest trigrams
+// first, until there are enough of them to choose from, so the postings of
+// common trigrams ("the") are rarely walked.
+func (ix *Index) shortlist(normalized string) []int32 {
+	if len(ix.names) <= IndexShortlist {
+		all := make([]int32, len(ix.names))
+		for i := range all {
+			all[i] = int32(i)
+		}
+		return all
+	}
+
+	grams := dedupe(trigrams(normalized))
+	slices.SortFunc(grams, func(a, b uint32) int {
+		return len(ix.postings[a]) - len(ix.postings[b])
+	})
+	seen := make([]uint64, (len(ix.names)+63)/64)
+	var candidates []int32
+	for _, gram := range grams {
+		if len(candidates) >= indexGatherLimit {
+			break
+		}
+		for _, i := range ix.postings[gram] {
+			if seen[i/64]&(1<<(i%64)) == 0 {
+				seen[i/64] |= 1 << (i % 64)
+				candidates = append(candidates, i)
+			}
+		}
+	}
+
+	slices.Sort(grams)
+	ranked := make([]shortlisted, len(candidates))
+	for n, i := range candidates {
+		ranked[n] = shortlisted{
+			index:      i,
+			shared:     countShared(ix.grams[ix.offsets[i]:ix.offsets[i+1]], grams),
+			lengthDiff: abs(len(ix.normalized[i]) - len(normalized)),
+		}
+	}
+	slices.SortFunc(ranked, func(a, b shortlisted) int {
+		if a.shared != b.shared {
+			return b.shared - a.shared
+		}
+		if a.lengthDiff != b.lengthDiff {
+			return a.lengthDiff - b.lengthDiff
+		}
+		return int(a.index - b.index)
+	})
+	if len(ranked) > IndexShortlist {
+		ranked = ranked[:IndexShortlist]
+	}
+
+	shortlist := make([]int32, len(ranked))
+	for n, c := range ranked {
+		shortlist[n] = c.index
+	}
+	slices.Sort(shortlist)
+	return shortlist
+}
+
+// shortlisted is a candidate ranked for a shortlist.
+type shortlisted struct {
+	index      int32
+	shared     int // trigrams shared with the search term
+	lengthDiff int // difference in normalized length from the search term
+}
+
+// countShared counts the values two sorted lists share.
+func countShared(a, b []uint32) int {
+	n := 0
+	for len(a) > 0 && len(b) > 0 {
+		switch {
+		case a[0] < b[0]:
+			a = a[1:]
+		case a[0] > b[0]:
+			b = b[1:]
+		default:
+			n++
+			a, b = a[1:], b[1:]
+		}
+	}
+	return n
+}
+
+// trigrams returns the byte trigrams of a space-padded string, packed into
+// integers.
+func trigrams(s string) []uint32 {
+	padded := " " + s + " "
+	if len(padded) < 3 {
+		return nil
+	}
+	grams := make([]uint32, 0, len(padded)-2)
+	for i := 0; i+3 <= len(padded); i++ {
+		grams = append(grams, uint32(padded[i])<<16|uint32(padded[i+1])<<8|uint32(padded[i+2]))
+	}
+	return grams
+}
+
+// dedupe removes repeated trigrams, keeping the first of each.
+func dedupe(grams []uint32) []uint32 {
+	seen := make(map[uint32]bool, len(grams))
+	unique := grams[:0]
+	for _, gram := range grams {
+		if !seen[gram] {
+			seen[gram] = true
+			unique = append(unique, gram)
+		}
+	}
+	return unique
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
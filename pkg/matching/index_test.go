@@ -0,0 +1,132 @@
+package matching
+
+import (
+	"math/rand"
+	"testing"
+)
+
+// syntheticNames returns n deterministic, game-like names, made of words
+// drawn with a skewed distribution from a vocabulary of made-up words, as
+// real catalogs repeat a few words ("super", "world") a lot.
+func syntheticNames(n int) []string {
+	const consonants, vowels = "bcdfghjklmnprstvwxyz", "aeiou"
+	r := rand.New(rand.NewSource(1))
+	words := make([]string, 20000)
+	for i := range words {
+		word := make([]byte, r.Intn(7)+3)
+		for j := range word {
+			if j%2 == 0 {
+				word[j] = consonants[r.Intn(len(consonants))]
+			} else {
+				word[j] = vowels[r.Intn(len(vowels))]
+			}
+		}
+		words[i] = string(word)
+	}
+	zipf := rand.NewZipf(r, 1.1, 1, uint64(len(words)-1))
+	names := make([]string, n)
+	for i := range names {
+		name := words[zipf.Uint64()]
+		for range r.Intn(4) + 1 {
+			name += " " + words[zipf.Uint64()]
+		}
+		names[i] = name
+	}
+	return names
+}
+
+func TestIndexFindBestMatch(t *testing.T) {
+	candidates := append(syntheticNames(5000),
+		"The Legend of Zelda: A Link to the Past",
+		"Super Mario World",
+		"Super Mario World 2: Yoshi's Island",
+	)
+	ix := NewIndex(candidates)
+	if ix.Len() != len(candidates) {
+		t.Fatalf("Len() = %d, want %d", ix.Len(), len(candidates))
+	}
+
+	for _, term := range []string{
+		"Super Mario World",
+		"super mario world (USA)",
+		"Legend of Zelda, The - A Link to the Past",
+		"Super Mario Wrld 2 Yoshis Island",
+	} {
+		opts := DefaultFindBestMatchOptions()
+		wantMatch, wantScore := FindBestMatch(term, candidates, opts)
+		gotMatch, gotScore := ix.FindBestMatch(term, opts)
+		if gotMatch != wantMatch || gotScore != wantScore {
+			t.Errorf("FindBestMatch(%q) = (%q, %v), want (%q, %v)", term, gotMatch, gotScore, wantMatch, wantScore)
+		}
+	}
+
+	if match, _ := ix.FindBestMatch("Completely Unrelated", DefaultFindBestMatchOptions()); match != "" {
+		t.Errorf("FindBestMatch(unrelated) = %q, want no match", match)
+	}
+}
+
+func TestIndexSmall(t *testing.T) {
+	candidates := []string{"Sonic the Hedgehog", "Sonic the Hedgehog 2", "Streets of Rage"}
+	ix := NewIndex(candidates)
+
+	opts := DefaultFindBestMatchOptions()
+	opts.Trace = &Trace{}
+	match, score := ix.FindBestMatch("Sonic 2", opts)
+	wantMatch, wantScore := FindBestMatch("Sonic 2", candidates, DefaultFindBestMatchOptions())
+	if match != wantMatch || score != wantScore {
+		t.Errorf("FindBestMatch() = (%q, %v), want (%q, %v)", match, score, wantMatch, wantScore)
+	}
+	if opts.Trace.CandidateCount != len(candidates) || opts.Trace.Match != match {
+		t.Errorf("Trace = %+v, want every candidate scored", opts.Trace)
+	}
+
+	matches := ix.FindAllMatches("Sonic the Hedgehog", 0.8, 0)
+	if len(matches) != 2 || matches[0].Name != "Sonic the Hedgehog" {
+		t.Errorf("FindAllMatches() = %+v", matches)
+	}
+}
+
+const benchmarkCandidates = 150000
+
+var benchmarkTerms = []string{
+	"Super Mario Kart",
+	"Dragon Quest VII",
+	"Legend of Zelda, The - Ocarina of Time",
+	"Metal Gear Solid",
+}
+
+func BenchmarkFindBestMatch(b *testing.B) {
+	candidates := syntheticNames(benchmarkCandidates)
+	opts := DefaultFindBestMatchOptions()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		FindBestMatch(benchmarkTerms[i%len(benchmarkTerms)], candidates, opts)
+	}
+}
+
+func BenchmarkIndexFindBestMatch(b *testing.B) {
+	ix := NewIndex(syntheticNames(benchmarkCandidates))
+	opts := DefaultFindBestMatchOptions()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		ix.FindBestMatch(benchmarkTerms[i%len(benchmarkTerms)], opts)
+	}
+}
+
+func BenchmarkIndexFindBestMatchExact(b *testing.B) {
+	candidates := syntheticNames(benchmarkCandidates)
+	ix := NewIndex(candidates)
+	opts := DefaultFindBestMatchOptions()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		ix.FindBestMatch(candidates[i%len(candidates)], opts)
+	}
+}
+
+func BenchmarkNewIndex(b *testing.B) {
+	candidates := syntheticNames(benchmarkCandidates)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		NewIndex(candidates)
+	}
+}
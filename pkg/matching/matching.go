@@ -1,7 +1,11 @@
-// Package matching provides string matching utilities using Jaro-Winkler similarity.
+// Package matching provides fuzzy string matching utilities for game names.
+//
+// Jaro-Winkler similarity is used by default, but the scoring function can be
+// swapped for any Scorer (see LevenshteinSimilarity and TokenSetRatio).
 package matching
 
 import (
+	"sort"
 	"strings"
 
 	"github.com/adrg/strutil"
@@ -32,6 +36,40 @@ type FindBestMatchOptions struct {
 	Normalize bool
 	// FirstNOnly limits matching to the first N candidates
 	FirstNOnly int
+	// Scorer is the similarity function used for comparison (defaults to Jaro-Winkler)
+	Scorer Scorer
+	// Trace, if set, is filled in with how the match was chosen
+	Trace *Trace
+}
+
+// MaxTraceCandidates is the number of best scoring candidates kept in a Trace.
+const MaxTraceCandidates = 10
+
+// Trace records how FindBestMatch chose a match, to explain wrong matches.
+type Trace struct {
+	// SearchTerm is the term matched against the candidates
+	SearchTerm string `json:"search_term"`
+	// NormalizedTerm is the search term as compared
+	NormalizedTerm string `json:"normalized_term"`
+	// Steps are the normalization steps that changed the search term
+	Steps []string `json:"normalization_steps,omitempty"`
+	// Candidates are the best scoring candidates, best first
+	Candidates []CandidateScore `json:"candidates"`
+	// CandidateCount is the number of candidates compared
+	CandidateCount int `json:"candidate_count"`
+	// MinScore is the score a candidate needed to match
+	MinScore float64 `json:"min_score"`
+	// Match is the matched candidate, or "" if none scored enough
+	Match string `json:"match,omitempty"`
+	// Score is the score of the match
+	Score float64 `json:"score,omitempty"`
+}
+
+// CandidateScore is a candidate's score in a Trace.
+type CandidateScore struct {
+	Name       string  `json:"name"`
+	Normalized string  `json:"normalized"`
+	Score      float64 `json:"score"`
 }
 
 // DefaultFindBestMatchOptions returns sensible defaults for FindBestMatch.
@@ -49,13 +87,17 @@ func DefaultFindBestMatchOptions() FindBestMatchOptions {
 // meets the minimum threshold.
 func FindBestMatch(searchTerm string, candidates []string, opts FindBestMatchOptions) (string, float64) {
 	if len(candidates) == 0 {
+		if opts.Trace != nil {
+			opts.Trace.finish(searchTerm, "", nil, opts.MinSimilarityScore, "", 0)
+		}
 		return "", 0.0
 	}
 
 	// Normalize the search term once
 	var searchTermNormalized string
+	var steps []string
 	if opts.Normalize {
-		searchTermNormalized = normalization.NormalizeSearchTermDefault(searchTerm)
+		searchTermNormalized, steps = normalization.NormalizeGameNameSteps(searchTerm)
 	} else {
 		searchTermNormalized = strings.ToLower(strings.TrimSpace(searchTerm))
 	}
@@ -66,6 +108,11 @@ func FindBestMatch(searchTerm string, candidates []string, opts FindBestMatchOpt
 		candidatesToCheck = candidates[:opts.FirstNOnly]
 	}
 
+	scorer := opts.Scorer
+	if scorer == nil {
+		scorer = JaroWinklerSimilarity
+	}
+
 	var bestMatch string
 	var bestScore float64
 
@@ -73,7 +120,7 @@ func FindBestMatch(searchTerm string, candidates []string, opts FindBestMatchOpt
 		// Normalize the candidate name
 		var candidateNormalized string
 		if opts.Normalize {
-			candidateNormalized = normalization.NormalizeSearchTermDefault(candidate)
+			candidateNormalized = normalization.NormalizeGameName(candidate)
 		} else {
 			candidateNormalized = strings.ToLower(strings.TrimSpace(candidate))
 		}
@@ -84,7 +131,7 @@ func FindBestMatch(searchTerm string, candidates []string, opts FindBestMatchOpt
 			if len(parts) > 1 {
 				lastPart := parts[len(parts)-1]
 				if opts.Normalize {
-					candidateNormalized = normalization.NormalizeSearchTermDefault(lastPart)
+					candidateNormalized = normalization.NormalizeGameName(lastPart)
 				} else {
 					candidateNormalized = strings.ToLower(strings.TrimSpace(lastPart))
 				}
@@ -92,24 +139,70 @@ func FindBestMatch(searchTerm string, candidates []string, opts FindBestMatchOpt
 		}
 
 		// Calculate similarity
-		score := JaroWinklerSimilarity(searchTermNormalized, candidateNormalized)
+		score := scorer(searchTermNormalized, candidateNormalized)
+		if opts.Trace != nil {
+			opts.Trace.Candidates = append(opts.Trace.Candidates, CandidateScore{Name: candidate, Normalized: candidateNormalized, Score: score})
+		}
 
 		if score > bestScore {
 			bestScore = score
 			bestMatch = candidate
 
-			// Early exit for perfect match
-			if score == 1.0 {
+			// Early exit for perfect match, unless tracing, where every
+			// candidate's score is wanted
+			if score == 1.0 && opts.Trace == nil {
 				break
 			}
 		}
 	}
 
-	if bestScore >= opts.MinSimilarityScore {
-		return bestMatch, bestScore
+	if bestScore < opts.MinSimilarityScore {
+		bestMatch, bestScore = "", 0.0
+	}
+	if opts.Trace != nil {
+		opts.Trace.finish(searchTerm, searchTermNormalized, steps, opts.MinSimilarityScore, bestMatch, bestScore)
+	}
+	return bestMatch, bestScore
+}
+
+// finish fills in a trace once the candidates are scored, keeping the best
+// MaxTraceCandidates of them.
+func (t *Trace) finish(searchTerm, normalized string, steps []string, minScore float64, match string, score float64) {
+	t.SearchTerm = searchTerm
+	t.NormalizedTerm = normalized
+	t.Steps = steps
+	t.MinScore = minScore
+	t.Match = match
+	t.Score = score
+	t.CandidateCount = len(t.Candidates)
+	sort.SliceStable(t.Candidates, func(i, j int) bool {
+		return t.Candidates[i].Score > t.Candidates[j].Score
+	})
+	if len(t.Candidates) > MaxTraceCandidates {
+		t.Candidates = t.Candidates[:MaxTraceCandidates]
 	}
+}
 
-	return "", 0.0
+// Ambiguous returns the candidates of a trace that scored within margin of
+// the match, best first, with duplicate names dropped. It returns nil unless
+// there was a match and at least one other candidate came that close.
+func (t Trace) Ambiguous(margin float64) []CandidateScore {
+	if t.Match == "" {
+		return nil
+	}
+	var near []CandidateScore
+	seen := make(map[string]bool)
+	for _, candidate := range t.Candidates {
+		if candidate.Score < t.MinScore || t.Score-candidate.Score > margin || seen[candidate.Name] {
+			continue
+		}
+		seen[candidate.Name] = true
+		near = append(near, candidate)
+	}
+	if len(near) < 2 {
+		return nil
+	}
+	return near
 }
 
 // FindBestMatchSimple is a convenience function that uses default options.
@@ -131,12 +224,12 @@ func FindAllMatches(searchTerm string, candidates []string, minScore float64, ma
 	}
 
 	// Normalize the search term once
-	searchTermNormalized := normalization.NormalizeSearchTermDefault(searchTerm)
+	searchTermNormalized := normalization.NormalizeGameName(searchTerm)
 
 	var matches []MatchResult
 
 	for _, candidate := range candidates {
-		candidateNormalized := normalization.NormalizeSearchTermDefault(candidate)
+		candidateNormalized := normalization.NormalizeGameName(candidate)
 		score := JaroWinklerSimilarity(searchTermNormalized, candidateNormalized)
 
 		if score >= minScore {
@@ -163,7 +256,7 @@ func FindAllMatches(searchTerm string, candidates []string, minScore float64, ma
 // IsExactMatch checks if two strings are an exact match after normalization.
 func IsExactMatch(s1, s2 string, normalize bool) bool {
 	if normalize {
-		return normalization.NormalizeSearchTermDefault(s1) == normalization.NormalizeSearchTermDefault(s2)
+		return normalization.NormalizeGameName(s1) == normalization.NormalizeGameName(s2)
 	}
 	return strings.EqualFold(strings.TrimSpace(s1), strings.TrimSpace(s2))
 }
@@ -172,8 +265,8 @@ func IsExactMatch(s1, s2 string, normalize bool) bool {
 func MatchConfidence(searchTerm, matchedName string, normalize bool) string {
 	var s1, s2 string
 	if normalize {
-		s1 = normalization.NormalizeSearchTermDefault(searchTerm)
-		s2 = normalization.NormalizeSearchTermDefault(matchedName)
+		s1 = normalization.NormalizeGameName(searchTerm)
+		s2 = normalization.NormalizeGameName(matchedName)
 	} else {
 		s1 = strings.ToLower(strings.TrimSpace(searchTerm))
 		s2 = strings.ToLower(strings.TrimSpace(matchedName))
@@ -130,6 +130,25 @@ func TestFindBestMatch(t *testing.T) {
 	}
 }
 
+func TestFindBestMatchTitleVariants(t *testing.T) {
+	tests := []struct {
+		searchTerm string
+		candidates []string
+		expected   string
+	}{
+		{"Legend of Zelda, The - A Link to the Past", []string{"Zelda II: The Adventure of Link", "The Legend of Zelda: A Link to the Past"}, "The Legend of Zelda: A Link to the Past"},
+		{"Final Fantasy 7", []string{"Final Fantasy VI", "Final Fantasy VII"}, "Final Fantasy VII"},
+		{"Banjo and Kazooie", []string{"Banjo & Kazooie"}, "Banjo & Kazooie"},
+	}
+
+	for _, tt := range tests {
+		match, score := FindBestMatchSimple(tt.searchTerm, tt.candidates)
+		if match != tt.expected || score != 1.0 {
+			t.Errorf("FindBestMatchSimple(%q) = (%q, %v), expected (%q, 1.0)", tt.searchTerm, match, score, tt.expected)
+		}
+	}
+}
+
 func TestFindBestMatchSimple(t *testing.T) {
 	match, score := FindBestMatchSimple("Super Mario World", []string{"Super Mario World", "Zelda"})
 	if match != "Super Mario World" {
@@ -195,3 +214,112 @@ func TestMatchConfidence(t *testing.T) {
 		}
 	}
 }
+
+func TestScorerByName(t *testing.T) {
+	tests := []struct {
+		name string
+		ok   bool
+	}{
+		{"", true},
+		{"jaro-winkler", true},
+		{"levenshtein", true},
+		{"token-set", true},
+		{"unknown", false},
+	}
+
+	for _, tt := range tests {
+		scorer, ok := ScorerByName(tt.name)
+		if ok != tt.ok {
+			t.Errorf("ScorerByName(%q) ok = %v, expected %v", tt.name, ok, tt.ok)
+		}
+		if ok && scorer("Super Mario World", "super mario world") != 1.0 {
+			t.Errorf("ScorerByName(%q) did not score identical strings as 1.0", tt.name)
+		}
+	}
+}
+
+func TestTokenSetRatio(t *testing.T) {
+	if score := TokenSetRatio("zelda legend of", "legend of zelda"); score != 1.0 {
+		t.Errorf("TokenSetRatio reordered words = %v, expected 1.0", score)
+	}
+	if score := TokenSetRatio("sonic", "tetris"); score >= DefaultMinSimilarity {
+		t.Errorf("TokenSetRatio unrelated words = %v, expected < %v", score, DefaultMinSimilarity)
+	}
+
+	// Extra region and revision words are dropped, other extra words aren't
+	tests := []struct {
+		s1, s2 string
+		exact  bool
+	}{
+		{"tetris", "tetris (usa)", true},
+		{"tetris (europe) (rev a)", "tetris", true},
+		{"tetris v1.1", "tetris", true},
+		{"super mario bros", "super mario bros the lost levels", false},
+		{"x", "x part ii", false},
+		{"tetris", "tetris 2", false},
+		{"tetris", "tetris a", false},
+	}
+	for _, tt := range tests {
+		if score := TokenSetRatio(tt.s1, tt.s2); (score == 1.0) != tt.exact {
+			t.Errorf("TokenSetRatio(%q, %q) = %v, expected exact %v", tt.s1, tt.s2, score, tt.exact)
+		}
+	}
+}
+
+func TestFindBestMatchCustomScorer(t *testing.T) {
+	opts := DefaultFindBestMatchOptions()
+	opts.Scorer = TokenSetRatio
+
+	match, score := FindBestMatch("World Mario Super", []string{"Super Mario World", "Super Mario Land"}, opts)
+	if match != "Super Mario World" || score != 1.0 {
+		t.Errorf("FindBestMatch with TokenSetRatio = (%q, %v), expected (\"Super Mario World\", 1.0)", match, score)
+	}
+
+	// A subtitle names a different game
+	if _, score := FindBestMatch("Metroid", []string{"Metroid: Zero Mission"}, opts); score == 1.0 {
+		t.Errorf("FindBestMatch(Metroid, Metroid: Zero Mission) with TokenSetRatio = %v, expected < 1.0", score)
+	}
+}
+
+func TestFindBestMatchTrace(t *testing.T) {
+	opts := DefaultFindBestMatchOptions()
+	opts.Trace = &Trace{}
+
+	candidates := []string{"Super Mario Land", "Super Mario World", "Tetris"}
+	match, score := FindBestMatch("Super Mario World II", candidates, opts)
+	trace := opts.Trace
+	if trace.Match != match || trace.Score != score || trace.NormalizedTerm != "super mario world 2" {
+		t.Errorf("Trace = %+v, expected match %q and normalized term \"super mario world 2\"", trace, match)
+	}
+	if trace.CandidateCount != 3 || trace.Candidates[0].Name != "Super Mario World" || trace.Candidates[2].Name != "Tetris" {
+		t.Errorf("Trace.Candidates = %+v, expected them sorted by score", trace.Candidates)
+	}
+	if len(trace.Steps) == 0 || trace.Steps[len(trace.Steps)-1] != "roman numerals" {
+		t.Errorf("Trace.Steps = %v, expected roman numerals last", trace.Steps)
+	}
+}
+
+func TestTraceAmbiguous(t *testing.T) {
+	opts := DefaultFindBestMatchOptions()
+	opts.Trace = &Trace{}
+
+	candidates := []string{"Tetris", "Tetris", "TETRIS", "Tetris Attack", "Pac-Man"}
+	FindBestMatch("Tetris", candidates, opts)
+	if opts.Trace.CandidateCount != len(candidates) {
+		t.Errorf("CandidateCount = %d, expected every candidate scored despite a perfect match", opts.Trace.CandidateCount)
+	}
+
+	ambiguous := opts.Trace.Ambiguous(0.05)
+	if len(ambiguous) != 2 || ambiguous[0].Name != "Tetris" || ambiguous[1].Name != "TETRIS" {
+		t.Errorf("Ambiguous(0.05) = %+v, expected Tetris and TETRIS", ambiguous)
+	}
+	if got := opts.Trace.Ambiguous(0.2); len(got) != 3 {
+		t.Errorf("Ambiguous(0.2) = %+v, expected Tetris Attack too", got)
+	}
+
+	opts.Trace = &Trace{}
+	FindBestMatch("Pac-Man", candidates, opts)
+	if got := opts.Trace.Ambiguous(0.05); got != nil {
+		t.Errorf("Ambiguous() = %+v for a clear match, expected nil", got)
+	}
+}
@@ -0,0 +1,132 @@
+package matching
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/adrg/strutil"
+	"github.com/adrg/strutil/metrics"
+)
+
+// Scorer is a similarity function returning a value between 0 and 1,
+// where 1 indicates an exact match.
+type Scorer func(s1, s2 string) float64
+
+// Scorer names accepted by ScorerByName.
+const (
+	ScorerJaroWinkler = "jaro-winkler"
+	ScorerLevenshtein = "levenshtein"
+	ScorerTokenSet    = "token-set"
+)
+
+// levenshtein is a reusable Levenshtein metric instance.
+var levenshtein = metrics.NewLevenshtein()
+
+// LevenshteinSimilarity calculates a normalized Levenshtein similarity between two strings.
+// The comparison is case-insensitive and returns a value between 0 and 1.
+func LevenshteinSimilarity(s1, s2 string) float64 {
+	return strutil.Similarity(strings.ToLower(s1), strings.ToLower(s2), levenshtein)
+}
+
+var (
+	// tagTokenPattern matches the words of region and revision tags
+	tagTokenPattern = regexp.MustCompile(`^[\(\[]?(usa|europe|japan|world|korea|china|taiwan|asia|australia|brazil|france|germany|italy|spain|netherlands|sweden|russia|rev|version|v\d[\d.]*)[\)\],]?$`)
+
+	// revisionValuePattern matches revision values like the "a" of "Rev A"
+	revisionValuePattern = regexp.MustCompile(`^[a-z0-9.]{1,4}[\)\],]?$`)
+)
+
+// TokenSetRatio compares two strings by their sets of words, ignoring order and
+// duplicate words. The shared words are compared against each side's full word
+// set using Jaro-Winkler and the highest ratio is returned, so
+// "Zelda Legend of" and "Legend of Zelda" score 1.0. Words only one side has
+// are dropped only if they're region or revision tags, so "Tetris (USA)"
+// scores 1.0 against "Tetris" but "X: Part II" doesn't against "X".
+func TokenSetRatio(s1, s2 string) float64 {
+	tokens1 := tokenSet(s1)
+	tokens2 := tokenSet(s2)
+
+	var intersection, diff1, diff2 []string
+	for token := range tokens1 {
+		if tokens2[token] {
+			intersection = append(intersection, token)
+		} else {
+			diff1 = append(diff1, token)
+		}
+	}
+	for token := range tokens2 {
+		if !tokens1[token] {
+			diff2 = append(diff2, token)
+		}
+	}
+
+	sort.Strings(intersection)
+	sort.Strings(diff1)
+	sort.Strings(diff2)
+
+	sorted := strings.Join(intersection, " ")
+	combined1 := strings.TrimSpace(sorted + " " + strings.Join(diff1, " "))
+	combined2 := strings.TrimSpace(sorted + " " + strings.Join(diff2, " "))
+
+	if combined1 == "" && combined2 == "" {
+		return 1.0
+	}
+
+	best := JaroWinklerSimilarity(combined1, combined2)
+	if sorted != "" {
+		if isTagTokens(diff2) {
+			if score := JaroWinklerSimilarity(sorted, combined1); score > best {
+				best = score
+			}
+		}
+		if isTagTokens(diff1) {
+			if score := JaroWinklerSimilarity(sorted, combined2); score > best {
+				best = score
+			}
+		}
+	}
+	return best
+}
+
+// isTagTokens returns true if every word is part of a region or revision
+// tag. Revision values like the "a" of "Rev A" only count next to a "rev"
+// or "version" word.
+func isTagTokens(tokens []string) bool {
+	revision := false
+	for _, token := range tokens {
+		if match := tagTokenPattern.FindStringSubmatch(token); match != nil && (match[1] == "rev" || match[1] == "version") {
+			revision = true
+		}
+	}
+	for _, token := range tokens {
+		if !tagTokenPattern.MatchString(token) && !(revision && revisionValuePattern.MatchString(token)) {
+			return false
+		}
+	}
+	return true
+}
+
+// tokenSet splits a string into a set of lowercase words.
+func tokenSet(s string) map[string]bool {
+	set := make(map[string]bool)
+	for _, token := range strings.Fields(strings.ToLower(s)) {
+		set[token] = true
+	}
+	return set
+}
+
+// ScorerByName returns the scorer registered under the given name.
+// An empty name returns the default Jaro-Winkler scorer.
+func ScorerByName(name string) (Scorer, bool) {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "", ScorerJaroWinkler, "jarowinkler", "jaro_winkler":
+		return JaroWinklerSimilarity, true
+	case ScorerLevenshtein:
+		return LevenshteinSimilarity, true
+	case ScorerTokenSet, "tokenset", "token_set":
+		return TokenSetRatio, true
+	default:
+		return nil, false
+	}
+}
@@ -0,0 +1,124 @@
+// Package normalize maps the genres, game modes and age ratings returned by
+// providers to a canonical vocabulary, so results from different providers
+// (and in different languages) can be compared, merged and filtered.
+//
+// Each provider spells the same genre its own way: IGDB says "Platform",
+// LaunchBox "Platform", MobyGames "Platformer" and ScreenScraper in French
+// "Plates-formes". Genre maps all of them to "Platform". Values that aren't
+// in the tables are kept as they are.
+package normalize
+
+import (
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+
+	"github.com/josegonzalez/retro-metadata/pkg/retrometadata"
+)
+
+// Result normalizes the genres, game modes and age ratings of a result in
+// place.
+func Result(result *retrometadata.GameResult) {
+	if result != nil {
+		Metadata(&result.Metadata)
+	}
+}
+
+// Metadata normalizes the genres, game modes and age ratings of metadata in
+// place. Values that normalize to the same canonical value are merged.
+func Metadata(m *retrometadata.GameMetadata) {
+	if m == nil {
+		return
+	}
+	m.Genres = Genres(m.Genres)
+	m.GameModes = GameModes(m.GameModes)
+	m.AgeRatings = AgeRatings(m.AgeRatings)
+}
+
+// Genre returns the canonical name of a genre, or the genre trimmed of
+// spaces if it isn't known.
+func Genre(genre string) string {
+	return lookup(genres, genre)
+}
+
+// Genres returns the canonical names of genres, without duplicates.
+// Compound genres such as "Action / Platform" or "Quiz/Trivia" that aren't
+// known as a whole are split into their parts.
+func Genres(names []string) []string {
+	return normalizeAll(genres, names)
+}
+
+// GameMode returns the canonical name of a game mode, or the mode trimmed of
+// spaces if it isn't known.
+func GameMode(mode string) string {
+	return lookup(gameModes, mode)
+}
+
+// GameModes returns the canonical names of game modes, without duplicates.
+func GameModes(modes []string) []string {
+	return normalizeAll(gameModes, modes)
+}
+
+// lookup returns the canonical value of s in table, or s trimmed of spaces.
+func lookup(table map[string]string, s string) string {
+	if canonical, ok := table[key(s)]; ok {
+		return canonical
+	}
+	return strings.TrimSpace(s)
+}
+
+// normalizeAll maps values to their canonical names, splitting unknown
+// compound values, and removes empty values and duplicates.
+func normalizeAll(table map[string]string, values []string) []string {
+	if values == nil {
+		return nil
+	}
+
+	out := make([]string, 0, len(values))
+	seen := make(map[string]bool, len(values))
+	add := func(v string) {
+		if v != "" && !seen[strings.ToLower(v)] {
+			seen[strings.ToLower(v)] = true
+			out = append(out, v)
+		}
+	}
+
+	for _, value := range values {
+		if canonical, ok := table[key(value)]; ok {
+			add(canonical)
+			continue
+		}
+		parts := strings.FieldsFunc(value, func(r rune) bool { return r == '/' || r == ',' || r == ';' })
+		if len(parts) < 2 {
+			add(strings.TrimSpace(value))
+			continue
+		}
+		for _, part := range parts {
+			add(lookup(table, part))
+		}
+	}
+	return out
+}
+
+// key reduces a value to the form used in the tables: lower case, without
+// accents, with every run of punctuation and spaces replaced by one space.
+func key(s string) string {
+	var b strings.Builder
+	space := false
+	for _, r := range norm.NFD.String(strings.ToLower(s)) {
+		switch {
+		case unicode.Is(unicode.Mn, r), r == '\'':
+			// Accents and apostrophes ("beat 'em up") are dropped
+		case unicode.IsLetter(r) || unicode.IsDigit(r) || r == '+':
+			if space && b.Len() > 0 {
+				b.WriteByte(' ')
+			}
+			space = false
+			b.WriteRune(r)
+		default:
+			space = true
+		}
+	}
+	return b.String()
+}
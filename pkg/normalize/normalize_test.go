@@ -0,0 +1,87 @@
+package normalize
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/josegonzalez/retro-metadata/pkg/retrometadata"
+)
+
+func TestGenre(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"Platform", "Platform"},
+		{"Platformer", "Platform"},
+		{"Plates-formes", "Platform"},
+		{"jump 'n' run", "Platform"},
+		{"Role-playing (RPG)", "Role-Playing"},
+		{"Shoot-'em-up", "Shoot 'em up"},
+		{"Réflexion", "Puzzle"},
+		{"  Roguelike ", "Roguelike"},
+	}
+
+	for _, tt := range tests {
+		if got := Genre(tt.input); got != tt.want {
+			t.Errorf("Genre(%q) = %q, want %q", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestGenres(t *testing.T) {
+	got := Genres([]string{"Platformer", "Action / Platform", "Hack and slash/Beat 'em up", "Quiz/Trivia", ""})
+	want := []string{"Platform", "Action", "Hack and Slash", "Beat 'em up", "Quiz"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Genres() = %v, want %v", got, want)
+	}
+}
+
+func TestGameModes(t *testing.T) {
+	got := GameModes([]string{"Single player", "Multijoueur", "Co-operative", "Massively Multiplayer Online (MMO)", "Singleplayer"})
+	want := []string{"Single-player", "Multiplayer", "Co-op", "MMO"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("GameModes() = %v, want %v", got, want)
+	}
+}
+
+func TestAgeRating(t *testing.T) {
+	tests := []struct {
+		input retrometadata.AgeRating
+		want  retrometadata.AgeRating
+	}{
+		{retrometadata.AgeRating{Category: "ESRB", Rating: "T - Teen"}, retrometadata.AgeRating{Category: "ESRB", Rating: "T"}},
+		{retrometadata.AgeRating{Category: "esrb", Rating: "Everyone 10+"}, retrometadata.AgeRating{Category: "ESRB", Rating: "E10+"}},
+		{retrometadata.AgeRating{Category: "pegi", Rating: "12"}, retrometadata.AgeRating{Category: "PEGI", Rating: "PEGI 12"}},
+		{retrometadata.AgeRating{Rating: "PEGI 16"}, retrometadata.AgeRating{Category: "PEGI", Rating: "PEGI 16"}},
+		{retrometadata.AgeRating{Category: "CERO", Rating: "A"}, retrometadata.AgeRating{Category: "CERO", Rating: "CERO A"}},
+		{retrometadata.AgeRating{Category: "USK", Rating: "USK ab 12"}, retrometadata.AgeRating{Category: "USK", Rating: "USK 12"}},
+		{retrometadata.AgeRating{Category: "OFLC", Rating: "M15+"}, retrometadata.AgeRating{Category: "OFLC", Rating: "M15+"}},
+	}
+
+	for _, tt := range tests {
+		if got := AgeRating(tt.input); got != tt.want {
+			t.Errorf("AgeRating(%+v) = %+v, want %+v", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestResult(t *testing.T) {
+	result := &retrometadata.GameResult{Metadata: retrometadata.GameMetadata{
+		Genres:    []string{"Platformer", "Platform"},
+		GameModes: []string{"1 player"},
+		AgeRatings: []retrometadata.AgeRating{
+			{Category: "PEGI", Rating: "PEGI 3"},
+			{Category: "PEGI", Rating: "3", CoverURL: "https://example.com/pegi3.png"},
+		},
+	}}
+	Result(result)
+
+	m := result.Metadata
+	if !reflect.DeepEqual(m.Genres, []string{"Platform"}) || !reflect.DeepEqual(m.GameModes, []string{"Single-player"}) {
+		t.Errorf("Genres = %v, GameModes = %v", m.Genres, m.GameModes)
+	}
+	if len(m.AgeRatings) != 1 || m.AgeRatings[0].CoverURL == "" {
+		t.Errorf("AgeRatings = %+v, expected the duplicates merged with the icon kept", m.AgeRatings)
+	}
+}
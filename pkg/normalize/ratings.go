@@ -0,0 +1,128 @@
+package normalize
+
+import (
+	"strings"
+
+	"github.com/josegonzalez/retro-metadata/pkg/retrometadata"
+)
+
+// Age rating systems.
+const (
+	ESRB     = "ESRB"
+	PEGI     = "PEGI"
+	CERO     = "CERO"
+	USK      = "USK"
+	ACB      = "ACB"
+	GRAC     = "GRAC"
+	ClassInd = "CLASS_IND"
+)
+
+// ratingSystems maps the keys of rating system names to the canonical names.
+var ratingSystems = map[string]string{
+	"esrb":      ESRB,
+	"pegi":      PEGI,
+	"cero":      CERO,
+	"usk":       USK,
+	"acb":       ACB,
+	"grac":      GRAC,
+	"class ind": ClassInd,
+	"classind":  ClassInd,
+}
+
+// ratingAliases maps, per system, the keys of rating spellings to the
+// canonical ratings, which follow the style of the IGDB provider: ESRB
+// letters, and the system name and age for PEGI, CERO and USK.
+var ratingAliases = map[string]map[string]string{
+	ESRB: {
+		"ec": "EC", "early childhood": "EC",
+		"e": "E", "everyone": "E", "k a": "E", "kids to adults": "E",
+		"e10": "E10+", "e10+": "E10+", "everyone 10+": "E10+", "everyone 10": "E10+",
+		"t": "T", "teen": "T",
+		"m": "M", "mature": "M", "mature 17+": "M", "m17": "M",
+		"ao": "AO", "adults only": "AO", "adults only 18+": "AO",
+		"rp": "RP", "rating pending": "RP",
+	},
+	PEGI: {
+		"3": "PEGI 3", "3+": "PEGI 3",
+		"4": "PEGI 4", "4+": "PEGI 4",
+		"6": "PEGI 6", "6+": "PEGI 6",
+		"7": "PEGI 7", "7+": "PEGI 7",
+		"12": "PEGI 12", "12+": "PEGI 12",
+		"16": "PEGI 16", "16+": "PEGI 16",
+		"18": "PEGI 18", "18+": "PEGI 18",
+	},
+	CERO: {
+		"a": "CERO A", "all ages": "CERO A",
+		"b": "CERO B", "12": "CERO B",
+		"c": "CERO C", "15": "CERO C",
+		"d": "CERO D", "17": "CERO D",
+		"z": "CERO Z", "18": "CERO Z",
+	},
+	USK: {
+		"0": "USK 0", "6": "USK 6", "12": "USK 12", "16": "USK 16", "18": "USK 18",
+		"ab 0": "USK 0", "ab 6": "USK 6", "ab 12": "USK 12", "ab 16": "USK 16", "ab 18": "USK 18",
+	},
+	ACB: {
+		"g": "G", "pg": "PG", "m": "M", "ma15+": "MA15+", "ma 15+": "MA15+",
+		"r18+": "R18+", "r 18+": "R18+", "rc": "RC",
+	},
+}
+
+// AgeRating returns a rating with its system and value in canonical form,
+// e.g. {Category: "pegi", Rating: "12"} becomes {Category: "PEGI", Rating:
+// "PEGI 12"}. A missing system is taken from the rating ("PEGI 12"), and
+// LaunchBox style descriptions ("T - Teen") are reduced to the rating.
+func AgeRating(rating retrometadata.AgeRating) retrometadata.AgeRating {
+	value := strings.TrimSpace(rating.Rating)
+	if code, _, ok := strings.Cut(value, " - "); ok {
+		value = strings.TrimSpace(code)
+	}
+
+	system, ok := ratingSystems[key(rating.Category)]
+	if !ok {
+		system = strings.TrimSpace(rating.Category)
+	}
+	valueKey := key(value)
+
+	// "PEGI 12" or "ESRB T" name the system in the rating
+	if prefix, rest, found := strings.Cut(valueKey, " "); found {
+		if named, ok := ratingSystems[prefix]; ok && (system == "" || system == named) {
+			system = named
+			valueKey = rest
+		}
+	}
+
+	if canonical, ok := ratingAliases[system][valueKey]; ok {
+		value = canonical
+	}
+	rating.Category = system
+	rating.Rating = value
+	return rating
+}
+
+// AgeRatings returns ratings in canonical form, without duplicates.
+func AgeRatings(ratings []retrometadata.AgeRating) []retrometadata.AgeRating {
+	if ratings == nil {
+		return nil
+	}
+
+	out := make([]retrometadata.AgeRating, 0, len(ratings))
+	seen := make(map[[2]string]int, len(ratings))
+	for _, r := range ratings {
+		r = AgeRating(r)
+		if r.Rating == "" {
+			continue
+		}
+		id := [2]string{r.Category, r.Rating}
+		if i, ok := seen[id]; ok {
+			// Keep the icon of whichever duplicate has one
+			if out[i].CoverURL == "" {
+				out[i].CoverURL = r.CoverURL
+			}
+			continue
+		}
+		seen[id] = len(out)
+		out = append(out, r)
+	}
+	return out
+}
@@ -0,0 +1,66 @@
+package normalize
+
+// genreAliases maps each canonical genre to the spellings providers use for
+// it, in English, French, German and Spanish. Aliases are matched by key, so
+// case, accents and punctuation don't matter.
+var genreAliases = map[string][]string{
+	"Action":              {"Action", "Acción", "Aktion"},
+	"Action-Adventure":    {"Action Adventure", "Action-Adventure", "Action/Adventure"},
+	"Adventure":           {"Adventure", "Aventure", "Abenteuer", "Aventura", "Point-and-click", "Point and click", "Graphic adventure"},
+	"Arcade":              {"Arcade", "Arcade game"},
+	"Beat 'em up":         {"Beat 'em up", "Beat-'em-up", "Beat em up", "Brawler", "Beat'em all", "Beat them all"},
+	"Board Game":          {"Board Game", "Board", "Tabletop", "Card & Board Game", "Jeu de plateau", "Brettspiel", "Juego de mesa"},
+	"Card Game":           {"Card Game", "Cards", "Jeu de cartes", "Kartenspiel", "Juego de cartas"},
+	"Casino":              {"Casino", "Gambling"},
+	"Casual":              {"Casual"},
+	"Educational":         {"Educational", "Education", "Edutainment", "Éducatif", "Educatif", "Lernspiel", "Educativo"},
+	"Fighting":            {"Fighting", "Fighter", "Versus fighting", "Combat", "Jeu de combat", "Kampfspiel", "Lucha"},
+	"Hack and Slash":      {"Hack and slash", "Hack 'n' slash", "Hack & slash"},
+	"Music":               {"Music", "Rhythm", "Music/Rhythm", "Musique", "Rythme", "Musik", "Música"},
+	"Party":               {"Party", "Party game", "Mini-games", "Minigames"},
+	"Pinball":             {"Pinball", "Flipper", "Flipper pinball"},
+	"Platform":            {"Platform", "Platformer", "Platforms", "Plate-forme", "Plates-formes", "Plateforme", "Plattform", "Plattformer", "Jump 'n' run", "Jump and run", "Plataformas"},
+	"Puzzle":              {"Puzzle", "Puzzles", "Puzzle game", "Réflexion", "Reflexion", "Logic", "Logique", "Denkspiel", "Rompecabezas"},
+	"Quiz":                {"Quiz", "Trivia", "Quiz/Trivia"},
+	"Racing":              {"Racing", "Race", "Driving", "Racing/Driving", "Course", "Rennspiel", "Carreras"},
+	"Real-Time Strategy":  {"Real Time Strategy", "Real-Time Strategy", "Real Time Strategy (RTS)", "RTS", "Stratégie temps réel"},
+	"Role-Playing":        {"Role-Playing", "Role Playing", "Roleplaying", "Role-playing (RPG)", "RPG", "Action RPG", "Jeu de rôle", "Jeux de rôle", "Rollenspiel"},
+	"Shoot 'em up":        {"Shoot 'em up", "Shoot-'em-up", "Shoot em up", "Shmup", "Shoot'em up"},
+	"Shooter":             {"Shooter", "FPS", "First-person shooter", "Third-person shooter", "Light gun", "Lightgun", "Lightgun shooter", "Tir", "Shooting", "Disparos"},
+	"Simulation":          {"Simulation", "Simulator", "Sim", "Simulación"},
+	"Sports":              {"Sports", "Sport", "Sportif", "Sportspiel", "Deportes"},
+	"Stealth":             {"Stealth", "Infiltration"},
+	"Strategy":            {"Strategy", "Stratégie", "Strategie", "Estrategia"},
+	"Tactical":            {"Tactical", "Tactics", "Tactique", "Taktik"},
+	"Turn-Based Strategy": {"Turn-Based Strategy", "Turn Based Strategy", "Turn-based strategy (TBS)", "TBS"},
+	"Visual Novel":        {"Visual Novel", "Visual novels"},
+}
+
+// gameModeAliases maps each canonical game mode to the spellings providers
+// use for it.
+var gameModeAliases = map[string][]string{
+	"Single-player": {"Single player", "Single-player", "Singleplayer", "1 player", "Solo", "Un joueur", "Einzelspieler"},
+	"Multiplayer":   {"Multiplayer", "Multi-player", "Multijoueur", "Mehrspieler", "Multijugador"},
+	"Co-op":         {"Co-op", "Coop", "Co-operative", "Cooperative", "Coopératif", "Kooperativ"},
+	"Split Screen":  {"Split screen", "Split-screen", "Écran partagé"},
+	"MMO":           {"MMO", "Massively Multiplayer Online (MMO)", "Massively multiplayer", "MMORPG"},
+	"Battle Royale": {"Battle Royale"},
+}
+
+// genres and gameModes map the key of every alias to its canonical value.
+var (
+	genres    = invert(genreAliases)
+	gameModes = invert(gameModeAliases)
+)
+
+// invert builds a lookup table from canonical values and their aliases.
+func invert(aliases map[string][]string) map[string]string {
+	table := make(map[string]string)
+	for canonical, names := range aliases {
+		table[key(canonical)] = canonical
+		for _, name := range names {
+			table[key(name)] = canonical
+		}
+	}
+	return table
+}
@@ -0,0 +1,160 @@
+package platform
+
+import (
+	"strings"
+	"unicode"
+)
+
+// slugAliases maps common synonyms, EmulationStation system names and RetroPie
+// folder names to slugs. Keys are compacted (see compactAlias), so "mega-drive",
+// "Mega Drive" and "megadrive" all match the same entry. Slugs and their
+// human-readable names are recognized without being listed here.
+var slugAliases = map[string]Slug{
+	// Nintendo
+	"fc":                               SlugFamicom,
+	"superfamicom":                     SlugSFam,
+	"sfc":                              SlugSFam,
+	"sufami":                           SlugSFam,
+	"supernes":                         SlugSNES,
+	"supernintendoentertainmentsystem": SlugSNES,
+	"nintendoentertainmentsystem":      SlugNES,
+	"snesmsu1":                         SlugMSU1,
+	"snesmsu":                          SlugMSU1,
+	"nintendo64":                       SlugN64,
+	"n64dd":                            SlugN64DD,
+	"gamecube":                         SlugNGC,
+	"gc":                               SlugNGC,
+	"gameboy":                          SlugGB,
+	"gameboycolor":                     SlugGBC,
+	"gameboyadvance":                   SlugGBA,
+	"ds":                               SlugNDS,
+	"nintendods":                       SlugNDS,
+	"n3ds":                             SlugN3DS,
+	"famicomdisksystem":                SlugFDS,
+	"pokemini":                         SlugPokemonMini,
+	"vb":                               SlugVirtualBoy,
+	// Sega
+	"megadrive":    SlugGenesis,
+	"md":           SlugGenesis,
+	"mastersystem": SlugSMS,
+	"32x":          SlugSega32,
+	"sega32x":      SlugSega32,
+	"megacd":       SlugSegaCD,
+	"dreamcast":    SlugDC,
+	"sg1000":       SlugSG1000,
+	"naomigd":      SlugNaomi,
+	// Sony
+	"ps1":          SlugPSX,
+	"psone":        SlugPSX,
+	"playstation1": SlugPSX,
+	"vita":         SlugPSVita,
+	// Atari
+	"a2600":         SlugAtari2600,
+	"a5200":         SlugAtari5200,
+	"a7800":         SlugAtari7800,
+	"atari800":      SlugAtari8bit,
+	"atarilynx":     SlugLynx,
+	"atarijaguar":   SlugJaguar,
+	"atarijaguarcd": SlugAtariJaguarCD,
+	"atarist":       SlugAtariST,
+	// NEC
+	"pcengine":     SlugTG16,
+	"pce":          SlugTG16,
+	"turbografx":   SlugTG16,
+	"turbografx16": SlugTG16,
+	"pcenginecd":   SlugTurboGrafxCD,
+	"tgcd":         SlugTurboGrafxCD,
+	"pcfx":         SlugPCFX,
+	"pc98":         SlugPC9800,
+	"pc88":         SlugPC8800,
+	// SNK and Bandai
+	"neogeo":          SlugNeoGeoMVS,
+	"neogeocd":        SlugNeoGeoCD,
+	"ngp":             SlugNeoGeoPocket,
+	"ngpc":            SlugNeoGeoPocketColor,
+	"wsc":             SlugWonderSwanColor,
+	"wonderswancolor": SlugWonderSwanColor,
+	// Arcade
+	"mame":         SlugArcade,
+	"mamelibretro": SlugArcade,
+	"mame2003":     SlugArcade,
+	"mame2003plus": SlugArcade,
+	"mame2010":     SlugArcade,
+	"fba":          SlugArcade,
+	"fbneo":        SlugArcade,
+	"finalburnneo": SlugArcade,
+	// Computers
+	"amstradcpc":  SlugAcpc,
+	"cpc":         SlugAcpc,
+	"zxspectrum":  SlugZXS,
+	"spectrum":    SlugZXS,
+	"commodore64": SlugC64,
+	"cd32":        SlugAmigaCD32,
+	"amigacd32":   SlugAmigaCD32,
+	"msx1":        SlugMSX,
+	"x68000":      SlugSharpX68000,
+	"apple2":      SlugAppleII,
+	"apple2gs":    SlugAppleIIGS,
+	"macintosh":   SlugMac,
+	"msdos":       SlugDOS,
+	"pc":          SlugDOS,
+	"pcwin":       SlugWin,
+	"windows":     SlugWin,
+	"win9x":       SlugWin9x,
+	"win98":       SlugWin9x,
+	"windows95":   SlugWin9x,
+	"windows98":   SlugWin9x,
+	"win31":       SlugWin3x,
+	// Others
+	"coleco":   SlugColecovision,
+	"videopac": SlugOdyssey2,
+	"channelf": SlugFairchildChannelF,
+	"pico":     SlugPico8,
+	"tic":      SlugTIC80,
+	"flash":    SlugBrowser,
+	"web":      SlugBrowser,
+}
+
+// aliasIndex maps compacted slugs, names and aliases to slugs.
+var aliasIndex = buildAliasIndex()
+
+// buildAliasIndex indexes every slug and name, then the explicit aliases.
+// Explicit aliases take precedence over names.
+func buildAliasIndex() map[string]Slug {
+	index := make(map[string]Slug, len(slugNames)*2+len(slugAliases))
+	for slug := range slugNames {
+		index[compactAlias(string(slug))] = slug
+	}
+	for slug, name := range slugNames {
+		if _, exists := index[compactAlias(name)]; !exists {
+			index[compactAlias(name)] = slug
+		}
+	}
+	for alias, slug := range slugAliases {
+		index[compactAlias(alias)] = slug
+	}
+	return index
+}
+
+// compactAlias lowercases s and removes everything but letters and digits.
+func compactAlias(s string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(s) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// ParseSlug resolves a platform slug, name or common alias to its canonical
+// slug. It accepts slugs ("snes"), names ("Super Nintendo"), synonyms
+// ("sega-genesis", "super-nintendo") and EmulationStation/RetroPie system
+// folder names ("megadrive", "pcengine", "mame"), ignoring case and punctuation.
+func ParseSlug(s string) (Slug, bool) {
+	if slug := Slug(strings.TrimSpace(s)); slug.IsValid() {
+		return slug, true
+	}
+	slug, ok := aliasIndex[compactAlias(s)]
+	return slug, ok
+}
@@ -0,0 +1,41 @@
+package platform
+
+import "testing"
+
+func TestParseSlug(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected Slug
+		ok       bool
+	}{
+		{"snes", SlugSNES, true},
+		{"super-nintendo", SlugSNES, true},
+		{"Super Nintendo", SlugSNES, true},
+		{"SNES", SlugSNES, true},
+		{"megadrive", SlugGenesis, true},
+		{"mega-drive", SlugGenesis, true},
+		{"sega-genesis", SlugGenesis, true},
+		{"Sega Genesis", SlugGenesis, true},
+		{"genesis", SlugGenesis, true},
+		{"pcengine", SlugTG16, true},
+		{"tg16", SlugTG16, true},
+		{"mame", SlugArcade, true},
+		{"gamecube", SlugNGC, true},
+		{"playstation", SlugPSX, true},
+		{"ps1", SlugPSX, true},
+		{"sfc", SlugSFam, true},
+		{"atari800", SlugAtari8bit, true},
+		{"snes_msu1", SlugMSU1, true},
+		{"  n64  ", SlugN64, true},
+		{"", "", false},
+		{"roms", "", false},
+		{"not-a-platform", "", false},
+	}
+
+	for _, tt := range tests {
+		slug, ok := ParseSlug(tt.input)
+		if slug != tt.expected || ok != tt.ok {
+			t.Errorf("ParseSlug(%q) = (%q, %v), want (%q, %v)", tt.input, slug, ok, tt.expected, tt.ok)
+		}
+	}
+}
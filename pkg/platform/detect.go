@@ -0,0 +1,131 @@
+package platform
+
+import (
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// maxDetectDepth is the number of parent directories DetectFromPath checks.
+const maxDetectDepth = 3
+
+// extensionSlugs maps ROM file extensions to the single platform that uses them.
+// Extensions shared by several platforms (.bin, .iso, .chd, .cue, .zip) are
+// deliberately left out, since only the folder can tell those apart.
+var extensionSlugs = map[string]Slug{
+	// Nintendo
+	"nes": SlugNES, "unf": SlugNES, "unif": SlugNES,
+	"fds": SlugFDS,
+	"sfc": SlugSNES, "smc": SlugSNES, "fig": SlugSNES, "swc": SlugSNES,
+	"n64": SlugN64, "v64": SlugN64, "z64": SlugN64,
+	"ndd": SlugN64DD,
+	"gb":  SlugGB,
+	"gbc": SlugGBC,
+	"gba": SlugGBA,
+	"nds": SlugNDS,
+	"3ds": SlugN3DS, "cia": SlugN3DS,
+	"vb":   SlugVirtualBoy,
+	"min":  SlugPokemonMini,
+	"gcm":  SlugNGC,
+	"wbfs": SlugWii,
+	"wud":  SlugWiiU, "wux": SlugWiiU, "rpx": SlugWiiU,
+	"nsp": SlugSwitch, "xci": SlugSwitch,
+	// Sega
+	"md": SlugGenesis, "gen": SlugGenesis, "smd": SlugGenesis,
+	"32x": SlugSega32,
+	"sms": SlugSMS,
+	"gg":  SlugGameGear,
+	"sg":  SlugSG1000,
+	"gdi": SlugDC, "cdi": SlugDC,
+	// Sony
+	"cso": SlugPSP,
+	"vpk": SlugPSVita,
+	// Atari
+	"a26": SlugAtari2600,
+	"a52": SlugAtari5200,
+	"a78": SlugAtari7800,
+	"j64": SlugJaguar, "jag": SlugJaguar,
+	"lnx": SlugLynx,
+	// NEC, SNK, Bandai and others
+	"pce": SlugTG16,
+	"sgx": SlugSuperGrafx,
+	"ws":  SlugWonderSwan,
+	"wsc": SlugWonderSwanColor,
+	"ngp": SlugNeoGeoPocket,
+	"ngc": SlugNeoGeoPocketColor,
+	"vec": SlugVectrex,
+	"col": SlugColecovision,
+	"int": SlugIntellvision,
+	// Computers
+	"d64": SlugC64, "t64": SlugC64, "prg": SlugC64, "crt": SlugC64,
+	"adf": SlugAmiga, "ipf": SlugAmiga, "dms": SlugAmiga,
+	"tzx": SlugZXS, "z80": SlugZXS, "sna": SlugZXS,
+	"cdt": SlugAcpc,
+	"mx1": SlugMSX, "mx2": SlugMSX,
+	// Fantasy consoles
+	"p8":  SlugPico8,
+	"tic": SlugTIC80,
+}
+
+// sharedExtensions are formats used by many platforms, which
+// RegisterExtensions never maps to one.
+var sharedExtensions = map[string]bool{
+	"bin": true, "iso": true, "img": true, "chd": true, "cue": true, "ccd": true,
+	"zip": true, "7z": true, "rar": true, "m3u": true, "rom": true,
+}
+
+// extensionsMu guards extensionSlugs, which RegisterExtensions changes at
+// runtime.
+var extensionsMu sync.RWMutex
+
+// RegisterExtensions adds ROM file extensions (without the dot) for
+// platforms, e.g. from a provider's list of systems. Extensions already
+// known, and formats many platforms use, like .bin and .zip, aren't mapped.
+// It is safe to call concurrently with detection.
+func RegisterExtensions(extensions map[string]Slug) {
+	extensionsMu.Lock()
+	defer extensionsMu.Unlock()
+	for ext, slug := range extensions {
+		ext = strings.ToLower(strings.TrimPrefix(ext, "."))
+		if _, ok := extensionSlugs[ext]; !ok && ext != "" && !sharedExtensions[ext] && slug.IsValid() {
+			extensionSlugs[ext] = slug
+		}
+	}
+}
+
+// DetectFromFilename detects the platform from a ROM file extension.
+// Returns an empty slug if the extension is unknown or shared by several platforms.
+func DetectFromFilename(filename string) Slug {
+	ext := strings.ToLower(strings.TrimPrefix(filepath.Ext(filename), "."))
+	extensionsMu.RLock()
+	defer extensionsMu.RUnlock()
+	return extensionSlugs[ext]
+}
+
+// DetectFromFolder detects the platform from a folder name like "snes",
+// "megadrive" or "psx", using the same aliases as ParseSlug.
+// Returns an empty slug if the folder isn't recognized.
+func DetectFromFolder(name string) Slug {
+	slug, _ := ParseSlug(name)
+	return slug
+}
+
+// DetectFromPath detects the platform of a ROM from its path.
+//
+// The nearest parent folders (e.g. roms/snes/Game.sfc) are checked first,
+// since a system folder is also the only way to place ambiguous formats like
+// .chd or .iso. The file extension is used if no folder matches.
+func DetectFromPath(path string) Slug {
+	dir := filepath.Dir(path)
+	for i := 0; i < maxDetectDepth; i++ {
+		base := filepath.Base(dir)
+		if base == "." || base == string(filepath.Separator) {
+			break
+		}
+		if slug := DetectFromFolder(base); slug != "" {
+			return slug
+		}
+		dir = filepath.Dir(dir)
+	}
+	return DetectFromFilename(path)
+}
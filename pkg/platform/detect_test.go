@@ -0,0 +1,32 @@
+package platform
+
+import "testing"
+
+func TestDetectFromPath(t *testing.T) {
+	tests := []struct {
+		path     string
+		expected Slug
+	}{
+		{"Super Mario World (USA).sfc", SlugSNES},
+		{"Sonic (USA).MD", SlugGenesis},
+		{"Pokemon Red.gb", SlugGB},
+		{"Celeste.p8", SlugPico8},
+		{"Game.chd", ""},
+		{"Game.iso", ""},
+		{"/roms/snes/Super Mario World (USA).sfc", SlugSNES},
+		{"/roms/psx/Final Fantasy VII (Disc 1).chd", SlugPSX},
+		{"/roms/megadrive/Sonic.bin", SlugGenesis},
+		{"/roms/dreamcast/Shenmue/Shenmue.gdi", SlugDC},
+		{"/roms/segacd/Sonic CD/Tracks/Sonic CD.cue", SlugSegaCD},
+		{"/roms/famicom/Game.nes", SlugFamicom},
+		{"/roms/mame/sf2.zip", SlugArcade},
+		{"/roms/unsorted/Game.gba", SlugGBA},
+		{"/roms/unsorted/Game.zip", ""},
+	}
+
+	for _, tt := range tests {
+		if result := DetectFromPath(tt.path); result != tt.expected {
+			t.Errorf("DetectFromPath(%q) = %q, want %q", tt.path, result, tt.expected)
+		}
+	}
+}
@@ -1,5 +1,10 @@
 package platform
 
+import (
+	"sort"
+	"strings"
+)
+
 // PlatformInfo contains information about a platform across multiple providers.
 type PlatformInfo struct {
 	// Slug is the universal platform slug
@@ -14,6 +19,13 @@ type PlatformInfo struct {
 	ScreenScraperID *int `json:"screenscraper_id,omitempty"`
 	// RetroAchievementsID is the RetroAchievements console ID
 	RetroAchievementsID *int `json:"retroachievements_id,omitempty"`
+	// ProviderIDs maps every provider that lists the platform to its platform ID
+	ProviderIDs map[string]int `json:"provider_ids,omitempty"`
+	// Parent is the platform provider IDs fall back to, if any
+	Parent Slug `json:"parent,omitempty"`
+	// LocalOnly is true if no provider catalogues the platform, so games can
+	// only be identified from local sources such as datfiles and filenames
+	LocalOnly bool `json:"local_only"`
 }
 
 // IGDB platform ID mappings
@@ -251,6 +263,13 @@ var mobygamesPlatformMap = map[Slug]int{
 	SlugOuya:              144,
 	SlugPlaydate:          298,
 	SlugEvercade:          294,
+	SlugJ2ME:              64,
+	SlugPalmOS:            51,
+	SlugSymbian:           67,
+	SlugOpenBOR:           256,
+	SlugScummVM:           257,
+	SlugTIC80:             301,
+	SlugPico8:             302,
 }
 
 // ScreenScraper platform ID mappings
@@ -309,6 +328,17 @@ var screenscraperPlatformMap = map[Slug]int{
 	SlugXbox:              32,
 	SlugXbox360:           33,
 	SlugZXS:               76,
+	SlugAtomiswave:        53,
+	SlugModel2:            54,
+	SlugModel3:            55,
+	SlugNaomi:             56,
+	SlugPC9800:            208,
+	SlugPalmOS:            170,
+	SlugSymbian:           168,
+	SlugOpenBOR:           214,
+	SlugScummVM:           123,
+	SlugTIC80:             222,
+	SlugPico8:             234,
 }
 
 // RetroAchievements platform ID mappings
@@ -349,40 +379,579 @@ var retroachievementsPlatformMap = map[Slug]int{
 	SlugVectrex:      46,
 	SlugVirtualBoy:   28,
 	SlugWonderSwan:   53,
+	SlugPC9800:       48,
+}
+
+// LaunchBox platform ID mappings
+var launchboxPlatformMap = map[Slug]int{
+	Slug3DO:               1,
+	SlugN3DS:              24,
+	SlugAcpc:              3,
+	SlugAmiga:             2,
+	SlugAmigaCD32:         119,
+	SlugAmstradGX4000:     109,
+	SlugAndroid:           4,
+	SlugAppleII:           110,
+	SlugAppleIIGS:         112,
+	SlugArcade:            5,
+	SlugAtari2600:         6,
+	SlugAtari5200:         7,
+	SlugAtari7800:         8,
+	SlugAtariJaguarCD:     10,
+	SlugAtariST:           76,
+	SlugAtariXEGS:         12,
+	SlugC64:               14,
+	SlugColecovision:      13,
+	SlugCommodoreCDTV:     120,
+	SlugDOS:               16,
+	SlugDC:                52,
+	SlugFairchildChannelF: 113,
+	SlugFDS:               75,
+	SlugGB:                17,
+	SlugGBA:               18,
+	SlugGBC:               19,
+	SlugGameGear:          47,
+	SlugGenesis:           49,
+	SlugIntellvision:      21,
+	SlugJaguar:            9,
+	SlugLynx:              11,
+	SlugMSX:               22,
+	SlugMSX2:              23,
+	SlugN64:               25,
+	SlugNDS:               26,
+	SlugNES:               27,
+	SlugNGC:               20,
+	SlugNeoGeoCD:          91,
+	SlugNeoGeoPocket:      92,
+	SlugNeoGeoPocketColor: 93,
+	SlugNeoGeoAES:         54,
+	SlugOdyssey2:          81,
+	SlugPC8800:            94,
+	SlugPC9800:            95,
+	SlugPCFX:              96,
+	SlugPSX:               55,
+	SlugPS2:               56,
+	SlugPS3:               57,
+	SlugPSP:               58,
+	SlugPSVita:            59,
+	SlugSaturn:            51,
+	SlugSega32:            44,
+	SlugSegaCD:            45,
+	SlugSG1000:            46,
+	SlugSMS:               48,
+	SlugSNES:              60,
+	SlugSuperGrafx:        100,
+	SlugSwitch:            61,
+	SlugTG16:              98,
+	SlugTurboGrafxCD:      99,
+	SlugVectrex:           80,
+	SlugVirtualBoy:        28,
+	SlugWii:               29,
+	SlugWiiU:              30,
+	SlugWonderSwan:        62,
+	SlugWonderSwanColor:   63,
+	SlugXbox:              31,
+	SlugXbox360:           32,
+	SlugZXS:               65,
+}
+
+// TheGamesDB platform ID mappings
+var thegamesdbPlatformMap = map[Slug]int{
+	Slug3DO:               25,
+	SlugAcpc:              4914,
+	SlugAmstradGX4000:     4999,
+	SlugAndroid:           4916,
+	SlugIOS:               4915,
+	SlugNGage:             4938,
+	SlugPalmOS:            4977,
+	SlugSymbian:           4978,
+	SlugAppleII:           4942,
+	SlugAppleIIGS:         5003,
+	SlugMac:               37,
+	SlugArcade:            23,
+	SlugNeoGeoAES:         24,
+	SlugNeoGeoMVS:         24,
+	SlugAtari2600:         22,
+	SlugAtari5200:         26,
+	SlugAtari7800:         27,
+	SlugAtari8bit:         4943,
+	SlugAtariST:           4937,
+	SlugAtariXEGS:         30,
+	SlugJaguar:            28,
+	SlugAtariJaguarCD:     29,
+	SlugLynx:              4924,
+	SlugWonderSwan:        4925,
+	SlugWonderSwanColor:   4926,
+	SlugBBCMicro:          5013,
+	SlugColecovision:      31,
+	SlugAmiga:             4911,
+	SlugAmigaCD32:         4947,
+	SlugCommodoreCDTV:     4986,
+	SlugC64:               40,
+	SlugC128:              4948,
+	SlugVIC20:             4945,
+	SlugDOS:               1,
+	SlugWin:               1,
+	SlugWin3x:             1,
+	SlugFMTowns:           4932,
+	SlugGamate:            4996,
+	SlugVectrex:           4939,
+	SlugIntellvision:      32,
+	SlugOdyssey2:          4927,
+	SlugXbox:              14,
+	SlugXbox360:           15,
+	SlugXboxOne:           4920,
+	SlugSeriesXS:          4998,
+	SlugMSX:               4929,
+	SlugMSX2:              4929,
+	SlugMSX2Plus:          4929,
+	SlugPC8800:            4933,
+	SlugPC9800:            4934,
+	SlugPCFX:              4930,
+	SlugTG16:              34,
+	SlugTurboGrafxCD:      4940,
+	SlugSuperGrafx:        4955,
+	SlugNeoGeoCD:          4956,
+	SlugNeoGeoPocket:      4922,
+	SlugNeoGeoPocketColor: 4923,
+	SlugNES:               7,
+	SlugFamicom:           7,
+	SlugFDS:               4936,
+	SlugSNES:              6,
+	SlugSFam:              6,
+	SlugN64:               3,
+	SlugNGC:               2,
+	SlugWii:               9,
+	SlugWiiU:              38,
+	SlugSwitch:            4971,
+	SlugGB:                4,
+	SlugGBC:               41,
+	SlugGBA:               5,
+	SlugNDS:               8,
+	SlugN3DS:              4912,
+	SlugVirtualBoy:        4918,
+	SlugPokemonMini:       4957,
+	SlugOuya:              4921,
+	SlugPlaydate:          4997,
+	SlugSG1000:            4949,
+	SlugSMS:               35,
+	SlugGenesis:           18,
+	SlugSegaCD:            21,
+	SlugSegaCD32:          4960,
+	SlugSega32:            33,
+	SlugSaturn:            17,
+	SlugDC:                16,
+	SlugGameGear:          20,
+	SlugSegaPico:          4958,
+	SlugSharpX68000:       4931,
+	SlugX1:                4990,
+	SlugZXS:               4913,
+	SlugZX81:              4969,
+	SlugPSX:               10,
+	SlugPS2:               11,
+	SlugPS3:               12,
+	SlugPS4:               4919,
+	SlugPS5:               4980,
+	SlugPSP:               13,
+	SlugPSVita:            39,
+	SlugPocketstation:     4959,
+	SlugSupervision:       4966,
+	SlugGameDotCom:        4961,
+	SlugStadia:            4982,
+	SlugGizmondo:          4951,
+	SlugEvercade:          5000,
+}
+
+// Flashpoint platform ID mappings
+var flashpointPlatformMap = map[Slug]int{
+	SlugBrowser: 1,
+}
+
+// LaunchBox platform names, as used in the LaunchBox metadata XML
+var launchboxPlatformNames = map[Slug]string{
+	Slug3DO:               "3DO Interactive Multiplayer",
+	SlugN3DS:              "Nintendo 3DS",
+	SlugAcpc:              "Amstrad CPC",
+	SlugAmiga:             "Commodore Amiga",
+	SlugAmigaCD32:         "Commodore Amiga CD32",
+	SlugAmstradGX4000:     "Amstrad GX4000",
+	SlugAndroid:           "Android",
+	SlugAppleII:           "Apple II",
+	SlugAppleIIGS:         "Apple IIGS",
+	SlugArcade:            "Arcade",
+	SlugAtari2600:         "Atari 2600",
+	SlugAtari5200:         "Atari 5200",
+	SlugAtari7800:         "Atari 7800",
+	SlugAtariJaguarCD:     "Atari Jaguar CD",
+	SlugAtariST:           "Atari ST",
+	SlugAtariXEGS:         "Atari XEGS",
+	SlugC64:               "Commodore 64",
+	SlugColecovision:      "ColecoVision",
+	SlugCommodoreCDTV:     "Commodore CDTV",
+	SlugDOS:               "DOS",
+	SlugDC:                "Sega Dreamcast",
+	SlugFairchildChannelF: "Fairchild Channel F",
+	SlugFDS:               "Nintendo Famicom Disk System",
+	SlugGB:                "Nintendo Game Boy",
+	SlugGBA:               "Nintendo Game Boy Advance",
+	SlugGBC:               "Nintendo Game Boy Color",
+	SlugGameGear:          "Sega Game Gear",
+	SlugGenesis:           "Sega Genesis",
+	SlugIntellvision:      "Mattel Intellivision",
+	SlugJaguar:            "Atari Jaguar",
+	SlugLynx:              "Atari Lynx",
+	SlugMSX:               "Microsoft MSX",
+	SlugMSX2:              "Microsoft MSX2",
+	SlugN64:               "Nintendo 64",
+	SlugNDS:               "Nintendo DS",
+	SlugNES:               "Nintendo Entertainment System",
+	SlugNGC:               "Nintendo GameCube",
+	SlugNeoGeoCD:          "SNK Neo Geo CD",
+	SlugNeoGeoPocket:      "SNK Neo Geo Pocket",
+	SlugNeoGeoPocketColor: "SNK Neo Geo Pocket Color",
+	SlugNeoGeoAES:         "SNK Neo Geo AES",
+	SlugOdyssey2:          "Magnavox Odyssey 2",
+	SlugPC8800:            "NEC PC-8801",
+	SlugPC9800:            "NEC PC-9801",
+	SlugPCFX:              "NEC PC-FX",
+	SlugPSX:               "Sony Playstation",
+	SlugPS2:               "Sony Playstation 2",
+	SlugPS3:               "Sony Playstation 3",
+	SlugPSP:               "Sony PSP",
+	SlugPSVita:            "Sony Playstation Vita",
+	SlugSaturn:            "Sega Saturn",
+	SlugSega32:            "Sega 32X",
+	SlugSegaCD:            "Sega CD",
+	SlugSG1000:            "Sega SG-1000",
+	SlugSMS:               "Sega Master System",
+	SlugSNES:              "Super Nintendo Entertainment System",
+	SlugSuperGrafx:        "NEC SuperGrafx",
+	SlugSwitch:            "Nintendo Switch",
+	SlugTG16:              "NEC TurboGrafx-16",
+	SlugTurboGrafxCD:      "NEC TurboGrafx-CD",
+	SlugVectrex:           "GCE Vectrex",
+	SlugVirtualBoy:        "Nintendo Virtual Boy",
+	SlugWii:               "Nintendo Wii",
+	SlugWiiU:              "Nintendo Wii U",
+	SlugWonderSwan:        "Bandai WonderSwan",
+	SlugWonderSwanColor:   "Bandai WonderSwan Color",
+	SlugXbox:              "Microsoft Xbox",
+	SlugXbox360:           "Microsoft Xbox 360",
+	SlugZXS:               "Sinclair ZX Spectrum",
+}
+
+// HowLongToBeat platform names, as used by its platform filter
+var hltbPlatformNames = map[Slug]string{
+	Slug3DO:               "3DO",
+	SlugAcpc:              "Amstrad CPC",
+	SlugAndroid:           "Android",
+	SlugIOS:               "iOS",
+	SlugAppleII:           "Apple II",
+	SlugAppleIIGS:         "Apple IIGS",
+	SlugMac:               "Mac",
+	SlugArcade:            "Arcade",
+	SlugCPS1:              "Arcade",
+	SlugCPS2:              "Arcade",
+	SlugCPS3:              "Arcade",
+	SlugNeoGeoAES:         "Neo Geo",
+	SlugNeoGeoMVS:         "Neo Geo",
+	SlugAtari2600:         "Atari 2600",
+	SlugAtari5200:         "Atari 5200",
+	SlugAtari7800:         "Atari 7800",
+	SlugAtari8bit:         "Atari 8-bit Family",
+	SlugAtariST:           "Atari ST",
+	SlugJaguar:            "Atari Jaguar",
+	SlugAtariJaguarCD:     "Atari Jaguar CD",
+	SlugLynx:              "Atari Lynx",
+	SlugWonderSwan:        "WonderSwan",
+	SlugWonderSwanColor:   "WonderSwan Color",
+	SlugBBCMicro:          "BBC Micro",
+	SlugColecovision:      "ColecoVision",
+	SlugAmiga:             "Amiga",
+	SlugAmigaCD32:         "Amiga CD32",
+	SlugC64:               "Commodore 64",
+	SlugC128:              "Commodore 64",
+	SlugVIC20:             "Commodore VIC-20",
+	SlugCommodoreCDTV:     "Commodore CDTV",
+	SlugDOS:               "PC",
+	SlugWin:               "PC",
+	SlugWin3x:             "PC",
+	SlugLinux:             "Linux",
+	SlugFairchildChannelF: "Channel F",
+	SlugFMTowns:           "FM Towns",
+	SlugIntellvision:      "Intellivision",
+	SlugXbox:              "Xbox",
+	SlugXbox360:           "Xbox 360",
+	SlugXboxOne:           "Xbox One",
+	SlugSeriesXS:          "Xbox Series X/S",
+	SlugPC8800:            "PC-88",
+	SlugPC9800:            "PC-98",
+	SlugPCFX:              "PC-FX",
+	SlugTG16:              "TurboGrafx-16",
+	SlugTurboGrafxCD:      "TurboGrafx-CD",
+	SlugSuperGrafx:        "SuperGrafx",
+	SlugNeoGeoCD:          "Neo Geo CD",
+	SlugNeoGeoPocket:      "Neo Geo Pocket",
+	SlugNeoGeoPocketColor: "Neo Geo Pocket Color",
+	SlugNES:               "NES",
+	SlugFamicom:           "NES",
+	SlugFDS:               "Famicom Disk System",
+	SlugSNES:              "SNES",
+	SlugSFam:              "SNES",
+	SlugSatellaview:       "Satellaview",
+	SlugN64:               "Nintendo 64",
+	SlugN64DD:             "Nintendo 64DD",
+	SlugNGC:               "GameCube",
+	SlugWii:               "Wii",
+	SlugWiiU:              "Wii U",
+	SlugSwitch:            "Nintendo Switch",
+	SlugGB:                "Game Boy",
+	SlugGBC:               "Game Boy Color",
+	SlugGBA:               "Game Boy Advance",
+	SlugNDS:               "Nintendo DS",
+	SlugNintendoDSi:       "Nintendo DSi",
+	SlugN3DS:              "Nintendo 3DS",
+	SlugNewNintendo3DS:    "New Nintendo 3DS",
+	SlugVirtualBoy:        "Virtual Boy",
+	SlugOdyssey2:          "Odyssey 2",
+	SlugSMS:               "Master System",
+	SlugSG1000:            "SG-1000",
+	SlugGenesis:           "Genesis",
+	SlugSegaCD:            "Sega CD",
+	SlugSega32:            "Sega 32X",
+	SlugSaturn:            "Saturn",
+	SlugDC:                "Dreamcast",
+	SlugGameGear:          "Game Gear",
+	SlugSegaPico:          "Sega Pico",
+	SlugSharpX68000:       "Sharp X68000",
+	SlugX1:                "Sharp X1",
+	SlugZXS:               "ZX Spectrum",
+	SlugZX80:              "ZX80",
+	SlugZX81:              "ZX81",
+	SlugPSX:               "PlayStation",
+	SlugPS2:               "PlayStation 2",
+	SlugPS3:               "PlayStation 3",
+	SlugPS4:               "PlayStation 4",
+	SlugPS5:               "PlayStation 5",
+	SlugPSP:               "PlayStation Portable",
+	SlugPSVita:            "PlayStation Vita",
+	SlugPSVR:              "PlayStation VR",
+	SlugPSVR2:             "PlayStation VR2",
+	SlugPocketstation:     "PocketStation",
+	SlugVectrex:           "Vectrex",
+	SlugMSX:               "MSX",
+	SlugMSX2:              "MSX2",
+	SlugMSX2Plus:          "MSX2+",
+	SlugStadia:            "Google Stadia",
+	SlugOuya:              "Ouya",
+	SlugPlaydate:          "Playdate",
+	SlugNGage:             "N-Gage",
+	SlugGamate:            "Gamate",
+	SlugGameDotCom:        "Game.com",
+	SlugGizmondo:          "Gizmondo",
+	SlugPokemonMini:       "Pokémon Mini",
+	SlugSupervision:       "Supervision",
+	SlugEvercade:          "Evercade",
+	SlugSymbian:           "Symbian",
+	SlugPalmOS:            "Palm OS",
+	SlugJ2ME:              "Java ME",
+	SlugTIC80:             "TIC-80",
+}
+
+// Provider names accepted by GetPlatformID and SlugFromProviderID.
+const (
+	ProviderIGDB              = "igdb"
+	ProviderMobyGames         = "mobygames"
+	ProviderScreenScraper     = "screenscraper"
+	ProviderRetroAchievements = "retroachievements"
+	ProviderLaunchBox         = "launchbox"
+	ProviderTheGamesDB        = "thegamesdb"
+	ProviderSteamGridDB       = "steamgriddb"
+	ProviderFlashpoint        = "flashpoint"
+	ProviderHLTB              = "hltb"
+	ProviderGamelist          = "gamelist"
+)
+
+// providerPlatformMaps maps provider names to their platform ID mappings.
+// SteamGridDB doesn't filter by platform, so it has no IDs.
+var providerPlatformMaps = map[string]map[Slug]int{
+	ProviderIGDB:              igdbPlatformMap,
+	ProviderMobyGames:         mobygamesPlatformMap,
+	ProviderScreenScraper:     screenscraperPlatformMap,
+	ProviderRetroAchievements: retroachievementsPlatformMap,
+	ProviderLaunchBox:         launchboxPlatformMap,
+	ProviderTheGamesDB:        thegamesdbPlatformMap,
+	ProviderSteamGridDB:       {},
+	ProviderFlashpoint:        flashpointPlatformMap,
+	// Gamelists have no platform IDs of their own, so they use IGDB's.
+	ProviderGamelist: igdbPlatformMap,
+}
+
+// providerPlatformNames maps provider names to the platform names they use,
+// for providers that identify platforms by name rather than ID.
+var providerPlatformNames = map[string]map[Slug]string{
+	ProviderLaunchBox: launchboxPlatformNames,
+	ProviderHLTB:      hltbPlatformNames,
+}
+
+// preferredSlugs are returned by reverse lookups when a provider uses the same
+// ID for several platforms (e.g., IGDB lists CPS-1/2/3 as Arcade).
+var preferredSlugs = map[Slug]bool{
+	SlugArcade:    true,
+	SlugNES:       true,
+	SlugSNES:      true,
+	SlugC64:       true,
+	SlugWin:       true,
+	SlugMSX:       true,
+	SlugNeoGeoAES: true,
+	SlugZXS:       true,
+	SlugSegaCD:    true,
+	SlugNDS:       true,
+	SlugPSVR:      true,
+	SlugAmigaCD:   true,
+	SlugAtari8bit: true,
+	SlugGenesis:   true,
+	SlugN64:       true,
+}
+
+// Providers returns the names of all providers with platform mappings.
+func Providers() []string {
+	names := make([]string, 0, len(providerPlatformMaps)+len(providerPlatformNames))
+	for name := range providerPlatformMaps {
+		names = append(names, name)
+	}
+	for name := range providerPlatformNames {
+		if _, ok := providerPlatformMaps[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// RegisterPlatformIDs adds or replaces the platform ID mappings for a provider,
+// so new providers can use GetPlatformID and SlugFromProviderID.
+// It is not safe to call concurrently with lookups.
+func RegisterPlatformIDs(provider string, ids map[Slug]int) {
+	m := make(map[Slug]int, len(ids))
+	for slug, id := range ids {
+		m[slug] = id
+	}
+	providerPlatformMaps[provider] = m
+}
+
+// GetPlatformID returns a provider's platform ID for a universal platform slug,
+// falling back to the slug's parent platform. Returns nil if the provider is
+// unknown or doesn't list the platform.
+func GetPlatformID(provider string, slug Slug) *int {
+	m, ok := providerPlatformMaps[provider]
+	if !ok {
+		return nil
+	}
+	return lookupPlatformID(m, slug)
+}
+
+// SlugFromProviderID returns the universal platform slug for a provider's
+// platform ID, or an empty slug if the ID is unknown.
+func SlugFromProviderID(provider string, id int) Slug {
+	var match Slug
+	for slug, slugID := range providerPlatformMaps[provider] {
+		if slugID == id && betterSlug(slug, match) {
+			match = slug
+		}
+	}
+	return match
+}
+
+// GetPlatformName returns the platform name a provider uses for a slug, for
+// providers that identify platforms by name (LaunchBox, HowLongToBeat).
+// Falls back to the slug's parent platform; returns "" if unknown.
+func GetPlatformName(provider string, slug Slug) string {
+	names := providerPlatformNames[provider]
+	if name, ok := names[slug]; ok {
+		return name
+	}
+	if parent := slug.Parent(); parent != "" {
+		return names[parent]
+	}
+	return ""
+}
+
+// SlugFromProviderName returns the universal platform slug for a provider's
+// platform name (case-insensitive), or an empty slug if the name is unknown.
+func SlugFromProviderName(provider, name string) Slug {
+	var match Slug
+	for slug, slugName := range providerPlatformNames[provider] {
+		if strings.EqualFold(slugName, name) && betterSlug(slug, match) {
+			match = slug
+		}
+	}
+	return match
+}
+
+// betterSlug reports whether candidate should replace current in a reverse
+// lookup. Preferred slugs win, then the alphabetically first slug, so lookups
+// are deterministic.
+func betterSlug(candidate, current Slug) bool {
+	if current == "" {
+		return true
+	}
+	if preferredSlugs[candidate] != preferredSlugs[current] {
+		return preferredSlugs[candidate]
+	}
+	return candidate < current
 }
 
 // GetIGDBPlatformID returns the IGDB platform ID for a universal platform slug.
 func GetIGDBPlatformID(slug Slug) *int {
-	if id, ok := igdbPlatformMap[slug]; ok {
-		return &id
-	}
-	return nil
+	return lookupPlatformID(igdbPlatformMap, slug)
 }
 
 // GetMobyGamesPlatformID returns the MobyGames platform ID for a universal platform slug.
 func GetMobyGamesPlatformID(slug Slug) *int {
-	if id, ok := mobygamesPlatformMap[slug]; ok {
-		return &id
-	}
-	return nil
+	return lookupPlatformID(mobygamesPlatformMap, slug)
 }
 
 // GetScreenScraperPlatformID returns the ScreenScraper platform ID for a universal platform slug.
 func GetScreenScraperPlatformID(slug Slug) *int {
-	if id, ok := screenscraperPlatformMap[slug]; ok {
-		return &id
-	}
-	return nil
+	return lookupPlatformID(screenscraperPlatformMap, slug)
 }
 
 // GetRetroAchievementsPlatformID returns the RetroAchievements platform ID for a universal platform slug.
 func GetRetroAchievementsPlatformID(slug Slug) *int {
-	if id, ok := retroachievementsPlatformMap[slug]; ok {
+	return lookupPlatformID(retroachievementsPlatformMap, slug)
+}
+
+// lookupPlatformID returns the ID for a slug, falling back to its parent platform.
+func lookupPlatformID(m map[Slug]int, slug Slug) *int {
+	if id, ok := m[slug]; ok {
 		return &id
 	}
+	if parent := slug.Parent(); parent != "" {
+		if id, ok := m[parent]; ok {
+			return &id
+		}
+	}
 	return nil
 }
 
+// IsLocalOnly returns true if no provider has an ID or name for the platform,
+// even through its parent. Providers should skip platform filtering for these
+// platforms rather than fail.
+func IsLocalOnly(slug Slug) bool {
+	for provider := range providerPlatformMaps {
+		if GetPlatformID(provider, slug) != nil {
+			return false
+		}
+	}
+	for provider := range providerPlatformNames {
+		if GetPlatformName(provider, slug) != "" {
+			return false
+		}
+	}
+	return true
+}
+
 // GetPlatformInfo returns comprehensive platform information for a universal platform slug.
 func GetPlatformInfo(slug Slug) *PlatformInfo {
 	if !slug.IsValid() {
@@ -396,45 +965,39 @@ func GetPlatformInfo(slug Slug) *PlatformInfo {
 		MobyGamesID:         GetMobyGamesPlatformID(slug),
 		ScreenScraperID:     GetScreenScraperPlatformID(slug),
 		RetroAchievementsID: GetRetroAchievementsPlatformID(slug),
+		ProviderIDs:         providerIDs(slug),
+		Parent:              slug.Parent(),
+		LocalOnly:           IsLocalOnly(slug),
 	}
 }
 
-// SlugFromIGDBID returns the universal platform slug from an IGDB platform ID.
-func SlugFromIGDBID(igdbID int) Slug {
-	for slug, id := range igdbPlatformMap {
-		if id == igdbID {
-			return slug
+// providerIDs returns the platform ID of every provider that lists the slug.
+func providerIDs(slug Slug) map[string]int {
+	ids := make(map[string]int)
+	for provider := range providerPlatformMaps {
+		if id := GetPlatformID(provider, slug); id != nil {
+			ids[provider] = *id
 		}
 	}
-	return ""
+	return ids
+}
+
+// SlugFromIGDBID returns the universal platform slug from an IGDB platform ID.
+func SlugFromIGDBID(igdbID int) Slug {
+	return SlugFromProviderID(ProviderIGDB, igdbID)
 }
 
 // SlugFromMobyGamesID returns the universal platform slug from a MobyGames platform ID.
 func SlugFromMobyGamesID(mobyID int) Slug {
-	for slug, id := range mobygamesPlatformMap {
-		if id == mobyID {
-			return slug
-		}
-	}
-	return ""
+	return SlugFromProviderID(ProviderMobyGames, mobyID)
 }
 
 // SlugFromScreenScraperID returns the universal platform slug from a ScreenScraper platform ID.
 func SlugFromScreenScraperID(ssID int) Slug {
-	for slug, id := range screenscraperPlatformMap {
-		if id == ssID {
-			return slug
-		}
-	}
-	return ""
+	return SlugFromProviderID(ProviderScreenScraper, ssID)
 }
 
 // SlugFromRetroAchievementsID returns the universal platform slug from a RetroAchievements platform ID.
 func SlugFromRetroAchievementsID(raID int) Slug {
-	for slug, id := range retroachievementsPlatformMap {
-		if id == raID {
-			return slug
-		}
-	}
-	return ""
+	return SlugFromProviderID(ProviderRetroAchievements, raID)
 }
@@ -279,3 +279,146 @@ func TestGetPlatformInfo(t *testing.T) {
 		})
 	}
 }
+
+func TestExtendedPlatforms(t *testing.T) {
+	intPtr := func(i int) *int { return &i }
+
+	tests := []struct {
+		slug          Slug
+		igdb          *int
+		mobygames     *int
+		screenscraper *int
+		parent        Slug
+	}{
+		{SlugPico8, nil, intPtr(302), intPtr(234), ""},
+		{SlugTIC80, nil, intPtr(301), intPtr(222), ""},
+		{SlugOpenBOR, nil, intPtr(256), intPtr(214), ""},
+		{SlugScummVM, nil, intPtr(257), intPtr(123), ""},
+		{SlugJ2ME, nil, intPtr(64), nil, ""},
+		{SlugNaomi, intPtr(52), intPtr(143), intPtr(56), SlugArcade},
+		{SlugModel2, intPtr(52), intPtr(143), intPtr(54), SlugArcade},
+		{SlugMSU1, intPtr(19), intPtr(15), intPtr(4), SlugSNES},
+		{SlugWin9x, intPtr(6), intPtr(3), nil, SlugWin},
+		{SlugPC9801, intPtr(149), intPtr(95), intPtr(208), SlugPC9800},
+	}
+
+	equal := func(a, b *int) bool {
+		return (a == nil && b == nil) || (a != nil && b != nil && *a == *b)
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.slug), func(t *testing.T) {
+			if !tt.slug.IsValid() {
+				t.Fatalf("%q is not a valid slug", tt.slug)
+			}
+			info := GetPlatformInfo(tt.slug)
+			if !equal(info.IGDBID, tt.igdb) {
+				t.Errorf("IGDBID = %v, want %v", info.IGDBID, tt.igdb)
+			}
+			if !equal(info.MobyGamesID, tt.mobygames) {
+				t.Errorf("MobyGamesID = %v, want %v", info.MobyGamesID, tt.mobygames)
+			}
+			if !equal(info.ScreenScraperID, tt.screenscraper) {
+				t.Errorf("ScreenScraperID = %v, want %v", info.ScreenScraperID, tt.screenscraper)
+			}
+			if info.Parent != tt.parent {
+				t.Errorf("Parent = %q, want %q", info.Parent, tt.parent)
+			}
+			if info.LocalOnly {
+				t.Errorf("LocalOnly = true, want false")
+			}
+		})
+	}
+}
+
+func TestIsLocalOnly(t *testing.T) {
+	if !IsLocalOnly(Slug("homebrew-console")) {
+		t.Error("IsLocalOnly(homebrew-console) = false, want true")
+	}
+	if IsLocalOnly(SlugSNES) {
+		t.Error("IsLocalOnly(snes) = true, want false")
+	}
+}
+
+func TestGetPlatformID(t *testing.T) {
+	tests := []struct {
+		provider string
+		slug     Slug
+		expected int
+	}{
+		{ProviderIGDB, SlugSNES, 19},
+		{ProviderMobyGames, SlugSNES, 15},
+		{ProviderScreenScraper, SlugSNES, 4},
+		{ProviderRetroAchievements, SlugSNES, 3},
+		{ProviderLaunchBox, SlugSNES, 60},
+		{ProviderTheGamesDB, SlugSNES, 6},
+		{ProviderFlashpoint, SlugBrowser, 1},
+		{ProviderLaunchBox, SlugMSU1, 60},
+	}
+
+	for _, tt := range tests {
+		result := GetPlatformID(tt.provider, tt.slug)
+		if result == nil || *result != tt.expected {
+			t.Errorf("GetPlatformID(%q, %q) = %v, want %d", tt.provider, tt.slug, result, tt.expected)
+		}
+	}
+
+	if result := GetPlatformID(ProviderSteamGridDB, SlugSNES); result != nil {
+		t.Errorf("GetPlatformID(steamgriddb, snes) = %d, want nil", *result)
+	}
+	if result := GetPlatformID("unknown", SlugSNES); result != nil {
+		t.Errorf("GetPlatformID(unknown, snes) = %d, want nil", *result)
+	}
+}
+
+func TestSlugFromProviderID(t *testing.T) {
+	tests := []struct {
+		provider string
+		id       int
+		expected Slug
+	}{
+		{ProviderIGDB, 19, SlugSNES},
+		{ProviderIGDB, 52, SlugArcade},
+		{ProviderTheGamesDB, 6, SlugSNES},
+		{ProviderTheGamesDB, 7, SlugNES},
+		{ProviderTheGamesDB, 1, SlugWin},
+		{ProviderLaunchBox, 49, SlugGenesis},
+		{ProviderLaunchBox, 99999, ""},
+	}
+
+	for _, tt := range tests {
+		// Repeat to catch nondeterministic map iteration
+		for i := 0; i < 10; i++ {
+			if result := SlugFromProviderID(tt.provider, tt.id); result != tt.expected {
+				t.Fatalf("SlugFromProviderID(%q, %d) = %q, want %q", tt.provider, tt.id, result, tt.expected)
+			}
+		}
+	}
+}
+
+func TestPlatformNames(t *testing.T) {
+	if name := GetPlatformName(ProviderLaunchBox, SlugGenesis); name != "Sega Genesis" {
+		t.Errorf("GetPlatformName(launchbox, genesis) = %q", name)
+	}
+	if slug := SlugFromProviderName(ProviderLaunchBox, "sony playstation"); slug != SlugPSX {
+		t.Errorf("SlugFromProviderName(launchbox, sony playstation) = %q", slug)
+	}
+	if name := GetPlatformName(ProviderHLTB, SlugSNES); name == "" {
+		t.Error("GetPlatformName(hltb, snes) is empty")
+	}
+}
+
+func TestRegisterPlatformIDs(t *testing.T) {
+	RegisterPlatformIDs("example", map[Slug]int{SlugGBA: 7})
+	defer delete(providerPlatformMaps, "example")
+
+	if result := GetPlatformID("example", SlugGBA); result == nil || *result != 7 {
+		t.Errorf("GetPlatformID(example, gba) = %v, want 7", result)
+	}
+	if slug := SlugFromProviderID("example", 7); slug != SlugGBA {
+		t.Errorf("SlugFromProviderID(example, 7) = %q, want gba", slug)
+	}
+	if info := GetPlatformInfo(SlugGBA); info.ProviderIDs["example"] != 7 {
+		t.Errorf("GetPlatformInfo(gba).ProviderIDs = %v", info.ProviderIDs)
+	}
+}
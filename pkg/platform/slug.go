@@ -25,7 +25,9 @@ const (
 	SlugAtariJaguarCD         Slug = "atari-jaguar-cd"
 	SlugAtariST               Slug = "atari-st"
 	SlugAtariXEGS             Slug = "atari-xegs"
+	SlugAtomiswave            Slug = "atomiswave"
 	SlugBBCMicro              Slug = "bbcmicro"
+	SlugBrowser               Slug = "browser"
 	SlugC128                  Slug = "c128"
 	SlugC16                   Slug = "c16"
 	SlugC64                   Slug = "c64"
@@ -52,16 +54,22 @@ const (
 	SlugGizmondo              Slug = "gizmondo"
 	SlugIOS                   Slug = "ios"
 	SlugIntellvision          Slug = "intellivision"
+	SlugJ2ME                  Slug = "j2me"
 	SlugJaguar                Slug = "jaguar"
 	SlugLinux                 Slug = "linux"
 	SlugLynx                  Slug = "lynx"
 	SlugMac                   Slug = "mac"
+	SlugModel2                Slug = "model2"
+	SlugModel3                Slug = "model3"
+	SlugMSU1                  Slug = "msu1"
 	SlugMSX                   Slug = "msx"
 	SlugMSX2                  Slug = "msx2"
 	SlugMSX2Plus              Slug = "msx2plus"
 	SlugN3DS                  Slug = "3ds"
 	SlugN64                   Slug = "n64"
 	SlugN64DD                 Slug = "64dd"
+	SlugNaomi                 Slug = "naomi"
+	SlugNaomi2                Slug = "naomi2"
 	SlugNDS                   Slug = "nds"
 	SlugNeoGeoAES             Slug = "neogeoaes"
 	SlugNeoGeoCD              Slug = "neo-geo-cd"
@@ -74,10 +82,15 @@ const (
 	SlugNGC                   Slug = "ngc"
 	SlugNintendoDSi           Slug = "nintendo-dsi"
 	SlugOdyssey2              Slug = "odyssey-2"
+	SlugOpenBOR               Slug = "openbor"
 	SlugOuya                  Slug = "ouya"
+	SlugPalmOS                Slug = "palm-os"
 	SlugPC8800                Slug = "pc-8800-series"
 	SlugPC9800                Slug = "pc-9800-series"
+	SlugPC9801                Slug = "pc-9801"
+	SlugPC9821                Slug = "pc-9821"
 	SlugPCFX                  Slug = "pc-fx"
+	SlugPico8                 Slug = "pico-8"
 	SlugPlaydate              Slug = "playdate"
 	SlugPocketstation         Slug = "pocketstation"
 	SlugPokemonMini           Slug = "pokemon-mini"
@@ -92,6 +105,7 @@ const (
 	SlugPSX                   Slug = "psx"
 	SlugSatellaview           Slug = "satellaview"
 	SlugSaturn                Slug = "saturn"
+	SlugScummVM               Slug = "scummvm"
 	SlugSega32                Slug = "sega32"
 	SlugSegaCD                Slug = "segacd"
 	SlugSegaCD32              Slug = "segacd32"
@@ -106,7 +120,9 @@ const (
 	SlugSuperGrafx            Slug = "supergrafx"
 	SlugSupervision           Slug = "supervision"
 	SlugSwitch                Slug = "switch"
+	SlugSymbian               Slug = "symbian"
 	SlugTG16                  Slug = "tg16"
+	SlugTIC80                 Slug = "tic-80"
 	SlugTurboGrafxCD          Slug = "turbografx-cd"
 	SlugVectrex               Slug = "vectrex"
 	SlugVIC20                 Slug = "vic-20"
@@ -115,6 +131,7 @@ const (
 	SlugWiiU                  Slug = "wiiu"
 	SlugWin                   Slug = "win"
 	SlugWin3x                 Slug = "win3x"
+	SlugWin9x                 Slug = "windows9x"
 	SlugWonderSwan            Slug = "wonderswan"
 	SlugWonderSwanColor       Slug = "wonderswan-color"
 	SlugX1                    Slug = "x1"
@@ -145,6 +162,27 @@ func (s Slug) Name() string {
 	return string(s)
 }
 
+// Parent returns the platform that providers catalogue this platform's games
+// under when they don't list it separately (e.g., MSU-1 games are SNES games),
+// or an empty slug if it has none.
+func (s Slug) Parent() Slug {
+	return parentSlugs[s]
+}
+
+// parentSlugs maps platforms that are variants, enhancements or subsystems of
+// another platform to that platform.
+var parentSlugs = map[Slug]Slug{
+	SlugAtomiswave: SlugArcade,
+	SlugModel2:     SlugArcade,
+	SlugModel3:     SlugArcade,
+	SlugMSU1:       SlugSNES,
+	SlugNaomi:      SlugArcade,
+	SlugNaomi2:     SlugArcade,
+	SlugPC9801:     SlugPC9800,
+	SlugPC9821:     SlugPC9800,
+	SlugWin9x:      SlugWin,
+}
+
 // slugNames maps slugs to human-readable names.
 var slugNames = map[Slug]string{
 	Slug3DO:               "3DO Interactive Multiplayer",
@@ -164,7 +202,9 @@ var slugNames = map[Slug]string{
 	SlugAtariJaguarCD:     "Atari Jaguar CD",
 	SlugAtariST:           "Atari ST",
 	SlugAtariXEGS:         "Atari XEGS",
+	SlugAtomiswave:        "Sammy Atomiswave",
 	SlugBBCMicro:          "BBC Micro",
+	SlugBrowser:           "Web Browser",
 	SlugC128:              "Commodore 128",
 	SlugC16:               "Commodore 16",
 	SlugC64:               "Commodore 64",
@@ -191,16 +231,22 @@ var slugNames = map[Slug]string{
 	SlugGizmondo:          "Gizmondo",
 	SlugIOS:               "iOS",
 	SlugIntellvision:      "Intellivision",
+	SlugJ2ME:              "Java ME",
 	SlugJaguar:            "Atari Jaguar",
 	SlugLinux:             "Linux",
 	SlugLynx:              "Atari Lynx",
 	SlugMac:               "Mac",
+	SlugModel2:            "Sega Model 2",
+	SlugModel3:            "Sega Model 3",
+	SlugMSU1:              "MSU-1",
 	SlugMSX:               "MSX",
 	SlugMSX2:              "MSX2",
 	SlugMSX2Plus:          "MSX2+",
 	SlugN3DS:              "Nintendo 3DS",
 	SlugN64:               "Nintendo 64",
 	SlugN64DD:             "Nintendo 64DD",
+	SlugNaomi:             "Sega NAOMI",
+	SlugNaomi2:            "Sega NAOMI 2",
 	SlugNDS:               "Nintendo DS",
 	SlugNeoGeoAES:         "Neo Geo AES",
 	SlugNeoGeoCD:          "Neo Geo CD",
@@ -213,10 +259,15 @@ var slugNames = map[Slug]string{
 	SlugNGC:               "Nintendo GameCube",
 	SlugNintendoDSi:       "Nintendo DSi",
 	SlugOdyssey2:          "Magnavox Odyssey 2",
+	SlugOpenBOR:           "OpenBOR",
 	SlugOuya:              "Ouya",
+	SlugPalmOS:            "Palm OS",
 	SlugPC8800:            "PC-8800 Series",
 	SlugPC9800:            "PC-9800 Series",
+	SlugPC9801:            "NEC PC-9801",
+	SlugPC9821:            "NEC PC-9821",
 	SlugPCFX:              "PC-FX",
+	SlugPico8:             "PICO-8",
 	SlugPlaydate:          "Playdate",
 	SlugPocketstation:     "PocketStation",
 	SlugPokemonMini:       "Pokemon Mini",
@@ -231,6 +282,7 @@ var slugNames = map[Slug]string{
 	SlugPSX:               "PlayStation",
 	SlugSatellaview:       "Satellaview",
 	SlugSaturn:            "Sega Saturn",
+	SlugScummVM:           "ScummVM",
 	SlugSega32:            "Sega 32X",
 	SlugSegaCD:            "Sega CD",
 	SlugSegaCD32:          "Sega CD 32X",
@@ -245,7 +297,9 @@ var slugNames = map[Slug]string{
 	SlugSuperGrafx:        "SuperGrafx",
 	SlugSupervision:       "Supervision",
 	SlugSwitch:            "Nintendo Switch",
+	SlugSymbian:           "Symbian",
 	SlugTG16:              "TurboGrafx-16",
+	SlugTIC80:             "TIC-80",
 	SlugTurboGrafxCD:      "TurboGrafx-CD",
 	SlugVectrex:           "Vectrex",
 	SlugVIC20:             "VIC-20",
@@ -254,6 +308,7 @@ var slugNames = map[Slug]string{
 	SlugWiiU:              "Wii U",
 	SlugWin:               "Windows",
 	SlugWin3x:             "Windows 3.x",
+	SlugWin9x:             "Windows 9x",
 	SlugWonderSwan:        "WonderSwan",
 	SlugWonderSwanColor:   "WonderSwan Color",
 	SlugX1:                "Sharp X1",
@@ -0,0 +1,132 @@
+// Package progress defines a progress reporting interface for long-running
+// operations such as scanning, batch identification and artwork downloads.
+//
+// Implementations must be safe for concurrent use, since batch operations may
+// report progress from several goroutines at once.
+package progress
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// Progress receives progress updates from a long-running operation.
+type Progress interface {
+	// Start begins a new operation with the given total number of items.
+	// A total of 0 means the total is unknown.
+	Start(total int)
+
+	// Increment marks n more items as completed.
+	Increment(n int)
+
+	// SetStatus sets a short description of the current item or phase.
+	SetStatus(status string)
+
+	// Done marks the operation as finished.
+	Done()
+}
+
+// Noop is a Progress that discards all updates.
+type Noop struct{}
+
+// Start does nothing.
+func (Noop) Start(int) {}
+
+// Increment does nothing.
+func (Noop) Increment(int) {}
+
+// SetStatus does nothing.
+func (Noop) SetStatus(string) {}
+
+// Done does nothing.
+func (Noop) Done() {}
+
+// OrNoop returns p, or a Noop progress if p is nil.
+func OrNoop(p Progress) Progress {
+	if p == nil {
+		return Noop{}
+	}
+	return p
+}
+
+// Snapshot is a point-in-time view of a Tracker.
+type Snapshot struct {
+	// Total is the total number of items, or 0 if unknown
+	Total int `json:"total"`
+	// Completed is the number of completed items
+	Completed int `json:"completed"`
+	// Status is the current status message
+	Status string `json:"status,omitempty"`
+	// Done indicates the operation has finished
+	Done bool `json:"done"`
+}
+
+// Tracker is a Progress that records updates so they can be polled,
+// e.g. by a GUI refreshing on a timer.
+type Tracker struct {
+	total     atomic.Int64
+	completed atomic.Int64
+	done      atomic.Bool
+	mu        sync.RWMutex
+	status    string
+}
+
+// NewTracker creates a new Tracker.
+func NewTracker() *Tracker {
+	return &Tracker{}
+}
+
+// Start resets the tracker for a new operation.
+func (t *Tracker) Start(total int) {
+	t.total.Store(int64(total))
+	t.completed.Store(0)
+	t.done.Store(false)
+	t.SetStatus("")
+}
+
+// Increment marks n more items as completed.
+func (t *Tracker) Increment(n int) {
+	t.completed.Add(int64(n))
+}
+
+// SetStatus sets the current status message.
+func (t *Tracker) SetStatus(status string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.status = status
+}
+
+// Done marks the operation as finished.
+func (t *Tracker) Done() {
+	t.done.Store(true)
+}
+
+// Snapshot returns the current progress.
+func (t *Tracker) Snapshot() Snapshot {
+	t.mu.RLock()
+	status := t.status
+	t.mu.RUnlock()
+
+	return Snapshot{
+		Total:     int(t.total.Load()),
+		Completed: int(t.completed.Load()),
+		Status:    status,
+		Done:      t.done.Load(),
+	}
+}
+
+// Checkpoint marks a point from which an interrupted operation can resume.
+type Checkpoint struct {
+	// Operation names the operation (e.g., "scan", "launchbox-index")
+	Operation string `json:"operation"`
+	// Completed is the number of items completed so far
+	Completed int `json:"completed"`
+	// Total is the total number of items, or 0 if unknown
+	Total int `json:"total"`
+	// Cursor identifies the last completed item, such as a path or platform ID
+	Cursor string `json:"cursor,omitempty"`
+}
+
+// CheckpointFunc receives checkpoints from a long-running operation.
+// It is called from the operation's goroutine and should return quickly.
+type CheckpointFunc func(Checkpoint)
@@ -0,0 +1,40 @@
+package progress
+
+import (
+	"sync"
+	"testing"
+)
+
+func TestTrackerConcurrent(t *testing.T) {
+	tracker := NewTracker()
+	tracker.Start(100)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 10; j++ {
+				tracker.SetStatus("working")
+				tracker.Increment(1)
+			}
+		}()
+	}
+	wg.Wait()
+	tracker.Done()
+
+	snapshot := tracker.Snapshot()
+	if snapshot.Total != 100 || snapshot.Completed != 100 || snapshot.Status != "working" || !snapshot.Done {
+		t.Errorf("Unexpected snapshot: %+v", snapshot)
+	}
+}
+
+func TestOrNoop(t *testing.T) {
+	if _, ok := OrNoop(nil).(Noop); !ok {
+		t.Error("Expected OrNoop(nil) to return Noop")
+	}
+	tracker := NewTracker()
+	if OrNoop(tracker) != tracker {
+		t.Error("Expected OrNoop to return the given progress")
+	}
+}
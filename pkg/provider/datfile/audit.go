@@ -0,0 +1,95 @@
+package datfile
+
+import "strings"
+
+// Audit compares a collection with an official datfile.
+type Audit struct {
+	// Have is the official games with every ROM in the collection
+	Have []string `json:"have"`
+	// Partial is the official games with only some of their ROMs in the
+	// collection, like multi-track discs missing a track
+	Partial []string `json:"partial,omitempty"`
+	// Missing is the official games with none of their ROMs in the collection
+	Missing []string `json:"missing"`
+	// Unknown is the collection's ROMs that aren't in the official datfile,
+	// like hacks, translations and bad dumps
+	Unknown []string `json:"unknown,omitempty"`
+}
+
+// romHashes indexes ROMs by each of their hashes.
+type romHashes struct {
+	sha1, md5, crc map[string]bool
+}
+
+// newROMHashes indexes the ROMs of a datfile.
+func newROMHashes(dat *Datfile) romHashes {
+	h := romHashes{sha1: make(map[string]bool), md5: make(map[string]bool), crc: make(map[string]bool)}
+	for _, game := range dat.Games {
+		for _, rom := range game.ROMs {
+			add(h.sha1, rom.SHA1)
+			add(h.md5, rom.MD5)
+			add(h.crc, rom.CRC)
+		}
+	}
+	return h
+}
+
+// add adds a hash to a set, if there is one.
+func add(set map[string]bool, hash string) {
+	if hash != "" {
+		set[strings.ToLower(hash)] = true
+	}
+}
+
+// has reports whether a ROM with the same content is indexed, going by its
+// strongest hash the other ROMs have too.
+func (h romHashes) has(rom ROM) bool {
+	switch {
+	case rom.SHA1 != "" && len(h.sha1) > 0:
+		return h.sha1[strings.ToLower(rom.SHA1)]
+	case rom.MD5 != "" && len(h.md5) > 0:
+		return h.md5[strings.ToLower(rom.MD5)]
+	default:
+		return h.crc[strings.ToLower(rom.CRC)]
+	}
+}
+
+// Compare audits a collection, like one built with FromGroups, against an
+// official datfile such as a No-Intro set. ROMs match by hash, so renamed
+// files are found; ROMs the official datfile marks "nodump" aren't needed.
+func Compare(official, collection *Datfile) *Audit {
+	audit := &Audit{}
+
+	have := newROMHashes(collection)
+	for _, game := range official.Games {
+		var needed, found int
+		for _, rom := range game.ROMs {
+			if rom.Status == "nodump" {
+				continue
+			}
+			needed++
+			if have.has(rom) {
+				found++
+			}
+		}
+		switch {
+		case needed == 0:
+		case found == needed:
+			audit.Have = append(audit.Have, game.Name)
+		case found > 0:
+			audit.Partial = append(audit.Partial, game.Name)
+		default:
+			audit.Missing = append(audit.Missing, game.Name)
+		}
+	}
+
+	known := newROMHashes(official)
+	for _, game := range collection.Games {
+		for _, rom := range game.ROMs {
+			if !known.has(rom) {
+				audit.Unknown = append(audit.Unknown, rom.Name)
+			}
+		}
+	}
+	return audit
+}
@@ -0,0 +1,773 @@
+// Package datfile provides hash-based identification from local Logiqx/ClrMamePro XML datfiles.
+//
+// Datfiles published by No-Intro, Redump and TOSEC list every known-good dump
+// with its hashes, so a hash match both identifies the game and verifies the dump.
+package datfile
+
+import (
+	"cmp"
+	"context"
+	"encoding/xml"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"iter"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"unicode"
+
+	"github.com/josegonzalez/retro-metadata/pkg/filename"
+	"github.com/josegonzalez/retro-metadata/pkg/internal/index"
+	"github.com/josegonzalez/retro-metadata/pkg/matching"
+	"github.com/josegonzalez/retro-metadata/pkg/provider"
+	retrometadata "github.com/josegonzalez/retro-metadata/pkg/retrometadata"
+)
+
+// ErrProviderDisabled is returned when the provider is disabled.
+var ErrProviderDisabled = retrometadata.ErrProviderDisabled
+
+// Header is the header of a Logiqx XML datfile.
+type Header struct {
+	Name        string `xml:"name,omitempty"`
+	Description string `xml:"description,omitempty"`
+	Version     string `xml:"version,omitempty"`
+	Author      string `xml:"author,omitempty"`
+	Homepage    string `xml:"homepage,omitempty"`
+	URL         string `xml:"url,omitempty"`
+}
+
+// ROM is a single ROM entry within a datfile game.
+type ROM struct {
+	Name   string `xml:"name,attr"`
+	Size   int64  `xml:"size,attr,omitempty"`
+	CRC    string `xml:"crc,attr,omitempty"`
+	MD5    string `xml:"md5,attr,omitempty"`
+	SHA1   string `xml:"sha1,attr,omitempty"`
+	SHA256 string `xml:"sha256,attr,omitempty"`
+	Status string `xml:"status,attr,omitempty"`
+	Serial string `xml:"serial,attr,omitempty"`
+}
+
+// Game is a game (or MAME machine) entry within a datfile.
+type Game struct {
+	Name         string `xml:"name,attr"`
+	CloneOf      string `xml:"cloneof,attr,omitempty"`
+	Description  string `xml:"description,omitempty"`
+	Year         string `xml:"year,omitempty"`
+	Manufacturer string `xml:"manufacturer,omitempty"`
+	ROMs         []ROM  `xml:"rom,omitempty"`
+}
+
+// Datfile is a parsed Logiqx XML datfile.
+type Datfile struct {
+	// Path is the file the datfile was loaded from, if any
+	Path string
+	// Source is the signature database the datfile belongs to (No-Intro, Redump, TOSEC)
+	Source string
+	// Header is the datfile header
+	Header Header
+	// Games is the list of games in the datfile
+	Games []Game
+}
+
+// Parse parses a Logiqx XML datfile from a reader.
+// Both <game> and MAME-style <machine> entries are supported.
+func Parse(r io.Reader) (*Datfile, error) {
+	dat := &Datfile{}
+	decoder := xml.NewDecoder(r)
+
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("parsing datfile: %w", err)
+		}
+
+		se, ok := token.(xml.StartElement)
+		if !ok {
+			continue
+		}
+
+		switch se.Name.Local {
+		case "header":
+			if err := decoder.DecodeElement(&dat.Header, &se); err != nil {
+				return nil, fmt.Errorf("parsing datfile header: %w", err)
+			}
+		case "game", "machine":
+			var game Game
+			if err := decoder.DecodeElement(&game, &se); err != nil {
+				continue
+			}
+			dat.Games = append(dat.Games, game)
+		}
+	}
+
+	dat.Source = DetectSource(dat.Header)
+	return dat, nil
+}
+
+// ParseFile parses a Logiqx XML datfile from disk.
+func ParseFile(path string) (*Datfile, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	dat, err := Parse(file)
+	if err != nil {
+		return nil, err
+	}
+	dat.Path = path
+	return dat, nil
+}
+
+// DetectSource determines which signature database a datfile header belongs to.
+func DetectSource(header Header) string {
+	text := strings.ToLower(strings.Join([]string{header.Name, header.Description, header.Homepage, header.URL, header.Author}, " "))
+	switch {
+	case strings.Contains(text, "no-intro") || strings.Contains(text, "nointro"):
+		return retrometadata.SignatureSourceNoIntro
+	case strings.Contains(text, "redump"):
+		return retrometadata.SignatureSourceRedump
+	case strings.Contains(text, "tosec"):
+		return retrometadata.SignatureSourceTOSEC
+	case strings.Contains(text, "fbneo") || strings.Contains(text, "finalburn"):
+		return retrometadata.SignatureSourceFBNeo
+	case strings.Contains(text, "mame"):
+		return retrometadata.SignatureSourceMAMEArcade
+	default:
+		return header.Name
+	}
+}
+
+// entry is an indexed game together with the datfile it came from.
+type entry struct {
+	game *Game
+	dat  *Datfile
+}
+
+// Provider implements a datfile-backed metadata provider.
+//
+// Games are indexed with sorted slices rather than maps, keyed by raw hash
+// bytes, so full No-Intro and Redump sets stay small enough for handhelds.
+// Lookups are safe for concurrent use; the datfiles are loaded once, by the
+// first lookup that needs them.
+type Provider struct {
+	config   *retrometadata.ProviderConfig
+	datPaths []string
+	// indexDir is where parsed datfiles are saved between runs, if set
+	indexDir string
+
+	// loadMu serializes loading the configured datfiles
+	loadMu sync.Mutex
+	// mu guards the loaded datfiles and their indexes
+	mu       sync.RWMutex
+	dats     []*Datfile
+	entries  []entry
+	byID     index.Sorted[int]
+	byMD5    index.Sorted[string]
+	bySHA1   index.Sorted[string]
+	byCRC    index.Sorted[string]
+	byName   index.Sorted[string]
+	bySerial index.Sorted[string]
+	loaded   bool
+
+	nameIndexMu sync.Mutex
+	nameIndex   *matching.Index // fuzzy matching index of names, built on first use
+}
+
+// New creates a new datfile provider.
+// The "dat_paths" option lists datfiles or directories containing .dat/.xml files.
+// The "index_dir" option is where parsed datfiles are saved, by default a
+// "retro-metadata/datfile" directory in the user's cache directory.
+func New(config *retrometadata.ProviderConfig) *Provider {
+	var datPaths []string
+	if config.Options != nil {
+		switch paths := config.Options["dat_paths"].(type) {
+		case []string:
+			datPaths = paths
+		case []any:
+			for _, path := range paths {
+				if s, ok := path.(string); ok {
+					datPaths = append(datPaths, s)
+				}
+			}
+		case string:
+			datPaths = []string{paths}
+		}
+	}
+
+	p := &Provider{
+		config:   config,
+		datPaths: datPaths,
+		indexDir: defaultIndexDir(),
+	}
+	if dir, ok := config.Options["index_dir"].(string); ok {
+		p.indexDir = dir
+	}
+	p.reset()
+	return p
+}
+
+func (p *Provider) reset() {
+	p.mu.Lock()
+	p.clear()
+	p.mu.Unlock()
+	p.forgetNames()
+}
+
+// clear removes every datfile. Callers must hold p.mu for writing.
+func (p *Provider) clear() {
+	p.dats = nil
+	p.entries = nil
+	p.byID.Reset()
+	p.byMD5.Reset()
+	p.bySHA1.Reset()
+	p.byCRC.Reset()
+	p.byName.Reset()
+	p.bySerial.Reset()
+	p.loaded = false
+}
+
+// forgetNames drops the fuzzy matching index of the names, so it's rebuilt
+// with the games added or removed since. Callers mustn't hold p.mu, which
+// namesIndex takes while holding p.nameIndexMu.
+func (p *Provider) forgetNames() {
+	p.nameIndexMu.Lock()
+	defer p.nameIndexMu.Unlock()
+	p.nameIndex = nil
+}
+
+// Name returns the provider name.
+func (p *Provider) Name() string {
+	return "datfile"
+}
+
+// LoadDatfiles loads every configured datfile, expanding directories. The
+// datfiles loaded before, including those added with AddDatfile, are
+// replaced once every configured datfile is in.
+func (p *Provider) LoadDatfiles(ctx context.Context) error {
+	p.loadMu.Lock()
+	defer p.loadMu.Unlock()
+	return p.loadDatfiles(ctx, true)
+}
+
+// loadDatfiles implements LoadDatfiles, adding the configured datfiles to
+// those already loaded unless replace is set. The datfiles are read first
+// and indexed together, so lookups never see some of them without the
+// others. Callers must hold p.loadMu.
+func (p *Provider) loadDatfiles(ctx context.Context, replace bool) error {
+	if len(p.datPaths) == 0 {
+		p.mu.Lock()
+		defer p.mu.Unlock()
+		if len(p.dats) > 0 && !replace {
+			// Datfiles were added with AddDatfile instead
+			p.loaded = true
+			return nil
+		}
+		return fmt.Errorf("no datfile paths configured")
+	}
+
+	var dats []*Datfile
+	for _, path := range p.datPaths {
+		info, err := os.Stat(path)
+		if err != nil {
+			return err
+		}
+
+		if !info.IsDir() {
+			dat, err := p.readDatfile(path)
+			if err != nil {
+				return err
+			}
+			dats = append(dats, dat)
+			continue
+		}
+
+		entries, err := os.ReadDir(path)
+		if err != nil {
+			return err
+		}
+		for _, e := range entries {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			ext := strings.ToLower(filepath.Ext(e.Name()))
+			if e.IsDir() || (ext != ".dat" && ext != ".xml") {
+				continue
+			}
+			dat, err := p.readDatfile(filepath.Join(path, e.Name()))
+			if err != nil {
+				return err
+			}
+			dats = append(dats, dat)
+		}
+	}
+
+	p.mu.Lock()
+	if replace {
+		p.clear()
+	}
+	for _, dat := range dats {
+		p.add(dat)
+	}
+	p.build()
+	p.loaded = true
+	p.mu.Unlock()
+	p.forgetNames()
+	return nil
+}
+
+// indexVersion changes whenever the on-disk form of a parsed datfile does.
+const indexVersion = 1
+
+// defaultIndexDir returns the default directory parsed datfiles are saved
+// to, or "" if the user has no cache directory.
+func defaultIndexDir() string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "retro-metadata", "datfile")
+}
+
+// indexPath returns where the parsed datfile at path is saved, or "" if
+// parsed datfiles aren't saved. Files are named after the datfile and a hash
+// of its absolute path, so datfiles with the same name don't collide.
+func (p *Provider) indexPath(path string) string {
+	if p.indexDir == "" {
+		return ""
+	}
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return ""
+	}
+	h := fnv.New64a()
+	h.Write([]byte(abs))
+	return filepath.Join(p.indexDir, fmt.Sprintf("%s-%016x.index", filepath.Base(abs), h.Sum64()))
+}
+
+// LoadDatfile loads and indexes a single datfile. The parsed datfile is
+// saved to the "index_dir" option's directory, so later loads skip parsing
+// the XML until it changes.
+func (p *Provider) LoadDatfile(path string) error {
+	dat, err := p.readDatfile(path)
+	if err != nil {
+		return err
+	}
+	p.AddDatfile(dat)
+	return nil
+}
+
+// readDatfile reads a datfile, from its saved parsed form if it's up to
+// date, parsing and saving it otherwise.
+func (p *Provider) readDatfile(path string) (*Datfile, error) {
+	indexPath := p.indexPath(path)
+	var dat Datfile
+	if indexPath != "" && index.Load(indexPath, indexVersion, []string{path}, &dat) {
+		return &dat, nil
+	}
+
+	parsed, err := ParseFile(path)
+	if err != nil {
+		return nil, err
+	}
+	// Failures only cost a slower next load, so they're ignored
+	if indexPath != "" && os.MkdirAll(p.indexDir, 0o755) == nil {
+		_ = index.Save(indexPath, indexVersion, []string{path}, parsed)
+	}
+	return parsed, nil
+}
+
+// AddDatfile indexes an already parsed datfile. Providers without
+// configured datfiles look games up in those added.
+func (p *Provider) AddDatfile(dat *Datfile) {
+	p.mu.Lock()
+	p.add(dat)
+	p.build()
+	p.mu.Unlock()
+	p.forgetNames()
+}
+
+// add adds a datfile's games to the indexes, which must be built before
+// lookups. Callers must hold p.mu for writing.
+func (p *Provider) add(dat *Datfile) {
+	p.dats = append(p.dats, dat)
+
+	for i := range dat.Games {
+		game := &dat.Games[i]
+		n := len(p.entries)
+		p.entries = append(p.entries, entry{game: game, dat: dat})
+
+		p.byID.Add(gameID(game.Name), n)
+		p.byName.Add(strings.ToLower(game.Name), n)
+
+		for _, rom := range game.ROMs {
+			if rom.MD5 != "" {
+				p.byMD5.Add(index.HashKey(rom.MD5), n)
+			}
+			if rom.SHA1 != "" {
+				p.bySHA1.Add(index.HashKey(rom.SHA1), n)
+			}
+			if rom.CRC != "" {
+				p.byCRC.Add(index.HashKey(rom.CRC), n)
+			}
+			// Redump lists every serial of a disc, separated by commas
+			for _, serial := range strings.Split(rom.Serial, ",") {
+				if key := serialKey(serial); key != "" {
+					p.bySerial.Add(key, n)
+				}
+			}
+		}
+	}
+}
+
+// build builds the indexes. Callers must hold p.mu for writing.
+func (p *Provider) build() {
+	p.byID.Build()
+	p.byName.Build()
+	p.byMD5.Build()
+	p.bySHA1.Build()
+	p.byCRC.Build()
+	p.bySerial.Build()
+}
+
+// serialKey returns the index key of a serial: upper case, without
+// separators, so SLUS-12345 and SLUS_123.45 match.
+func serialKey(serial string) string {
+	return strings.Map(func(r rune) rune {
+		switch r {
+		case '-', '_', '.', ' ':
+			return -1
+		}
+		return unicode.ToUpper(r)
+	}, serial)
+}
+
+// latest returns the most recently added entry for key in an index, so later
+// datfiles take precedence over earlier ones.
+func latest[K cmp.Ordered](p *Provider, s *index.Sorted[K], key K) (entry, bool) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	values := s.Lookup(key)
+	if len(values) == 0 {
+		return entry{}, false
+	}
+	return p.entries[values[len(values)-1]], true
+}
+
+// names returns every distinct lowercased game name, in sorted order.
+func (p *Provider) names() []string {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	names := make([]string, 0, p.byName.Len())
+	for i := 0; i < p.byName.Len(); i++ {
+		if name := p.byName.Key(i); len(names) == 0 || names[len(names)-1] != name {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// namesIndex returns the fuzzy matching index of the names, building it the
+// first time it's needed.
+func (p *Provider) namesIndex() *matching.Index {
+	p.nameIndexMu.Lock()
+	defer p.nameIndexMu.Unlock()
+	if p.nameIndex == nil {
+		p.nameIndex = matching.NewIndex(p.names())
+	}
+	return p.nameIndex
+}
+
+// Datfiles returns the loaded datfiles.
+func (p *Provider) Datfiles() []*Datfile {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return slices.Clone(p.dats)
+}
+
+// ensureLoaded loads the configured datfiles unless datfiles were loaded
+// already. Concurrent callers wait for a single load.
+func (p *Provider) ensureLoaded(ctx context.Context) error {
+	if p.isLoaded() {
+		return nil
+	}
+	p.loadMu.Lock()
+	defer p.loadMu.Unlock()
+	if p.isLoaded() {
+		return nil
+	}
+	return p.loadDatfiles(ctx, false)
+}
+
+func (p *Provider) isLoaded() bool {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.loaded
+}
+
+// lookupHashes finds every datfile entry matching the hashes, strongest hash first.
+func (p *Provider) lookupHashes(hashes retrometadata.FileHashes) []entry {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	var found []entry
+	seen := make(map[*Datfile]bool)
+
+	add := func(values []int32) {
+		for _, v := range values {
+			if e := p.entries[v]; !seen[e.dat] {
+				seen[e.dat] = true
+				found = append(found, e)
+			}
+		}
+	}
+
+	if hashes.SHA1 != "" {
+		add(p.bySHA1.Lookup(index.HashKey(hashes.SHA1)))
+	}
+	if hashes.MD5 != "" {
+		add(p.byMD5.Lookup(index.HashKey(hashes.MD5)))
+	}
+	if hashes.CRC32 != "" {
+		add(p.byCRC.Lookup(index.HashKey(hashes.CRC32)))
+	}
+
+	return found
+}
+
+// Search searches for games by name.
+func (p *Provider) Search(ctx context.Context, query string, opts retrometadata.SearchOptions) ([]retrometadata.SearchResult, error) {
+	if opts.Limit == 0 {
+		opts.Limit = 20
+	}
+	return provider.CollectResults(p.SearchIter(ctx, query, opts))
+}
+
+// SearchIter yields the games whose names contain query.
+func (p *Provider) SearchIter(ctx context.Context, query string, opts retrometadata.SearchOptions) iter.Seq2[retrometadata.SearchResult, error] {
+	return func(yield func(retrometadata.SearchResult, error) bool) {
+		if !p.config.Enabled {
+			return
+		}
+		if err := p.ensureLoaded(ctx); err != nil {
+			yield(retrometadata.SearchResult{}, err)
+			return
+		}
+
+		queryLower := strings.ToLower(query)
+		yielded := 0
+		for _, name := range p.names() {
+			if !strings.Contains(name, queryLower) {
+				continue
+			}
+			// The indexes may have been replaced since names was read
+			e, ok := latest(p, &p.byName, name)
+			if !ok {
+				continue
+			}
+			result := retrometadata.SearchResult{
+				Name:       filename.CleanFilename(e.game.Name, true),
+				Provider:   p.Name(),
+				ProviderID: gameID(e.game.Name),
+			}
+			if !yield(result, nil) {
+				return
+			}
+			if yielded++; opts.Limit > 0 && yielded >= opts.Limit {
+				return
+			}
+		}
+	}
+}
+
+// GetByID gets a game by its datfile ID (a hash of the game name).
+func (p *Provider) GetByID(ctx context.Context, id int) (*retrometadata.GameResult, error) {
+	if !p.config.Enabled {
+		return nil, nil
+	}
+	if err := p.ensureLoaded(ctx); err != nil {
+		return nil, err
+	}
+
+	e, ok := latest(p, &p.byID, id)
+	if !ok {
+		return nil, nil
+	}
+	return p.buildGameResult(e, nil), nil
+}
+
+// Identify identifies a game from a ROM filename by matching datfile game names.
+func (p *Provider) Identify(ctx context.Context, romFilename string, opts retrometadata.IdentifyOptions) (*retrometadata.GameResult, error) {
+	if !p.config.Enabled {
+		return nil, nil
+	}
+	if err := p.ensureLoaded(ctx); err != nil {
+		return nil, err
+	}
+
+	if opts.Hashes != nil {
+		if result, err := p.IdentifyByHash(ctx, *opts.Hashes, opts); err != nil || result != nil {
+			return result, err
+		}
+	}
+
+	if serial := provider.Serial(p.Name(), romFilename, opts); serial != "" {
+		if result, err := p.IdentifyBySerial(ctx, serial, opts); err != nil || result != nil {
+			return result, err
+		}
+	}
+
+	base := strings.TrimSuffix(filepath.Base(romFilename), filepath.Ext(romFilename))
+	if e, ok := latest(p, &p.byName, strings.ToLower(base)); ok {
+		result := p.buildGameResult(e, nil)
+		result.MatchScore = 1.0
+		result.MatchType = "filename"
+		return result, nil
+	}
+
+	bestMatch, score := provider.FindBestMatchIndex(ctx, p.Name(), filename.CleanFilename(base, true), p.namesIndex(), provider.MatchOptions(*p.config, 0.85))
+	if bestMatch == "" {
+		return nil, nil
+	}
+
+	// The indexes may have been replaced since the name index was built
+	e, ok := latest(p, &p.byName, bestMatch)
+	if !ok {
+		return nil, nil
+	}
+	result := p.buildGameResult(e, nil)
+	result.MatchScore = score
+	result.MatchType = "filename"
+	return result, nil
+}
+
+// IdentifyByHash identifies a game by its hashes, attaching the matched signatures.
+func (p *Provider) IdentifyByHash(ctx context.Context, hashes retrometadata.FileHashes, _ retrometadata.IdentifyOptions) (*retrometadata.GameResult, error) {
+	if !p.config.Enabled {
+		return nil, nil
+	}
+	if err := p.ensureLoaded(ctx); err != nil {
+		return nil, err
+	}
+
+	found := p.lookupHashes(hashes)
+	if len(found) == 0 {
+		return nil, nil
+	}
+
+	result := p.buildGameResult(found[0], found)
+	result.MatchScore = 1.0
+	result.MatchType = "hash"
+	return result, nil
+}
+
+// IdentifyBySerial identifies a game by the serial of one of its ROMs.
+func (p *Provider) IdentifyBySerial(ctx context.Context, serial string, _ retrometadata.IdentifyOptions) (*retrometadata.GameResult, error) {
+	if !p.config.Enabled {
+		return nil, nil
+	}
+	if err := p.ensureLoaded(ctx); err != nil {
+		return nil, err
+	}
+
+	e, ok := latest(p, &p.bySerial, serialKey(serial))
+	if !ok {
+		return nil, nil
+	}
+	result := p.buildGameResult(e, nil)
+	result.MatchScore = 1.0
+	result.MatchType = "serial"
+	return result, nil
+}
+
+// MatchSignatures implements verify.SignatureMatcher using the loaded datfiles.
+func (p *Provider) MatchSignatures(ctx context.Context, hashes retrometadata.FileHashes) (*retrometadata.Signatures, error) {
+	if err := p.ensureLoaded(ctx); err != nil {
+		return nil, err
+	}
+	return signaturesFor(p.lookupHashes(hashes)), nil
+}
+
+// Heartbeat checks if the configured datfiles are available.
+func (p *Provider) Heartbeat(_ context.Context) error {
+	if !p.config.Enabled {
+		return ErrProviderDisabled
+	}
+	if len(p.datPaths) == 0 {
+		return fmt.Errorf("no datfile paths configured")
+	}
+	for _, path := range p.datPaths {
+		if _, err := os.Stat(path); os.IsNotExist(err) {
+			return fmt.Errorf("datfile not found: %s", path)
+		}
+	}
+	return nil
+}
+
+// Close clears loaded data.
+func (p *Provider) Close() error {
+	p.reset()
+	return nil
+}
+
+func (p *Provider) buildGameResult(e entry, matches []entry) *retrometadata.GameResult {
+	id := gameID(e.game.Name)
+	parsed := filename.ParseNoIntroFilename(e.game.Name)
+
+	result := &retrometadata.GameResult{
+		Name:        parsed.Name,
+		Provider:    p.Name(),
+		ProviderID:  &id,
+		ProviderIDs: map[string]int{"datfile": id},
+		Signatures:  signaturesFor(matches),
+		RawResponse: map[string]any{
+			"name":        e.game.Name,
+			"description": e.game.Description,
+			"datfile":     e.dat.Header.Name,
+		},
+	}
+
+	if e.game.Manufacturer != "" {
+		result.Metadata.Publisher = e.game.Manufacturer
+		result.Metadata.Companies = []string{e.game.Manufacturer}
+	}
+	if len(e.game.Year) >= 4 {
+		var year int
+		if _, err := fmt.Sscanf(e.game.Year[:4], "%d", &year); err == nil {
+			result.Metadata.ReleaseYear = &year
+		}
+	}
+
+	return result
+}
+
+// signaturesFor converts datfile matches into typed signature matches.
+func signaturesFor(found []entry) *retrometadata.Signatures {
+	if len(found) == 0 {
+		return nil
+	}
+
+	signatures := &retrometadata.Signatures{}
+	for _, e := range found {
+		signatures.Matches = append(signatures.Matches, retrometadata.SignatureMatch{
+			Source:   e.dat.Source,
+			Name:     e.game.Name,
+			Revision: filename.ParseNoIntroFilename(e.game.Name).Version,
+		})
+	}
+	return signatures
+}
+
+// gameID derives a stable integer ID from a datfile game name.
+func gameID(name string) int {
+	h := fnv.New32a()
+	h.Write([]byte(name))
+	return int(h.Sum32() & 0x7FFFFFFF)
+}
@@ -0,0 +1,89 @@
+package datfile
+
+import (
+	"encoding/xml"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/josegonzalez/retro-metadata/pkg/identify"
+	retrometadata "github.com/josegonzalez/retro-metadata/pkg/retrometadata"
+)
+
+// doctype is the Logiqx datfile DOCTYPE ClrMamePro and RomVault expect.
+const doctype = `<!DOCTYPE datafile PUBLIC "-//Logiqx//DTD ROM Management Datafile//EN" "http://www.logiqx.com/Dats/datafile.dtd">`
+
+// xmlDatfile is the XML form of a Datfile.
+type xmlDatfile struct {
+	XMLName xml.Name `xml:"datafile"`
+	Header  Header   `xml:"header"`
+	Games   []Game   `xml:"game"`
+}
+
+// Write writes the datfile as Logiqx XML, which Parse reads back.
+func (d *Datfile) Write(w io.Writer) error {
+	if _, err := io.WriteString(w, xml.Header+doctype+"\n"); err != nil {
+		return err
+	}
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "\t")
+	if err := encoder.Encode(xmlDatfile{Header: d.Header, Games: d.Games}); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+// WriteFile writes the datfile to path as Logiqx XML.
+func (d *Datfile) WriteFile(path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := d.Write(f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// FromGroups builds a datfile of a scanned collection: a game for each
+// file or multi-disc set, named after its file, with a ROM for each hashed
+// file. Files that weren't hashed are left out.
+func FromGroups(header Header, groups []identify.DiscGroup) *Datfile {
+	dat := &Datfile{Header: header, Source: header.Name}
+	for _, group := range groups {
+		game := Game{Name: strings.TrimSuffix(filepath.Base(group.Filename), filepath.Ext(group.Filename))}
+		game.Description = game.Name
+		if len(group.Discs) == 0 {
+			if rom, ok := hashedROM(group.Filename, group.Hashes); ok {
+				game.ROMs = append(game.ROMs, rom)
+			}
+		}
+		for _, disc := range group.Discs {
+			if rom, ok := hashedROM(disc.Filename, disc.Hashes); ok {
+				game.ROMs = append(game.ROMs, rom)
+			}
+		}
+		if len(game.ROMs) > 0 {
+			dat.Games = append(dat.Games, game)
+		}
+	}
+	return dat
+}
+
+// hashedROM returns the ROM entry of a hashed file.
+func hashedROM(path string, hashes *retrometadata.FileHashes) (ROM, bool) {
+	if hashes == nil || (hashes.CRC32 == "" && hashes.MD5 == "" && hashes.SHA1 == "") {
+		return ROM{}, false
+	}
+	return ROM{
+		Name:   filepath.Base(path),
+		Size:   hashes.Size,
+		CRC:    strings.ToLower(hashes.CRC32),
+		MD5:    strings.ToLower(hashes.MD5),
+		SHA1:   strings.ToLower(hashes.SHA1),
+		SHA256: strings.ToLower(hashes.SHA256),
+	}, true
+}
@@ -13,6 +13,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/josegonzalez/retro-metadata/pkg/provider"
 	retrometadata "github.com/josegonzalez/retro-metadata/pkg/retrometadata"
 )
 
@@ -24,7 +25,7 @@ var (
 	uuidRegex = regexp.MustCompile(`(?i)[a-f0-9]{8}-[a-f0-9]{4}-[a-f0-9]{4}-[a-f0-9]{4}-[a-f0-9]{12}`)
 
 	// ErrProviderDisabled is returned when the provider is disabled.
-	ErrProviderDisabled = fmt.Errorf("provider is disabled")
+	ErrProviderDisabled = retrometadata.ErrProviderDisabled
 )
 
 // Provider implements the Flashpoint metadata provider.
@@ -33,23 +34,28 @@ type Provider struct {
 	client    *http.Client
 	baseURL   string
 	userAgent string
+	limiter   *provider.RateLimiter
 }
 
-// New creates a new Flashpoint provider.
+// New creates a new Flashpoint provider. The Flashpoint database API has no
+// API keys, so it always runs keyless: requests are limited to
+// provider.KeylessRateLimit per second unless config.RateLimit is set.
 func New(config *retrometadata.ProviderConfig) *Provider {
-	timeout := time.Duration(config.Timeout) * time.Second
-	if timeout == 0 {
-		timeout = 30 * time.Second
-	}
-
 	return &Provider{
 		config:    config,
-		client:    &http.Client{Timeout: timeout},
+		client:    provider.NewHTTPClient(*config),
 		baseURL:   "https://db-api.unstable.life",
 		userAgent: "retro-metadata/1.0",
+		limiter:   provider.NewConfigRateLimiter(*config, provider.KeylessRateLimit),
 	}
 }
 
+// Keyless reports whether the provider runs without credentials, which it
+// always does.
+func (p *Provider) Keyless() bool {
+	return true
+}
+
 // Name returns the provider name.
 func (p *Provider) Name() string {
 	return "flashpoint"
@@ -64,6 +70,9 @@ func buildImageURL(gameID string, imageType string) string {
 }
 
 func (p *Provider) request(ctx context.Context, endpoint string, params url.Values) (interface{}, error) {
+	if err := p.limiter.Wait(ctx); err != nil {
+		return nil, err
+	}
 	reqURL := p.baseURL + endpoint
 	if len(params) > 0 {
 		reqURL += "?" + params.Encode()
@@ -78,12 +87,12 @@ func (p *Provider) request(ctx context.Context, endpoint string, params url.Valu
 
 	resp, err := p.client.Do(req)
 	if err != nil {
-		return nil, &retrometadata.ConnectionError{Provider: p.Name(), Details: err.Error()}
+		return nil, retrometadata.RequestError(ctx, p.Name(), err)
 	}
 	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		return nil, &retrometadata.ConnectionError{Provider: p.Name(), Details: fmt.Sprintf("HTTP %d", resp.StatusCode)}
+	if err := retrometadata.HTTPError(p.Name(), resp); err != nil {
+		return nil, err
 	}
 
 	body, err := io.ReadAll(resp.Body)
@@ -152,8 +161,7 @@ func (p *Provider) Search(ctx context.Context, query string, opts retrometadata.
 		results = append(results, retrometadata.SearchResult{
 			Name:        getString(game, "title"),
 			Provider:    p.Name(),
-			ProviderID:  0, // Flashpoint uses UUID strings, not integers
-			Slug:        gameID,
+			ProviderUID: gameID,
 			CoverURL:    coverURL,
 			Platforms:   []string{getString(game, "platform")},
 			ReleaseYear: releaseYear,
@@ -163,8 +171,8 @@ func (p *Provider) Search(ctx context.Context, query string, opts retrometadata.
 	return results, nil
 }
 
-// GetByID gets game details by Flashpoint UUID.
-func (p *Provider) GetByID(ctx context.Context, gameID string) (*retrometadata.GameResult, error) {
+// GetByUID gets game details by Flashpoint UUID.
+func (p *Provider) GetByUID(ctx context.Context, gameID string) (*retrometadata.GameResult, error) {
 	if !p.config.Enabled {
 		return nil, nil
 	}
@@ -191,9 +199,16 @@ func (p *Provider) GetByID(ctx context.Context, gameID string) (*retrometadata.G
 	return p.buildGameResult(game), nil
 }
 
+// GetByID is not supported for Flashpoint, which uses UUIDs; use GetByUID.
+func (p *Provider) GetByID(ctx context.Context, gameID int) (*retrometadata.GameResult, error) {
+	return nil, nil
+}
+
 // GetByIntID is not supported for Flashpoint (uses UUIDs).
+//
+// Deprecated: Use GetByUID.
 func (p *Provider) GetByIntID(ctx context.Context, gameID int) (*retrometadata.GameResult, error) {
-	return nil, nil
+	return p.GetByID(ctx, gameID)
 }
 
 // Identify identifies a game from a filename.
@@ -204,7 +219,7 @@ func (p *Provider) Identify(ctx context.Context, filename string, opts retrometa
 
 	// Check for Flashpoint ID tag in filename
 	if matches := flashpointTagRegex.FindStringSubmatch(filename); len(matches) > 1 {
-		result, err := p.GetByID(ctx, matches[1])
+		result, err := p.GetByUID(ctx, matches[1])
 		if err == nil && result != nil {
 			return result, nil
 		}
@@ -212,7 +227,7 @@ func (p *Provider) Identify(ctx context.Context, filename string, opts retrometa
 
 	// Check for UUID in filename
 	if uuidMatch := uuidRegex.FindString(filename); uuidMatch != "" {
-		result, err := p.GetByID(ctx, uuidMatch)
+		result, err := p.GetByUID(ctx, uuidMatch)
 		if err == nil && result != nil {
 			return result, nil
 		}
@@ -251,7 +266,7 @@ func (p *Provider) Identify(ctx context.Context, filename string, opts retrometa
 		names = append(names, name)
 	}
 
-	bestMatch, score := findBestMatch(searchTerm, names)
+	bestMatch, score := provider.FindBestMatch(ctx, p.Name(), searchTerm, names, provider.MatchOptions(*p.config, 0.6))
 	if bestMatch == "" {
 		return nil, nil
 	}
@@ -276,12 +291,11 @@ func (p *Provider) buildGameResult(game map[string]interface{}) *retrometadata.G
 	metadata := p.extractMetadata(game)
 
 	return &retrometadata.GameResult{
-		Name:        getString(game, "title"),
-		Summary:     getString(game, "originalDescription"),
-		Provider:    p.Name(),
-		ProviderID:  nil, // Flashpoint uses UUIDs
-		Slug:        gameID,
-		ProviderIDs: map[string]int{}, // Will store UUID in slug instead
+		Name:         getString(game, "title"),
+		Summary:      getString(game, "originalDescription"),
+		Provider:     p.Name(),
+		ProviderUID:  gameID,
+		ProviderUIDs: map[string]string{"flashpoint": gameID},
 		Artwork: retrometadata.Artwork{
 			CoverURL:       coverURL,
 			ScreenshotURLs: screenshotURLs,
@@ -417,42 +431,3 @@ func cleanFilename(filename string) string {
 	name = uuidRegex.ReplaceAllString(name, "")
 	return strings.TrimSpace(name)
 }
-
-func findBestMatch(query string, candidates []string) (string, float64) {
-	if len(candidates) == 0 {
-		return "", 0
-	}
-
-	queryLower := strings.ToLower(query)
-	bestMatch := ""
-	bestScore := 0.0
-
-	for _, candidate := range candidates {
-		candidateLower := strings.ToLower(candidate)
-
-		if candidateLower == queryLower {
-			return candidate, 1.0
-		}
-
-		if strings.Contains(candidateLower, queryLower) || strings.Contains(queryLower, candidateLower) {
-			score := float64(len(queryLower)) / float64(max(len(queryLower), len(candidateLower)))
-			if score > bestScore {
-				bestScore = score
-				bestMatch = candidate
-			}
-		}
-	}
-
-	if bestScore >= 0.6 {
-		return bestMatch, bestScore
-	}
-
-	return candidates[0], 0.5
-}
-
-func max(a, b int) int {
-	if a > b {
-		return a
-	}
-	return b
-}
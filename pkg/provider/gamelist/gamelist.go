@@ -4,6 +4,7 @@ package gamelist
 import (
 	"context"
 	"encoding/xml"
+	"errors"
 	"fmt"
 	"hash/fnv"
 	"io"
@@ -12,6 +13,8 @@ import (
 	"strconv"
 	"strings"
 
+	"github.com/josegonzalez/retro-metadata/pkg/platform"
+	"github.com/josegonzalez/retro-metadata/pkg/provider"
 	retrometadata "github.com/josegonzalez/retro-metadata/pkg/retrometadata"
 )
 
@@ -51,14 +54,17 @@ var (
 	}
 
 	// ErrProviderDisabled is returned when the provider is disabled.
-	ErrProviderDisabled = fmt.Errorf("provider is disabled")
+	ErrProviderDisabled = retrometadata.ErrProviderDisabled
 )
 
 // Provider implements the Gamelist metadata provider.
 type Provider struct {
-	config          *retrometadata.ProviderConfig
-	romsPath        string
-	gamesByFilename map[string]map[string]string
+	config        *retrometadata.ProviderConfig
+	romsPath      string
+	gamelistsPath string
+	// gamesByFilename lists every game with a ROM filename; systems loaded
+	// by LoadAll can share filenames
+	gamesByFilename map[string][]map[string]string
 	gamesByPath     map[string]map[string]string
 	platformDir     string
 	loaded          bool
@@ -67,16 +73,23 @@ type Provider struct {
 // New creates a new Gamelist provider.
 func New(config *retrometadata.ProviderConfig) *Provider {
 	romsPath := ""
+	gamelistsPath := ""
 	if config.Options != nil {
 		if path, ok := config.Options["roms_path"].(string); ok {
 			romsPath = path
 		}
+		// ES-DE keeps gamelists outside the ROM tree, in
+		// ~/ES-DE/gamelists/<system>/gamelist.xml
+		if path, ok := config.Options["gamelists_path"].(string); ok {
+			gamelistsPath = path
+		}
 	}
 
 	return &Provider{
 		config:          config,
 		romsPath:        romsPath,
-		gamesByFilename: make(map[string]map[string]string),
+		gamelistsPath:   gamelistsPath,
+		gamesByFilename: make(map[string][]map[string]string),
 		gamesByPath:     make(map[string]map[string]string),
 	}
 }
@@ -86,7 +99,8 @@ func (p *Provider) Name() string {
 	return "gamelist"
 }
 
-// LoadGamelist loads games from a gamelist.xml file.
+// LoadGamelist loads games from a gamelist.xml file. Games are tagged with
+// the platform detected from the name of platformDir, if any.
 func (p *Provider) LoadGamelist(ctx context.Context, gamelistPath string, platformDir string) error {
 	if gamelistPath == "" {
 		return fmt.Errorf("no gamelist path provided")
@@ -103,6 +117,7 @@ func (p *Provider) LoadGamelist(ctx context.Context, gamelistPath string, platfo
 	} else {
 		p.platformDir = filepath.Dir(gamelistPath)
 	}
+	platformSlug := platform.DetectFromFolder(filepath.Base(p.platformDir))
 
 	decoder := xml.NewDecoder(file)
 	for {
@@ -122,11 +137,15 @@ func (p *Provider) LoadGamelist(ctx context.Context, gamelistPath string, platfo
 					continue
 				}
 
+				if platformSlug != "" {
+					game["platform"] = string(platformSlug)
+				}
+
 				// Index by filename
 				gamePath := game["path"]
 				if gamePath != "" {
 					filename := filepath.Base(gamePath)
-					p.gamesByFilename[filename] = game
+					p.gamesByFilename[filename] = append(p.gamesByFilename[filename], game)
 					p.gamesByPath[gamePath] = game
 				}
 			}
@@ -137,6 +156,51 @@ func (p *Provider) LoadGamelist(ctx context.Context, gamelistPath string, platfo
 	return nil
 }
 
+// LoadAll loads the gamelist.xml of every system in a RetroPie or ES-DE ROM
+// tree (romsRoot/<system>/gamelist.xml), tagging each game with the platform
+// of its system folder. With the gamelists_path option, gamelists are read
+// from gamelists_path/<system>/gamelist.xml instead, as ES-DE stores them.
+// Systems without a gamelist are skipped; gamelists that fail to load don't
+// stop the others from loading, and their errors are returned together.
+func (p *Provider) LoadAll(ctx context.Context, romsRoot string) error {
+	if romsRoot == "" {
+		romsRoot = p.romsPath
+	}
+	if romsRoot == "" {
+		return fmt.Errorf("no roms path provided")
+	}
+
+	entries, err := os.ReadDir(romsRoot)
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	for _, entry := range entries {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if !entry.IsDir() {
+			continue
+		}
+
+		platformDir := filepath.Join(romsRoot, entry.Name())
+		gamelistPath := filepath.Join(platformDir, "gamelist.xml")
+		if p.gamelistsPath != "" {
+			gamelistPath = filepath.Join(p.gamelistsPath, entry.Name(), "gamelist.xml")
+		}
+		if _, err := os.Stat(gamelistPath); err != nil {
+			continue
+		}
+
+		if err := p.LoadGamelist(ctx, gamelistPath, platformDir); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", gamelistPath, err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
 func parseGame(decoder *xml.Decoder, start *xml.StartElement, game map[string]string, platformDir string) error {
 	coreFields := []string{"path", "name", "desc", "rating", "releasedate", "developer",
 		"publisher", "genre", "players", "md5", "lang", "region", "family"}
@@ -225,6 +289,38 @@ func hashFilename(filename string) int {
 	return int(h.Sum32())
 }
 
+// gameID returns the ID of a game: the hash of its filename, prefixed with
+// its platform so the same file on two systems gets two IDs.
+func gameID(game map[string]string, filename string) int {
+	if slug := game["platform"]; slug != "" {
+		return hashFilename(slug + "/" + filename)
+	}
+	return hashFilename(filename)
+}
+
+// matchesPlatform reports whether a game is on the platform with platformID,
+// an IGDB platform ID. Games with an unknown platform match any platform.
+func matchesPlatform(game map[string]string, platformID *int) bool {
+	if platformID == nil || game["platform"] == "" {
+		return true
+	}
+	id := platform.GetPlatformID(platform.ProviderGamelist, platform.Slug(game["platform"]))
+	return id != nil && *id == *platformID
+}
+
+// platformInfo returns the platform a game was tagged with, if any.
+func platformInfo(game map[string]string) *retrometadata.Platform {
+	slug := platform.Slug(game["platform"])
+	if slug == "" {
+		return nil
+	}
+	info := &retrometadata.Platform{Slug: string(slug), Name: slug.Name()}
+	if id := platform.GetPlatformID(platform.ProviderGamelist, slug); id != nil {
+		info.ProviderIDs = map[string]int{"gamelist": *id}
+	}
+	return info
+}
+
 // Search searches for games by name.
 func (p *Provider) Search(ctx context.Context, query string, opts retrometadata.SearchOptions) ([]retrometadata.SearchResult, error) {
 	if !p.config.Enabled {
@@ -242,44 +338,56 @@ func (p *Provider) Search(ctx context.Context, query string, opts retrometadata.
 	}
 
 	var results []retrometadata.SearchResult
-	for filename, game := range p.gamesByFilename {
-		name := game["name"]
-		if !strings.Contains(strings.ToLower(name), queryLower) &&
-			!strings.Contains(strings.ToLower(filename), queryLower) {
-			continue
-		}
+	for filename, games := range p.gamesByFilename {
+		for _, game := range games {
+			name := game["name"]
+			if !strings.Contains(strings.ToLower(name), queryLower) &&
+				!strings.Contains(strings.ToLower(filename), queryLower) {
+				continue
+			}
+			if !matchesPlatform(game, opts.PlatformID) {
+				continue
+			}
 
-		coverURL := game["box2d_url"]
-		if coverURL == "" {
-			coverURL = game["image_url"]
-		}
+			coverURL := game["box2d_url"]
+			if coverURL == "" {
+				coverURL = game["image_url"]
+			}
+
+			platforms := []string{}
+			if slug := game["platform"]; slug != "" {
+				platforms = append(platforms, slug)
+			}
 
-		results = append(results, retrometadata.SearchResult{
-			Name:       name,
-			Provider:   p.Name(),
-			ProviderID: hashFilename(filename),
-			CoverURL:   coverURL,
-			Platforms:  []string{},
-		})
+			results = append(results, retrometadata.SearchResult{
+				Name:       name,
+				Provider:   p.Name(),
+				ProviderID: gameID(game, filename),
+				CoverURL:   coverURL,
+				Platforms:  platforms,
+			})
 
-		if len(results) >= limit {
-			break
+			if len(results) >= limit {
+				return results, nil
+			}
 		}
 	}
 
 	return results, nil
 }
 
-// GetByID gets game details by ID (hash of filename).
-func (p *Provider) GetByID(ctx context.Context, gameID int) (*retrometadata.GameResult, error) {
+// GetByID gets game details by ID (hash of platform and filename).
+func (p *Provider) GetByID(ctx context.Context, id int) (*retrometadata.GameResult, error) {
 	if !p.config.Enabled || !p.loaded {
 		return nil, nil
 	}
 
 	// Find by matching hash
-	for filename, game := range p.gamesByFilename {
-		if hashFilename(filename) == gameID {
-			return p.buildGameResult(game, filename), nil
+	for filename, games := range p.gamesByFilename {
+		for _, game := range games {
+			if gameID(game, filename) == id {
+				return p.buildGameResult(game, filename), nil
+			}
 		}
 	}
 
@@ -293,27 +401,39 @@ func (p *Provider) Identify(ctx context.Context, filename string, opts retrometa
 	}
 
 	// Try exact match first
-	if game, ok := p.gamesByFilename[filename]; ok {
+	if game := p.findGame(filename, opts.PlatformID); game != nil {
 		return p.buildGameResult(game, filename), nil
 	}
 
 	// Try fuzzy match
 	var names []string
 	for name := range p.gamesByFilename {
-		names = append(names, name)
+		if p.findGame(name, opts.PlatformID) != nil {
+			names = append(names, name)
+		}
 	}
 
-	bestMatch, score := findBestMatch(filename, names)
+	bestMatch, score := provider.FindBestMatch(ctx, p.Name(), filename, names, provider.MatchOptions(*p.config, 0.6))
 	if bestMatch == "" {
 		return nil, nil
 	}
 
-	game := p.gamesByFilename[bestMatch]
+	game := p.findGame(bestMatch, opts.PlatformID)
 	result := p.buildGameResult(game, bestMatch)
 	result.MatchScore = score
 	return result, nil
 }
 
+// findGame returns the first game loaded for a filename on a platform.
+func (p *Provider) findGame(filename string, platformID *int) map[string]string {
+	for _, game := range p.gamesByFilename[filename] {
+		if matchesPlatform(game, platformID) {
+			return game
+		}
+	}
+	return nil
+}
+
 func (p *Provider) buildGameResult(game map[string]string, filename string) *retrometadata.GameResult {
 	// Get artwork
 	coverURL := game["box2d_url"]
@@ -330,19 +450,20 @@ func (p *Provider) buildGameResult(game map[string]string, filename string) *ret
 
 	metadata := p.extractMetadata(game)
 
-	providerID := hashFilename(filename)
+	providerID := gameID(game, filename)
 	return &retrometadata.GameResult{
 		Name:       game["name"],
 		Summary:    game["desc"],
 		Provider:   p.Name(),
 		ProviderID: &providerID,
 		ProviderIDs: map[string]int{
-			"gamelist": hashFilename(filename),
+			"gamelist": providerID,
 		},
 		Artwork: retrometadata.Artwork{
 			CoverURL:       coverURL,
 			ScreenshotURLs: screenshotURLs,
 			LogoURL:        game["marquee_url"],
+			ManualURL:      game["manual_url"],
 		},
 		Metadata:    metadata,
 		RawResponse: stringMapToAnyMap(game),
@@ -396,6 +517,12 @@ func (p *Provider) extractMetadata(game map[string]string) retrometadata.GameMet
 		playerCount = "1"
 	}
 
+	// Platform
+	platforms := []retrometadata.Platform{}
+	if info := platformInfo(game); info != nil {
+		platforms = append(platforms, *info)
+	}
+
 	return retrometadata.GameMetadata{
 		TotalRating: totalRating,
 		Genres:      genres,
@@ -405,13 +532,14 @@ func (p *Provider) extractMetadata(game map[string]string) retrometadata.GameMet
 		Developer:   game["developer"],
 		Publisher:   game["publisher"],
 		ReleaseYear: releaseYear,
+		Platforms:   platforms,
 		RawData:     stringMapToAnyMap(game),
 	}
 }
 
 // ClearCache clears the loaded gamelist data.
 func (p *Provider) ClearCache() {
-	p.gamesByFilename = make(map[string]map[string]string)
+	p.gamesByFilename = make(map[string][]map[string]string)
 	p.gamesByPath = make(map[string]map[string]string)
 	p.platformDir = ""
 	p.loaded = false
@@ -442,46 +570,3 @@ func stringMapToAnyMap(m map[string]string) map[string]any {
 	}
 	return result
 }
-
-func findBestMatch(query string, candidates []string) (string, float64) {
-	if len(candidates) == 0 {
-		return "", 0
-	}
-
-	queryLower := strings.ToLower(query)
-	bestMatch := ""
-	bestScore := 0.0
-
-	for _, candidate := range candidates {
-		candidateLower := strings.ToLower(candidate)
-
-		if candidateLower == queryLower {
-			return candidate, 1.0
-		}
-
-		if strings.Contains(candidateLower, queryLower) || strings.Contains(queryLower, candidateLower) {
-			score := float64(len(queryLower)) / float64(max(len(queryLower), len(candidateLower)))
-			if score > bestScore {
-				bestScore = score
-				bestMatch = candidate
-			}
-		}
-	}
-
-	if bestScore >= 0.6 {
-		return bestMatch, bestScore
-	}
-
-	if len(candidates) > 0 {
-		return candidates[0], 0.5
-	}
-
-	return "", 0
-}
-
-func max(a, b int) int {
-	if a > b {
-		return a
-	}
-	return b
-}
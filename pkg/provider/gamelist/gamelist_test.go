@@ -0,0 +1,84 @@
+package gamelist
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/josegonzalez/retro-metadata/pkg/platform"
+	"github.com/josegonzalez/retro-metadata/pkg/retrometadata"
+)
+
+func writeGamelist(t *testing.T, path, name string) {
+	t.Helper()
+	data := `<?xml version="1.0"?>
+<gameList>
+	<game>
+		<path>./Tetris.zip</path>
+		<name>` + name + `</name>
+	</game>
+</gameList>`
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLoadAll(t *testing.T) {
+	root := t.TempDir()
+	writeGamelist(t, filepath.Join(root, "gb", "gamelist.xml"), "Tetris (GB)")
+	writeGamelist(t, filepath.Join(root, "nes", "gamelist.xml"), "Tetris (NES)")
+	if err := os.MkdirAll(filepath.Join(root, "snes"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	p := New(&retrometadata.ProviderConfig{Enabled: true})
+	ctx := context.Background()
+	if err := p.LoadAll(ctx, root); err != nil {
+		t.Fatalf("LoadAll() error: %v", err)
+	}
+
+	results, _ := p.Search(ctx, "tetris", retrometadata.SearchOptions{})
+	if len(results) != 2 || results[0].ProviderID == results[1].ProviderID {
+		t.Fatalf("Expected a result with its own ID for each system, got %+v", results)
+	}
+
+	nes := platform.GetPlatformID(platform.ProviderGamelist, platform.SlugNES)
+	results, _ = p.Search(ctx, "tetris", retrometadata.SearchOptions{PlatformID: nes})
+	if len(results) != 1 || results[0].Name != "Tetris (NES)" || results[0].Platforms[0] != "nes" {
+		t.Errorf("Search() on NES = %+v, expected only the NES game", results)
+	}
+
+	gb := platform.GetPlatformID(platform.ProviderGamelist, platform.SlugGB)
+	result, _ := p.Identify(ctx, "Tetris.zip", retrometadata.IdentifyOptions{PlatformID: gb})
+	if result == nil || result.Name != "Tetris (GB)" {
+		t.Fatalf("Identify() on GB = %+v, expected the GB game", result)
+	}
+	if len(result.Metadata.Platforms) != 1 || result.Metadata.Platforms[0].Slug != "gb" {
+		t.Errorf("Metadata.Platforms = %+v, expected gb", result.Metadata.Platforms)
+	}
+
+	if byID, _ := p.GetByID(ctx, *result.ProviderID); byID == nil || byID.Name != result.Name {
+		t.Errorf("GetByID() = %+v, expected %s", byID, result.Name)
+	}
+}
+
+func TestLoadAllGamelistsPath(t *testing.T) {
+	root := t.TempDir()
+	gamelists := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(root, "gb"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	writeGamelist(t, filepath.Join(gamelists, "gb", "gamelist.xml"), "Tetris")
+
+	p := New(&retrometadata.ProviderConfig{Enabled: true, Options: map[string]any{"gamelists_path": gamelists}})
+	if err := p.LoadAll(context.Background(), root); err != nil {
+		t.Fatalf("LoadAll() error: %v", err)
+	}
+	if result, _ := p.Identify(context.Background(), "Tetris.zip", retrometadata.IdentifyOptions{}); result == nil {
+		t.Error("Expected the gamelist from gamelists_path to be loaded")
+	}
+}
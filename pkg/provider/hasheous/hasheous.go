@@ -13,9 +13,9 @@ import (
 	"regexp"
 	"strconv"
 	"strings"
-	"time"
 
 	"github.com/josegonzalez/retro-metadata/pkg/cache"
+	"github.com/josegonzalez/retro-metadata/pkg/filename"
 	"github.com/josegonzalez/retro-metadata/pkg/provider"
 	"github.com/josegonzalez/retro-metadata/pkg/retrometadata"
 )
@@ -43,6 +43,10 @@ type Provider struct {
 	userAgent  string
 	httpClient *http.Client
 	devMode    bool
+
+	// mergeSources makes IdentifyByHash combine every proxied metadata
+	// source instead of using only the first one available
+	mergeSources bool
 }
 
 // NewProvider creates a new Hasheous provider instance.
@@ -52,6 +56,14 @@ func NewProvider(config retrometadata.ProviderConfig, c cache.Cache) (*Provider,
 
 // NewProviderWithMode creates a new Hasheous provider with dev mode option.
 func NewProviderWithMode(config retrometadata.ProviderConfig, c cache.Cache, devMode bool) (*Provider, error) {
+	base, err := provider.NewBaseProvider("hasheous", config, c)
+	if err != nil {
+		return nil, err
+	}
+	if err = provider.CheckProxy(config); err != nil {
+		return nil, err
+	}
+
 	baseURL := HasheousProductionURL
 	apiKey := HasheousAPIKeyProduction
 	if devMode {
@@ -60,13 +72,16 @@ func NewProviderWithMode(config retrometadata.ProviderConfig, c cache.Cache, dev
 	}
 
 	p := &Provider{
-		BaseProvider: provider.NewBaseProvider("hasheous", config, c),
+		BaseProvider: base,
 		baseURL:      baseURL,
 		apiKey:       apiKey,
 		userAgent:    "retro-metadata/1.0",
-		httpClient:   &http.Client{Timeout: 30 * time.Second},
+		httpClient:   provider.NewHTTPClient(config),
 		devMode:      devMode,
 	}
+	if mergeSources, ok := config.Options["merge_sources"].(bool); ok {
+		p.mergeSources = mergeSources
+	}
 	p.SetMinSimilarityScore(0.6)
 	return p, nil
 }
@@ -106,17 +121,16 @@ func (p *Provider) request(ctx context.Context, method, endpoint string, params
 
 	resp, err := p.httpClient.Do(req)
 	if err != nil {
-		return nil, &retrometadata.ProviderError{Provider: p.Name(), Err: retrometadata.ErrProviderConnection}
+		return nil, retrometadata.RequestError(ctx, p.Name(), err)
 	}
 	defer resp.Body.Close()
 
-	if resp.StatusCode == 429 {
-		return nil, &retrometadata.ProviderError{Provider: p.Name(), Err: retrometadata.ErrProviderRateLimit}
-	}
-
 	if resp.StatusCode == 404 {
 		return nil, nil
 	}
+	if err := retrometadata.HTTPError(p.Name(), resp); err != nil {
+		return nil, err
+	}
 
 	respBody, err := io.ReadAll(resp.Body)
 	if err != nil {
@@ -254,51 +268,53 @@ func (p *Provider) LookupByHash(ctx context.Context, md5, sha1, crc string, retu
 }
 
 // IdentifyByHash implements the HashProvider interface for hash-based identification.
+//
+// If opts.PlatformID is set, it's an IGDB platform ID and matches for other
+// platforms are ignored. With the merge_sources option, the IGDB,
+// RetroAchievements and TheGamesDB data Hasheous links to are combined;
+// otherwise only IGDB is used.
 func (p *Provider) IdentifyByHash(ctx context.Context, hashes retrometadata.FileHashes, opts retrometadata.IdentifyOptions) (*retrometadata.GameResult, error) {
 	result, err := p.LookupByHash(ctx, hashes.MD5, hashes.SHA1, hashes.CRC32, true)
 	if err != nil || result == nil {
 		return nil, err
 	}
-
-	// Try to get IGDB game data
-	igdbGame, err := p.GetIGDBGame(ctx, result)
-	if err == nil && igdbGame != nil {
-		return p.buildGameResultFromIGDB(igdbGame), nil
+	if opts.PlatformID != nil && !platformMatches(result, *opts.PlatformID) {
+		return nil, nil
 	}
 
-	// Fall back to basic result
-	return p.buildGameResultFromHashLookup(result), nil
-}
+	var gameResult *retrometadata.GameResult
 
-// GetIGDBGame gets IGDB game data through Hasheous proxy.
-func (p *Provider) GetIGDBGame(ctx context.Context, hasheousResult map[string]interface{}) (map[string]interface{}, error) {
-	if !p.IsEnabled() {
-		return nil, nil
+	if p.mergeSources {
+		gameResult = p.identifyFromSources(ctx, result)
+	} else if igdbGame, err := p.GetIGDBGame(ctx, result); err == nil && igdbGame != nil {
+		gameResult = p.buildGameResultFromIGDB(igdbGame)
+	}
+	if gameResult == nil {
+		// Fall back to basic result
+		gameResult = p.buildGameResultFromHashLookup(result)
 	}
 
-	// Check for IGDB ID in the Hasheous result
-	var igdbID int
-
-	// Look in metadata list
-	if metadataList, ok := hasheousResult["metadata"].([]interface{}); ok {
-		for _, meta := range metadataList {
-			if metaMap, ok := meta.(map[string]interface{}); ok {
-				if getString(metaMap, "source") == "IGDB" {
-					igdbID = getInt(metaMap, "immutableId")
-					break
-				}
+	// The IDs Hasheous links to let other providers skip a search
+	for name, id := range linkedIDs(result) {
+		if _, ok := gameResult.ProviderIDs[name]; !ok {
+			if gameResult.ProviderIDs == nil {
+				gameResult.ProviderIDs = make(map[string]int)
 			}
+			gameResult.ProviderIDs[name] = id
 		}
 	}
 
-	// Also check direct igdb_id field
-	if igdbID == 0 {
-		igdbID = getInt(hasheousResult, "igdb_id")
-	}
-	if igdbID == 0 {
-		igdbID = getInt(hasheousResult, "igdbId")
+	gameResult.Signatures = p.GetSignatures(result)
+	return gameResult, nil
+}
+
+// GetIGDBGame gets IGDB game data through Hasheous proxy.
+func (p *Provider) GetIGDBGame(ctx context.Context, hasheousResult map[string]interface{}) (map[string]interface{}, error) {
+	if !p.IsEnabled() {
+		return nil, nil
 	}
 
+	igdbID := metadataID(hasheousResult, MetadataSourceIGDB, "igdb_id", "igdbId")
 	if igdbID == 0 {
 		return nil, nil
 	}
@@ -328,29 +344,7 @@ func (p *Provider) GetRAGame(ctx context.Context, hasheousResult map[string]inte
 		return nil, nil
 	}
 
-	// Check for RetroAchievements ID in the Hasheous result
-	var raID int
-
-	// Look in metadata list
-	if metadataList, ok := hasheousResult["metadata"].([]interface{}); ok {
-		for _, meta := range metadataList {
-			if metaMap, ok := meta.(map[string]interface{}); ok {
-				if getString(metaMap, "source") == "RetroAchievements" {
-					raID = getInt(metaMap, "immutableId")
-					break
-				}
-			}
-		}
-	}
-
-	// Also check direct ra_id field
-	if raID == 0 {
-		raID = getInt(hasheousResult, "ra_id")
-	}
-	if raID == 0 {
-		raID = getInt(hasheousResult, "retroAchievementsId")
-	}
-
+	raID := metadataID(hasheousResult, MetadataSourceRetroAchievements, "ra_id", "retroAchievementsId")
 	if raID == 0 {
 		return nil, nil
 	}
@@ -370,40 +364,95 @@ func (p *Provider) GetRAGame(ctx context.Context, hasheousResult map[string]inte
 }
 
 // GetSignatureMatches extracts signature matching flags from Hasheous lookup result.
+//
+// Deprecated: Use GetSignatures, which also reports the matched names and revisions.
 func (p *Provider) GetSignatureMatches(hasheousResult map[string]interface{}) map[string]bool {
-	matches := map[string]bool{
-		"tosec_match":       false,
-		"nointro_match":     false,
-		"redump_match":      false,
-		"mame_arcade_match": false,
-		"mame_mess_match":   false,
-		"whdload_match":     false,
-		"ra_match":          false,
-		"fbneo_match":       false,
-		"puredos_match":     false,
+	signatures := p.GetSignatures(hasheousResult)
+	return map[string]bool{
+		"tosec_match":       signatures.Has(retrometadata.SignatureSourceTOSEC),
+		"nointro_match":     signatures.Has(retrometadata.SignatureSourceNoIntro),
+		"redump_match":      signatures.Has(retrometadata.SignatureSourceRedump),
+		"mame_arcade_match": signatures.Has(retrometadata.SignatureSourceMAMEArcade),
+		"mame_mess_match":   signatures.Has(retrometadata.SignatureSourceMAMEMess),
+		"whdload_match":     signatures.Has(retrometadata.SignatureSourceWHDLoad),
+		"ra_match":          signatures.Has(retrometadata.SignatureSourceRetroAchievements),
+		"fbneo_match":       signatures.Has(retrometadata.SignatureSourceFBNeo),
+		"puredos_match":     signatures.Has(retrometadata.SignatureSourcePureDOS),
 	}
+}
 
+// signatureSources maps Hasheous signature keys to signature database names,
+// in the order matches are reported.
+var signatureSources = []struct {
+	key    string
+	source string
+}{
+	{"NoIntros", retrometadata.SignatureSourceNoIntro},
+	{"Redump", retrometadata.SignatureSourceRedump},
+	{"TOSEC", retrometadata.SignatureSourceTOSEC},
+	{"MAMEArcade", retrometadata.SignatureSourceMAMEArcade},
+	{"MAMEMess", retrometadata.SignatureSourceMAMEMess},
+	{"FBNeo", retrometadata.SignatureSourceFBNeo},
+	{"WHDLoad", retrometadata.SignatureSourceWHDLoad},
+	{"PureDOS", retrometadata.SignatureSourcePureDOS},
+	{"RetroAchievements", retrometadata.SignatureSourceRetroAchievements},
+}
+
+// GetSignatures extracts the typed signature matches from a Hasheous lookup result.
+// Returns nil if no signatures matched.
+func (p *Provider) GetSignatures(hasheousResult map[string]interface{}) *retrometadata.Signatures {
 	signatures, ok := hasheousResult["signatures"].(map[string]interface{})
 	if !ok {
-		return matches
+		return nil
 	}
 
-	signatureKeys := make(map[string]bool)
-	for key := range signatures {
-		signatureKeys[key] = true
+	var matches []retrometadata.SignatureMatch
+	for _, s := range signatureSources {
+		data, ok := signatures[s.key]
+		if !ok {
+			continue
+		}
+		name := signatureName(data)
+		matches = append(matches, retrometadata.SignatureMatch{
+			Source:   s.source,
+			Name:     name,
+			Revision: filename.ParseNoIntroFilename(name).Version,
+		})
 	}
 
-	matches["tosec_match"] = signatureKeys["TOSEC"]
-	matches["nointro_match"] = signatureKeys["NoIntros"]
-	matches["redump_match"] = signatureKeys["Redump"]
-	matches["mame_arcade_match"] = signatureKeys["MAMEArcade"]
-	matches["mame_mess_match"] = signatureKeys["MAMEMess"]
-	matches["whdload_match"] = signatureKeys["WHDLoad"]
-	matches["ra_match"] = signatureKeys["RetroAchievements"]
-	matches["fbneo_match"] = signatureKeys["FBNeo"]
-	matches["puredos_match"] = signatureKeys["PureDOS"]
+	if len(matches) == 0 {
+		return nil
+	}
+	return &retrometadata.Signatures{Matches: matches}
+}
 
-	return matches
+// MatchSignatures implements verify.SignatureMatcher using the Hasheous hash lookup.
+func (p *Provider) MatchSignatures(ctx context.Context, hashes retrometadata.FileHashes) (*retrometadata.Signatures, error) {
+	result, err := p.LookupByHash(ctx, hashes.MD5, hashes.SHA1, hashes.CRC32, true)
+	if err != nil || result == nil {
+		return nil, err
+	}
+	return p.GetSignatures(result), nil
+}
+
+// signatureName extracts the matched dump name from a Hasheous signature entry.
+func signatureName(data interface{}) string {
+	switch v := data.(type) {
+	case map[string]interface{}:
+		if game, ok := v["game"].(map[string]interface{}); ok {
+			if name := getString(game, "name"); name != "" {
+				return name
+			}
+		}
+		return getString(v, "name")
+	case []interface{}:
+		for _, item := range v {
+			if name := signatureName(item); name != "" {
+				return name
+			}
+		}
+	}
+	return ""
 }
 
 // Identify identifies a game from a ROM filename.
@@ -447,7 +496,7 @@ func (p *Provider) Identify(ctx context.Context, filename string, opts retrometa
 		names = append(names, r.Name)
 	}
 
-	bestMatch, score := p.FindBestMatch(searchTerm, names)
+	bestMatch, score := p.FindBestMatchContext(ctx, searchTerm, names)
 
 	if bestMatch != "" {
 		if sr, ok := gamesByName[bestMatch]; ok {
@@ -0,0 +1,149 @@
+package hasheous
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/josegonzalez/retro-metadata/pkg/cache"
+	"github.com/josegonzalez/retro-metadata/pkg/retrometadata"
+)
+
+func newTestServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var response any
+		switch r.URL.Path {
+		case "/Lookup/ByHash":
+			response = map[string]any{
+				"name": "Super Metroid",
+				"platform": map[string]any{
+					"name":     "Super Nintendo Entertainment System",
+					"metadata": []any{map[string]any{"source": "IGDB", "immutableId": "19"}},
+				},
+				"signatures": map[string]any{
+					"NoIntros": []any{map[string]any{"game": map[string]any{"name": "Super Metroid (Japan, USA) (En,Ja)"}}},
+				},
+				"metadata": []any{
+					map[string]any{"source": "IGDB", "immutableId": "1103"},
+					map[string]any{"source": "RetroAchievements", "immutableId": "335"},
+					map[string]any{"source": "TheGamesDB", "immutableId": "1018"},
+				},
+			}
+		case "/MetadataProxy/IGDB/Game":
+			response = map[string]any{
+				"id":      1103,
+				"name":    "Super Metroid",
+				"summary": "Samus returns to Zebes.",
+				"genres":  []any{map[string]any{"name": "Platform"}},
+			}
+		case "/MetadataProxy/RA/Game":
+			response = map[string]any{
+				"ID":          335,
+				"Title":       "Super Metroid",
+				"ImageIcon":   "/Images/066295.png",
+				"ImageBoxArt": "/Images/024226.png",
+				"Developer":   "Nintendo R&D1",
+				"Publisher":   "Nintendo",
+				"Released":    "1994-03-19",
+			}
+		case "/MetadataProxy/TheGamesDB/Games/ByGameID":
+			response = map[string]any{
+				"data": map[string]any{"games": []any{
+					map[string]any{"id": 1018, "game_title": "Super Metroid", "players": 1},
+				}},
+			}
+		default:
+			http.NotFound(w, r)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(response)
+	}))
+}
+
+func TestIdentifyByHashMergeSources(t *testing.T) {
+	server := newTestServer(t)
+	defer server.Close()
+
+	config := retrometadata.ProviderConfig{
+		Enabled:     true,
+		Credentials: map[string]string{"api_key": "key"},
+		Options:     map[string]any{"merge_sources": true},
+	}
+	p, err := NewProvider(config, cache.NewMemoryCache())
+	if err != nil {
+		t.Fatal(err)
+	}
+	p.baseURL = server.URL
+	ctx := context.Background()
+	hashes := retrometadata.FileHashes{MD5: "21f3e98df4780ee1c667b84e57d88675"}
+
+	result, err := p.IdentifyByHash(ctx, hashes, retrometadata.IdentifyOptions{})
+	if err != nil || result == nil {
+		t.Fatalf("IdentifyByHash() = %v, %v", result, err)
+	}
+	if result.Provider != "igdb" || result.Summary != "Samus returns to Zebes." {
+		t.Errorf("result = %s %q, want the IGDB data first", result.Provider, result.Summary)
+	}
+	wantIDs := map[string]int{"igdb": 1103, "retroachievements": 335, "thegamesdb": 1018}
+	for name, id := range wantIDs {
+		if result.ProviderIDs[name] != id {
+			t.Errorf("ProviderIDs[%s] = %d, want %d", name, result.ProviderIDs[name], id)
+		}
+	}
+	// Fields IGDB lacks come from RetroAchievements and TheGamesDB
+	if result.Artwork.CoverURL != raMediaURL+"/Images/024226.png" || result.Metadata.Publisher != "Nintendo" {
+		t.Errorf("cover %q, publisher %q; want them from RetroAchievements", result.Artwork.CoverURL, result.Metadata.Publisher)
+	}
+	if result.Metadata.PlayerCount != "1" {
+		t.Errorf("PlayerCount = %q, want it from TheGamesDB", result.Metadata.PlayerCount)
+	}
+	if len(result.Metadata.Genres) != 1 || result.Metadata.Genres[0] != "Platform" {
+		t.Errorf("Genres = %v, want IGDB's", result.Metadata.Genres)
+	}
+	if !result.Signatures.Has(retrometadata.SignatureSourceNoIntro) {
+		t.Errorf("Signatures = %+v, want a No-Intro match", result.Signatures)
+	}
+
+	// Matches for other platforms are ignored
+	genesis := 29
+	if result, err := p.IdentifyByHash(ctx, hashes, retrometadata.IdentifyOptions{PlatformID: &genesis}); err != nil || result != nil {
+		t.Errorf("IdentifyByHash() on another platform = %v, %v; want no match", result, err)
+	}
+
+	// Without the option only IGDB is used
+	p.mergeSources = false
+	result, _ = p.IdentifyByHash(ctx, hashes, retrometadata.IdentifyOptions{})
+	if result == nil || result.Metadata.Publisher != "" {
+		t.Errorf("IdentifyByHash() without merging = %+v, want only IGDB data", result)
+	}
+	// but the other sources' IDs are still linked
+	for name, id := range wantIDs {
+		if result.ProviderIDs[name] != id {
+			t.Errorf("ProviderIDs[%s] without merging = %d, want %d", name, result.ProviderIDs[name], id)
+		}
+	}
+}
+
+func TestServerError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		_, _ = w.Write([]byte("<html>Service Unavailable</html>"))
+	}))
+	defer server.Close()
+
+	config := retrometadata.ProviderConfig{Enabled: true, Credentials: map[string]string{"api_key": "key"}}
+	p, err := NewProvider(config, cache.NewMemoryCache())
+	if err != nil {
+		t.Fatal(err)
+	}
+	p.baseURL = server.URL
+
+	hashes := retrometadata.FileHashes{MD5: "21f3e98df4780ee1c667b84e57d88675"}
+	_, err = p.IdentifyByHash(context.Background(), hashes, retrometadata.IdentifyOptions{})
+	if !retrometadata.Retryable(err) || retrometadata.HTTPStatus(err) != http.StatusServiceUnavailable {
+		t.Errorf("IdentifyByHash() error = %v, want a retryable 503", err)
+	}
+}
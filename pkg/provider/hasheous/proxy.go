@@ -0,0 +1,243 @@
+package hasheous
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/josegonzalez/retro-metadata/pkg/retrometadata"
+)
+
+// Metadata sources Hasheous links hash matches to and proxies.
+const (
+	MetadataSourceIGDB              = "IGDB"
+	MetadataSourceRetroAchievements = "RetroAchievements"
+	MetadataSourceTheGamesDB        = "TheGamesDB"
+)
+
+// Base URLs for artwork of proxied games.
+const (
+	raMediaURL   = "https://media.retroachievements.org"
+	tgdbImageURL = "https://cdn.thegamesdb.net/images/original/"
+)
+
+// metadataID returns the ID a Hasheous lookup result links to in a metadata
+// source, checking the metadata list first and then the given fields.
+func metadataID(hasheousResult map[string]interface{}, source string, fields ...string) int {
+	if metadataList, ok := hasheousResult["metadata"].([]interface{}); ok {
+		for _, meta := range metadataList {
+			if metaMap, ok := meta.(map[string]interface{}); ok && getString(metaMap, "source") == source {
+				if id := getInt(metaMap, "immutableId"); id > 0 {
+					return id
+				}
+			}
+		}
+	}
+	for _, field := range fields {
+		if id := getInt(hasheousResult, field); id > 0 {
+			return id
+		}
+	}
+	return 0
+}
+
+// linkedIDs returns the IDs of the games a lookup result links to, by
+// provider name.
+func linkedIDs(hasheousResult map[string]interface{}) map[string]int {
+	ids := make(map[string]int)
+	if id := metadataID(hasheousResult, MetadataSourceIGDB, "igdb_id", "igdbId"); id > 0 {
+		ids["igdb"] = id
+	}
+	if id := metadataID(hasheousResult, MetadataSourceRetroAchievements, "ra_id", "retroAchievementsId"); id > 0 {
+		ids["retroachievements"] = id
+	}
+	if id := metadataID(hasheousResult, MetadataSourceTheGamesDB, "tgdb_id", "theGamesDbId"); id > 0 {
+		ids["thegamesdb"] = id
+	}
+	return ids
+}
+
+// platformMatches reports whether a lookup result is for the IGDB platform
+// platformID. Results that don't name an IGDB platform match any platform.
+func platformMatches(hasheousResult map[string]interface{}, platformID int) bool {
+	platform, ok := hasheousResult["platform"].(map[string]interface{})
+	if !ok {
+		return true
+	}
+	id := metadataID(platform, MetadataSourceIGDB)
+	return id == 0 || id == platformID
+}
+
+// GetTGDBGame gets TheGamesDB game data through Hasheous proxy.
+func (p *Provider) GetTGDBGame(ctx context.Context, hasheousResult map[string]interface{}) (map[string]interface{}, error) {
+	if !p.IsEnabled() {
+		return nil, nil
+	}
+
+	tgdbID := metadataID(hasheousResult, MetadataSourceTheGamesDB, "tgdb_id", "theGamesDbId")
+	if tgdbID == 0 {
+		return nil, nil
+	}
+
+	params := map[string]string{
+		"id":      strconv.Itoa(tgdbID),
+		"fields":  "players,publishers,genres,overview,rating,platform",
+		"include": "boxart",
+	}
+	result, err := p.request(ctx, "GET", "/MetadataProxy/TheGamesDB/Games/ByGameID", params, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	// The proxy returns TheGamesDB's response, with the games under data
+	resultMap, ok := result.(map[string]interface{})
+	if !ok {
+		return nil, nil
+	}
+	data, _ := resultMap["data"].(map[string]interface{})
+	games, _ := data["games"].([]interface{})
+	if len(games) == 0 {
+		return nil, nil
+	}
+	game, ok := games[0].(map[string]interface{})
+	if !ok {
+		return nil, nil
+	}
+	if cover := tgdbCover(resultMap, tgdbID); cover != "" {
+		game["cover_url"] = cover
+	}
+	return game, nil
+}
+
+// tgdbCover returns the front boxart of a game in a proxied TheGamesDB response.
+func tgdbCover(response map[string]interface{}, gameID int) string {
+	include, _ := response["include"].(map[string]interface{})
+	boxart, _ := include["boxart"].(map[string]interface{})
+	data, _ := boxart["data"].(map[string]interface{})
+	images, _ := data[strconv.Itoa(gameID)].([]interface{})
+	for _, image := range images {
+		if imageMap, ok := image.(map[string]interface{}); ok && getString(imageMap, "side") == "front" {
+			return tgdbImageURL + getString(imageMap, "filename")
+		}
+	}
+	return ""
+}
+
+// identifyFromSources builds a result from every metadata source the lookup
+// result links to. IGDB is preferred, then RetroAchievements, then
+// TheGamesDB; fields the preferred source lacks are filled from the others.
+// Returns nil if no source has the game.
+func (p *Provider) identifyFromSources(ctx context.Context, hasheousResult map[string]interface{}) *retrometadata.GameResult {
+	var merged *retrometadata.GameResult
+	add := func(result *retrometadata.GameResult) {
+		if merged == nil {
+			merged = result
+			return
+		}
+		mergeGameResult(merged, result)
+	}
+
+	if game, err := p.GetIGDBGame(ctx, hasheousResult); err == nil && game != nil {
+		add(p.buildGameResultFromIGDB(game))
+	}
+	if game, err := p.GetRAGame(ctx, hasheousResult); err == nil && game != nil {
+		add(p.buildGameResultFromRA(game))
+	}
+	if game, err := p.GetTGDBGame(ctx, hasheousResult); err == nil && game != nil {
+		add(p.buildGameResultFromTGDB(game))
+	}
+	return merged
+}
+
+func (p *Provider) buildGameResultFromRA(game map[string]interface{}) *retrometadata.GameResult {
+	providerID := getInt(game, "ID")
+	result := &retrometadata.GameResult{
+		Provider:    "retroachievements",
+		ProviderID:  &providerID,
+		ProviderIDs: map[string]int{"retroachievements": providerID},
+		Name:        getString(game, "Title"),
+		RawResponse: game,
+	}
+
+	if boxart := getString(game, "ImageBoxArt"); boxart != "" {
+		result.Artwork.CoverURL = raMediaURL + boxart
+	}
+	if icon := getString(game, "ImageIcon"); icon != "" {
+		result.Artwork.IconURL = raMediaURL + icon
+	}
+	for _, key := range []string{"ImageIngame", "ImageTitle"} {
+		if image := getString(game, key); image != "" {
+			result.Artwork.ScreenshotURLs = append(result.Artwork.ScreenshotURLs, raMediaURL+image)
+		}
+	}
+
+	result.Metadata = p.extractMetadata(map[string]interface{}{
+		"genres":       getString(game, "Genre"),
+		"publisher":    getString(game, "Publisher"),
+		"developer":    getString(game, "Developer"),
+		"release_date": getString(game, "Released"),
+	})
+	result.Metadata.RawData = game
+	return result
+}
+
+func (p *Provider) buildGameResultFromTGDB(game map[string]interface{}) *retrometadata.GameResult {
+	providerID := getInt(game, "id")
+	result := &retrometadata.GameResult{
+		Provider:    "thegamesdb",
+		ProviderID:  &providerID,
+		ProviderIDs: map[string]int{"thegamesdb": providerID},
+		Name:        getString(game, "game_title"),
+		Summary:     getString(game, "overview"),
+		RawResponse: game,
+		Artwork:     retrometadata.Artwork{CoverURL: getString(game, "cover_url")},
+	}
+	result.Metadata = p.extractMetadata(game)
+	return result
+}
+
+// mergeGameResult fills the fields dst lacks from src and adds src's
+// provider IDs to dst.
+func mergeGameResult(dst, src *retrometadata.GameResult) {
+	if dst.ProviderIDs == nil {
+		dst.ProviderIDs = make(map[string]int)
+	}
+	for name, id := range src.ProviderIDs {
+		if _, ok := dst.ProviderIDs[name]; !ok {
+			dst.ProviderIDs[name] = id
+		}
+	}
+
+	dst.Name = coalesce(dst.Name, src.Name)
+	dst.Summary = coalesce(dst.Summary, src.Summary)
+	dst.Slug = coalesce(dst.Slug, src.Slug)
+
+	art, srcArt := &dst.Artwork, src.Artwork
+	art.CoverURL = coalesce(art.CoverURL, srcArt.CoverURL)
+	art.IconURL = coalesce(art.IconURL, srcArt.IconURL)
+	art.LogoURL = coalesce(art.LogoURL, srcArt.LogoURL)
+	art.BannerURL = coalesce(art.BannerURL, srcArt.BannerURL)
+	art.BackgroundURL = coalesce(art.BackgroundURL, srcArt.BackgroundURL)
+	if len(art.ScreenshotURLs) == 0 {
+		art.ScreenshotURLs = srcArt.ScreenshotURLs
+	}
+
+	meta, srcMeta := &dst.Metadata, src.Metadata
+	meta.Developer = coalesce(meta.Developer, srcMeta.Developer)
+	meta.Publisher = coalesce(meta.Publisher, srcMeta.Publisher)
+	meta.PlayerCount = coalesce(meta.PlayerCount, srcMeta.PlayerCount)
+	if len(meta.Genres) == 0 {
+		meta.Genres = srcMeta.Genres
+	}
+	if len(meta.Companies) == 0 {
+		meta.Companies = srcMeta.Companies
+	}
+	if meta.ReleaseYear == nil {
+		meta.ReleaseYear = srcMeta.ReleaseYear
+	}
+	if meta.FirstReleaseDate == nil {
+		meta.FirstReleaseDate = srcMeta.FirstReleaseDate
+	}
+	if meta.TotalRating == nil {
+		meta.TotalRating = srcMeta.TotalRating
+	}
+}
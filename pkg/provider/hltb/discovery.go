@@ -0,0 +1,171 @@
+package hltb
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+)
+
+const (
+	hltbSiteURL = "https://howlongtobeat.com"
+
+	// endpointTTL is how long a discovered search endpoint is used before
+	// it's looked up again
+	endpointTTL = 24 * time.Hour
+
+	// maxScriptSize caps how much of a page or script is read during discovery
+	maxScriptSize = 8 << 20
+)
+
+var (
+	// Next.js script chunks referenced by the home page, e.g. /_next/static/chunks/pages/_app-0123abcd.js
+	nextScriptRegex = regexp.MustCompile(`/_next/static/[^"'\s]+\.js`)
+
+	// The search request in the site's JavaScript. The endpoint is either a
+	// plain path or a path with .concat("...") pieces appended, e.g.
+	// fetch("/api/search/".concat("4b4cbe57").concat("0602c886"), {method:"POST", ...
+	searchFetchRegex = regexp.MustCompile(`fetch\(\s*["']/api/([a-zA-Z0-9_/]+)["']((?:\s*\.concat\(\s*["'][^"']*["']\s*\))*)\s*,\s*\{[^}]*method:\s*["']POST["']`)
+	concatRegex      = regexp.MustCompile(`\.concat\(\s*["']([^"']*)["']\s*\)`)
+)
+
+// fetchSearchEndpoint returns the search endpoint, discovering it if none is
+// cached or the cached one is older than endpointTTL.
+//
+// The endpoint is read from the site's Next.js scripts first, then from the
+// URL in the endpoint_url option (by default a file in the RomM repository),
+// and finally defaults to "search". A search_endpoint option skips discovery.
+func (p *Provider) fetchSearchEndpoint(ctx context.Context) string {
+	if endpoint, ok := p.config.Options["search_endpoint"].(string); ok && endpoint != "" {
+		return strings.Trim(endpoint, "/")
+	}
+
+	p.endpointMu.Lock()
+	defer p.endpointMu.Unlock()
+
+	if p.searchEndpoint != "" && time.Since(p.endpointFetchedAt) < endpointTTL {
+		return p.searchEndpoint
+	}
+
+	endpoint := p.discoverSearchEndpoint(ctx)
+	if endpoint == "" {
+		endpoint = p.fetchPublishedEndpoint(ctx)
+	}
+	if endpoint == "" {
+		// Keep a previously discovered endpoint over the default
+		if p.searchEndpoint != "" {
+			endpoint = p.searchEndpoint
+		} else {
+			endpoint = defaultSearchEndpoint
+		}
+	}
+
+	p.searchEndpoint = endpoint
+	p.endpointFetchedAt = time.Now()
+	return endpoint
+}
+
+// resetSearchEndpoint forgets the cached search endpoint, so the next
+// search discovers it again.
+func (p *Provider) resetSearchEndpoint() {
+	p.endpointMu.Lock()
+	p.searchEndpoint = ""
+	p.endpointMu.Unlock()
+}
+
+// discoverSearchEndpoint finds the search endpoint in the scripts the home
+// page loads. The app chunk usually has it, so it's checked first. Returns
+// an empty string if it can't be found.
+func (p *Provider) discoverSearchEndpoint(ctx context.Context) string {
+	page, err := p.fetchText(ctx, p.siteURL)
+	if err != nil {
+		return ""
+	}
+
+	seen := make(map[string]bool)
+	var scripts []string
+	for _, script := range nextScriptRegex.FindAllString(page, -1) {
+		if seen[script] {
+			continue
+		}
+		seen[script] = true
+		if strings.Contains(script, "/_app-") {
+			scripts = append([]string{script}, scripts...)
+		} else {
+			scripts = append(scripts, script)
+		}
+	}
+
+	for _, script := range scripts {
+		source, err := p.fetchText(ctx, p.siteURL+script)
+		if err != nil {
+			if ctx.Err() != nil {
+				return ""
+			}
+			continue
+		}
+		if endpoint := parseSearchEndpoint(source); endpoint != "" {
+			return endpoint
+		}
+	}
+	return ""
+}
+
+// parseSearchEndpoint extracts the search endpoint, relative to /api/, from
+// the site's JavaScript.
+func parseSearchEndpoint(source string) string {
+	match := searchFetchRegex.FindStringSubmatch(source)
+	if match == nil {
+		return ""
+	}
+	endpoint := strings.Trim(match[1], "/")
+	for _, piece := range concatRegex.FindAllStringSubmatch(match[2], -1) {
+		endpoint += "/" + strings.Trim(piece[1], "/")
+	}
+	return strings.TrimSuffix(endpoint, "/")
+}
+
+// fetchPublishedEndpoint reads the search endpoint from the endpoint URL.
+// Returns an empty string if it's unavailable.
+func (p *Provider) fetchPublishedEndpoint(ctx context.Context) string {
+	if p.endpointURL == "" {
+		return ""
+	}
+	body, err := p.fetchText(ctx, p.endpointURL)
+	if err != nil {
+		return ""
+	}
+	endpoint := strings.Trim(strings.TrimSpace(body), "/")
+	if strings.ContainsAny(endpoint, " \n<>") {
+		return ""
+	}
+	return endpoint
+}
+
+// fetchText GETs a URL and returns its body.
+func (p *Provider) fetchText(ctx context.Context, url string) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("User-Agent", p.userAgent)
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("HTTP %d", resp.StatusCode)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxScriptSize))
+	if err != nil {
+		return "", err
+	}
+	return string(body), nil
+}
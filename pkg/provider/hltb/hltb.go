@@ -6,12 +6,13 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"io"
 	"net/http"
 	"regexp"
 	"strings"
+	"sync"
 	"time"
 
+	"github.com/josegonzalez/retro-metadata/pkg/provider"
 	retrometadata "github.com/josegonzalez/retro-metadata/pkg/retrometadata"
 )
 
@@ -26,67 +27,56 @@ var (
 	hltbTagRegex = regexp.MustCompile(`(?i)\(hltb-(\d+)\)`)
 
 	// ErrProviderDisabled is returned when the provider is disabled.
-	ErrProviderDisabled = fmt.Errorf("provider is disabled")
+	ErrProviderDisabled = retrometadata.ErrProviderDisabled
 )
 
 // Provider implements the HowLongToBeat metadata provider.
 type Provider struct {
-	config         *retrometadata.ProviderConfig
-	client         *http.Client
-	baseURL        string
-	userAgent      string
-	securityToken  string
-	searchEndpoint string
+	config        *retrometadata.ProviderConfig
+	client        *http.Client
+	baseURL       string
+	siteURL       string
+	endpointURL   string
+	userAgent     string
+	securityToken string
+	limiter       *provider.RateLimiter
+
+	endpointMu        sync.Mutex
+	searchEndpoint    string
+	endpointFetchedAt time.Time
 }
 
-// New creates a new HLTB provider.
+// New creates a new HLTB provider. HowLongToBeat has no API keys, so it
+// always runs keyless: requests are limited to provider.KeylessRateLimit per
+// second unless config.RateLimit is set.
 func New(config *retrometadata.ProviderConfig) *Provider {
-	timeout := time.Duration(config.Timeout) * time.Second
-	if timeout == 0 {
-		timeout = 30 * time.Second
+	endpointURL := githubHLTBAPIURL
+	if u, ok := config.Options["endpoint_url"].(string); ok {
+		endpointURL = u
 	}
 
 	return &Provider{
-		config:    config,
-		client:    &http.Client{Timeout: timeout},
-		baseURL:   "https://howlongtobeat.com/api",
-		userAgent: "retro-metadata/1.0",
+		config:      config,
+		client:      provider.NewHTTPClient(*config),
+		baseURL:     hltbSiteURL + "/api",
+		siteURL:     hltbSiteURL,
+		endpointURL: endpointURL,
+		userAgent:   "retro-metadata/1.0",
+		limiter:     provider.NewConfigRateLimiter(*config, provider.KeylessRateLimit),
 	}
 }
 
+// Keyless reports whether the provider runs without credentials, which it
+// always does.
+func (p *Provider) Keyless() bool {
+	return true
+}
+
 // Name returns the provider name.
 func (p *Provider) Name() string {
 	return "hltb"
 }
 
-func (p *Provider) fetchSearchEndpoint(ctx context.Context) string {
-	if p.searchEndpoint != "" {
-		return p.searchEndpoint
-	}
-
-	req, err := http.NewRequestWithContext(ctx, "GET", githubHLTBAPIURL, nil)
-	if err != nil {
-		p.searchEndpoint = defaultSearchEndpoint
-		return p.searchEndpoint
-	}
-
-	resp, err := p.client.Do(req)
-	if err != nil {
-		p.searchEndpoint = defaultSearchEndpoint
-		return p.searchEndpoint
-	}
-	defer resp.Body.Close()
-
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		p.searchEndpoint = defaultSearchEndpoint
-		return p.searchEndpoint
-	}
-
-	p.searchEndpoint = strings.TrimSpace(string(body))
-	return p.searchEndpoint
-}
-
 func (p *Provider) fetchSecurityToken(ctx context.Context) string {
 	if p.securityToken != "" {
 		return p.securityToken
@@ -118,11 +108,24 @@ func (p *Provider) fetchSecurityToken(ctx context.Context) string {
 }
 
 func (p *Provider) request(ctx context.Context, endpoint string, data map[string]interface{}) (map[string]interface{}, error) {
-	// Use dynamic search endpoint if this is a search request
-	if endpoint == "search" {
-		endpoint = p.fetchSearchEndpoint(ctx)
+	if endpoint != "search" {
+		return p.post(ctx, endpoint, data)
 	}
 
+	// Use the dynamic search endpoint. HLTB moves it with site updates, so
+	// a 404 means the cached one is stale and it's discovered again.
+	result, err := p.post(ctx, p.fetchSearchEndpoint(ctx), data)
+	if retrometadata.HTTPStatus(err) == http.StatusNotFound {
+		p.resetSearchEndpoint()
+		result, err = p.post(ctx, p.fetchSearchEndpoint(ctx), data)
+	}
+	return result, err
+}
+
+func (p *Provider) post(ctx context.Context, endpoint string, data map[string]interface{}) (map[string]interface{}, error) {
+	if err := p.limiter.Wait(ctx); err != nil {
+		return nil, err
+	}
 	url := p.baseURL + "/" + endpoint
 
 	jsonData, err := json.Marshal(data)
@@ -147,12 +150,12 @@ func (p *Provider) request(ctx context.Context, endpoint string, data map[string
 
 	resp, err := p.client.Do(req)
 	if err != nil {
-		return nil, &retrometadata.ConnectionError{Provider: p.Name(), Details: err.Error()}
+		return nil, retrometadata.RequestError(ctx, p.Name(), err)
 	}
 	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		return nil, &retrometadata.ConnectionError{Provider: p.Name(), Details: fmt.Sprintf("HTTP %d", resp.StatusCode)}
+	if err := retrometadata.HTTPError(p.Name(), resp); err != nil {
+		return nil, err
 	}
 
 	var result map[string]interface{}
@@ -322,7 +325,7 @@ func (p *Provider) Identify(ctx context.Context, filename string, opts retrometa
 		names = append(names, name)
 	}
 
-	bestMatch, score := findBestMatch(searchTerm, names)
+	bestMatch, score := provider.FindBestMatch(ctx, p.Name(), searchTerm, names, provider.MatchOptions(*p.config, 0.6))
 	if bestMatch == "" {
 		return nil, nil
 	}
@@ -477,42 +480,3 @@ func cleanFilename(filename string) string {
 	name = regexp.MustCompile(`\s*[\(\[][^\)\]]*[\)\]]`).ReplaceAllString(name, "")
 	return strings.TrimSpace(name)
 }
-
-func findBestMatch(query string, candidates []string) (string, float64) {
-	if len(candidates) == 0 {
-		return "", 0
-	}
-
-	queryLower := strings.ToLower(query)
-	bestMatch := ""
-	bestScore := 0.0
-
-	for _, candidate := range candidates {
-		candidateLower := strings.ToLower(candidate)
-
-		if candidateLower == queryLower {
-			return candidate, 1.0
-		}
-
-		if strings.Contains(candidateLower, queryLower) || strings.Contains(queryLower, candidateLower) {
-			score := float64(len(queryLower)) / float64(max(len(queryLower), len(candidateLower)))
-			if score > bestScore {
-				bestScore = score
-				bestMatch = candidate
-			}
-		}
-	}
-
-	if bestScore >= 0.6 {
-		return bestMatch, bestScore
-	}
-
-	return candidates[0], 0.5
-}
-
-func max(a, b int) int {
-	if a > b {
-		return a
-	}
-	return b
-}
@@ -0,0 +1,104 @@
+package hltb
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/josegonzalez/retro-metadata/pkg/retrometadata"
+)
+
+func TestParseSearchEndpoint(t *testing.T) {
+	tests := []struct {
+		source string
+		want   string
+	}{
+		{`e=await fetch("/api/search/".concat("4b4cbe57").concat("0602c886"),{method:"POST",headers:t,body:n})`, "search/4b4cbe57/0602c886"},
+		{`let r=await fetch("/api/seek/7d2e1f",{method:"POST",body:JSON.stringify(n)})`, "seek/7d2e1f"},
+		{`fetch("/api/user/1/stats",{method:"GET"})`, ""},
+		{`console.log("no requests here")`, ""},
+	}
+	for _, tt := range tests {
+		if got := parseSearchEndpoint(tt.source); got != tt.want {
+			t.Errorf("parseSearchEndpoint(%q) = %q, want %q", tt.source, got, tt.want)
+		}
+	}
+}
+
+func TestSearchEndpointDiscovery(t *testing.T) {
+	var siteUp, publishedUp atomic.Bool
+	var endpoint atomic.Value
+	endpoint.Store("find/abc123")
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/":
+			if !siteUp.Load() {
+				http.Error(w, "unavailable", http.StatusServiceUnavailable)
+				return
+			}
+			_, _ = w.Write([]byte(`<html><script src="/_next/static/chunks/main-1.js"></script><script src="/_next/static/chunks/pages/_app-2.js"></script></html>`))
+		case "/_next/static/chunks/main-1.js":
+			_, _ = w.Write([]byte(`fetch("/api/user/1",{method:"GET"})`))
+		case "/_next/static/chunks/pages/_app-2.js":
+			_, _ = w.Write([]byte(`fetch("/api/` + endpoint.Load().(string) + `",{method:"POST",body:t})`))
+		case "/hltb_api_url":
+			if !publishedUp.Load() {
+				http.NotFound(w, r)
+				return
+			}
+			_, _ = w.Write([]byte("published\n"))
+		case "/api/search/init":
+			_ = json.NewEncoder(w).Encode(map[string]any{"token": "token"})
+		case "/api/" + endpoint.Load().(string):
+			_ = json.NewEncoder(w).Encode(map[string]any{"data": []any{
+				map[string]any{"game_id": 10270, "game_name": "Super Metroid"},
+			}})
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	newProvider := func() *Provider {
+		p := New(&retrometadata.ProviderConfig{Enabled: true})
+		p.siteURL = server.URL
+		p.baseURL = server.URL + "/api"
+		p.endpointURL = server.URL + "/hltb_api_url"
+		return p
+	}
+	ctx := context.Background()
+
+	// The site's scripts are checked first
+	siteUp.Store(true)
+	publishedUp.Store(true)
+	p := newProvider()
+	if got := p.fetchSearchEndpoint(ctx); got != "find/abc123" {
+		t.Errorf("endpoint from site = %q, want find/abc123", got)
+	}
+
+	// Without the site, the published endpoint is used
+	siteUp.Store(false)
+	if got := newProvider().fetchSearchEndpoint(ctx); got != "published" {
+		t.Errorf("endpoint without site = %q, want published", got)
+	}
+
+	// Without either, the default is used
+	publishedUp.Store(false)
+	if got := newProvider().fetchSearchEndpoint(ctx); got != defaultSearchEndpoint {
+		t.Errorf("endpoint without sources = %q, want %q", got, defaultSearchEndpoint)
+	}
+
+	// A moved endpoint is rediscovered when searches start failing
+	siteUp.Store(true)
+	endpoint.Store("find/def456")
+	results, err := p.Search(ctx, "Super Metroid", retrometadata.SearchOptions{})
+	if err != nil || len(results) != 1 {
+		t.Fatalf("Search() after the endpoint moved = %v, %v", results, err)
+	}
+	if p.searchEndpoint != "find/def456" {
+		t.Errorf("cached endpoint = %q, want find/def456", p.searchEndpoint)
+	}
+}
@@ -0,0 +1,83 @@
+package igdb
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// externalSources maps the IGDB external game sources IDs are kept for to
+// the names they're kept under in ProviderIDs, or ProviderUIDs for sources
+// without numeric IDs.
+var externalSources = map[int]struct {
+	name    string
+	numeric bool
+}{
+	1:  {"steam", true},
+	5:  {"gog", true},
+	11: {"microsoft", false},
+	26: {"epic", false},
+	30: {"itchio", false},
+	36: {"playstation", false},
+}
+
+// externalFields are the fields fetched from the external_games endpoint.
+var externalFields = []string{"game", "uid", "external_game_source"}
+
+// externalIDs returns the store and service IDs of external games, by the
+// names in externalSources.
+func externalIDs(external []ExternalGame) (map[string]int, map[string]string) {
+	ids := make(map[string]int)
+	uids := make(map[string]string)
+	for _, e := range external {
+		source, ok := externalSources[e.ExternalGameSource]
+		if !ok || e.UID == "" {
+			continue
+		}
+		if !source.numeric {
+			uids[source.name] = e.UID
+		} else if id, err := strconv.Atoi(e.UID); err == nil {
+			ids[source.name] = id
+		}
+	}
+	return ids, uids
+}
+
+// LinkIDs returns the IGDB ID and the store IDs IGDB has for a game,
+// looking it up by its IGDB ID or by one of its numeric store IDs.
+func (p *Provider) LinkIDs(ctx context.Context, ids map[string]int, uids map[string]string) (map[string]int, map[string]string, error) {
+	if !p.IsEnabled() {
+		return nil, nil, nil
+	}
+
+	gameID := ids["igdb"]
+	if gameID == 0 {
+		var where []string
+		for source, s := range externalSources {
+			if id := ids[s.name]; s.numeric && id > 0 {
+				where = append(where, fmt.Sprintf(`(uid="%d" & external_game_source=%d)`, id, source))
+			}
+		}
+		if len(where) == 0 {
+			return nil, nil, nil
+		}
+
+		var found []ExternalGame
+		if _, err := p.requestPage(ctx, "external_games", "", externalFields, strings.Join(where, " | "), 1, 0, &found); err != nil {
+			return nil, nil, err
+		}
+		if len(found) == 0 || found[0].Game == 0 {
+			return nil, nil, nil
+		}
+		gameID = found[0].Game
+	}
+
+	var external []ExternalGame
+	if _, err := p.requestPage(ctx, "external_games", "", externalFields, fmt.Sprintf("game=%d", gameID), 50, 0, &external); err != nil {
+		return nil, nil, err
+	}
+	linked, linkedUIDs := externalIDs(external)
+	linked["igdb"] = gameID
+	return linked, linkedUIDs, nil
+}
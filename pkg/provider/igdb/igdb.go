@@ -15,7 +15,6 @@ import (
 	"time"
 
 	"github.com/josegonzalez/retro-metadata/pkg/cache"
-	"github.com/josegonzalez/retro-metadata/pkg/internal/matching"
 	"github.com/josegonzalez/retro-metadata/pkg/internal/normalization"
 	"github.com/josegonzalez/retro-metadata/pkg/platform"
 	"github.com/josegonzalez/retro-metadata/pkg/provider"
@@ -31,22 +30,40 @@ var gamesFields = []string{
 	"first_release_date", "cover.url", "screenshots.url", "platforms.id",
 	"platforms.name", "alternative_names.name", "genres.name", "franchise.name",
 	"franchises.name", "collections.name", "game_modes.name",
-	"involved_companies.company.name", "expansions.id", "expansions.slug",
+	"involved_companies.company.name", "involved_companies.developer",
+	"involved_companies.publisher", "involved_companies.porting",
+	"involved_companies.supporting", "expansions.id", "expansions.slug",
 	"expansions.name", "expansions.cover.url", "dlcs.id", "dlcs.name",
 	"dlcs.slug", "dlcs.cover.url", "remakes.id", "remakes.slug",
 	"remakes.name", "remakes.cover.url", "remasters.id", "remasters.slug",
 	"remasters.name", "remasters.cover.url", "ports.id", "ports.slug",
 	"ports.name", "ports.cover.url", "similar_games.id", "similar_games.slug",
-	"similar_games.name", "similar_games.cover.url", "age_ratings.rating_category",
-	"videos.video_id", "multiplayer_modes.campaigncoop", "multiplayer_modes.dropin",
+	"similar_games.name", "similar_games.cover.url", "age_ratings.organization.name",
+	"age_ratings.rating_category.rating", "age_ratings.rating_cover_url",
+	"themes.name", "keywords.name", "player_perspectives.name",
+	"language_supports.language.locale",
+	"game_localizations.name", "game_localizations.region.identifier",
+	"videos.name", "videos.video_id", "release_dates.date", "release_dates.y",
+	"release_dates.platform", "release_dates.release_region.region",
+	"multiplayer_modes.campaigncoop", "multiplayer_modes.dropin",
 	"multiplayer_modes.lancoop", "multiplayer_modes.offlinecoop",
 	"multiplayer_modes.offlinecoopmax", "multiplayer_modes.offlinemax",
 	"multiplayer_modes.onlinecoop", "multiplayer_modes.onlinecoopmax",
 	"multiplayer_modes.onlinemax", "multiplayer_modes.splitscreen",
 	"multiplayer_modes.splitscreenonline", "multiplayer_modes.platform.id",
-	"multiplayer_modes.platform.name",
+	"multiplayer_modes.platform.name", "external_games.uid",
+	"external_games.external_game_source",
 }
 
+const (
+	// tokenRefreshMargin is how long before expiry an OAuth token is replaced
+	tokenRefreshMargin = 5 * time.Minute
+
+	// tokenRecheckInterval is how often a token loaded from the cache is
+	// looked up again, since its exact expiry isn't cached with it
+	tokenRecheckInterval = time.Minute
+)
+
 // searchFields contains the fields to fetch for search results
 var searchFields = []string{
 	"id", "name", "slug", "cover.url", "platforms.name", "first_release_date",
@@ -79,7 +96,9 @@ type Provider struct {
 	userAgent     string
 	httpClient    *http.Client
 	oauthToken    string
+	oauthRefreshAt time.Time
 	oauthMu       sync.RWMutex
+	oauthRefreshMu sync.Mutex
 	paginationLimit int
 }
 
@@ -96,6 +115,14 @@ func NewProvider(config retrometadata.ProviderConfig, c cache.Cache) (*Provider,
 
 // NewProviderWithOptions creates a new IGDB provider instance with custom options.
 func NewProviderWithOptions(config retrometadata.ProviderConfig, c cache.Cache, opts Options) (*Provider, error) {
+	base, err := provider.NewBaseProvider("igdb", config, c)
+	if err != nil {
+		return nil, err
+	}
+	if err = provider.CheckProxy(config); err != nil {
+		return nil, err
+	}
+
 	baseURL := "https://api.igdb.com/v4"
 	if opts.BaseURL != "" {
 		baseURL = opts.BaseURL
@@ -107,11 +134,11 @@ func NewProviderWithOptions(config retrometadata.ProviderConfig, c cache.Cache,
 	}
 
 	return &Provider{
-		BaseProvider:    provider.NewBaseProvider("igdb", config, c),
+		BaseProvider:    base,
 		baseURL:         baseURL,
 		twitchURL:       tokenURL,
 		userAgent:       "retro-metadata/1.0",
-		httpClient:      &http.Client{Timeout: 30 * time.Second},
+		httpClient:      provider.NewHTTPClient(config),
 		paginationLimit: 200,
 	}, nil
 }
@@ -124,28 +151,69 @@ func (p *Provider) clientSecret() string {
 	return p.GetCredential("client_secret")
 }
 
-func (p *Provider) getOAuthToken(ctx context.Context) (string, error) {
-	// Check if we have a cached token
+// currentToken returns the in-memory token if it isn't due for a refresh.
+func (p *Provider) currentToken() (string, bool) {
 	p.oauthMu.RLock()
-	if p.oauthToken != "" {
-		token := p.oauthToken
-		p.oauthMu.RUnlock()
+	defer p.oauthMu.RUnlock()
+	if p.oauthToken == "" || !time.Now().Before(p.oauthRefreshAt) {
+		return "", false
+	}
+	return p.oauthToken, true
+}
+
+func (p *Provider) setToken(token string, refreshAt time.Time) {
+	p.oauthMu.Lock()
+	p.oauthToken = token
+	p.oauthRefreshAt = refreshAt
+	p.oauthMu.Unlock()
+}
+
+// getOAuthToken returns a Twitch app access token, requesting a new one
+// tokenRefreshMargin before the current one expires. Concurrent callers
+// share a single token request.
+func (p *Provider) getOAuthToken(ctx context.Context) (string, error) {
+	if token, ok := p.currentToken(); ok {
 		return token, nil
 	}
-	p.oauthMu.RUnlock()
 
-	// Check cache
-	cached, err := p.GetCached(ctx, "oauth_token")
-	if err == nil && cached != nil {
-		if token, ok := cached.(string); ok && token != "" {
-			p.oauthMu.Lock()
-			p.oauthToken = token
-			p.oauthMu.Unlock()
-			return token, nil
-		}
+	p.oauthRefreshMu.Lock()
+	defer p.oauthRefreshMu.Unlock()
+
+	// Another caller may have refreshed the token while we waited
+	if token, ok := p.currentToken(); ok {
+		return token, nil
+	}
+
+	// Tokens are cached until tokenRefreshMargin before they expire, so a
+	// cached token is valid for at least that long
+	if token, ok := provider.GetCachedTyped[string](ctx, p.BaseProvider, "oauth_token"); ok && token != "" {
+		p.setToken(token, time.Now().Add(tokenRecheckInterval))
+		return token, nil
+	}
+
+	token, expiresIn, err := p.requestOAuthToken(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	lifetime := time.Duration(expiresIn) * time.Second
+	if lifetime > 2*tokenRefreshMargin {
+		p.setToken(token, time.Now().Add(lifetime-tokenRefreshMargin))
+		_ = provider.SetCachedTyped(ctx, p.BaseProvider, "oauth_token", token, lifetime-tokenRefreshMargin)
+	} else {
+		p.setToken(token, time.Now().Add(lifetime/2))
+	}
+
+	return token, nil
+}
+
+// requestOAuthToken requests a new token from Twitch, returning it with its
+// lifetime in seconds.
+func (p *Provider) requestOAuthToken(ctx context.Context) (string, int, error) {
+	if p.clientID() == "" || p.clientSecret() == "" {
+		return "", 0, retrometadata.CredentialsRequired(p.Name(), "API requests")
 	}
 
-	// Request new token from Twitch
 	data := url.Values{}
 	data.Set("client_id", p.clientID())
 	data.Set("client_secret", p.clientSecret())
@@ -153,22 +221,22 @@ func (p *Provider) getOAuthToken(ctx context.Context) (string, error) {
 
 	req, err := http.NewRequestWithContext(ctx, "POST", p.twitchURL+"?"+data.Encode(), nil)
 	if err != nil {
-		return "", fmt.Errorf("failed to create OAuth request: %w", err)
+		return "", 0, fmt.Errorf("failed to create OAuth request: %w", err)
 	}
 
 	resp, err := p.httpClient.Do(req)
 	if err != nil {
-		return "", &retrometadata.ProviderError{Provider: p.Name(), Err: retrometadata.ErrProviderConnection}
+		return "", 0, retrometadata.RequestError(ctx, p.Name(), err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode == 400 {
-		return "", &retrometadata.ProviderError{Provider: p.Name(), Err: retrometadata.ErrProviderAuth}
+		return "", 0, &retrometadata.AuthError{Provider: p.Name(), StatusCode: resp.StatusCode, Details: "invalid client credentials"}
 	}
 
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return "", fmt.Errorf("failed to read OAuth response: %w", err)
+		return "", 0, fmt.Errorf("failed to read OAuth response: %w", err)
 	}
 
 	var tokenResp struct {
@@ -176,33 +244,41 @@ func (p *Provider) getOAuthToken(ctx context.Context) (string, error) {
 		ExpiresIn   int    `json:"expires_in"`
 	}
 	if err := json.Unmarshal(body, &tokenResp); err != nil {
-		return "", fmt.Errorf("failed to parse OAuth response: %w", err)
+		return "", 0, fmt.Errorf("failed to parse OAuth response: %w", err)
 	}
 
 	if tokenResp.AccessToken == "" {
-		return "", &retrometadata.ProviderError{Provider: p.Name(), Err: retrometadata.ErrProviderAuth}
+		return "", 0, &retrometadata.AuthError{Provider: p.Name(), Details: "no access token in OAuth response"}
 	}
 
-	// Cache the token
-	p.oauthMu.Lock()
-	p.oauthToken = tokenResp.AccessToken
-	p.oauthMu.Unlock()
-
-	// Store in cache with TTL
-	if tokenResp.ExpiresIn > 60 {
-		_ = p.SetCached(ctx, "oauth_token", tokenResp.AccessToken)
-	}
+	return tokenResp.AccessToken, tokenResp.ExpiresIn, nil
+}
 
-	return tokenResp.AccessToken, nil
+func (p *Provider) requestGames(ctx context.Context, searchTerm string, fields []string, where string, limit int) ([]Game, error) {
+	var games []Game
+	_, err := p.requestPage(ctx, "games", searchTerm, fields, where, limit, 0, &games)
+	return games, err
 }
 
-func (p *Provider) request(ctx context.Context, endpoint string, searchTerm string, fields []string, where string, limit int) ([]map[string]interface{}, error) {
-	token, err := p.getOAuthToken(ctx)
+// requestPage makes a query starting at offset and decodes the results into
+// out. It returns the total number of matches from the X-Count header, or -1
+// if it wasn't sent.
+func (p *Provider) requestPage(ctx context.Context, endpoint string, searchTerm string, fields []string, where string, limit, offset int, out any) (int, error) {
+	header, err := p.post(ctx, endpoint, buildQuery(searchTerm, fields, where, limit, offset), out)
 	if err != nil {
-		return nil, err
+		return -1, err
 	}
 
-	// Build query
+	total := -1
+	if count, err := strconv.Atoi(header.Get("X-Count")); err == nil {
+		total = count
+	}
+
+	return total, nil
+}
+
+// buildQuery builds an Apicalypse query body.
+func buildQuery(searchTerm string, fields []string, where string, limit, offset int) string {
 	var queryParts []string
 	if searchTerm != "" {
 		queryParts = append(queryParts, fmt.Sprintf(`search "%s";`, searchTerm))
@@ -216,8 +292,20 @@ func (p *Provider) request(ctx context.Context, endpoint string, searchTerm stri
 	if limit > 0 {
 		queryParts = append(queryParts, fmt.Sprintf("limit %d;", limit))
 	}
+	if offset > 0 {
+		queryParts = append(queryParts, fmt.Sprintf("offset %d;", offset))
+	}
+
+	return strings.Join(queryParts, " ")
+}
 
-	body := strings.Join(queryParts, " ")
+// post sends a query body to an endpoint and decodes the JSON response into
+// out, returning the response headers.
+func (p *Provider) post(ctx context.Context, endpoint string, body string, out any) (http.Header, error) {
+	token, err := p.getOAuthToken(ctx)
+	if err != nil {
+		return nil, err
+	}
 
 	req, err := http.NewRequestWithContext(ctx, "POST", p.baseURL+"/"+endpoint, strings.NewReader(body))
 	if err != nil {
@@ -231,20 +319,19 @@ func (p *Provider) request(ctx context.Context, endpoint string, searchTerm stri
 
 	resp, err := p.httpClient.Do(req)
 	if err != nil {
-		return nil, &retrometadata.ProviderError{Provider: p.Name(), Err: retrometadata.ErrProviderConnection}
+		return nil, retrometadata.RequestError(ctx, p.Name(), err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode == 401 {
-		// Token expired, clear and retry
-		p.oauthMu.Lock()
-		p.oauthToken = ""
-		p.oauthMu.Unlock()
-		return nil, &retrometadata.ProviderError{Provider: p.Name(), Err: retrometadata.ErrProviderAuth}
+		// Token was revoked or expired early, request a new one next time
+		p.setToken("", time.Time{})
+		_ = p.DeleteCached(ctx, "oauth_token")
+		return nil, retrometadata.HTTPError(p.Name(), resp)
 	}
 
-	if resp.StatusCode == 429 {
-		return nil, &retrometadata.ProviderError{Provider: p.Name(), Err: retrometadata.ErrProviderRateLimit}
+	if err := retrometadata.HTTPError(p.Name(), resp); err != nil {
+		return nil, err
 	}
 
 	respBody, err := io.ReadAll(resp.Body)
@@ -252,16 +339,25 @@ func (p *Provider) request(ctx context.Context, endpoint string, searchTerm stri
 		return nil, fmt.Errorf("failed to read response: %w", err)
 	}
 
-	var result []map[string]interface{}
-	if err := json.Unmarshal(respBody, &result); err != nil {
+	if err := json.Unmarshal(respBody, out); err != nil {
 		return nil, fmt.Errorf("failed to parse response: %w", err)
 	}
 
-	return result, nil
+	return resp.Header, nil
 }
 
 // Search searches for games by name.
 func (p *Provider) Search(ctx context.Context, query string, opts retrometadata.SearchOptions) ([]retrometadata.SearchResult, error) {
+	page, err := p.SearchPage(ctx, query, opts)
+	if err != nil || page == nil {
+		return nil, err
+	}
+	return page.Results, nil
+}
+
+// SearchPage searches for games by name, returning the page at opts.StartOffset()
+// with the total match count IGDB reports.
+func (p *Provider) SearchPage(ctx context.Context, query string, opts retrometadata.SearchOptions) (*retrometadata.SearchPage, error) {
 	if !p.IsEnabled() {
 		return nil, nil
 	}
@@ -276,45 +372,42 @@ func (p *Provider) Search(ctx context.Context, query string, opts retrometadata.
 		limit = 10
 	}
 
-	results, err := p.request(ctx, "games", query, searchFields, where, limit)
+	offset := opts.StartOffset()
+	var games []Game
+	total, err := p.requestPage(ctx, "games", query, searchFields, where, limit, offset, &games)
 	if err != nil {
 		return nil, err
 	}
 
+	return retrometadata.NewSearchPage(p.searchResults(games), offset, limit, total), nil
+}
+
+func (p *Provider) searchResults(games []Game) []retrometadata.SearchResult {
 	var searchResults []retrometadata.SearchResult
-	for _, game := range results {
+	for _, game := range games {
 		sr := retrometadata.SearchResult{
 			Provider:   p.Name(),
-			ProviderID: int(getFloat64(game, "id")),
-			Name:       getString(game, "name"),
-			Slug:       getString(game, "slug"),
+			ProviderID: game.ID,
+			Name:       game.Name,
+			Slug:       game.Slug,
 		}
 
-		// Extract cover URL
-		if cover, ok := game["cover"].(map[string]interface{}); ok {
-			coverURL := getString(cover, "url")
-			sr.CoverURL = p.normalizeCoverURL(coverURL, "t_cover_big")
+		if game.Cover != nil {
+			sr.CoverURL = p.normalizeCoverURL(game.Cover.URL, "t_cover_big")
 		}
 
-		// Extract platforms
-		if platforms, ok := game["platforms"].([]interface{}); ok {
-			for _, pl := range platforms {
-				if plMap, ok := pl.(map[string]interface{}); ok {
-					sr.Platforms = append(sr.Platforms, getString(plMap, "name"))
-				}
-			}
+		for _, pl := range game.Platforms {
+			sr.Platforms = append(sr.Platforms, pl.Name)
 		}
 
-		// Extract release year
-		if timestamp := getFloat64(game, "first_release_date"); timestamp > 0 {
-			year := time.Unix(int64(timestamp), 0).Year()
+		if game.FirstReleaseDate > 0 {
+			year := time.Unix(game.FirstReleaseDate, 0).Year()
 			sr.ReleaseYear = &year
 		}
 
 		searchResults = append(searchResults, sr)
 	}
-
-	return searchResults, nil
+	return searchResults
 }
 
 // GetByID gets game details by IGDB ID.
@@ -323,16 +416,16 @@ func (p *Provider) GetByID(ctx context.Context, gameID int) (*retrometadata.Game
 		return nil, nil
 	}
 
-	results, err := p.request(ctx, "games", "", gamesFields, fmt.Sprintf("id=%d", gameID), 1)
+	games, err := p.requestGames(ctx, "", gamesFields, fmt.Sprintf("id=%d", gameID), 1)
 	if err != nil {
 		return nil, err
 	}
 
-	if len(results) == 0 {
+	if len(games) == 0 {
 		return nil, nil
 	}
 
-	return p.buildGameResult(results[0]), nil
+	return p.buildGameResult(&games[0]), nil
 }
 
 // Identify identifies a game from a ROM filename.
@@ -374,39 +467,35 @@ func (p *Provider) Identify(ctx context.Context, filename string, opts retrometa
 	gameTypeFilter := fmt.Sprintf("& category=(%s)", strings.Join(catStrings, ","))
 	where := fmt.Sprintf("platforms=[%d] %s", *opts.PlatformID, gameTypeFilter)
 
-	results, err := p.request(ctx, "games", searchTerm, gamesFields, where, p.paginationLimit)
+	games, err := p.requestGames(ctx, searchTerm, gamesFields, where, p.paginationLimit)
 	if err != nil {
 		return nil, err
 	}
 
-	if len(results) == 0 {
+	if len(games) == 0 {
 		// Try without game type filter
 		where = fmt.Sprintf("platforms=[%d]", *opts.PlatformID)
-		results, err = p.request(ctx, "games", searchTerm, gamesFields, where, p.paginationLimit)
+		games, err = p.requestGames(ctx, searchTerm, gamesFields, where, p.paginationLimit)
 		if err != nil {
 			return nil, err
 		}
 	}
 
-	if len(results) == 0 {
+	if len(games) == 0 {
 		return nil, nil
 	}
 
 	// Find best match
-	gamesByName := make(map[string]map[string]interface{})
+	gamesByName := make(map[string]*Game)
 	var names []string
-	for _, g := range results {
-		name := getString(g, "name")
-		if name != "" {
-			gamesByName[name] = g
+	for i := range games {
+		if name := games[i].Name; name != "" {
+			gamesByName[name] = &games[i]
 			names = append(names, name)
 		}
 	}
 
-	bestMatch, score := matching.FindBestMatch(searchTerm, names, matching.FindBestMatchOptions{
-		MinSimilarityScore: matching.DefaultMinSimilarity,
-		Normalize:          true,
-	})
+	bestMatch, score := p.FindBestMatchContext(ctx, searchTerm, names)
 
 	if bestMatch != "" {
 		if game, ok := gamesByName[bestMatch]; ok {
@@ -425,187 +514,240 @@ func (p *Provider) Heartbeat(ctx context.Context) error {
 	return err
 }
 
-func (p *Provider) buildGameResult(game map[string]interface{}) *retrometadata.GameResult {
-	providerID := int(getFloat64(game, "id"))
+func (p *Provider) buildGameResult(game *Game) *retrometadata.GameResult {
+	providerID := game.ID
+	raw := game.RawMap()
 	result := &retrometadata.GameResult{
 		Provider:    p.Name(),
 		ProviderID:  &providerID,
 		ProviderIDs: map[string]int{"igdb": providerID},
-		Name:        getString(game, "name"),
-		Slug:        getString(game, "slug"),
-		Summary:     getString(game, "summary"),
-		RawResponse: game,
+		Name:        game.Name,
+		Slug:        game.Slug,
+		Summary:     game.Summary,
+		Language:    retrometadata.LanguageEnglish,
+		RawResponse: raw,
 	}
 
-	// Extract cover URL
-	if cover, ok := game["cover"].(map[string]interface{}); ok {
-		coverURL := getString(cover, "url")
-		result.Artwork.CoverURL = p.normalizeCoverURL(coverURL, "t_1080p")
+	if game.Cover != nil {
+		result.Artwork.CoverURL = p.normalizeCoverURL(game.Cover.URL, "t_1080p")
+	}
+	for _, s := range game.Screenshots {
+		result.Artwork.ScreenshotURLs = append(result.Artwork.ScreenshotURLs, p.normalizeCoverURL(s.URL, "t_720p"))
 	}
 
-	// Extract screenshots
-	if screenshots, ok := game["screenshots"].([]interface{}); ok {
-		for _, s := range screenshots {
-			if sMap, ok := s.(map[string]interface{}); ok {
-				ssURL := getString(sMap, "url")
-				result.Artwork.ScreenshotURLs = append(result.Artwork.ScreenshotURLs, p.normalizeCoverURL(ssURL, "t_720p"))
-			}
-		}
+	// Store IDs let other providers find the game without a search
+	ids, uids := externalIDs(game.ExternalGames)
+	for name, id := range ids {
+		result.ProviderIDs[name] = id
+	}
+	if len(uids) > 0 {
+		result.ProviderUIDs = uids
 	}
 
-	// Extract metadata
 	result.Metadata = p.extractMetadata(game)
+	result.Metadata.RawData = raw
+
+	// IGDB names and summaries are in English; localized names replace the
+	// name, which is kept as an alternative name
+	if name, language, ok := localizedName(game.GameLocalizations, p.Languages()); ok && name != game.Name {
+		result.Name = name
+		result.Language = language
+		result.Metadata.AlternativeNames = append([]string{game.Name}, result.Metadata.AlternativeNames...)
+	}
 
 	return result
 }
 
-func (p *Provider) extractMetadata(game map[string]interface{}) retrometadata.GameMetadata {
-	metadata := retrometadata.GameMetadata{
-		RawData: game,
-	}
+func (p *Provider) extractMetadata(game *Game) retrometadata.GameMetadata {
+	var metadata retrometadata.GameMetadata
 
-	// Total rating
-	if rating := getFloat64(game, "total_rating"); rating > 0 {
+	if game.TotalRating > 0 {
+		rating := game.TotalRating
 		metadata.TotalRating = &rating
 	}
-
-	// Aggregated rating
-	if rating := getFloat64(game, "aggregated_rating"); rating > 0 {
+	if game.AggregatedRating > 0 {
+		rating := game.AggregatedRating
 		metadata.AggregatedRating = &rating
 	}
-
-	// First release date
-	if timestamp := getFloat64(game, "first_release_date"); timestamp > 0 {
-		ts := int64(timestamp)
+	if game.FirstReleaseDate > 0 {
+		ts := game.FirstReleaseDate
 		metadata.FirstReleaseDate = &ts
 	}
+	metadata.Releases = releases(game.ReleaseDates)
 
-	// Genres
-	if genres, ok := game["genres"].([]interface{}); ok {
-		for _, g := range genres {
-			if gMap, ok := g.(map[string]interface{}); ok {
-				if name := getString(gMap, "name"); name != "" {
-					metadata.Genres = append(metadata.Genres, name)
-				}
-			}
-		}
+	metadata.Genres = names(game.Genres)
+	if game.Franchise != nil && game.Franchise.Name != "" {
+		metadata.Franchises = append(metadata.Franchises, game.Franchise.Name)
 	}
+	metadata.Franchises = append(metadata.Franchises, names(game.Franchises)...)
+	metadata.AlternativeNames = names(game.AlternativeNames)
+	metadata.Collections = names(game.Collections)
+	metadata.GameModes = names(game.GameModes)
+	metadata.Themes = names(game.Themes)
+	metadata.Keywords = names(game.Keywords)
+	metadata.PlayerPerspectives = names(game.PlayerPerspectives)
+	metadata.Languages = languages(game.LanguageSupports)
 
-	// Franchises
-	if franchise, ok := game["franchise"].(map[string]interface{}); ok {
-		if name := getString(franchise, "name"); name != "" {
-			metadata.Franchises = append(metadata.Franchises, name)
+	for _, ar := range game.AgeRatings {
+		if rating, ok := ageRating(ar); ok {
+			metadata.AgeRatings = append(metadata.AgeRatings, rating)
 		}
 	}
-	if franchises, ok := game["franchises"].([]interface{}); ok {
-		for _, f := range franchises {
-			if fMap, ok := f.(map[string]interface{}); ok {
-				if name := getString(fMap, "name"); name != "" {
-					metadata.Franchises = append(metadata.Franchises, name)
-				}
-			}
+
+	for _, ic := range game.InvolvedCompanies {
+		if ic.Company != nil {
+			metadata.AddCompany(retrometadata.Company{
+				Name:       ic.Company.Name,
+				Developer:  ic.Developer,
+				Publisher:  ic.Publisher,
+				Porting:    ic.Porting,
+				Supporting: ic.Supporting,
+			})
 		}
 	}
 
-	// Alternative names
-	if altNames, ok := game["alternative_names"].([]interface{}); ok {
-		for _, n := range altNames {
-			if nMap, ok := n.(map[string]interface{}); ok {
-				if name := getString(nMap, "name"); name != "" {
-					metadata.AlternativeNames = append(metadata.AlternativeNames, name)
-				}
-			}
-		}
+	for _, pl := range game.Platforms {
+		metadata.Platforms = append(metadata.Platforms, toPlatform(pl))
 	}
 
-	// Collections
-	if collections, ok := game["collections"].([]interface{}); ok {
-		for _, c := range collections {
-			if cMap, ok := c.(map[string]interface{}); ok {
-				if name := getString(cMap, "name"); name != "" {
-					metadata.Collections = append(metadata.Collections, name)
-				}
-			}
-		}
+	metadata.MultiplayerModes, metadata.PlayerCount = multiplayerModes(game.MultiplayerModes)
+
+	// Videos (YouTube)
+	if len(game.Videos) > 0 {
+		metadata.YouTubeVideoID = game.Videos[0].VideoID
 	}
+	metadata.Videos = p.videos(game.Videos)
 
-	// Companies
-	if companies, ok := game["involved_companies"].([]interface{}); ok {
-		for _, ic := range companies {
-			if icMap, ok := ic.(map[string]interface{}); ok {
-				if company, ok := icMap["company"].(map[string]interface{}); ok {
-					if name := getString(company, "name"); name != "" {
-						metadata.Companies = append(metadata.Companies, name)
-					}
-				}
-			}
-		}
+	// Related games
+	metadata.Expansions = p.relatedGames(game.Expansions, retrometadata.RelationExpansion)
+	metadata.DLCs = p.relatedGames(game.DLCs, retrometadata.RelationDLC)
+	metadata.Remasters = p.relatedGames(game.Remasters, retrometadata.RelationRemaster)
+	metadata.Remakes = p.relatedGames(game.Remakes, retrometadata.RelationRemake)
+	metadata.Ports = p.relatedGames(game.Ports, retrometadata.RelationPort)
+	metadata.SimilarGames = p.relatedGames(game.SimilarGames, retrometadata.RelationSimilar)
+
+	return metadata
+}
+
+// toPlatform converts an IGDB platform, filling in the universal slug when
+// the platform is known.
+func toPlatform(pl Platform) retrometadata.Platform {
+	return retrometadata.Platform{
+		Slug:        string(platform.SlugFromIGDBID(pl.ID)),
+		Name:        pl.Name,
+		ProviderIDs: map[string]int{"igdb": pl.ID},
 	}
+}
 
-	// Game modes
-	if modes, ok := game["game_modes"].([]interface{}); ok {
-		for _, m := range modes {
-			if mMap, ok := m.(map[string]interface{}); ok {
-				if name := getString(mMap, "name"); name != "" {
-					metadata.GameModes = append(metadata.GameModes, name)
-				}
-			}
+// multiplayerModes converts IGDB multiplayer modes and returns the maximum
+// player count across every mode and platform, or "" if IGDB has none.
+func multiplayerModes(modes []MultiplayerMode) ([]retrometadata.MultiplayerMode, string) {
+	var result []retrometadata.MultiplayerMode
+	maxPlayers := 0
+	for _, mode := range modes {
+		mm := retrometadata.MultiplayerMode{
+			CampaignCoop:      mode.CampaignCoop,
+			DropIn:            mode.DropIn,
+			LANCoop:           mode.LANCoop,
+			OfflineCoop:       mode.OfflineCoop,
+			OfflineCoopMax:    mode.OfflineCoopMax,
+			OfflineMax:        mode.OfflineMax,
+			OnlineCoop:        mode.OnlineCoop,
+			OnlineCoopMax:     mode.OnlineCoopMax,
+			OnlineMax:         mode.OnlineMax,
+			SplitScreen:       mode.SplitScreen,
+			SplitScreenOnline: mode.SplitScreenOnline,
+		}
+		if mode.Platform != nil {
+			pl := toPlatform(*mode.Platform)
+			mm.Platform = &pl
 		}
+		result = append(result, mm)
+		maxPlayers = max(maxPlayers, mode.OfflineMax, mode.OfflineCoopMax, mode.OnlineMax, mode.OnlineCoopMax)
 	}
 
-	// Platforms
-	if platforms, ok := game["platforms"].([]interface{}); ok {
-		for _, pl := range platforms {
-			if plMap, ok := pl.(map[string]interface{}); ok {
-				platform := retrometadata.Platform{
-					Name:        getString(plMap, "name"),
-					ProviderIDs: map[string]int{"igdb": int(getFloat64(plMap, "id"))},
-				}
-				metadata.Platforms = append(metadata.Platforms, platform)
-			}
-		}
+	if maxPlayers == 0 {
+		return result, ""
 	}
+	return result, strconv.Itoa(maxPlayers)
+}
 
-	// Videos (YouTube)
-	if videos, ok := game["videos"].([]interface{}); ok {
-		if len(videos) > 0 {
-			if vMap, ok := videos[0].(map[string]interface{}); ok {
-				if videoID := getString(vMap, "video_id"); videoID != "" {
-					metadata.YouTubeVideoID = videoID
-				}
-			}
+// releaseRegions maps IGDB release regions to regions. Regions without one,
+// like New Zealand, are RegionUnknown.
+var releaseRegions = map[string]retrometadata.Region{
+	"europe":        retrometadata.RegionEurope,
+	"north_america": retrometadata.RegionUSA,
+	"australia":     retrometadata.RegionAustralia,
+	"japan":         retrometadata.RegionJapan,
+	"china":         retrometadata.RegionChina,
+	"asia":          retrometadata.RegionAsia,
+	"worldwide":     retrometadata.RegionWorld,
+	"korea":         retrometadata.RegionKorea,
+	"brazil":        retrometadata.RegionBrazil,
+}
+
+// releases converts IGDB release dates. Dates without a year aren't
+// released yet and are left out.
+func releases(dates []ReleaseDate) []retrometadata.Release {
+	var result []retrometadata.Release
+	for _, d := range dates {
+		release := retrometadata.Release{
+			Date:         d.Date,
+			Year:         d.Y,
+			PlatformSlug: string(platform.SlugFromIGDBID(d.Platform)),
+		}
+		if d.Date > 0 {
+			release.Year = time.Unix(d.Date, 0).UTC().Year()
+		}
+		if d.ReleaseRegion != nil {
+			release.Region = releaseRegions[d.ReleaseRegion.Region]
+		}
+		if release.Year > 0 {
+			result = append(result, release)
 		}
 	}
+	return result
+}
 
-	// Related games
-	metadata.Expansions = p.extractRelatedGames(game, "expansions", "expansion")
-	metadata.DLCs = p.extractRelatedGames(game, "dlcs", "dlc")
-	metadata.Remasters = p.extractRelatedGames(game, "remasters", "remaster")
-	metadata.Remakes = p.extractRelatedGames(game, "remakes", "remake")
-	metadata.Ports = p.extractRelatedGames(game, "ports", "port")
-	metadata.SimilarGames = p.extractRelatedGames(game, "similar_games", "similar")
-
-	return metadata
+// videos converts IGDB videos, trailers first. IGDB names videos rather
+// than typing them, e.g. "Trailer" or "Gameplay video".
+func (p *Provider) videos(videos []Video) []retrometadata.Video {
+	var trailers, gameplay []retrometadata.Video
+	for _, video := range videos {
+		if video.VideoID == "" {
+			continue
+		}
+		v := retrometadata.Video{
+			Provider:  p.Name(),
+			Type:      retrometadata.VideoTrailer,
+			URL:       retrometadata.YouTubeURL(video.VideoID),
+			YouTubeID: video.VideoID,
+		}
+		name := strings.ToLower(video.Name)
+		if strings.Contains(name, "gameplay") && !strings.Contains(name, "trailer") {
+			v.Type = retrometadata.VideoGameplay
+			gameplay = append(gameplay, v)
+		} else {
+			trailers = append(trailers, v)
+		}
+	}
+	return append(trailers, gameplay...)
 }
 
-func (p *Provider) extractRelatedGames(game map[string]interface{}, key, relationType string) []retrometadata.RelatedGame {
+func (p *Provider) relatedGames(refs []GameRef, relationType string) []retrometadata.RelatedGame {
 	var related []retrometadata.RelatedGame
-	if items, ok := game[key].([]interface{}); ok {
-		for _, item := range items {
-			if itemMap, ok := item.(map[string]interface{}); ok {
-				rg := retrometadata.RelatedGame{
-					ID:           int(getFloat64(itemMap, "id")),
-					Name:         getString(itemMap, "name"),
-					Slug:         getString(itemMap, "slug"),
-					RelationType: relationType,
-					Provider:     p.Name(),
-				}
-				if cover, ok := itemMap["cover"].(map[string]interface{}); ok {
-					rg.CoverURL = p.normalizeCoverURL(getString(cover, "url"), "t_1080p")
-				}
-				related = append(related, rg)
-			}
+	for _, ref := range refs {
+		rg := retrometadata.RelatedGame{
+			ID:           ref.ID,
+			Name:         ref.Name,
+			Slug:         ref.Slug,
+			RelationType: relationType,
+			Provider:     p.Name(),
 		}
+		if ref.Cover != nil {
+			rg.CoverURL = p.normalizeCoverURL(ref.Cover.URL, "t_1080p")
+		}
+		related = append(related, rg)
 	}
 	return related
 }
@@ -648,22 +790,15 @@ func cleanFilename(filename string) string {
 	return strings.TrimSpace(name)
 }
 
-func getString(m map[string]interface{}, key string) string {
-	if v, ok := m[key]; ok {
-		if s, ok := v.(string); ok {
-			return s
-		}
-	}
-	return ""
-}
-
-func getFloat64(m map[string]interface{}, key string) float64 {
-	if v, ok := m[key]; ok {
-		if f, ok := v.(float64); ok {
-			return f
+// names returns the non-empty names of a list of entities.
+func names(entities []Named) []string {
+	var out []string
+	for _, e := range entities {
+		if e.Name != "" {
+			out = append(out, e.Name)
 		}
 	}
-	return 0
+	return out
 }
 
 // IGDBPlatformNames maps IGDB platform IDs to names
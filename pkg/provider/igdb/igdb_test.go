@@ -0,0 +1,228 @@
+package igdb
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/josegonzalez/retro-metadata/pkg/cache"
+	"github.com/josegonzalez/retro-metadata/pkg/retrometadata"
+)
+
+func TestOAuthTokenRefresh(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := requests.Add(1)
+		// Slow enough for concurrent callers to pile up behind the first request
+		time.Sleep(20 * time.Millisecond)
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"access_token": fmt.Sprintf("token-%d", n),
+			"expires_in":   3600,
+		})
+	}))
+	defer server.Close()
+
+	config := retrometadata.ProviderConfig{
+		Enabled:     true,
+		Credentials: map[string]string{"client_id": "id", "client_secret": "secret"},
+	}
+	p, err := NewProviderWithOptions(config, cache.NewMemoryCache(), Options{TokenURL: server.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	var wg sync.WaitGroup
+	for range 10 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if token, err := p.getOAuthToken(ctx); err != nil || token != "token-1" {
+				t.Errorf("getOAuthToken() = %q, %v; want token-1", token, err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if n := requests.Load(); n != 1 {
+		t.Errorf("concurrent callers made %d token requests, want 1", n)
+	}
+	if until := time.Until(p.oauthRefreshAt); until > time.Hour-tokenRefreshMargin || until < time.Hour-tokenRefreshMargin-time.Minute {
+		t.Errorf("token refresh scheduled in %v, want about %v", until, time.Hour-tokenRefreshMargin)
+	}
+
+	// Once the token is due for a refresh and gone from the cache, a new one is requested
+	p.setToken("token-1", time.Now().Add(-time.Second))
+	_ = p.DeleteCached(ctx, "oauth_token")
+	if token, _ := p.getOAuthToken(ctx); token != "token-2" {
+		t.Errorf("getOAuthToken() after expiry = %q, want token-2", token)
+	}
+
+	// A token that's due for a recheck but still cached is reused
+	p.setToken("token-2", time.Now().Add(-time.Second))
+	if token, _ := p.getOAuthToken(ctx); token != "token-2" || requests.Load() != 2 {
+		t.Errorf("getOAuthToken() = %q after %d requests, want the cached token-2", token, requests.Load())
+	}
+}
+
+func TestMultiplayerModes(t *testing.T) {
+	modes, playerCount := multiplayerModes([]MultiplayerMode{
+		{Platform: &Platform{ID: 19, Name: "Super Nintendo Entertainment System"}, OfflineMax: 2, OfflineCoop: true, OfflineCoopMax: 2},
+		{Platform: &Platform{ID: 130, Name: "Nintendo Switch"}, OnlineMax: 4, OnlineCoop: true},
+	})
+
+	if len(modes) != 2 {
+		t.Fatalf("got %d modes, want 2", len(modes))
+	}
+	if pl := modes[0].Platform; pl == nil || pl.Slug != "snes" || pl.ProviderIDs["igdb"] != 19 {
+		t.Errorf("first mode platform = %+v, want snes (igdb 19)", pl)
+	}
+	if !modes[0].OfflineCoop || modes[0].OfflineCoopMax != 2 || !modes[1].OnlineCoop || modes[1].OnlineMax != 4 {
+		t.Errorf("unexpected modes: %+v", modes)
+	}
+	if playerCount != "4" {
+		t.Errorf("player count = %q, want 4", playerCount)
+	}
+
+	if _, playerCount := multiplayerModes(nil); playerCount != "" {
+		t.Errorf("player count without modes = %q, want empty", playerCount)
+	}
+}
+
+func TestVideos(t *testing.T) {
+	p, _ := NewProvider(retrometadata.ProviderConfig{}, cache.NewMemoryCache())
+	videos := p.videos([]Video{
+		{Name: "Gameplay video", VideoID: "b"},
+		{Name: "Launch Trailer", VideoID: "a"},
+		{Name: "Trailer"},
+	})
+	if len(videos) != 2 || videos[0].YouTubeID != "a" || videos[0].Type != retrometadata.VideoTrailer || videos[1].Type != retrometadata.VideoGameplay {
+		t.Fatalf("videos() = %+v, want the trailer then the gameplay video", videos)
+	}
+	if videos[1].URL != "https://www.youtube.com/watch?v=b" || videos[1].Provider != "igdb" {
+		t.Errorf("videos()[1] = %+v", videos[1])
+	}
+}
+
+func TestReleases(t *testing.T) {
+	got := releases([]ReleaseDate{
+		{Date: 677548800, Y: 1991, Platform: 29, ReleaseRegion: &ReleaseRegion{Region: "japan"}},
+		{Y: 1991, Platform: 29, ReleaseRegion: &ReleaseRegion{Region: "new_zealand"}},
+		{Platform: 29, ReleaseRegion: &ReleaseRegion{Region: "europe"}},
+	})
+	if len(got) != 2 {
+		t.Fatalf("releases() = %+v, want the released two", got)
+	}
+	if got[0].Region != retrometadata.RegionJapan || got[0].Year != 1991 || got[0].PlatformSlug != "genesis" {
+		t.Errorf("releases()[0] = %+v", got[0])
+	}
+	if got[1].Region != retrometadata.RegionUnknown || got[1].Date != 0 {
+		t.Errorf("releases()[1] = %+v", got[1])
+	}
+}
+
+func TestLocalizedName(t *testing.T) {
+	game := &Game{
+		ID:   1,
+		Name: "Mother 3",
+		GameLocalizations: []GameLocalization{
+			{Name: "Mother 3 (EU)", Region: &Region{Identifier: "europe"}},
+			{Name: "マザー3", Region: &Region{Identifier: "ja-JP"}},
+		},
+	}
+
+	p, _ := NewProvider(retrometadata.ProviderConfig{Locale: "ja_JP"}, cache.NewMemoryCache())
+	result := p.buildGameResult(game)
+	if result.Name != "マザー3" || result.Language != retrometadata.LanguageJapanese {
+		t.Errorf("Name = %q (%s), want the Japanese localization", result.Name, result.Language)
+	}
+	if len(result.Metadata.AlternativeNames) == 0 || result.Metadata.AlternativeNames[0] != "Mother 3" {
+		t.Errorf("AlternativeNames = %v, want the original name kept", result.Metadata.AlternativeNames)
+	}
+
+	p, _ = NewProvider(retrometadata.ProviderConfig{Locale: "fr-FR"}, cache.NewMemoryCache())
+	if result := p.buildGameResult(game); result.Name != "Mother 3" || result.Language != retrometadata.LanguageEnglish {
+		t.Errorf("Name = %q (%s), want the English name without a French localization", result.Name, result.Language)
+	}
+}
+
+func TestLinkIDs(t *testing.T) {
+	var queries []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/token" {
+			_ = json.NewEncoder(w).Encode(map[string]any{"access_token": "token", "expires_in": 3600})
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		queries = append(queries, string(body))
+		if strings.Contains(string(body), "uid=") {
+			_ = json.NewEncoder(w).Encode([]ExternalGame{{Game: 1942}})
+			return
+		}
+		_ = json.NewEncoder(w).Encode([]ExternalGame{
+			{Game: 1942, UID: "292030", ExternalGameSource: 1},
+			{Game: 1942, UID: "1207664643", ExternalGameSource: 5},
+			{Game: 1942, UID: "bb7e6cbd", ExternalGameSource: 26},
+			{Game: 1942, UID: "ignored", ExternalGameSource: 10},
+		})
+	}))
+	defer server.Close()
+
+	config := retrometadata.ProviderConfig{
+		Enabled:     true,
+		Credentials: map[string]string{"client_id": "id", "client_secret": "secret"},
+	}
+	p, err := NewProviderWithOptions(config, cache.NewMemoryCache(), Options{BaseURL: server.URL, TokenURL: server.URL + "/token"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ids, uids, err := p.LinkIDs(context.Background(), map[string]int{"steam": 292030}, nil)
+	if err != nil {
+		t.Fatalf("LinkIDs() error = %v", err)
+	}
+	if ids["igdb"] != 1942 || ids["steam"] != 292030 || ids["gog"] != 1207664643 || uids["epic"] != "bb7e6cbd" || len(uids) != 1 {
+		t.Errorf("LinkIDs() = %v, %v", ids, uids)
+	}
+	if len(queries) != 2 || !strings.Contains(queries[0], `uid="292030" & external_game_source=1`) || !strings.Contains(queries[1], "game=1942") {
+		t.Errorf("queries = %q", queries)
+	}
+
+	if ids, uids, err := p.LinkIDs(context.Background(), map[string]int{"mobygames": 1}, nil); ids != nil || uids != nil || err != nil {
+		t.Errorf("LinkIDs() without known IDs = %v, %v, %v; want nothing", ids, uids, err)
+	}
+}
+
+func TestServerError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/token" {
+			_ = json.NewEncoder(w).Encode(map[string]any{"access_token": "token", "expires_in": 3600})
+			return
+		}
+		w.WriteHeader(http.StatusServiceUnavailable)
+		_, _ = w.Write([]byte("<html>Service Unavailable</html>"))
+	}))
+	defer server.Close()
+
+	config := retrometadata.ProviderConfig{
+		Enabled:     true,
+		Credentials: map[string]string{"client_id": "id", "client_secret": "secret"},
+	}
+	p, err := NewProviderWithOptions(config, cache.NewMemoryCache(), Options{BaseURL: server.URL, TokenURL: server.URL + "/token"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = p.Search(context.Background(), "Zelda", retrometadata.SearchOptions{})
+	if !retrometadata.Retryable(err) || retrometadata.HTTPStatus(err) != http.StatusServiceUnavailable {
+		t.Errorf("Search() error = %v, want a retryable 503", err)
+	}
+}
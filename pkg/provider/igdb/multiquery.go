@@ -0,0 +1,134 @@
+package igdb
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/josegonzalez/retro-metadata/pkg/retrometadata"
+)
+
+// MaxMultiqueries is the number of queries IGDB accepts in a single
+// /multiquery request.
+const MaxMultiqueries = 10
+
+// subquery is a single named query in a /multiquery request.
+type subquery struct {
+	name     string
+	endpoint string
+	query    string
+}
+
+// multiqueryResult is the response for a single named subquery.
+type multiqueryResult struct {
+	Name   string `json:"name"`
+	Result []Game `json:"result"`
+}
+
+// multiquery sends up to MaxMultiqueries game queries in one request and
+// returns the games for each query by name.
+func (p *Provider) multiquery(ctx context.Context, queries []subquery) (map[string][]Game, error) {
+	if len(queries) > MaxMultiqueries {
+		return nil, fmt.Errorf("igdb multiquery accepts at most %d queries, got %d", MaxMultiqueries, len(queries))
+	}
+
+	var body strings.Builder
+	for _, q := range queries {
+		fmt.Fprintf(&body, "query %s %q { %s };\n", q.endpoint, q.name, q.query)
+	}
+
+	var results []multiqueryResult
+	if _, err := p.post(ctx, "multiquery", body.String(), &results); err != nil {
+		return nil, err
+	}
+
+	games := make(map[string][]Game, len(results))
+	for _, r := range results {
+		games[r.Name] = r.Result
+	}
+	return games, nil
+}
+
+// BatchSearch searches for several games at once, packing up to
+// MaxMultiqueries searches into each API call. Results are returned in the
+// same order as queries; opts applies to every search.
+func (p *Provider) BatchSearch(ctx context.Context, queries []string, opts retrometadata.SearchOptions) ([][]retrometadata.SearchResult, error) {
+	if !p.IsEnabled() {
+		return nil, nil
+	}
+
+	var where string
+	if opts.PlatformID != nil {
+		where = fmt.Sprintf("platforms=[%d]", *opts.PlatformID)
+	}
+
+	limit := opts.Limit
+	if limit == 0 {
+		limit = 10
+	}
+
+	results := make([][]retrometadata.SearchResult, len(queries))
+	for start := 0; start < len(queries); start += MaxMultiqueries {
+		end := min(start+MaxMultiqueries, len(queries))
+
+		batch := make([]subquery, 0, end-start)
+		for i := start; i < end; i++ {
+			batch = append(batch, subquery{
+				name:     strconv.Itoa(i),
+				endpoint: "games",
+				query:    buildQuery(queries[i], searchFields, where, limit, opts.StartOffset()),
+			})
+		}
+
+		games, err := p.multiquery(ctx, batch)
+		if err != nil {
+			return nil, err
+		}
+		for i := start; i < end; i++ {
+			results[i] = p.searchResults(games[strconv.Itoa(i)])
+		}
+	}
+
+	return results, nil
+}
+
+// BatchGetByID gets the details of several games, fetching up to the
+// provider's pagination limit per query and packing up to MaxMultiqueries
+// queries into each API call. Games IGDB doesn't know are left out of the
+// returned map.
+func (p *Provider) BatchGetByID(ctx context.Context, gameIDs []int) (map[int]*retrometadata.GameResult, error) {
+	if !p.IsEnabled() {
+		return nil, nil
+	}
+
+	var batch []subquery
+	for start := 0; start < len(gameIDs); start += p.paginationLimit {
+		end := min(start+p.paginationLimit, len(gameIDs))
+
+		ids := make([]string, 0, end-start)
+		for _, id := range gameIDs[start:end] {
+			ids = append(ids, strconv.Itoa(id))
+		}
+		batch = append(batch, subquery{
+			name:     strconv.Itoa(len(batch)),
+			endpoint: "games",
+			query:    buildQuery("", gamesFields, fmt.Sprintf("id=(%s)", strings.Join(ids, ",")), end-start, 0),
+		})
+	}
+
+	results := make(map[int]*retrometadata.GameResult, len(gameIDs))
+	for start := 0; start < len(batch); start += MaxMultiqueries {
+		games, err := p.multiquery(ctx, batch[start:min(start+MaxMultiqueries, len(batch))])
+		if err != nil {
+			return nil, err
+		}
+		for _, list := range games {
+			for i := range list {
+				results[list[i].ID] = p.buildGameResult(&list[i])
+			}
+		}
+	}
+
+	return results, nil
+}
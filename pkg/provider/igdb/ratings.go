@@ -0,0 +1,115 @@
+package igdb
+
+import (
+	"strings"
+
+	"github.com/josegonzalez/retro-metadata/pkg/internal/normalization"
+	"github.com/josegonzalez/retro-metadata/pkg/retrometadata"
+)
+
+// AgeRatingOrganizations maps IGDB age rating organization IDs to names.
+var AgeRatingOrganizations = map[int]string{
+	1: "ESRB",
+	2: "PEGI",
+	3: "CERO",
+	4: "USK",
+	5: "GRAC",
+	6: "CLASS_IND",
+	7: "ACB",
+}
+
+// ratingNumbers maps the spelled-out ratings IGDB uses for PEGI and similar
+// age-based systems to their numbers.
+var ratingNumbers = map[string]string{
+	"three":    "3",
+	"seven":    "7",
+	"twelve":   "12",
+	"sixteen":  "16",
+	"eighteen": "18",
+}
+
+// ageRating converts an IGDB age rating to the ESRB/PEGI style used across
+// providers, e.g. {Category: "ESRB", Rating: "T"} or {Category: "PEGI",
+// Rating: "PEGI 12"}. Returns false if the rating has no value.
+func ageRating(ar AgeRating) (retrometadata.AgeRating, bool) {
+	if ar.RatingCategory == nil || ar.RatingCategory.Rating == "" {
+		return retrometadata.AgeRating{}, false
+	}
+
+	var organization string
+	if ar.Organization != nil {
+		organization = ar.Organization.Name
+		if organization == "" {
+			organization = AgeRatingOrganizations[ar.Organization.ID]
+		}
+	}
+
+	rating := strings.ReplaceAll(ar.RatingCategory.Rating, "_", " ")
+	if n, ok := ratingNumbers[strings.ToLower(rating)]; ok {
+		rating = n
+	}
+
+	switch organization {
+	case "ESRB":
+		if rating == "E10" {
+			rating = "E10+"
+		}
+	case "PEGI":
+		if !strings.HasPrefix(rating, "PEGI") {
+			rating = "PEGI " + rating
+		}
+	}
+
+	return retrometadata.AgeRating{
+		Rating:   rating,
+		Category: organization,
+		CoverURL: normalizeRatingCoverURL(ar.RatingCoverURL),
+	}, true
+}
+
+func normalizeRatingCoverURL(url string) string {
+	if url == "" {
+		return ""
+	}
+	return normalization.NormalizeCoverURL(url)
+}
+
+// localizedName returns the name of the localization in the highest
+// priority language other than English, which is the language of the
+// game's own name. Returns false if there's none.
+func localizedName(localizations []GameLocalization, priority []retrometadata.Language) (string, retrometadata.Language, bool) {
+	for _, preferred := range priority {
+		if preferred == retrometadata.LanguageEnglish {
+			break
+		}
+		for _, l := range localizations {
+			if l.Region == nil || l.Name == "" {
+				continue
+			}
+			if language, ok := retrometadata.ParseLocale(l.Region.Identifier); ok && language == preferred {
+				return l.Name, language, true
+			}
+		}
+	}
+	return "", "", false
+}
+
+// languages returns the distinct languages a game supports, in the order IGDB
+// lists them. Locales that don't map to a known language are skipped.
+func languages(supports []LanguageSupport) []retrometadata.Language {
+	var out []retrometadata.Language
+	seen := make(map[retrometadata.Language]bool)
+	for _, support := range supports {
+		if support.Language == nil {
+			continue
+		}
+		code, _, _ := strings.Cut(support.Language.Locale, "-")
+		language, ok := retrometadata.ParseLanguage(code)
+		if !ok || seen[language] {
+			continue
+		}
+		seen[language] = true
+		out = append(out, language)
+	}
+	return out
+}
@@ -0,0 +1,58 @@
+package igdb
+
+import (
+	"testing"
+
+	"github.com/josegonzalez/retro-metadata/pkg/retrometadata"
+)
+
+func TestAgeRating(t *testing.T) {
+	tests := []struct {
+		name  string
+		input AgeRating
+		want  retrometadata.AgeRating
+		ok    bool
+	}{
+		{
+			name: "esrb",
+			input: AgeRating{
+				Organization:   &Named{ID: 1, Name: "ESRB"},
+				RatingCategory: &AgeRatingCategory{Rating: "E10"},
+			},
+			want: retrometadata.AgeRating{Category: "ESRB", Rating: "E10+"},
+			ok:   true,
+		},
+		{
+			name: "pegi spelled out",
+			input: AgeRating{
+				Organization:   &Named{ID: 2, Name: "PEGI"},
+				RatingCategory: &AgeRatingCategory{Rating: "Twelve"},
+				RatingCoverURL: "//images.igdb.com/pegi12.png",
+			},
+			want: retrometadata.AgeRating{Category: "PEGI", Rating: "PEGI 12", CoverURL: "https://images.igdb.com/pegi12.png"},
+			ok:   true,
+		},
+		{
+			name: "organization without name",
+			input: AgeRating{
+				Organization:   &Named{ID: 3},
+				RatingCategory: &AgeRatingCategory{Rating: "CERO_A"},
+			},
+			want: retrometadata.AgeRating{Category: "CERO", Rating: "CERO A"},
+			ok:   true,
+		},
+		{
+			name:  "no rating",
+			input: AgeRating{Organization: &Named{ID: 1, Name: "ESRB"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := ageRating(tt.input)
+			if got != tt.want || ok != tt.ok {
+				t.Errorf("ageRating() = %+v, %v; want %+v, %v", got, ok, tt.want, tt.ok)
+			}
+		})
+	}
+}
@@ -0,0 +1,65 @@
+package igdb
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/josegonzalez/retro-metadata/pkg/retrometadata"
+)
+
+// seriesEndpoints are the endpoints of each series kind.
+var seriesEndpoints = map[retrometadata.SeriesKind]string{
+	retrometadata.SeriesFranchise:  "franchises",
+	retrometadata.SeriesCollection: "collections",
+}
+
+// seriesFields are the fields fetched for franchises and collections.
+var seriesFields = []string{"name", "games.name", "games.first_release_date", "games.platforms.name"}
+
+// series is a franchise or collection record.
+type series struct {
+	ID    int    `json:"id"`
+	Name  string `json:"name"`
+	Games []Game `json:"games"`
+}
+
+// GetSeries returns an IGDB franchise or collection, by ID or by its name,
+// ignoring case.
+func (p *Provider) GetSeries(ctx context.Context, kind retrometadata.SeriesKind, name string, id int) (*retrometadata.Series, error) {
+	endpoint, ok := seriesEndpoints[kind]
+	if !ok || !p.IsEnabled() {
+		return nil, nil
+	}
+
+	where := fmt.Sprintf("id = %d", id)
+	if id == 0 {
+		if name == "" {
+			return nil, nil
+		}
+		where = fmt.Sprintf(`name ~ "%s"`, strings.ReplaceAll(name, `"`, `\"`))
+	}
+
+	var found []series
+	if _, err := p.requestPage(ctx, endpoint, "", seriesFields, where, 1, 0, &found); err != nil {
+		return nil, err
+	}
+	if len(found) == 0 {
+		return nil, nil
+	}
+
+	result := &retrometadata.Series{
+		Name:        found[0].Name,
+		Kind:        kind,
+		ProviderIDs: map[string]int{p.Name(): found[0].ID},
+	}
+	for _, game := range p.searchResults(found[0].Games) {
+		result.Games = append(result.Games, retrometadata.SeriesGame{
+			Name:        game.Name,
+			ReleaseYear: game.ReleaseYear,
+			Platforms:   game.Platforms,
+			ProviderIDs: map[string]int{p.Name(): game.ProviderID},
+		})
+	}
+	return result, nil
+}
@@ -0,0 +1,66 @@
+package igdb
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/josegonzalez/retro-metadata/pkg/cache"
+	"github.com/josegonzalez/retro-metadata/pkg/retrometadata"
+)
+
+func TestGetSeries(t *testing.T) {
+	var paths, queries []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/token" {
+			_ = json.NewEncoder(w).Encode(map[string]any{"access_token": "token", "expires_in": 3600})
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		paths = append(paths, r.URL.Path)
+		queries = append(queries, string(body))
+		_ = json.NewEncoder(w).Encode([]map[string]any{{
+			"id":   596,
+			"name": "The Legend of Zelda",
+			"games": []map[string]any{
+				{"id": 1022, "name": "The Legend of Zelda", "first_release_date": 509328000, "platforms": []map[string]any{{"id": 18, "name": "Nintendo Entertainment System"}}},
+			},
+		}})
+	}))
+	defer server.Close()
+
+	config := retrometadata.ProviderConfig{
+		Enabled:     true,
+		Credentials: map[string]string{"client_id": "id", "client_secret": "secret"},
+	}
+	p, err := NewProviderWithOptions(config, cache.NewMemoryCache(), Options{BaseURL: server.URL, TokenURL: server.URL + "/token"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	series, err := p.GetSeries(ctx, retrometadata.SeriesFranchise, `the legend of "zelda"`, 0)
+	if err != nil {
+		t.Fatalf("GetSeries() error = %v", err)
+	}
+	if series.Name != "The Legend of Zelda" || series.ProviderIDs["igdb"] != 596 || len(series.Games) != 1 {
+		t.Fatalf("GetSeries() = %+v", series)
+	}
+	if game := series.Games[0]; game.ProviderIDs["igdb"] != 1022 || *game.ReleaseYear != 1986 || game.Platforms[0] != "Nintendo Entertainment System" {
+		t.Errorf("Games[0] = %+v", game)
+	}
+
+	if _, err := p.GetSeries(ctx, retrometadata.SeriesCollection, "", 106); err != nil {
+		t.Fatalf("GetSeries() error = %v", err)
+	}
+	if len(paths) != 2 || paths[0] != "/franchises" || paths[1] != "/collections" {
+		t.Errorf("paths = %q", paths)
+	}
+	if !strings.Contains(queries[0], `where name ~ "the legend of \"zelda\""`) || !strings.Contains(queries[1], "where id = 106") {
+		t.Errorf("queries = %q", queries)
+	}
+}
@@ -0,0 +1,192 @@
+package igdb
+
+import "encoding/json"
+
+// Game is a game record from the IGDB games endpoint. Only the fields
+// requested through gamesFields or searchFields are populated.
+type Game struct {
+	ID                 int                `json:"id"`
+	Name               string             `json:"name"`
+	Slug               string             `json:"slug"`
+	Summary            string             `json:"summary"`
+	TotalRating        float64            `json:"total_rating"`
+	AggregatedRating   float64            `json:"aggregated_rating"`
+	FirstReleaseDate   int64              `json:"first_release_date"`
+	Cover              *Image             `json:"cover"`
+	Screenshots        []Image            `json:"screenshots"`
+	Platforms          []Platform         `json:"platforms"`
+	AlternativeNames   []Named            `json:"alternative_names"`
+	Genres             []Named            `json:"genres"`
+	Franchise          *Named             `json:"franchise"`
+	Franchises         []Named            `json:"franchises"`
+	Collections        []Named            `json:"collections"`
+	GameModes          []Named            `json:"game_modes"`
+	Themes             []Named            `json:"themes"`
+	Keywords           []Named            `json:"keywords"`
+	PlayerPerspectives []Named            `json:"player_perspectives"`
+	LanguageSupports   []LanguageSupport  `json:"language_supports"`
+	InvolvedCompanies  []InvolvedCompany  `json:"involved_companies"`
+	Expansions         []GameRef          `json:"expansions"`
+	DLCs               []GameRef          `json:"dlcs"`
+	Remakes            []GameRef          `json:"remakes"`
+	Remasters          []GameRef          `json:"remasters"`
+	Ports              []GameRef          `json:"ports"`
+	SimilarGames       []GameRef          `json:"similar_games"`
+	AgeRatings         []AgeRating        `json:"age_ratings"`
+	Videos             []Video            `json:"videos"`
+	MultiplayerModes   []MultiplayerMode  `json:"multiplayer_modes"`
+	GameLocalizations  []GameLocalization `json:"game_localizations"`
+	ExternalGames      []ExternalGame     `json:"external_games"`
+	ReleaseDates       []ReleaseDate      `json:"release_dates"`
+
+	// raw is the undecoded record, kept for GameResult.RawResponse
+	raw json.RawMessage
+}
+
+// UnmarshalJSON decodes a game and keeps a copy of the raw record.
+func (g *Game) UnmarshalJSON(data []byte) error {
+	type plain Game
+	if err := json.Unmarshal(data, (*plain)(g)); err != nil {
+		return err
+	}
+	g.raw = append(json.RawMessage(nil), data...)
+	return nil
+}
+
+// RawMap decodes the raw record into a generic map. It returns nil if the
+// game wasn't decoded from JSON.
+func (g *Game) RawMap() map[string]any {
+	if len(g.raw) == 0 {
+		return nil
+	}
+	var m map[string]any
+	if err := json.Unmarshal(g.raw, &m); err != nil {
+		return nil
+	}
+	return m
+}
+
+// Image is a cover or screenshot.
+type Image struct {
+	ID  int    `json:"id"`
+	URL string `json:"url"`
+}
+
+// Platform is an IGDB platform.
+type Platform struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+}
+
+// Named is any IGDB entity that is only fetched for its name, like genres,
+// franchises, collections and game modes.
+type Named struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+}
+
+// InvolvedCompany links a company to a game.
+type InvolvedCompany struct {
+	Company    *Named `json:"company"`
+	Developer  bool   `json:"developer"`
+	Publisher  bool   `json:"publisher"`
+	Porting    bool   `json:"porting"`
+	Supporting bool   `json:"supporting"`
+}
+
+// GameRef is a related game, like an expansion, port or remake.
+type GameRef struct {
+	ID    int    `json:"id"`
+	Name  string `json:"name"`
+	Slug  string `json:"slug"`
+	Cover *Image `json:"cover"`
+}
+
+// AgeRating is an age rating from a rating organization like the ESRB or PEGI.
+type AgeRating struct {
+	ID             int                `json:"id"`
+	Organization   *Named             `json:"organization"`
+	RatingCategory *AgeRatingCategory `json:"rating_category"`
+	RatingCoverURL string             `json:"rating_cover_url"`
+}
+
+// AgeRatingCategory is a rating within an organization, like "T" or "Twelve".
+type AgeRatingCategory struct {
+	ID     int    `json:"id"`
+	Rating string `json:"rating"`
+}
+
+// LanguageSupport is a language a game supports for audio, subtitles or its interface.
+type LanguageSupport struct {
+	ID       int       `json:"id"`
+	Language *Language `json:"language"`
+}
+
+// Language is an IGDB language; Locale is a code like "en-US".
+type Language struct {
+	ID     int    `json:"id"`
+	Name   string `json:"name"`
+	Locale string `json:"locale"`
+}
+
+// GameLocalization is a game's name in a region, e.g. its Japanese title.
+type GameLocalization struct {
+	ID     int     `json:"id"`
+	Name   string  `json:"name"`
+	Region *Region `json:"region"`
+}
+
+// Region is a localization region; Identifier is a locale like "ja-JP" for
+// regions with their own language, or a name like "europe".
+type Region struct {
+	ID         int    `json:"id"`
+	Name       string `json:"name"`
+	Identifier string `json:"identifier"`
+}
+
+// Video is a game video hosted on YouTube.
+type Video struct {
+	ID      int    `json:"id"`
+	Name    string `json:"name"`
+	VideoID string `json:"video_id"`
+}
+
+// ExternalGame is a game's ID on a store or service, like its Steam app ID.
+type ExternalGame struct {
+	ID                 int    `json:"id"`
+	Game               int    `json:"game"`
+	UID                string `json:"uid"`
+	ExternalGameSource int    `json:"external_game_source"`
+}
+
+// MultiplayerMode describes the multiplayer support of a game on a platform.
+type MultiplayerMode struct {
+	ID                int       `json:"id"`
+	Platform          *Platform `json:"platform"`
+	CampaignCoop      bool      `json:"campaigncoop"`
+	DropIn            bool      `json:"dropin"`
+	LANCoop           bool      `json:"lancoop"`
+	OfflineCoop       bool      `json:"offlinecoop"`
+	OfflineCoopMax    int       `json:"offlinecoopmax"`
+	OfflineMax        int       `json:"offlinemax"`
+	OnlineCoop        bool      `json:"onlinecoop"`
+	OnlineCoopMax     int       `json:"onlinecoopmax"`
+	OnlineMax         int       `json:"onlinemax"`
+	SplitScreen       bool      `json:"splitscreen"`
+	SplitScreenOnline bool      `json:"splitscreenonline"`
+}
+
+// ReleaseDate is a game's release on a platform in a region. Y is set
+// without a full date.
+type ReleaseDate struct {
+	Date          int64          `json:"date"`
+	Y             int            `json:"y"`
+	Platform      int            `json:"platform"`
+	ReleaseRegion *ReleaseRegion `json:"release_region"`
+}
+
+// ReleaseRegion is a release region, e.g. "north_america".
+type ReleaseRegion struct {
+	ID     int    `json:"id"`
+	Region string `json:"region"`
+}
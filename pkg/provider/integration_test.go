@@ -1,15 +1,23 @@
 package provider_test
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
+	"io"
 	"net/http"
 	"net/http/httptest"
 	"os"
 	"path/filepath"
+	"reflect"
+	"regexp"
 	"strings"
+	"sync"
 	"testing"
 
+	"github.com/josegonzalez/retro-metadata/pkg/identify"
+	"github.com/josegonzalez/retro-metadata/pkg/provider/datfile"
 	"github.com/josegonzalez/retro-metadata/pkg/provider/igdb"
 	"github.com/josegonzalez/retro-metadata/pkg/provider/mobygames"
 	"github.com/josegonzalez/retro-metadata/pkg/retrometadata"
@@ -124,6 +132,69 @@ func TestIGDBSearchIntegration(t *testing.T) {
 	}
 }
 
+func TestIGDBSearchPageIntegration(t *testing.T) {
+	searchResponse := loadFixture(t, "igdb", "search_mario.json")
+
+	var query string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.Contains(r.URL.Path, "oauth2/token") {
+			w.Header().Set("Content-Type", "application/json")
+			_ = json.NewEncoder(w).Encode(map[string]any{
+				"access_token": "test_token",
+				"expires_in":   3600,
+				"token_type":   "bearer",
+			})
+			return
+		}
+
+		if strings.Contains(r.URL.Path, "/games") {
+			body, _ := io.ReadAll(r.Body)
+			query = string(body)
+			w.Header().Set("Content-Type", "application/json")
+			w.Header().Set("X-Count", "500")
+			_, _ = w.Write(searchResponse)
+			return
+		}
+
+		http.NotFound(w, r)
+	}))
+	defer server.Close()
+
+	config := retrometadata.ProviderConfig{
+		Enabled: true,
+		Credentials: map[string]string{
+			"client_id":     "test_client_id",
+			"client_secret": "test_client_secret",
+		},
+		Timeout: 30,
+	}
+
+	provider, err := igdb.NewProviderWithOptions(config, nil, igdb.Options{
+		BaseURL:  server.URL + "/v4",
+		TokenURL: server.URL + "/oauth2/token",
+	})
+	if err != nil {
+		t.Fatalf("Failed to create provider: %v", err)
+	}
+
+	page, err := provider.SearchPage(context.Background(), "Super Mario", retrometadata.SearchOptions{Limit: 10, Page: 3})
+	if err != nil {
+		t.Fatalf("SearchPage error: %v", err)
+	}
+
+	if !strings.Contains(query, "offset 20;") {
+		t.Errorf("Expected query with offset 20, got %q", query)
+	}
+	if page.Offset != 20 || page.Total != 500 || !page.HasMore {
+		t.Errorf("Unexpected page: offset=%d total=%d has_more=%v", page.Offset, page.Total, page.HasMore)
+	}
+
+	next := retrometadata.SearchOptions{Limit: 10, Cursor: page.Cursor}
+	if offset := next.StartOffset(); offset != 20+len(page.Results) {
+		t.Errorf("Expected cursor to continue at %d, got %d", 20+len(page.Results), offset)
+	}
+}
+
 func TestIGDBGetByIDIntegration(t *testing.T) {
 	gameResponse := loadFixture(t, "igdb", "game_1074.json")
 
@@ -194,6 +265,23 @@ func TestIGDBGetByIDIntegration(t *testing.T) {
 		t.Error("Expected companies, got none")
 	}
 
+	if !reflect.DeepEqual(result.Metadata.Themes, []string{"Fantasy"}) {
+		t.Errorf("Expected themes [Fantasy], got %v", result.Metadata.Themes)
+	}
+
+	if !reflect.DeepEqual(result.Metadata.PlayerPerspectives, []string{"Side view"}) {
+		t.Errorf("Expected player perspectives [Side view], got %v", result.Metadata.PlayerPerspectives)
+	}
+
+	wantLanguages := []retrometadata.Language{retrometadata.LanguageEnglish, retrometadata.LanguageJapanese}
+	if !reflect.DeepEqual(result.Metadata.Languages, wantLanguages) {
+		t.Errorf("Expected languages %v, got %v", wantLanguages, result.Metadata.Languages)
+	}
+
+	if result.RawResponse["name"] != "Super Mario World" {
+		t.Errorf("Expected raw response to keep the game record, got %v", result.RawResponse["name"])
+	}
+
 	// Verify artwork
 	if result.Artwork.CoverURL == "" {
 		t.Error("Expected cover URL, got empty string")
@@ -204,6 +292,81 @@ func TestIGDBGetByIDIntegration(t *testing.T) {
 	}
 }
 
+func TestIGDBBatchSearchIntegration(t *testing.T) {
+	searchResponse := loadFixture(t, "igdb", "search_mario.json")
+	queryName := regexp.MustCompile(`query games "([^"]+)"`)
+
+	var requests int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.Contains(r.URL.Path, "oauth2/token") {
+			w.Header().Set("Content-Type", "application/json")
+			_ = json.NewEncoder(w).Encode(map[string]any{
+				"access_token": "test_token",
+				"expires_in":   3600,
+				"token_type":   "bearer",
+			})
+			return
+		}
+
+		if strings.HasSuffix(r.URL.Path, "/multiquery") {
+			requests++
+			body, _ := io.ReadAll(r.Body)
+			var response []map[string]any
+			for _, match := range queryName.FindAllStringSubmatch(string(body), -1) {
+				response = append(response, map[string]any{
+					"name":   match[1],
+					"result": json.RawMessage(searchResponse),
+				})
+			}
+			w.Header().Set("Content-Type", "application/json")
+			_ = json.NewEncoder(w).Encode(response)
+			return
+		}
+
+		http.NotFound(w, r)
+	}))
+	defer server.Close()
+
+	config := retrometadata.ProviderConfig{
+		Enabled: true,
+		Credentials: map[string]string{
+			"client_id":     "test_client_id",
+			"client_secret": "test_client_secret",
+		},
+		Timeout: 30,
+	}
+
+	provider, err := igdb.NewProviderWithOptions(config, nil, igdb.Options{
+		BaseURL:  server.URL + "/v4",
+		TokenURL: server.URL + "/oauth2/token",
+	})
+	if err != nil {
+		t.Fatalf("Failed to create provider: %v", err)
+	}
+
+	queries := make([]string, igdb.MaxMultiqueries+2)
+	for i := range queries {
+		queries[i] = "Super Mario"
+	}
+
+	results, err := provider.BatchSearch(context.Background(), queries, retrometadata.SearchOptions{Limit: 10})
+	if err != nil {
+		t.Fatalf("BatchSearch error: %v", err)
+	}
+
+	if requests != 2 {
+		t.Errorf("Expected 2 multiquery requests, got %d", requests)
+	}
+	if len(results) != len(queries) {
+		t.Fatalf("Expected %d result lists, got %d", len(queries), len(results))
+	}
+	for i, list := range results {
+		if len(list) == 0 || list[0].Name != "Super Mario World" {
+			t.Errorf("Unexpected results for query %d: %+v", i, list)
+		}
+	}
+}
+
 func TestMobyGamesSearchIntegration(t *testing.T) {
 	searchResponse := loadFixture(t, "mobygames", "search_zelda.json")
 
@@ -223,7 +386,8 @@ func TestMobyGamesSearchIntegration(t *testing.T) {
 		Credentials: map[string]string{
 			"api_key": "test_api_key",
 		},
-		Timeout: 30,
+		Timeout:   30,
+		RateLimit: 100,
 	}
 
 	provider, err := mobygames.NewProviderWithOptions(config, nil, mobygames.Options{
@@ -260,12 +424,21 @@ func TestMobyGamesSearchIntegration(t *testing.T) {
 
 func TestMobyGamesGetByIDIntegration(t *testing.T) {
 	gameResponse := loadFixture(t, "mobygames", "game_564.json")
+	coversResponse := loadFixture(t, "mobygames", "covers_564_15.json")
+	screenshotsResponse := loadFixture(t, "mobygames", "screenshots_564_15.json")
 
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		if strings.Contains(r.URL.Path, "/games/564") {
-			w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/games/564":
 			_, _ = w.Write(gameResponse)
 			return
+		case "/games/564/platforms/15/covers":
+			_, _ = w.Write(coversResponse)
+			return
+		case "/games/564/platforms/15/screenshots":
+			_, _ = w.Write(screenshotsResponse)
+			return
 		}
 
 		http.NotFound(w, r)
@@ -277,7 +450,8 @@ func TestMobyGamesGetByIDIntegration(t *testing.T) {
 		Credentials: map[string]string{
 			"api_key": "test_api_key",
 		},
-		Timeout: 30,
+		Timeout:   30,
+		RateLimit: 100,
 	}
 
 	provider, err := mobygames.NewProviderWithOptions(config, nil, mobygames.Options{
@@ -308,9 +482,15 @@ func TestMobyGamesGetByIDIntegration(t *testing.T) {
 		t.Error("Expected genres, got none")
 	}
 
-	// Verify artwork
-	if result.Artwork.CoverURL == "" {
-		t.Error("Expected cover URL, got empty string")
+	// Verify artwork comes from the US cover group of the first platform
+	if result.Artwork.CoverURL != "https://cdn.mobygames.com/covers/564_us_front.jpg" {
+		t.Errorf("Expected the US front cover, got %q", result.Artwork.CoverURL)
+	}
+	if len(result.Artwork.ScreenshotURLs) != 3 {
+		t.Errorf("Expected 3 screenshots, got %d", len(result.Artwork.ScreenshotURLs))
+	}
+	if len(result.Artwork.Media) != 3 || result.Artwork.Media[0].Region != "jp" || result.Artwork.Media[1].Type != "back cover" {
+		t.Errorf("Unexpected cover media: %+v", result.Artwork.Media)
 	}
 }
 
@@ -409,3 +589,368 @@ func TestDisabledProvider(t *testing.T) {
 		t.Error("Expected nil results for disabled provider")
 	}
 }
+
+// TestProviderMatchScorer tests that providers reject unknown scorers.
+func TestProviderMatchScorer(t *testing.T) {
+	config := retrometadata.ProviderConfig{
+		Enabled:     true,
+		Credentials: map[string]string{"api_key": "test_api_key"},
+		MatchScorer: "token-set",
+	}
+	if _, err := mobygames.NewProvider(config, nil); err != nil {
+		t.Fatalf("NewProvider(token-set) error: %v", err)
+	}
+
+	config.MatchScorer = "soundex"
+	_, err := mobygames.NewProvider(config, nil)
+	var configErr *retrometadata.ConfigError
+	if !errors.As(err, &configErr) {
+		t.Fatalf("NewProvider(soundex) error = %v, want a ConfigError", err)
+	}
+	for _, name := range []string{"soundex", "jaro-winkler", "levenshtein", "token-set"} {
+		if !strings.Contains(configErr.Details, name) {
+			t.Errorf("ConfigError details = %q, want them to name %q", configErr.Details, name)
+		}
+	}
+}
+
+func TestDatfileIdentifyByHashIntegration(t *testing.T) {
+	dat, err := datfile.Parse(strings.NewReader(string(loadFixture(t, "datfile", "nintendo_game_boy.dat"))))
+	if err != nil {
+		t.Fatalf("Failed to parse datfile: %v", err)
+	}
+
+	if dat.Source != retrometadata.SignatureSourceNoIntro {
+		t.Errorf("Expected source %q, got %q", retrometadata.SignatureSourceNoIntro, dat.Source)
+	}
+
+	provider := datfile.New(&retrometadata.ProviderConfig{Enabled: true})
+	provider.AddDatfile(dat)
+
+	ctx := context.Background()
+	result, err := provider.IdentifyByHash(ctx, retrometadata.FileHashes{CRC32: "46DF91AD"}, retrometadata.IdentifyOptions{})
+	if err != nil {
+		t.Fatalf("IdentifyByHash error: %v", err)
+	}
+	if result == nil {
+		t.Fatal("Expected result, got nil")
+	}
+
+	if result.Name != "Tetris" {
+		t.Errorf("Expected name 'Tetris', got %q", result.Name)
+	}
+
+	match := result.Signatures.Get(retrometadata.SignatureSourceNoIntro)
+	if match == nil {
+		t.Fatal("Expected No-Intro signature match")
+	}
+	if match.Name != "Tetris (World) (Rev 1)" || match.Revision != "Rev 1" {
+		t.Errorf("Unexpected signature match: %+v", match)
+	}
+
+	signatures, err := provider.MatchSignatures(ctx, retrometadata.FileHashes{MD5: "00000000000000000000000000000000"})
+	if err != nil {
+		t.Fatalf("MatchSignatures error: %v", err)
+	}
+	if signatures != nil {
+		t.Errorf("Expected no signatures for unknown hash, got %+v", signatures)
+	}
+}
+
+func TestDatfileIdentifyBySerialIntegration(t *testing.T) {
+	dat, err := datfile.Parse(strings.NewReader(`<?xml version="1.0"?>
+<datafile>
+	<header><name>Sony - PlayStation</name><description>Redump</description></header>
+	<game name="Final Fantasy VII (USA) (Disc 1)">
+		<description>Final Fantasy VII (USA) (Disc 1)</description>
+		<rom name="Final Fantasy VII (USA) (Disc 1).bin" size="1" crc="00000001" serial="SCUS-94163, SCUS-94164"/>
+	</game>
+</datafile>`))
+	if err != nil {
+		t.Fatalf("Failed to parse datfile: %v", err)
+	}
+	provider := datfile.New(&retrometadata.ProviderConfig{Enabled: true})
+	provider.AddDatfile(dat)
+	ctx := context.Background()
+
+	result, err := provider.IdentifyBySerial(ctx, "scus_941.64", retrometadata.IdentifyOptions{})
+	if err != nil || result == nil || result.Name != "Final Fantasy VII" || result.MatchType != "serial" {
+		t.Errorf("IdentifyBySerial() = %+v, %v; want Final Fantasy VII", result, err)
+	}
+
+	result, err = provider.Identify(ctx, "FF7 [SCUS-94163].bin", retrometadata.IdentifyOptions{})
+	if err != nil || result == nil || result.MatchType != "serial" {
+		t.Errorf("Identify() of a file named with its serial = %+v, %v; want a serial match", result, err)
+	}
+}
+
+func TestDatfileConcurrentLoadIntegration(t *testing.T) {
+	datDir, indexDir := t.TempDir(), t.TempDir()
+	datPath := filepath.Join(datDir, "nintendo_game_boy.dat")
+	if err := os.WriteFile(datPath, loadFixture(t, "datfile", "nintendo_game_boy.dat"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	provider := datfile.New(&retrometadata.ProviderConfig{
+		Enabled: true,
+		Options: map[string]any{"dat_paths": []string{datPath}, "index_dir": indexDir},
+	})
+
+	// The first lookups load the datfile; run them together under -race
+	ctx := context.Background()
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			hashes := retrometadata.FileHashes{CRC32: "46DF91AD"}
+			result, err := provider.Identify(ctx, "Tetris.gb", retrometadata.IdentifyOptions{Hashes: &hashes})
+			if err != nil || result == nil || result.Name != "Tetris" {
+				t.Errorf("Identify() = %+v, %v; want Tetris", result, err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if got := len(provider.Datfiles()); got != 1 {
+		t.Errorf("Loaded %d datfiles, want 1", got)
+	}
+
+	// The parsed datfile is saved to the index directory, not beside it
+	if saved, _ := filepath.Glob(filepath.Join(indexDir, "*.index")); len(saved) != 1 {
+		t.Errorf("Saved indexes = %v, want 1", saved)
+	}
+	if beside, _ := filepath.Glob(filepath.Join(datDir, "*.index")); len(beside) != 0 {
+		t.Errorf("Indexes saved beside the datfile: %v", beside)
+	}
+}
+
+func TestDatfileMultipleDatfilesIntegration(t *testing.T) {
+	datDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(datDir, "a_nintendo_game_boy.dat"), loadFixture(t, "datfile", "nintendo_game_boy.dat"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"b_extra", "c_extra"} {
+		dat := `<?xml version="1.0"?>
+<datafile>
+	<header><name>` + name + `</name></header>
+	<game name="Game ` + name + ` (World)">
+		<description>Game ` + name + ` (World)</description>
+		<rom name="Game ` + name + ` (World).gb" size="1" crc="0000000` + name[:1] + `"/>
+	</game>
+</datafile>`
+		if err := os.WriteFile(filepath.Join(datDir, name+".dat"), []byte(dat), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	provider := datfile.New(&retrometadata.ProviderConfig{
+		Enabled: true,
+		Options: map[string]any{"dat_paths": []string{datDir}, "index_dir": t.TempDir()},
+	})
+
+	// Lookups running during the load wait for every datfile, including
+	// the last one read
+	ctx := context.Background()
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			hashes := retrometadata.FileHashes{CRC32: "0000000C"}
+			result, err := provider.IdentifyByHash(ctx, hashes, retrometadata.IdentifyOptions{})
+			if err != nil || result == nil || result.Name != "Game c_extra" {
+				t.Errorf("IdentifyByHash() = %+v, %v; want Game c_extra", result, err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	// Loading again replaces the datfiles rather than adding them twice
+	if err := provider.LoadDatfiles(ctx); err != nil {
+		t.Fatalf("LoadDatfiles() error: %v", err)
+	}
+	if got := len(provider.Datfiles()); got != 3 {
+		t.Errorf("Loaded %d datfiles, want 3", got)
+	}
+	results, err := provider.Search(ctx, "Game b_extra", retrometadata.SearchOptions{})
+	if err != nil || len(results) != 1 {
+		t.Errorf("Search() = %+v, %v; want a single result", results, err)
+	}
+}
+
+func TestDatfileAddDatfileFuzzyIntegration(t *testing.T) {
+	parse := func(name string) *datfile.Datfile {
+		dat, err := datfile.Parse(strings.NewReader(`<?xml version="1.0"?>
+<datafile>
+	<header><name>Test</name></header>
+	<game name="` + name + ` (World)"><description>` + name + ` (World)</description></game>
+</datafile>`))
+		if err != nil {
+			t.Fatalf("Failed to parse datfile: %v", err)
+		}
+		return dat
+	}
+	provider := datfile.New(&retrometadata.ProviderConfig{Enabled: true})
+	provider.AddDatfile(parse("Alpha Quest"))
+	ctx := context.Background()
+
+	// The first fuzzy lookup builds the name index
+	result, err := provider.Identify(ctx, "Alpha Quest (USA).gb", retrometadata.IdentifyOptions{})
+	if err != nil || result == nil || result.Name != "Alpha Quest" {
+		t.Errorf("Identify() = %+v, %v; want Alpha Quest", result, err)
+	}
+	if result, _ := provider.Identify(ctx, "Omega Saga (USA).gb", retrometadata.IdentifyOptions{}); result != nil {
+		t.Errorf("Identify() = %+v before Omega Saga was added, want no match", result)
+	}
+
+	// Games added afterwards are fuzzy matched too
+	provider.AddDatfile(parse("Omega Saga"))
+	result, err = provider.Identify(ctx, "Omega Saga (USA).gb", retrometadata.IdentifyOptions{})
+	if err != nil || result == nil || result.Name != "Omega Saga" {
+		t.Errorf("Identify() = %+v, %v; want Omega Saga", result, err)
+	}
+}
+
+func TestDatfileCollectionAudit(t *testing.T) {
+	official, err := datfile.Parse(strings.NewReader(string(loadFixture(t, "datfile", "nintendo_game_boy.dat"))))
+	if err != nil {
+		t.Fatalf("Failed to parse datfile: %v", err)
+	}
+
+	groups := []identify.DiscGroup{
+		{Filename: "/roms/gb/tetris.gb", Hashes: &retrometadata.FileHashes{
+			CRC32: "46DF91AD", SHA1: "74591CC9501AF93873F9A5D3EB12DA12C0723BBC", Size: 32768,
+		}},
+		{Filename: "/roms/gb/Tetris DX (Hack).gb", Hashes: &retrometadata.FileHashes{CRC32: "12345678"}},
+		{Filename: "/roms/gb/Unhashed.gb"},
+	}
+	collection := datfile.FromGroups(datfile.Header{Name: "My Game Boy"}, groups)
+
+	var buf bytes.Buffer
+	if err := collection.Write(&buf); err != nil {
+		t.Fatalf("Write error: %v", err)
+	}
+	if !strings.Contains(buf.String(), `<rom name="tetris.gb" size="32768" crc="46df91ad" sha1="74591cc9501af93873f9a5d3eb12da12c0723bbc"></rom>`) {
+		t.Errorf("Unexpected collection datfile:\n%s", buf.String())
+	}
+
+	// The written datfile reads back with the parser
+	parsed, err := datfile.Parse(&buf)
+	if err != nil {
+		t.Fatalf("Failed to parse written datfile: %v", err)
+	}
+	if parsed.Header.Name != "My Game Boy" || len(parsed.Games) != 2 || parsed.Games[0].Name != "tetris" {
+		t.Fatalf("Parsed collection = %+v", parsed)
+	}
+
+	audit := datfile.Compare(official, parsed)
+	if len(audit.Have) != 1 || audit.Have[0] != "Tetris (World) (Rev 1)" {
+		t.Errorf("Have = %v", audit.Have)
+	}
+	if len(audit.Missing) != 1 || audit.Missing[0] != "Super Mario Land (World) (Rev 1)" {
+		t.Errorf("Missing = %v", audit.Missing)
+	}
+	if len(audit.Unknown) != 1 || audit.Unknown[0] != "Tetris DX (Hack).gb" {
+		t.Errorf("Unknown = %v", audit.Unknown)
+	}
+}
+
+// countingTransport counts the requests it sends.
+type countingTransport struct {
+	requests int
+}
+
+func (t *countingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	t.requests++
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+func TestHTTPClientInjection(t *testing.T) {
+	searchResponse := loadFixture(t, "mobygames", "search_zelda.json")
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write(searchResponse)
+	}))
+	defer server.Close()
+
+	transport := &countingTransport{}
+	config := retrometadata.ProviderConfig{
+		Enabled:     true,
+		Credentials: map[string]string{"api_key": "test_api_key"},
+		Timeout:     5,
+		HTTPClient:  &http.Client{Transport: transport},
+	}
+	provider, err := mobygames.NewProviderWithOptions(config, nil, mobygames.Options{BaseURL: server.URL})
+	if err != nil {
+		t.Fatalf("Failed to create provider: %v", err)
+	}
+
+	if _, err := provider.Search(context.Background(), "Legend of Zelda", retrometadata.SearchOptions{Limit: 10}); err != nil {
+		t.Fatalf("Search error: %v", err)
+	}
+	if transport.requests == 0 {
+		t.Error("Expected requests to go through the injected transport")
+	}
+}
+
+func TestProviderProxy(t *testing.T) {
+	searchResponse := loadFixture(t, "mobygames", "search_zelda.json")
+	var proxied []string
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// A forward proxy gets the absolute URL of the request
+		proxied = append(proxied, r.URL.Host)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write(searchResponse)
+	}))
+	defer proxy.Close()
+
+	newProvider := func(proxyURL string) *mobygames.Provider {
+		config := retrometadata.ProviderConfig{
+			Enabled:     true,
+			Credentials: map[string]string{"api_key": "test_api_key"},
+			Options:     map[string]any{"proxy": proxyURL},
+		}
+		p, err := mobygames.NewProviderWithOptions(config, nil, mobygames.Options{BaseURL: "http://api.mobygames.invalid"})
+		if err != nil {
+			t.Fatalf("Failed to create provider: %v", err)
+		}
+		return p
+	}
+
+	if _, err := newProvider(proxy.URL).Search(context.Background(), "Legend of Zelda", retrometadata.SearchOptions{Limit: 10}); err != nil {
+		t.Fatalf("Search error: %v", err)
+	}
+	if len(proxied) == 0 || proxied[0] != "api.mobygames.invalid" {
+		t.Errorf("Proxied hosts = %v, want [api.mobygames.invalid]", proxied)
+	}
+
+	// An invalid proxy fails the provider's construction
+	for _, proxyURL := range []string{"ftp://proxy:21", "proxy:3128", "http://"} {
+		config := retrometadata.ProviderConfig{
+			Enabled:     true,
+			Credentials: map[string]string{"api_key": "test_api_key"},
+			Options:     map[string]any{"proxy": proxyURL},
+		}
+		_, err := mobygames.NewProviderWithOptions(config, nil, mobygames.Options{})
+		var configErr *retrometadata.ConfigError
+		if !errors.As(err, &configErr) || configErr.Field != "options.proxy" {
+			t.Errorf("NewProviderWithOptions(proxy %q) error = %v, want an options.proxy ConfigError", proxyURL, err)
+		}
+	}
+
+	// A proxy can't be set on other transports, so requests fail rather than
+	// being sent directly
+	config := retrometadata.ProviderConfig{
+		Enabled:     true,
+		Credentials: map[string]string{"api_key": "test_api_key"},
+		Options:     map[string]any{"proxy": proxy.URL},
+		HTTPClient:  &http.Client{Transport: &countingTransport{}},
+	}
+	p, err := mobygames.NewProviderWithOptions(config, nil, mobygames.Options{BaseURL: "http://api.mobygames.invalid"})
+	if err != nil {
+		t.Fatalf("Failed to create provider: %v", err)
+	}
+	_, err = p.Search(context.Background(), "Legend of Zelda", retrometadata.SearchOptions{Limit: 10})
+	if !errors.Is(err, retrometadata.ErrInvalidConfig) || retrometadata.Retryable(err) {
+		t.Errorf("Search with a proxy on a custom transport error = %v, want a configuration error", err)
+	}
+}
@@ -0,0 +1,31 @@
+package provider
+
+import (
+	"github.com/josegonzalez/retro-metadata/pkg/retrometadata"
+)
+
+// KeylessRateLimit is the default rate limit, in requests per second, of
+// providers running without credentials, whose anonymous access the
+// services limit more strictly or share between every keyless user.
+const KeylessRateLimit = 1.0
+
+// HasCredentials reports whether config has every one of the credentials.
+// Providers that can run without credentials are keyless when it's false.
+func HasCredentials(config retrometadata.ProviderConfig, keys ...string) bool {
+	for _, key := range keys {
+		if config.GetCredential(key) == "" {
+			return false
+		}
+	}
+	return true
+}
+
+// NewConfigRateLimiter returns the limiter for config.RateLimit, or for
+// fallback requests per second if it's zero. A negative RateLimit, or a
+// fallback of zero, disables the limit.
+func NewConfigRateLimiter(config retrometadata.ProviderConfig, fallback float64) *RateLimiter {
+	if config.RateLimit != 0 {
+		return NewRateLimiter(config.RateLimit)
+	}
+	return NewRateLimiter(fallback)
+}
@@ -0,0 +1,203 @@
+package launchbox
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/josegonzalez/retro-metadata/pkg/retrometadata"
+)
+
+const (
+	// MetadataURL is the official LaunchBox metadata archive
+	MetadataURL = "https://gamesdb.launchbox-app.com/Metadata.zip"
+
+	// DefaultRefreshInterval is how long a downloaded archive is used before
+	// RefreshMetadata checks for a newer one
+	DefaultRefreshInterval = 7 * 24 * time.Hour
+
+	// metadataStateFile records the downloaded archive's version in destDir
+	metadataStateFile = "metadata-state.json"
+)
+
+// MetadataState records which version of the metadata archive was downloaded.
+type MetadataState struct {
+	// ETag is the archive's ETag header
+	ETag string `json:"etag,omitempty"`
+	// LastModified is the archive's Last-Modified header
+	LastModified string `json:"last_modified,omitempty"`
+	// CheckedAt is when the server was last asked for a newer archive
+	CheckedAt time.Time `json:"checked_at"`
+}
+
+// LoadMetadataState reads the state DownloadMetadata saved in destDir. It
+// returns the zero state if nothing has been downloaded yet.
+func LoadMetadataState(destDir string) (MetadataState, error) {
+	var state MetadataState
+	data, err := os.ReadFile(filepath.Join(destDir, metadataStateFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return state, nil
+	}
+	if err != nil {
+		return state, err
+	}
+	return state, json.Unmarshal(data, &state)
+}
+
+func saveMetadataState(destDir string, state MetadataState) error {
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(destDir, metadataStateFile), data, 0o644)
+}
+
+// DownloadMetadata downloads the LaunchBox metadata archive and extracts its
+// XML files (Metadata.xml, Platforms.xml, ...) into destDir. The request is
+// conditional on the previously downloaded version, so an unchanged archive
+// isn't downloaded again. Returns whether new files were extracted.
+//
+// The provider's metadata path is set to the extracted Metadata.xml. New
+// files drop the provider's index, which the next lookup loads again.
+func (p *Provider) DownloadMetadata(ctx context.Context, destDir string) (bool, error) {
+	if err := os.MkdirAll(destDir, 0o755); err != nil {
+		return false, err
+	}
+	state, err := LoadMetadataState(destDir)
+	if err != nil {
+		return false, err
+	}
+	metadataPath := filepath.Join(destDir, "Metadata.xml")
+	if _, err := os.Stat(metadataPath); err != nil {
+		// Download everything again if the extracted files are gone
+		state.ETag, state.LastModified = "", ""
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", p.metadataURL, nil)
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("User-Agent", p.userAgent)
+	if state.ETag != "" {
+		req.Header.Set("If-None-Match", state.ETag)
+	}
+	if state.LastModified != "" {
+		req.Header.Set("If-Modified-Since", state.LastModified)
+	}
+
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return false, fmt.Errorf("failed to download metadata: %w", retrometadata.RequestError(ctx, p.Name(), err))
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusNotModified:
+		p.metadataPath = metadataPath
+		state.CheckedAt = time.Now()
+		return false, saveMetadataState(destDir, state)
+	case http.StatusOK:
+	default:
+		return false, fmt.Errorf("failed to download metadata: %w", retrometadata.HTTPError(p.Name(), resp))
+	}
+
+	// zip needs random access, so the archive is spooled to disk first
+	archive, err := os.CreateTemp(destDir, "Metadata-*.zip")
+	if err != nil {
+		return false, err
+	}
+	defer os.Remove(archive.Name())
+	defer archive.Close()
+
+	size, err := io.Copy(archive, resp.Body)
+	if err != nil {
+		return false, fmt.Errorf("failed to download metadata: %w", err)
+	}
+	if err := extractXML(ctx, archive, size, destDir); err != nil {
+		return false, err
+	}
+
+	// The index holds offsets into the replaced files
+	_ = p.Close()
+	p.metadataPath = metadataPath
+	return true, saveMetadataState(destDir, MetadataState{
+		ETag:         resp.Header.Get("ETag"),
+		LastModified: resp.Header.Get("Last-Modified"),
+		CheckedAt:    time.Now(),
+	})
+}
+
+// RefreshMetadata downloads the metadata archive into destDir with
+// DownloadMetadata if it hasn't been checked within the refresh interval.
+// Returns whether the metadata changed.
+//
+// The interval defaults to DefaultRefreshInterval and can be set with the
+// "metadata_refresh_interval" option (e.g. "24h").
+func (p *Provider) RefreshMetadata(ctx context.Context, destDir string) (bool, error) {
+	state, err := LoadMetadataState(destDir)
+	if err != nil {
+		return false, err
+	}
+	metadataPath := filepath.Join(destDir, "Metadata.xml")
+	if _, err := os.Stat(metadataPath); err == nil && time.Since(state.CheckedAt) < p.refreshInterval {
+		p.metadataPath = metadataPath
+		return false, nil
+	}
+
+	return p.DownloadMetadata(ctx, destDir)
+}
+
+// extractXML extracts the top-level XML files of a zip archive into destDir.
+// Each file is written to a temporary name and renamed into place, so readers
+// never see a partially written file.
+func extractXML(ctx context.Context, r io.ReaderAt, size int64, destDir string) error {
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return fmt.Errorf("failed to open metadata archive: %w", err)
+	}
+
+	for _, f := range zr.File {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		// Only plain file names, which also rules out paths escaping destDir
+		if f.FileInfo().IsDir() || filepath.Base(f.Name) != f.Name || !strings.EqualFold(filepath.Ext(f.Name), ".xml") {
+			continue
+		}
+		if err := extractFile(f, filepath.Join(destDir, f.Name)); err != nil {
+			return fmt.Errorf("failed to extract %s: %w", f.Name, err)
+		}
+	}
+	return nil
+}
+
+func extractFile(f *zip.File, dest string) error {
+	src, err := f.Open()
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	tmp, err := os.CreateTemp(filepath.Dir(dest), filepath.Base(dest)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := io.Copy(tmp, src); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), dest)
+}
@@ -0,0 +1,140 @@
+package launchbox
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/josegonzalez/retro-metadata/pkg/retrometadata"
+)
+
+func metadataArchive(t *testing.T, gameName string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	files := map[string]string{
+		"Metadata.xml":    "<LaunchBox><Game><Name>" + gameName + "</Name><DatabaseID>1</DatabaseID><Platform>Super Nintendo Entertainment System</Platform></Game></LaunchBox>",
+		"Platforms.xml":   "<LaunchBox></LaunchBox>",
+		"../escape.xml":   "<LaunchBox></LaunchBox>",
+		"Images/logo.png": "png",
+	}
+	for name, content := range files {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, _ = w.Write([]byte(content))
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestDownloadMetadata(t *testing.T) {
+	var downloads atomic.Int32
+	var version atomic.Value
+	version.Store(`"v1"`)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		etag := version.Load().(string)
+		if r.Header.Get("If-None-Match") == etag {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		downloads.Add(1)
+		w.Header().Set("ETag", etag)
+		name := "Super Metroid"
+		if etag != `"v1"` {
+			name = "Super Metroid (Updated)"
+		}
+		_, _ = w.Write(metadataArchive(t, name))
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	p := New(&retrometadata.ProviderConfig{Enabled: true, Options: map[string]any{"metadata_refresh_interval": "1h"}})
+	p.metadataURL = server.URL
+	ctx := context.Background()
+
+	updated, err := p.DownloadMetadata(ctx, dir)
+	if err != nil || !updated {
+		t.Fatalf("DownloadMetadata() = %v, %v; want an update", updated, err)
+	}
+	for _, name := range []string{"Metadata.xml", "Platforms.xml"} {
+		if _, err := os.Stat(filepath.Join(dir, name)); err != nil {
+			t.Errorf("%s wasn't extracted: %v", name, err)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(filepath.Dir(dir), "escape.xml")); err == nil {
+		t.Error("an archive entry was extracted outside the destination")
+	}
+	if state, _ := LoadMetadataState(dir); state.ETag != `"v1"` {
+		t.Errorf("saved ETag = %q, want \"v1\"", state.ETag)
+	}
+
+	// An unchanged archive isn't downloaded again
+	if updated, err := p.DownloadMetadata(ctx, dir); err != nil || updated || downloads.Load() != 1 {
+		t.Errorf("DownloadMetadata() of an unchanged archive = %v, %v after %d downloads", updated, err, downloads.Load())
+	}
+
+	// Within the refresh interval RefreshMetadata doesn't check at all
+	if err := p.LoadMetadata(ctx, ""); err != nil {
+		t.Fatal(err)
+	}
+	version.Store(`"v2"`)
+	if updated, _ := p.RefreshMetadata(ctx, dir); updated || downloads.Load() != 1 {
+		t.Errorf("RefreshMetadata() within the interval = %v after %d downloads", updated, downloads.Load())
+	}
+
+	// Once it passes, a new version is downloaded and loaded
+	p.refreshInterval = time.Nanosecond
+	updated, err = p.RefreshMetadata(ctx, dir)
+	if err != nil || !updated {
+		t.Fatalf("RefreshMetadata() after the interval = %v, %v; want an update", updated, err)
+	}
+	if game, err := p.GetByID(ctx, 1); err != nil || game == nil || game.Name != "Super Metroid (Updated)" {
+		t.Errorf("GetByID() after refresh = %v, %v; want the updated metadata", game, err)
+	}
+}
+
+func TestDownloadMetadataReload(t *testing.T) {
+	var name atomic.Value
+	name.Store("Super Metroid")
+	var userAgent atomic.Value
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		userAgent.Store(r.Header.Get("User-Agent"))
+		_, _ = w.Write(metadataArchive(t, name.Load().(string)))
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	p := New(&retrometadata.ProviderConfig{Enabled: true})
+	p.metadataURL = server.URL
+	ctx := context.Background()
+
+	if _, err := p.DownloadMetadata(ctx, dir); err != nil {
+		t.Fatal(err)
+	}
+	if game, err := p.GetByID(ctx, 1); err != nil || game == nil || game.Name != "Super Metroid" {
+		t.Fatalf("GetByID() = %v, %v", game, err)
+	}
+	if userAgent.Load() != p.userAgent {
+		t.Errorf("User-Agent = %v, want %q", userAgent.Load(), p.userAgent)
+	}
+
+	// A longer name moves every offset after it in the new Metadata.xml
+	name.Store("The Legend of Zelda: A Link to the Past")
+	if updated, err := p.DownloadMetadata(ctx, dir); err != nil || !updated {
+		t.Fatalf("DownloadMetadata() = %v, %v; want an update", updated, err)
+	}
+	if game, err := p.GetByID(ctx, 1); err != nil || game == nil || game.Name != "The Legend of Zelda: A Link to the Past" {
+		t.Errorf("GetByID() after a download = %v, %v; want the new metadata", game, err)
+	}
+}
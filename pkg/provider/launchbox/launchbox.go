@@ -6,17 +6,31 @@ import (
 	"encoding/xml"
 	"fmt"
 	"io"
+	"iter"
+	"net/http"
 	"os"
 	"regexp"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
+	"github.com/josegonzalez/retro-metadata/pkg/internal/index"
+	"github.com/josegonzalez/retro-metadata/pkg/matching"
+	"github.com/josegonzalez/retro-metadata/pkg/platform"
+	"github.com/josegonzalez/retro-metadata/pkg/progress"
+	"github.com/josegonzalez/retro-metadata/pkg/provider"
 	retrometadata "github.com/josegonzalez/retro-metadata/pkg/retrometadata"
 )
 
 const (
 	launchboxImageURL = "https://images.launchbox-app.com"
+
+	// checkpointInterval is the number of games indexed between checkpoints
+	checkpointInterval = 1000
+
+	// rootElement wraps the remaining games when resuming part way through a file
+	rootElement = "<LaunchBox>"
 )
 
 var (
@@ -33,34 +47,72 @@ var (
 	}
 
 	// ErrProviderDisabled is returned when the provider is disabled.
-	ErrProviderDisabled = fmt.Errorf("provider is disabled")
+	ErrProviderDisabled = retrometadata.ErrProviderDisabled
 )
 
 // Provider implements the LaunchBox metadata provider.
+//
+// The full LaunchBox database has hundreds of thousands of games and images,
+// so only their IDs, names and positions in the XML files are kept in memory,
+// indexed with sorted slices. Games are read from the file when looked up.
+// The index is saved next to the metadata file, so later loads don't parse
+// the XML again until it changes.
 type Provider struct {
 	config        *retrometadata.ProviderConfig
 	metadataPath  string
-	gamesByID     map[int]map[string]string
-	gamesByName   map[string]map[int]map[string]string // name -> platformID -> game
-	imagesByID    map[int][]map[string]string
+	gamesPath     string // metadata file the index was built from
+	imagesPath    string
+	games         []gameRecord
+	gamesByID     index.Sorted[int]
+	gamesByName   index.Sorted[string] // lowercased name, only games with a known platform
+	gamesBySeries index.Sorted[string] // lowercased series name
+	images        []imageRecord
+	imagesByID    index.Sorted[int]
 	loaded        bool
+
+	nameIndexMu sync.Mutex
+	nameIndex   *matching.Index // fuzzy matching index of gameNames, built on first use
+
+	// metadataURL and refreshInterval control DownloadMetadata and RefreshMetadata
+	metadataURL     string
+	refreshInterval time.Duration
+	httpClient      *http.Client
+	userAgent       string
+
+	checkpoint  progress.CheckpointFunc
+	resumePath  string // metadata file of an interrupted load
+	resumeAt    int64  // offset just past the last indexed game in resumePath
+	gamesLoaded bool   // resumePath games are indexed and only images remain
+	indexed     int    // games indexed from resumePath so far
 }
 
 // New creates a new LaunchBox provider.
 func New(config *retrometadata.ProviderConfig) *Provider {
 	metadataPath := ""
+	refreshInterval := DefaultRefreshInterval
 	if config.Options != nil {
 		if path, ok := config.Options["metadata_path"].(string); ok {
 			metadataPath = path
 		}
+		if interval, ok := config.Options["metadata_refresh_interval"].(string); ok {
+			if d, err := time.ParseDuration(interval); err == nil && d > 0 {
+				refreshInterval = d
+			}
+		}
 	}
 
+	// The metadata archive is hundreds of megabytes, so its download gets
+	// longer than the request timeout
+	httpClient := provider.NewHTTPClient(*config)
+	httpClient.Timeout = 10 * time.Minute
+
 	return &Provider{
-		config:       config,
-		metadataPath: metadataPath,
-		gamesByID:    make(map[int]map[string]string),
-		gamesByName:  make(map[string]map[int]map[string]string),
-		imagesByID:   make(map[int][]map[string]string),
+		config:          config,
+		metadataPath:    metadataPath,
+		metadataURL:     MetadataURL,
+		refreshInterval: refreshInterval,
+		httpClient:      httpClient,
+		userAgent:       "retro-metadata/1.0",
 	}
 }
 
@@ -69,7 +121,17 @@ func (p *Provider) Name() string {
 	return "launchbox"
 }
 
+// SetCheckpoint sets a callback that receives a checkpoint every 1000 games
+// while LoadMetadata builds its index, and when the load is cancelled.
+func (p *Provider) SetCheckpoint(fn progress.CheckpointFunc) {
+	p.checkpoint = fn
+}
+
 // LoadMetadata loads metadata from LaunchBox XML files.
+//
+// Cancelling ctx stops the load after the current game. The games indexed so
+// far are kept, and calling LoadMetadata again with the same path continues
+// from the last checkpoint instead of starting over.
 func (p *Provider) LoadMetadata(ctx context.Context, path string) error {
 	if path == "" {
 		path = p.metadataPath
@@ -77,15 +139,84 @@ func (p *Provider) LoadMetadata(ctx context.Context, path string) error {
 	if path == "" {
 		return fmt.Errorf("no metadata path provided")
 	}
+	// Images come from a separate Images.xml file, if there is one
+	imagesPath := strings.TrimSuffix(path, ".xml") + "/../Images.xml"
+
+	if path != p.resumePath {
+		if p.loadIndex(path, imagesPath) {
+			p.gamesPath, p.imagesPath = path, imagesPath
+			p.loaded = true
+			return nil
+		}
+
+		p.resetIndex()
+		p.resumePath = path
+		p.resumeAt = 0
+		p.gamesLoaded = false
+		p.indexed = 0
+	}
 
+	if !p.gamesLoaded {
+		if err := p.loadGames(ctx, path); err != nil {
+			return err
+		}
+		p.gamesLoaded = true
+	}
+
+	if imagesFile, err := os.Open(imagesPath); err == nil {
+		defer imagesFile.Close()
+		if err := p.loadImages(ctx, imagesFile); err != nil {
+			return err
+		}
+	}
+
+	p.gamesPath, p.imagesPath = path, imagesPath
+	p.saveIndex(path, imagesPath)
+	p.resumePath = ""
+	p.loaded = true
+	return nil
+}
+
+// resetIndex removes every indexed game and image.
+func (p *Provider) resetIndex() {
+	p.games = nil
+	p.gamesByID.Reset()
+	p.gamesByName.Reset()
+	p.gamesBySeries.Reset()
+	p.images = nil
+	p.imagesByID.Reset()
+	p.nameIndexMu.Lock()
+	p.nameIndex = nil
+	p.nameIndexMu.Unlock()
+}
+
+// loadGames indexes the games in a metadata file, starting after the last
+// game indexed by an interrupted load.
+func (p *Provider) loadGames(ctx context.Context, path string) error {
 	file, err := os.Open(path)
 	if err != nil {
 		return err
 	}
 	defer file.Close()
 
-	decoder := xml.NewDecoder(file)
+	var reader io.Reader = file
+	base := int64(0)
+	if p.resumeAt > 0 {
+		if _, err := file.Seek(p.resumeAt, io.SeekStart); err != nil {
+			return err
+		}
+		reader = io.MultiReader(strings.NewReader(rootElement), file)
+		base = p.resumeAt - int64(len(rootElement))
+	}
+
+	decoder := xml.NewDecoder(reader)
 	for {
+		if err := ctx.Err(); err != nil {
+			p.emitCheckpoint()
+			return err
+		}
+
+		start := base + decoder.InputOffset()
 		token, err := decoder.Token()
 		if err == io.EOF {
 			break
@@ -98,57 +229,159 @@ func (p *Provider) LoadMetadata(ctx context.Context, path string) error {
 		case xml.StartElement:
 			if se.Name.Local == "Game" {
 				game := make(map[string]string)
-				if err := parseGame(decoder, &se, game); err != nil {
+				err := parseGame(decoder, &se, game)
+				p.resumeAt = base + decoder.InputOffset()
+				if err != nil {
 					continue
 				}
-
-				dbIDStr := game["DatabaseID"]
-				if dbIDStr == "" {
-					continue
+				if rec, ok := newGameRecord(game, start, p.resumeAt); ok {
+					p.indexGame(rec)
 				}
 
-				dbID, err := strconv.Atoi(dbIDStr)
-				if err != nil {
-					continue
+				p.indexed++
+				if p.indexed%checkpointInterval == 0 {
+					p.emitCheckpoint()
 				}
+			}
+		}
+	}
 
-				p.gamesByID[dbID] = game
-
-				// Index by name and platform
-				nameLower := strings.ToLower(game["Name"])
-				if nameLower != "" {
-					if _, ok := p.gamesByName[nameLower]; !ok {
-						p.gamesByName[nameLower] = make(map[int]map[string]string)
-					}
-					platformID := getPlatformIDByName(game["Platform"])
-					if platformID > 0 {
-						p.gamesByName[nameLower][platformID] = game
-					}
-				}
+	p.gamesByID.Build()
+	p.gamesByName.Build()
+	p.gamesBySeries.Build()
+	return nil
+}
+
+// newGameRecord creates the index record for a game parsed from the bytes
+// between start and end. Returns false if the game has no database ID.
+func newGameRecord(game map[string]string, start, end int64) (gameRecord, bool) {
+	dbID, err := strconv.Atoi(game["DatabaseID"])
+	if err != nil {
+		return gameRecord{}, false
+	}
+	return gameRecord{
+		ID:         int32(dbID),
+		PlatformID: int32(getPlatformIDByName(game["Platform"])),
+		Name:       strings.ToLower(game["Name"]),
+		Series:     strings.ToLower(game["Series"]),
+		Offset:     start,
+		Length:     int32(end - start),
+	}, true
+}
+
+// indexGame adds a game record to the ID and name indexes.
+func (p *Provider) indexGame(rec gameRecord) {
+	n := len(p.games)
+	p.games = append(p.games, rec)
+	p.gamesByID.Add(int(rec.ID), n)
+
+	// Index by name, for games on a known platform
+	if rec.Name != "" && rec.PlatformID > 0 {
+		p.gamesByName.Add(rec.Name, n)
+	}
+	for _, series := range splitSeries(rec.Series) {
+		p.gamesBySeries.Add(series, n)
+	}
+}
+
+// readGame reads a game's fields from the metadata file.
+func (p *Provider) readGame(rec gameRecord) (map[string]string, bool) {
+	game, err := readElement(p.gamesPath, rec.Offset, rec.Length)
+	return game, err == nil
+}
+
+// gameByID returns the last game loaded with a database ID.
+func (p *Provider) gameByID(dbID int) (map[string]string, bool) {
+	values := p.gamesByID.Lookup(dbID)
+	if len(values) == 0 {
+		return nil, false
+	}
+	return p.readGame(p.games[values[len(values)-1]])
+}
+
+// gameByName returns the game with a lowercased name, preferring the given
+// platform (the most recently loaded game on it) and otherwise the first one loaded.
+func (p *Provider) gameByName(nameLower string, platformID *int) (map[string]string, bool) {
+	values := p.gamesByName.Lookup(nameLower)
+	if len(values) == 0 {
+		return nil, false
+	}
+	if platformID != nil {
+		for i := len(values) - 1; i >= 0; i-- {
+			if rec := p.games[values[i]]; int(rec.PlatformID) == *platformID {
+				return p.readGame(rec)
 			}
 		}
 	}
+	return p.readGame(p.games[values[0]])
+}
 
-	// Try to load images from a separate Images.xml file
-	imagesPath := strings.TrimSuffix(path, ".xml") + "/../Images.xml"
-	if imagesFile, err := os.Open(imagesPath); err == nil {
-		defer imagesFile.Close()
-		p.loadImages(imagesFile)
+// gameNames returns every distinct indexed game name, in sorted order.
+func (p *Provider) gameNames() []string {
+	names := make([]string, 0, p.gamesByName.Len())
+	for i := 0; i < p.gamesByName.Len(); i++ {
+		if name := p.gamesByName.Key(i); len(names) == 0 || names[len(names)-1] != name {
+			names = append(names, name)
+		}
 	}
+	return names
+}
 
-	p.loaded = true
-	return nil
+// gameNameIndex returns the fuzzy matching index of the game names, building
+// it the first time it's needed.
+func (p *Provider) gameNameIndex() *matching.Index {
+	p.nameIndexMu.Lock()
+	defer p.nameIndexMu.Unlock()
+	if p.nameIndex == nil {
+		p.nameIndex = matching.NewIndex(p.gameNames())
+	}
+	return p.nameIndex
+}
+
+// gameImages returns the images of a game, in file order.
+func (p *Provider) gameImages(dbID int) []map[string]string {
+	values := p.imagesByID.Lookup(dbID)
+	images := make([]map[string]string, 0, len(values))
+	for _, v := range values {
+		rec := p.images[v]
+		if image, err := readElement(p.imagesPath, rec.Offset, rec.Length); err == nil {
+			images = append(images, image)
+		}
+	}
+	return images
 }
 
-func (p *Provider) loadImages(file *os.File) {
+// emitCheckpoint reports the progress of the current load, if a callback is set.
+func (p *Provider) emitCheckpoint() {
+	if p.checkpoint == nil {
+		return
+	}
+	p.checkpoint(progress.Checkpoint{
+		Operation: "launchbox-index",
+		Completed: p.indexed,
+		Cursor:    strconv.FormatInt(p.resumeAt, 10),
+	})
+}
+
+// loadImages indexes the images in an Images.xml file.
+// Images are reloaded from the start if a previous load was cancelled.
+func (p *Provider) loadImages(ctx context.Context, file *os.File) error {
+	p.images = nil
+	p.imagesByID.Reset()
+
 	decoder := xml.NewDecoder(file)
 	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		start := decoder.InputOffset()
 		token, err := decoder.Token()
 		if err == io.EOF {
 			break
 		}
 		if err != nil {
-			return
+			return nil
 		}
 
 		switch se := token.(type) {
@@ -159,22 +392,26 @@ func (p *Provider) loadImages(file *os.File) {
 					continue
 				}
 
-				dbIDStr := image["DatabaseID"]
-				if dbIDStr == "" {
-					continue
-				}
-
-				dbID, err := strconv.Atoi(dbIDStr)
+				dbID, err := strconv.Atoi(image["DatabaseID"])
 				if err != nil {
 					continue
 				}
 
-				p.imagesByID[dbID] = append(p.imagesByID[dbID], image)
+				p.imagesByID.Add(dbID, len(p.images))
+				p.images = append(p.images, imageRecord{
+					ID:     int32(dbID),
+					Offset: start,
+					Length: int32(decoder.InputOffset() - start),
+				})
 			}
 		}
 	}
+
+	p.imagesByID.Build()
+	return nil
 }
 
+// parseGame reads the child elements of start into game.
 func parseGame(decoder *xml.Decoder, start *xml.StartElement, game map[string]string) error {
 	for {
 		token, err := decoder.Token()
@@ -198,68 +435,53 @@ func parseGame(decoder *xml.Decoder, start *xml.StartElement, game map[string]st
 }
 
 func getPlatformIDByName(platformName string) int {
-	// Simplified platform name to ID mapping
-	platformMap := map[string]int{
-		"3DO Interactive Multiplayer":          1,
-		"Nintendo 3DS":                         24,
-		"Amstrad CPC":                          3,
-		"Commodore Amiga":                      2,
-		"Android":                              4,
-		"Arcade":                               5,
-		"Atari 2600":                           6,
-		"Atari 5200":                           7,
-		"Atari 7800":                           8,
-		"Nintendo Game Boy":                    17,
-		"Nintendo Game Boy Advance":            18,
-		"Nintendo Game Boy Color":              19,
-		"Sega Game Gear":                       47,
-		"Sega Genesis":                         49,
-		"Sega Dreamcast":                       52,
-		"Nintendo 64":                          25,
-		"Nintendo DS":                          26,
-		"Nintendo Entertainment System":        27,
-		"Nintendo GameCube":                    20,
-		"Nintendo Wii":                         29,
-		"Nintendo Wii U":                       30,
-		"Nintendo Switch":                      61,
-		"Sony Playstation":                     55,
-		"Sony Playstation 2":                   56,
-		"Sony Playstation 3":                   57,
-		"Sony PSP":                             58,
-		"Sony Playstation Vita":                59,
-		"Microsoft Xbox":                       31,
-		"Microsoft Xbox 360":                   32,
-		"Super Nintendo Entertainment System":  60,
-	}
-	return platformMap[platformName]
+	slug := platform.SlugFromProviderName(platform.ProviderLaunchBox, platformName)
+	if id := platform.GetPlatformID(platform.ProviderLaunchBox, slug); id != nil {
+		return *id
+	}
+	return 0
 }
 
 // Search searches for games by name.
 func (p *Provider) Search(ctx context.Context, query string, opts retrometadata.SearchOptions) ([]retrometadata.SearchResult, error) {
-	if !p.config.Enabled {
-		return nil, nil
+	if opts.Limit == 0 {
+		opts.Limit = 20
 	}
+	return provider.CollectResults(p.SearchIter(ctx, query, opts))
+}
 
-	if !p.loaded {
-		if err := p.LoadMetadata(ctx, ""); err != nil {
-			return nil, err
+// SearchIter yields the games whose names contain query, reading each from
+// the metadata file only when it's yielded.
+func (p *Provider) SearchIter(ctx context.Context, query string, opts retrometadata.SearchOptions) iter.Seq2[retrometadata.SearchResult, error] {
+	return func(yield func(retrometadata.SearchResult, error) bool) {
+		if !p.config.Enabled {
+			return
 		}
-	}
-
-	queryLower := strings.ToLower(query)
-	limit := opts.Limit
-	if limit == 0 {
-		limit = 20
-	}
 
-	var results []retrometadata.SearchResult
-	for name, platforms := range p.gamesByName {
-		if !strings.Contains(name, queryLower) {
-			continue
+		if !p.loaded {
+			if err := p.LoadMetadata(ctx, ""); err != nil {
+				yield(retrometadata.SearchResult{}, err)
+				return
+			}
 		}
 
-		for platformID, game := range platforms {
-			if opts.PlatformID != nil && platformID != *opts.PlatformID {
+		queryLower := strings.ToLower(query)
+		yielded := 0
+		for i := 0; i < p.gamesByName.Len(); i++ {
+			if !strings.Contains(p.gamesByName.Key(i), queryLower) {
+				continue
+			}
+			if err := ctx.Err(); err != nil {
+				yield(retrometadata.SearchResult{}, err)
+				return
+			}
+
+			rec := p.games[p.gamesByName.Value(i)]
+			if opts.PlatformID != nil && int(rec.PlatformID) != *opts.PlatformID {
+				continue
+			}
+			game, ok := p.readGame(rec)
+			if !ok {
 				continue
 			}
 
@@ -275,26 +497,22 @@ func (p *Provider) Search(ctx context.Context, query string, opts retrometadata.
 				}
 			}
 
-			results = append(results, retrometadata.SearchResult{
+			result := retrometadata.SearchResult{
 				Name:        game["Name"],
 				Provider:    p.Name(),
 				ProviderID:  dbID,
 				CoverURL:    coverURL,
 				Platforms:   []string{game["Platform"]},
 				ReleaseYear: releaseYear,
-			})
-
-			if len(results) >= limit {
-				break
 			}
-		}
-
-		if len(results) >= limit {
-			break
+			if !yield(result, nil) {
+				return
+			}
+			if yielded++; opts.Limit > 0 && yielded >= opts.Limit {
+				return
+			}
 		}
 	}
-
-	return results, nil
 }
 
 // GetByID gets game details by LaunchBox database ID.
@@ -309,7 +527,7 @@ func (p *Provider) GetByID(ctx context.Context, gameID int) (*retrometadata.Game
 		}
 	}
 
-	game, ok := p.gamesByID[gameID]
+	game, ok := p.gameByID(gameID)
 	if !ok {
 		return nil, nil
 	}
@@ -347,44 +565,18 @@ func (p *Provider) Identify(ctx context.Context, filename string, opts retrometa
 	searchTermLower := strings.ToLower(searchTerm)
 
 	// Look for exact match first
-	if platforms, ok := p.gamesByName[searchTermLower]; ok {
-		if opts.PlatformID != nil {
-			if game, ok := platforms[*opts.PlatformID]; ok {
-				return p.buildGameResult(game), nil
-			}
-		}
-		// Return first match if no platform specified
-		for _, game := range platforms {
-			return p.buildGameResult(game), nil
-		}
+	if game, ok := p.gameByName(searchTermLower, opts.PlatformID); ok {
+		return p.buildGameResult(game), nil
 	}
 
 	// Fuzzy match
-	var names []string
-	for name := range p.gamesByName {
-		names = append(names, name)
-	}
-
-	bestMatch, score := findBestMatch(searchTermLower, names)
+	bestMatch, score := provider.FindBestMatchIndex(ctx, p.Name(), searchTermLower, p.gameNameIndex(), provider.MatchOptions(*p.config, 0.6))
 	if bestMatch == "" {
 		return nil, nil
 	}
 
-	platforms := p.gamesByName[bestMatch]
-	var game map[string]string
-	if opts.PlatformID != nil {
-		if g, ok := platforms[*opts.PlatformID]; ok {
-			game = g
-		}
-	}
-	if game == nil {
-		for _, g := range platforms {
-			game = g
-			break
-		}
-	}
-
-	if game == nil {
+	game, ok := p.gameByName(bestMatch, opts.PlatformID)
+	if !ok {
 		return nil, nil
 	}
 
@@ -394,10 +586,7 @@ func (p *Provider) Identify(ctx context.Context, filename string, opts retrometa
 }
 
 func (p *Provider) getBestCover(gameID int) string {
-	images, ok := p.imagesByID[gameID]
-	if !ok {
-		return ""
-	}
+	images := p.gameImages(gameID)
 
 	for _, coverType := range coverPriority {
 		for _, image := range images {
@@ -413,10 +602,7 @@ func (p *Provider) getBestCover(gameID int) string {
 }
 
 func (p *Provider) getScreenshots(gameID int) []string {
-	images, ok := p.imagesByID[gameID]
-	if !ok {
-		return nil
-	}
+	images := p.gameImages(gameID)
 
 	var screenshots []string
 	for _, image := range images {
@@ -502,6 +688,15 @@ func (p *Provider) extractMetadata(game map[string]string) retrometadata.GameMet
 
 	// YouTube video
 	youtubeVideoID := extractVideoID(game["VideoURL"])
+	var videos []retrometadata.Video
+	if url := game["VideoURL"]; url != "" {
+		videos = append(videos, retrometadata.Video{
+			Provider:  p.Name(),
+			Type:      retrometadata.VideoTrailer,
+			URL:       url,
+			YouTubeID: youtubeVideoID,
+		})
+	}
 
 	// Rating
 	var totalRating *float64
@@ -533,6 +728,7 @@ func (p *Provider) extractMetadata(game map[string]string) retrometadata.GameMet
 		TotalRating:      totalRating,
 		FirstReleaseDate: firstReleaseDate,
 		YouTubeVideoID:   youtubeVideoID,
+		Videos:           videos,
 		Genres:           genres,
 		GameModes:        gameModes,
 		Companies:        companies,
@@ -541,6 +737,7 @@ func (p *Provider) extractMetadata(game map[string]string) retrometadata.GameMet
 		Developer:        game["Developer"],
 		Publisher:        game["Publisher"],
 		ReleaseYear:      releaseYear,
+		Collections:      splitSeries(game["Series"]),
 		RawData:          stringMapToAnyMap(game),
 	}
 }
@@ -584,9 +781,7 @@ func (p *Provider) Heartbeat(ctx context.Context) error {
 
 // Close clears loaded data.
 func (p *Provider) Close() error {
-	p.gamesByID = make(map[int]map[string]string)
-	p.gamesByName = make(map[string]map[int]map[string]string)
-	p.imagesByID = make(map[int][]map[string]string)
+	p.resetIndex()
 	p.loaded = false
 	return nil
 }
@@ -606,46 +801,3 @@ func cleanFilename(filename string) string {
 	name = regexp.MustCompile(`\s*[\(\[][^\)\]]*[\)\]]`).ReplaceAllString(name, "")
 	return strings.TrimSpace(name)
 }
-
-func findBestMatch(query string, candidates []string) (string, float64) {
-	if len(candidates) == 0 {
-		return "", 0
-	}
-
-	queryLower := strings.ToLower(query)
-	bestMatch := ""
-	bestScore := 0.0
-
-	for _, candidate := range candidates {
-		candidateLower := strings.ToLower(candidate)
-
-		if candidateLower == queryLower {
-			return candidate, 1.0
-		}
-
-		if strings.Contains(candidateLower, queryLower) || strings.Contains(queryLower, candidateLower) {
-			score := float64(len(queryLower)) / float64(max(len(queryLower), len(candidateLower)))
-			if score > bestScore {
-				bestScore = score
-				bestMatch = candidate
-			}
-		}
-	}
-
-	if bestScore >= 0.6 {
-		return bestMatch, bestScore
-	}
-
-	if len(candidates) > 0 {
-		return candidates[0], 0.5
-	}
-
-	return "", 0
-}
-
-func max(a, b int) int {
-	if a > b {
-		return a
-	}
-	return b
-}
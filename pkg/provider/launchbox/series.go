@@ -0,0 +1,64 @@
+package launchbox
+
+import (
+	"context"
+	"strconv"
+	"strings"
+
+	"github.com/josegonzalez/retro-metadata/pkg/retrometadata"
+)
+
+// splitSeries splits a game's Series field, which lists its series
+// separated by semicolons.
+func splitSeries(field string) []string {
+	var series []string
+	for _, s := range strings.Split(field, ";") {
+		if s = strings.TrimSpace(s); s != "" {
+			series = append(series, s)
+		}
+	}
+	return series
+}
+
+// GetSeries returns the games of a LaunchBox series, by its name ignoring
+// case. LaunchBox series are collections; it has no franchises, and no
+// series IDs.
+func (p *Provider) GetSeries(ctx context.Context, kind retrometadata.SeriesKind, name string, id int) (*retrometadata.Series, error) {
+	if !p.config.Enabled || kind != retrometadata.SeriesCollection || id != 0 || name == "" {
+		return nil, nil
+	}
+	if !p.loaded {
+		if err := p.LoadMetadata(ctx, ""); err != nil {
+			return nil, err
+		}
+	}
+
+	var result *retrometadata.Series
+	for _, v := range p.gamesBySeries.Lookup(strings.ToLower(strings.TrimSpace(name))) {
+		game, ok := p.readGame(p.games[v])
+		if !ok {
+			continue
+		}
+		if result == nil {
+			result = &retrometadata.Series{Name: name, Kind: kind}
+			// Use the series name as LaunchBox spells it
+			for _, s := range splitSeries(game["Series"]) {
+				if strings.EqualFold(s, strings.TrimSpace(name)) {
+					result.Name = s
+				}
+			}
+		}
+
+		dbID, _ := strconv.Atoi(game["DatabaseID"])
+		seriesGame := retrometadata.SeriesGame{
+			Name:        game["Name"],
+			ReleaseYear: p.extractMetadata(game).ReleaseYear,
+			ProviderIDs: map[string]int{p.Name(): dbID},
+		}
+		if game["Platform"] != "" {
+			seriesGame.Platforms = []string{game["Platform"]}
+		}
+		result.Games = append(result.Games, seriesGame)
+	}
+	return result, nil
+}
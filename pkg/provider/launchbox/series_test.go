@@ -0,0 +1,68 @@
+package launchbox
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/josegonzalez/retro-metadata/pkg/retrometadata"
+)
+
+const seriesMetadata = `<LaunchBox>
+  <Game><Name>Metroid</Name><DatabaseID>1</DatabaseID><Platform>Nintendo Entertainment System</Platform><ReleaseDate>1986-08-06T00:00:00-07:00</ReleaseDate><Series>Metroid</Series></Game>
+  <Game><Name>Super Metroid</Name><DatabaseID>2</DatabaseID><Platform>Super Nintendo Entertainment System</Platform><ReleaseDate>1994-03-19T00:00:00-08:00</ReleaseDate><Series>Metroid; Super Metroid</Series></Game>
+  <Game><Name>Sonic the Hedgehog</Name><DatabaseID>3</DatabaseID><Platform>Sega Genesis</Platform><Series>Sonic</Series></Game>
+</LaunchBox>`
+
+func TestGetSeries(t *testing.T) {
+	metadataPath := filepath.Join(t.TempDir(), "Metadata.xml")
+	if err := os.WriteFile(metadataPath, []byte(seriesMetadata), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	// The second provider reads the saved index
+	for range 2 {
+		p := New(&retrometadata.ProviderConfig{Enabled: true, Options: map[string]any{"metadata_path": metadataPath}})
+		series, err := p.GetSeries(ctx, retrometadata.SeriesCollection, "METROID", 0)
+		if err != nil || series == nil {
+			t.Fatalf("GetSeries() = %+v, %v", series, err)
+		}
+		if series.Name != "Metroid" || len(series.Games) != 2 || series.Games[1].Name != "Super Metroid" || *series.Games[1].ReleaseYear != 1994 {
+			t.Errorf("GetSeries() = %+v", series)
+		}
+		if series.Games[0].ProviderIDs["launchbox"] != 1 || series.Games[0].Platforms[0] != "Nintendo Entertainment System" {
+			t.Errorf("Games[0] = %+v", series.Games[0])
+		}
+
+		if series, err := p.GetSeries(ctx, retrometadata.SeriesFranchise, "Metroid", 0); series != nil || err != nil {
+			t.Errorf("GetSeries(franchise) = %+v, %v, want nothing", series, err)
+		}
+	}
+}
+
+func TestSearchIter(t *testing.T) {
+	metadataPath := filepath.Join(t.TempDir(), "Metadata.xml")
+	if err := os.WriteFile(metadataPath, []byte(seriesMetadata), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	p := New(&retrometadata.ProviderConfig{Enabled: true, Options: map[string]any{"metadata_path": metadataPath}})
+
+	var names []string
+	for result, err := range p.SearchIter(context.Background(), "metroid", retrometadata.SearchOptions{}) {
+		if err != nil {
+			t.Fatalf("SearchIter() error = %v", err)
+		}
+		names = append(names, result.Name)
+		break
+	}
+	if len(names) != 1 {
+		t.Errorf("SearchIter() yielded %q after the loop stopped", names)
+	}
+
+	results, err := p.Search(context.Background(), "metroid", retrometadata.SearchOptions{})
+	if err != nil || len(results) != 2 {
+		t.Errorf("Search() = %+v, %v, want both games", results, err)
+	}
+}
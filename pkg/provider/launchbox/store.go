@@ -0,0 +1,101 @@
+package launchbox
+
+import (
+	"encoding/xml"
+	"errors"
+	"io"
+	"os"
+
+	"github.com/josegonzalez/retro-metadata/pkg/internal/index"
+)
+
+// indexVersion changes whenever the on-disk index format does.
+const indexVersion = 3
+
+// gameRecord locates a game's <Game> element in the metadata file. Only the
+// fields needed to look games up are kept in memory; everything else is read
+// from the file on demand.
+type gameRecord struct {
+	ID         int32
+	PlatformID int32
+	Name       string // lowercased
+	Series     string // lowercased, ";"-separated
+	Offset     int64
+	Length     int32
+}
+
+// imageRecord locates a <GameImage> element in the images file.
+type imageRecord struct {
+	ID     int32
+	Offset int64
+	Length int32
+}
+
+// diskIndex is the on-disk form of the index, saved next to the metadata
+// file so later loads skip parsing the XML.
+type diskIndex struct {
+	Records []gameRecord
+	Pics    []imageRecord
+}
+
+// indexPath returns where the index for a metadata file is stored.
+func indexPath(metadataPath string) string {
+	return metadataPath + ".index"
+}
+
+// loadIndex reads the on-disk index for a metadata file. Returns false if
+// there's none or it was built from different files.
+func (p *Provider) loadIndex(metadataPath, imagesPath string) bool {
+	var idx diskIndex
+	if !index.Load(indexPath(metadataPath), indexVersion, []string{metadataPath, imagesPath}, &idx) {
+		return false
+	}
+
+	p.resetIndex()
+	for _, rec := range idx.Records {
+		p.indexGame(rec)
+	}
+	p.gamesByID.Build()
+	p.gamesByName.Build()
+	p.gamesBySeries.Build()
+	for _, rec := range idx.Pics {
+		p.imagesByID.Add(int(rec.ID), len(p.images))
+		p.images = append(p.images, rec)
+	}
+	p.imagesByID.Build()
+	return true
+}
+
+// saveIndex writes the index next to the metadata file. Failures only cost
+// a slower next load, so they're ignored.
+func (p *Provider) saveIndex(metadataPath, imagesPath string) {
+	idx := diskIndex{Records: p.games, Pics: p.images}
+	_ = index.Save(indexPath(metadataPath), indexVersion, []string{metadataPath, imagesPath}, &idx)
+}
+
+// readElement parses the element at offset in a file into a field map.
+func readElement(path string, offset int64, length int32) (map[string]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	decoder := xml.NewDecoder(io.NewSectionReader(file, offset, int64(length)))
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			if err == io.EOF {
+				err = errors.New("element not found at indexed offset")
+			}
+			return nil, err
+		}
+		if se, ok := token.(xml.StartElement); ok {
+			fields := make(map[string]string)
+			if err := parseGame(decoder, &se, fields); err != nil {
+				return nil, err
+			}
+			return fields, nil
+		}
+	}
+}
@@ -0,0 +1,89 @@
+package launchbox
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/josegonzalez/retro-metadata/pkg/retrometadata"
+)
+
+const storeMetadata = `<LaunchBox>
+  <Game><Name>Super Metroid</Name><DatabaseID>1</DatabaseID><Platform>Super Nintendo Entertainment System</Platform><Overview>Samus returns.</Overview></Game>
+  <Game><Name>Sonic the Hedgehog</Name><DatabaseID>2</DatabaseID><Platform>Sega Genesis</Platform></Game>
+  <Game><Name>Unknown Game</Name><DatabaseID>3</DatabaseID><Platform>Nonexistent</Platform></Game>
+</LaunchBox>`
+
+const storeImages = `<LaunchBox>
+  <GameImage><DatabaseID>1</DatabaseID><FileName>super-metroid-box.png</FileName><Type>Box - Front</Type><Region>North America</Region></GameImage>
+</LaunchBox>`
+
+func TestMetadataIndex(t *testing.T) {
+	dir := t.TempDir()
+	metadataPath := filepath.Join(dir, "Metadata.xml")
+	if err := os.WriteFile(metadataPath, []byte(storeMetadata), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	// Images.xml is looked up relative to a directory named after the metadata file
+	if err := os.Mkdir(filepath.Join(dir, "Metadata"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "Images.xml"), []byte(storeImages), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	p := New(&retrometadata.ProviderConfig{Enabled: true, Options: map[string]any{"metadata_path": metadataPath}})
+	if err := p.LoadMetadata(ctx, ""); err != nil {
+		t.Fatalf("LoadMetadata() error: %v", err)
+	}
+	if _, err := os.Stat(indexPath(metadataPath)); err != nil {
+		t.Fatalf("index wasn't saved: %v", err)
+	}
+
+	game, ok := p.gameByID(1)
+	if !ok || game["Name"] != "Super Metroid" || game["Overview"] != "Samus returns." {
+		t.Errorf("gameByID(1) = %v, %v", game, ok)
+	}
+	if images := p.gameImages(1); len(images) != 1 || images[0]["FileName"] != "super-metroid-box.png" {
+		t.Errorf("gameImages(1) = %v", images)
+	}
+	if _, ok := p.gameByName("unknown game", nil); ok {
+		t.Error("a game on an unknown platform was indexed by name")
+	}
+
+	// A new provider reads the saved index instead of the XML
+	reloaded := New(&retrometadata.ProviderConfig{Enabled: true, Options: map[string]any{"metadata_path": metadataPath}})
+	if err := reloaded.LoadMetadata(ctx, ""); err != nil {
+		t.Fatalf("LoadMetadata() error: %v", err)
+	}
+	if reloaded.indexed != 0 {
+		t.Errorf("parsed %d games, want the saved index to be used", reloaded.indexed)
+	}
+	if game, ok := reloaded.gameByName("sonic the hedgehog", nil); !ok || game["DatabaseID"] != "2" {
+		t.Errorf("gameByName() from saved index = %v, %v", game, ok)
+	}
+	if images := reloaded.gameImages(1); len(images) != 1 {
+		t.Errorf("gameImages(1) from saved index = %v", images)
+	}
+
+	// Changing the metadata file invalidates the index
+	updated := storeMetadata[:len(storeMetadata)-len("</LaunchBox>")] +
+		"  <Game><Name>Chrono Trigger</Name><DatabaseID>4</DatabaseID><Platform>Super Nintendo Entertainment System</Platform></Game>\n</LaunchBox>"
+	if err := os.WriteFile(metadataPath, []byte(updated), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	later := time.Now().Add(time.Minute)
+	_ = os.Chtimes(metadataPath, later, later)
+	if p.loadIndex(metadataPath, filepath.Join(dir, "Images.xml")) {
+		t.Error("stale index was reused")
+	}
+	if err := p.LoadMetadata(ctx, metadataPath); err != nil {
+		t.Fatalf("LoadMetadata() error: %v", err)
+	}
+	if game, ok := p.gameByID(4); !ok || game["Name"] != "Chrono Trigger" {
+		t.Errorf("gameByID(4) after update = %v, %v", game, ok)
+	}
+}
@@ -0,0 +1,197 @@
+package mobygames
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/josegonzalez/retro-metadata/pkg/retrometadata"
+)
+
+// CoverGroup is a set of cover scans for one release of a game.
+type CoverGroup struct {
+	Comments  string   `json:"comments"`
+	Countries []string `json:"countries"`
+	Covers    []Cover  `json:"covers"`
+}
+
+// Cover is a single cover scan.
+type Cover struct {
+	Description    string `json:"description"`
+	Image          string `json:"image"`
+	ThumbnailImage string `json:"thumbnail_image"`
+	ScanOf         string `json:"scan_of"`
+	Width          int    `json:"width"`
+	Height         int    `json:"height"`
+}
+
+// Screenshot is a single game screenshot.
+type Screenshot struct {
+	Caption        string `json:"caption"`
+	Image          string `json:"image"`
+	ThumbnailImage string `json:"thumbnail_image"`
+	Width          int    `json:"width"`
+	Height         int    `json:"height"`
+}
+
+// countryRegions maps MobyGames country names that ParseRegion doesn't know
+// to regions. European countries count as Europe, since few cover groups are
+// listed for Europe as a whole.
+var countryRegions = map[string]retrometadata.Region{
+	"united states":  retrometadata.RegionUSA,
+	"worldwide":      retrometadata.RegionWorld,
+	"united kingdom": retrometadata.RegionEurope,
+	"austria":        retrometadata.RegionEurope,
+	"belgium":        retrometadata.RegionEurope,
+	"denmark":        retrometadata.RegionEurope,
+	"finland":        retrometadata.RegionEurope,
+	"france":         retrometadata.RegionEurope,
+	"germany":        retrometadata.RegionEurope,
+	"ireland":        retrometadata.RegionEurope,
+	"italy":          retrometadata.RegionEurope,
+	"netherlands":    retrometadata.RegionEurope,
+	"norway":         retrometadata.RegionEurope,
+	"portugal":       retrometadata.RegionEurope,
+	"spain":          retrometadata.RegionEurope,
+	"sweden":         retrometadata.RegionEurope,
+	"switzerland":    retrometadata.RegionEurope,
+}
+
+// regions returns the regions a cover group was released in.
+func (g CoverGroup) regions() []retrometadata.Region {
+	var regions []retrometadata.Region
+	for _, country := range g.Countries {
+		if region, ok := countryRegions[strings.ToLower(country)]; ok {
+			regions = append(regions, region)
+		}
+		if region, ok := retrometadata.ParseRegion(country); ok {
+			regions = append(regions, region)
+		}
+	}
+	return regions
+}
+
+// rank returns the best PriorityRank among the group's regions.
+func (g CoverGroup) rank(priority []retrometadata.Region) int {
+	best := len(priority)
+	for _, region := range g.regions() {
+		best = min(best, retrometadata.PriorityRank(region, priority))
+	}
+	return best
+}
+
+// frontCover returns the group's front cover, or its first scan if it has none.
+func (g CoverGroup) frontCover() (Cover, bool) {
+	for _, cover := range g.Covers {
+		if strings.EqualFold(cover.ScanOf, "Front Cover") {
+			return cover, true
+		}
+	}
+	if len(g.Covers) > 0 {
+		return g.Covers[0], true
+	}
+	return Cover{}, false
+}
+
+// GetCovers returns the cover groups for a game on a platform.
+func (p *Provider) GetCovers(ctx context.Context, gameID, platformID int) ([]CoverGroup, error) {
+	var resp struct {
+		CoverGroups []CoverGroup `json:"cover_groups"`
+	}
+	if err := p.requestInto(ctx, fmt.Sprintf("/games/%d/platforms/%d/covers", gameID, platformID), nil, &resp); err != nil {
+		return nil, err
+	}
+	return resp.CoverGroups, nil
+}
+
+// GetScreenshots returns the screenshots for a game on a platform.
+func (p *Provider) GetScreenshots(ctx context.Context, gameID, platformID int) ([]Screenshot, error) {
+	var resp struct {
+		Screenshots []Screenshot `json:"screenshots"`
+	}
+	if err := p.requestInto(ctx, fmt.Sprintf("/games/%d/platforms/%d/screenshots", gameID, platformID), nil, &resp); err != nil {
+		return nil, err
+	}
+	return resp.Screenshots, nil
+}
+
+// GetArtwork returns the covers and screenshots for a game on a platform.
+// The cover comes from the cover group that best matches the configured
+// region priority; every scan is listed in Artwork.Media.
+func (p *Provider) GetArtwork(ctx context.Context, gameID, platformID int) (*retrometadata.Artwork, error) {
+	if !p.IsEnabled() {
+		return nil, nil
+	}
+
+	groups, err := p.GetCovers(ctx, gameID, platformID)
+	if err != nil {
+		return nil, err
+	}
+	screenshots, err := p.GetScreenshots(ctx, gameID, platformID)
+	if err != nil {
+		return nil, err
+	}
+
+	artwork := &retrometadata.Artwork{}
+	bestRank := -1
+	for _, group := range groups {
+		var region string
+		if regions := group.regions(); len(regions) > 0 {
+			region = string(regions[0])
+		}
+		for _, cover := range group.Covers {
+			artwork.Media = append(artwork.Media, retrometadata.Media{
+				Type:   strings.ToLower(cover.ScanOf),
+				URL:    cover.Image,
+				Region: region,
+			})
+		}
+
+		if cover, ok := group.frontCover(); ok {
+			if rank := group.rank(p.regions); bestRank < 0 || rank < bestRank {
+				artwork.CoverURL = cover.Image
+				bestRank = rank
+			}
+		}
+	}
+
+	for _, screenshot := range screenshots {
+		if screenshot.Image != "" {
+			artwork.ScreenshotURLs = append(artwork.ScreenshotURLs, screenshot.Image)
+		}
+	}
+
+	return artwork, nil
+}
+
+// addArtwork replaces the sample cover and screenshots of a result with the
+// full artwork for platformID. Failures keep the sample artwork.
+func (p *Provider) addArtwork(ctx context.Context, result *retrometadata.GameResult, platformID int) {
+	if result.ProviderID == nil || platformID == 0 {
+		return
+	}
+	artwork, err := p.GetArtwork(ctx, *result.ProviderID, platformID)
+	if err != nil || artwork == nil {
+		return
+	}
+	if artwork.CoverURL != "" {
+		result.Artwork.CoverURL = artwork.CoverURL
+	}
+	if len(artwork.ScreenshotURLs) > 0 {
+		result.Artwork.ScreenshotURLs = artwork.ScreenshotURLs
+	}
+	result.Artwork.Media = artwork.Media
+}
+
+// firstPlatformID returns the ID of the first platform a game is listed on.
+func firstPlatformID(game map[string]interface{}) int {
+	platforms, _ := game["platforms"].([]interface{})
+	for _, pl := range platforms {
+		if plMap, ok := pl.(map[string]interface{}); ok {
+			if id := int(getFloat64(plMap, "platform_id")); id > 0 {
+				return id
+			}
+		}
+	}
+	return 0
+}
@@ -23,18 +23,6 @@ import (
 // MobyGamesTagRegex matches MobyGames ID tags in filenames like (moby-12345)
 var MobyGamesTagRegex = regexp.MustCompile(`(?i)\(moby-(\d+)\)`)
 
-// SonySerialRegex matches PS1/PS2/PSP serial codes like SLUS-12345, SCUS-97328
-var SonySerialRegex = regexp.MustCompile(`(?i)([A-Z]{4})[_-](\d{5})`)
-
-// PS2OPLRegex matches PS2 OPL format like SLUS_123.45
-var PS2OPLRegex = regexp.MustCompile(`(?i)([A-Z]{4})_(\d{3})\.(\d{2})`)
-
-// SwitchTitleDBRegex matches Nintendo Switch 16-character hex IDs
-var SwitchTitleDBRegex = regexp.MustCompile(`([0-9A-Fa-f]{16})`)
-
-// SwitchProductIDRegex matches Switch product IDs like LA-H-AAAAA
-var SwitchProductIDRegex = regexp.MustCompile(`(?i)[A-Z]{2}-[A-Z]-([A-Z0-9]{5})`)
-
 // MAMEArcadeRegex matches MAME ROM names
 var MAMEArcadeRegex = regexp.MustCompile(`(?i)^([a-z0-9_]+)$`)
 
@@ -44,8 +32,19 @@ type Provider struct {
 	baseURL    string
 	userAgent  string
 	httpClient *http.Client
+	// regions is the region priority for choosing a cover group
+	regions []retrometadata.Region
+	limiter *provider.RateLimiter
 }
 
+// DefaultRateLimit is the number of requests per second MobyGames allows per
+// API key. Keys that exceed it get banned, so the provider stays under it
+// unless ProviderConfig.RateLimit says otherwise.
+const DefaultRateLimit = 1.0
+
+// rateLimitCooldown is how long to wait after a 429 without a Retry-After header.
+const rateLimitCooldown = 5 * time.Second
+
 // Options contains optional configuration for the MobyGames provider.
 type Options struct {
 	BaseURL string // Override the MobyGames API base URL (for testing)
@@ -57,17 +56,44 @@ func NewProvider(config retrometadata.ProviderConfig, c cache.Cache) (*Provider,
 }
 
 // NewProviderWithOptions creates a new MobyGames provider instance with custom options.
+// The "regions" config option sets the region priority used to pick covers,
+// e.g. ["eu", "us"]; it defaults to the client's default region priority.
+// Requests are limited to config.RateLimit per second, or DefaultRateLimit if
+// it's zero; a negative RateLimit disables the limit.
 func NewProviderWithOptions(config retrometadata.ProviderConfig, c cache.Cache, opts Options) (*Provider, error) {
+	base, err := provider.NewBaseProvider("mobygames", config, c)
+	if err != nil {
+		return nil, err
+	}
+	if err = provider.CheckProxy(config); err != nil {
+		return nil, err
+	}
+
 	baseURL := "https://api.mobygames.com/v1"
 	if opts.BaseURL != "" {
 		baseURL = opts.BaseURL
 	}
 
 	p := &Provider{
-		BaseProvider: provider.NewBaseProvider("mobygames", config, c),
+		BaseProvider: base,
 		baseURL:      baseURL,
 		userAgent:    "retro-metadata/1.0",
-		httpClient:   &http.Client{Timeout: 30 * time.Second},
+		httpClient:   provider.NewHTTPClient(config),
+		regions:      retrometadata.ParseRegions(retrometadata.DefaultConfig().RegionPriority),
+	}
+	p.limiter = provider.NewConfigRateLimiter(config, DefaultRateLimit)
+	switch regions := config.Options["regions"].(type) {
+	case []string:
+		p.regions = retrometadata.ParseRegions(regions)
+	case []any:
+		p.regions = nil
+		for _, r := range regions {
+			if s, ok := r.(string); ok {
+				if region, ok := retrometadata.ParseRegion(s); ok {
+					p.regions = append(p.regions, region)
+				}
+			}
+		}
 	}
 	p.SetMinSimilarityScore(0.6)
 	return p, nil
@@ -78,9 +104,22 @@ func (p *Provider) apiKey() string {
 }
 
 func (p *Provider) request(ctx context.Context, endpoint string, params map[string]string) (interface{}, error) {
+	var result interface{}
+	if err := p.requestInto(ctx, endpoint, params, &result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// requestInto makes an API request and decodes the response into out.
+func (p *Provider) requestInto(ctx context.Context, endpoint string, params map[string]string, out any) error {
+	if p.apiKey() == "" {
+		return retrometadata.CredentialsRequired(p.Name(), "API requests")
+	}
+
 	u, err := url.Parse(p.baseURL + endpoint)
 	if err != nil {
-		return nil, fmt.Errorf("failed to parse URL: %w", err)
+		return fmt.Errorf("failed to parse URL: %w", err)
 	}
 
 	q := u.Query()
@@ -90,48 +129,88 @@ func (p *Provider) request(ctx context.Context, endpoint string, params map[stri
 	}
 	u.RawQuery = q.Encode()
 
-	req, err := http.NewRequestWithContext(ctx, "GET", u.String(), nil)
+	body, err := p.get(ctx, u.String())
 	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
+		return err
 	}
-	req.Header.Set("User-Agent", p.userAgent)
 
-	resp, err := p.httpClient.Do(req)
-	if err != nil {
-		return nil, &retrometadata.ProviderError{Provider: p.Name(), Err: retrometadata.ErrProviderConnection}
+	if err := json.Unmarshal(body, out); err != nil {
+		return fmt.Errorf("failed to parse response: %w", err)
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode == 401 {
-		return nil, &retrometadata.ProviderError{Provider: p.Name(), Err: retrometadata.ErrProviderAuth}
-	}
+	return nil
+}
 
-	if resp.StatusCode == 429 {
-		return nil, &retrometadata.ProviderError{Provider: p.Name(), Err: retrometadata.ErrProviderRateLimit}
-	}
+// get fetches a URL, waiting for the rate limiter first. A 429 response is
+// retried once after the cooldown the server asks for; other statuses that
+// aren't 2xx fail with retrometadata.HTTPError.
+func (p *Provider) get(ctx context.Context, u string) ([]byte, error) {
+	for attempt := 0; ; attempt++ {
+		if err := p.limiter.Wait(ctx); err != nil {
+			return nil, err
+		}
 
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read response: %w", err)
-	}
+		req, err := http.NewRequestWithContext(ctx, "GET", u, nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create request: %w", err)
+		}
+		req.Header.Set("User-Agent", p.userAgent)
 
-	var result interface{}
-	if err := json.Unmarshal(body, &result); err != nil {
-		return nil, fmt.Errorf("failed to parse response: %w", err)
-	}
+		resp, err := p.httpClient.Do(req)
+		if err != nil {
+			return nil, retrometadata.RequestError(ctx, p.Name(), err)
+		}
+		body, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+
+		if resp.StatusCode == 429 {
+			cooldown := provider.RetryAfter(resp.Header, rateLimitCooldown)
+			if attempt == 0 {
+				p.limiter.Backoff(cooldown)
+				if p.limiter == nil {
+					if err := provider.Sleep(ctx, cooldown); err != nil {
+						return nil, err
+					}
+				}
+				continue
+			}
+			return nil, &retrometadata.RateLimitError{Provider: p.Name(), StatusCode: resp.StatusCode, RetryAfter: int(cooldown.Seconds())}
+		}
+		if err := retrometadata.HTTPError(p.Name(), resp); err != nil {
+			return nil, err
+		}
 
-	return result, nil
+		if err != nil {
+			return nil, fmt.Errorf("failed to read response: %w", err)
+		}
+		return body, nil
+	}
 }
 
 // Search searches for games by name.
 func (p *Provider) Search(ctx context.Context, query string, opts retrometadata.SearchOptions) ([]retrometadata.SearchResult, error) {
+	page, err := p.SearchPage(ctx, query, opts)
+	if err != nil || page == nil {
+		return nil, err
+	}
+	return page.Results, nil
+}
+
+// SearchPage searches for games by name, returning the page at opts.StartOffset().
+// MobyGames doesn't report a total, so a full page is assumed to have more after it.
+func (p *Provider) SearchPage(ctx context.Context, query string, opts retrometadata.SearchOptions) (*retrometadata.SearchPage, error) {
 	if !p.IsEnabled() {
 		return nil, nil
 	}
 
+	limit := max(opts.Limit, 10)
+	offset := opts.StartOffset()
 	params := map[string]string{
 		"title": query,
-		"limit": strconv.Itoa(max(opts.Limit, 10)),
+		"limit": strconv.Itoa(limit),
+	}
+	if offset > 0 {
+		params["offset"] = strconv.Itoa(offset)
 	}
 
 	if opts.PlatformID != nil {
@@ -195,7 +274,7 @@ func (p *Provider) Search(ctx context.Context, query string, opts retrometadata.
 		searchResults = append(searchResults, sr)
 	}
 
-	return searchResults, nil
+	return retrometadata.NewSearchPage(searchResults, offset, limit, -1), nil
 }
 
 // GetByID gets game details by MobyGames ID.
@@ -205,6 +284,9 @@ func (p *Provider) GetByID(ctx context.Context, gameID int) (*retrometadata.Game
 	}
 
 	result, err := p.request(ctx, fmt.Sprintf("/games/%d", gameID), nil)
+	if retrometadata.HTTPStatus(err) == http.StatusNotFound {
+		return nil, nil
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -214,7 +296,7 @@ func (p *Provider) GetByID(ctx context.Context, gameID int) (*retrometadata.Game
 		return nil, nil
 	}
 
-	return p.buildGameResult(game), nil
+	return p.buildGameResult(ctx, game, firstPlatformID(game)), nil
 }
 
 // Identify identifies a game from a ROM filename.
@@ -237,24 +319,9 @@ func (p *Provider) Identify(ctx context.Context, filename string, opts retrometa
 		return nil, nil
 	}
 
-	var searchTerm string
-
-	// Try Sony serial format for PS1/PS2/PSP platforms
-	// MobyGames platform IDs: PS1=6, PS2=7, PSP=46
+	// Try the serial for platforms that have them
 	platformID := *opts.PlatformID
-	if platformID == 6 || platformID == 7 || platformID == 46 {
-		if serial := extractSerialCode(filename); serial != "" {
-			searchTerm = serial
-		}
-	}
-
-	// Try Nintendo Switch ID formats (platform ID 203)
-	if platformID == 203 && searchTerm == "" {
-		_, productID := extractSwitchID(filename)
-		if productID != "" {
-			searchTerm = productID
-		}
-	}
+	searchTerm := provider.Serial(p.Name(), filename, opts)
 
 	// Try MAME format for arcade platform (ID 143)
 	if platformID == 143 && searchTerm == "" {
@@ -318,11 +385,11 @@ func (p *Provider) Identify(ctx context.Context, filename string, opts retrometa
 		}
 	}
 
-	bestMatch, score := p.FindBestMatch(searchTerm, names)
+	bestMatch, score := p.FindBestMatchContext(ctx, searchTerm, names)
 
 	if bestMatch != "" {
 		if game, ok := gamesByName[bestMatch]; ok {
-			gameResult := p.buildGameResult(game)
+			gameResult := p.buildGameResult(ctx, game, platformID)
 			gameResult.MatchScore = score
 			return gameResult, nil
 		}
@@ -337,7 +404,9 @@ func (p *Provider) Heartbeat(ctx context.Context) error {
 	return err
 }
 
-func (p *Provider) buildGameResult(game map[string]interface{}) *retrometadata.GameResult {
+// buildGameResult converts a game to a result. When platformID is set, the
+// sample cover and screenshots are replaced by the full artwork for that platform.
+func (p *Provider) buildGameResult(ctx context.Context, game map[string]interface{}, platformID int) *retrometadata.GameResult {
 	providerID := int(getFloat64(game, "game_id"))
 	result := &retrometadata.GameResult{
 		Provider:    p.Name(),
@@ -364,6 +433,8 @@ func (p *Provider) buildGameResult(game map[string]interface{}) *retrometadata.G
 		}
 	}
 
+	p.addArtwork(ctx, result, platformID)
+
 	// Extract metadata
 	result.Metadata = p.extractMetadata(game)
 
@@ -439,39 +510,6 @@ func (p *Provider) GetPlatform(slug string) *retrometadata.Platform {
 	}
 }
 
-func extractSerialCode(filename string) string {
-	// Try PS2 OPL format first (SLUS_123.45)
-	if match := PS2OPLRegex.FindStringSubmatch(filename); len(match) > 3 {
-		prefix := strings.ToUpper(match[1])
-		part1 := match[2]
-		part2 := match[3]
-		return fmt.Sprintf("%s-%s%s", prefix, part1, part2)
-	}
-
-	// Try standard Sony serial format (SLUS-12345 or SLUS_12345)
-	if match := SonySerialRegex.FindStringSubmatch(filename); len(match) > 2 {
-		prefix := strings.ToUpper(match[1])
-		number := match[2]
-		return fmt.Sprintf("%s-%s", prefix, number)
-	}
-
-	return ""
-}
-
-func extractSwitchID(filename string) (titleID, productID string) {
-	// Try titleID format (16-char hex)
-	if match := SwitchTitleDBRegex.FindStringSubmatch(filename); len(match) > 1 {
-		titleID = strings.ToUpper(match[1])
-	}
-
-	// Try productID format (LA-H-AAAAA)
-	if match := SwitchProductIDRegex.FindStringSubmatch(filename); len(match) > 1 {
-		productID = strings.ToUpper(match[1])
-	}
-
-	return titleID, productID
-}
-
 func isMAMEFormat(filename string) bool {
 	// Remove extension first
 	name := regexp.MustCompile(`\.[^.]+$`).ReplaceAllString(filename, "")
@@ -0,0 +1,97 @@
+package mobygames
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/josegonzalez/retro-metadata/pkg/cache"
+	"github.com/josegonzalez/retro-metadata/pkg/retrometadata"
+)
+
+func newTestProvider(t *testing.T, handler http.HandlerFunc, rateLimit float64) *Provider {
+	t.Helper()
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	config := retrometadata.ProviderConfig{
+		Enabled:     true,
+		Credentials: map[string]string{"api_key": "key"},
+		RateLimit:   rateLimit,
+	}
+	p, err := NewProviderWithOptions(config, cache.NewMemoryCache(), Options{BaseURL: server.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+func TestRateLimit(t *testing.T) {
+	p := newTestProvider(t, func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"games": []}`))
+	}, 20)
+
+	start := time.Now()
+	for range 3 {
+		if _, err := p.request(context.Background(), "/games", nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Three requests at 20/s take at least two 50ms intervals
+	if elapsed := time.Since(start); elapsed < 100*time.Millisecond {
+		t.Errorf("3 requests took %v, want at least 100ms at 20 req/s", elapsed)
+	}
+}
+
+func TestRateLimitRetry(t *testing.T) {
+	var requests atomic.Int32
+	p := newTestProvider(t, func(w http.ResponseWriter, r *http.Request) {
+		if requests.Add(1) == 1 {
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		_, _ = w.Write([]byte(`{"games": []}`))
+	}, -1)
+
+	if _, err := p.request(context.Background(), "/games", nil); err != nil {
+		t.Fatalf("request() after a single 429 = %v, want a transparent retry", err)
+	}
+	if n := requests.Load(); n != 2 {
+		t.Errorf("made %d requests, want 2", n)
+	}
+
+	// A second 429 in a row is returned to the caller
+	p = newTestProvider(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Retry-After", "0")
+		w.WriteHeader(http.StatusTooManyRequests)
+	}, -1)
+	_, err := p.request(context.Background(), "/games", nil)
+	if !errors.Is(err, retrometadata.ErrProviderRateLimit) {
+		t.Errorf("request() after two 429s = %v, want a rate limit error", err)
+	}
+}
+
+func TestServerError(t *testing.T) {
+	p := newTestProvider(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		_, _ = w.Write([]byte("<html>Service Unavailable</html>"))
+	}, -1)
+
+	_, err := p.Search(context.Background(), "Zelda", retrometadata.SearchOptions{})
+	if !retrometadata.Retryable(err) || retrometadata.HTTPStatus(err) != http.StatusServiceUnavailable {
+		t.Errorf("Search() error = %v, want a retryable 503", err)
+	}
+
+	p = newTestProvider(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = w.Write([]byte(`{"error": "not found"}`))
+	}, -1)
+	if game, err := p.GetByID(context.Background(), 1); game != nil || err != nil {
+		t.Errorf("GetByID() of a missing game = %v, %v; want nil, nil", game, err)
+	}
+}
@@ -4,13 +4,12 @@ package playmatch
 import (
 	"context"
 	"encoding/json"
-	"fmt"
 	"io"
 	"net/http"
 	"net/url"
 	"strconv"
-	"time"
 
+	"github.com/josegonzalez/retro-metadata/pkg/provider"
 	retrometadata "github.com/josegonzalez/retro-metadata/pkg/retrometadata"
 )
 
@@ -27,7 +26,7 @@ const (
 
 var (
 	// ErrProviderDisabled is returned when the provider is disabled.
-	ErrProviderDisabled = fmt.Errorf("provider is disabled")
+	ErrProviderDisabled = retrometadata.ErrProviderDisabled
 )
 
 // Provider implements the Playmatch hash-matching provider.
@@ -40,14 +39,9 @@ type Provider struct {
 
 // New creates a new Playmatch provider.
 func New(config *retrometadata.ProviderConfig) *Provider {
-	timeout := time.Duration(config.Timeout) * time.Second
-	if timeout == 0 {
-		timeout = 30 * time.Second
-	}
-
 	return &Provider{
 		config:    config,
-		client:    &http.Client{Timeout: timeout},
+		client:    provider.NewHTTPClient(*config),
 		baseURL:   "https://playmatch.retrorealm.dev/api",
 		userAgent: "retro-metadata/1.0",
 	}
@@ -73,13 +67,16 @@ func (p *Provider) request(ctx context.Context, endpoint string, params url.Valu
 
 	resp, err := p.client.Do(req)
 	if err != nil {
-		return nil, &retrometadata.ConnectionError{Provider: p.Name(), Details: err.Error()}
+		return nil, retrometadata.RequestError(ctx, p.Name(), err)
 	}
 	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
+	if resp.StatusCode == http.StatusNotFound {
 		return nil, nil
 	}
+	if err := retrometadata.HTTPError(p.Name(), resp); err != nil {
+		return nil, err
+	}
 
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
@@ -0,0 +1,35 @@
+package playmatch
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/josegonzalez/retro-metadata/pkg/retrometadata"
+)
+
+func TestServerError(t *testing.T) {
+	var status atomic.Int32
+	status.Store(http.StatusServiceUnavailable)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(int(status.Load()))
+		_, _ = w.Write([]byte("<html>Service Unavailable</html>"))
+	}))
+	defer server.Close()
+
+	p := New(&retrometadata.ProviderConfig{Enabled: true})
+	p.baseURL = server.URL
+	ctx := context.Background()
+
+	_, err := p.LookupByHash(ctx, "game.sfc", 1024, "21f3e98df4780ee1c667b84e57d88675", "")
+	if !retrometadata.Retryable(err) || retrometadata.HTTPStatus(err) != http.StatusServiceUnavailable {
+		t.Errorf("LookupByHash() error = %v, want a retryable 503", err)
+	}
+
+	status.Store(http.StatusNotFound)
+	if result, err := p.LookupByHash(ctx, "game.sfc", 1024, "21f3e98df4780ee1c667b84e57d88675", ""); result != nil || err != nil {
+		t.Errorf("LookupByHash() of an unknown ROM = %v, %v; want nil, nil", result, err)
+	}
+}
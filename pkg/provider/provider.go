@@ -5,11 +5,14 @@ import (
 	"context"
 	"fmt"
 	"regexp"
+	"time"
 
 	"github.com/josegonzalez/retro-metadata/pkg/cache"
-	"github.com/josegonzalez/retro-metadata/pkg/internal/matching"
 	"github.com/josegonzalez/retro-metadata/pkg/internal/normalization"
+	"github.com/josegonzalez/retro-metadata/pkg/matching"
+	"github.com/josegonzalez/retro-metadata/pkg/platform"
 	"github.com/josegonzalez/retro-metadata/pkg/retrometadata"
+	"github.com/josegonzalez/retro-metadata/pkg/serials"
 )
 
 // Provider is the interface that all metadata providers must implement.
@@ -43,20 +46,31 @@ type HashProvider interface {
 
 // BaseProvider provides common functionality for providers.
 type BaseProvider struct {
-	name              string
-	config            retrometadata.ProviderConfig
-	cache             cache.Cache
+	name               string
+	config             retrometadata.ProviderConfig
+	cache              cache.Cache
 	minSimilarityScore float64
+	scorer             matching.Scorer
 }
 
-// NewBaseProvider creates a new BaseProvider.
-func NewBaseProvider(name string, config retrometadata.ProviderConfig, c cache.Cache) *BaseProvider {
+// NewBaseProvider creates a new BaseProvider. It returns a
+// *retrometadata.ConfigError if config.MatchScorer isn't a known scorer.
+func NewBaseProvider(name string, config retrometadata.ProviderConfig, c cache.Cache) (*BaseProvider, error) {
+	scorer, ok := matching.ScorerByName(config.MatchScorer)
+	if !ok {
+		return nil, &retrometadata.ConfigError{
+			Field:   name + ".match_scorer",
+			Details: fmt.Sprintf("unknown scorer %q, want %q, %q or %q", config.MatchScorer, matching.ScorerJaroWinkler, matching.ScorerLevenshtein, matching.ScorerTokenSet),
+		}
+	}
+
 	return &BaseProvider{
-		name:              name,
-		config:            config,
-		cache:             c,
+		name:               name,
+		config:             config,
+		cache:              c,
 		minSimilarityScore: matching.DefaultMinSimilarity,
-	}
+		scorer:             scorer,
+	}, nil
 }
 
 // Name returns the provider name.
@@ -84,6 +98,12 @@ func (p *BaseProvider) GetCredential(key string) string {
 	return p.config.GetCredential(key)
 }
 
+// Languages returns the languages to prefer for localized text, from the
+// configured locale.
+func (p *BaseProvider) Languages() []retrometadata.Language {
+	return retrometadata.LanguagePriority(p.config.Locale)
+}
+
 // NormalizeSearchTerm normalizes a search term for comparison.
 func (p *BaseProvider) NormalizeSearchTerm(name string) string {
 	return normalization.NormalizeSearchTermDefault(name)
@@ -97,21 +117,51 @@ func (p *BaseProvider) NormalizeCoverURL(url string) string {
 // FindBestMatch finds the best matching name from candidates.
 func (p *BaseProvider) FindBestMatch(searchTerm string, candidates []string) (string, float64) {
 	return matching.FindBestMatch(searchTerm, candidates, matching.FindBestMatchOptions{
-		MinSimilarityScore: p.minSimilarityScore,
+		MinSimilarityScore: p.MinSimilarityScore(),
 		Normalize:          true,
+		Scorer:             p.scorer,
+	})
+}
+
+// FindBestMatchContext finds the best matching name from candidates, like
+// FindBestMatch, recording the match in the context's match trace and
+// resolving ambiguous matches with its ambiguity handler.
+func (p *BaseProvider) FindBestMatchContext(ctx context.Context, searchTerm string, candidates []string) (string, float64) {
+	return FindBestMatch(ctx, p.name, searchTerm, candidates, matching.FindBestMatchOptions{
+		MinSimilarityScore: p.MinSimilarityScore(),
+		Normalize:          true,
+		Scorer:             p.scorer,
 	})
 }
 
 // FindBestMatchWithOptions finds the best match with custom options.
+// The provider's scorer is used when opts.Scorer is nil.
 func (p *BaseProvider) FindBestMatchWithOptions(searchTerm string, candidates []string, opts matching.FindBestMatchOptions) (string, float64) {
+	if opts.Scorer == nil {
+		opts.Scorer = p.scorer
+	}
 	return matching.FindBestMatch(searchTerm, candidates, opts)
 }
 
-// SetMinSimilarityScore sets the minimum similarity score for matching.
+// SetMinSimilarityScore sets the provider's default minimum similarity score for matching.
+// A MinMatchScore set in the provider configuration takes precedence.
 func (p *BaseProvider) SetMinSimilarityScore(score float64) {
 	p.minSimilarityScore = score
 }
 
+// MinSimilarityScore returns the effective minimum similarity score for matching.
+func (p *BaseProvider) MinSimilarityScore() float64 {
+	if p.config.MinMatchScore > 0 {
+		return p.config.MinMatchScore
+	}
+	return p.minSimilarityScore
+}
+
+// SetScorer sets the similarity function used for matching.
+func (p *BaseProvider) SetScorer(scorer matching.Scorer) {
+	p.scorer = scorer
+}
+
 // ExtractIDFromFilename extracts a provider ID from a filename using a regex pattern.
 func (p *BaseProvider) ExtractIDFromFilename(filename string, pattern *regexp.Regexp) *int {
 	match := pattern.FindStringSubmatch(filename)
@@ -124,6 +174,63 @@ func (p *BaseProvider) ExtractIDFromFilename(filename string, pattern *regexp.Re
 	return nil
 }
 
+// MatchOptions builds fuzzy matching options from a provider configuration.
+// The configured MinMatchScore and MatchScorer take precedence over defaultMinScore
+// and the default Jaro-Winkler scorer.
+func MatchOptions(config retrometadata.ProviderConfig, defaultMinScore float64) matching.FindBestMatchOptions {
+	opts := matching.DefaultFindBestMatchOptions()
+	opts.MinSimilarityScore = defaultMinScore
+	if config.MinMatchScore > 0 {
+		opts.MinSimilarityScore = config.MinMatchScore
+	}
+	if scorer, ok := matching.ScorerByName(config.MatchScorer); ok {
+		opts.Scorer = scorer
+	}
+	return opts
+}
+
+// FindBestMatch finds the best matching name from candidates with
+// matching.FindBestMatch, recording the match under the provider's name in
+// the context's match trace, if it has one, and resolving ambiguous matches
+// with the context's ambiguity handler, if it has one.
+func FindBestMatch(ctx context.Context, providerName, searchTerm string, candidates []string, opts matching.FindBestMatchOptions) (string, float64) {
+	trace := retrometadata.MatchTraceFromContext(ctx)
+	if trace == nil && !retrometadata.HasAmbiguityHandler(ctx) {
+		return matching.FindBestMatch(searchTerm, candidates, opts)
+	}
+	opts.Trace = &matching.Trace{}
+	matching.FindBestMatch(searchTerm, candidates, opts)
+	trace.AddMatch(providerName, *opts.Trace)
+	return retrometadata.ResolveAmbiguity(ctx, providerName, *opts.Trace)
+}
+
+// FindBestMatchIndex finds the best matching name in a pre-built index, like
+// FindBestMatch, for providers matching against a large local catalog.
+func FindBestMatchIndex(ctx context.Context, providerName, searchTerm string, ix *matching.Index, opts matching.FindBestMatchOptions) (string, float64) {
+	trace := retrometadata.MatchTraceFromContext(ctx)
+	if trace == nil && !retrometadata.HasAmbiguityHandler(ctx) {
+		return ix.FindBestMatch(searchTerm, opts)
+	}
+	opts.Trace = &matching.Trace{}
+	ix.FindBestMatch(searchTerm, opts)
+	trace.AddMatch(providerName, *opts.Trace)
+	return retrometadata.ResolveAmbiguity(ctx, providerName, *opts.Trace)
+}
+
+// Serial returns the serial to look a file up by: opts.Serial, or the serial
+// extracted from the filename for the platform opts.PlatformID is the
+// provider's ID of.
+func Serial(providerName, filename string, opts retrometadata.IdentifyOptions) string {
+	if opts.Serial != "" {
+		return opts.Serial
+	}
+	var slug platform.Slug
+	if opts.PlatformID != nil {
+		slug = platform.SlugFromProviderID(providerName, *opts.PlatformID)
+	}
+	return serials.Extract(filename, slug)
+}
+
 // SplitSearchTerm splits a search term by common delimiters.
 func (p *BaseProvider) SplitSearchTerm(name string) []string {
 	return normalization.SplitSearchTerm(name)
@@ -137,14 +244,56 @@ func (p *BaseProvider) GetCached(ctx context.Context, key string) (any, error) {
 	return p.cache.Get(ctx, p.name+":"+key)
 }
 
-// SetCached stores a value in cache if available.
+// SetCached stores a value in cache if available, unless the context
+// disables cache writes.
 func (p *BaseProvider) SetCached(ctx context.Context, key string, value any) error {
-	if p.cache == nil {
+	if p.cache == nil || retrometadata.CacheWritesDisabled(ctx) {
 		return nil
 	}
 	return p.cache.Set(ctx, p.name+":"+key, value, 0)
 }
 
+// SetCachedTTL stores a value in cache with an explicit TTL if available,
+// unless the context disables cache writes.
+func (p *BaseProvider) SetCachedTTL(ctx context.Context, key string, value any, ttl time.Duration) error {
+	if p.cache == nil || retrometadata.CacheWritesDisabled(ctx) {
+		return nil
+	}
+	return p.cache.Set(ctx, p.name+":"+key, value, ttl)
+}
+
+// DeleteCached removes a value from cache if available.
+func (p *BaseProvider) DeleteCached(ctx context.Context, key string) error {
+	if p.cache == nil {
+		return nil
+	}
+	_, err := p.cache.Delete(ctx, p.name+":"+key)
+	return err
+}
+
+// GetCachedTyped retrieves a value of type T from a provider's cache with
+// cache.GetTyped, so values cached by persistent backends decode into T.
+// It returns false if there's no cache, the key isn't found or the cached
+// value isn't a T.
+func GetCachedTyped[T any](ctx context.Context, p *BaseProvider, key string) (T, bool) {
+	var value T
+	if p.cache == nil {
+		return value, false
+	}
+	value, ok, err := cache.GetTyped[T](ctx, p.cache, p.name+":"+key)
+	return value, ok && err == nil
+}
+
+// SetCachedTyped stores a value of type T in a provider's cache with
+// cache.SetTyped, with an explicit TTL (0 = the default), unless the context
+// disables cache writes.
+func SetCachedTyped[T any](ctx context.Context, p *BaseProvider, key string, value T, ttl time.Duration) error {
+	if p.cache == nil || retrometadata.CacheWritesDisabled(ctx) {
+		return nil
+	}
+	return cache.SetTyped(ctx, p.cache, p.name+":"+key, value, ttl)
+}
+
 // Close is a no-op by default. Providers should override if cleanup is needed.
 func (p *BaseProvider) Close() error {
 	return nil
@@ -0,0 +1,81 @@
+package provider
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// RateLimiter spaces requests evenly so that no more than a given number are
+// started per second. A nil RateLimiter doesn't limit.
+type RateLimiter struct {
+	mu       sync.Mutex
+	interval time.Duration
+	next     time.Time
+}
+
+// NewRateLimiter returns a limiter allowing perSecond requests per second,
+// or nil (no limit) if perSecond isn't positive.
+func NewRateLimiter(perSecond float64) *RateLimiter {
+	if perSecond <= 0 {
+		return nil
+	}
+	return &RateLimiter{interval: time.Duration(float64(time.Second) / perSecond)}
+}
+
+// Wait blocks until the next request may start or ctx is done.
+func (l *RateLimiter) Wait(ctx context.Context) error {
+	if l == nil {
+		return ctx.Err()
+	}
+
+	l.mu.Lock()
+	now := time.Now()
+	start := l.next
+	if start.Before(now) {
+		start = now
+	}
+	l.next = start.Add(l.interval)
+	l.mu.Unlock()
+
+	return Sleep(ctx, time.Until(start))
+}
+
+// Backoff delays the next request until at least d from now, e.g. after the
+// server asked the client to slow down.
+func (l *RateLimiter) Backoff(d time.Duration) {
+	if l == nil {
+		return
+	}
+	l.mu.Lock()
+	if next := time.Now().Add(d); next.After(l.next) {
+		l.next = next
+	}
+	l.mu.Unlock()
+}
+
+// Sleep waits for d or until ctx is done, returning ctx's error in the latter case.
+func Sleep(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// RetryAfter parses a Retry-After header given in seconds. Returns fallback
+// if the header is missing or not a number of seconds.
+func RetryAfter(header http.Header, fallback time.Duration) time.Duration {
+	if seconds, err := strconv.Atoi(header.Get("Retry-After")); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	return fallback
+}
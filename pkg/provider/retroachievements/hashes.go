@@ -0,0 +1,127 @@
+package retroachievements
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/josegonzalez/retro-metadata/pkg/filename"
+	"github.com/josegonzalez/retro-metadata/pkg/provider"
+	"github.com/josegonzalez/retro-metadata/pkg/retrometadata"
+)
+
+// GameHash is a ROM hash RetroAchievements supports for a game.
+type GameHash struct {
+	// MD5 is the RetroAchievements hash of the ROM
+	MD5 string `json:"md5"`
+	// Name is the name of the ROM, usually its No-Intro or Redump name
+	Name string `json:"name"`
+	// Labels are the sets the ROM comes from (e.g., "nointro", "redump", "rapatches")
+	Labels []string `json:"labels,omitempty"`
+	// PatchURL is the URL of the patch needed to produce the ROM, if any
+	PatchURL string `json:"patch_url,omitempty"`
+}
+
+// signature returns the hash as a signature match.
+func (h GameHash) signature() retrometadata.SignatureMatch {
+	return retrometadata.SignatureMatch{
+		Source:   retrometadata.SignatureSourceRetroAchievements,
+		Name:     h.Name,
+		Revision: filename.ParseNoIntroFilename(h.Name).Version,
+		Labels:   h.Labels,
+	}
+}
+
+// GetGameHashes returns the hashes RetroAchievements supports for a game,
+// with their ROM names and labels.
+func (p *Provider) GetGameHashes(ctx context.Context, gameID int) ([]GameHash, error) {
+	if !p.IsEnabled() {
+		return nil, nil
+	}
+
+	key := fmt.Sprintf("hashes:%d", gameID)
+	if hashes, ok := provider.GetCachedTyped[[]GameHash](ctx, p.BaseProvider, key); ok {
+		return hashes, nil
+	}
+
+	result, err := p.request(ctx, "/API_GetGameHashes.php", map[string]string{"i": strconv.Itoa(gameID)})
+	if err != nil {
+		return nil, err
+	}
+
+	resultMap, _ := result.(map[string]interface{})
+	results, _ := resultMap["Results"].([]interface{})
+	hashes := make([]GameHash, 0, len(results))
+	for _, r := range results {
+		rMap, ok := r.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		hash := GameHash{
+			MD5:      strings.ToLower(getString(rMap, "MD5")),
+			Name:     getString(rMap, "Name"),
+			PatchURL: getString(rMap, "PatchUrl"),
+		}
+		if labels, ok := rMap["Labels"].([]interface{}); ok {
+			for _, l := range labels {
+				if label, ok := l.(string); ok {
+					hash.Labels = append(hash.Labels, label)
+				}
+			}
+		}
+		hashes = append(hashes, hash)
+	}
+
+	_ = provider.SetCachedTyped(ctx, p.BaseProvider, key, hashes, 0)
+	return hashes, nil
+}
+
+// hashIndex maps MD5 hashes to game IDs for one platform's game list.
+type hashIndex struct {
+	// games is the list the index was built from, to detect refreshes
+	games []interface{}
+	ids   map[string]int
+}
+
+// hashIndex returns the MD5 to game ID index for a platform, rebuilding it
+// when the cached game list changes.
+func (p *Provider) hashIndex(ctx context.Context, platformID int) (map[string]int, error) {
+	games, err := p.gameList(ctx, platformID)
+	if err != nil {
+		return nil, err
+	}
+
+	p.hashIndexMu.Lock()
+	defer p.hashIndexMu.Unlock()
+
+	if index, ok := p.hashIndexes[platformID]; ok && sameList(index.games, games) {
+		return index.ids, nil
+	}
+
+	ids := make(map[string]int)
+	for _, g := range games {
+		game, ok := g.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		hashes, _ := game["Hashes"].([]interface{})
+		for _, h := range hashes {
+			if hash, ok := h.(string); ok {
+				ids[strings.ToLower(hash)] = getInt(game, "ID")
+			}
+		}
+	}
+
+	if p.hashIndexes == nil {
+		p.hashIndexes = make(map[int]hashIndex)
+	}
+	p.hashIndexes[platformID] = hashIndex{games: games, ids: ids}
+	return ids, nil
+}
+
+// sameList reports whether a and b are the same slice, as returned by the
+// cache for an unchanged entry.
+func sameList(a, b []interface{}) bool {
+	return len(a) == len(b) && (len(a) == 0 || &a[0] == &b[0])
+}
@@ -2,19 +2,24 @@
 package retroachievements
 
 import (
+	"cmp"
 	"context"
 	"encoding/json"
 	"fmt"
 	"io"
+	"iter"
 	"net/http"
 	"net/url"
 	"regexp"
+	"slices"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/josegonzalez/retro-metadata/pkg/cache"
 	"github.com/josegonzalez/retro-metadata/pkg/platform"
+	"github.com/josegonzalez/retro-metadata/pkg/progress"
 	"github.com/josegonzalez/retro-metadata/pkg/provider"
 	"github.com/josegonzalez/retro-metadata/pkg/retrometadata"
 )
@@ -49,15 +54,34 @@ type Provider struct {
 	baseURL    string
 	userAgent  string
 	httpClient *http.Client
+
+	// hashIndexes maps each platform's MD5 hashes to game IDs, built from
+	// the cached game list
+	hashIndexMu sync.Mutex
+	hashIndexes map[int]hashIndex
+
+	// indexDir is where game lists are saved between runs, if set
+	indexDir string
 }
 
 // NewProvider creates a new RetroAchievements provider instance.
 func NewProvider(config retrometadata.ProviderConfig, c cache.Cache) (*Provider, error) {
+	base, err := provider.NewBaseProvider("retroachievements", config, c)
+	if err != nil {
+		return nil, err
+	}
+	if err = provider.CheckProxy(config); err != nil {
+		return nil, err
+	}
+
 	p := &Provider{
-		BaseProvider: provider.NewBaseProvider("retroachievements", config, c),
+		BaseProvider: base,
 		baseURL:      "https://retroachievements.org/API",
 		userAgent:    "retro-metadata/1.0",
-		httpClient:   &http.Client{Timeout: 30 * time.Second},
+		httpClient:   provider.NewHTTPClient(config),
+	}
+	if dir, ok := config.Options["index_dir"].(string); ok {
+		p.indexDir = dir
 	}
 	p.SetMinSimilarityScore(0.6)
 	return p, nil
@@ -76,6 +100,10 @@ func (p *Provider) username() string {
 }
 
 func (p *Provider) request(ctx context.Context, endpoint string, params map[string]string) (interface{}, error) {
+	if p.apiKey() == "" {
+		return nil, retrometadata.CredentialsRequired(p.Name(), "API requests")
+	}
+
 	u, err := url.Parse(p.baseURL + endpoint)
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse URL: %w", err)
@@ -97,16 +125,12 @@ func (p *Provider) request(ctx context.Context, endpoint string, params map[stri
 
 	resp, err := p.httpClient.Do(req)
 	if err != nil {
-		return nil, &retrometadata.ProviderError{Provider: p.Name(), Err: retrometadata.ErrProviderConnection}
+		return nil, retrometadata.RequestError(ctx, p.Name(), err)
 	}
 	defer resp.Body.Close()
 
-	if resp.StatusCode == 401 {
-		return nil, &retrometadata.ProviderError{Provider: p.Name(), Err: retrometadata.ErrProviderAuth}
-	}
-
-	if resp.StatusCode == 429 {
-		return nil, &retrometadata.ProviderError{Provider: p.Name(), Err: retrometadata.ErrProviderRateLimit}
+	if err := retrometadata.HTTPError(p.Name(), resp); err != nil {
+		return nil, err
 	}
 
 	body, err := io.ReadAll(resp.Body)
@@ -122,23 +146,75 @@ func (p *Provider) request(ctx context.Context, endpoint string, params map[stri
 	return result, nil
 }
 
-// Search searches for games by name.
-// Note: RetroAchievements doesn't have a search endpoint, so this fetches the
-// game list for the platform and filters locally.
-func (p *Provider) Search(ctx context.Context, query string, opts retrometadata.SearchOptions) ([]retrometadata.SearchResult, error) {
-	if !p.IsEnabled() {
-		return nil, nil
+// Game list caching. A platform's list with hashes is kept for
+// gameListTTL; after gameListRefreshInterval, the much smaller list without
+// hashes is fetched to check for added or changed games, and the full list is
+// only downloaded again if there are any.
+const (
+	gameListTTL             = 30 * 24 * time.Hour
+	gameListRefreshInterval = 24 * time.Hour
+)
+
+// gameList returns the game list, with hashes, for a platform. It's
+// downloaded only if it isn't cached or has changed since it was cached.
+func (p *Provider) gameList(ctx context.Context, platformID int) ([]interface{}, error) {
+	key := fmt.Sprintf("gamelist:%d", platformID)
+	checkedKey := key + ":checked"
+
+	var cachedGames []interface{}
+	if cached, err := p.GetCached(ctx, key); err == nil {
+		cachedGames, _ = cached.([]interface{})
+	}
+	if cachedGames == nil {
+		// A list saved by an earlier run counts as checked when it was saved
+		if games, age, ok := p.loadGameList(platformID); ok {
+			cachedGames = games
+			_ = p.SetCachedTTL(ctx, key, games, gameListTTL-age)
+			if age < gameListRefreshInterval {
+				_ = p.SetCachedTTL(ctx, checkedKey, true, gameListRefreshInterval-age)
+			}
+		}
 	}
+	if cachedGames != nil {
+		if checked, err := p.GetCached(ctx, checkedKey); err == nil && checked != nil {
+			return cachedGames, nil
+		}
 
-	if opts.PlatformID == nil {
+		changed, err := p.gameListChanged(ctx, platformID, cachedGames)
+		if err != nil {
+			// Keep using the cached list while the API is unavailable
+			return cachedGames, nil
+		}
+		if !changed {
+			_ = p.SetCachedTTL(ctx, checkedKey, true, gameListRefreshInterval)
+			p.saveGameList(platformID, cachedGames)
+			return cachedGames, nil
+		}
+	}
+
+	games, err := p.fetchGameList(ctx, platformID, true)
+	if err != nil {
+		return nil, err
+	}
+	if games == nil {
 		return nil, nil
 	}
+	_ = p.SetCachedTTL(ctx, key, games, gameListTTL)
+	_ = p.SetCachedTTL(ctx, checkedKey, true, gameListRefreshInterval)
+	p.saveGameList(platformID, games)
+	return games, nil
+}
 
-	// Get game list for platform
+// fetchGameList downloads the list of games with achievements for a platform.
+func (p *Provider) fetchGameList(ctx context.Context, platformID int, withHashes bool) ([]interface{}, error) {
+	hashes := "0"
+	if withHashes {
+		hashes = "1"
+	}
 	params := map[string]string{
-		"i": strconv.Itoa(*opts.PlatformID),
+		"i": strconv.Itoa(platformID),
 		"f": "1", // Only games with achievements
-		"h": "0", // Don't include hashes
+		"h": hashes,
 	}
 
 	result, err := p.request(ctx, "/API_GetGameList.php", params)
@@ -146,59 +222,157 @@ func (p *Provider) Search(ctx context.Context, query string, opts retrometadata.
 		return nil, err
 	}
 
-	games, ok := result.([]interface{})
-	if !ok {
-		return nil, nil
-	}
+	games, _ := result.([]interface{})
+	return games, nil
+}
 
-	// Filter by query
-	queryLower := strings.ToLower(query)
-	limit := opts.Limit
-	if limit == 0 {
-		limit = 25
+// gameListChanged reports whether any game was added, removed or modified
+// since cached was downloaded, using the list without hashes.
+func (p *Provider) gameListChanged(ctx context.Context, platformID int, cached []interface{}) (bool, error) {
+	current, err := p.fetchGameList(ctx, platformID, false)
+	if err != nil {
+		return false, err
+	}
+	if len(current) != len(cached) {
+		return true, nil
 	}
 
-	var searchResults []retrometadata.SearchResult
-	for _, g := range games {
+	modified := make(map[int]string, len(cached))
+	for _, g := range cached {
+		if game, ok := g.(map[string]interface{}); ok {
+			modified[getInt(game, "ID")] = gameVersion(game)
+		}
+	}
+	for _, g := range current {
 		game, ok := g.(map[string]interface{})
 		if !ok {
 			continue
 		}
+		if version, ok := modified[getInt(game, "ID")]; !ok || version != gameVersion(game) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
 
-		title := getString(game, "Title")
-		if !strings.Contains(strings.ToLower(title), queryLower) {
-			continue
+// gameVersion identifies a revision of a game list entry.
+func gameVersion(game map[string]interface{}) string {
+	return getString(game, "DateModified") + "/" + strconv.Itoa(getInt(game, "NumAchievements"))
+}
+
+// DownloadGameLists downloads and caches the game lists, with hashes, for
+// each platform so later hash lookups don't need to fetch them.
+//
+// Platforms whose list is already cached aren't downloaded again unless they
+// changed, so a download that was cancelled resumes where it stopped when
+// called again with the same cache.
+// checkpoint, if not nil, is called after each platform.
+func (p *Provider) DownloadGameLists(ctx context.Context, platformIDs []int, checkpoint progress.CheckpointFunc) error {
+	if !p.IsEnabled() {
+		return nil
+	}
+
+	for i, platformID := range platformIDs {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if _, err := p.gameList(ctx, platformID); err != nil {
+			return err
 		}
+		if checkpoint != nil {
+			checkpoint(progress.Checkpoint{
+				Operation: "retroachievements-game-lists",
+				Completed: i + 1,
+				Total:     len(platformIDs),
+				Cursor:    strconv.Itoa(platformID),
+			})
+		}
+	}
+
+	return nil
+}
 
-		icon := getString(game, "ImageIcon")
-		coverURL := ""
-		if icon != "" {
-			coverURL = RAMediaURL + icon
+// Search searches for games by name.
+// Note: RetroAchievements doesn't have a search endpoint, so this fetches the
+// game list for the platform and filters locally.
+func (p *Provider) Search(ctx context.Context, query string, opts retrometadata.SearchOptions) ([]retrometadata.SearchResult, error) {
+	if opts.Limit == 0 {
+		opts.Limit = 25
+	}
+	return provider.CollectResults(p.SearchIter(ctx, query, opts))
+}
+
+// SearchIter yields the games of opts.PlatformID's game list whose titles
+// contain query.
+func (p *Provider) SearchIter(ctx context.Context, query string, opts retrometadata.SearchOptions) iter.Seq2[retrometadata.SearchResult, error] {
+	return func(yield func(retrometadata.SearchResult, error) bool) {
+		if !p.IsEnabled() || opts.PlatformID == nil {
+			return
 		}
 
-		sr := retrometadata.SearchResult{
-			Provider:   p.Name(),
-			ProviderID: getInt(game, "ID"),
-			Name:       title,
-			CoverURL:   coverURL,
-			Platforms:  []string{getString(game, "ConsoleName")},
+		games, err := p.gameList(ctx, *opts.PlatformID)
+		if err != nil {
+			yield(retrometadata.SearchResult{}, err)
+			return
 		}
 
-		searchResults = append(searchResults, sr)
-		if len(searchResults) >= limit {
-			break
+		queryLower := strings.ToLower(query)
+		yielded := 0
+		for _, g := range games {
+			game, ok := g.(map[string]interface{})
+			if !ok {
+				continue
+			}
+
+			title := getString(game, "Title")
+			if !strings.Contains(strings.ToLower(title), queryLower) {
+				continue
+			}
+
+			icon := getString(game, "ImageIcon")
+			coverURL := ""
+			if icon != "" {
+				coverURL = RAMediaURL + icon
+			}
+
+			sr := retrometadata.SearchResult{
+				Provider:   p.Name(),
+				ProviderID: getInt(game, "ID"),
+				Name:       title,
+				CoverURL:   coverURL,
+				Platforms:  []string{getString(game, "ConsoleName")},
+			}
+			if !yield(sr, nil) {
+				return
+			}
+			if yielded++; opts.Limit > 0 && yielded >= opts.Limit {
+				return
+			}
 		}
 	}
-
-	return searchResults, nil
 }
 
-// GetByID gets game details by RetroAchievements ID.
+// GetByID gets game details by RetroAchievements ID, with its achievements
+// unless they're skipped, in which case the smaller game summary is fetched.
 func (p *Provider) GetByID(ctx context.Context, gameID int) (*retrometadata.GameResult, error) {
 	if !p.IsEnabled() {
 		return nil, nil
 	}
 
+	if p.Config().SkipAchievements {
+		result, err := p.request(ctx, "/API_GetGame.php", map[string]string{"i": strconv.Itoa(gameID)})
+		if err != nil {
+			return nil, err
+		}
+		game, ok := result.(map[string]interface{})
+		if !ok || getString(game, "Title") == "" {
+			return nil, nil
+		}
+		// The summary doesn't include the ID
+		game["ID"] = gameID
+		return p.buildGameResult(game), nil
+	}
+
 	result, err := p.request(ctx, "/API_GetGameExtended.php", map[string]string{"i": strconv.Itoa(gameID)})
 	if err != nil {
 		return nil, err
@@ -212,7 +386,8 @@ func (p *Provider) GetByID(ctx context.Context, gameID int) (*retrometadata.Game
 	return p.buildGameResult(game), nil
 }
 
-// GetAchievements gets all achievements for a game.
+// GetAchievements gets all achievements for a game. GameResult.Achievements
+// lists them too, without another request.
 func (p *Provider) GetAchievements(ctx context.Context, gameID int) ([]RAGameAchievement, error) {
 	if !p.IsEnabled() {
 		return nil, nil
@@ -273,47 +448,32 @@ func (p *Provider) LookupByHash(ctx context.Context, platformID int, md5 string)
 		return nil, nil
 	}
 
-	// Get game list with hashes
-	params := map[string]string{
-		"i": strconv.Itoa(platformID),
-		"f": "1", // Only games with achievements
-		"h": "1", // Include hashes
-	}
-
-	result, err := p.request(ctx, "/API_GetGameList.php", params)
+	index, err := p.hashIndex(ctx, platformID)
 	if err != nil {
 		return nil, err
 	}
-
-	games, ok := result.([]interface{})
+	md5 = strings.ToLower(md5)
+	gameID, ok := index[md5]
 	if !ok {
 		return nil, nil
 	}
 
-	// Find matching hash
-	md5Lower := strings.ToLower(md5)
-	for _, g := range games {
-		game, ok := g.(map[string]interface{})
-		if !ok {
-			continue
-		}
-
-		hashes, ok := game["Hashes"].([]interface{})
-		if !ok {
-			continue
-		}
+	result, err := p.GetByID(ctx, gameID)
+	if err != nil || result == nil {
+		return result, err
+	}
 
-		for _, h := range hashes {
-			if hash, ok := h.(string); ok {
-				if strings.ToLower(hash) == md5Lower {
-					// Get full game details
-					return p.GetByID(ctx, getInt(game, "ID"))
-				}
+	// Labels are nice to have; the match stands without them
+	if hashes, err := p.GetGameHashes(ctx, gameID); err == nil {
+		for _, hash := range hashes {
+			if strings.EqualFold(hash.MD5, md5) {
+				result.Signatures = &retrometadata.Signatures{Matches: []retrometadata.SignatureMatch{hash.signature()}}
+				break
 			}
 		}
 	}
 
-	return nil, nil
+	return result, nil
 }
 
 // IdentifyByHash implements the HashProvider interface for hash-based identification.
@@ -347,23 +507,11 @@ func (p *Provider) Identify(ctx context.Context, filename string, opts retrometa
 	// Clean the filename and search
 	searchTerm := cleanFilename(filename)
 
-	// Get game list for platform
-	params := map[string]string{
-		"i": strconv.Itoa(*opts.PlatformID),
-		"f": "1",
-		"h": "0",
-	}
-
-	result, err := p.request(ctx, "/API_GetGameList.php", params)
-	if err != nil {
+	games, err := p.gameList(ctx, *opts.PlatformID)
+	if err != nil || len(games) == 0 {
 		return nil, err
 	}
 
-	games, ok := result.([]interface{})
-	if !ok || len(games) == 0 {
-		return nil, nil
-	}
-
 	// Build name mapping
 	gamesByName := make(map[string]map[string]interface{})
 	var names []string
@@ -378,7 +526,7 @@ func (p *Provider) Identify(ctx context.Context, filename string, opts retrometa
 	}
 
 	// Find best match
-	bestMatch, score := p.FindBestMatch(searchTerm, names)
+	bestMatch, score := p.FindBestMatchContext(ctx, searchTerm, names)
 
 	if bestMatch != "" {
 		if game, ok := gamesByName[bestMatch]; ok {
@@ -443,10 +591,63 @@ func (p *Provider) buildGameResult(game map[string]interface{}) *retrometadata.G
 
 	// Extract metadata
 	result.Metadata = p.extractMetadata(game)
+	if !p.Config().SkipAchievements {
+		result.Achievements = p.achievements(game)
+	}
 
 	return result
 }
 
+// achievements returns the achievements of an extended game record, in
+// display order.
+func (p *Provider) achievements(game map[string]interface{}) []retrometadata.Achievement {
+	achievementsData, _ := game["Achievements"].(map[string]interface{})
+	players := getInt(game, "NumDistinctPlayers")
+
+	type ordered struct {
+		order, id   int
+		achievement retrometadata.Achievement
+	}
+	var list []ordered
+	for _, achData := range achievementsData {
+		ach, ok := achData.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		achievement := retrometadata.Achievement{
+			ID:              strconv.Itoa(getInt(ach, "ID")),
+			Provider:        p.Name(),
+			Title:           getString(ach, "Title"),
+			Description:     getString(ach, "Description"),
+			Points:          getInt(ach, "Points"),
+			Type:            getString(ach, "type"),
+			Unlocks:         getInt(ach, "NumAwarded"),
+			UnlocksHardcore: getInt(ach, "NumAwardedHardcore"),
+		}
+		if badgeID := getString(ach, "BadgeName"); badgeID != "" {
+			achievement.BadgeURL = fmt.Sprintf("%s/%s.png", RABadgeURL, badgeID)
+			achievement.BadgeLockedURL = fmt.Sprintf("%s/%s_lock.png", RABadgeURL, badgeID)
+		}
+		if players > 0 {
+			rate := float64(achievement.Unlocks) / float64(players)
+			achievement.UnlockRate = &rate
+		}
+		list = append(list, ordered{getInt(ach, "DisplayOrder"), getInt(ach, "ID"), achievement})
+	}
+
+	slices.SortFunc(list, func(a, b ordered) int {
+		return cmp.Or(cmp.Compare(a.order, b.order), cmp.Compare(a.id, b.id))
+	})
+	achievements := make([]retrometadata.Achievement, 0, len(list))
+	for _, o := range list {
+		achievements = append(achievements, o.achievement)
+	}
+	if len(achievements) == 0 {
+		return nil
+	}
+	return achievements
+}
+
 func (p *Provider) extractMetadata(game map[string]interface{}) retrometadata.GameMetadata {
 	metadata := retrometadata.GameMetadata{
 		RawData: game,
@@ -0,0 +1,198 @@
+package retroachievements
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/josegonzalez/retro-metadata/pkg/cache"
+	"github.com/josegonzalez/retro-metadata/pkg/retrometadata"
+)
+
+func TestGameListRefresh(t *testing.T) {
+	var full, light atomic.Int32
+	var modified atomic.Value
+	modified.Store("2024-01-01 00:00:00")
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		game := map[string]any{"ID": 1, "Title": "Sonic the Hedgehog", "NumAchievements": 23, "DateModified": modified.Load()}
+		if r.URL.Query().Get("h") == "1" {
+			full.Add(1)
+			game["Hashes"] = []string{"1bc674be034e43c96b86487ac69d9293"}
+		} else {
+			light.Add(1)
+		}
+		_ = json.NewEncoder(w).Encode([]any{game})
+	}))
+	defer server.Close()
+
+	config := retrometadata.ProviderConfig{Enabled: true, Credentials: map[string]string{"api_key": "key"}}
+	p, _ := NewProvider(config, cache.NewMemoryCache())
+	p.baseURL = server.URL
+	ctx := context.Background()
+
+	for range 3 {
+		if games, err := p.gameList(ctx, 1); err != nil || len(games) != 1 {
+			t.Fatalf("gameList() = %v, %v", games, err)
+		}
+	}
+	if full.Load() != 1 || light.Load() != 0 {
+		t.Fatalf("got %d full and %d light downloads, want the list downloaded once", full.Load(), light.Load())
+	}
+
+	// Once the refresh interval passes, an unchanged list is only checked
+	_ = p.DeleteCached(ctx, "gamelist:1:checked")
+	_, _ = p.gameList(ctx, 1)
+	if full.Load() != 1 || light.Load() != 1 {
+		t.Errorf("got %d full and %d light downloads after an unchanged check, want 1 and 1", full.Load(), light.Load())
+	}
+
+	// A modified game triggers a full download
+	modified.Store("2024-06-01 00:00:00")
+	_ = p.DeleteCached(ctx, "gamelist:1:checked")
+	games, _ := p.gameList(ctx, 1)
+	if full.Load() != 2 || light.Load() != 2 {
+		t.Errorf("got %d full and %d light downloads after a change, want 2 and 2", full.Load(), light.Load())
+	}
+	if game, _ := games[0].(map[string]interface{}); getString(game, "DateModified") != "2024-06-01 00:00:00" {
+		t.Errorf("gameList() after a change = %v, want the updated list", games)
+	}
+}
+
+func TestGameListSaved(t *testing.T) {
+	var full atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		full.Add(1)
+		game := map[string]any{"ID": 1, "Title": "Sonic the Hedgehog", "Hashes": []string{"1bc674be034e43c96b86487ac69d9293"}}
+		_ = json.NewEncoder(w).Encode([]any{game})
+	}))
+	defer server.Close()
+
+	config := retrometadata.ProviderConfig{
+		Enabled:     true,
+		Credentials: map[string]string{"api_key": "key"},
+		Options:     map[string]any{"index_dir": t.TempDir()},
+	}
+	ctx := context.Background()
+	for run := range 2 {
+		// Each run starts with an empty cache, like a new process
+		p, _ := NewProvider(config, cache.NewMemoryCache())
+		p.baseURL = server.URL
+		ids, err := p.hashIndex(ctx, 1)
+		if err != nil || ids["1bc674be034e43c96b86487ac69d9293"] != 1 {
+			t.Fatalf("run %d: hashIndex() = %v, %v", run, ids, err)
+		}
+	}
+	if full.Load() != 1 {
+		t.Errorf("got %d downloads, want the saved list used by the second run", full.Load())
+	}
+}
+
+func TestLookupByHashLabels(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/API_GetGameList.php":
+			_ = json.NewEncoder(w).Encode([]any{
+				map[string]any{"ID": 1, "Title": "Sonic the Hedgehog", "Hashes": []string{"1BC674BE034E43C96B86487AC69D9293", "d9bdb5f8c8d5a7ecaf4d8f4b8b2d3d5e"}},
+				map[string]any{"ID": 2, "Title": "Sonic the Hedgehog 2", "Hashes": []string{"9feeb724052c39982d432a7851c98d3e"}},
+			})
+		case "/API_GetGameExtended.php":
+			_ = json.NewEncoder(w).Encode(map[string]any{"ID": r.URL.Query().Get("i"), "Title": "Sonic the Hedgehog"})
+		case "/API_GetGameHashes.php":
+			_ = json.NewEncoder(w).Encode(map[string]any{"Results": []any{
+				map[string]any{"MD5": "1bc674be034e43c96b86487ac69d9293", "Name": "Sonic The Hedgehog (USA, Europe)", "Labels": []string{"nointro"}, "PatchUrl": nil},
+				map[string]any{"MD5": "d9bdb5f8c8d5a7ecaf4d8f4b8b2d3d5e", "Name": "Sonic The Hedgehog (Japan, Korea) (Rev 1)", "Labels": []string{"nointro"}, "PatchUrl": nil},
+			}})
+		}
+	}))
+	defer server.Close()
+
+	config := retrometadata.ProviderConfig{Enabled: true, Credentials: map[string]string{"api_key": "key"}}
+	p, _ := NewProvider(config, cache.NewMemoryCache())
+	p.baseURL = server.URL
+	ctx := context.Background()
+
+	result, err := p.LookupByHash(ctx, 1, "D9BDB5F8C8D5A7ECAF4D8F4B8B2D3D5E")
+	if err != nil || result == nil {
+		t.Fatalf("LookupByHash() = %v, %v", result, err)
+	}
+	if *result.ProviderID != 1 {
+		t.Errorf("ProviderID = %d, want 1", *result.ProviderID)
+	}
+	match := result.Signatures.Get(retrometadata.SignatureSourceRetroAchievements)
+	if match == nil || match.Name != "Sonic The Hedgehog (Japan, Korea) (Rev 1)" || match.Revision != "Rev 1" || len(match.Labels) != 1 || match.Labels[0] != "nointro" {
+		t.Errorf("RetroAchievements signature = %+v", match)
+	}
+
+	if result, err := p.LookupByHash(ctx, 1, "00000000000000000000000000000000"); result != nil || err != nil {
+		t.Errorf("LookupByHash() for an unknown hash = %v, %v; want no match", result, err)
+	}
+}
+
+func TestAchievements(t *testing.T) {
+	var paths []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.Path)
+		switch r.URL.Path {
+		case "/API_GetGameExtended.php":
+			_ = json.NewEncoder(w).Encode(map[string]any{
+				"ID": 1, "Title": "Sonic the Hedgehog", "NumDistinctPlayers": 200,
+				"Achievements": map[string]any{
+					"20": map[string]any{"ID": 20, "Title": "Chaos", "Points": 25, "BadgeName": "250", "NumAwarded": 50, "DisplayOrder": 2, "type": "win_condition"},
+					"10": map[string]any{"ID": 10, "Title": "Rings", "Points": 5, "NumAwarded": 150, "NumAwardedHardcore": 100, "DisplayOrder": 1},
+				},
+			})
+		case "/API_GetGame.php":
+			_ = json.NewEncoder(w).Encode(map[string]any{"Title": "Sonic the Hedgehog", "ConsoleID": 1})
+		}
+	}))
+	defer server.Close()
+	ctx := context.Background()
+
+	config := retrometadata.ProviderConfig{Enabled: true, Credentials: map[string]string{"api_key": "key"}}
+	p, _ := NewProvider(config, cache.NewMemoryCache())
+	p.baseURL = server.URL
+	result, err := p.GetByID(ctx, 1)
+	if err != nil || result == nil {
+		t.Fatalf("GetByID() = %v, %v", result, err)
+	}
+	achievements := result.Achievements
+	if len(achievements) != 2 || achievements[0].Title != "Rings" || achievements[1].ID != "20" {
+		t.Fatalf("Achievements = %+v, want in display order", achievements)
+	}
+	if a := achievements[1]; a.Points != 25 || a.Type != "win_condition" || a.BadgeLockedURL != RABadgeURL+"/250_lock.png" || *a.UnlockRate != 0.25 {
+		t.Errorf("Achievements[1] = %+v", a)
+	}
+
+	// Skipping achievements fetches the game summary instead
+	config.SkipAchievements = true
+	p, _ = NewProvider(config, cache.NewMemoryCache())
+	p.baseURL = server.URL
+	result, err = p.GetByID(ctx, 1)
+	if err != nil || result == nil || *result.ProviderID != 1 || result.Achievements != nil {
+		t.Fatalf("GetByID() without achievements = %+v, %v", result, err)
+	}
+	if paths[len(paths)-1] != "/API_GetGame.php" {
+		t.Errorf("requested %q, want the game summary", paths)
+	}
+}
+
+func TestServerError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		_, _ = w.Write([]byte("<html>Service Unavailable</html>"))
+	}))
+	defer server.Close()
+
+	config := retrometadata.ProviderConfig{Enabled: true, Credentials: map[string]string{"api_key": "key"}}
+	p, _ := NewProvider(config, cache.NewMemoryCache())
+	p.baseURL = server.URL
+
+	_, err := p.GetByID(context.Background(), 1)
+	if !retrometadata.Retryable(err) || retrometadata.HTTPStatus(err) != http.StatusServiceUnavailable {
+		t.Errorf("GetByID() error = %v, want a retryable 503", err)
+	}
+}
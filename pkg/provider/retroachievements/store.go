@@ -0,0 +1,69 @@
+package retroachievements
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/josegonzalez/retro-metadata/pkg/internal/index"
+)
+
+// gameListVersion changes whenever the on-disk form of game lists does.
+const gameListVersion = 1
+
+// savedGameList is the on-disk form of a platform's game list, saved to the
+// "index_dir" option's directory so later runs don't download it again.
+type savedGameList struct {
+	SavedAt int64  // Unix time the list was downloaded or checked
+	Games   []byte // the list as JSON
+}
+
+// gameListPath returns where a platform's game list is saved, or "" if game
+// lists aren't saved.
+func (p *Provider) gameListPath(platformID int) string {
+	if p.indexDir == "" {
+		return ""
+	}
+	return filepath.Join(p.indexDir, fmt.Sprintf("retroachievements-gamelist-%d.index", platformID))
+}
+
+// loadGameList reads a platform's saved game list and how long ago it was
+// downloaded or checked. Returns false if there's none or it's expired.
+func (p *Provider) loadGameList(platformID int) ([]interface{}, time.Duration, bool) {
+	path := p.gameListPath(platformID)
+	if path == "" {
+		return nil, 0, false
+	}
+	var saved savedGameList
+	if !index.Load(path, gameListVersion, nil, &saved) {
+		return nil, 0, false
+	}
+	age := time.Since(time.Unix(saved.SavedAt, 0))
+	if age >= gameListTTL {
+		return nil, 0, false
+	}
+	var games []interface{}
+	if err := json.Unmarshal(saved.Games, &games); err != nil {
+		return nil, 0, false
+	}
+	return games, age, true
+}
+
+// saveGameList saves a platform's game list as downloaded or checked now.
+// Failures only cost a download on the next run, so they're ignored.
+func (p *Provider) saveGameList(platformID int, games []interface{}) {
+	path := p.gameListPath(platformID)
+	if path == "" {
+		return
+	}
+	data, err := json.Marshal(games)
+	if err != nil {
+		return
+	}
+	if err := os.MkdirAll(p.indexDir, 0o755); err != nil {
+		return
+	}
+	_ = index.Save(path, gameListVersion, nil, savedGameList{SavedAt: time.Now().Unix(), Games: data})
+}
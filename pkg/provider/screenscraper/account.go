@@ -0,0 +1,144 @@
+package screenscraper
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+
+	"github.com/josegonzalez/retro-metadata/pkg/retrometadata"
+)
+
+// AccountInfo describes the ScreenScraper account requests are made with.
+type AccountInfo struct {
+	Quota
+	// Username is the account's login
+	Username string `json:"username"`
+	// NumericID is the account's numeric ID
+	NumericID int `json:"numeric_id"`
+	// Level is the account's level, which sets its limits
+	Level int `json:"level"`
+	// Contribution is the account's financial contribution level
+	Contribution int `json:"contribution"`
+	// MaxDownloadSpeed is the media download speed limit, in KB/s
+	MaxDownloadSpeed int `json:"max_download_speed"`
+}
+
+// GetAccountInfo returns the account's level and limits, from
+// ssuserInfos.php. It needs a username and password.
+func (p *Provider) GetAccountInfo(ctx context.Context) (*AccountInfo, error) {
+	if p.keyless {
+		return nil, retrometadata.CredentialsRequired(p.Name(), "account info")
+	}
+	result, err := p.request(ctx, "ssuserInfos.php", nil)
+	if err != nil {
+		return nil, err
+	}
+	response, _ := result["response"].(map[string]interface{})
+	user, ok := response["ssuser"].(map[string]interface{})
+	if !ok {
+		return nil, &retrometadata.ProviderError{Provider: p.Name(), Op: "account info", Err: errors.New("response has no user information")}
+	}
+
+	return &AccountInfo{
+		Quota:            quotaFromUser(user),
+		Username:         getString(user, "id"),
+		NumericID:        getInt(user, "numid"),
+		Level:            getInt(user, "niveau"),
+		Contribution:     getInt(user, "contribution"),
+		MaxDownloadSpeed: getInt(user, "maxdownloadspeed"),
+	}, nil
+}
+
+// MediaTransport returns a transport for downloading the media URLs of
+// ScreenScraper games, e.g. with artwork.WithHTTPClient. Media downloads count
+// against the account's quota like API requests, so downloads from
+// ScreenScraper share the provider's thread and per-minute limits, fail with
+// a *retrometadata.RateLimitError once the daily quota is used up and have
+// the account's credentials added. Other URLs are sent with base unchanged.
+// A nil base uses http.DefaultTransport.
+func (p *Provider) MediaTransport(base http.RoundTripper) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &mediaTransport{provider: p, base: base}
+}
+
+// mediaTransport implements Provider.MediaTransport.
+type mediaTransport struct {
+	provider *Provider
+	base     http.RoundTripper
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *mediaTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	p := t.provider
+	if !p.isMediaHost(req.URL.Hostname()) {
+		return t.base.RoundTrip(req)
+	}
+
+	ctx := req.Context()
+	if p.Quota().Exhausted() {
+		return nil, &retrometadata.RateLimitError{Provider: p.Name(), Details: "daily request quota exhausted"}
+	}
+	if err := p.wait(ctx); err != nil {
+		return nil, err
+	}
+	if err := p.threads.acquire(ctx); err != nil {
+		return nil, err
+	}
+
+	req = req.Clone(ctx)
+	query := req.URL.Query()
+	for key, value := range p.buildAuthParams() {
+		if sensitiveKeys[key] && value != "" {
+			query.Set(key, value)
+		}
+	}
+	req.URL.RawQuery = query.Encode()
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		p.threads.release()
+		return nil, err
+	}
+	p.countRequest()
+
+	if resp.StatusCode == 430 || resp.StatusCode == 431 {
+		resp.Body.Close()
+		p.threads.release()
+		return nil, &retrometadata.RateLimitError{Provider: p.Name(), StatusCode: resp.StatusCode, Details: "daily request quota exhausted"}
+	}
+
+	// The download holds its thread until the body is read and closed
+	resp.Body = &releasingBody{ReadCloser: resp.Body, release: p.threads.release}
+	return resp, nil
+}
+
+// isMediaHost reports whether host serves ScreenScraper media.
+func (p *Provider) isMediaHost(host string) bool {
+	host = strings.ToLower(host)
+	if host == "screenscraper.fr" || strings.HasSuffix(host, ".screenscraper.fr") {
+		return true
+	}
+	if base, err := url.Parse(p.baseURL); err == nil {
+		return host == strings.ToLower(base.Hostname())
+	}
+	return false
+}
+
+// releasingBody calls release once when the body is closed.
+type releasingBody struct {
+	io.ReadCloser
+	once    sync.Once
+	release func()
+}
+
+// Close implements io.Closer.
+func (b *releasingBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.once.Do(b.release)
+	return err
+}
@@ -0,0 +1,101 @@
+package screenscraper
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/josegonzalez/retro-metadata/pkg/retrometadata"
+)
+
+// ScreenScraper media types. The API returns many more; these are the ones
+// most frontends use.
+const (
+	MediaBox2D           = "box-2D"
+	MediaBox2DBack       = "box-2D-back"
+	MediaBox3D           = "box-3D"
+	MediaSupport2D       = "support-2D"
+	MediaScreenshot      = "ss"
+	MediaTitleScreen     = "sstitle"
+	MediaFanart          = "fanart"
+	MediaWheel           = "wheel"
+	MediaWheelHD         = "wheel-hd"
+	MediaMarquee         = "screenmarquee"
+	MediaMixRBV1         = "mixrbv1"
+	MediaMixRBV2         = "mixrbv2"
+	MediaVideo           = "video"
+	MediaVideoNormalized = "video-normalized"
+	MediaManual          = "manuel"
+	MediaMaps            = "maps"
+)
+
+// stringsOption reads a list of strings from a provider option, accepting a
+// single string as a one-element list.
+func stringsOption(options map[string]any, key string) []string {
+	var values []string
+	switch v := options[key].(type) {
+	case []string:
+		values = v
+	case []any:
+		for _, item := range v {
+			if s, ok := item.(string); ok {
+				values = append(values, s)
+			}
+		}
+	case string:
+		values = []string{v}
+	}
+	return values
+}
+
+// collectMedia returns every game media matching the configured media types
+// and regions, in the order ScreenScraper lists them. Without a media_types
+// option all types are returned; without a regions option all regions are.
+func (p *Provider) collectMedia(medias []interface{}) []retrometadata.Media {
+	var out []retrometadata.Media
+	for _, m := range medias {
+		mMap, ok := m.(map[string]interface{})
+		if !ok || getString(mMap, "parent") != "jeu" {
+			continue
+		}
+
+		mediaType := getString(mMap, "type")
+		region := getString(mMap, "region")
+		url := getString(mMap, "url")
+		if url == "" || (len(p.mediaTypes) > 0 && !slices.Contains(p.mediaTypes, mediaType)) {
+			continue
+		}
+		if p.regionFilter && region != "" && !slices.Contains(p.regionPriority, region) {
+			continue
+		}
+
+		out = append(out, retrometadata.Media{
+			Type:   mediaType,
+			URL:    stripSensitiveParams(url),
+			Region: region,
+			Format: strings.ToLower(getString(mMap, "format")),
+		})
+	}
+	return out
+}
+
+// collectVideos returns the game's videos, normalized ones first.
+// ScreenScraper's videos are gameplay captures rather than trailers.
+func (p *Provider) collectVideos(medias []interface{}) []retrometadata.Video {
+	var videos []retrometadata.Video
+	for _, mediaType := range []string{MediaVideoNormalized, MediaVideo} {
+		for _, m := range medias {
+			mMap, ok := m.(map[string]interface{})
+			if !ok || getString(mMap, "parent") != "jeu" || getString(mMap, "type") != mediaType {
+				continue
+			}
+			if url := getString(mMap, "url"); url != "" {
+				videos = append(videos, retrometadata.Video{
+					Provider: p.Name(),
+					Type:     retrometadata.VideoGameplay,
+					URL:      stripSensitiveParams(url),
+				})
+			}
+		}
+	}
+	return videos
+}
@@ -0,0 +1,140 @@
+package screenscraper
+
+import (
+	"testing"
+
+	"github.com/josegonzalez/retro-metadata/pkg/cache"
+	"github.com/josegonzalez/retro-metadata/pkg/retrometadata"
+)
+
+var testMedias = []interface{}{
+	map[string]interface{}{"type": "box-2D", "parent": "jeu", "region": "us", "format": "png", "url": "https://example.com/box2d-us.png?ssid=user&media=box-2D"},
+	map[string]interface{}{"type": "box-3D", "parent": "jeu", "region": "eu", "format": "png", "url": "https://example.com/box3d-eu.png"},
+	map[string]interface{}{"type": "box-3D", "parent": "jeu", "region": "us", "format": "png", "url": "https://example.com/box3d-us.png"},
+	map[string]interface{}{"type": "video", "parent": "jeu", "format": "MP4", "url": "https://example.com/video.mp4"},
+	map[string]interface{}{"type": "manuel", "parent": "jeu", "region": "jp", "format": "pdf", "url": "https://example.com/manual-jp.pdf"},
+	map[string]interface{}{"type": "wheel", "parent": "editeur", "region": "us", "url": "https://example.com/publisher.png"},
+}
+
+func TestBuildGameResultMedia(t *testing.T) {
+	p, _ := NewProvider(retrometadata.ProviderConfig{Enabled: true}, cache.NewMemoryCache())
+	result := p.buildGameResult(map[string]interface{}{"id": "1", "medias": testMedias})
+
+	if result.Artwork.CoverURL != "https://example.com/box2d-us.png?media=box-2D" {
+		t.Errorf("CoverURL = %q", result.Artwork.CoverURL)
+	}
+	if len(result.Artwork.Media) != 5 {
+		t.Fatalf("got %d media, want every game media (5): %+v", len(result.Artwork.Media), result.Artwork.Media)
+	}
+	if m := result.Artwork.Media[3]; m.Type != MediaVideo || m.Region != "" || m.Format != "mp4" {
+		t.Errorf("video media = %+v", m)
+	}
+	if v := result.Metadata.Videos; len(v) != 1 || v[0].Type != retrometadata.VideoGameplay || v[0].URL != "https://example.com/video.mp4" {
+		t.Errorf("Videos = %+v", v)
+	}
+	if result.Artwork.ManualURL != "https://example.com/manual-jp.pdf" {
+		t.Errorf("ManualURL = %q", result.Artwork.ManualURL)
+	}
+}
+
+func TestExtractMetadataCompanies(t *testing.T) {
+	p, _ := NewProvider(retrometadata.ProviderConfig{Enabled: true}, cache.NewMemoryCache())
+	metadata := p.extractMetadata(map[string]interface{}{
+		"editeur":     map[string]interface{}{"id": "1", "text": "Sega"},
+		"developpeur": map[string]interface{}{"id": "2", "text": "Sonic Team"},
+	})
+	companies := metadata.InvolvedCompanies
+	if len(companies) != 2 || !companies[0].Publisher || companies[0].Developer || !companies[1].Developer {
+		t.Errorf("InvolvedCompanies = %+v", companies)
+	}
+	if metadata.Publisher != "Sega" || metadata.Developer != "Sonic Team" || len(metadata.Companies) != 2 {
+		t.Errorf("legacy fields = %q, %q, %q", metadata.Publisher, metadata.Developer, metadata.Companies)
+	}
+}
+
+func TestExtractMetadataReleases(t *testing.T) {
+	p, _ := NewProvider(retrometadata.ProviderConfig{Enabled: true}, cache.NewMemoryCache())
+	metadata := p.extractMetadata(map[string]interface{}{
+		"systeme": map[string]interface{}{"id": "1", "text": "Megadrive"},
+		"dates": []interface{}{
+			map[string]interface{}{"region": "jp", "text": "1991-07-26"},
+			map[string]interface{}{"region": "eu", "text": "1991"},
+		},
+	})
+	releases := metadata.Releases
+	if len(releases) != 2 || releases[0].Region != retrometadata.RegionJapan || releases[0].Date == 0 || releases[0].PlatformSlug != "genesis" {
+		t.Fatalf("Releases = %+v", releases)
+	}
+	if r := metadata.ReleaseIn(retrometadata.RegionEurope); r == nil || r.Year != 1991 || r.Date != 0 {
+		t.Errorf("ReleaseIn(eu) = %+v, want the year only", r)
+	}
+}
+
+func TestMediaOptions(t *testing.T) {
+	config := retrometadata.ProviderConfig{
+		Enabled: true,
+		Options: map[string]any{
+			"regions":     []any{"eu", "us"},
+			"media_types": []string{MediaBox3D, MediaVideo, MediaManual},
+			"cover_type":  MediaBox3D,
+		},
+	}
+	p, _ := NewProvider(config, cache.NewMemoryCache())
+	result := p.buildGameResult(map[string]interface{}{"id": "1", "medias": testMedias})
+
+	if result.Artwork.CoverURL != "https://example.com/box3d-eu.png" {
+		t.Errorf("CoverURL = %q, want the EU 3D box", result.Artwork.CoverURL)
+	}
+
+	// The JP manual is outside the configured regions; the region-less video is kept
+	var types []string
+	for _, m := range result.Artwork.Media {
+		types = append(types, m.Type+"/"+m.Region)
+	}
+	want := []string{"box-3D/eu", "box-3D/us", "video/"}
+	if len(types) != len(want) {
+		t.Fatalf("media = %v, want %v", types, want)
+	}
+	for i := range want {
+		if types[i] != want[i] {
+			t.Errorf("media = %v, want %v", types, want)
+			break
+		}
+	}
+}
+
+func TestLocale(t *testing.T) {
+	game := map[string]interface{}{
+		"id": "1",
+		"noms": []interface{}{
+			map[string]interface{}{"region": "us", "text": "Super Mario World"},
+			map[string]interface{}{"region": "fr", "text": "Super Mario World : Super Mario Bros. 4"},
+		},
+		"synopsis": []interface{}{
+			map[string]interface{}{"langue": "en", "text": "Mario's adventure"},
+			map[string]interface{}{"langue": "de", "text": "Marios Abenteuer"},
+			map[string]interface{}{"langue": "fr", "text": "L'aventure de Mario"},
+		},
+	}
+
+	tests := []struct {
+		locale   string
+		name     string
+		summary  string
+		language retrometadata.Language
+	}{
+		{"", "Super Mario World", "Mario's adventure", retrometadata.LanguageEnglish},
+		{"fr-FR", "Super Mario World: Super Mario Bros. 4", "L'aventure de Mario", retrometadata.LanguageFrench},
+		{"de", "Super Mario World", "Marios Abenteuer", retrometadata.LanguageGerman},
+		// Without a synopsis in the locale's language, English is the fallback
+		{"ja-JP", "Super Mario World", "Mario's adventure", retrometadata.LanguageEnglish},
+	}
+
+	for _, tt := range tests {
+		p, _ := NewProvider(retrometadata.ProviderConfig{Enabled: true, Locale: tt.locale}, cache.NewMemoryCache())
+		result := p.buildGameResult(game)
+		if result.Name != tt.name || result.Summary != tt.summary || result.Language != tt.language {
+			t.Errorf("locale %q: got %q, %q, %q; want %q, %q, %q", tt.locale, result.Name, result.Summary, result.Language, tt.name, tt.summary, tt.language)
+		}
+	}
+}
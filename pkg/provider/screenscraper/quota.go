@@ -0,0 +1,173 @@
+package screenscraper
+
+import (
+	"context"
+	"sync"
+
+	"github.com/josegonzalez/retro-metadata/pkg/provider"
+)
+
+// Quota holds the account limits ScreenScraper reports with every response.
+type Quota struct {
+	// MaxThreads is the number of concurrent requests the account may make
+	MaxThreads int `json:"max_threads"`
+	// RequestsToday is the number of requests made today
+	RequestsToday int `json:"requests_today"`
+	// MaxRequestsPerDay is the daily request limit
+	MaxRequestsPerDay int `json:"max_requests_per_day"`
+	// RequestsKOToday is the number of failed (not found) requests made today
+	RequestsKOToday int `json:"requests_ko_today"`
+	// MaxRequestsKOPerDay is the daily limit for failed requests
+	MaxRequestsKOPerDay int `json:"max_requests_ko_per_day"`
+	// MaxRequestsPerMinute is the per-minute request limit
+	MaxRequestsPerMinute int `json:"max_requests_per_minute"`
+}
+
+// Exhausted reports whether the daily request quota has been used up.
+func (q Quota) Exhausted() bool {
+	return (q.MaxRequestsPerDay > 0 && q.RequestsToday >= q.MaxRequestsPerDay) ||
+		(q.MaxRequestsKOPerDay > 0 && q.RequestsKOToday >= q.MaxRequestsKOPerDay)
+}
+
+// RemainingRequests returns the number of requests left today, or -1 if the
+// limit isn't known yet.
+func (q Quota) RemainingRequests() int {
+	return remaining(q.RequestsToday, q.MaxRequestsPerDay)
+}
+
+// RemainingKORequests returns the number of failed (not found) requests left
+// today, or -1 if the limit isn't known yet.
+func (q Quota) RemainingKORequests() int {
+	return remaining(q.RequestsKOToday, q.MaxRequestsKOPerDay)
+}
+
+func remaining(used, limit int) int {
+	if limit <= 0 {
+		return -1
+	}
+	return max(limit-used, 0)
+}
+
+// parseQuota reads the ssuser block of a response. Returns false if the
+// response has no user information.
+func parseQuota(result map[string]interface{}) (Quota, bool) {
+	response, _ := result["response"].(map[string]interface{})
+	user, ok := response["ssuser"].(map[string]interface{})
+	if !ok {
+		return Quota{}, false
+	}
+	return quotaFromUser(user), true
+}
+
+// quotaFromUser reads the limits of an ssuser block.
+func quotaFromUser(user map[string]interface{}) Quota {
+	return Quota{
+		MaxThreads:           getInt(user, "maxthreads"),
+		RequestsToday:        getInt(user, "requeststoday"),
+		MaxRequestsPerDay:    getInt(user, "maxrequestsperday"),
+		RequestsKOToday:      getInt(user, "requestskotoday"),
+		MaxRequestsKOPerDay:  getInt(user, "maxrequestskoperday"),
+		MaxRequestsPerMinute: getInt(user, "maxrequestspermin"),
+	}
+}
+
+// Quota returns the account limits from the most recent response. It's the
+// zero value until the first request completes.
+func (p *Provider) Quota() Quota {
+	p.quotaMu.RLock()
+	defer p.quotaMu.RUnlock()
+	return p.quota
+}
+
+func (p *Provider) setQuota(quota Quota) {
+	p.quotaMu.Lock()
+	if quota.MaxRequestsPerMinute != p.quota.MaxRequestsPerMinute {
+		p.rate = provider.NewRateLimiter(float64(quota.MaxRequestsPerMinute) / 60)
+	}
+	p.quota = quota
+	p.quotaMu.Unlock()
+	if quota.MaxThreads > 0 {
+		p.threads.setLimit(quota.MaxThreads)
+	}
+}
+
+// countRequest counts a request the server doesn't report the quota with,
+// like a media download, against the daily quota until the next response
+// reports the real count.
+func (p *Provider) countRequest() {
+	p.quotaMu.Lock()
+	p.quota.RequestsToday++
+	p.quotaMu.Unlock()
+}
+
+// rateLimiter returns the limiter spacing requests to the account's
+// maxrequestspermin, or nil until a response reports it.
+func (p *Provider) rateLimiter() *provider.RateLimiter {
+	p.quotaMu.RLock()
+	defer p.quotaMu.RUnlock()
+	return p.rate
+}
+
+// wait waits for both the configured and the account's rate limits.
+func (p *Provider) wait(ctx context.Context) error {
+	if err := p.limiter.Wait(ctx); err != nil {
+		return err
+	}
+	return p.rateLimiter().Wait(ctx)
+}
+
+// threadLimiter caps the number of concurrent requests. Unlike a buffered
+// channel its limit can change while requests are in flight, so it can follow
+// the maxthreads value ScreenScraper reports.
+type threadLimiter struct {
+	mu      sync.Mutex
+	limit   int
+	active  int
+	changed chan struct{}
+}
+
+func newThreadLimiter(limit int) *threadLimiter {
+	return &threadLimiter{limit: limit, changed: make(chan struct{})}
+}
+
+// acquire waits for a free slot or for ctx to be done.
+func (t *threadLimiter) acquire(ctx context.Context) error {
+	for {
+		t.mu.Lock()
+		if t.active < t.limit {
+			t.active++
+			t.mu.Unlock()
+			return nil
+		}
+		changed := t.changed
+		t.mu.Unlock()
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-changed:
+		}
+	}
+}
+
+func (t *threadLimiter) release() {
+	t.mu.Lock()
+	t.active--
+	t.notify()
+	t.mu.Unlock()
+}
+
+func (t *threadLimiter) setLimit(limit int) {
+	t.mu.Lock()
+	if limit != t.limit {
+		t.limit = limit
+		t.notify()
+	}
+	t.mu.Unlock()
+}
+
+// notify wakes every waiter. Must be called with mu held.
+func (t *threadLimiter) notify() {
+	close(t.changed)
+	t.changed = make(chan struct{})
+}
@@ -0,0 +1,206 @@
+package screenscraper
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/josegonzalez/retro-metadata/pkg/cache"
+	"github.com/josegonzalez/retro-metadata/pkg/retrometadata"
+)
+
+func TestQuotaThrottling(t *testing.T) {
+	var requests, inFlight, peak atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := inFlight.Add(1)
+		defer inFlight.Add(-1)
+		for {
+			old := peak.Load()
+			if n <= old || peak.CompareAndSwap(old, n) {
+				break
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+		fmt.Fprintf(w, `{"response": {"ssuser": {"maxthreads": "2", "requeststoday": "%d", "maxrequestsperday": "20", "requestskotoday": "0", "maxrequestskoperday": "10"}}}`, requests.Add(1))
+	}))
+	defer server.Close()
+
+	// Anonymous requests are spaced out unless the rate limit is disabled
+	p, _ := NewProvider(retrometadata.ProviderConfig{Enabled: true, RateLimit: -1}, cache.NewMemoryCache())
+	p.baseURL = server.URL
+	ctx := context.Background()
+
+	if _, err := p.request(ctx, "ssuserInfos.php", nil); err != nil {
+		t.Fatal(err)
+	}
+	if q := p.Quota(); q.MaxThreads != 2 || q.RequestsToday != 1 || q.MaxRequestsPerDay != 20 || q.MaxRequestsKOPerDay != 10 {
+		t.Errorf("Quota() = %+v", q)
+	}
+
+	var wg sync.WaitGroup
+	for range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, _ = p.request(ctx, "jeuInfos.php", nil)
+		}()
+	}
+	wg.Wait()
+	if got := peak.Load(); got != 2 {
+		t.Errorf("peak concurrent requests = %d, want the account's maxthreads (2)", got)
+	}
+
+	// Once the daily quota is used up, requests fail without reaching the server
+	p.setQuota(Quota{MaxThreads: 2, RequestsToday: 20, MaxRequestsPerDay: 20})
+	before := requests.Load()
+	_, err := p.request(ctx, "jeuInfos.php", nil)
+	var rateLimitErr *retrometadata.RateLimitError
+	if !errors.As(err, &rateLimitErr) {
+		t.Errorf("request with exhausted quota error = %v, want a RateLimitError", err)
+	}
+	if requests.Load() != before {
+		t.Error("request with exhausted quota reached the server")
+	}
+}
+
+func TestThreadLimiterCancel(t *testing.T) {
+	limiter := newThreadLimiter(1)
+	if err := limiter.acquire(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := limiter.acquire(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("acquire() on a full limiter = %v, want DeadlineExceeded", err)
+	}
+
+	// Raising the limit wakes waiters
+	done := make(chan error)
+	go func() { done <- limiter.acquire(context.Background()) }()
+	limiter.setLimit(2)
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Error(err)
+		}
+	case <-time.After(time.Second):
+		t.Error("acquire() didn't return after the limit was raised")
+	}
+}
+
+func TestGetAccountInfo(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/ssuserInfos.php" || r.URL.Query().Get("ssid") != "player" {
+			http.NotFound(w, r)
+			return
+		}
+		fmt.Fprint(w, `{"response": {"ssuser": {"id": "player", "numid": "42", "niveau": "2", "contribution": "1", "maxthreads": "4", "maxdownloadspeed": "256", "requeststoday": "150", "maxrequestsperday": "20000", "requestskotoday": "10", "maxrequestskoperday": "2000", "maxrequestspermin": "1200"}}}`)
+	}))
+	defer server.Close()
+
+	p, _ := NewProvider(retrometadata.ProviderConfig{
+		Enabled:     true,
+		Credentials: map[string]string{"username": "player", "password": "secret"},
+	}, cache.NewMemoryCache())
+	p.baseURL = server.URL
+
+	info, err := p.GetAccountInfo(context.Background())
+	if err != nil {
+		t.Fatalf("GetAccountInfo() error: %v", err)
+	}
+	if info.Username != "player" || info.NumericID != 42 || info.Level != 2 || info.MaxThreads != 4 || info.MaxDownloadSpeed != 256 {
+		t.Errorf("GetAccountInfo() = %+v", info)
+	}
+	if info.RemainingRequests() != 19850 || info.RemainingKORequests() != 1990 {
+		t.Errorf("remaining = %d, %d; want 19850, 1990", info.RemainingRequests(), info.RemainingKORequests())
+	}
+	if p.Quota() != info.Quota || p.rateLimiter() == nil {
+		t.Errorf("Quota() = %+v, want the account's quota applied", p.Quota())
+	}
+
+	if got := (Quota{}).RemainingRequests(); got != -1 {
+		t.Errorf("RemainingRequests() without a limit = %d, want -1", got)
+	}
+}
+
+func TestMediaTransport(t *testing.T) {
+	var mediaRequests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mediaRequests.Add(1)
+		if r.URL.Query().Get("ssid") != "player" || r.URL.Query().Get("media") != "box-2D" {
+			t.Errorf("media request %s is missing credentials or parameters", r.URL)
+		}
+		fmt.Fprint(w, "image")
+	}))
+	defer server.Close()
+
+	p, _ := NewProvider(retrometadata.ProviderConfig{
+		Enabled:     true,
+		Credentials: map[string]string{"username": "player", "password": "secret"},
+	}, cache.NewMemoryCache())
+	p.baseURL = server.URL + "/api2"
+	p.setQuota(Quota{MaxThreads: 1, RequestsToday: 8, MaxRequestsPerDay: 10})
+	client := &http.Client{Transport: p.MediaTransport(nil)}
+
+	mediaURL := server.URL + "/api2/mediaJeu.php?systemeid=1&jeuid=3&media=box-2D"
+	for range 2 {
+		resp, err := client.Get(mediaURL)
+		if err != nil {
+			t.Fatalf("Get() error: %v", err)
+		}
+		resp.Body.Close()
+	}
+	if q := p.Quota(); q.RequestsToday != 10 {
+		t.Errorf("RequestsToday = %d, want media downloads counted", q.RequestsToday)
+	}
+
+	// The quota is now used up, so downloads stop before reaching the server
+	_, err := client.Get(mediaURL)
+	var rateLimitErr *retrometadata.RateLimitError
+	if !errors.As(err, &rateLimitErr) {
+		t.Errorf("Get() with exhausted quota error = %v, want a RateLimitError", err)
+	}
+	if got := mediaRequests.Load(); got != 2 {
+		t.Errorf("server got %d media requests, want 2", got)
+	}
+}
+
+func TestKeyless(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		fmt.Fprint(w, `{"response": {}}`)
+	}))
+	defer server.Close()
+
+	p, _ := NewProvider(retrometadata.ProviderConfig{Enabled: true, RateLimit: 20}, cache.NewMemoryCache())
+	p.baseURL = server.URL
+	if !p.Keyless() {
+		t.Fatal("Keyless() = false without credentials")
+	}
+
+	if _, err := p.GetAccountInfo(context.Background()); !errors.Is(err, retrometadata.ErrCredentialsRequired) {
+		t.Errorf("GetAccountInfo() error = %v, want ErrCredentialsRequired", err)
+	}
+	if requests.Load() != 0 {
+		t.Error("GetAccountInfo() reached the server without credentials")
+	}
+
+	// Anonymous requests still work, within the rate limit
+	start := time.Now()
+	for range 3 {
+		if _, err := p.request(context.Background(), "jeuInfos.php", nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if elapsed := time.Since(start); elapsed < 90*time.Millisecond {
+		t.Errorf("3 requests at 20/s took %v, want them spaced out", elapsed)
+	}
+}
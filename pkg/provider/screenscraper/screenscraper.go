@@ -9,9 +9,12 @@ import (
 	"io"
 	"net/http"
 	"net/url"
+	"path/filepath"
 	"regexp"
+	"slices"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/josegonzalez/retro-metadata/pkg/cache"
@@ -56,24 +59,104 @@ type Provider struct {
 	httpClient       *http.Client
 	regionPriority   []string
 	languagePriority []string
+	// regionFilter is set when the regions option restricts media variants
+	regionFilter bool
+	mediaTypes   []string
+	coverType    string
+
+	// threads limits concurrent requests to the account's maxthreads. It
+	// starts at one until the first response reports the real limit.
+	threads *threadLimiter
+	quotaMu sync.RWMutex
+	quota   Quota
+	// rate spaces requests to the account's maxrequestspermin
+	rate *provider.RateLimiter
+	// limiter applies config.RateLimit, or provider.KeylessRateLimit to
+	// anonymous requests
+	limiter *provider.RateLimiter
+	keyless bool
+
+	// refreshSystems loads the list of systems before the first
+	// identification, until it loads once
+	refreshSystems   bool
+	systemsMu        sync.RWMutex
+	systemsLoaded    bool
+	systemNames      map[int]string
+	systemExtensions map[string]int
 }
 
 // NewProvider creates a new ScreenScraper provider instance.
+//
+// Options:
+//   - "regions": region priority for names and media, e.g. ["eu", "us"]. Media
+//     variants for other regions are left out of Artwork.Media.
+//   - "media_types": media types to collect into Artwork.Media, e.g.
+//     ["box-3D", "video", "manuel"]. Defaults to every type.
+//   - "cover_type": media type used for Artwork.CoverURL. Defaults to "box-2D".
+//   - "refresh_systems": load ScreenScraper's list of systems (see
+//     RefreshSystems) before the first identification.
+//
+// Without a username and password the provider runs anonymously, with the
+// developer credentials only: requests are limited to
+// provider.KeylessRateLimit per second unless config.RateLimit is set, and
+// GetAccountInfo isn't available.
+//
+// With a locale, synopses in the locale's language are preferred, then
+// English and French, and without a regions option names for the locale's
+// country are preferred.
 func NewProvider(config retrometadata.ProviderConfig, c cache.Cache) (*Provider, error) {
+	base, err := provider.NewBaseProvider("screenscraper", config, c)
+	if err != nil {
+		return nil, err
+	}
+	if err = provider.CheckProxy(config); err != nil {
+		return nil, err
+	}
+
 	p := &Provider{
-		BaseProvider:     provider.NewBaseProvider("screenscraper", config, c),
+		BaseProvider:     base,
 		baseURL:          "https://api.screenscraper.fr/api2",
 		userAgent:        "retro-metadata/1.0",
 		devID:            ssDevID,
 		devPassword:      ssDevPassword,
-		httpClient:       &http.Client{Timeout: 30 * time.Second},
+		httpClient:       provider.NewHTTPClient(config),
 		regionPriority:   append([]string{}, defaultRegions...),
 		languagePriority: append([]string{}, defaultLanguages...),
+		coverType:        MediaBox2D,
+		threads:          newThreadLimiter(1),
+	}
+	if regions := stringsOption(config.Options, "regions"); len(regions) > 0 {
+		p.regionPriority = regions
+		p.regionFilter = true
+	} else if region, ok := localeRegion(config.Locale); ok {
+		p.regionPriority = prepend(p.regionPriority, string(region))
+	}
+	for _, language := range slices.Backward(p.Languages()) {
+		p.languagePriority = prepend(p.languagePriority, string(language))
+	}
+	p.mediaTypes = stringsOption(config.Options, "media_types")
+	if coverType, ok := config.Options["cover_type"].(string); ok && coverType != "" {
+		p.coverType = coverType
+	}
+	if refresh, ok := config.Options["refresh_systems"].(bool); ok {
+		p.refreshSystems = refresh
+	}
+	p.keyless = !provider.HasCredentials(config, "username", "password")
+	if p.keyless {
+		p.limiter = provider.NewConfigRateLimiter(config, provider.KeylessRateLimit)
+	} else {
+		p.limiter = provider.NewConfigRateLimiter(config, 0)
 	}
 	p.SetMinSimilarityScore(0.6)
 	return p, nil
 }
 
+// Keyless reports whether the provider runs anonymously, without a
+// ScreenScraper account.
+func (p *Provider) Keyless() bool {
+	return p.keyless
+}
+
 func (p *Provider) username() string {
 	return p.GetCredential("username")
 }
@@ -119,28 +202,40 @@ func (p *Provider) request(ctx context.Context, endpoint string, params map[stri
 	}
 	req.Header.Set("User-Agent", p.userAgent)
 
+	// Stop before the server starts refusing (and eventually banning) the account
+	if p.Quota().Exhausted() {
+		return nil, &retrometadata.RateLimitError{Provider: p.Name(), Details: "daily request quota exhausted"}
+	}
+
+	if err := p.wait(ctx); err != nil {
+		return nil, err
+	}
+	if err := p.threads.acquire(ctx); err != nil {
+		return nil, err
+	}
 	resp, err := p.httpClient.Do(req)
 	if err != nil {
-		return nil, &retrometadata.ProviderError{Provider: p.Name(), Err: retrometadata.ErrProviderConnection}
+		p.threads.release()
+		return nil, retrometadata.RequestError(ctx, p.Name(), err)
 	}
-	defer resp.Body.Close()
-
 	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	p.threads.release()
 	if err != nil {
 		return nil, fmt.Errorf("failed to read response: %w", err)
 	}
 
 	// Check for login error in response text
 	if strings.Contains(string(body), "Erreur de login") {
-		return nil, &retrometadata.ProviderError{Provider: p.Name(), Err: retrometadata.ErrProviderAuth}
+		return nil, &retrometadata.AuthError{Provider: p.Name(), StatusCode: resp.StatusCode, Details: "login failed"}
 	}
 
-	if resp.StatusCode == 401 {
-		return nil, &retrometadata.ProviderError{Provider: p.Name(), Err: retrometadata.ErrProviderAuth}
+	// 430 and 431 mean the daily quota (or the quota for unknown ROMs) is used up
+	if resp.StatusCode == 430 || resp.StatusCode == 431 {
+		return nil, &retrometadata.RateLimitError{Provider: p.Name(), StatusCode: resp.StatusCode, Details: "daily request quota exhausted"}
 	}
-
-	if resp.StatusCode == 429 {
-		return nil, &retrometadata.ProviderError{Provider: p.Name(), Err: retrometadata.ErrProviderRateLimit}
+	if err := retrometadata.HTTPError(p.Name(), resp); err != nil {
+		return nil, err
 	}
 
 	var result map[string]interface{}
@@ -148,6 +243,10 @@ func (p *Provider) request(ctx context.Context, endpoint string, params map[stri
 		return nil, fmt.Errorf("failed to parse response: %w", err)
 	}
 
+	if quota, ok := parseQuota(result); ok {
+		p.setQuota(quota)
+	}
+
 	return result, nil
 }
 
@@ -180,40 +279,46 @@ func (p *Provider) AddAuthToURL(mediaURL string) string {
 }
 
 func (p *Provider) getPreferredName(names []interface{}) string {
-	for _, region := range p.regionPriority {
-		for _, n := range names {
-			if nMap, ok := n.(map[string]interface{}); ok {
-				if getString(nMap, "region") == region {
-					return getString(nMap, "text")
-				}
-			}
-		}
-	}
-	// Fallback to first name
-	if len(names) > 0 {
-		if nMap, ok := names[0].(map[string]interface{}); ok {
-			return getString(nMap, "text")
-		}
-	}
-	return ""
+	text, _ := preferredText(names, "region", p.regionPriority)
+	return text
 }
 
 func (p *Provider) getPreferredText(items []interface{}, langKey string) string {
-	for _, lang := range p.languagePriority {
-		for _, item := range items {
-			if itemMap, ok := item.(map[string]interface{}); ok {
-				if getString(itemMap, langKey) == lang {
-					return getString(itemMap, "text")
-				}
-			}
-		}
+	text, _ := preferredText(items, langKey, p.languagePriority)
+	return text
+}
+
+// localeRegion returns the region of a locale's country, e.g. "fr" for
+// "fr-FR". Returns false if the locale has no known country.
+func localeRegion(locale string) (retrometadata.Region, bool) {
+	_, country, ok := strings.Cut(strings.ReplaceAll(locale, "_", "-"), "-")
+	if !ok {
+		return "", false
 	}
-	if len(items) > 0 {
-		if itemMap, ok := items[0].(map[string]interface{}); ok {
-			return getString(itemMap, "text")
+	return retrometadata.ParseRegion(country)
+}
+
+// prepend returns values with value moved or added to the front.
+func prepend(values []string, value string) []string {
+	return append([]string{value}, slices.DeleteFunc(values, func(v string) bool { return v == value })...)
+}
+
+// preferredText returns the text of the item whose key (region or language)
+// ranks highest in priority, falling back to the first item, and its key.
+func preferredText(items []interface{}, key string, priority []string) (string, string) {
+	texts := make(map[string]string, len(items))
+	available := make([]string, 0, len(items))
+	for _, item := range items {
+		if itemMap, ok := item.(map[string]interface{}); ok {
+			value := getString(itemMap, key)
+			if _, seen := texts[value]; !seen {
+				texts[value] = getString(itemMap, "text")
+				available = append(available, value)
+			}
 		}
 	}
-	return ""
+	selected := retrometadata.SelectPreferred(available, priority)
+	return texts[selected], selected
 }
 
 func (p *Provider) getMediaURL(medias []interface{}, mediaType string) string {
@@ -239,6 +344,20 @@ func (p *Provider) getMediaURL(medias []interface{}, mediaType string) string {
 	return ""
 }
 
+// getMediaURLs returns the URLs of every game media of a type, like the maps
+// of each level.
+func (p *Provider) getMediaURLs(medias []interface{}, mediaType string) []string {
+	var urls []string
+	for _, m := range medias {
+		if mMap, ok := m.(map[string]interface{}); ok {
+			if getString(mMap, "type") == mediaType && getString(mMap, "parent") == "jeu" && getString(mMap, "url") != "" {
+				urls = append(urls, stripSensitiveParams(getString(mMap, "url")))
+			}
+		}
+	}
+	return urls
+}
+
 func stripSensitiveParams(u string) string {
 	if !strings.Contains(u, "?") {
 		return u
@@ -315,7 +434,7 @@ func (p *Provider) Search(ctx context.Context, query string, opts retrometadata.
 		medias, _ := game["medias"].([]interface{})
 
 		name := p.getPreferredName(names)
-		coverURL := p.getMediaURL(medias, "box-2D")
+		coverURL := p.getMediaURL(medias, p.coverType)
 
 		sr := retrometadata.SearchResult{
 			Provider:   p.Name(),
@@ -353,21 +472,12 @@ func (p *Provider) GetByID(ctx context.Context, gameID int) (*retrometadata.Game
 		return nil, nil
 	}
 
-	result, err := p.request(ctx, "jeuInfos.php", map[string]string{"gameid": strconv.Itoa(gameID)})
-	if err != nil {
-		return nil, err
-	}
-
-	response, _ := result["response"].(map[string]interface{})
-	game, ok := response["jeu"].(map[string]interface{})
-	if !ok || getString(game, "id") == "" {
-		return nil, nil
-	}
-
-	return p.buildGameResult(game), nil
+	return p.gameInfo(ctx, map[string]string{"gameid": strconv.Itoa(gameID)})
 }
 
 // LookupByHash looks up a game by ROM hash.
+// romSize is sent as romtaille when positive; for disc images it must be the
+// data track size (see identify.ExpectedSize), not the container size.
 func (p *Provider) LookupByHash(ctx context.Context, platformID int, md5, sha1, crc string, romSize int64) (*retrometadata.GameResult, error) {
 	if !p.IsEnabled() {
 		return nil, nil
@@ -391,7 +501,53 @@ func (p *Provider) LookupByHash(ctx context.Context, platformID int, md5, sha1,
 		params["romtaille"] = strconv.FormatInt(romSize, 10)
 	}
 
+	return p.gameInfo(ctx, params)
+}
+
+// LookupByROM looks up a game by its original ROM filename and size, along
+// with its serial and any hashes that are known. ScreenScraper matches these
+// against its ROM database, which is far more accurate than a name search.
+func (p *Provider) LookupByROM(ctx context.Context, platformID int, filename, serial string, hashes retrometadata.FileHashes) (*retrometadata.GameResult, error) {
+	if !p.IsEnabled() {
+		return nil, nil
+	}
+
+	romName := filepath.Base(filename)
+	if romName == "." || romName == string(filepath.Separator) {
+		return nil, nil
+	}
+
+	params := map[string]string{
+		"systemeid": strconv.Itoa(platformID),
+		"romtype":   "rom",
+		"romnom":    romName,
+	}
+	if serial != "" {
+		params["serialnum"] = serial
+	}
+	if hashes.Size > 0 {
+		params["romtaille"] = strconv.FormatInt(hashes.Size, 10)
+	}
+	if hashes.MD5 != "" {
+		params["md5"] = hashes.MD5
+	}
+	if hashes.SHA1 != "" {
+		params["sha1"] = hashes.SHA1
+	}
+	if hashes.CRC32 != "" {
+		params["crc"] = hashes.CRC32
+	}
+
+	return p.gameInfo(ctx, params)
+}
+
+// gameInfo fetches a single game from jeuInfos.php, which answers unknown
+// games and ROMs with a 404 and a plain-text message.
+func (p *Provider) gameInfo(ctx context.Context, params map[string]string) (*retrometadata.GameResult, error) {
 	result, err := p.request(ctx, "jeuInfos.php", params)
+	if retrometadata.HTTPStatus(err) == http.StatusNotFound {
+		return nil, nil
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -410,7 +566,7 @@ func (p *Provider) IdentifyByHash(ctx context.Context, hashes retrometadata.File
 	if opts.PlatformID == nil {
 		return nil, nil
 	}
-	return p.LookupByHash(ctx, *opts.PlatformID, hashes.MD5, hashes.SHA1, hashes.CRC32, 0)
+	return p.LookupByHash(ctx, *opts.PlatformID, hashes.MD5, hashes.SHA1, hashes.CRC32, hashes.Size)
 }
 
 // Identify identifies a game from a ROM filename.
@@ -430,7 +586,21 @@ func (p *Provider) Identify(ctx context.Context, filename string, opts retrometa
 	}
 
 	if opts.PlatformID == nil {
-		return nil, nil
+		p.loadSystems(ctx)
+		id, ok := p.systemForFile(filename)
+		if !ok {
+			return nil, nil
+		}
+		opts.PlatformID = &id
+	}
+
+	// Try an exact ROM lookup by filename, serial, size and hashes first
+	var hashes retrometadata.FileHashes
+	if opts.Hashes != nil {
+		hashes = *opts.Hashes
+	}
+	if result, err := p.LookupByROM(ctx, *opts.PlatformID, filename, provider.Serial(p.Name(), filename, opts), hashes); err != nil || result != nil {
+		return result, err
 	}
 
 	// Clean the filename
@@ -513,7 +683,7 @@ func (p *Provider) Identify(ctx context.Context, filename string, opts retrometa
 	}
 
 	// Find best match
-	bestMatch, score := p.FindBestMatch(searchTerm, names)
+	bestMatch, score := p.FindBestMatchContext(ctx, searchTerm, names)
 
 	if bestMatch != "" {
 		if game, ok := gamesByName[bestMatch]; ok {
@@ -538,7 +708,8 @@ func (p *Provider) buildGameResult(game map[string]interface{}) *retrometadata.G
 	medias, _ := game["medias"].([]interface{})
 
 	name := p.getPreferredName(names)
-	summary := p.getPreferredText(synopsis, "langue")
+	summary, summaryLanguage := preferredText(synopsis, "langue", p.languagePriority)
+	language, _ := retrometadata.ParseLanguage(summaryLanguage)
 
 	providerID := getInt(game, "id")
 	result := &retrometadata.GameResult{
@@ -547,30 +718,38 @@ func (p *Provider) buildGameResult(game map[string]interface{}) *retrometadata.G
 		ProviderIDs: map[string]int{"screenscraper": providerID},
 		Name:        strings.ReplaceAll(name, " : ", ": "),
 		Summary:     summary,
+		Language:    language,
 		RawResponse: game,
 	}
 
 	// Extract artwork
-	result.Artwork.CoverURL = p.getMediaURL(medias, "box-2D")
+	result.Artwork.CoverURL = p.getMediaURL(medias, p.coverType)
+	if result.Artwork.CoverURL == "" && p.coverType != MediaBox2D {
+		result.Artwork.CoverURL = p.getMediaURL(medias, MediaBox2D)
+	}
 
-	if ssURL := p.getMediaURL(medias, "ss"); ssURL != "" {
+	if ssURL := p.getMediaURL(medias, MediaScreenshot); ssURL != "" {
 		result.Artwork.ScreenshotURLs = append(result.Artwork.ScreenshotURLs, ssURL)
 	}
-	if titleScreen := p.getMediaURL(medias, "sstitle"); titleScreen != "" {
+	if titleScreen := p.getMediaURL(medias, MediaTitleScreen); titleScreen != "" {
 		result.Artwork.ScreenshotURLs = append(result.Artwork.ScreenshotURLs, titleScreen)
 	}
-	if fanart := p.getMediaURL(medias, "fanart"); fanart != "" {
+	if fanart := p.getMediaURL(medias, MediaFanart); fanart != "" {
 		result.Artwork.ScreenshotURLs = append(result.Artwork.ScreenshotURLs, fanart)
 	}
 
-	result.Artwork.LogoURL = p.getMediaURL(medias, "wheel-hd")
+	result.Artwork.LogoURL = p.getMediaURL(medias, MediaWheelHD)
 	if result.Artwork.LogoURL == "" {
-		result.Artwork.LogoURL = p.getMediaURL(medias, "wheel")
+		result.Artwork.LogoURL = p.getMediaURL(medias, MediaWheel)
 	}
-	result.Artwork.BannerURL = p.getMediaURL(medias, "screenmarquee")
+	result.Artwork.BannerURL = p.getMediaURL(medias, MediaMarquee)
+	result.Artwork.ManualURL = p.getMediaURL(medias, MediaManual)
+	result.Artwork.MapURLs = p.getMediaURLs(medias, MediaMaps)
+	result.Artwork.Media = p.collectMedia(medias)
 
 	// Extract metadata
 	result.Metadata = p.extractMetadata(game)
+	result.Metadata.Videos = p.collectVideos(medias)
 
 	return result
 }
@@ -641,26 +820,10 @@ func (p *Provider) extractMetadata(game map[string]interface{}) retrometadata.Ga
 
 	// Companies
 	if editeur, ok := game["editeur"].(map[string]interface{}); ok {
-		if text := getString(editeur, "text"); text != "" {
-			metadata.Companies = append(metadata.Companies, text)
-			metadata.Publisher = text
-		}
+		metadata.AddCompany(retrometadata.Company{Name: getString(editeur, "text"), Publisher: true})
 	}
 	if dev, ok := game["developpeur"].(map[string]interface{}); ok {
-		if text := getString(dev, "text"); text != "" {
-			// Avoid duplicates
-			found := false
-			for _, c := range metadata.Companies {
-				if c == text {
-					found = true
-					break
-				}
-			}
-			if !found {
-				metadata.Companies = append(metadata.Companies, text)
-			}
-			metadata.Developer = text
-		}
+		metadata.AddCompany(retrometadata.Company{Name: getString(dev, "text"), Developer: true})
 	}
 
 	// Rating (SS scores are out of 20, normalize to 100)
@@ -710,11 +873,42 @@ func (p *Provider) extractMetadata(game map[string]interface{}) retrometadata.Ga
 				}
 			}
 		}
+		metadata.Releases = releases(game, dates)
 	}
 
 	return metadata
 }
 
+// releases returns a game's release in each region from its dates, which
+// are full dates or years.
+func releases(game map[string]interface{}, dates []interface{}) []retrometadata.Release {
+	var platformSlug string
+	if systeme, ok := game["systeme"].(map[string]interface{}); ok {
+		platformSlug = string(platform.SlugFromScreenScraperID(getInt(systeme, "id")))
+	}
+
+	var result []retrometadata.Release
+	for _, d := range dates {
+		dMap, ok := d.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		release := retrometadata.Release{PlatformSlug: platformSlug}
+		release.Region, _ = retrometadata.ParseRegion(getString(dMap, "region"))
+		text := getString(dMap, "text")
+		if t, err := time.Parse("2006-01-02", text); err == nil {
+			release.Date = t.Unix()
+			release.Year = t.Year()
+		} else if len(text) >= 4 {
+			release.Year, _ = strconv.Atoi(text[:4])
+		}
+		if release.Year > 0 {
+			result = append(result, release)
+		}
+	}
+	return result
+}
+
 // GetPlatform returns platform information for a slug.
 func (p *Provider) GetPlatform(slug string) *retrometadata.Platform {
 	platformSlug := platform.Slug(slug)
@@ -723,7 +917,7 @@ func (p *Provider) GetPlatform(slug string) *retrometadata.Platform {
 		return nil
 	}
 
-	name := ScreenScraperPlatformNames[*platformID]
+	name := p.platformName(*platformID)
 	if name == "" {
 		name = strings.ReplaceAll(slug, "-", " ")
 	}
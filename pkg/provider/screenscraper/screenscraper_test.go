@@ -0,0 +1,109 @@
+package screenscraper
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/josegonzalez/retro-metadata/pkg/cache"
+	"github.com/josegonzalez/retro-metadata/pkg/retrometadata"
+)
+
+func TestIdentifyByROMName(t *testing.T) {
+	var searched bool
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		switch r.URL.Path {
+		case "/jeuInfos.php":
+			if q.Get("romnom") != "Super Metroid (Japan, USA) (En,Ja).sfc" || q.Get("romtaille") != "3145728" || q.Get("crc") != "d63ed5f8" {
+				http.Error(w, "Erreur : Rom/Iso/Dossier non trouvée !", http.StatusNotFound)
+				return
+			}
+			_ = json.NewEncoder(w).Encode(map[string]any{"response": map[string]any{"jeu": map[string]any{
+				"id":   "1171",
+				"noms": []any{map[string]any{"region": "us", "text": "Super Metroid"}},
+			}}})
+		case "/jeuRecherche.php":
+			searched = true
+			_ = json.NewEncoder(w).Encode(map[string]any{"response": map[string]any{"jeux": []any{map[string]any{}}}})
+		}
+	}))
+	defer server.Close()
+
+	config := retrometadata.ProviderConfig{
+		Enabled:     true,
+		Credentials: map[string]string{"username": "user", "password": "pass"},
+	}
+	p, _ := NewProvider(config, cache.NewMemoryCache())
+	p.baseURL = server.URL
+	platformID := 4
+	ctx := context.Background()
+
+	result, err := p.Identify(ctx, "/roms/snes/Super Metroid (Japan, USA) (En,Ja).sfc", retrometadata.IdentifyOptions{
+		PlatformID: &platformID,
+		Hashes:     &retrometadata.FileHashes{CRC32: "d63ed5f8", Size: 3145728},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result == nil || result.Name != "Super Metroid" {
+		t.Fatalf("Identify() = %+v, want Super Metroid", result)
+	}
+	if searched {
+		t.Error("Identify() fell back to a name search despite an exact ROM match")
+	}
+
+	// An unknown ROM falls back to the name search
+	result, err = p.Identify(ctx, "Unknown Game (USA).sfc", retrometadata.IdentifyOptions{PlatformID: &platformID})
+	if err != nil || result != nil {
+		t.Errorf("Identify() for an unknown ROM = %+v, %v; want no match", result, err)
+	}
+	if !searched {
+		t.Error("Identify() didn't fall back to a name search")
+	}
+}
+
+func TestServerError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		_, _ = w.Write([]byte("<html>Service Unavailable</html>"))
+	}))
+	defer server.Close()
+
+	config := retrometadata.ProviderConfig{
+		Enabled:     true,
+		Credentials: map[string]string{"username": "user", "password": "pass"},
+	}
+	p, _ := NewProvider(config, cache.NewMemoryCache())
+	p.baseURL = server.URL
+
+	_, err := p.GetByID(context.Background(), 1171)
+	if !retrometadata.Retryable(err) || retrometadata.HTTPStatus(err) != http.StatusServiceUnavailable {
+		t.Errorf("GetByID() error = %v, want a retryable 503", err)
+	}
+}
+
+func TestNotFound(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "Erreur : Jeu non trouvée !", http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	config := retrometadata.ProviderConfig{
+		Enabled:     true,
+		Credentials: map[string]string{"username": "user", "password": "pass"},
+	}
+	p, _ := NewProvider(config, cache.NewMemoryCache())
+	p.baseURL = server.URL
+	ctx := context.Background()
+
+	// Only jeuInfos.php's 404 means the game is unknown
+	if game, err := p.GetByID(ctx, 1171); game != nil || err != nil {
+		t.Errorf("GetByID() of an unknown game = %v, %v; want nil, nil", game, err)
+	}
+	if _, err := p.Search(ctx, "Super Metroid", retrometadata.SearchOptions{}); retrometadata.HTTPStatus(err) != http.StatusNotFound {
+		t.Errorf("Search() against a missing endpoint error = %v, want a 404", err)
+	}
+}
@@ -0,0 +1,159 @@
+package screenscraper
+
+import (
+	"context"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/josegonzalez/retro-metadata/pkg/platform"
+	"github.com/josegonzalez/retro-metadata/pkg/provider"
+)
+
+// systemsTTL is how long the list of systems is cached. ScreenScraper adds
+// systems rarely.
+const systemsTTL = 7 * 24 * time.Hour
+
+// System is a platform as ScreenScraper lists it.
+type System struct {
+	// ID is the ScreenScraper platform ID
+	ID int `json:"id"`
+	// ParentID is the ID of the platform this one is a variant of, or 0
+	ParentID int `json:"parent_id,omitempty"`
+	// Name is the platform's name, the US name if it has one
+	Name string `json:"name"`
+	// Extensions are the ROM file extensions, without the dot
+	Extensions []string `json:"extensions,omitempty"`
+	// Type is the kind of platform, e.g. "Console" or "Arcade"
+	Type string `json:"type,omitempty"`
+}
+
+// Systems returns the platforms ScreenScraper lists, from the cache if they
+// were fetched within systemsTTL.
+func (p *Provider) Systems(ctx context.Context) ([]System, error) {
+	if systems, ok := provider.GetCachedTyped[[]System](ctx, p.BaseProvider, "systems"); ok && len(systems) > 0 {
+		return systems, nil
+	}
+
+	result, err := p.request(ctx, "systemesListe.php", nil)
+	if err != nil {
+		return nil, err
+	}
+	response, _ := result["response"].(map[string]interface{})
+	list, _ := response["systemes"].([]interface{})
+
+	var systems []System
+	for _, item := range list {
+		s, ok := item.(map[string]interface{})
+		if !ok || getInt(s, "id") == 0 {
+			continue
+		}
+		system := System{
+			ID:       getInt(s, "id"),
+			ParentID: getInt(s, "parentid"),
+			Name:     systemName(s),
+			Type:     getString(s, "type"),
+		}
+		for _, ext := range strings.Split(getString(s, "extensions"), ",") {
+			if ext = strings.ToLower(strings.TrimSpace(ext)); ext != "" {
+				system.Extensions = append(system.Extensions, ext)
+			}
+		}
+		systems = append(systems, system)
+	}
+	if len(systems) > 0 {
+		_ = provider.SetCachedTyped(ctx, p.BaseProvider, "systems", systems, systemsTTL)
+	}
+	return systems, nil
+}
+
+// systemName returns a system's US name, or its European or first common
+// name.
+func systemName(s map[string]interface{}) string {
+	names, _ := s["noms"].(map[string]interface{})
+	for _, key := range []string{"nom_us", "nom_eu"} {
+		if name := getString(names, key); name != "" {
+			return name
+		}
+	}
+	common, _, _ := strings.Cut(getString(names, "noms_commun"), ",")
+	return strings.TrimSpace(common)
+}
+
+// RefreshSystems loads the platforms ScreenScraper lists, replacing the
+// built-in ScreenScraperPlatformNames for the platforms it knows, and
+// identifying ROMs without a platform ID by extension. Extensions only one
+// platform uses are registered with platform.RegisterExtensions, so
+// platform.DetectFromPath recognizes them too.
+func (p *Provider) RefreshSystems(ctx context.Context) error {
+	systems, err := p.Systems(ctx)
+	if err != nil {
+		return err
+	}
+
+	names := make(map[int]string, len(systems))
+	byExtension := make(map[string][]int)
+	for _, system := range systems {
+		if system.Name != "" {
+			names[system.ID] = system.Name
+		}
+		for _, ext := range system.Extensions {
+			if !slices.Contains(byExtension[ext], system.ID) {
+				byExtension[ext] = append(byExtension[ext], system.ID)
+			}
+		}
+	}
+
+	extensions := make(map[string]int)
+	slugs := make(map[string]platform.Slug)
+	for ext, ids := range byExtension {
+		if len(ids) != 1 {
+			continue
+		}
+		extensions[ext] = ids[0]
+		if slug := platform.SlugFromScreenScraperID(ids[0]); slug != "" {
+			slugs[ext] = slug
+		}
+	}
+	platform.RegisterExtensions(slugs)
+
+	p.systemsMu.Lock()
+	p.systemsLoaded = true
+	p.systemNames = names
+	p.systemExtensions = extensions
+	p.systemsMu.Unlock()
+	return nil
+}
+
+// loadSystems refreshes the systems if the refresh_systems option is set
+// and they haven't loaded yet. Failures are retried on the next call.
+func (p *Provider) loadSystems(ctx context.Context) {
+	p.systemsMu.RLock()
+	loaded := p.systemsLoaded
+	p.systemsMu.RUnlock()
+	if p.refreshSystems && !loaded {
+		_ = p.RefreshSystems(ctx)
+	}
+}
+
+// platformName returns the name of a ScreenScraper platform, as listed by
+// RefreshSystems or built in.
+func (p *Provider) platformName(id int) string {
+	p.systemsMu.RLock()
+	defer p.systemsMu.RUnlock()
+	if name, ok := p.systemNames[id]; ok {
+		return name
+	}
+	return ScreenScraperPlatformNames[id]
+}
+
+// systemForFile returns the ScreenScraper platform of a ROM from its
+// extension, if RefreshSystems found only one platform using it.
+func (p *Provider) systemForFile(filename string) (int, bool) {
+	ext := strings.ToLower(strings.TrimPrefix(filepath.Ext(filename), "."))
+	p.systemsMu.RLock()
+	defer p.systemsMu.RUnlock()
+	id, ok := p.systemExtensions[ext]
+	return id, ok
+}
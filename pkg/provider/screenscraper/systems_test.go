@@ -0,0 +1,77 @@
+package screenscraper
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/josegonzalez/retro-metadata/pkg/cache"
+	"github.com/josegonzalez/retro-metadata/pkg/platform"
+	"github.com/josegonzalez/retro-metadata/pkg/retrometadata"
+)
+
+func TestRefreshSystems(t *testing.T) {
+	var listed int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/systemesListe.php":
+			listed++
+			_ = json.NewEncoder(w).Encode(map[string]any{"response": map[string]any{"systemes": []any{
+				map[string]any{"id": 1, "noms": map[string]any{"nom_eu": "Megadrive", "nom_us": "Genesis"}, "extensions": "bin,gen,md,smd,mdx,zip", "type": "Console"},
+				map[string]any{"id": 2, "parentid": 0, "noms": map[string]any{"noms_commun": "Master System, Mark III"}, "extensions": "bin,sms,zip"},
+			}}})
+		case "/jeuInfos.php":
+			if r.URL.Query().Get("systemeid") != "1" {
+				http.Error(w, "Erreur : Rom/Iso/Dossier non trouvée !", http.StatusNotFound)
+				return
+			}
+			_ = json.NewEncoder(w).Encode(map[string]any{"response": map[string]any{"jeu": map[string]any{
+				"id":   "3",
+				"noms": []any{map[string]any{"region": "us", "text": "Sonic the Hedgehog"}},
+			}}})
+		}
+	}))
+	defer server.Close()
+
+	config := retrometadata.ProviderConfig{
+		Enabled:     true,
+		Credentials: map[string]string{"username": "user", "password": "pass"},
+		Options:     map[string]any{"refresh_systems": true},
+	}
+	p, _ := NewProvider(config, cache.NewMemoryCache())
+	p.baseURL = server.URL
+	ctx := context.Background()
+
+	systems, err := p.Systems(ctx)
+	if err != nil || len(systems) != 2 {
+		t.Fatalf("Systems() = %+v, %v", systems, err)
+	}
+	if systems[0].Name != "Genesis" || systems[1].Name != "Master System" || len(systems[0].Extensions) != 6 {
+		t.Errorf("Systems() = %+v", systems)
+	}
+
+	// Without a platform ID, the platform is found by extension
+	result, err := p.Identify(ctx, "Sonic the Hedgehog (USA).mdx", retrometadata.IdentifyOptions{})
+	if err != nil || result == nil || result.Name != "Sonic the Hedgehog" {
+		t.Fatalf("Identify() = %+v, %v", result, err)
+	}
+	if listed != 1 {
+		t.Errorf("systemesListe.php requested %d times, want once (then cached)", listed)
+	}
+
+	if got := p.GetPlatform("genesis"); got == nil || got.Name != "Genesis" {
+		t.Errorf("GetPlatform(genesis) = %+v, want the listed name", got)
+	}
+	if got := platform.DetectFromFilename("Game.mdx"); got != platform.SlugGenesis {
+		t.Errorf("DetectFromFilename(.mdx) = %q, want %q", got, platform.SlugGenesis)
+	}
+	// Shared extensions aren't registered
+	if got := platform.DetectFromFilename("Game.bin"); got != "" {
+		t.Errorf("DetectFromFilename(.bin) = %q, want none", got)
+	}
+	if _, ok := p.systemForFile("Game.zip"); ok {
+		t.Error("systemForFile(.zip) found a system for a shared extension")
+	}
+}
@@ -0,0 +1,20 @@
+package provider
+
+import (
+	"iter"
+
+	"github.com/josegonzalez/retro-metadata/pkg/retrometadata"
+)
+
+// CollectResults collects the results of a search iterator, e.g. to
+// implement Search with SearchIter. It stops at the first error.
+func CollectResults(results iter.Seq2[retrometadata.SearchResult, error]) ([]retrometadata.SearchResult, error) {
+	var collected []retrometadata.SearchResult
+	for result, err := range results {
+		if err != nil {
+			return nil, err
+		}
+		collected = append(collected, result)
+	}
+	return collected, nil
+}
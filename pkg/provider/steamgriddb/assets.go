@@ -0,0 +1,163 @@
+package steamgriddb
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// SGDBType represents SteamGridDB animation type options.
+type SGDBType string
+
+const (
+	TypeStatic   SGDBType = "static"
+	TypeAnimated SGDBType = "animated"
+)
+
+// AssetQuery filters the artwork returned by GetGrids, GetHeroes, GetLogos
+// and GetIcons. Empty filters match everything. The content filters (nsfw,
+// humor, epilepsy) come from the provider options.
+type AssetQuery struct {
+	// Dimensions limits grids, heroes and icons to these sizes
+	Dimensions []SGDBDimension
+	// Styles limits artwork to these styles
+	Styles []SGDBStyle
+	// Mimes limits artwork to these file types
+	Mimes []SGDBMime
+	// Types limits artwork to static or animated assets
+	Types []SGDBType
+	// Pages is the number of result pages to fetch; 0 fetches one page and a
+	// negative value fetches every page
+	Pages int
+}
+
+// Asset is a single piece of artwork.
+type Asset struct {
+	ID        int    `json:"id"`
+	Score     int    `json:"score"`
+	Style     string `json:"style"`
+	Width     int    `json:"width"`
+	Height    int    `json:"height"`
+	NSFW      bool   `json:"nsfw"`
+	Humor     bool   `json:"humor"`
+	Epilepsy  bool   `json:"epilepsy"`
+	Mime      string `json:"mime"`
+	Language  string `json:"language"`
+	URL       string `json:"url"`
+	Thumb     string `json:"thumb"`
+	Upvotes   int    `json:"upvotes"`
+	Downvotes int    `json:"downvotes"`
+}
+
+// GetGrids returns the grids (covers) for a game.
+func (p *Provider) GetGrids(ctx context.Context, gameID int, query AssetQuery) ([]Asset, error) {
+	return p.fetchAssets(ctx, "grids", gameID, query)
+}
+
+// GetHeroes returns the heroes (banners and backgrounds) for a game.
+func (p *Provider) GetHeroes(ctx context.Context, gameID int, query AssetQuery) ([]Asset, error) {
+	return p.fetchAssets(ctx, "heroes", gameID, query)
+}
+
+// GetLogos returns the logos for a game.
+func (p *Provider) GetLogos(ctx context.Context, gameID int, query AssetQuery) ([]Asset, error) {
+	return p.fetchAssets(ctx, "logos", gameID, query)
+}
+
+// GetIcons returns the icons for a game.
+func (p *Provider) GetIcons(ctx context.Context, gameID int, query AssetQuery) ([]Asset, error) {
+	return p.fetchAssets(ctx, "icons", gameID, query)
+}
+
+// fetchAssets fetches the artwork of one kind for a game, following pages up
+// to query.Pages.
+func (p *Provider) fetchAssets(ctx context.Context, kind string, gameID int, query AssetQuery) ([]Asset, error) {
+	if !p.config.Enabled {
+		return nil, nil
+	}
+
+	params := p.buildFilterParams(query.Dimensions, query.Styles, query.Mimes)
+	if len(query.Types) > 0 {
+		types := make([]string, len(query.Types))
+		for i, t := range query.Types {
+			types[i] = string(t)
+		}
+		params.Set("types", strings.Join(types, ","))
+	}
+
+	pages := query.Pages
+	if pages == 0 {
+		pages = 1
+	}
+
+	var assets []Asset
+	for page := 0; pages < 0 || page < pages; page++ {
+		params.Set("page", strconv.Itoa(page))
+
+		var resp struct {
+			Success bool    `json:"success"`
+			Total   int     `json:"total"`
+			Data    []Asset `json:"data"`
+		}
+		if err := p.requestInto(ctx, fmt.Sprintf("/%s/game/%d", kind, gameID), params, &resp); err != nil {
+			return nil, err
+		}
+		if !resp.Success {
+			break
+		}
+
+		assets = append(assets, resp.Data...)
+		if len(resp.Data) == 0 || len(assets) >= resp.Total {
+			break
+		}
+	}
+	return assets, nil
+}
+
+// Animated asset preferences for the "animated" option.
+const (
+	AnimatedAllow   = "allow"
+	AnimatedPrefer  = "prefer"
+	AnimatedExclude = "exclude"
+)
+
+// RankAssets sorts assets best first: by score, then by net votes. Assets
+// that tie keep SteamGridDB's order.
+func RankAssets(assets []Asset) {
+	slices.SortStableFunc(assets, func(a, b Asset) int {
+		if c := cmp.Compare(b.Score, a.Score); c != 0 {
+			return c
+		}
+		return cmp.Compare(b.Upvotes-b.Downvotes, a.Upvotes-a.Downvotes)
+	})
+}
+
+// rankedAssets fetches the assets of one kind for a game following the
+// animated preference, best first.
+func (p *Provider) rankedAssets(ctx context.Context, kind string, gameID int, styles []SGDBStyle) ([]Asset, error) {
+	query := AssetQuery{Styles: styles}
+	switch p.animated {
+	case AnimatedPrefer:
+		query.Types = []SGDBType{TypeAnimated}
+	case AnimatedExclude:
+		query.Types = []SGDBType{TypeStatic}
+	}
+
+	assets, err := p.fetchAssets(ctx, kind, gameID, query)
+	if err != nil {
+		return nil, err
+	}
+	if len(assets) == 0 && p.animated == AnimatedPrefer {
+		// Fall back to static artwork when there's nothing animated
+		query.Types = nil
+		if assets, err = p.fetchAssets(ctx, kind, gameID, query); err != nil {
+			return nil, err
+		}
+	}
+
+	RankAssets(assets)
+	return assets, nil
+}
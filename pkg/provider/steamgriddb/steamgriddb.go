@@ -10,8 +10,8 @@ import (
 	"net/url"
 	"regexp"
 	"strings"
-	"time"
 
+	"github.com/josegonzalez/retro-metadata/pkg/provider"
 	retrometadata "github.com/josegonzalez/retro-metadata/pkg/retrometadata"
 )
 
@@ -71,7 +71,7 @@ var (
 	sgdbTagRegex = regexp.MustCompile(`(?i)\(sgdb-(\d+)\)`)
 
 	// ErrProviderDisabled is returned when the provider is disabled.
-	ErrProviderDisabled = fmt.Errorf("provider is disabled")
+	ErrProviderDisabled = retrometadata.ErrProviderDisabled
 )
 
 // Provider implements the SteamGridDB artwork provider.
@@ -83,18 +83,22 @@ type Provider struct {
 	nsfw      bool
 	humor     bool
 	epilepsy  bool
+	// animated is the animated asset preference (AnimatedAllow, AnimatedPrefer or AnimatedExclude)
+	animated string
+	// officialOnly limits logos to the official style
+	officialOnly bool
 }
 
 // New creates a new SteamGridDB provider.
+//
+// Besides the nsfw, humor and epilepsy content filters, the options control
+// which artwork fills a result: "animated" is "allow" (default), "prefer" or
+// "exclude", and "official_only" limits logos to the official style. The
+// best-scored asset of each kind is used.
 func New(config *retrometadata.ProviderConfig) *Provider {
-	timeout := time.Duration(config.Timeout) * time.Second
-	if timeout == 0 {
-		timeout = 30 * time.Second
-	}
-
 	p := &Provider{
 		config:    config,
-		client:    &http.Client{Timeout: timeout},
+		client:    provider.NewHTTPClient(*config),
 		baseURL:   "https://www.steamgriddb.com/api/v2",
 		userAgent: "retro-metadata/1.0",
 		nsfw:      false,
@@ -113,6 +117,12 @@ func New(config *retrometadata.ProviderConfig) *Provider {
 		if epilepsy, ok := config.Options["epilepsy"].(bool); ok {
 			p.epilepsy = epilepsy
 		}
+		if animated, ok := config.Options["animated"].(string); ok {
+			p.animated = animated
+		}
+		if officialOnly, ok := config.Options["official_only"].(bool); ok {
+			p.officialOnly = officialOnly
+		}
 	}
 
 	return p
@@ -128,6 +138,19 @@ func (p *Provider) apiKey() string {
 }
 
 func (p *Provider) request(ctx context.Context, endpoint string, params url.Values) (map[string]interface{}, error) {
+	var result map[string]interface{}
+	if err := p.requestInto(ctx, endpoint, params, &result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// requestInto makes an API request and decodes the response into out.
+func (p *Provider) requestInto(ctx context.Context, endpoint string, params url.Values, out any) error {
+	if p.apiKey() == "" {
+		return retrometadata.CredentialsRequired(p.Name(), "API requests")
+	}
+
 	reqURL := p.baseURL + endpoint
 	if len(params) > 0 {
 		reqURL += "?" + params.Encode()
@@ -135,7 +158,7 @@ func (p *Provider) request(ctx context.Context, endpoint string, params url.Valu
 
 	req, err := http.NewRequestWithContext(ctx, "GET", reqURL, nil)
 	if err != nil {
-		return nil, err
+		return err
 	}
 
 	req.Header.Set("User-Agent", p.userAgent)
@@ -143,31 +166,23 @@ func (p *Provider) request(ctx context.Context, endpoint string, params url.Valu
 
 	resp, err := p.client.Do(req)
 	if err != nil {
-		return nil, retrometadata.NewProviderError(p.Name(), "request", err)
+		return retrometadata.RequestError(ctx, p.Name(), err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode == http.StatusUnauthorized {
-		return nil, &retrometadata.AuthError{Provider: p.Name(), Details: "invalid API key"}
-	}
-	if resp.StatusCode == http.StatusTooManyRequests {
-		return nil, &retrometadata.RateLimitError{Provider: p.Name()}
+		return &retrometadata.AuthError{Provider: p.Name(), StatusCode: resp.StatusCode, Details: "invalid API key"}
 	}
-	if resp.StatusCode != http.StatusOK {
-		return nil, &retrometadata.ConnectionError{Provider: p.Name(), Details: fmt.Sprintf("HTTP %d", resp.StatusCode)}
+	if err := retrometadata.HTTPError(p.Name(), resp); err != nil {
+		return err
 	}
 
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return nil, err
+		return err
 	}
 
-	var result map[string]interface{}
-	if err := json.Unmarshal(body, &result); err != nil {
-		return nil, err
-	}
-
-	return result, nil
+	return json.Unmarshal(body, out)
 }
 
 func (p *Provider) buildFilterParams(dimensions []SGDBDimension, styles []SGDBStyle, mimes []SGDBMime) url.Values {
@@ -215,140 +230,34 @@ func (p *Provider) buildFilterParams(dimensions []SGDBDimension, styles []SGDBSt
 	return params
 }
 
-func (p *Provider) fetchGrids(ctx context.Context, gameID int) ([]map[string]interface{}, error) {
-	params := p.buildFilterParams(nil, nil, nil)
-	result, err := p.request(ctx, fmt.Sprintf("/grids/game/%d", gameID), params)
-	if err != nil {
-		return nil, err
-	}
-
-	if success, ok := result["success"].(bool); !ok || !success {
-		return nil, nil
-	}
-
-	data, ok := result["data"].([]interface{})
-	if !ok {
-		return nil, nil
-	}
-
-	var grids []map[string]interface{}
-	for _, item := range data {
-		if grid, ok := item.(map[string]interface{}); ok {
-			grids = append(grids, grid)
-		}
-	}
-	return grids, nil
-}
-
-func (p *Provider) fetchHeroes(ctx context.Context, gameID int) ([]map[string]interface{}, error) {
-	params := p.buildFilterParams(nil, nil, nil)
-	result, err := p.request(ctx, fmt.Sprintf("/heroes/game/%d", gameID), params)
-	if err != nil {
-		return nil, err
-	}
-
-	if success, ok := result["success"].(bool); !ok || !success {
-		return nil, nil
-	}
-
-	data, ok := result["data"].([]interface{})
-	if !ok {
-		return nil, nil
-	}
-
-	var heroes []map[string]interface{}
-	for _, item := range data {
-		if hero, ok := item.(map[string]interface{}); ok {
-			heroes = append(heroes, hero)
-		}
-	}
-	return heroes, nil
-}
-
-func (p *Provider) fetchLogos(ctx context.Context, gameID int) ([]map[string]interface{}, error) {
-	params := p.buildFilterParams(nil, nil, nil)
-	result, err := p.request(ctx, fmt.Sprintf("/logos/game/%d", gameID), params)
-	if err != nil {
-		return nil, err
-	}
-
-	if success, ok := result["success"].(bool); !ok || !success {
-		return nil, nil
-	}
-
-	data, ok := result["data"].([]interface{})
-	if !ok {
-		return nil, nil
-	}
-
-	var logos []map[string]interface{}
-	for _, item := range data {
-		if logo, ok := item.(map[string]interface{}); ok {
-			logos = append(logos, logo)
-		}
-	}
-	return logos, nil
-}
-
-func (p *Provider) fetchIcons(ctx context.Context, gameID int) ([]map[string]interface{}, error) {
-	params := p.buildFilterParams(nil, nil, nil)
-	result, err := p.request(ctx, fmt.Sprintf("/icons/game/%d", gameID), params)
-	if err != nil {
-		return nil, err
-	}
-
-	if success, ok := result["success"].(bool); !ok || !success {
-		return nil, nil
-	}
-
-	data, ok := result["data"].([]interface{})
-	if !ok {
-		return nil, nil
-	}
-
-	var icons []map[string]interface{}
-	for _, item := range data {
-		if icon, ok := item.(map[string]interface{}); ok {
-			icons = append(icons, icon)
-		}
-	}
-	return icons, nil
-}
-
 func (p *Provider) fetchAllArtwork(ctx context.Context, gameID int) retrometadata.Artwork {
 	artwork := retrometadata.Artwork{}
 
 	// Fetch grids (covers)
-	if grids, err := p.fetchGrids(ctx, gameID); err == nil && len(grids) > 0 {
-		if url, ok := grids[0]["url"].(string); ok {
-			artwork.CoverURL = url
-		}
+	if grids, err := p.rankedAssets(ctx, "grids", gameID, nil); err == nil && len(grids) > 0 {
+		artwork.CoverURL = grids[0].URL
 	}
 
 	// Fetch heroes (banners/backgrounds)
-	if heroes, err := p.fetchHeroes(ctx, gameID); err == nil && len(heroes) > 0 {
-		if url, ok := heroes[0]["url"].(string); ok {
-			artwork.BackgroundURL = url
-		}
+	if heroes, err := p.rankedAssets(ctx, "heroes", gameID, nil); err == nil && len(heroes) > 0 {
+		artwork.BackgroundURL = heroes[0].URL
 		if len(heroes) > 1 {
-			if url, ok := heroes[1]["url"].(string); ok {
-				artwork.BannerURL = url
-			}
+			artwork.BannerURL = heroes[1].URL
 		}
 	}
 
 	// Fetch logos
-	if logos, err := p.fetchLogos(ctx, gameID); err == nil && len(logos) > 0 {
-		if url, ok := logos[0]["url"].(string); ok {
-			artwork.LogoURL = url
-		}
+	var logoStyles []SGDBStyle
+	if p.officialOnly {
+		logoStyles = []SGDBStyle{StyleLogoOfficial}
+	}
+	if logos, err := p.rankedAssets(ctx, "logos", gameID, logoStyles); err == nil && len(logos) > 0 {
+		artwork.LogoURL = logos[0].URL
 	}
 
 	// Fetch icons
-	if icons, err := p.fetchIcons(ctx, gameID); err == nil && len(icons) > 0 {
-		if url, ok := icons[0]["url"].(string); ok {
-			artwork.IconURL = url
-		}
+	if icons, err := p.rankedAssets(ctx, "icons", gameID, nil); err == nil && len(icons) > 0 {
+		artwork.IconURL = icons[0].URL
 	}
 
 	return artwork
@@ -397,10 +306,8 @@ func (p *Provider) Search(ctx context.Context, query string, opts retrometadata.
 
 		// Try to get cover image
 		coverURL := ""
-		if grids, err := p.fetchGrids(ctx, gameID); err == nil && len(grids) > 0 {
-			if url, ok := grids[0]["url"].(string); ok {
-				coverURL = url
-			}
+		if grids, err := p.rankedAssets(ctx, "grids", gameID, nil); err == nil && len(grids) > 0 {
+			coverURL = grids[0].URL
 		}
 
 		var releaseYear *int
@@ -510,7 +417,7 @@ func (p *Provider) Identify(ctx context.Context, filename string, opts retrometa
 		names = append(names, name)
 	}
 
-	bestMatch, score := findBestMatch(searchTerm, names)
+	bestMatch, score := provider.FindBestMatch(ctx, p.Name(), searchTerm, names, provider.MatchOptions(*p.config, 0.6))
 	if bestMatch == "" {
 		return nil, nil
 	}
@@ -608,46 +515,3 @@ func cleanFilename(filename string) string {
 	name = regexp.MustCompile(`\s*[\(\[][^\)\]]*[\)\]]`).ReplaceAllString(name, "")
 	return strings.TrimSpace(name)
 }
-
-func findBestMatch(query string, candidates []string) (string, float64) {
-	if len(candidates) == 0 {
-		return "", 0
-	}
-
-	// Simple similarity based on common prefix and lowercase comparison
-	queryLower := strings.ToLower(query)
-	bestMatch := ""
-	bestScore := 0.0
-
-	for _, candidate := range candidates {
-		candidateLower := strings.ToLower(candidate)
-
-		// Exact match
-		if candidateLower == queryLower {
-			return candidate, 1.0
-		}
-
-		// Contains match
-		if strings.Contains(candidateLower, queryLower) || strings.Contains(queryLower, candidateLower) {
-			score := float64(len(queryLower)) / float64(max(len(queryLower), len(candidateLower)))
-			if score > bestScore {
-				bestScore = score
-				bestMatch = candidate
-			}
-		}
-	}
-
-	if bestScore >= 0.6 {
-		return bestMatch, bestScore
-	}
-
-	// Return first candidate as fallback
-	return candidates[0], 0.5
-}
-
-func max(a, b int) int {
-	if a > b {
-		return a
-	}
-	return b
-}
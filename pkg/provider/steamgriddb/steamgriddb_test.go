@@ -0,0 +1,126 @@
+package steamgriddb
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/josegonzalez/retro-metadata/pkg/retrometadata"
+)
+
+func newTestProvider(t *testing.T, handler http.HandlerFunc) *Provider {
+	t.Helper()
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	p := New(&retrometadata.ProviderConfig{Enabled: true, Credentials: map[string]string{"api_key": "key"}})
+	p.baseURL = server.URL
+	return p
+}
+
+func TestGetGridsPaging(t *testing.T) {
+	var query []string
+	p := newTestProvider(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/grids/game/42" {
+			http.NotFound(w, r)
+			return
+		}
+		q := r.URL.Query()
+		query = []string{q.Get("dimensions"), q.Get("styles"), q.Get("mimes"), q.Get("types")}
+
+		// Three pages of two grids each
+		page, _ := strconv.Atoi(q.Get("page"))
+		var data []map[string]any
+		if page < 3 {
+			for i := range 2 {
+				id := page*2 + i + 1
+				data = append(data, map[string]any{"id": id, "score": id * 10, "url": "https://cdn.steamgriddb.com/grid/" + strconv.Itoa(id) + ".png"})
+			}
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{"success": true, "page": page, "total": 6, "limit": 2, "data": data})
+	})
+	ctx := context.Background()
+
+	grids, err := p.GetGrids(ctx, 42, AssetQuery{
+		Dimensions: []SGDBDimension{DimSteamVertical, DimGOGGalaxy},
+		Styles:     []SGDBStyle{StyleAlternate},
+		Mimes:      []SGDBMime{MimePNG},
+		Types:      []SGDBType{TypeStatic},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(grids) != 2 || grids[1].ID != 2 || grids[1].Score != 20 {
+		t.Errorf("GetGrids() with one page = %+v", grids)
+	}
+	want := []string{"600x900,342x482", "alternate", "image/png", "static"}
+	for i := range want {
+		if query[i] != want[i] {
+			t.Errorf("filters = %q, want %q", query, want)
+			break
+		}
+	}
+
+	if grids, _ := p.GetGrids(ctx, 42, AssetQuery{Pages: 2}); len(grids) != 4 {
+		t.Errorf("GetGrids() with two pages returned %d grids, want 4", len(grids))
+	}
+	if grids, _ := p.GetGrids(ctx, 42, AssetQuery{Pages: -1}); len(grids) != 6 || grids[5].ID != 6 {
+		t.Errorf("GetGrids() with every page = %+v, want all 6 grids", grids)
+	}
+}
+
+func TestArtworkRanking(t *testing.T) {
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		var data []map[string]any
+		switch {
+		case r.URL.Path == "/grids/game/42" && q.Get("types") == "animated":
+			// No animated grids, so preferring them falls back to static ones
+		case r.URL.Path == "/grids/game/42":
+			data = []map[string]any{
+				{"id": 1, "score": 0, "upvotes": 9, "url": "grid-1.png"},
+				{"id": 2, "score": 3, "upvotes": 0, "url": "grid-2.png"},
+				{"id": 3, "score": 3, "upvotes": 5, "downvotes": 1, "url": "grid-3.png"},
+			}
+		case r.URL.Path == "/logos/game/42" && q.Get("styles") == "official":
+			data = []map[string]any{{"id": 4, "style": "official", "url": "logo-official.png"}}
+		case r.URL.Path == "/logos/game/42":
+			data = []map[string]any{{"id": 5, "style": "white", "score": 10, "url": "logo-white.png"}}
+		case r.URL.Path == "/heroes/game/42" && q.Get("types") == "static":
+			data = []map[string]any{{"id": 6, "url": "hero-static.png"}}
+		case r.URL.Path == "/heroes/game/42":
+			data = []map[string]any{{"id": 7, "score": 5, "url": "hero-animated.webm"}, {"id": 6, "url": "hero-static.png"}}
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{"success": true, "total": len(data), "data": data})
+	}
+	ctx := context.Background()
+
+	p := newTestProvider(t, handler)
+	artwork := p.fetchAllArtwork(ctx, 42)
+	if artwork.CoverURL != "grid-3.png" {
+		t.Errorf("CoverURL = %q, want the best-scored, best-voted grid", artwork.CoverURL)
+	}
+	if artwork.LogoURL != "logo-white.png" || artwork.BackgroundURL != "hero-animated.webm" {
+		t.Errorf("LogoURL = %q, BackgroundURL = %q", artwork.LogoURL, artwork.BackgroundURL)
+	}
+
+	p = newTestProvider(t, handler)
+	p.animated = AnimatedPrefer
+	p.officialOnly = true
+	artwork = p.fetchAllArtwork(ctx, 42)
+	if artwork.CoverURL != "grid-3.png" {
+		t.Errorf("CoverURL preferring animated = %q, want the static fallback", artwork.CoverURL)
+	}
+	if artwork.LogoURL != "logo-official.png" {
+		t.Errorf("LogoURL with official_only = %q", artwork.LogoURL)
+	}
+
+	p = newTestProvider(t, handler)
+	p.animated = AnimatedExclude
+	if artwork := p.fetchAllArtwork(ctx, 42); artwork.BackgroundURL != "hero-static.png" {
+		t.Errorf("BackgroundURL excluding animated = %q", artwork.BackgroundURL)
+	}
+}
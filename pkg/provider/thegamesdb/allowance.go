@@ -0,0 +1,95 @@
+package thegamesdb
+
+import (
+	"time"
+
+	"github.com/josegonzalez/retro-metadata/pkg/retrometadata"
+)
+
+// Allowance is the API key's request allowance, as reported with every
+// TheGamesDB response. Keys get a monthly allowance plus an optional extra
+// allowance that's used once the monthly one runs out.
+type Allowance struct {
+	// Remaining is the number of requests left this month
+	Remaining int `json:"remaining_monthly_allowance"`
+	// Extra is the number of extra requests left
+	Extra int `json:"extra_allowance"`
+	// RefreshAt is when the monthly allowance resets
+	RefreshAt time.Time `json:"refresh_at"`
+	// UpdatedAt is when the allowance was last reported; zero until the first response
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Exhausted reports whether no requests are left until the allowance resets.
+func (a Allowance) Exhausted() bool {
+	if a.UpdatedAt.IsZero() || a.Remaining+a.Extra > 0 {
+		return false
+	}
+	return a.RefreshAt.IsZero() || time.Now().Before(a.RefreshAt)
+}
+
+// parseAllowance reads the allowance fields of a response. Returns false if
+// the response has none.
+func parseAllowance(result map[string]interface{}) (Allowance, bool) {
+	remaining, ok := result["remaining_monthly_allowance"].(float64)
+	if !ok {
+		return Allowance{}, false
+	}
+	now := time.Now()
+	allowance := Allowance{
+		Remaining: int(remaining),
+		Extra:     int(getFloat64(result, "extra_allowance")),
+		UpdatedAt: now,
+	}
+	if refresh := getFloat64(result, "allowance_refresh_timer"); refresh > 0 {
+		allowance.RefreshAt = now.Add(time.Duration(refresh) * time.Second)
+	}
+	return allowance, true
+}
+
+// Allowance returns the allowance of the API key in use from the most recent
+// response. It's the zero value until the first request completes.
+func (p *Provider) Allowance() Allowance {
+	p.allowanceMu.RLock()
+	defer p.allowanceMu.RUnlock()
+	if len(p.allowances) == 0 {
+		return Allowance{}
+	}
+	return p.allowances[p.current]
+}
+
+func (p *Provider) setAllowance(key int, allowance Allowance) {
+	p.allowanceMu.Lock()
+	p.allowances[key] = allowance
+	p.allowanceMu.Unlock()
+}
+
+// nextKey returns the index of the first API key, starting with the one in
+// use, with allowance left. Once every key's allowance is used up requests
+// fail until one resets, so they aren't spent finding that out.
+func (p *Provider) nextKey() (int, error) {
+	if len(p.keys) == 0 {
+		return 0, retrometadata.CredentialsRequired(p.Name(), "API requests")
+	}
+
+	p.allowanceMu.Lock()
+	defer p.allowanceMu.Unlock()
+	var refreshAt time.Time
+	for i := range p.keys {
+		key := (p.current + i) % len(p.keys)
+		allowance := p.allowances[key]
+		if !allowance.Exhausted() {
+			p.current = key
+			return key, nil
+		}
+		if refreshAt.IsZero() || allowance.RefreshAt.Before(refreshAt) {
+			refreshAt = allowance.RefreshAt
+		}
+	}
+
+	err := &retrometadata.RateLimitError{Provider: p.Name(), Details: "API allowance exhausted"}
+	if !refreshAt.IsZero() {
+		err.RetryAfter = int(time.Until(refreshAt).Seconds())
+	}
+	return 0, err
+}
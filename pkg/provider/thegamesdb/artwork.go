@@ -0,0 +1,167 @@
+package thegamesdb
+
+import (
+	"context"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/josegonzalez/retro-metadata/pkg/retrometadata"
+)
+
+// Image types returned by /Games/Images
+const (
+	ImageBoxart      = "boxart"
+	ImageFanart      = "fanart"
+	ImageBanner      = "banner"
+	ImageClearLogo   = "clearlogo"
+	ImageScreenshot  = "screenshot"
+	ImageTitleScreen = "titlescreen"
+)
+
+// Image sizes, the keys of the base URLs TheGamesDB returns with images
+const (
+	SizeThumb    = "thumb"
+	SizeOriginal = "original"
+)
+
+// Image is a single image of a game.
+type Image struct {
+	ID         int    `json:"id"`
+	Type       string `json:"type"`
+	Side       string `json:"side"`
+	Filename   string `json:"filename"`
+	Resolution string `json:"resolution"`
+}
+
+// Images are the images of a game and the base URLs their filenames are relative to.
+type Images struct {
+	BaseURL map[string]string
+	Images  []Image
+}
+
+// URL returns the URL of an image in the given size, falling back to the
+// original if TheGamesDB has no base URL for that size.
+func (imgs *Images) URL(image Image, size string) string {
+	base, ok := imgs.BaseURL[size]
+	if !ok {
+		base, ok = imgs.BaseURL[SizeOriginal]
+	}
+	if !ok || image.Filename == "" {
+		return ""
+	}
+	return base + image.Filename
+}
+
+// GetImages returns every image of a game.
+func (p *Provider) GetImages(ctx context.Context, gameID int) (*Images, error) {
+	params := url.Values{}
+	params.Set("games_id", strconv.Itoa(gameID))
+
+	result, err := p.request(ctx, "/Games/Images", params)
+	if err != nil {
+		return nil, err
+	}
+
+	images := &Images{}
+	data, ok := result["data"].(map[string]interface{})
+	if !ok {
+		return images, nil
+	}
+	images.BaseURL = getBoxartBaseURL(data)
+
+	byGame, _ := data["images"].(map[string]interface{})
+	gameImages, _ := byGame[strconv.Itoa(gameID)].([]interface{})
+	for _, item := range gameImages {
+		image, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		images.Images = append(images.Images, Image{
+			ID:         int(getFloat64(image, "id")),
+			Type:       getString(image, "type"),
+			Side:       getString(image, "side"),
+			Filename:   getString(image, "filename"),
+			Resolution: getString(image, "resolution"),
+		})
+	}
+	return images, nil
+}
+
+// GetArtwork returns the artwork of a game: the front boxart as the cover,
+// the first banner, clear logo and fanart, and every screenshot. All images,
+// including back covers and title screens, are listed in Artwork.Media.
+func (p *Provider) GetArtwork(ctx context.Context, gameID int) (*retrometadata.Artwork, error) {
+	if !p.config.Enabled {
+		return nil, nil
+	}
+
+	images, err := p.GetImages(ctx, gameID)
+	if err != nil {
+		return nil, err
+	}
+
+	artwork := &retrometadata.Artwork{}
+	for _, image := range images.Images {
+		imageURL := images.URL(image, p.imageSize)
+		if imageURL == "" {
+			continue
+		}
+
+		mediaType := image.Type
+		if image.Side != "" {
+			mediaType += "-" + image.Side
+		}
+		artwork.Media = append(artwork.Media, retrometadata.Media{
+			Type:   mediaType,
+			URL:    imageURL,
+			Format: strings.TrimPrefix(path.Ext(image.Filename), "."),
+		})
+
+		switch image.Type {
+		case ImageBoxart:
+			if image.Side == "front" && artwork.CoverURL == "" {
+				artwork.CoverURL = imageURL
+			}
+		case ImageFanart:
+			if artwork.BackgroundURL == "" {
+				artwork.BackgroundURL = imageURL
+			}
+		case ImageBanner:
+			if artwork.BannerURL == "" {
+				artwork.BannerURL = imageURL
+			}
+		case ImageClearLogo:
+			if artwork.LogoURL == "" {
+				artwork.LogoURL = imageURL
+			}
+		case ImageScreenshot:
+			artwork.ScreenshotURLs = append(artwork.ScreenshotURLs, imageURL)
+		}
+	}
+
+	return artwork, nil
+}
+
+// addArtwork adds the full artwork of a game to a result. The boxart
+// included with the game is kept if the images can't be fetched.
+func (p *Provider) addArtwork(ctx context.Context, result *retrometadata.GameResult) {
+	if result.ProviderID == nil {
+		return
+	}
+	artwork, err := p.GetArtwork(ctx, *result.ProviderID)
+	if err != nil || artwork == nil {
+		return
+	}
+	if artwork.CoverURL != "" {
+		result.Artwork.CoverURL = artwork.CoverURL
+	}
+	if len(artwork.ScreenshotURLs) > 0 {
+		result.Artwork.ScreenshotURLs = artwork.ScreenshotURLs
+	}
+	result.Artwork.BannerURL = artwork.BannerURL
+	result.Artwork.LogoURL = artwork.LogoURL
+	result.Artwork.BackgroundURL = artwork.BackgroundURL
+	result.Artwork.Media = artwork.Media
+}
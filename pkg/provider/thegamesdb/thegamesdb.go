@@ -9,10 +9,12 @@ import (
 	"net/http"
 	"net/url"
 	"regexp"
+	"slices"
 	"strconv"
 	"strings"
-	"time"
+	"sync"
 
+	"github.com/josegonzalez/retro-metadata/pkg/provider"
 	retrometadata "github.com/josegonzalez/retro-metadata/pkg/retrometadata"
 )
 
@@ -21,7 +23,7 @@ var (
 	tgdbTagRegex = regexp.MustCompile(`(?i)\(tgdb-(\d+)\)`)
 
 	// ErrProviderDisabled is returned when the provider is disabled.
-	ErrProviderDisabled = fmt.Errorf("provider is disabled")
+	ErrProviderDisabled = retrometadata.ErrProviderDisabled
 )
 
 // Provider implements the TheGamesDB metadata provider.
@@ -30,21 +32,65 @@ type Provider struct {
 	client    *http.Client
 	baseURL   string
 	userAgent string
+	imageSize string // SizeThumb or SizeOriginal
+	limiter   *provider.RateLimiter
+
+	// keys is the API key, or the pool of public keys in keyless mode
+	keys    []string
+	keyless bool
+
+	allowanceMu sync.RWMutex
+	// allowances holds each key's allowance; current is the key in use
+	allowances []Allowance
+	current    int
 }
 
 // New creates a new TheGamesDB provider.
+//
+// Without an "api_key" credential the provider runs keyless, with the pool
+// of public API keys in the "public_keys" option: each is used until its
+// allowance runs out, then the next one, and requests are limited to
+// provider.KeylessRateLimit per second unless config.RateLimit is set.
+// Without either every request fails with retrometadata.ErrCredentialsRequired.
 func New(config *retrometadata.ProviderConfig) *Provider {
-	timeout := time.Duration(config.Timeout) * time.Second
-	if timeout == 0 {
-		timeout = 30 * time.Second
+	imageSize := SizeThumb
+	if size, ok := config.Options["image_size"].(string); ok && size != "" {
+		imageSize = size
 	}
 
-	return &Provider{
+	p := &Provider{
 		config:    config,
-		client:    &http.Client{Timeout: timeout},
+		client:    provider.NewHTTPClient(*config),
 		baseURL:   "https://api.thegamesdb.net/v1",
 		userAgent: "retro-metadata/1.0",
+		imageSize: imageSize,
+	}
+	if key := config.GetCredential("api_key"); key != "" {
+		p.keys = []string{key}
+		p.limiter = provider.NewConfigRateLimiter(*config, 0)
+	} else {
+		p.keys = publicKeys(config.Options["public_keys"])
+		p.keyless = true
+		p.limiter = provider.NewConfigRateLimiter(*config, provider.KeylessRateLimit)
+	}
+	p.allowances = make([]Allowance, len(p.keys))
+	return p
+}
+
+// publicKeys reads the "public_keys" option, a list of keys.
+func publicKeys(option any) []string {
+	var keys []string
+	switch option := option.(type) {
+	case []string:
+		keys = option
+	case []any:
+		for _, key := range option {
+			if s, ok := key.(string); ok {
+				keys = append(keys, s)
+			}
+		}
 	}
+	return slices.DeleteFunc(slices.Clone(keys), func(key string) bool { return key == "" })
 }
 
 // Name returns the provider name.
@@ -52,15 +98,25 @@ func (p *Provider) Name() string {
 	return "thegamesdb"
 }
 
-func (p *Provider) apiKey() string {
-	return p.config.GetCredential("api_key")
+// Keyless reports whether the provider runs with public API keys rather
+// than an API key of its own.
+func (p *Provider) Keyless() bool {
+	return p.keyless
 }
 
 func (p *Provider) request(ctx context.Context, endpoint string, params url.Values) (map[string]interface{}, error) {
+	key, err := p.nextKey()
+	if err != nil {
+		return nil, err
+	}
+	if err := p.limiter.Wait(ctx); err != nil {
+		return nil, err
+	}
+
 	if params == nil {
 		params = url.Values{}
 	}
-	params.Set("apikey", p.apiKey())
+	params.Set("apikey", p.keys[key])
 
 	reqURL := p.baseURL + endpoint + "?" + params.Encode()
 
@@ -73,18 +129,15 @@ func (p *Provider) request(ctx context.Context, endpoint string, params url.Valu
 
 	resp, err := p.client.Do(req)
 	if err != nil {
-		return nil, retrometadata.NewProviderError(p.Name(), "request", err)
+		return nil, retrometadata.RequestError(ctx, p.Name(), err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode == http.StatusUnauthorized {
-		return nil, &retrometadata.AuthError{Provider: p.Name(), Details: "invalid API key"}
-	}
-	if resp.StatusCode == http.StatusTooManyRequests {
-		return nil, &retrometadata.RateLimitError{Provider: p.Name()}
+		return nil, &retrometadata.AuthError{Provider: p.Name(), StatusCode: resp.StatusCode, Details: "invalid API key"}
 	}
-	if resp.StatusCode != http.StatusOK {
-		return nil, &retrometadata.ConnectionError{Provider: p.Name(), Details: fmt.Sprintf("HTTP %d", resp.StatusCode)}
+	if err := retrometadata.HTTPError(p.Name(), resp); err != nil {
+		return nil, err
 	}
 
 	body, err := io.ReadAll(resp.Body)
@@ -97,6 +150,10 @@ func (p *Provider) request(ctx context.Context, endpoint string, params url.Valu
 		return nil, err
 	}
 
+	if allowance, ok := parseAllowance(result); ok {
+		p.setAllowance(key, allowance)
+	}
+
 	return result, nil
 }
 
@@ -155,7 +212,7 @@ func (p *Provider) Search(ctx context.Context, query string, opts retrometadata.
 		}
 
 		// Get cover image
-		coverURL := getCoverURL(boxartData, gameID, baseURL)
+		coverURL := getCoverURL(boxartData, gameID, baseURL, p.imageSize)
 
 		var releaseYear *int
 		if dateStr := getString(game, "release_date"); dateStr != "" && len(dateStr) >= 4 {
@@ -209,7 +266,7 @@ func (p *Provider) GetByID(ctx context.Context, gameID int) (*retrometadata.Game
 		if !ok {
 			return nil, nil
 		}
-		return p.buildGameResult(game, getBoxartData(result)), nil
+		return p.buildGameResult(ctx, game, getBoxartData(result)), nil
 	}
 
 	game, ok := games[0].(map[string]interface{})
@@ -217,7 +274,7 @@ func (p *Provider) GetByID(ctx context.Context, gameID int) (*retrometadata.Game
 		return nil, nil
 	}
 
-	return p.buildGameResult(game, getBoxartData(result)), nil
+	return p.buildGameResult(ctx, game, getBoxartData(result)), nil
 }
 
 // Identify identifies a game from a ROM filename.
@@ -283,28 +340,28 @@ func (p *Provider) Identify(ctx context.Context, filename string, opts retrometa
 		names = append(names, name)
 	}
 
-	bestMatch, score := findBestMatch(searchTerm, names)
+	bestMatch, score := provider.FindBestMatch(ctx, p.Name(), searchTerm, names, provider.MatchOptions(*p.config, 0.6))
 	if bestMatch == "" {
 		return nil, nil
 	}
 
 	game := gamesByName[bestMatch]
-	gameResult := p.buildGameResult(game, boxartData)
+	gameResult := p.buildGameResult(ctx, game, boxartData)
 	gameResult.MatchScore = score
 	return gameResult, nil
 }
 
-func (p *Provider) buildGameResult(game map[string]interface{}, boxartData map[string]interface{}) *retrometadata.GameResult {
+func (p *Provider) buildGameResult(ctx context.Context, game map[string]interface{}, boxartData map[string]interface{}) *retrometadata.GameResult {
 	gameID := int(getFloat64(game, "id"))
 	baseURL := getBoxartBaseURL(boxartData)
 
-	coverURL := getCoverURL(boxartData, gameID, baseURL)
-	screenshotURLs := getBackCoverURLs(boxartData, gameID, baseURL)
+	coverURL := getCoverURL(boxartData, gameID, baseURL, p.imageSize)
+	screenshotURLs := getBackCoverURLs(boxartData, gameID, baseURL, p.imageSize)
 
 	metadata := p.extractMetadata(game)
 
 	providerID := gameID
-	return &retrometadata.GameResult{
+	result := &retrometadata.GameResult{
 		Name:       getString(game, "game_title"),
 		Summary:    getString(game, "overview"),
 		Provider:   p.Name(),
@@ -319,6 +376,8 @@ func (p *Provider) buildGameResult(game map[string]interface{}, boxartData map[s
 		Metadata:    metadata,
 		RawResponse: game,
 	}
+	p.addArtwork(ctx, result)
+	return result
 }
 
 func (p *Provider) extractMetadata(game map[string]interface{}) retrometadata.GameMetadata {
@@ -486,7 +545,7 @@ func getBoxartBaseURL(boxartData map[string]interface{}) map[string]string {
 	return result
 }
 
-func getCoverURL(boxartData map[string]interface{}, gameID int, baseURL map[string]string) string {
+func getCoverURL(boxartData map[string]interface{}, gameID int, baseURL map[string]string, size string) string {
 	if boxartData == nil || baseURL == nil {
 		return ""
 	}
@@ -507,15 +566,15 @@ func getCoverURL(boxartData map[string]interface{}, gameID int, baseURL map[stri
 			continue
 		}
 		if getString(artMap, "side") == "front" {
-			if thumb, ok := baseURL["thumb"]; ok {
-				return thumb + getString(artMap, "filename")
+			if base, ok := baseURL[size]; ok {
+				return base + getString(artMap, "filename")
 			}
 		}
 	}
 	return ""
 }
 
-func getBackCoverURLs(boxartData map[string]interface{}, gameID int, baseURL map[string]string) []string {
+func getBackCoverURLs(boxartData map[string]interface{}, gameID int, baseURL map[string]string, size string) []string {
 	if boxartData == nil || baseURL == nil {
 		return nil
 	}
@@ -537,8 +596,8 @@ func getBackCoverURLs(boxartData map[string]interface{}, gameID int, baseURL map
 			continue
 		}
 		if getString(artMap, "side") == "back" {
-			if original, ok := baseURL["original"]; ok {
-				urls = append(urls, original+getString(artMap, "filename"))
+			if base, ok := baseURL[size]; ok {
+				urls = append(urls, base+getString(artMap, "filename"))
 			}
 		}
 	}
@@ -550,42 +609,3 @@ func cleanFilename(filename string) string {
 	name = regexp.MustCompile(`\s*[\(\[][^\)\]]*[\)\]]`).ReplaceAllString(name, "")
 	return strings.TrimSpace(name)
 }
-
-func findBestMatch(query string, candidates []string) (string, float64) {
-	if len(candidates) == 0 {
-		return "", 0
-	}
-
-	queryLower := strings.ToLower(query)
-	bestMatch := ""
-	bestScore := 0.0
-
-	for _, candidate := range candidates {
-		candidateLower := strings.ToLower(candidate)
-
-		if candidateLower == queryLower {
-			return candidate, 1.0
-		}
-
-		if strings.Contains(candidateLower, queryLower) || strings.Contains(queryLower, candidateLower) {
-			score := float64(len(queryLower)) / float64(max(len(queryLower), len(candidateLower)))
-			if score > bestScore {
-				bestScore = score
-				bestMatch = candidate
-			}
-		}
-	}
-
-	if bestScore >= 0.6 {
-		return bestMatch, bestScore
-	}
-
-	return candidates[0], 0.5
-}
-
-func max(a, b int) int {
-	if a > b {
-		return a
-	}
-	return b
-}
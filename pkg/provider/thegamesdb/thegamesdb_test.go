@@ -0,0 +1,191 @@
+package thegamesdb
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/josegonzalez/retro-metadata/pkg/retrometadata"
+)
+
+var testCredentials = map[string]string{"api_key": "key"}
+
+func TestArtwork(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		baseURL := map[string]any{
+			"original": "https://cdn.thegamesdb.net/images/original/",
+			"thumb":    "https://cdn.thegamesdb.net/images/thumb/",
+		}
+		switch r.URL.Path {
+		case "/Games/ByGameID":
+			_ = json.NewEncoder(w).Encode(map[string]any{
+				"data": map[string]any{"games": []any{
+					map[string]any{"id": 1018, "game_title": "Super Metroid", "platform": 6},
+				}},
+				"include": map[string]any{"boxart": map[string]any{
+					"base_url": baseURL,
+					"data": map[string]any{"1018": []any{
+						map[string]any{"id": 1, "type": "boxart", "side": "front", "filename": "boxart/front/1018-1.jpg"},
+					}},
+				}},
+			})
+		case "/Games/Images":
+			if r.URL.Query().Get("games_id") != "1018" {
+				t.Errorf("games_id = %q, want 1018", r.URL.Query().Get("games_id"))
+			}
+			_ = json.NewEncoder(w).Encode(map[string]any{
+				"data": map[string]any{
+					"base_url": baseURL,
+					"images": map[string]any{"1018": []any{
+						map[string]any{"id": 1, "type": "boxart", "side": "front", "filename": "boxart/front/1018-1.jpg"},
+						map[string]any{"id": 2, "type": "boxart", "side": "back", "filename": "boxart/back/1018-1.jpg"},
+						map[string]any{"id": 3, "type": "fanart", "filename": "fanart/1018-1.jpg", "resolution": "1920x1080"},
+						map[string]any{"id": 4, "type": "banner", "filename": "graphical/1018-g.jpg"},
+						map[string]any{"id": 5, "type": "clearlogo", "filename": "clearlogo/1018.png"},
+						map[string]any{"id": 6, "type": "screenshot", "filename": "screenshots/1018-1.jpg"},
+						map[string]any{"id": 7, "type": "screenshot", "filename": "screenshots/1018-2.jpg"},
+					}},
+				},
+			})
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	p := New(&retrometadata.ProviderConfig{Enabled: true, Credentials: testCredentials, Options: map[string]any{"image_size": "original"}})
+	p.baseURL = server.URL
+
+	result, err := p.GetByID(context.Background(), 1018)
+	if err != nil || result == nil {
+		t.Fatalf("GetByID() = %v, %v", result, err)
+	}
+
+	const original = "https://cdn.thegamesdb.net/images/original/"
+	artwork := result.Artwork
+	if artwork.CoverURL != original+"boxart/front/1018-1.jpg" {
+		t.Errorf("CoverURL = %q", artwork.CoverURL)
+	}
+	if artwork.BackgroundURL != original+"fanart/1018-1.jpg" {
+		t.Errorf("BackgroundURL = %q", artwork.BackgroundURL)
+	}
+	if artwork.BannerURL != original+"graphical/1018-g.jpg" {
+		t.Errorf("BannerURL = %q", artwork.BannerURL)
+	}
+	if artwork.LogoURL != original+"clearlogo/1018.png" {
+		t.Errorf("LogoURL = %q", artwork.LogoURL)
+	}
+	if len(artwork.ScreenshotURLs) != 2 || artwork.ScreenshotURLs[0] != original+"screenshots/1018-1.jpg" {
+		t.Errorf("ScreenshotURLs = %v", artwork.ScreenshotURLs)
+	}
+	if len(artwork.Media) != 7 || artwork.Media[1].Type != "boxart-back" || artwork.Media[4].Format != "png" {
+		t.Errorf("Media = %+v", artwork.Media)
+	}
+
+	// Thumbnails are the default
+	p = New(&retrometadata.ProviderConfig{Enabled: true, Credentials: testCredentials})
+	p.baseURL = server.URL
+	artworkThumbs, err := p.GetArtwork(context.Background(), 1018)
+	if err != nil || artworkThumbs.LogoURL != "https://cdn.thegamesdb.net/images/thumb/clearlogo/1018.png" {
+		t.Errorf("GetArtwork() = %+v, %v; want thumbnail URLs", artworkThumbs, err)
+	}
+}
+
+func TestAllowance(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		remaining := 2 - requests.Add(1)
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"data":                        map[string]any{"count": 0, "games": []any{}},
+			"remaining_monthly_allowance": remaining,
+			"extra_allowance":             0,
+			"allowance_refresh_timer":     3600,
+		})
+	}))
+	defer server.Close()
+
+	p := New(&retrometadata.ProviderConfig{Enabled: true, Credentials: testCredentials})
+	p.baseURL = server.URL
+	ctx := context.Background()
+
+	if !p.Allowance().UpdatedAt.IsZero() {
+		t.Errorf("Allowance() before any request = %+v, want the zero value", p.Allowance())
+	}
+	for range 2 {
+		if _, err := p.Search(ctx, "Super Metroid", retrometadata.SearchOptions{}); err != nil {
+			t.Fatalf("Search() error: %v", err)
+		}
+	}
+	allowance := p.Allowance()
+	if allowance.Remaining != 0 || !allowance.Exhausted() {
+		t.Errorf("Allowance() = %+v, want it exhausted", allowance)
+	}
+	if until := time.Until(allowance.RefreshAt); until < 59*time.Minute || until > time.Hour {
+		t.Errorf("allowance refreshes in %v, want about an hour", until)
+	}
+
+	// Further requests fail without reaching the API
+	_, err := p.Search(ctx, "Super Metroid", retrometadata.SearchOptions{})
+	var rateLimitErr *retrometadata.RateLimitError
+	if !errors.As(err, &rateLimitErr) || rateLimitErr.RetryAfter <= 0 {
+		t.Errorf("Search() with no allowance left = %v, want a rate limit error with a retry time", err)
+	}
+	if n := requests.Load(); n != 2 {
+		t.Errorf("made %d requests, want 2", n)
+	}
+
+	// Extra allowance keeps the key usable
+	p.setAllowance(0, Allowance{Extra: 10, UpdatedAt: time.Now()})
+	if p.Allowance().Exhausted() {
+		t.Error("allowance with extra requests left is exhausted")
+	}
+}
+
+func TestKeyless(t *testing.T) {
+	var keys []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		keys = append(keys, r.URL.Query().Get("apikey"))
+		remaining := 1
+		if len(keys) > 1 {
+			remaining = 0
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"data":                        map[string]any{"count": 0, "games": []any{}},
+			"remaining_monthly_allowance": remaining,
+			"allowance_refresh_timer":     3600,
+		})
+	}))
+	defer server.Close()
+	ctx := context.Background()
+
+	p := New(&retrometadata.ProviderConfig{Enabled: true})
+	if _, err := p.Search(ctx, "Super Metroid", retrometadata.SearchOptions{}); !errors.Is(err, retrometadata.ErrCredentialsRequired) {
+		t.Errorf("Search() without keys error = %v, want ErrCredentialsRequired", err)
+	}
+
+	p = New(&retrometadata.ProviderConfig{Enabled: true, RateLimit: -1, Options: map[string]any{"public_keys": []any{"one", "two"}}})
+	p.baseURL = server.URL
+	if !p.Keyless() {
+		t.Fatal("Keyless() = false with public keys only")
+	}
+	for range 3 {
+		if _, err := p.Search(ctx, "Super Metroid", retrometadata.SearchOptions{}); err != nil {
+			t.Fatalf("Search() error: %v", err)
+		}
+	}
+	// Each key is used until its allowance runs out, then the next one
+	if want := []string{"one", "one", "two"}; !slices.Equal(keys, want) {
+		t.Errorf("requests used keys %v, want %v", keys, want)
+	}
+
+	var rateLimitErr *retrometadata.RateLimitError
+	if _, err := p.Search(ctx, "Super Metroid", retrometadata.SearchOptions{}); !errors.As(err, &rateLimitErr) {
+		t.Errorf("Search() with every key exhausted error = %v, want a RateLimitError", err)
+	}
+}
@@ -0,0 +1,337 @@
+// Package recorder records the HTTP interactions of providers into cassette
+// files and replays them, so provider behavior can be tested and
+// user-reported mismatches debugged without network access or credentials.
+//
+// A Recorder is an http.RoundTripper; install it with
+// retrometadata.WithTransport:
+//
+//	rec, err := recorder.New("testdata/fixtures/igdb/zelda.json", recorder.Record)
+//	client, err := retrometadata.NewClient(retrometadata.WithTransport(rec))
+//	// ... make requests ...
+//	err = rec.Save()
+//
+// Credentials are redacted from the recorded URLs, request bodies and JSON
+// response bodies, and request headers aren't recorded at all, so cassettes
+// can be committed and shared.
+package recorder
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+// Mode is whether a Recorder records or replays interactions.
+type Mode int
+
+const (
+	// Record sends requests and records them with their responses
+	Record Mode = iota
+	// Replay answers requests with recorded responses, without sending them
+	Replay
+)
+
+// Redacted replaces the values of credentials in cassettes.
+const Redacted = "REDACTED"
+
+// DefaultSecrets are the query parameters, form fields and JSON keys
+// redacted from cassettes: the credentials of every provider.
+var DefaultSecrets = []string{
+	"api_key", "apikey", "client_id", "client_secret", "access_token",
+	"token", "password", "ssid", "sspassword", "devid", "devpassword", "y", "z",
+}
+
+// ErrNoInteraction is returned when replaying a request that wasn't recorded.
+var ErrNoInteraction = errors.New("no recorded interaction for request")
+
+// Cassette is the file format of recorded interactions.
+type Cassette struct {
+	Interactions []Interaction `json:"interactions"`
+}
+
+// Interaction is a recorded request and its response.
+type Interaction struct {
+	Request  Request  `json:"request"`
+	Response Response `json:"response"`
+}
+
+// Request is a recorded request, with its credentials redacted.
+type Request struct {
+	Method string `json:"method"`
+	URL    string `json:"url"`
+	Body   string `json:"body,omitempty"`
+}
+
+// Response is a recorded response. Bodies that aren't valid UTF-8, such as
+// images, are recorded in BodyBase64.
+type Response struct {
+	StatusCode int         `json:"status"`
+	Header     http.Header `json:"header,omitempty"`
+	Body       string      `json:"body,omitempty"`
+	BodyBase64 string      `json:"body_base64,omitempty"`
+}
+
+// Recorder is an http.RoundTripper that records or replays interactions.
+type Recorder struct {
+	path      string
+	mode      Mode
+	transport http.RoundTripper
+	secrets   map[string]bool
+
+	mu       sync.Mutex
+	cassette Cassette
+	played   map[string]int
+}
+
+// Option is a functional option for Recorder.
+type Option func(*Recorder)
+
+// WithTransport sets the transport requests are sent with when recording
+// (default http.DefaultTransport).
+func WithTransport(transport http.RoundTripper) Option {
+	return func(r *Recorder) {
+		r.transport = transport
+	}
+}
+
+// WithSecrets adds query parameters, form fields and JSON keys to redact.
+func WithSecrets(names ...string) Option {
+	return func(r *Recorder) {
+		for _, name := range names {
+			r.secrets[strings.ToLower(name)] = true
+		}
+	}
+}
+
+// New creates a recorder for the cassette at path. When replaying, the
+// cassette is loaded and must exist; when recording, it's written by Save.
+func New(path string, mode Mode, opts ...Option) (*Recorder, error) {
+	r := &Recorder{
+		path:      path,
+		mode:      mode,
+		transport: http.DefaultTransport,
+		secrets:   make(map[string]bool),
+		played:    make(map[string]int),
+	}
+	WithSecrets(DefaultSecrets...)(r)
+	for _, opt := range opts {
+		opt(r)
+	}
+
+	if mode == Replay {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(data, &r.cassette); err != nil {
+			return nil, fmt.Errorf("parsing cassette %s: %w", path, err)
+		}
+	}
+	return r, nil
+}
+
+// Interactions returns the interactions recorded or loaded so far.
+func (r *Recorder) Interactions() []Interaction {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]Interaction(nil), r.cassette.Interactions...)
+}
+
+// Save writes the recorded interactions to the cassette. It does nothing
+// when replaying.
+func (r *Recorder) Save() error {
+	if r.mode != Record {
+		return nil
+	}
+
+	r.mu.Lock()
+	data, err := json.MarshalIndent(r.cassette, "", "  ")
+	r.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(r.path), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(r.path, append(data, '\n'), 0o644)
+}
+
+// RoundTrip implements http.RoundTripper.
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	var body []byte
+	if req.Body != nil {
+		var err error
+		body, err = io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+	recorded := Request{
+		Method: req.Method,
+		URL:    r.redactURL(req.URL),
+		Body:   r.redactBody(body),
+	}
+
+	if r.mode == Replay {
+		return r.replay(req, recorded)
+	}
+
+	out := req.Clone(req.Context())
+	out.Body = io.NopCloser(bytes.NewReader(body))
+	resp, err := r.transport.RoundTrip(out)
+	if err != nil {
+		return nil, err
+	}
+	respBody, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(respBody))
+
+	response := Response{StatusCode: resp.StatusCode, Header: resp.Header.Clone()}
+	// Redaction changes the length of bodies
+	response.Header.Del("Content-Length")
+	response.Header.Del("Set-Cookie")
+	if utf8.Valid(respBody) {
+		response.Body = r.redactBody(respBody)
+	} else {
+		response.BodyBase64 = base64.StdEncoding.EncodeToString(respBody)
+	}
+
+	r.mu.Lock()
+	r.cassette.Interactions = append(r.cassette.Interactions, Interaction{Request: recorded, Response: response})
+	r.mu.Unlock()
+	return resp, nil
+}
+
+// replay returns the response recorded for a request. Identical requests
+// get their recorded responses in order, and the last one once they run out.
+func (r *Recorder) replay(req *http.Request, recorded Request) (*http.Response, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	key := recorded.Method + " " + recorded.URL + "\n" + recorded.Body
+	var matches []Interaction
+	for _, interaction := range r.cassette.Interactions {
+		if interaction.Request == recorded {
+			matches = append(matches, interaction)
+		}
+	}
+	if len(matches) == 0 {
+		return nil, fmt.Errorf("%w: %s %s", ErrNoInteraction, recorded.Method, recorded.URL)
+	}
+	response := matches[min(r.played[key], len(matches)-1)].Response
+	r.played[key]++
+
+	body := []byte(response.Body)
+	if response.BodyBase64 != "" {
+		var err error
+		if body, err = base64.StdEncoding.DecodeString(response.BodyBase64); err != nil {
+			return nil, fmt.Errorf("decoding recorded body: %w", err)
+		}
+	}
+	header := response.Header.Clone()
+	if header == nil {
+		header = http.Header{}
+	}
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", response.StatusCode, http.StatusText(response.StatusCode)),
+		StatusCode:    response.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}, nil
+}
+
+// redactURL returns u with its secret query parameters redacted and its
+// query in a stable order.
+func (r *Recorder) redactURL(u *url.URL) string {
+	redacted := *u
+	redacted.User = nil
+	if u.RawQuery != "" {
+		redacted.RawQuery = r.redactValues(u.Query()).Encode()
+	}
+	return redacted.String()
+}
+
+// redactValues redacts the secret values of a query or form.
+func (r *Recorder) redactValues(values url.Values) url.Values {
+	for name, v := range values {
+		if r.secrets[strings.ToLower(name)] {
+			for i := range v {
+				v[i] = Redacted
+			}
+		}
+	}
+	return values
+}
+
+// redactBody returns a body with its secrets redacted: the values of secret
+// keys in JSON, or of secret fields in forms. Other bodies are unchanged.
+func (r *Recorder) redactBody(body []byte) string {
+	trimmed := bytes.TrimSpace(body)
+	if len(trimmed) == 0 {
+		return string(body)
+	}
+
+	if trimmed[0] == '{' || trimmed[0] == '[' {
+		decoder := json.NewDecoder(bytes.NewReader(trimmed))
+		decoder.UseNumber()
+		var v any
+		if decoder.Decode(&v) == nil && r.redactJSON(v) {
+			if data, err := json.Marshal(v); err == nil {
+				return string(data)
+			}
+		}
+		return string(body)
+	}
+
+	if values, err := url.ParseQuery(string(trimmed)); err == nil && strings.Contains(string(trimmed), "=") {
+		for name := range values {
+			if r.secrets[strings.ToLower(name)] {
+				return r.redactValues(values).Encode()
+			}
+		}
+	}
+	return string(body)
+}
+
+// redactJSON redacts the values of secret keys in decoded JSON, and reports
+// whether any were found.
+func (r *Recorder) redactJSON(v any) bool {
+	found := false
+	switch v := v.(type) {
+	case map[string]any:
+		for key, value := range v {
+			if r.secrets[strings.ToLower(key)] {
+				v[key] = Redacted
+				found = true
+			} else if r.redactJSON(value) {
+				found = true
+			}
+		}
+	case []any:
+		for _, value := range v {
+			if r.redactJSON(value) {
+				found = true
+			}
+		}
+	}
+	return found
+}
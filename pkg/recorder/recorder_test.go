@@ -0,0 +1,109 @@
+package recorder
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func get(t *testing.T, client *http.Client, url string) (int, string, error) {
+	t.Helper()
+	resp, err := client.Get(url)
+	if err != nil {
+		return 0, "", err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	return resp.StatusCode, string(body), err
+}
+
+func TestRecordAndReplay(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Path == "/token" {
+			_, _ = w.Write([]byte(`{"access_token":"abc123","expires_in":3600}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"name":"Super Mario World","count":` + string(rune('0'+requests)) + `}`))
+	}))
+	defer server.Close()
+
+	path := filepath.Join(t.TempDir(), "fixtures", "cassette.json")
+	rec, err := New(path, Record)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := &http.Client{Transport: rec}
+	for _, u := range []string{"/games?api_key=s3cr3t&name=mario", "/games?name=mario&api_key=s3cr3t", "/token?client_secret=hunter2"} {
+		if _, _, err := get(t, client, server.URL+u); err != nil {
+			t.Fatalf("GET %s: %v", u, err)
+		}
+	}
+	if err := rec.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, secret := range []string{"s3cr3t", "hunter2", "abc123"} {
+		if strings.Contains(string(data), secret) {
+			t.Errorf("cassette contains %q:\n%s", secret, data)
+		}
+	}
+
+	// Replays don't reach the server and work with other credentials
+	recorded := requests
+	rec, err = New(path, Replay)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client = &http.Client{Transport: rec}
+	for i, want := range []string{`"count":1`, `"count":2`, `"count":2`} {
+		status, body, err := get(t, client, server.URL+"/games?name=mario&api_key=other")
+		if err != nil || status != http.StatusOK || !strings.Contains(body, want) {
+			t.Errorf("replay %d = %d, %q, %v; want a body with %s", i, status, body, err, want)
+		}
+	}
+	if requests != recorded {
+		t.Errorf("replaying sent %d requests, want none", requests-recorded)
+	}
+
+	if _, _, err := get(t, client, server.URL+"/games?name=zelda"); !errors.Is(err, ErrNoInteraction) {
+		t.Errorf("unrecorded request error = %v, want ErrNoInteraction", err)
+	}
+}
+
+func TestReplayBinaryBody(t *testing.T) {
+	image := []byte{0x89, 'P', 'N', 'G', 0xff, 0x00}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write(image)
+	}))
+	defer server.Close()
+
+	path := filepath.Join(t.TempDir(), "cassette.json")
+	rec, _ := New(path, Record)
+	if _, _, err := get(t, &http.Client{Transport: rec}, server.URL+"/cover.png"); err != nil {
+		t.Fatal(err)
+	}
+	if err := rec.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	rec, err := New(path, Replay)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, body, err := get(t, &http.Client{Transport: rec}, server.URL+"/cover.png")
+	if err != nil || body != string(image) {
+		t.Errorf("replayed body = %q, %v; want %q", body, err, image)
+	}
+}
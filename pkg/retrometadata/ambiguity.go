@@ -0,0 +1,99 @@
+package retrometadata
+
+import (
+	"context"
+
+	"github.com/josegonzalez/retro-metadata/pkg/matching"
+)
+
+const (
+	// DefaultAmbiguityMargin is the default IdentifyOptions.AmbiguityMargin.
+	DefaultAmbiguityMargin = 0.05
+	// DefaultMaxAmbiguousCandidates is the default
+	// IdentifyOptions.MaxAmbiguousCandidates.
+	DefaultMaxAmbiguousCandidates = 5
+)
+
+// AmbiguousMatch is a fuzzy match whose best candidates scored too close to
+// tell apart.
+type AmbiguousMatch struct {
+	// Provider is the provider matching
+	Provider string `json:"provider"`
+	// SearchTerm is the term matched against the candidates
+	SearchTerm string `json:"search_term"`
+	// Candidates are the close candidates, best first
+	Candidates []matching.CandidateScore `json:"candidates"`
+}
+
+// AmbiguityHandler chooses between the candidates of an ambiguous match,
+// for example by asking the user. It returns the name of the chosen
+// candidate, or "" to reject them all, in which case the provider finds no
+// match. Providers identifying in parallel may call it concurrently.
+type AmbiguityHandler func(ctx context.Context, match AmbiguousMatch) string
+
+type ambiguityKey struct{}
+
+// ambiguity is the disambiguation settings of IdentifyOptions.
+type ambiguity struct {
+	handler       AmbiguityHandler
+	margin        float64
+	maxCandidates int
+}
+
+// WithAmbiguityHandler returns a context in which providers resolve
+// ambiguous matches with opts.OnAmbiguous. Client.Identify does this itself;
+// it's needed to disambiguate when calling providers directly. ctx is
+// returned unchanged when opts.OnAmbiguous is nil.
+func WithAmbiguityHandler(ctx context.Context, opts IdentifyOptions) context.Context {
+	if opts.OnAmbiguous == nil {
+		return ctx
+	}
+	a := ambiguity{
+		handler:       opts.OnAmbiguous,
+		margin:        opts.AmbiguityMargin,
+		maxCandidates: opts.MaxAmbiguousCandidates,
+	}
+	if a.margin <= 0 {
+		a.margin = DefaultAmbiguityMargin
+	}
+	if a.maxCandidates <= 0 {
+		a.maxCandidates = DefaultMaxAmbiguousCandidates
+	}
+	return context.WithValue(ctx, ambiguityKey{}, a)
+}
+
+// HasAmbiguityHandler reports whether ctx was returned by
+// WithAmbiguityHandler.
+func HasAmbiguityHandler(ctx context.Context) bool {
+	_, ok := ctx.Value(ambiguityKey{}).(ambiguity)
+	return ok
+}
+
+// ResolveAmbiguity returns the match of a traced fuzzy match run by a
+// provider, asking the context's ambiguity handler to choose if the match is
+// ambiguous. It returns ("", 0) if the handler rejects the candidates.
+func ResolveAmbiguity(ctx context.Context, provider string, trace matching.Trace) (string, float64) {
+	a, ok := ctx.Value(ambiguityKey{}).(ambiguity)
+	if !ok {
+		return trace.Match, trace.Score
+	}
+	candidates := trace.Ambiguous(a.margin)
+	if candidates == nil {
+		return trace.Match, trace.Score
+	}
+	if len(candidates) > a.maxCandidates {
+		candidates = candidates[:a.maxCandidates]
+	}
+
+	chosen := a.handler(ctx, AmbiguousMatch{
+		Provider:   provider,
+		SearchTerm: trace.SearchTerm,
+		Candidates: candidates,
+	})
+	for _, candidate := range candidates {
+		if candidate.Name == chosen {
+			return candidate.Name, candidate.Score
+		}
+	}
+	return "", 0
+}
@@ -0,0 +1,51 @@
+package retrometadata
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/josegonzalez/retro-metadata/pkg/cache"
+)
+
+func TestIdentifyOnAmbiguous(t *testing.T) {
+	hltb := &matchingProvider{fakeProvider: fakeProvider{name: "hltb"}, games: []string{"Pac-Man", "Ms. Pac-Man", "Pac-Man (Arcade)", "Dig Dug"}}
+	RegisterProvider("hltb", func(ProviderConfig, cache.Cache) (Provider, error) { return hltb, nil })
+
+	config := DefaultConfig()
+	config.HLTB = ProviderConfig{Enabled: true, Priority: 1}
+	client, err := NewClient(WithConfig(config))
+	if err != nil {
+		t.Fatalf("NewClient() error: %v", err)
+	}
+	defer client.Close()
+	ctx := context.Background()
+
+	var asked []AmbiguousMatch
+	choose := func(choice string) AmbiguityHandler {
+		return func(_ context.Context, match AmbiguousMatch) string {
+			asked = append(asked, match)
+			return choice
+		}
+	}
+
+	result, err := client.Identify(ctx, "Pac-Man", IdentifyOptions{OnAmbiguous: choose("Pac-Man (Arcade)"), AmbiguityMargin: 0.2})
+	if err != nil || result.Name != "Pac-Man (Arcade)" {
+		t.Errorf("Identify() = %+v, %v; want the chosen candidate", result, err)
+	}
+	if len(asked) != 1 || asked[0].Provider != "hltb" || len(asked[0].Candidates) < 2 || asked[0].Candidates[0].Name != "Pac-Man" {
+		t.Errorf("OnAmbiguous asked %+v, want the close candidates best first", asked)
+	}
+
+	asked = nil
+	_, err = client.Identify(ctx, "Pac-Man", IdentifyOptions{OnAmbiguous: choose(""), AmbiguityMargin: 0.2})
+	if !errors.Is(err, ErrGameNotFound) {
+		t.Errorf("Identify() with the candidates rejected error = %v, want not found", err)
+	}
+
+	asked = nil
+	result, err = client.Identify(ctx, "Dig Dug", IdentifyOptions{OnAmbiguous: choose("")})
+	if err != nil || result.Name != "Dig Dug" || len(asked) != 0 {
+		t.Errorf("Identify() of a clear match = %+v, %v, asked %d times; want Dig Dug without asking", result, err, len(asked))
+	}
+}
@@ -0,0 +1,248 @@
+package retrometadata
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// IdentifyRequest is a single ROM to identify with IdentifyBatch.
+type IdentifyRequest struct {
+	// Filename is the ROM filename
+	Filename string
+	// Hashes are the ROM's hashes, tried with hash-capable providers before
+	// the filename. Options.Hashes is used if nil.
+	Hashes *FileHashes
+	// Options are the identification options for this ROM
+	Options IdentifyOptions
+}
+
+// IdentifyItem is the outcome of one IdentifyRequest.
+type IdentifyItem struct {
+	// Request is the request this item answers
+	Request IdentifyRequest
+	// Result is the identified game, or nil. Items answered by the same
+	// provider call share the slices and maps of their results, so results
+	// must be treated as read-only
+	Result *GameResult
+	// Err is why no game was identified: a *GameNotFoundError if every
+	// provider answered without a match, otherwise the provider errors
+	// joined, or the context's error
+	Err error
+	// ProviderErrors are the errors returned by each provider that failed,
+	// even if another provider went on to identify the game
+	ProviderErrors map[string]error
+}
+
+// IdentifyBatchSummary counts the outcomes of a batch.
+type IdentifyBatchSummary struct {
+	// Matched is the number of requests identified
+	Matched int `json:"matched"`
+	// Unmatched is the number of requests no provider could identify
+	Unmatched int `json:"unmatched"`
+	// ProviderErrors is the number of requests that weren't identified
+	// because a provider failed or the context was done
+	ProviderErrors int `json:"provider_errors"`
+}
+
+// IdentifyBatchResult is the outcome of IdentifyBatch.
+type IdentifyBatchResult struct {
+	// Items are the outcomes, in the order of the requests
+	Items []IdentifyItem
+	// Summary counts the outcomes
+	Summary IdentifyBatchSummary
+}
+
+// IdentifyBatch identifies many ROMs concurrently, up to
+// MaxConcurrentRequests at a time.
+//
+// Each request is identified like IdentifySmart: by its override (see
+// Config.Overrides) if it has one, otherwise by hash with hash-capable
+// providers first, then by filename. Matches scoring below
+// Config.MinMatchScore are rejected as by Identify; if no provider matches,
+// the item's Err is the best of them in a LowConfidenceError. Unlike
+// Identify, provider errors are reported on each item rather than skipped
+// silently. Provider calls with the same input are made once per batch, so
+// duplicate files, or discs of the same set sharing hashes, don't cost
+// extra requests; their items' results share the same data and are
+// read-only.
+func (c *Client) IdentifyBatch(ctx context.Context, requests []IdentifyRequest) *IdentifyBatchResult {
+	batch := &IdentifyBatchResult{Items: make([]IdentifyItem, len(requests))}
+	calls := &callGroup{calls: make(map[string]*call)}
+
+	c.mu.RLock()
+	workers := c.config.MaxConcurrentRequests
+	c.mu.RUnlock()
+	if workers <= 0 || workers > len(requests) {
+		workers = len(requests)
+	}
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+	for range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				batch.Items[i] = c.identifyItem(ctx, calls, requests[i])
+			}
+		}()
+	}
+	for i := range requests {
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+
+	for _, item := range batch.Items {
+		switch {
+		case item.Result != nil:
+			batch.Summary.Matched++
+		case errors.Is(item.Err, ErrGameNotFound):
+			batch.Summary.Unmatched++
+		default:
+			batch.Summary.ProviderErrors++
+		}
+	}
+	return batch
+}
+
+// identifyItem identifies a single request of a batch, by its override if
+// it has one.
+func (c *Client) identifyItem(ctx context.Context, calls *callGroup, req IdentifyRequest) IdentifyItem {
+	item := IdentifyItem{Request: req}
+	opts := req.Options
+	if req.Hashes != nil {
+		opts.Hashes = req.Hashes
+	}
+	if result, ok, err := c.override(ctx, req.Filename, opts.Hashes); ok {
+		item.Result, item.Err = result, err
+		return item
+	}
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	names := c.selectProviders(opts.Providers, opts.ExcludeProviders)
+	var rejects rejected
+
+	try := func(name, matchType string, identify func() (*GameResult, error)) bool {
+		filename := req.Filename
+		if matchType == "hash" {
+			// Hash lookups don't depend on the filename
+			filename = ""
+		}
+		key := fmt.Sprintf("%s|%s|%s|%v|%v", name, matchType, filename, platformKey(opts.PlatformID), hashesKey(opts.Hashes))
+		result, err := calls.do(key, func() (*GameResult, error) {
+			if err := c.acquire(ctx); err != nil {
+				return nil, err
+			}
+			defer c.release()
+			return identify()
+		})
+		if err != nil {
+			if item.ProviderErrors == nil {
+				item.ProviderErrors = make(map[string]error)
+			}
+			item.ProviderErrors[name] = err
+			return false
+		}
+		if result == nil {
+			return false
+		}
+		if _, err := rejects.check(result, req.Filename, c.config.MinMatchScore); err != nil {
+			return false
+		}
+		// Copy the shared result, so each item's MatchType and raw payloads
+		// are its own; the slices and maps it holds stay shared
+		matched := *result
+		matched.MatchType = matchType
+		item.Result = c.withRaw(&matched)
+		return true
+	}
+
+	if opts.Hashes != nil {
+		for _, name := range names {
+			hashProvider, ok := c.providers[name].(HashProvider)
+			if !ok {
+				continue
+			}
+			if try(name, "hash", func() (*GameResult, error) {
+				return hashProvider.IdentifyByHash(ctx, *opts.Hashes, opts)
+			}) {
+				return item
+			}
+		}
+	}
+	for _, name := range names {
+		provider := c.providers[name]
+		if try(name, "filename", func() (*GameResult, error) {
+			return provider.Identify(ctx, req.Filename, opts)
+		}) {
+			return item
+		}
+	}
+
+	switch {
+	case ctx.Err() != nil:
+		item.Err = ctx.Err()
+	case len(item.ProviderErrors) > 0:
+		errs := make([]error, 0, len(item.ProviderErrors))
+		for _, name := range names {
+			if err, ok := item.ProviderErrors[name]; ok {
+				errs = append(errs, NewProviderError(name, "identify", err))
+			}
+		}
+		item.Err = errors.Join(errs...)
+	default:
+		item.Err = rejects.notFound(req.Filename)
+	}
+	return item
+}
+
+// platformKey formats an optional platform ID for a call key.
+func platformKey(id *int) any {
+	if id == nil {
+		return ""
+	}
+	return *id
+}
+
+// hashesKey formats optional hashes for a call key.
+func hashesKey(hashes *FileHashes) any {
+	if hashes == nil {
+		return ""
+	}
+	return *hashes
+}
+
+// call is a provider call shared by the requests of a batch.
+type call struct {
+	done   chan struct{}
+	result *GameResult
+	err    error
+}
+
+// callGroup makes each distinct provider call of a batch once; later callers
+// wait for and share the first caller's result.
+type callGroup struct {
+	mu    sync.Mutex
+	calls map[string]*call
+}
+
+// do returns the result of the call with key, making it with fn if it
+// hasn't been made yet.
+func (g *callGroup) do(key string, fn func() (*GameResult, error)) (*GameResult, error) {
+	g.mu.Lock()
+	if existing, ok := g.calls[key]; ok {
+		g.mu.Unlock()
+		<-existing.done
+		return existing.result, existing.err
+	}
+	c := &call{done: make(chan struct{})}
+	g.calls[key] = c
+	g.mu.Unlock()
+
+	c.result, c.err = fn()
+	close(c.done)
+	return c.result, c.err
+}
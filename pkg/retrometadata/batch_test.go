@@ -0,0 +1,113 @@
+package retrometadata
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+
+	"github.com/josegonzalez/retro-metadata/pkg/cache"
+)
+
+// batchProvider matches "Game*" filenames and the MD5 "abc", fuzzy matches
+// "Fuzzy.sfc" with a score of 0.5, fails for "Broken.sfc", and counts its
+// calls.
+type batchProvider struct {
+	fakeProvider
+	identifyCalls atomic.Int32
+	hashCalls     atomic.Int32
+}
+
+func (p *batchProvider) Identify(_ context.Context, filename string, _ IdentifyOptions) (*GameResult, error) {
+	p.identifyCalls.Add(1)
+	switch {
+	case filename == "Broken.sfc":
+		return nil, &ConnectionError{Provider: p.name, Details: "connection refused"}
+	case filename == "Fuzzy.sfc":
+		return &GameResult{Name: "Fuzzy", Provider: p.name, MatchScore: 0.5}, nil
+	case len(filename) >= 4 && filename[:4] == "Game":
+		return &GameResult{Name: filename, Provider: p.name}, nil
+	}
+	return nil, nil
+}
+
+func (p *batchProvider) IdentifyByHash(_ context.Context, hashes FileHashes, _ IdentifyOptions) (*GameResult, error) {
+	p.hashCalls.Add(1)
+	if hashes.MD5 != "abc" {
+		return nil, nil
+	}
+	return &GameResult{Name: "Hashed", Provider: p.name}, nil
+}
+
+func TestClientIdentifyBatch(t *testing.T) {
+	p := &batchProvider{fakeProvider: fakeProvider{name: "mobygames"}}
+	RegisterProvider("mobygames", func(ProviderConfig, cache.Cache) (Provider, error) {
+		return p, nil
+	})
+
+	client, err := NewClient(WithMobyGames("key"), WithMaxConcurrentRequests(2))
+	if err != nil {
+		t.Fatalf("NewClient() error: %v", err)
+	}
+	defer client.Close()
+
+	hashes := &FileHashes{MD5: "abc"}
+	batch := client.IdentifyBatch(context.Background(), []IdentifyRequest{
+		{Filename: "Disc 1.cue", Hashes: hashes},
+		{Filename: "Disc 2.cue", Hashes: hashes},
+		{Filename: "Game.sfc"},
+		{Filename: "Game.sfc"},
+		{Filename: "Unknown.sfc"},
+		{Filename: "Broken.sfc"},
+	})
+
+	expected := IdentifyBatchSummary{Matched: 4, Unmatched: 1, ProviderErrors: 1}
+	if batch.Summary != expected {
+		t.Errorf("Summary = %+v, expected %+v", batch.Summary, expected)
+	}
+	if item := batch.Items[0]; item.Result == nil || item.Result.MatchType != "hash" {
+		t.Errorf("Items[0] = %+v, expected a hash match", item)
+	}
+	if item := batch.Items[2]; item.Result == nil || item.Result.MatchType != "filename" {
+		t.Errorf("Items[2] = %+v, expected a filename match", item)
+	}
+	var notFound *GameNotFoundError
+	if !errors.As(batch.Items[4].Err, &notFound) {
+		t.Errorf("Items[4].Err = %v, expected a GameNotFoundError", batch.Items[4].Err)
+	}
+	if item := batch.Items[5]; !errors.Is(item.Err, ErrProviderConnection) || item.ProviderErrors["mobygames"] == nil {
+		t.Errorf("Items[5] = %+v, expected a connection error from mobygames", item)
+	}
+
+	// Calls with the same input are made once
+	if calls := p.hashCalls.Load(); calls != 1 {
+		t.Errorf("IdentifyByHash called %d times, expected 1", calls)
+	}
+	if calls := p.identifyCalls.Load(); calls != 3 {
+		t.Errorf("Identify called %d times, expected 3", calls)
+	}
+}
+
+func TestClientIdentifyBatchMinMatchScore(t *testing.T) {
+	RegisterProvider("mobygames", func(ProviderConfig, cache.Cache) (Provider, error) {
+		return &batchProvider{fakeProvider: fakeProvider{name: "mobygames"}}, nil
+	})
+	client, err := NewClient(WithMobyGames("key"), WithMinMatchScore(0.8))
+	if err != nil {
+		t.Fatalf("NewClient() error: %v", err)
+	}
+	defer client.Close()
+
+	batch := client.IdentifyBatch(context.Background(), []IdentifyRequest{
+		{Filename: "Fuzzy.sfc"},
+		{Filename: "Game.sfc"},
+	})
+	expected := IdentifyBatchSummary{Matched: 1, Unmatched: 1}
+	if batch.Summary != expected {
+		t.Errorf("Summary = %+v, expected %+v", batch.Summary, expected)
+	}
+	var low *LowConfidenceError
+	if item := batch.Items[0]; item.Result != nil || !errors.As(item.Err, &low) || low.Result.Name != "Fuzzy" {
+		t.Errorf("Items[0] = %+v, expected the match rejected in a LowConfidenceError", item)
+	}
+}
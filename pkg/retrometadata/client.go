@@ -2,10 +2,18 @@ package retrometadata
 
 import (
 	"context"
+	"errors"
+	"iter"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
 	"sync"
 	"time"
 
 	"github.com/josegonzalez/retro-metadata/pkg/cache"
+	"github.com/josegonzalez/retro-metadata/pkg/platform"
+	"github.com/josegonzalez/retro-metadata/pkg/serials"
 )
 
 // Provider is the interface that all metadata providers must implement.
@@ -38,6 +46,46 @@ type HashProvider interface {
 	IdentifyByHash(ctx context.Context, hashes FileHashes, opts IdentifyOptions) (*GameResult, error)
 }
 
+// UIDProvider is an optional interface for providers whose game IDs aren't
+// integers. Their results carry the ID in ProviderUID.
+type UIDProvider interface {
+	Provider
+
+	// GetByUID gets game details by provider-specific string ID.
+	GetByUID(ctx context.Context, uid string) (*GameResult, error)
+}
+
+// PagedSearcher is an optional interface for providers whose search API
+// supports offsets, so long result lists can be paged through.
+type PagedSearcher interface {
+	Provider
+
+	// SearchPage returns the page of results starting at opts.StartOffset().
+	SearchPage(ctx context.Context, query string, opts SearchOptions) (*SearchPage, error)
+}
+
+// SearchIterator is an optional interface for providers that can yield
+// search results lazily, like those searching local databases, so broad
+// queries don't collect every result in memory.
+type SearchIterator interface {
+	Provider
+
+	// SearchIter yields the results of a search until the caller stops, or
+	// opts.Limit results were yielded if it isn't 0. An error ends the
+	// iteration.
+	SearchIter(ctx context.Context, query string, opts SearchOptions) iter.Seq2[SearchResult, error]
+}
+
+// KeylessProvider is an optional interface for providers that can run
+// without credentials, with lower limits. Operations that need credentials
+// fail with ErrCredentialsRequired in keyless mode.
+type KeylessProvider interface {
+	Provider
+
+	// Keyless reports whether the provider runs without credentials.
+	Keyless() bool
+}
+
 // ProviderFactory is a function that creates a provider instance.
 type ProviderFactory func(config ProviderConfig, cache cache.Cache) (Provider, error)
 
@@ -61,7 +109,24 @@ type Client struct {
 	config    Config
 	cache     cache.Cache
 	providers map[string]Provider
-	mu        sync.RWMutex
+	// providerConfigs are the configurations the enabled providers were
+	// created with, with secrets resolved
+	providerConfigs map[string]ProviderConfig
+	// calls count each provider's fanOut calls in progress, so a provider
+	// replaced or disabled at runtime is closed once they finish
+	calls map[string]*sync.WaitGroup
+	mu    sync.RWMutex
+	// requests limits concurrent provider calls to MaxConcurrentRequests
+	requests chan struct{}
+	// closing is cancelled by Close, to stop provider calls that outlived
+	// their soft deadline
+	closing    context.Context
+	stop       context.CancelFunc
+	background sync.WaitGroup
+
+	// changeHandlers are called when providers change at runtime
+	changeMu       sync.Mutex
+	changeHandlers []func(ProviderChange)
 }
 
 // NewClient creates a new metadata client with the given options.
@@ -70,11 +135,20 @@ func NewClient(opts ...Option) (*Client, error) {
 	for _, opt := range opts {
 		opt(&config)
 	}
+	if err := prepareConfig(&config); err != nil {
+		return nil, err
+	}
 
 	c := &Client{
-		config:    config,
-		providers: make(map[string]Provider),
+		config:          config,
+		providers:       make(map[string]Provider),
+		providerConfigs: make(map[string]ProviderConfig),
+		calls:           make(map[string]*sync.WaitGroup),
+	}
+	if config.MaxConcurrentRequests > 0 {
+		c.requests = make(chan struct{}, config.MaxConcurrentRequests)
 	}
+	c.closing, c.stop = context.WithCancel(context.Background())
 
 	// Initialize cache
 	var err error
@@ -91,48 +165,134 @@ func NewClient(opts ...Option) (*Client, error) {
 	return c, nil
 }
 
-func (c *Client) initCache() (cache.Cache, error) {
-	switch c.config.Cache.Backend {
-	case "memory":
-		return cache.NewMemoryCache(
-			cache.WithMaxSize(c.config.Cache.MaxSize),
-			cache.WithDefaultTTL(time.Duration(c.config.Cache.TTL)*time.Second),
-		), nil
-	case "null", "none", "":
-		return cache.NewNullCache(), nil
+// prepareConfig validates a configuration and loads its overrides file.
+func prepareConfig(config *Config) error {
+	switch config.IdentifyStrategy {
+	case "", IdentifyWaterfall, IdentifyRace:
 	default:
+		return &ConfigError{Field: "identify_strategy", Details: "unknown strategy " + strconv.Quote(config.IdentifyStrategy)}
+	}
+	if config.OverridesFile != "" {
+		overrides, err := LoadOverrides(config.OverridesFile)
+		if err != nil {
+			return &ConfigError{Field: "overrides_file", Details: err.Error()}
+		}
+		config.Overrides = overrides.Merge(config.Overrides)
+	}
+	return nil
+}
+
+// initCache creates the configured cache backend. Backends that aren't
+// compiled into this build (see cache.Backends) fall back to no caching.
+func (c *Client) initCache() (cache.Cache, error) {
+	backend := c.config.Cache.Backend
+	if backend == "" || backend == "none" {
+		backend = "null"
+	}
+
+	cc, err := cache.NewBackend(backend, cache.BackendOptions{
+		TTL:              time.Duration(c.config.Cache.TTL) * time.Second,
+		MaxSize:          c.config.Cache.MaxSize,
+		MaxBytes:         c.config.Cache.MaxBytes,
+		ConnectionString: c.config.Cache.ConnectionString,
+		Options:          c.config.Cache.Options,
+	})
+	if errors.Is(err, cache.ErrBackendUnavailable) {
 		return cache.NewNullCache(), nil
 	}
+	return cc, err
 }
 
-func (c *Client) initProviders() error {
-	providerRegistry.mu.RLock()
-	defer providerRegistry.mu.RUnlock()
+// acquire waits for one of the MaxConcurrentRequests request slots, returning
+// the context's error if it's done first. Every successful acquire must be
+// paired with a release.
+func (c *Client) acquire(ctx context.Context) error {
+	if c.requests == nil {
+		return nil
+	}
+	select {
+	case c.requests <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
 
-	enabledProviders := c.config.GetEnabledProviders()
+// release frees a request slot taken by acquire.
+func (c *Client) release() {
+	if c.requests != nil {
+		<-c.requests
+	}
+}
 
-	for _, name := range enabledProviders {
-		providerConfig := c.config.GetProviderConfig(name)
-		if providerConfig == nil {
-			continue
+func (c *Client) initProviders() error {
+	for _, name := range c.config.GetEnabledProviders() {
+		pc, ok, err := providerConfig(name, c.config)
+		if err != nil {
+			return err
 		}
-
-		factory, ok := providerRegistry.factories[name]
 		if !ok {
 			continue
 		}
-
-		p, err := factory(*providerConfig, c.cache)
-		if err != nil {
+		c.providerConfigs[name] = pc
+		p, err := c.newProvider(name, pc)
+		if err != nil || p == nil {
 			continue // Skip providers that fail to initialize
 		}
 		c.providers[name] = p
+		c.calls[name] = new(sync.WaitGroup)
 	}
 
 	return nil
 }
 
+// providerConfig returns a provider's configuration in config, with the
+// client-wide settings it doesn't set itself filled in and its credentials'
+// secret references (see SecretResolver) resolved.
+func providerConfig(name string, config Config) (ProviderConfig, bool, error) {
+	providerConfig := config.GetProviderConfig(name)
+	if providerConfig == nil {
+		return ProviderConfig{}, false, nil
+	}
+
+	pc := *providerConfig
+	credentials, err := resolveCredentials(pc.Credentials, config.SecretResolvers)
+	if err != nil {
+		return ProviderConfig{}, false, &ConfigError{Field: name + ".credentials", Details: err.Error()}
+	}
+	pc.Credentials = credentials
+	if pc.Locale == "" {
+		pc.Locale = config.PreferredLocale
+	}
+	if config.SkipAchievements {
+		pc.SkipAchievements = true
+	}
+	if pc.Timeout == 0 {
+		pc.Timeout = config.DefaultTimeout
+	}
+	if pc.HTTPClient == nil {
+		pc.HTTPClient = config.HTTPClient
+	}
+	return pc, true, nil
+}
+
+// newProvider creates a provider from its configuration. It returns nil if
+// the provider isn't registered.
+func (c *Client) newProvider(name string, pc ProviderConfig) (Provider, error) {
+	providerRegistry.mu.RLock()
+	factory, ok := providerRegistry.factories[name]
+	providerRegistry.mu.RUnlock()
+	if !ok {
+		return nil, nil
+	}
+	return factory(pc, c.cache)
+}
+
 // Search searches for games by name across all enabled providers.
+//
+// If any provider has a soft deadline (see Config.ProviderDeadline), the
+// providers are searched at once and the results of those that answer in
+// time are returned.
 func (c *Client) Search(ctx context.Context, query string, opts SearchOptions) ([]SearchResult, error) {
 	c.mu.RLock()
 	defer c.mu.RUnlock()
@@ -143,8 +303,25 @@ func (c *Client) Search(ctx context.Context, query string, opts SearchOptions) (
 
 	var allResults []SearchResult
 
-	for _, p := range c.providers {
-		results, err := p.Search(ctx, query, opts)
+	targets := c.targets(c.selectProviders(opts.Providers, opts.ExcludeProviders))
+	if hasDeadlines(targets) {
+		outcomes := fanOut(c, ctx, targets, func(ctx context.Context, t target) ([]SearchResult, error) {
+			return t.provider.Search(ctx, query, opts)
+		}, nil, false)
+		for _, o := range outcomes {
+			if o.err == nil {
+				allResults = append(allResults, o.value...)
+			}
+		}
+		targets = nil
+	}
+
+	for _, t := range targets {
+		if c.acquire(ctx) != nil {
+			break
+		}
+		results, err := t.provider.Search(ctx, query, opts)
+		c.release()
 		if err != nil {
 			continue // Skip providers that fail
 		}
@@ -159,72 +336,428 @@ func (c *Client) Search(ctx context.Context, query string, opts SearchOptions) (
 	return allResults, nil
 }
 
+// SearchIter searches like Search, yielding results as they're found rather
+// than collecting them, provider by provider in priority order. Providers
+// implementing SearchIterator yield lazily, without a provider request slot
+// (see MaxConcurrentRequests), so the loop body can use the client; the
+// others are searched with Search. A provider being iterated isn't closed
+// until its iteration ends, so the loop body mustn't replace or disable it
+// (see UpdateConfig), which would wait for the iteration forever. opts.Limit limits the results overall,
+// and 0 means no limit. Provider errors are yielded, as ProviderErrors,
+// and the search goes on with the next provider if the caller continues.
+func (c *Client) SearchIter(ctx context.Context, query string, opts SearchOptions) iter.Seq2[SearchResult, error] {
+	return func(yield func(SearchResult, error) bool) {
+		c.mu.RLock()
+		names := c.selectProviders(opts.Providers, opts.ExcludeProviders)
+		c.mu.RUnlock()
+
+		yielded := 0
+		for _, name := range names {
+			if !c.searchProvider(ctx, name, query, opts, &yielded, yield) {
+				return
+			}
+		}
+	}
+}
+
+// searchProvider yields the results of one provider for SearchIter,
+// counting them in yielded, and reports whether the search goes on.
+func (c *Client) searchProvider(ctx context.Context, name, query string, opts SearchOptions, yielded *int, yield func(SearchResult, error) bool) bool {
+	pc, ok := c.startCall(name)
+	if !ok {
+		// Disabled since the search started
+		return true
+	}
+	p := pc.provider
+	limit := opts.Limit
+	providerOpts := opts
+	if limit > 0 {
+		providerOpts.Limit = limit - *yielded
+	}
+
+	var results iter.Seq2[SearchResult, error]
+	if searcher, ok := p.(SearchIterator); ok {
+		// The provider searches until the caller is done with its results
+		defer pc.done()
+		results = searcher.SearchIter(ctx, query, providerOpts)
+	} else {
+		if err := c.acquire(ctx); err != nil {
+			pc.done()
+			yield(SearchResult{}, err)
+			return false
+		}
+		found, err := p.Search(ctx, query, providerOpts)
+		c.release()
+		pc.done()
+		results = func(yield func(SearchResult, error) bool) {
+			if err != nil {
+				yield(SearchResult{}, err)
+				return
+			}
+			for _, r := range found {
+				if !yield(r, nil) {
+					return
+				}
+			}
+		}
+	}
+
+	for result, err := range results {
+		if err != nil {
+			var providerErr *ProviderError
+			if !errors.As(err, &providerErr) {
+				err = NewProviderError(p.Name(), "search", err)
+			}
+			return yield(SearchResult{}, err)
+		}
+		if !yield(result, nil) {
+			return false
+		}
+		if *yielded++; limit > 0 && *yielded >= limit {
+			return false
+		}
+	}
+	return true
+}
+
+// SearchPage returns one page of search results from a single provider: the
+// highest priority provider allowed by opts.Providers and opts.ExcludeProviders.
+//
+// Providers that don't implement PagedSearcher are paged by requesting every
+// result up to the end of the page and skipping those before it.
+func (c *Client) SearchPage(ctx context.Context, query string, opts SearchOptions) (*SearchPage, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	if opts.Limit == 0 {
+		opts.Limit = 10
+	}
+
+	names := c.selectProviders(opts.Providers, opts.ExcludeProviders)
+	if len(names) == 0 {
+		return nil, &ProviderError{Provider: strings.Join(opts.Providers, ","), Err: ErrProviderNotFound}
+	}
+	p := c.providers[names[0]]
+
+	if err := c.acquire(ctx); err != nil {
+		return nil, err
+	}
+	defer c.release()
+
+	if paged, ok := p.(PagedSearcher); ok {
+		return paged.SearchPage(ctx, query, opts)
+	}
+
+	offset := opts.StartOffset()
+	all := opts
+	all.Limit = offset + opts.Limit
+	results, err := p.Search(ctx, query, all)
+	if err != nil {
+		return nil, err
+	}
+
+	// A short response means there are no results after it
+	total := -1
+	if len(results) < all.Limit {
+		total = len(results)
+	}
+	results = results[min(offset, len(results)):]
+	if len(results) > opts.Limit {
+		results = results[:opts.Limit]
+	}
+	return NewSearchPage(results, offset, opts.Limit, total), nil
+}
+
 // GetByID gets game details by provider-specific ID.
 func (c *Client) GetByID(ctx context.Context, providerName string, gameID int) (*GameResult, error) {
-	c.mu.RLock()
-	p, ok := c.providers[providerName]
-	c.mu.RUnlock()
+	pc, ok := c.startCall(providerName)
+	if !ok {
+		return nil, &ProviderError{
+			Provider: providerName,
+			Err:      ErrProviderNotFound,
+		}
+	}
+	defer pc.done()
 
+	if err := c.acquire(ctx); err != nil {
+		return nil, err
+	}
+	defer c.release()
+
+	result, err := pc.provider.GetByID(ctx, gameID)
+	_ = pc.raw.Apply(result)
+	return result, err
+}
+
+// GetByUID gets game details by a provider-specific string ID, such as the
+// ProviderUID of an earlier result. Providers with integer IDs are passed
+// the ID parsed as an integer.
+func (c *Client) GetByUID(ctx context.Context, providerName string, uid string) (*GameResult, error) {
+	pc, ok := c.startCall(providerName)
 	if !ok {
 		return nil, &ProviderError{
 			Provider: providerName,
 			Err:      ErrProviderNotFound,
 		}
 	}
+	defer pc.done()
+
+	uidProvider, isUID := pc.provider.(UIDProvider)
+	var gameID int
+	if !isUID {
+		var err error
+		if gameID, err = strconv.Atoi(uid); err != nil {
+			return nil, &GameNotFoundError{SearchTerm: uid, Provider: providerName}
+		}
+	}
 
-	return p.GetByID(ctx, gameID)
+	if err := c.acquire(ctx); err != nil {
+		return nil, err
+	}
+	defer c.release()
+
+	var result *GameResult
+	var err error
+	if isUID {
+		result, err = uidProvider.GetByUID(ctx, uid)
+	} else {
+		result, err = pc.provider.GetByID(ctx, gameID)
+	}
+	_ = pc.raw.Apply(result)
+	return result, err
+}
+
+// providerCall is a provider resolved for a call made without holding c.mu,
+// with the configuration the call needs.
+type providerCall struct {
+	provider Provider
+	raw      RawConfig
+	calls    *sync.WaitGroup
+}
+
+// startCall resolves a provider for a call made without holding c.mu,
+// counting the call as in progress so a provider replaced or disabled
+// meanwhile isn't closed before done is called.
+func (c *Client) startCall(name string) (providerCall, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	p, ok := c.providers[name]
+	if !ok {
+		return providerCall{}, false
+	}
+	calls := c.calls[name]
+	calls.Add(1)
+	return providerCall{provider: p, raw: c.config.RawResponses, calls: calls}, true
+}
+
+// done ends a call started with startCall.
+func (pc providerCall) done() {
+	pc.calls.Done()
 }
 
 // Identify identifies a game from a ROM filename.
+//
+// If any provider has a soft deadline (see Config.ProviderDeadline), the
+// providers are asked at once, and the highest priority match among those
+// that answer in time is returned. With the IdentifyRace strategy (see
+// Config.IdentifyStrategy), the providers are asked at once and the first
+// match scoring at least Config.RaceMinScore is returned, cancelling the
+// providers still running.
+//
+// Files with an override (see Config.Overrides) are identified by it,
+// without asking the other providers, and providers' answers cached by
+// Prefetch are used without asking them again. Matches scoring below
+// Config.MinMatchScore are rejected; if no provider matches, the best of
+// them is returned in a LowConfidenceError.
 func (c *Client) Identify(ctx context.Context, filename string, opts IdentifyOptions) (*GameResult, error) {
+	if result, ok, err := c.override(ctx, filename, opts.Hashes); ok {
+		return result, err
+	}
+	if opts.Serial == "" {
+		opts.Serial = serials.Extract(filename, platform.DetectFromPath(filename))
+	}
+
 	c.mu.RLock()
 	defer c.mu.RUnlock()
 
-	// Try each provider in priority order
-	for _, name := range c.config.GetEnabledProviders() {
-		p, ok := c.providers[name]
+	var trace *MatchTrace
+	if opts.Trace {
+		trace = &MatchTrace{Filename: filename}
+		ctx = WithMatchTrace(ctx, trace)
+	}
+	ctx = WithAmbiguityHandler(ctx, opts)
+	var rejects rejected
+	minMatchScore := c.config.MinMatchScore
+	identify := func(ctx context.Context, t target) (*GameResult, error) {
+		result, ok := c.prefetched(ctx, t.name, filename, opts)
+		var err error
 		if !ok {
-			continue
+			result, err = t.provider.Identify(ctx, filename, opts)
+		}
+		if err == nil {
+			result, err = rejects.check(result, filename, minMatchScore)
+		}
+		trace.addOutcome(t.name, result, err)
+		return result, err
+	}
+
+	targets := c.targets(c.selectProviders(opts.Providers, opts.ExcludeProviders))
+	if c.config.IdentifyStrategy == IdentifyRace {
+		minScore := c.config.RaceMinScore
+		if minScore <= 0 {
+			minScore = DefaultRaceMinScore
+		}
+		outcomes := fanOut(c, ctx, targets, identify, raceWon(minScore), true)
+		result := raceWinner(outcomes, minScore)
+		if result == nil {
+			result = bestMatch(outcomes)
 		}
+		if result != nil {
+			result.MatchTrace = trace.snapshot()
+			return c.identified(ctx, result), nil
+		}
+		return nil, rejects.notFound(filename)
+	}
+	if hasDeadlines(targets) {
+		result := bestMatch(fanOut(c, ctx, targets, identify, matched, false))
+		if result != nil {
+			// Providers still running in the background keep adding to trace
+			result.MatchTrace = trace.snapshot()
+			return c.identified(ctx, result), nil
+		}
+		targets = nil
+	}
 
-		result, err := p.Identify(ctx, filename, opts)
+	// Try each provider in priority order
+	for _, t := range targets {
+		if c.acquire(ctx) != nil {
+			break
+		}
+		result, err := identify(ctx, t)
+		c.release()
 		if err != nil {
 			continue
 		}
 		if result != nil {
-			return result, nil
+			result.MatchTrace = trace
+			return c.identified(ctx, result), nil
 		}
 	}
 
-	return nil, &GameNotFoundError{
-		SearchTerm: filename,
-	}
+	return nil, rejects.notFound(filename)
 }
 
-// IdentifyByHash identifies a game using file hashes.
-func (c *Client) IdentifyByHash(ctx context.Context, hashes FileHashes, opts IdentifyOptions) (*GameResult, error) {
+// ExplainIdentify identifies a game like Identify with IdentifyOptions.Trace
+// set, to explain a wrong or missing match: it asks every selected provider
+// rather than stopping at the first match, and returns the trace even if no
+// game matched. Providers don't write to the cache meanwhile, so explaining a
+// match doesn't change later ones.
+func (c *Client) ExplainIdentify(ctx context.Context, filename string, opts IdentifyOptions) (*GameResult, *MatchTrace, error) {
 	c.mu.RLock()
 	defer c.mu.RUnlock()
 
-	// Try hash-capable providers first
-	for _, name := range c.config.GetEnabledProviders() {
-		p, ok := c.providers[name]
-		if !ok {
-			continue
+	trace := &MatchTrace{Filename: filename}
+	ctx = WithoutCacheWrites(WithMatchTrace(ctx, trace))
+	ctx = WithAmbiguityHandler(ctx, opts)
+
+	var rejects rejected
+	var best *GameResult
+	for _, name := range c.selectProviders(opts.Providers, opts.ExcludeProviders) {
+		if err := c.acquire(ctx); err != nil {
+			return nil, trace, err
+		}
+		result, err := c.providers[name].Identify(ctx, filename, opts)
+		c.release()
+		if err == nil {
+			result, err = rejects.check(result, filename, c.config.MinMatchScore)
+		}
+		trace.addOutcome(name, result, err)
+		if best == nil && err == nil && result != nil {
+			best = result
 		}
+	}
+
+	if best == nil {
+		return nil, trace, rejects.notFound(filename)
+	}
+	best.MatchTrace = trace
+	return c.withRaw(best), trace, nil
+}
 
+// rejected keeps the best of the matches rejected for scoring below
+// Config.MinMatchScore, to return if no provider matches.
+type rejected struct {
+	mu  sync.Mutex
+	err *LowConfidenceError
+}
+
+// check returns result, or a LowConfidenceError if it scored below minScore.
+func (r *rejected) check(result *GameResult, searchTerm string, minScore float64) (*GameResult, error) {
+	err := CheckMatchScore(result, searchTerm, minScore)
+	if err == nil {
+		return result, nil
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if low := err.(*LowConfidenceError); r.err == nil || low.Result.MatchScore > r.err.Result.MatchScore {
+		r.err = low
+	}
+	return nil, err
+}
+
+// notFound returns the error for a search term no provider matched: the
+// best rejected match, if any.
+func (r *rejected) notFound(searchTerm string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.err != nil {
+		return r.err
+	}
+	return &GameNotFoundError{SearchTerm: searchTerm}
+}
+
+// IdentifyByHash identifies a game using file hashes. Hashes with an
+// override (see Config.Overrides) are identified by it.
+func (c *Client) IdentifyByHash(ctx context.Context, hashes FileHashes, opts IdentifyOptions) (*GameResult, error) {
+	if result, ok, err := c.override(ctx, "", &hashes); ok {
+		return result, err
+	}
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	var names []string
+	for _, name := range c.selectProviders(opts.Providers, opts.ExcludeProviders) {
 		// Check if provider supports hash-based identification
-		hashProvider, ok := p.(HashProvider)
-		if !ok {
-			continue
+		if _, ok := c.providers[name].(HashProvider); ok {
+			names = append(names, name)
+		}
+	}
+	targets := c.targets(names)
+	if hasDeadlines(targets) {
+		result := bestMatch(fanOut(c, ctx, targets, func(ctx context.Context, t target) (*GameResult, error) {
+			return t.provider.(HashProvider).IdentifyByHash(ctx, hashes, opts)
+		}, matched, false))
+		if result != nil {
+			return c.identified(ctx, result), nil
 		}
+		targets = nil
+	}
+
+	// Try hash-capable providers first
+	for _, t := range targets {
+		hashProvider := t.provider.(HashProvider)
 
+		if c.acquire(ctx) != nil {
+			break
+		}
 		result, err := hashProvider.IdentifyByHash(ctx, hashes, opts)
+		c.release()
 		if err != nil {
 			continue
 		}
 		if result != nil {
-			return result, nil
+			return c.identified(ctx, result), nil
 		}
 	}
 
@@ -235,6 +768,10 @@ func (c *Client) IdentifyByHash(ctx context.Context, hashes FileHashes, opts Ide
 
 // IdentifySmart uses a 3-tier strategy: hash first, then filename, then search.
 func (c *Client) IdentifySmart(ctx context.Context, filename string, hashes *FileHashes, opts IdentifyOptions) (*GameResult, error) {
+	if result, ok, err := c.override(ctx, filename, hashes); ok {
+		return result, err
+	}
+
 	// Tier 1: Try hash-based identification if hashes provided
 	if hashes != nil {
 		result, err := c.IdentifyByHash(ctx, *hashes, opts)
@@ -250,12 +787,67 @@ func (c *Client) IdentifySmart(ctx context.Context, filename string, hashes *Fil
 		result.MatchType = "filename"
 		return result, nil
 	}
+	if errors.Is(err, ErrLowConfidence) {
+		return nil, err
+	}
 
 	return nil, &GameNotFoundError{
 		SearchTerm: filename,
 	}
 }
 
+// IdentifyFunc identifies a file with the given providers, in priority
+// order. It's how custom identification strategies, such as an
+// identify.Pipeline, are run with IdentifyWith.
+type IdentifyFunc func(ctx context.Context, providers []Provider) (*GameResult, error)
+
+// IdentifyWith identifies a file with a custom identification strategy,
+// under the same policy as Identify. Files with an override (see
+// Config.Overrides) are identified by it, by their name or opts.Hashes,
+// without running identify. Otherwise the file takes one request slot (see
+// MaxConcurrentRequests) while identify runs, and the providers selected by
+// opts.Providers and opts.ExcludeProviders aren't closed by UpdateConfig
+// until it returns. Matches scoring below Config.MinMatchScore are rejected
+// with a LowConfidenceError, and matches are finished like Identify's: their
+// IDs are reconciled if Config.ReconcileIDs is set, and the RawResponses
+// config is applied.
+func (c *Client) IdentifyWith(ctx context.Context, filename string, opts IdentifyOptions, identify IdentifyFunc) (*GameResult, error) {
+	if result, ok, err := c.override(ctx, filename, opts.Hashes); ok {
+		return result, err
+	}
+
+	c.mu.RLock()
+	names := c.selectProviders(opts.Providers, opts.ExcludeProviders)
+	providers := make([]Provider, len(names))
+	for i, name := range names {
+		providers[i] = c.providers[name]
+		calls := c.calls[name]
+		calls.Add(1)
+		defer calls.Done()
+	}
+	minMatchScore := c.config.MinMatchScore
+	c.mu.RUnlock()
+
+	if err := c.acquire(ctx); err != nil {
+		return nil, err
+	}
+	result, err := identify(ctx, providers)
+	c.release()
+	if err != nil {
+		return nil, err
+	}
+	if result == nil {
+		return nil, &GameNotFoundError{SearchTerm: filename}
+	}
+	if err := CheckMatchScore(result, filepath.Base(filename), minMatchScore); err != nil {
+		return nil, err
+	}
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.identified(ctx, result), nil
+}
+
 // Heartbeat checks if all enabled providers are accessible.
 func (c *Client) Heartbeat(ctx context.Context) []ProviderStatus {
 	c.mu.RLock()
@@ -282,6 +874,41 @@ func (c *Client) Heartbeat(ctx context.Context) []ProviderStatus {
 	return statuses
 }
 
+// withRaw applies the RawResponses config to a result. Payloads that can't
+// be dumped are kept. Callers must hold c.mu.
+func (c *Client) withRaw(result *GameResult) *GameResult {
+	_ = c.config.RawResponses.Apply(result)
+	return result
+}
+
+// selectProviders returns the names of the initialized providers in priority
+// order, limited to include if it isn't empty and without those in exclude.
+// Callers must hold c.mu.
+func (c *Client) selectProviders(include, exclude []string) []string {
+	names := make([]string, 0, len(c.providers))
+	for _, name := range c.config.GetEnabledProviders() {
+		if _, ok := c.providers[name]; !ok {
+			continue
+		}
+		if len(include) > 0 && !slices.Contains(include, name) {
+			continue
+		}
+		if slices.Contains(exclude, name) {
+			continue
+		}
+		names = append(names, name)
+	}
+	return names
+}
+
+// MinMatchScore returns the match score below which fuzzy matches are
+// treated as unmatched (see Config.MinMatchScore).
+func (c *Client) MinMatchScore() float64 {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.config.MinMatchScore
+}
+
 // GetProvider returns a specific provider by name.
 func (c *Client) GetProvider(name string) (Provider, bool) {
 	c.mu.RLock()
@@ -290,6 +917,20 @@ func (c *Client) GetProvider(name string) (Provider, bool) {
 	return p, ok
 }
 
+// Providers returns the initialized providers in priority order.
+func (c *Client) Providers() []Provider {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	providers := make([]Provider, 0, len(c.providers))
+	for _, name := range c.config.GetEnabledProviders() {
+		if p, ok := c.providers[name]; ok {
+			providers = append(providers, p)
+		}
+	}
+	return providers
+}
+
 // EnabledProviders returns the list of enabled provider names.
 func (c *Client) EnabledProviders() []string {
 	c.mu.RLock()
@@ -302,8 +943,12 @@ func (c *Client) EnabledProviders() []string {
 	return names
 }
 
-// Close closes all providers and the cache.
+// Close closes all providers and the cache, after cancelling provider calls
+// that outlived their soft deadline.
 func (c *Client) Close() error {
+	c.stop()
+	c.background.Wait()
+
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
@@ -0,0 +1,418 @@
+package retrometadata
+
+import (
+	"context"
+	"errors"
+	"iter"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/josegonzalez/retro-metadata/pkg/cache"
+)
+
+// fakeProvider returns a result named after itself for every request.
+type fakeProvider struct {
+	name string
+}
+
+func (p *fakeProvider) Name() string { return p.name }
+
+func (p *fakeProvider) Search(_ context.Context, query string, _ SearchOptions) ([]SearchResult, error) {
+	return []SearchResult{{Name: query, Provider: p.name}}, nil
+}
+
+func (p *fakeProvider) GetByID(_ context.Context, _ int) (*GameResult, error) { return nil, nil }
+
+func (p *fakeProvider) Identify(_ context.Context, filename string, _ IdentifyOptions) (*GameResult, error) {
+	return &GameResult{Name: filename, Provider: p.name}, nil
+}
+
+func (p *fakeProvider) Heartbeat(_ context.Context) error { return nil }
+
+func (p *fakeProvider) Close() error { return nil }
+
+func TestClientProviderSelection(t *testing.T) {
+	for _, name := range []string{"mobygames", "hltb"} {
+		RegisterProvider(name, func(config ProviderConfig, _ cache.Cache) (Provider, error) {
+			return &fakeProvider{name: name}, nil
+		})
+	}
+
+	client, err := NewClient(WithMobyGames("key"), WithHLTB())
+	if err != nil {
+		t.Fatalf("NewClient() error: %v", err)
+	}
+	defer client.Close()
+	ctx := context.Background()
+
+	tests := []struct {
+		name     string
+		include  []string
+		exclude  []string
+		expected string
+	}{
+		{"priority order", nil, nil, "mobygames"},
+		{"restricted", []string{"hltb"}, nil, "hltb"},
+		{"excluded", nil, []string{"mobygames"}, "hltb"},
+		{"unknown provider", []string{"igdb"}, nil, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, _ := client.Identify(ctx, "Game.sfc", IdentifyOptions{Providers: tt.include, ExcludeProviders: tt.exclude})
+			provider := ""
+			if result != nil {
+				provider = result.Provider
+			}
+			if provider != tt.expected {
+				t.Errorf("Identify() used provider %q, expected %q", provider, tt.expected)
+			}
+
+			results, _ := client.Search(ctx, "Game", SearchOptions{Providers: tt.include, ExcludeProviders: tt.exclude})
+			provider = ""
+			if len(results) > 0 {
+				provider = results[0].Provider
+			}
+			if provider != tt.expected {
+				t.Errorf("Search() used provider %q, expected %q", provider, tt.expected)
+			}
+		})
+	}
+}
+
+func TestSearchOptionsStartOffset(t *testing.T) {
+	tests := []struct {
+		opts     SearchOptions
+		expected int
+	}{
+		{SearchOptions{}, 0},
+		{SearchOptions{Offset: 15}, 15},
+		{SearchOptions{Limit: 10, Page: 3}, 20},
+		{SearchOptions{Limit: 10, Page: 3, Cursor: "7"}, 7},
+		{SearchOptions{Offset: 5, Cursor: "bogus"}, 5},
+	}
+
+	for _, tt := range tests {
+		if offset := tt.opts.StartOffset(); offset != tt.expected {
+			t.Errorf("%+v.StartOffset() = %d, expected %d", tt.opts, offset, tt.expected)
+		}
+	}
+}
+
+func TestNewSearchPage(t *testing.T) {
+	results := []SearchResult{{Name: "a"}, {Name: "b"}}
+
+	page := NewSearchPage(results, 10, 2, 50)
+	if !page.HasMore || page.Cursor != "12" || page.Total != 50 {
+		t.Errorf("Unexpected page with known total: %+v", page)
+	}
+
+	page = NewSearchPage(results, 10, 5, -1)
+	if page.HasMore || page.Cursor != "" || page.Total != -1 {
+		t.Errorf("Expected short page with unknown total to be the last: %+v", page)
+	}
+
+	page = NewSearchPage(results, 0, 2, -1)
+	if !page.HasMore || page.Cursor != "2" {
+		t.Errorf("Expected full page with unknown total to have more: %+v", page)
+	}
+}
+
+func TestLoadConfig(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	data := `{"mobygames": {"enabled": true, "credentials": {"api_key": "key"}}, "region_priority": ["eu"]}`
+	if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	config, err := LoadConfig(path)
+	if err != nil {
+		t.Fatalf("LoadConfig() error: %v", err)
+	}
+	if !config.MobyGames.Enabled || config.MobyGames.Credentials["api_key"] != "key" {
+		t.Errorf("Expected MobyGames to be configured: %+v", config.MobyGames)
+	}
+	if config.MobyGames.Timeout != 30 || config.Cache.Backend != "memory" {
+		t.Error("Expected settings missing from the file to keep their defaults")
+	}
+	if len(config.RegionPriority) != 1 || config.RegionPriority[0] != "eu" {
+		t.Errorf("RegionPriority = %v, expected [eu]", config.RegionPriority)
+	}
+
+	if _, err := LoadConfig(filepath.Join(t.TempDir(), "missing.json")); err == nil {
+		t.Error("Expected error for a missing config file")
+	}
+}
+
+// blockingProvider holds every Identify call until release is closed or the
+// context is done, and records the peak number of concurrent calls.
+type blockingProvider struct {
+	fakeProvider
+	release  chan struct{}
+	mu       sync.Mutex
+	inFlight int
+	peak     int
+}
+
+func (p *blockingProvider) Identify(ctx context.Context, filename string, opts IdentifyOptions) (*GameResult, error) {
+	p.mu.Lock()
+	p.inFlight++
+	p.peak = max(p.peak, p.inFlight)
+	p.mu.Unlock()
+
+	defer func() {
+		p.mu.Lock()
+		p.inFlight--
+		p.mu.Unlock()
+	}()
+
+	select {
+	case <-p.release:
+		return p.fakeProvider.Identify(ctx, filename, opts)
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func TestClientMaxConcurrentRequests(t *testing.T) {
+	p := &blockingProvider{fakeProvider: fakeProvider{name: "mobygames"}, release: make(chan struct{})}
+	RegisterProvider("mobygames", func(ProviderConfig, cache.Cache) (Provider, error) {
+		return p, nil
+	})
+
+	client, err := NewClient(WithMobyGames("key"), WithMaxConcurrentRequests(2))
+	if err != nil {
+		t.Fatalf("NewClient() error: %v", err)
+	}
+	defer client.Close()
+
+	var wg sync.WaitGroup
+	for range 5 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, _ = client.Identify(context.Background(), "Game.sfc", IdentifyOptions{})
+		}()
+	}
+
+	for {
+		p.mu.Lock()
+		full := p.inFlight == 2
+		p.mu.Unlock()
+		if full {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	// A caller waiting for a slot gives up when its context is done
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if _, err := client.Identify(ctx, "Game.sfc", IdentifyOptions{}); err == nil {
+		t.Error("Expected Identify to fail while every request slot is taken")
+	}
+
+	close(p.release)
+	wg.Wait()
+
+	if p.peak != 2 {
+		t.Errorf("peak concurrent calls = %d, expected 2", p.peak)
+	}
+}
+
+// uidProvider is a fakeProvider with string IDs.
+type uidProvider struct {
+	fakeProvider
+}
+
+func (p *uidProvider) GetByUID(_ context.Context, uid string) (*GameResult, error) {
+	return &GameResult{Name: "Game", Provider: p.name, ProviderUID: uid}, nil
+}
+
+func TestClientGetByUID(t *testing.T) {
+	RegisterProvider("mobygames", func(ProviderConfig, cache.Cache) (Provider, error) {
+		return &fakeProvider{name: "mobygames"}, nil
+	})
+	RegisterProvider("hltb", func(ProviderConfig, cache.Cache) (Provider, error) {
+		return &uidProvider{fakeProvider{name: "hltb"}}, nil
+	})
+
+	client, err := NewClient(WithMobyGames("key"), WithHLTB())
+	if err != nil {
+		t.Fatalf("NewClient() error: %v", err)
+	}
+	defer client.Close()
+	ctx := context.Background()
+
+	const uid = "0a3c8ab4-bb7b-4a4a-8d4d-6e1a3c1c3f3e"
+	result, err := client.GetByUID(ctx, "hltb", uid)
+	if err != nil || result == nil || result.ProviderUID != uid {
+		t.Errorf("GetByUID() = %+v, %v; want the result for %s", result, err, uid)
+	}
+
+	// Providers with integer IDs need a numeric UID
+	if _, err := client.GetByUID(ctx, "mobygames", "1018"); err != nil {
+		t.Errorf("GetByUID() with a numeric ID error: %v", err)
+	}
+	var notFound *GameNotFoundError
+	if _, err := client.GetByUID(ctx, "mobygames", uid); !errors.As(err, &notFound) {
+		t.Errorf("GetByUID() with a UUID on an integer provider = %v, want GameNotFoundError", err)
+	}
+	if _, err := client.GetByUID(ctx, "igdb", uid); !errors.Is(err, ErrProviderNotFound) {
+		t.Errorf("GetByUID() on an unconfigured provider = %v, want ErrProviderNotFound", err)
+	}
+}
+
+func TestClientLocale(t *testing.T) {
+	var locales []string
+	for _, name := range []string{"mobygames", "hltb"} {
+		RegisterProvider(name, func(config ProviderConfig, _ cache.Cache) (Provider, error) {
+			locales = append(locales, config.Locale)
+			return &fakeProvider{name: name}, nil
+		})
+	}
+
+	config := DefaultConfig()
+	config.MobyGames = ProviderConfig{Enabled: true, Priority: 1, Credentials: map[string]string{"api_key": "key"}}
+	config.HLTB = ProviderConfig{Enabled: true, Priority: 2, Locale: "de-DE"}
+	config.PreferredLocale = "fr-CA"
+	client, err := NewClient(WithConfig(config))
+	if err != nil {
+		t.Fatalf("NewClient() error: %v", err)
+	}
+	defer client.Close()
+
+	// Providers get the client's locale unless they set their own
+	if len(locales) != 2 || locales[0] != "fr-CA" || locales[1] != "de-DE" {
+		t.Errorf("provider locales = %v, want [fr-CA de-DE]", locales)
+	}
+
+	if got := config.Languages(); len(got) != 2 || got[0] != LanguageFrench || got[1] != LanguageEnglish {
+		t.Errorf("Languages() = %v, want [fr en]", got)
+	}
+	if got := LanguagePriority("xx"); len(got) != 1 || got[0] != LanguageEnglish {
+		t.Errorf("LanguagePriority(xx) = %v, want [en]", got)
+	}
+}
+
+func TestClientHTTPClient(t *testing.T) {
+	configs := map[string]ProviderConfig{}
+	for _, name := range []string{"mobygames", "hltb"} {
+		RegisterProvider(name, func(config ProviderConfig, _ cache.Cache) (Provider, error) {
+			configs[name] = config
+			return &fakeProvider{name: name}, nil
+		})
+	}
+
+	shared := &http.Client{}
+	own := &http.Client{}
+	config := DefaultConfig()
+	config.MobyGames = ProviderConfig{Enabled: true, Priority: 1, Credentials: map[string]string{"api_key": "key"}}
+	config.HLTB = ProviderConfig{Enabled: true, Priority: 2, Timeout: 5, HTTPClient: own}
+	config.HTTPClient = shared
+	client, err := NewClient(WithConfig(config), WithTimeout(12), WithoutAchievements())
+	if err != nil {
+		t.Fatalf("NewClient() error: %v", err)
+	}
+	defer client.Close()
+
+	// Providers get the client's HTTP client and timeout unless they set their own
+	if got := configs["mobygames"]; got.HTTPClient != shared || got.Timeout != 12 || !got.SkipAchievements {
+		t.Errorf("mobygames HTTPClient, Timeout = %p, %d; want %p, 12", got.HTTPClient, got.Timeout, shared)
+	}
+	if got := configs["hltb"]; got.HTTPClient != own || got.Timeout != 5 {
+		t.Errorf("hltb HTTPClient, Timeout = %p, %d; want %p, 5", got.HTTPClient, got.Timeout, own)
+	}
+}
+
+func TestClientMinMatchScore(t *testing.T) {
+	hltb := &matchingProvider{fakeProvider: fakeProvider{name: "hltb"}, games: []string{"Sonic the Hedgehog"}}
+	RegisterProvider("hltb", func(ProviderConfig, cache.Cache) (Provider, error) { return hltb, nil })
+
+	config := DefaultConfig()
+	config.HLTB = ProviderConfig{Enabled: true, Priority: 1}
+	config.MinMatchScore = 0.9
+	client, err := NewClient(WithConfig(config))
+	if err != nil {
+		t.Fatalf("NewClient() error: %v", err)
+	}
+	defer client.Close()
+	ctx := context.Background()
+
+	if result, err := client.Identify(ctx, "Sonic the Hedgehog", IdentifyOptions{}); err != nil || result.Name != "Sonic the Hedgehog" {
+		t.Errorf("Identify() of a confident match = %+v, %v", result, err)
+	}
+
+	_, err = client.Identify(ctx, "Sonic Spinball", IdentifyOptions{})
+	var low *LowConfidenceError
+	if !errors.As(err, &low) || !errors.Is(err, ErrGameNotFound) || low.Result.Name != "Sonic the Hedgehog" {
+		t.Errorf("Identify() of a poor match error = %v, want a LowConfidenceError with the rejected match", err)
+	}
+	if _, err := client.IdentifySmart(ctx, "Sonic Spinball", nil, IdentifyOptions{}); !errors.Is(err, ErrLowConfidence) {
+		t.Errorf("IdentifySmart() of a poor match error = %v, want ErrLowConfidence", err)
+	}
+}
+
+// iterProvider yields numbered results without end.
+type iterProvider struct {
+	fakeProvider
+	yielded int
+}
+
+func (p *iterProvider) SearchIter(_ context.Context, query string, _ SearchOptions) iter.Seq2[SearchResult, error] {
+	return func(yield func(SearchResult, error) bool) {
+		for i := 1; ; i++ {
+			p.yielded++
+			if !yield(SearchResult{Name: query, Provider: p.name, ProviderID: i}, nil) {
+				return
+			}
+		}
+	}
+}
+
+func TestClientSearchIter(t *testing.T) {
+	moby := &iterProvider{fakeProvider: fakeProvider{name: "mobygames"}}
+	RegisterProvider("mobygames", func(ProviderConfig, cache.Cache) (Provider, error) { return moby, nil })
+	RegisterProvider("hltb", func(ProviderConfig, cache.Cache) (Provider, error) {
+		return &fakeProvider{name: "hltb"}, nil
+	})
+	client, err := NewClient(WithMobyGames("key"), WithHLTB(), WithMaxConcurrentRequests(1))
+	if err != nil {
+		t.Fatalf("NewClient() error: %v", err)
+	}
+	defer client.Close()
+	ctx := context.Background()
+
+	// Results are yielded lazily, and the loop body can use the client
+	var found []SearchResult
+	for result, err := range client.SearchIter(ctx, "Tetris", SearchOptions{}) {
+		if err != nil {
+			t.Fatalf("SearchIter() error: %v", err)
+		}
+		if _, err := client.Search(ctx, "Tetris", SearchOptions{Providers: []string{"hltb"}}); err != nil {
+			t.Fatalf("Search() in the loop error: %v", err)
+		}
+		if found = append(found, result); len(found) == 3 {
+			break
+		}
+	}
+	if moby.yielded != 3 || found[2].ProviderID != 3 {
+		t.Errorf("yielded %d results for %+v, want 3", moby.yielded, found)
+	}
+
+	// The limit applies to the results overall
+	found = nil
+	for result, err := range client.SearchIter(ctx, "Tetris", SearchOptions{Limit: 2}) {
+		if err != nil {
+			t.Fatalf("SearchIter() error: %v", err)
+		}
+		found = append(found, result)
+	}
+	if len(found) != 2 || found[1].Provider != "mobygames" || moby.yielded != 5 {
+		t.Errorf("SearchIter() with a limit = %+v", found)
+	}
+}
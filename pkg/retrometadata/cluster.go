@@ -0,0 +1,170 @@
+package retrometadata
+
+import (
+	"context"
+	"slices"
+	"strings"
+
+	"github.com/josegonzalez/retro-metadata/pkg/internal/normalization"
+	"github.com/josegonzalez/retro-metadata/pkg/platform"
+)
+
+// SearchCluster is a game found by one or more providers: the search results
+// that refer to it, combined.
+type SearchCluster struct {
+	// Name is the name of the best scoring result
+	Name string `json:"name"`
+	// ProviderIDs maps provider names to the game's IDs
+	ProviderIDs map[string]int `json:"provider_ids,omitempty"`
+	// ProviderUIDs maps provider names to the game's string IDs
+	ProviderUIDs map[string]string `json:"provider_uids,omitempty"`
+	// CoverURL is the cover of the first result with one
+	CoverURL string `json:"cover_url,omitempty"`
+	// Platforms are the platforms of every result, deduplicated
+	Platforms []string `json:"platforms,omitempty"`
+	// ReleaseYear is the first known release year
+	ReleaseYear *int `json:"release_year,omitempty"`
+	// MatchScore is the best score of the results
+	MatchScore float64 `json:"match_score,omitempty"`
+	// Results are the provider results, in the order they were found
+	Results []SearchResult `json:"results"`
+}
+
+// clusterKey is what results must agree on to refer to the same game.
+type clusterKey struct {
+	name      string
+	year      *int
+	platforms map[string]bool
+	providers map[string]bool
+}
+
+// accepts reports whether a result refers to the game of a cluster: its
+// normalized name is the same, its year and platforms agree where both are
+// known, and the cluster has no result from its provider yet, since a
+// provider lists a game once.
+func (k *clusterKey) accepts(r SearchResult, name string, platforms map[string]bool) bool {
+	if k.name != name || k.providers[r.Provider] {
+		return false
+	}
+	if k.year != nil && r.ReleaseYear != nil && *k.year != *r.ReleaseYear {
+		return false
+	}
+	if len(k.platforms) == 0 || len(platforms) == 0 {
+		return true
+	}
+	for p := range platforms {
+		if k.platforms[p] {
+			return true
+		}
+	}
+	return false
+}
+
+// platformKeys returns the platforms of a result as universal slugs where
+// they're recognized, so providers naming a platform differently agree.
+func platformKeys(r SearchResult) map[string]bool {
+	keys := make(map[string]bool, len(r.Platforms))
+	for _, name := range r.Platforms {
+		slug := platform.SlugFromProviderName(r.Provider, name)
+		if slug == "" {
+			slug, _ = platform.ParseSlug(name)
+		}
+		if slug != "" {
+			keys[string(slug)] = true
+		} else if name != "" {
+			keys[strings.ToLower(name)] = true
+		}
+	}
+	return keys
+}
+
+// ClusterResults groups search results referring to the same game, like
+// those of several providers, into one cluster each, with every provider's
+// ID attached. Results refer to the same game if their names are the same
+// after normalization (see NormalizeGameName), and their release years and
+// platforms agree where both are known. Clusters are in the order of their
+// first result.
+func ClusterResults(results []SearchResult) []SearchCluster {
+	var clusters []SearchCluster
+	var keys []*clusterKey
+	for _, r := range results {
+		name := normalization.NormalizeGameName(r.Name)
+		platforms := platformKeys(r)
+
+		i := slices.IndexFunc(keys, func(k *clusterKey) bool {
+			return k.accepts(r, name, platforms)
+		})
+		if i < 0 {
+			clusters = append(clusters, SearchCluster{Name: r.Name, MatchScore: r.MatchScore})
+			keys = append(keys, &clusterKey{name: name, platforms: make(map[string]bool), providers: make(map[string]bool)})
+			i = len(clusters) - 1
+		}
+
+		cluster, key := &clusters[i], keys[i]
+		cluster.add(r)
+		key.providers[r.Provider] = true
+		for p := range platforms {
+			key.platforms[p] = true
+		}
+		if key.year == nil {
+			key.year = r.ReleaseYear
+		}
+	}
+	return clusters
+}
+
+// add adds a result to the cluster.
+func (c *SearchCluster) add(r SearchResult) {
+	c.Results = append(c.Results, r)
+	if r.MatchScore > c.MatchScore {
+		c.Name = r.Name
+		c.MatchScore = r.MatchScore
+	}
+	if r.ProviderUID != "" {
+		if c.ProviderUIDs == nil {
+			c.ProviderUIDs = make(map[string]string)
+		}
+		c.ProviderUIDs[r.Provider] = r.ProviderUID
+	} else {
+		if c.ProviderIDs == nil {
+			c.ProviderIDs = make(map[string]int)
+		}
+		c.ProviderIDs[r.Provider] = r.ProviderID
+	}
+	if c.CoverURL == "" {
+		c.CoverURL = r.CoverURL
+	}
+	for _, p := range r.Platforms {
+		if !slices.Contains(c.Platforms, p) {
+			c.Platforms = append(c.Platforms, p)
+		}
+	}
+	if c.ReleaseYear == nil {
+		c.ReleaseYear = r.ReleaseYear
+	}
+}
+
+// SearchClustered searches like Search, then groups the results referring
+// to the same game with ClusterResults. opts.Limit limits the clusters
+// rather than the results; the search itself is limited to opts.Limit
+// results per provider searched.
+func (c *Client) SearchClustered(ctx context.Context, query string, opts SearchOptions) ([]SearchCluster, error) {
+	if opts.Limit == 0 {
+		opts.Limit = 10
+	}
+	limit := opts.Limit
+
+	c.mu.RLock()
+	opts.Limit *= max(len(c.selectProviders(opts.Providers, opts.ExcludeProviders)), 1)
+	c.mu.RUnlock()
+
+	results, err := c.Search(ctx, query, opts)
+	if err != nil {
+		return nil, err
+	}
+	clusters := ClusterResults(results)
+	if len(clusters) > limit {
+		clusters = clusters[:limit]
+	}
+	return clusters, nil
+}
@@ -0,0 +1,62 @@
+package retrometadata
+
+import (
+	"context"
+	"testing"
+
+	"github.com/josegonzalez/retro-metadata/pkg/cache"
+)
+
+func TestClusterResults(t *testing.T) {
+	year := func(y int) *int { return &y }
+	results := []SearchResult{
+		{Name: "The Legend of Zelda: A Link to the Past", Provider: "igdb", ProviderID: 1026, Platforms: []string{"Super Nintendo Entertainment System"}, ReleaseYear: year(1991), MatchScore: 0.9},
+		{Name: "Legend of Zelda, The - A Link to the Past", Provider: "mobygames", ProviderID: 2891, Platforms: []string{"SNES"}, ReleaseYear: year(1991), MatchScore: 0.95, CoverURL: "moby.jpg"},
+		{Name: "The Legend of Zelda: A Link to the Past", Provider: "flashpoint", ProviderUID: "uuid"},
+		// A remake on another platform is another game
+		{Name: "The Legend of Zelda: A Link to the Past", Provider: "thegamesdb", ProviderID: 5, Platforms: []string{"Game Boy Advance"}, ReleaseYear: year(2002)},
+		// The same provider lists a game once, so this joins the remake
+		{Name: "The Legend of Zelda: A Link to the Past", Provider: "igdb", ProviderID: 9999},
+		{Name: "Super Metroid", Provider: "igdb", ProviderID: 1103},
+	}
+
+	clusters := ClusterResults(results)
+	if len(clusters) != 3 {
+		t.Fatalf("ClusterResults() = %d clusters, want 3: %+v", len(clusters), clusters)
+	}
+
+	zelda := clusters[0]
+	if zelda.Name != "Legend of Zelda, The - A Link to the Past" || zelda.MatchScore != 0.95 {
+		t.Errorf("Name = %q, MatchScore = %v, want the best scoring result's", zelda.Name, zelda.MatchScore)
+	}
+	if zelda.ProviderIDs["igdb"] != 1026 || zelda.ProviderIDs["mobygames"] != 2891 || zelda.ProviderUIDs["flashpoint"] != "uuid" {
+		t.Errorf("ProviderIDs = %v, UIDs = %v", zelda.ProviderIDs, zelda.ProviderUIDs)
+	}
+	if len(zelda.Results) != 3 || zelda.CoverURL != "moby.jpg" || *zelda.ReleaseYear != 1991 || len(zelda.Platforms) != 2 {
+		t.Errorf("cluster = %+v", zelda)
+	}
+	if clusters[1].ProviderIDs["thegamesdb"] != 5 || clusters[1].ProviderIDs["igdb"] != 9999 || clusters[2].Name != "Super Metroid" {
+		t.Errorf("clusters = %+v", clusters[1:])
+	}
+}
+
+func TestClientSearchClustered(t *testing.T) {
+	for _, name := range []string{"mobygames", "hltb"} {
+		RegisterProvider(name, func(ProviderConfig, cache.Cache) (Provider, error) {
+			return &fakeProvider{name: name}, nil
+		})
+	}
+	client, err := NewClient(WithMobyGames("key"), WithHLTB())
+	if err != nil {
+		t.Fatalf("NewClient() error: %v", err)
+	}
+	defer client.Close()
+
+	clusters, err := client.SearchClustered(context.Background(), "Chrono Trigger", SearchOptions{Limit: 1})
+	if err != nil {
+		t.Fatalf("SearchClustered() error: %v", err)
+	}
+	if len(clusters) != 1 || len(clusters[0].Results) != 2 || len(clusters[0].ProviderIDs) != 2 {
+		t.Errorf("SearchClustered() = %+v, want one cluster from both providers", clusters)
+	}
+}
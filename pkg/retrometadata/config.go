@@ -1,6 +1,13 @@
 package retrometadata
 
-import "sort"
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"sort"
+	"time"
+)
 
 // ProviderConfig contains configuration for an individual metadata provider.
 type ProviderConfig struct {
@@ -10,10 +17,30 @@ type ProviderConfig struct {
 	Credentials map[string]string `json:"credentials,omitempty"`
 	// Priority is the priority order for this provider (lower = higher priority)
 	Priority int `json:"priority"`
-	// Timeout is the request timeout in seconds
+	// Timeout is the request timeout in seconds. The client sets it to
+	// Config.DefaultTimeout if it's 0
 	Timeout int `json:"timeout"`
-	// RateLimit is the maximum requests per second (0 = unlimited)
+	// HTTPClient is the HTTP client the provider's requests are sent with,
+	// with Timeout applied. The client sets it to Config.HTTPClient if it's nil
+	HTTPClient *http.Client `json:"-"`
+	// Locale is the preferred locale for localized names and summaries, e.g.
+	// "fr-FR". The client sets it to Config.PreferredLocale if it's empty
+	Locale string `json:"locale,omitempty"`
+	// SkipAchievements leaves achievements out of results, for providers
+	// that spend requests or time on them. The client sets it if
+	// Config.SkipAchievements is set
+	SkipAchievements bool `json:"skip_achievements,omitempty"`
+	// Deadline overrides Config.ProviderDeadline for this provider, in
+	// seconds (0 = the client's)
+	Deadline float64 `json:"deadline,omitempty"`
+	// RateLimit is the maximum requests per second (0 = the provider's
+	// default, unlimited for most; negative = unlimited)
 	RateLimit float64 `json:"rate_limit"`
+	// MinMatchScore overrides the provider's minimum similarity score for fuzzy matching (0 = provider default)
+	MinMatchScore float64 `json:"min_match_score,omitempty"`
+	// MatchScorer selects the similarity function ("jaro-winkler", "levenshtein", "token-set");
+	// providers reject other names
+	MatchScorer string `json:"match_scorer,omitempty"`
 	// Options contains additional provider-specific options
 	Options map[string]any `json:"options,omitempty"`
 }
@@ -48,6 +75,9 @@ type CacheConfig struct {
 	TTL int `json:"ttl"`
 	// MaxSize is the maximum number of entries for memory cache
 	MaxSize int `json:"max_size"`
+	// MaxBytes bounds the approximate memory of the memory cache's entries
+	// (0 = unbounded)
+	MaxBytes int64 `json:"max_bytes,omitempty"`
 	// ConnectionString is the connection string for redis/sqlite backends
 	ConnectionString string `json:"connection_string,omitempty"`
 	// Options contains additional backend-specific options
@@ -63,6 +93,19 @@ func DefaultCacheConfig() CacheConfig {
 	}
 }
 
+// Identify strategies, for Config.IdentifyStrategy.
+const (
+	// IdentifyWaterfall asks providers in priority order, one at a time
+	IdentifyWaterfall = "waterfall"
+	// IdentifyRace asks providers at once and returns the first confident
+	// match, cancelling the rest
+	IdentifyRace = "race"
+)
+
+// DefaultRaceMinScore is the match score that wins a race when
+// Config.RaceMinScore isn't set.
+const DefaultRaceMinScore = 0.9
+
 // Config is the main configuration for the Client.
 type Config struct {
 	// Provider configurations
@@ -86,12 +129,55 @@ type Config struct {
 	DefaultTimeout int `json:"default_timeout"`
 	// MaxConcurrentRequests is the maximum concurrent requests across all providers
 	MaxConcurrentRequests int `json:"max_concurrent_requests"`
+	// MinMatchScore is the match score below which fuzzy matches are
+	// treated as unmatched, returning a LowConfidenceError (0 = accept
+	// every match providers return)
+	MinMatchScore float64 `json:"min_match_score,omitempty"`
+	// ProviderDeadline is the soft deadline in seconds for each provider when
+	// searching or identifying across providers (0 = none). With a deadline,
+	// providers are asked at once and the best result available when the
+	// deadline passes is returned; slower providers finish in the background,
+	// within their timeout, to warm the cache
+	ProviderDeadline float64 `json:"provider_deadline,omitempty"`
+	// IdentifyStrategy is how Identify asks providers: IdentifyWaterfall
+	// (the default) asks them in turn, IdentifyRace asks them at once and
+	// returns the first match scoring at least RaceMinScore
+	IdentifyStrategy string `json:"identify_strategy,omitempty"`
+	// RaceMinScore is the match score that wins a race; matches without a
+	// score, like hash matches, always do (0 = DefaultRaceMinScore)
+	RaceMinScore float64 `json:"race_min_score,omitempty"`
+	// TrustWeights weigh providers per field when IdentifyMerged merges
+	// their matches
+	TrustWeights TrustWeights `json:"trust_weights,omitempty"`
+	// ReconcileIDs sets whether matches get the IDs other providers have
+	// for the game, found through the providers implementing IDLinker
+	ReconcileIDs bool `json:"reconcile_ids,omitempty"`
+	// HTTPClient is the HTTP client providers send requests with, e.g. with
+	// a proxy, client certificates or a recording transport (nil = one using
+	// http.DefaultTransport). Providers share its transport and connection
+	// pool, and apply their own Timeout
+	HTTPClient *http.Client `json:"-"`
 	// UserAgent is the user agent string for HTTP requests
 	UserAgent string `json:"user_agent"`
 	// PreferredLocale is the preferred locale for localized content
 	PreferredLocale string `json:"preferred_locale,omitempty"`
 	// RegionPriority is the list of region codes in priority order
 	RegionPriority []string `json:"region_priority"`
+	// SkipAchievements leaves achievements out of every provider's results
+	SkipAchievements bool `json:"skip_achievements,omitempty"`
+	// RawResponses controls the raw provider payloads kept on results. If
+	// they can't be dumped, they're kept
+	RawResponses RawConfig `json:"raw_responses,omitempty"`
+	// Overrides pin files to games, consulted before any provider when
+	// identifying
+	Overrides Overrides `json:"overrides,omitempty"`
+	// OverridesFile is a JSON overrides file loaded by NewClient. Its
+	// overrides are added to Overrides, which take precedence
+	OverridesFile string `json:"overrides_file,omitempty"`
+	// SecretResolvers resolve provider credentials referencing a secret
+	// with their scheme, e.g. "vault:secret/moby", when providers are
+	// created. "env:NAME" and "file:PATH" references are always resolved
+	SecretResolvers map[string]SecretResolver `json:"-"`
 }
 
 // DefaultConfig returns a configuration with sensible defaults.
@@ -117,6 +203,31 @@ func DefaultConfig() Config {
 	}
 }
 
+// LoadConfig reads a JSON configuration file. Settings missing from the file
+// keep their DefaultConfig values.
+func LoadConfig(path string) (Config, error) {
+	config := DefaultConfig()
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return config, err
+	}
+	if err := json.Unmarshal(data, &config); err != nil {
+		return config, fmt.Errorf("parsing config %s: %w", path, err)
+	}
+	return config, nil
+}
+
+// Languages returns the language priority for PreferredLocale.
+func (c *Config) Languages() []Language {
+	return LanguagePriority(c.PreferredLocale)
+}
+
+// Regions returns RegionPriority as normalized regions, dropping unknown codes.
+func (c *Config) Regions() []Region {
+	return ParseRegions(c.RegionPriority)
+}
+
 // GetEnabledProviders returns a list of enabled provider names sorted by priority.
 func (c *Config) GetEnabledProviders() []string {
 	type providerPriority struct {
@@ -195,6 +306,14 @@ func (c *Config) GetProviderConfig(name string) *ProviderConfig {
 // Option is a functional option for configuring the Client.
 type Option func(*Config)
 
+// WithConfig replaces the whole configuration, e.g. one read by LoadConfig.
+// Options after it still apply on top.
+func WithConfig(config Config) Option {
+	return func(c *Config) {
+		*c = config
+	}
+}
+
 // WithIGDB configures the IGDB provider.
 func WithIGDB(clientID, clientSecret string) Option {
 	return func(c *Config) {
@@ -272,6 +391,14 @@ func WithCache(backend string, ttl, maxSize int) Option {
 	}
 }
 
+// WithCacheMaxBytes bounds the approximate memory of the memory cache's
+// entries, evicting the least recently used ones past it.
+func WithCacheMaxBytes(maxBytes int64) Option {
+	return func(c *Config) {
+		c.Cache.MaxBytes = maxBytes
+	}
+}
+
 // WithRedisCache configures a Redis cache backend.
 func WithRedisCache(connectionString string, ttl int) Option {
 	return func(c *Config) {
@@ -297,6 +424,26 @@ func WithUserAgent(userAgent string) Option {
 	}
 }
 
+// WithHTTPClient sets the HTTP client providers send requests with.
+func WithHTTPClient(client *http.Client) Option {
+	return func(c *Config) {
+		c.HTTPClient = client
+	}
+}
+
+// WithTransport sets the transport providers send requests with, e.g. an
+// *http.Transport with a proxy or client certificates.
+func WithTransport(transport http.RoundTripper) Option {
+	return func(c *Config) {
+		client := &http.Client{}
+		if c.HTTPClient != nil {
+			*client = *c.HTTPClient
+		}
+		client.Transport = transport
+		c.HTTPClient = client
+	}
+}
+
 // WithTimeout sets the default timeout.
 func WithTimeout(seconds int) Option {
 	return func(c *Config) {
@@ -311,6 +458,69 @@ func WithMaxConcurrentRequests(max int) Option {
 	}
 }
 
+// WithMinMatchScore sets the match score below which fuzzy matches are
+// treated as unmatched.
+func WithMinMatchScore(score float64) Option {
+	return func(c *Config) {
+		c.MinMatchScore = score
+	}
+}
+
+// WithProviderDeadline sets the soft deadline for each provider when
+// searching or identifying across providers.
+func WithProviderDeadline(d time.Duration) Option {
+	return func(c *Config) {
+		c.ProviderDeadline = d.Seconds()
+	}
+}
+
+// WithIdentifyRace makes Identify ask providers at once and return the
+// first match scoring at least minScore (0 = DefaultRaceMinScore).
+func WithIdentifyRace(minScore float64) Option {
+	return func(c *Config) {
+		c.IdentifyStrategy = IdentifyRace
+		c.RaceMinScore = minScore
+	}
+}
+
+// WithTrustWeights sets how much IdentifyMerged trusts each provider for
+// each field.
+func WithTrustWeights(weights TrustWeights) Option {
+	return func(c *Config) {
+		c.TrustWeights = weights
+	}
+}
+
+// WithReconcileIDs makes matches carry the IDs other providers have for the
+// game, as ReconcileIDs adds them.
+func WithReconcileIDs() Option {
+	return func(c *Config) {
+		c.ReconcileIDs = true
+	}
+}
+
+// WithRawResponses sets how raw provider payloads are kept on results.
+func WithRawResponses(raw RawConfig) Option {
+	return func(c *Config) {
+		c.RawResponses = raw
+	}
+}
+
+// WithOverrides sets the overrides consulted before any provider when
+// identifying.
+func WithOverrides(overrides Overrides) Option {
+	return func(c *Config) {
+		c.Overrides = overrides
+	}
+}
+
+// WithOverridesFile sets the JSON overrides file loaded by NewClient.
+func WithOverridesFile(path string) Option {
+	return func(c *Config) {
+		c.OverridesFile = path
+	}
+}
+
 // WithPreferredLocale sets the preferred locale.
 func WithPreferredLocale(locale string) Option {
 	return func(c *Config) {
@@ -318,9 +528,28 @@ func WithPreferredLocale(locale string) Option {
 	}
 }
 
+// WithoutAchievements leaves achievements out of results, which is faster
+// with providers that fetch them separately.
+func WithoutAchievements() Option {
+	return func(c *Config) {
+		c.SkipAchievements = true
+	}
+}
+
 // WithRegionPriority sets the region priority order.
 func WithRegionPriority(regions []string) Option {
 	return func(c *Config) {
 		c.RegionPriority = regions
 	}
 }
+
+// WithSecretResolver resolves provider credentials of the form
+// "scheme:ref" with resolver, e.g. to read them from a secrets manager.
+func WithSecretResolver(scheme string, resolver SecretResolver) Option {
+	return func(c *Config) {
+		if c.SecretResolvers == nil {
+			c.SecretResolvers = make(map[string]SecretResolver)
+		}
+		c.SecretResolvers[scheme] = resolver
+	}
+}
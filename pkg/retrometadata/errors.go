@@ -1,8 +1,11 @@
 package retrometadata
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"net/http"
+	"strconv"
 )
 
 // Common sentinel errors for the library.
@@ -10,9 +13,19 @@ var (
 	// ErrProviderNotFound indicates that a requested provider is not found or not configured.
 	ErrProviderNotFound = errors.New("provider not found or not configured")
 
+	// ErrNotSupported indicates that a provider doesn't support an operation.
+	ErrNotSupported = errors.New("operation not supported by provider")
+
+	// ErrProviderDisabled indicates that a provider is disabled.
+	ErrProviderDisabled = errors.New("provider is disabled")
+
 	// ErrProviderAuth indicates that provider authentication failed.
 	ErrProviderAuth = errors.New("provider authentication failed")
 
+	// ErrCredentialsRequired indicates that an operation needs credentials
+	// the provider was configured without.
+	ErrCredentialsRequired = errors.New("provider credentials required")
+
 	// ErrProviderConnection indicates that connection to a provider failed.
 	ErrProviderConnection = errors.New("provider connection failed")
 
@@ -22,6 +35,10 @@ var (
 	// ErrGameNotFound indicates that a game was not found.
 	ErrGameNotFound = errors.New("game not found")
 
+	// ErrLowConfidence indicates that a game was matched with a score below
+	// the configured minimum, so it's treated as not found.
+	ErrLowConfidence = errors.New("match score below minimum")
+
 	// ErrInvalidConfig indicates that the configuration is invalid.
 	ErrInvalidConfig = errors.New("invalid configuration")
 
@@ -65,10 +82,14 @@ func NewProviderError(provider, op string, err error) *ProviderError {
 type RateLimitError struct {
 	// Provider is the name of the provider
 	Provider string
+	// StatusCode is the HTTP status of the response, if any
+	StatusCode int
 	// RetryAfter is the number of seconds to wait before retrying
 	RetryAfter int
 	// Details provides additional context
 	Details string
+	// Err is the underlying error, if any
+	Err error
 }
 
 // Error implements the error interface.
@@ -77,59 +98,64 @@ func (e *RateLimitError) Error() string {
 	if e.RetryAfter > 0 {
 		msg += fmt.Sprintf(" (retry after %ds)", e.RetryAfter)
 	}
-	if e.Details != "" {
-		msg += fmt.Sprintf(": %s", e.Details)
-	}
-	return msg
+	return msg + detail(e.Details, e.StatusCode, e.Err)
 }
 
-// Unwrap returns the underlying sentinel error.
-func (e *RateLimitError) Unwrap() error {
-	return ErrProviderRateLimit
+// Unwrap returns the sentinel error and the underlying error.
+func (e *RateLimitError) Unwrap() []error {
+	return unwrap(ErrProviderRateLimit, e.Err)
 }
 
 // AuthError represents an authentication error.
 type AuthError struct {
 	// Provider is the name of the provider
 	Provider string
+	// StatusCode is the HTTP status of the response, if any
+	StatusCode int
 	// Details provides additional context
 	Details string
+	// Err is the underlying error, if any
+	Err error
 }
 
 // Error implements the error interface.
 func (e *AuthError) Error() string {
-	msg := fmt.Sprintf("authentication failed for provider '%s'", e.Provider)
-	if e.Details != "" {
-		msg += fmt.Sprintf(": %s", e.Details)
-	}
-	return msg
+	return fmt.Sprintf("authentication failed for provider '%s'", e.Provider) + detail(e.Details, e.StatusCode, e.Err)
 }
 
-// Unwrap returns the underlying sentinel error.
-func (e *AuthError) Unwrap() error {
-	return ErrProviderAuth
+// Unwrap returns the sentinel error and the underlying error.
+func (e *AuthError) Unwrap() []error {
+	return unwrap(ErrProviderAuth, e.Err)
+}
+
+// CredentialsRequired returns the error for an operation, e.g. "API
+// requests", that a provider running without credentials can't perform: an
+// *AuthError wrapping ErrCredentialsRequired.
+func CredentialsRequired(provider, op string) error {
+	return &AuthError{Provider: provider, Details: "credentials required for " + op, Err: ErrCredentialsRequired}
 }
 
-// ConnectionError represents a connection error.
+// ConnectionError represents a connection error: the request failed, or the
+// provider answered with an unexpected HTTP status.
 type ConnectionError struct {
 	// Provider is the name of the provider
 	Provider string
+	// StatusCode is the HTTP status of the response, or 0 if there was none
+	StatusCode int
 	// Details provides additional context
 	Details string
+	// Err is the underlying error, if any
+	Err error
 }
 
 // Error implements the error interface.
 func (e *ConnectionError) Error() string {
-	msg := fmt.Sprintf("connection failed for provider '%s'", e.Provider)
-	if e.Details != "" {
-		msg += fmt.Sprintf(": %s", e.Details)
-	}
-	return msg
+	return fmt.Sprintf("connection failed for provider '%s'", e.Provider) + detail(e.Details, e.StatusCode, e.Err)
 }
 
-// Unwrap returns the underlying sentinel error.
-func (e *ConnectionError) Unwrap() error {
-	return ErrProviderConnection
+// Unwrap returns the sentinel error and the underlying error.
+func (e *ConnectionError) Unwrap() []error {
+	return unwrap(ErrProviderConnection, e.Err)
 }
 
 // GameNotFoundError represents a game not found error.
@@ -154,6 +180,38 @@ func (e *GameNotFoundError) Unwrap() error {
 	return ErrGameNotFound
 }
 
+// LowConfidenceError is returned instead of a match that scored below the
+// minimum match score. It matches both ErrLowConfidence and ErrGameNotFound.
+type LowConfidenceError struct {
+	// SearchTerm is the search term that was used
+	SearchTerm string
+	// Result is the best of the rejected matches, for manual review
+	Result *GameResult
+	// MinScore is the minimum match score
+	MinScore float64
+}
+
+// Error implements the error interface.
+func (e *LowConfidenceError) Error() string {
+	return fmt.Sprintf("game not found: '%s': best match '%s' from '%s' scored %.2f, below %.2f",
+		e.SearchTerm, e.Result.Name, e.Result.Provider, e.Result.MatchScore, e.MinScore)
+}
+
+// Unwrap returns the underlying sentinel errors.
+func (e *LowConfidenceError) Unwrap() []error {
+	return []error{ErrLowConfidence, ErrGameNotFound}
+}
+
+// CheckMatchScore returns a LowConfidenceError if result was fuzzy matched
+// with a score below minScore. Results without a match score, such as hash
+// matches, always pass, as does everything when minScore is 0.
+func CheckMatchScore(result *GameResult, searchTerm string, minScore float64) error {
+	if result == nil || result.MatchScore == 0 || result.MatchScore >= minScore {
+		return nil
+	}
+	return &LowConfidenceError{SearchTerm: searchTerm, Result: result, MinScore: minScore}
+}
+
 // ConfigError represents a configuration error.
 type ConfigError struct {
 	// Field is the configuration field with the error
@@ -196,3 +254,116 @@ func (e *CacheError) Error() string {
 func (e *CacheError) Unwrap() error {
 	return ErrCacheOperation
 }
+
+// detail formats the details of an error: Details, the HTTP status, or the
+// underlying error, whichever is set first.
+func detail(details string, statusCode int, err error) string {
+	switch {
+	case details != "":
+		return ": " + details
+	case statusCode != 0:
+		return fmt.Sprintf(": HTTP %d", statusCode)
+	case err != nil:
+		return ": " + err.Error()
+	}
+	return ""
+}
+
+// unwrap returns the errors a typed error wraps.
+func unwrap(sentinel, err error) []error {
+	if err == nil {
+		return []error{sentinel}
+	}
+	return []error{sentinel, err}
+}
+
+// HTTPError returns the error for a provider's HTTP response that isn't a
+// 2xx: an *AuthError for 401 and 403, a *RateLimitError with the Retry-After
+// delay for 429, and a *ConnectionError for any other status. It returns nil
+// for 2xx responses.
+func HTTPError(provider string, resp *http.Response) error {
+	status := resp.StatusCode
+	switch {
+	case status >= 200 && status < 300:
+		return nil
+	case status == http.StatusUnauthorized || status == http.StatusForbidden:
+		return &AuthError{Provider: provider, StatusCode: status}
+	case status == http.StatusTooManyRequests:
+		err := &RateLimitError{Provider: provider, StatusCode: status}
+		if seconds, convErr := strconv.Atoi(resp.Header.Get("Retry-After")); convErr == nil && seconds > 0 {
+			err.RetryAfter = seconds
+		}
+		return err
+	default:
+		return &ConnectionError{Provider: provider, StatusCode: status}
+	}
+}
+
+// RequestError returns the error for a provider request that got no
+// response: ctx's error if it's done, so cancellations can be told apart
+// from provider failures, otherwise a *ConnectionError wrapping err.
+func RequestError(ctx context.Context, provider string, err error) error {
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		return ctxErr
+	}
+	return &ConnectionError{Provider: provider, Err: err}
+}
+
+// Retryable reports whether a failed provider call may succeed if it's
+// retried later: rate limits, connection failures, timeouts and 5xx
+// responses are retryable; authentication failures, missing games, invalid
+// configurations, other 4xx responses and cancellations aren't.
+func Retryable(err error) bool {
+	var rateLimit *RateLimitError
+	var connection *ConnectionError
+	switch {
+	case err == nil, errors.Is(err, context.Canceled), errors.Is(err, ErrProviderAuth), errors.Is(err, ErrGameNotFound), errors.Is(err, ErrInvalidConfig):
+		return false
+	case errors.As(err, &rateLimit):
+		return true
+	case errors.As(err, &connection):
+		status := connection.StatusCode
+		return status == 0 || status >= 500 || status == http.StatusRequestTimeout
+	}
+	return errors.Is(err, ErrProviderRateLimit) || errors.Is(err, ErrProviderConnection)
+}
+
+// HTTPStatus returns the HTTP status of the response a provider error came
+// from, or 0 if it didn't come from a response.
+func HTTPStatus(err error) int {
+	var auth *AuthError
+	var rateLimit *RateLimitError
+	var connection *ConnectionError
+	switch {
+	case errors.As(err, &auth):
+		return auth.StatusCode
+	case errors.As(err, &rateLimit):
+		return rateLimit.StatusCode
+	case errors.As(err, &connection):
+		return connection.StatusCode
+	}
+	return 0
+}
+
+// ErrorProvider returns the name of the provider an error came from, or ""
+// if it doesn't name one.
+func ErrorProvider(err error) string {
+	var auth *AuthError
+	var rateLimit *RateLimitError
+	var connection *ConnectionError
+	var provider *ProviderError
+	var notFound *GameNotFoundError
+	switch {
+	case errors.As(err, &auth):
+		return auth.Provider
+	case errors.As(err, &rateLimit):
+		return rateLimit.Provider
+	case errors.As(err, &connection):
+		return connection.Provider
+	case errors.As(err, &provider):
+		return provider.Provider
+	case errors.As(err, &notFound):
+		return notFound.Provider
+	}
+	return ""
+}
@@ -0,0 +1,98 @@
+package retrometadata
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"testing"
+)
+
+func response(status int, header http.Header) *http.Response {
+	return &http.Response{StatusCode: status, Header: header}
+}
+
+func TestHTTPError(t *testing.T) {
+	tests := []struct {
+		status    int
+		sentinel  error
+		retryable bool
+	}{
+		{http.StatusUnauthorized, ErrProviderAuth, false},
+		{http.StatusForbidden, ErrProviderAuth, false},
+		{http.StatusTooManyRequests, ErrProviderRateLimit, true},
+		{http.StatusNotFound, ErrProviderConnection, false},
+		{http.StatusRequestTimeout, ErrProviderConnection, true},
+		{http.StatusBadGateway, ErrProviderConnection, true},
+	}
+	for _, tt := range tests {
+		err := HTTPError("igdb", response(tt.status, http.Header{}))
+		if !errors.Is(err, tt.sentinel) {
+			t.Errorf("HTTPError(%d) = %v, want %v", tt.status, err, tt.sentinel)
+		}
+		if got := Retryable(err); got != tt.retryable {
+			t.Errorf("Retryable(HTTPError(%d)) = %v, want %v", tt.status, got, tt.retryable)
+		}
+		// Wrapping keeps the status and provider reachable
+		wrapped := fmt.Errorf("search: %w", err)
+		if HTTPStatus(wrapped) != tt.status || ErrorProvider(wrapped) != "igdb" {
+			t.Errorf("HTTPStatus, ErrorProvider = %d, %q; want %d, igdb", HTTPStatus(wrapped), ErrorProvider(wrapped), tt.status)
+		}
+	}
+
+	if err := HTTPError("igdb", response(http.StatusOK, nil)); err != nil {
+		t.Errorf("HTTPError(200) = %v, want nil", err)
+	}
+
+	var rateLimit *RateLimitError
+	err := HTTPError("igdb", response(http.StatusTooManyRequests, http.Header{"Retry-After": {"30"}}))
+	if !errors.As(err, &rateLimit) || rateLimit.RetryAfter != 30 {
+		t.Errorf("HTTPError(429) = %#v, want RetryAfter 30", err)
+	}
+}
+
+func TestRequestError(t *testing.T) {
+	err := RequestError(context.Background(), "igdb", io.ErrUnexpectedEOF)
+	if !errors.Is(err, ErrProviderConnection) || !errors.Is(err, io.ErrUnexpectedEOF) || !Retryable(err) {
+		t.Errorf("RequestError() = %v, want a retryable connection error wrapping the cause", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err = RequestError(ctx, "igdb", io.ErrUnexpectedEOF)
+	if !errors.Is(err, context.Canceled) || errors.Is(err, ErrProviderConnection) || Retryable(err) {
+		t.Errorf("RequestError() after cancel = %v, want context.Canceled", err)
+	}
+}
+
+func TestCredentialsRequired(t *testing.T) {
+	err := CredentialsRequired("screenscraper", "account info")
+	if !errors.Is(err, ErrCredentialsRequired) || !errors.Is(err, ErrProviderAuth) {
+		t.Errorf("CredentialsRequired() = %v, want an auth error wrapping ErrCredentialsRequired", err)
+	}
+	if want := "authentication failed for provider 'screenscraper': credentials required for account info"; err.Error() != want {
+		t.Errorf("Error() = %q, want %q", err.Error(), want)
+	}
+}
+
+func TestRetryable(t *testing.T) {
+	tests := []struct {
+		err  error
+		want bool
+	}{
+		{nil, false},
+		{&GameNotFoundError{Provider: "igdb"}, false},
+		{&ProviderError{Provider: "igdb", Err: ErrProviderRateLimit}, true},
+		{&ProviderError{Provider: "igdb", Err: ErrProviderAuth}, false},
+		{&RateLimitError{Provider: "screenscraper", StatusCode: 430}, true},
+		{CredentialsRequired("mobygames", "API requests"), false},
+		{context.DeadlineExceeded, false},
+		{errors.New("failed to parse response"), false},
+	}
+	for _, tt := range tests {
+		if got := Retryable(tt.err); got != tt.want {
+			t.Errorf("Retryable(%v) = %v, want %v", tt.err, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,212 @@
+package retrometadata
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// outcome is the result of one provider's call in a fan-out.
+type outcome[T any] struct {
+	done  bool
+	value T
+	err   error
+}
+
+// target is a provider called by fanOut, with its limits. Targets are
+// resolved while the caller holds c.mu, so calls that outlive fanOut don't
+// read the client's configuration or providers, which may change meanwhile.
+type target struct {
+	name     string
+	provider Provider
+	// deadline is the soft deadline, 0 for none
+	deadline time.Duration
+	// timeout is the hard timeout, 0 for none
+	timeout time.Duration
+	// calls counts the provider's calls in progress
+	calls *sync.WaitGroup
+}
+
+// targets resolves the named providers. Callers must hold c.mu.
+func (c *Client) targets(names []string) []target {
+	targets := make([]target, 0, len(names))
+	for _, name := range names {
+		targets = append(targets, target{
+			name:     name,
+			provider: c.providers[name],
+			deadline: c.deadline(name),
+			timeout:  c.timeout(name),
+			calls:    c.calls[name],
+		})
+	}
+	return targets
+}
+
+// deadline returns a provider's soft deadline: its own Deadline, or the
+// client's ProviderDeadline. Zero means no deadline.
+func (c *Client) deadline(name string) time.Duration {
+	seconds := c.config.ProviderDeadline
+	if pc := c.config.GetProviderConfig(name); pc != nil && pc.Deadline > 0 {
+		seconds = pc.Deadline
+	}
+	return time.Duration(seconds * float64(time.Second))
+}
+
+// hasDeadlines reports whether any of the targets has a soft deadline, in
+// which case they're called concurrently with fanOut rather than in turn.
+func hasDeadlines(targets []target) bool {
+	for _, t := range targets {
+		if t.deadline > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// timeout returns the hard timeout of a provider call that outlived its
+// soft deadline: the provider's Timeout, or the client's DefaultTimeout.
+func (c *Client) timeout(name string) time.Duration {
+	seconds := c.config.DefaultTimeout
+	if pc := c.config.GetProviderConfig(name); pc != nil && pc.Timeout > 0 {
+		seconds = pc.Timeout
+	}
+	return time.Duration(seconds) * time.Second
+}
+
+// fanOut calls every target at once and returns their outcomes in the
+// order of targets. It returns once every provider is done or past its soft
+// deadline, or earlier if ready reports the outcomes so far are enough.
+//
+// Calls still running when fanOut returns carry on in the background until
+// their hard timeout, so their results reach the provider's cache for the
+// next request, unless cancelRest is set. Cancelling ctx before fanOut
+// returns cancels every call, and closing the client cancels background
+// calls. call must only use the target and values resolved beforehand, not
+// the client's configuration or providers.
+func fanOut[T any](c *Client, ctx context.Context, targets []target, call func(ctx context.Context, t target) (T, error), ready func([]outcome[T]) bool, cancelRest bool) []outcome[T] {
+	type done struct {
+		index int
+		outcome[T]
+	}
+	results := make(chan done, len(targets))
+	stops := make([]func() bool, 0, len(targets))
+	cancels := make([]context.CancelFunc, 0, len(targets))
+
+	c.background.Add(len(targets))
+	for i, t := range targets {
+		t.calls.Add(1)
+		callCtx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+		if t.timeout > 0 {
+			cancel()
+			callCtx, cancel = context.WithTimeout(context.WithoutCancel(ctx), t.timeout)
+		}
+		stops = append(stops, context.AfterFunc(ctx, cancel))
+		cancels = append(cancels, cancel)
+		stopClosing := context.AfterFunc(c.closing, cancel)
+
+		go func() {
+			defer c.background.Done()
+			defer t.calls.Done()
+			defer stopClosing()
+			defer cancel()
+
+			var result done
+			result.index = i
+			if result.err = c.acquire(callCtx); result.err == nil {
+				result.value, result.err = call(callCtx, t)
+				c.release()
+			}
+			result.done = true
+			results <- result
+		}()
+	}
+
+	outcomes := make([]outcome[T], len(targets))
+	start := time.Now()
+	for pending := len(targets); pending > 0 && (ready == nil || !ready(outcomes)); {
+		var expired <-chan time.Time
+		if wait, bounded := untilDeadlines(targets, outcomes, start); bounded {
+			if wait <= 0 {
+				break
+			}
+			expired = time.After(wait)
+		}
+
+		select {
+		case result := <-results:
+			outcomes[result.index] = result.outcome
+			pending--
+		case <-expired:
+		case <-ctx.Done():
+			// The AfterFuncs cancel every call
+			return outcomes
+		}
+	}
+
+	// Detach the calls still running from ctx
+	for i, stop := range stops {
+		stop()
+		if cancelRest {
+			cancels[i]()
+		}
+	}
+	return outcomes
+}
+
+// matched reports whether the highest priority match is known: a provider
+// returned a game and every provider before it is done.
+func matched(outcomes []outcome[*GameResult]) bool {
+	for _, o := range outcomes {
+		if !o.done {
+			return false
+		}
+		if o.err == nil && o.value != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// raceWon returns a function reporting whether a provider returned a game
+// scoring at least minScore, or without a score as for hash matches.
+func raceWon(minScore float64) func([]outcome[*GameResult]) bool {
+	return func(outcomes []outcome[*GameResult]) bool {
+		return raceWinner(outcomes, minScore) != nil
+	}
+}
+
+// raceWinner returns the highest priority game among the outcomes scoring
+// at least minScore, or nil.
+func raceWinner(outcomes []outcome[*GameResult], minScore float64) *GameResult {
+	for _, o := range outcomes {
+		if o.done && o.err == nil && o.value != nil && (o.value.MatchScore == 0 || o.value.MatchScore >= minScore) {
+			return o.value
+		}
+	}
+	return nil
+}
+
+// bestMatch returns the highest priority game among the outcomes, or nil.
+func bestMatch(outcomes []outcome[*GameResult]) *GameResult {
+	for _, o := range outcomes {
+		if o.done && o.err == nil && o.value != nil {
+			return o.value
+		}
+	}
+	return nil
+}
+
+// untilDeadlines returns how long until every pending target is past its
+// soft deadline. It isn't bounded if a pending target has no deadline.
+func untilDeadlines[T any](targets []target, outcomes []outcome[T], start time.Time) (wait time.Duration, bounded bool) {
+	for i, t := range targets {
+		if outcomes[i].done {
+			continue
+		}
+		if t.deadline <= 0 {
+			return 0, false
+		}
+		wait = max(wait, time.Until(start.Add(t.deadline)))
+	}
+	return wait, true
+}
@@ -0,0 +1,188 @@
+package retrometadata
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/josegonzalez/retro-metadata/pkg/cache"
+)
+
+// slowProvider answers once released, and reports whether each call finished
+// or was cancelled.
+type slowProvider struct {
+	fakeProvider
+	release  chan struct{}
+	finished chan error
+	// started, if set, is closed when the first call starts
+	started chan struct{}
+	once    sync.Once
+}
+
+func (p *slowProvider) wait(ctx context.Context) error {
+	if p.started != nil {
+		p.once.Do(func() { close(p.started) })
+	}
+	select {
+	case <-p.release:
+		p.finished <- nil
+		return nil
+	case <-ctx.Done():
+		p.finished <- ctx.Err()
+		return ctx.Err()
+	}
+}
+
+func (p *slowProvider) Search(ctx context.Context, query string, opts SearchOptions) ([]SearchResult, error) {
+	if err := p.wait(ctx); err != nil {
+		return nil, err
+	}
+	return p.fakeProvider.Search(ctx, query, opts)
+}
+
+func (p *slowProvider) Identify(ctx context.Context, filename string, opts IdentifyOptions) (*GameResult, error) {
+	if err := p.wait(ctx); err != nil {
+		return nil, err
+	}
+	return p.fakeProvider.Identify(ctx, filename, opts)
+}
+
+func TestClientProviderDeadline(t *testing.T) {
+	slow := &slowProvider{
+		fakeProvider: fakeProvider{name: "mobygames"},
+		release:      make(chan struct{}),
+		finished:     make(chan error, 4),
+	}
+	RegisterProvider("mobygames", func(ProviderConfig, cache.Cache) (Provider, error) {
+		return slow, nil
+	})
+	RegisterProvider("hltb", func(ProviderConfig, cache.Cache) (Provider, error) {
+		return &fakeProvider{name: "hltb"}, nil
+	})
+
+	client, err := NewClient(WithMobyGames("key"), WithHLTB(), WithProviderDeadline(50*time.Millisecond))
+	if err != nil {
+		t.Fatalf("NewClient() error: %v", err)
+	}
+	ctx := context.Background()
+
+	// The slow, higher priority provider misses the deadline, so the fast
+	// provider's match is returned
+	result, err := client.Identify(ctx, "Game.sfc", IdentifyOptions{})
+	if err != nil || result == nil || result.Provider != "hltb" {
+		t.Fatalf("Identify() = %+v, %v; expected the hltb result", result, err)
+	}
+	results, _ := client.Search(ctx, "Game", SearchOptions{})
+	if len(results) != 1 || results[0].Provider != "hltb" {
+		t.Errorf("Search() = %+v, expected only the hltb result", results)
+	}
+
+	// The slow calls carry on after their deadline
+	close(slow.release)
+	for range 2 {
+		if err := <-slow.finished; err != nil {
+			t.Errorf("Background call error: %v", err)
+		}
+	}
+
+	// Once the slow provider answers in time, its match has priority
+	result, _ = client.Identify(ctx, "Game.sfc", IdentifyOptions{})
+	if result == nil || result.Provider != "mobygames" {
+		t.Errorf("Identify() = %+v, expected the mobygames result", result)
+	}
+	<-slow.finished
+
+	if err := client.Close(); err != nil {
+		t.Errorf("Close() error: %v", err)
+	}
+}
+
+func TestClientProviderDeadlineClose(t *testing.T) {
+	slow := &slowProvider{
+		fakeProvider: fakeProvider{name: "mobygames"},
+		release:      make(chan struct{}),
+		finished:     make(chan error, 1),
+	}
+	RegisterProvider("mobygames", func(ProviderConfig, cache.Cache) (Provider, error) {
+		return slow, nil
+	})
+
+	client, err := NewClient(WithMobyGames("key"), WithProviderDeadline(10*time.Millisecond))
+	if err != nil {
+		t.Fatalf("NewClient() error: %v", err)
+	}
+	if _, err := client.Identify(context.Background(), "Game.sfc", IdentifyOptions{}); err == nil {
+		t.Error("Expected Identify to fail when no provider answers in time")
+	}
+
+	// Closing the client cancels the background call
+	client.Close()
+	if err := <-slow.finished; err == nil {
+		t.Error("Expected the background call to be cancelled by Close")
+	}
+}
+
+// scoredProvider returns fakeProvider matches with a fixed score, once
+// after is closed if it's set.
+type scoredProvider struct {
+	fakeProvider
+	score float64
+	after chan struct{}
+}
+
+func (p *scoredProvider) Identify(ctx context.Context, filename string, opts IdentifyOptions) (*GameResult, error) {
+	if p.after != nil {
+		<-p.after
+	}
+	result, err := p.fakeProvider.Identify(ctx, filename, opts)
+	if result != nil {
+		result.MatchScore = p.score
+	}
+	return result, err
+}
+
+func TestClientIdentifyRace(t *testing.T) {
+	slow := &slowProvider{
+		fakeProvider: fakeProvider{name: "mobygames"},
+		release:      make(chan struct{}),
+		finished:     make(chan error, 2),
+		started:      make(chan struct{}),
+	}
+	// The fast provider answers once the slow call is running, to cancel it
+	fast := &scoredProvider{fakeProvider: fakeProvider{name: "hltb"}, score: 0.95, after: slow.started}
+	RegisterProvider("mobygames", func(ProviderConfig, cache.Cache) (Provider, error) {
+		return slow, nil
+	})
+	RegisterProvider("hltb", func(ProviderConfig, cache.Cache) (Provider, error) {
+		return fast, nil
+	})
+
+	client, err := NewClient(WithMobyGames("key"), WithHLTB(), WithIdentifyRace(0))
+	if err != nil {
+		t.Fatalf("NewClient() error: %v", err)
+	}
+	defer client.Close()
+	ctx := context.Background()
+
+	// The fast provider's confident match wins, and the slow call is cancelled
+	result, err := client.Identify(ctx, "Game.sfc", IdentifyOptions{})
+	if err != nil || result == nil || result.Provider != "hltb" {
+		t.Fatalf("Identify() = %+v, %v; expected the hltb result", result, err)
+	}
+	if err := <-slow.finished; err == nil {
+		t.Error("Expected the slow call to be cancelled")
+	}
+
+	// A match below the race score waits for the higher priority provider
+	fast.score = 0.5
+	close(slow.release)
+	result, _ = client.Identify(ctx, "Game.sfc", IdentifyOptions{})
+	if result == nil || result.Provider != "mobygames" {
+		t.Errorf("Identify() = %+v, expected the mobygames result", result)
+	}
+
+	if _, err := NewClient(WithConfig(Config{IdentifyStrategy: "fastest"})); err == nil {
+		t.Error("Expected an unknown identify strategy to be rejected")
+	}
+}
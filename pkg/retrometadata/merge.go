@@ -0,0 +1,208 @@
+package retrometadata
+
+import (
+	"context"
+	"maps"
+	"slices"
+
+	"github.com/josegonzalez/retro-metadata/pkg/platform"
+	"github.com/josegonzalez/retro-metadata/pkg/serials"
+)
+
+// Merge fields, the fields TrustWeights weigh providers by.
+const (
+	// MergeFieldName is the game name
+	MergeFieldName = "name"
+	// MergeFieldSummary is the summary
+	MergeFieldSummary = "summary"
+	// MergeFieldArtwork is each artwork URL and the media list
+	MergeFieldArtwork = "artwork"
+	// MergeFieldReleaseDate is the release date and year
+	MergeFieldReleaseDate = "release_date"
+	// MergeFieldRating is the user and critic ratings
+	MergeFieldRating = "rating"
+	// MergeFieldGenres is the genres and themes
+	MergeFieldGenres = "genres"
+	// MergeFieldCompanies is the developer, publisher and companies
+	MergeFieldCompanies = "companies"
+	// MergeFieldMetadata is every other metadata field
+	MergeFieldMetadata = "metadata"
+	// MergeFieldDefault weighs a provider for the fields it has no weight
+	// for
+	MergeFieldDefault = "*"
+)
+
+// TrustWeights weigh how much each provider is trusted for each merge
+// field, e.g. {"screenscraper": {"artwork": 2}, "igdb": {"summary": 2}}.
+// Providers and fields without a weight have a weight of 1.
+type TrustWeights map[string]map[string]float64
+
+// Weight returns the weight of a provider for a merge field.
+func (w TrustWeights) Weight(provider, field string) float64 {
+	fields := w[provider]
+	if weight, ok := fields[field]; ok {
+		return weight
+	}
+	if weight, ok := fields[MergeFieldDefault]; ok {
+		return weight
+	}
+	return 1
+}
+
+// mergeField is a field, or fields chosen together, of a merged result.
+type mergeField struct {
+	// key is the merge field weighing the providers
+	key string
+	// has reports whether a result has the field
+	has func(r *GameResult) bool
+	// copy copies the field from src to dst
+	copy func(dst, src *GameResult)
+}
+
+// mergeFields are the fields MergeResults picks a provider for.
+var mergeFields = []mergeField{
+	{MergeFieldName, func(r *GameResult) bool { return r.Name != "" }, func(dst, src *GameResult) { dst.Name = src.Name }},
+	{MergeFieldSummary, func(r *GameResult) bool { return r.Summary != "" }, func(dst, src *GameResult) {
+		dst.Summary, dst.Language = src.Summary, src.Language
+	}},
+	{MergeFieldArtwork, func(r *GameResult) bool { return r.Artwork.CoverURL != "" }, func(dst, src *GameResult) { dst.Artwork.CoverURL = src.Artwork.CoverURL }},
+	{MergeFieldArtwork, func(r *GameResult) bool { return len(r.Artwork.ScreenshotURLs) > 0 }, func(dst, src *GameResult) { dst.Artwork.ScreenshotURLs = src.Artwork.ScreenshotURLs }},
+	{MergeFieldArtwork, func(r *GameResult) bool { return r.Artwork.BannerURL != "" }, func(dst, src *GameResult) { dst.Artwork.BannerURL = src.Artwork.BannerURL }},
+	{MergeFieldArtwork, func(r *GameResult) bool { return r.Artwork.IconURL != "" }, func(dst, src *GameResult) { dst.Artwork.IconURL = src.Artwork.IconURL }},
+	{MergeFieldArtwork, func(r *GameResult) bool { return r.Artwork.LogoURL != "" }, func(dst, src *GameResult) { dst.Artwork.LogoURL = src.Artwork.LogoURL }},
+	{MergeFieldArtwork, func(r *GameResult) bool { return r.Artwork.BackgroundURL != "" }, func(dst, src *GameResult) { dst.Artwork.BackgroundURL = src.Artwork.BackgroundURL }},
+	{MergeFieldArtwork, func(r *GameResult) bool { return r.Artwork.ManualURL != "" }, func(dst, src *GameResult) { dst.Artwork.ManualURL = src.Artwork.ManualURL }},
+	{MergeFieldArtwork, func(r *GameResult) bool { return len(r.Artwork.MapURLs) > 0 }, func(dst, src *GameResult) { dst.Artwork.MapURLs = src.Artwork.MapURLs }},
+	{MergeFieldArtwork, func(r *GameResult) bool { return len(r.Artwork.Media) > 0 }, func(dst, src *GameResult) { dst.Artwork.Media = src.Artwork.Media }},
+	{MergeFieldReleaseDate, func(r *GameResult) bool {
+		return r.Metadata.FirstReleaseDate != nil || r.Metadata.ReleaseYear != nil
+	}, func(dst, src *GameResult) {
+		dst.Metadata.FirstReleaseDate, dst.Metadata.ReleaseYear = src.Metadata.FirstReleaseDate, src.Metadata.ReleaseYear
+	}},
+	{MergeFieldReleaseDate, func(r *GameResult) bool { return len(r.Metadata.Releases) > 0 }, func(dst, src *GameResult) { dst.Metadata.Releases = src.Metadata.Releases }},
+	{MergeFieldRating, func(r *GameResult) bool { return r.Metadata.TotalRating != nil }, func(dst, src *GameResult) { dst.Metadata.TotalRating = src.Metadata.TotalRating }},
+	{MergeFieldRating, func(r *GameResult) bool { return r.Metadata.AggregatedRating != nil }, func(dst, src *GameResult) { dst.Metadata.AggregatedRating = src.Metadata.AggregatedRating }},
+	{MergeFieldGenres, func(r *GameResult) bool { return len(r.Metadata.Genres) > 0 }, func(dst, src *GameResult) { dst.Metadata.Genres = src.Metadata.Genres }},
+	{MergeFieldGenres, func(r *GameResult) bool { return len(r.Metadata.Themes) > 0 }, func(dst, src *GameResult) { dst.Metadata.Themes = src.Metadata.Themes }},
+	{MergeFieldCompanies, func(r *GameResult) bool { return r.Metadata.Developer != "" }, func(dst, src *GameResult) { dst.Metadata.Developer = src.Metadata.Developer }},
+	{MergeFieldCompanies, func(r *GameResult) bool { return r.Metadata.Publisher != "" }, func(dst, src *GameResult) { dst.Metadata.Publisher = src.Metadata.Publisher }},
+	{MergeFieldCompanies, func(r *GameResult) bool { return len(r.Metadata.Companies) > 0 }, func(dst, src *GameResult) { dst.Metadata.Companies = src.Metadata.Companies }},
+	{MergeFieldCompanies, func(r *GameResult) bool { return len(r.Metadata.InvolvedCompanies) > 0 }, func(dst, src *GameResult) { dst.Metadata.InvolvedCompanies = src.Metadata.InvolvedCompanies }},
+	{MergeFieldMetadata, func(r *GameResult) bool { return r.Metadata.YouTubeVideoID != "" }, func(dst, src *GameResult) { dst.Metadata.YouTubeVideoID = src.Metadata.YouTubeVideoID }},
+	{MergeFieldMetadata, func(r *GameResult) bool { return len(r.Achievements) > 0 }, func(dst, src *GameResult) { dst.Achievements = src.Achievements }},
+	{MergeFieldMetadata, func(r *GameResult) bool { return len(r.Metadata.Videos) > 0 }, func(dst, src *GameResult) { dst.Metadata.Videos = src.Metadata.Videos }},
+	{MergeFieldMetadata, func(r *GameResult) bool { return len(r.Metadata.Franchises) > 0 }, func(dst, src *GameResult) { dst.Metadata.Franchises = src.Metadata.Franchises }},
+	{MergeFieldMetadata, func(r *GameResult) bool { return len(r.Metadata.AlternativeNames) > 0 }, func(dst, src *GameResult) { dst.Metadata.AlternativeNames = src.Metadata.AlternativeNames }},
+	{MergeFieldMetadata, func(r *GameResult) bool { return len(r.Metadata.Collections) > 0 }, func(dst, src *GameResult) { dst.Metadata.Collections = src.Metadata.Collections }},
+	{MergeFieldMetadata, func(r *GameResult) bool { return len(r.Metadata.GameModes) > 0 }, func(dst, src *GameResult) { dst.Metadata.GameModes = src.Metadata.GameModes }},
+	{MergeFieldMetadata, func(r *GameResult) bool { return len(r.Metadata.Keywords) > 0 }, func(dst, src *GameResult) { dst.Metadata.Keywords = src.Metadata.Keywords }},
+	{MergeFieldMetadata, func(r *GameResult) bool { return len(r.Metadata.PlayerPerspectives) > 0 }, func(dst, src *GameResult) { dst.Metadata.PlayerPerspectives = src.Metadata.PlayerPerspectives }},
+	{MergeFieldMetadata, func(r *GameResult) bool { return len(r.Metadata.Languages) > 0 }, func(dst, src *GameResult) { dst.Metadata.Languages = src.Metadata.Languages }},
+	{MergeFieldMetadata, func(r *GameResult) bool { return len(r.Metadata.AgeRatings) > 0 }, func(dst, src *GameResult) { dst.Metadata.AgeRatings = src.Metadata.AgeRatings }},
+	{MergeFieldMetadata, func(r *GameResult) bool { return len(r.Metadata.Platforms) > 0 }, func(dst, src *GameResult) { dst.Metadata.Platforms = src.Metadata.Platforms }},
+	{MergeFieldMetadata, func(r *GameResult) bool { return len(r.Metadata.MultiplayerModes) > 0 }, func(dst, src *GameResult) { dst.Metadata.MultiplayerModes = src.Metadata.MultiplayerModes }},
+	{MergeFieldMetadata, func(r *GameResult) bool { return r.Metadata.PlayerCount != "" }, func(dst, src *GameResult) { dst.Metadata.PlayerCount = src.Metadata.PlayerCount }},
+}
+
+// confidence returns how sure a provider is of its match: its MatchScore,
+// or 1 for matches without one, like hash matches.
+func confidence(r *GameResult) float64 {
+	if r.MatchScore > 0 {
+		return r.MatchScore
+	}
+	return 1
+}
+
+// MergeResults merges the results providers returned for the same game,
+// in priority order. Each field is taken from the result with the highest
+// weight for it times match score, the highest priority one on ties; the
+// rest, like the provider and match type, are the highest priority
+// result's. Provider IDs are combined. Returns nil for no results.
+func MergeResults(results []*GameResult, weights TrustWeights) *GameResult {
+	var present []*GameResult
+	for _, r := range results {
+		if r != nil {
+			present = append(present, r)
+		}
+	}
+	if len(present) == 0 {
+		return nil
+	}
+
+	merged := *present[0]
+	merged.ProviderIDs = make(map[string]int)
+	merged.ProviderUIDs = make(map[string]string)
+	// Lower priority IDs go first, for higher priority ones to replace
+	for _, r := range slices.Backward(present) {
+		maps.Copy(merged.ProviderIDs, r.ProviderIDs)
+		maps.Copy(merged.ProviderUIDs, r.ProviderUIDs)
+		if r.ProviderID != nil && r.Provider != "" {
+			merged.ProviderIDs[r.Provider] = *r.ProviderID
+		}
+		if r.ProviderUID != "" && r.Provider != "" {
+			merged.ProviderUIDs[r.Provider] = r.ProviderUID
+		}
+	}
+	if len(merged.ProviderUIDs) == 0 {
+		merged.ProviderUIDs = nil
+	}
+
+	for _, field := range mergeFields {
+		var best *GameResult
+		var bestScore float64
+		for _, r := range present {
+			if !field.has(r) {
+				continue
+			}
+			if score := weights.Weight(r.Provider, field.key) * confidence(r); best == nil || score > bestScore {
+				best, bestScore = r, score
+			}
+		}
+		if best != nil {
+			field.copy(&merged, best)
+		}
+	}
+	return &merged
+}
+
+// IdentifyMerged identifies a game with every selected provider and merges
+// their matches with MergeResults, weighing them by Config.TrustWeights.
+// Matches scoring below Config.MinMatchScore are left out.
+func (c *Client) IdentifyMerged(ctx context.Context, filename string, opts IdentifyOptions) (*GameResult, error) {
+	if result, ok, err := c.override(ctx, filename, opts.Hashes); ok {
+		return result, err
+	}
+	if opts.Serial == "" {
+		opts.Serial = serials.Extract(filename, platform.DetectFromPath(filename))
+	}
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	ctx = WithAmbiguityHandler(ctx, opts)
+
+	var rejects rejected
+	minMatchScore := c.config.MinMatchScore
+	identify := func(ctx context.Context, t target) (*GameResult, error) {
+		result, err := t.provider.Identify(ctx, filename, opts)
+		if err == nil {
+			result, err = rejects.check(result, filename, minMatchScore)
+		}
+		return result, err
+	}
+
+	var results []*GameResult
+	for _, o := range fanOut(c, ctx, c.targets(c.selectProviders(opts.Providers, opts.ExcludeProviders)), identify, nil, false) {
+		if o.done && o.err == nil && o.value != nil {
+			results = append(results, o.value)
+		}
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	merged := MergeResults(results, c.config.TrustWeights)
+	if merged == nil {
+		return nil, rejects.notFound(filename)
+	}
+	return c.identified(ctx, merged), nil
+}
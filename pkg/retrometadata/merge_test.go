@@ -0,0 +1,99 @@
+package retrometadata
+
+import (
+	"context"
+	"testing"
+
+	"github.com/josegonzalez/retro-metadata/pkg/cache"
+)
+
+func TestMergeResults(t *testing.T) {
+	igdbID, ssID := 1, 2
+	year := 1991
+	igdb := &GameResult{
+		Name:       "Super Mario World",
+		Summary:    "IGDB summary",
+		Provider:   "igdb",
+		ProviderID: &igdbID,
+		MatchScore: 0.9,
+		Artwork:    Artwork{CoverURL: "igdb-cover"},
+	}
+	screenscraper := &GameResult{
+		Name:       "Super Mario World (USA)",
+		Summary:    "ScreenScraper summary",
+		Provider:   "screenscraper",
+		ProviderID: &ssID,
+		Artwork:    Artwork{CoverURL: "ss-cover", LogoURL: "ss-logo"},
+		Metadata:   GameMetadata{ReleaseYear: &year},
+	}
+
+	weights := TrustWeights{
+		"screenscraper": {MergeFieldArtwork: 2, MergeFieldDefault: 0.5},
+		"igdb":          {MergeFieldSummary: 2},
+	}
+	merged := MergeResults([]*GameResult{igdb, nil, screenscraper}, weights)
+
+	// IGDB wins the name (1 x 0.9 against 0.5 x 1) and the summary, and
+	// ScreenScraper the artwork
+	if merged.Name != "Super Mario World" || merged.Summary != "IGDB summary" {
+		t.Errorf("Merged name and summary = %q, %q", merged.Name, merged.Summary)
+	}
+	if merged.Artwork.CoverURL != "ss-cover" || merged.Artwork.LogoURL != "ss-logo" {
+		t.Errorf("Merged artwork = %+v", merged.Artwork)
+	}
+	// Fields only one provider has are always taken
+	if merged.Metadata.ReleaseYear == nil || *merged.Metadata.ReleaseYear != 1991 {
+		t.Errorf("Merged release year = %v", merged.Metadata.ReleaseYear)
+	}
+	if merged.Provider != "igdb" || merged.ProviderIDs["igdb"] != 1 || merged.ProviderIDs["screenscraper"] != 2 {
+		t.Errorf("Merged provider = %q, IDs %v", merged.Provider, merged.ProviderIDs)
+	}
+	// The inputs aren't changed
+	if igdb.Artwork.CoverURL != "igdb-cover" || igdb.ProviderIDs != nil {
+		t.Errorf("MergeResults changed its input: %+v", igdb)
+	}
+
+	// Without weights, the higher priority result wins ties
+	if merged := MergeResults([]*GameResult{screenscraper, igdb}, nil); merged.Summary != "ScreenScraper summary" {
+		t.Errorf("Unweighted summary = %q", merged.Summary)
+	}
+	if MergeResults(nil, weights) != nil {
+		t.Error("Expected nil for no results")
+	}
+}
+
+// summaryProvider returns fakeProvider matches with a summary naming the
+// provider.
+type summaryProvider struct {
+	fakeProvider
+}
+
+func (p *summaryProvider) Identify(ctx context.Context, filename string, opts IdentifyOptions) (*GameResult, error) {
+	result, err := p.fakeProvider.Identify(ctx, filename, opts)
+	if result != nil {
+		result.Summary = p.name + " summary"
+	}
+	return result, err
+}
+
+func TestClientIdentifyMerged(t *testing.T) {
+	for _, name := range []string{"mobygames", "hltb"} {
+		RegisterProvider(name, func(ProviderConfig, cache.Cache) (Provider, error) {
+			return &summaryProvider{fakeProvider{name: name}}, nil
+		})
+	}
+
+	client, err := NewClient(WithMobyGames("key"), WithHLTB(), WithTrustWeights(TrustWeights{"hltb": {MergeFieldSummary: 2}}))
+	if err != nil {
+		t.Fatalf("NewClient() error: %v", err)
+	}
+	defer client.Close()
+
+	result, err := client.IdentifyMerged(context.Background(), "Game.sfc", IdentifyOptions{})
+	if err != nil {
+		t.Fatalf("IdentifyMerged() error: %v", err)
+	}
+	if result.Provider != "mobygames" || result.Summary != "hltb summary" {
+		t.Errorf("IdentifyMerged() = %+v, expected the mobygames result with the hltb summary", result)
+	}
+}
@@ -0,0 +1,129 @@
+package retrometadata
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// MatchTypeOverride is the GameResult.MatchType of games identified by an
+// override.
+const MatchTypeOverride = "override"
+
+// Override pins a game to a provider's ID, for files providers keep
+// mismatching.
+type Override struct {
+	// Provider is the provider name
+	Provider string `json:"provider"`
+	// ID is the provider-specific game ID, as used with Client.GetByUID.
+	// It may be written as a JSON number or string
+	ID string `json:"id"`
+}
+
+// UnmarshalJSON accepts IDs written as JSON numbers as well as strings.
+func (o *Override) UnmarshalJSON(data []byte) error {
+	var raw struct {
+		Provider string          `json:"provider"`
+		ID       json.RawMessage `json:"id"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	o.Provider = raw.Provider
+	o.ID = string(bytes.Trim(raw.ID, `"`))
+	return nil
+}
+
+// Overrides are user-maintained game identifications, consulted by the
+// Client before any provider.
+type Overrides struct {
+	// Filenames maps file names, without directories and compared
+	// case-insensitively, to their games
+	Filenames map[string]Override `json:"filenames,omitempty"`
+	// Hashes maps MD5, SHA1, CRC32 or SHA256 hashes to their games
+	Hashes map[string]Override `json:"hashes,omitempty"`
+}
+
+// LoadOverrides reads a JSON overrides file.
+func LoadOverrides(path string) (Overrides, error) {
+	var overrides Overrides
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return overrides, err
+	}
+	if err := json.Unmarshal(data, &overrides); err != nil {
+		return overrides, fmt.Errorf("parsing overrides %s: %w", path, err)
+	}
+	return overrides, nil
+}
+
+// Merge returns the overrides with those of other added, taking precedence.
+func (o Overrides) Merge(other Overrides) Overrides {
+	merged := Overrides{
+		Filenames: maps.Clone(o.Filenames),
+		Hashes:    maps.Clone(o.Hashes),
+	}
+	if merged.Filenames == nil && other.Filenames != nil {
+		merged.Filenames = make(map[string]Override)
+	}
+	if merged.Hashes == nil && other.Hashes != nil {
+		merged.Hashes = make(map[string]Override)
+	}
+	maps.Copy(merged.Filenames, other.Filenames)
+	maps.Copy(merged.Hashes, other.Hashes)
+	return merged
+}
+
+// Lookup returns the override for a file, by its hashes if given, then by
+// its name.
+func (o Overrides) Lookup(filename string, hashes *FileHashes) (Override, bool) {
+	if hashes != nil {
+		for _, hash := range []string{hashes.SHA256, hashes.SHA1, hashes.MD5, hashes.CRC32} {
+			if hash == "" {
+				continue
+			}
+			for key, override := range o.Hashes {
+				if strings.EqualFold(key, hash) {
+					return override, true
+				}
+			}
+		}
+	}
+	if filename != "" {
+		base := filepath.Base(filename)
+		for key, override := range o.Filenames {
+			if strings.EqualFold(key, base) {
+				return override, true
+			}
+		}
+	}
+	return Override{}, false
+}
+
+// override identifies a file from the configured overrides. It reports
+// whether the file has one; if it does, the result is the overriding game
+// or the error getting it. Callers mustn't hold c.mu.
+func (c *Client) override(ctx context.Context, filename string, hashes *FileHashes) (*GameResult, bool, error) {
+	c.mu.RLock()
+	overrides := c.config.Overrides
+	c.mu.RUnlock()
+
+	override, ok := overrides.Lookup(filename, hashes)
+	if !ok {
+		return nil, false, nil
+	}
+	result, err := c.GetByUID(ctx, override.Provider, override.ID)
+	if err != nil {
+		return nil, true, err
+	}
+	if result == nil {
+		return nil, true, &GameNotFoundError{SearchTerm: override.ID, Provider: override.Provider}
+	}
+	result.MatchType = MatchTypeOverride
+	return result, true, nil
+}
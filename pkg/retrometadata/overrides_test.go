@@ -0,0 +1,102 @@
+package retrometadata
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/josegonzalez/retro-metadata/pkg/cache"
+)
+
+// idProvider returns a game for any ID, and fails the test if asked to
+// identify.
+type idProvider struct {
+	fakeProvider
+	t *testing.T
+}
+
+func (p *idProvider) GetByID(_ context.Context, gameID int) (*GameResult, error) {
+	return &GameResult{Name: "Game", Provider: p.name, ProviderID: &gameID}, nil
+}
+
+func (p *idProvider) Identify(context.Context, string, IdentifyOptions) (*GameResult, error) {
+	p.t.Error("Identify() called for an overridden file")
+	return nil, nil
+}
+
+func TestOverrides(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "overrides.json")
+	data := `{
+		"filenames": {"Tetris (World).gb": {"provider": "mobygames", "id": 100}},
+		"hashes": {"ABCDEF": {"provider": "mobygames", "id": "200"}}
+	}`
+	if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	overrides, err := LoadOverrides(path)
+	if err != nil {
+		t.Fatalf("LoadOverrides() error: %v", err)
+	}
+	if got := overrides.Filenames["Tetris (World).gb"]; got.ID != "100" {
+		t.Errorf("numeric ID loaded as %q, want 100", got.ID)
+	}
+
+	moby := &idProvider{fakeProvider: fakeProvider{name: "mobygames"}, t: t}
+	RegisterProvider("mobygames", func(ProviderConfig, cache.Cache) (Provider, error) { return moby, nil })
+	config := DefaultConfig()
+	config.MobyGames = ProviderConfig{Enabled: true, Priority: 1, Credentials: map[string]string{"api_key": "key"}}
+	config.OverridesFile = path
+	config.Overrides = Overrides{Filenames: map[string]Override{"Doom.zip": {Provider: "igdb", ID: "1"}}}
+	client, err := NewClient(WithConfig(config))
+	if err != nil {
+		t.Fatalf("NewClient() error: %v", err)
+	}
+	defer client.Close()
+	ctx := context.Background()
+
+	tests := []struct {
+		name     string
+		identify func() (*GameResult, error)
+		wantID   int
+	}{
+		{"filename", func() (*GameResult, error) {
+			return client.Identify(ctx, "/roms/gb/TETRIS (WORLD).GB", IdentifyOptions{})
+		}, 100},
+		{"hash", func() (*GameResult, error) {
+			return client.IdentifyByHash(ctx, FileHashes{MD5: "abcdef"}, IdentifyOptions{})
+		}, 200},
+		{"hash before filename", func() (*GameResult, error) {
+			return client.IdentifySmart(ctx, "Tetris (World).gb", &FileHashes{CRC32: "abcdef"}, IdentifyOptions{})
+		}, 200},
+		{"batch", func() (*GameResult, error) {
+			batch := client.IdentifyBatch(ctx, []IdentifyRequest{{Filename: "Tetris (World).gb"}})
+			return batch.Items[0].Result, batch.Items[0].Err
+		}, 100},
+		{"custom strategy", func() (*GameResult, error) {
+			return client.IdentifyWith(ctx, "Tetris (World).gb", IdentifyOptions{}, func(context.Context, []Provider) (*GameResult, error) {
+				t.Error("custom strategy run for an overridden file")
+				return nil, nil
+			})
+		}, 100},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := tt.identify()
+			if err != nil || result.ProviderID == nil || *result.ProviderID != tt.wantID || result.MatchType != MatchTypeOverride {
+				t.Errorf("got %+v, %v; want override to ID %d", result, err, tt.wantID)
+			}
+		})
+	}
+
+	// Overrides to providers that aren't enabled fail rather than fall back
+	if _, err := client.Identify(ctx, "doom.zip", IdentifyOptions{}); !errors.Is(err, ErrProviderNotFound) {
+		t.Errorf("Identify() of an override to a disabled provider error = %v, want ErrProviderNotFound", err)
+	}
+
+	config.OverridesFile = filepath.Join(t.TempDir(), "missing.json")
+	if _, err := NewClient(WithConfig(config)); !errors.Is(err, ErrInvalidConfig) {
+		t.Errorf("NewClient() with a missing overrides file error = %v, want ErrInvalidConfig", err)
+	}
+}
@@ -0,0 +1,199 @@
+package retrometadata
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/josegonzalez/retro-metadata/pkg/platform"
+	"github.com/josegonzalez/retro-metadata/pkg/serials"
+)
+
+// maxPrefetchRetries is how many times Prefetch retries a provider call
+// that was rate limited.
+const maxPrefetchRetries = 3
+
+// prefetchRetryDelay is how long Prefetch waits to retry a rate limited call
+// if the provider doesn't say.
+var prefetchRetryDelay = time.Second
+
+// PrefetchResult counts the outcomes of Prefetch, one per title and
+// provider.
+type PrefetchResult struct {
+	// Cached is the number of provider answers cached, matches or not
+	Cached int `json:"cached"`
+	// Skipped is the number already in the cache
+	Skipped int `json:"skipped"`
+	// Failed is the number of provider calls that failed
+	Failed int `json:"failed"`
+}
+
+// Prefetch warms the cache for titles on a platform, e.g. overnight, so
+// identifying them later with Identify doesn't wait on the providers. Each
+// title is identified with every enabled provider, with the provider's
+// platform ID for platformSlug, and the provider's answer is cached for the
+// cache TTL. Titles are the filenames or names that will be passed to
+// Identify later.
+//
+// Up to MaxConcurrentRequests provider calls are made at once, each
+// provider's rate limit applies, and rate limited calls are retried after
+// the delay the provider asks for. Provider errors are counted rather than
+// returned; the error is the context's, if it's done first.
+func (c *Client) Prefetch(ctx context.Context, platformSlug string, titles []string) (*PrefetchResult, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	type job struct {
+		name, title string
+	}
+	var jobs []job
+	for _, title := range titles {
+		for _, name := range c.selectProviders(nil, nil) {
+			jobs = append(jobs, job{name, title})
+		}
+	}
+
+	var (
+		result PrefetchResult
+		mu     sync.Mutex
+		wg     sync.WaitGroup
+	)
+	workers := c.config.MaxConcurrentRequests
+	if workers <= 0 || workers > len(jobs) {
+		workers = len(jobs)
+	}
+	queue := make(chan job)
+	for range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := range queue {
+				cached, err := c.prefetch(ctx, j.name, platform.Slug(platformSlug), j.title)
+				mu.Lock()
+				switch {
+				case err != nil:
+					result.Failed++
+				case cached:
+					result.Cached++
+				default:
+					result.Skipped++
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+	for _, j := range jobs {
+		if ctx.Err() != nil {
+			break
+		}
+		queue <- j
+	}
+	close(queue)
+	wg.Wait()
+
+	return &result, ctx.Err()
+}
+
+// prefetch caches a provider's answer for a title, unless it's cached
+// already. Callers must hold c.mu.
+func (c *Client) prefetch(ctx context.Context, name string, slug platform.Slug, title string) (bool, error) {
+	opts := IdentifyOptions{
+		PlatformID: platform.GetPlatformID(name, slug),
+		Serial:     serials.Extract(title, slug),
+	}
+	key := prefetchKey(name, slug, title)
+	if cached, err := c.cache.Get(ctx, key); err == nil && cached != nil {
+		return false, nil
+	}
+
+	for attempt := 0; ; attempt++ {
+		if err := c.acquire(ctx); err != nil {
+			return false, err
+		}
+		result, err := c.providers[name].Identify(ctx, title, opts)
+		c.release()
+
+		var rateLimited *RateLimitError
+		if errors.As(err, &rateLimited) && attempt < maxPrefetchRetries {
+			delay := time.Duration(rateLimited.RetryAfter) * time.Second
+			if delay <= 0 {
+				delay = prefetchRetryDelay
+			}
+			if err := sleep(ctx, delay); err != nil {
+				return false, err
+			}
+			continue
+		}
+		if err != nil {
+			return false, err
+		}
+
+		// A nil result is cached too, as the provider's answer that the
+		// title has no match
+		data, err := json.Marshal(result)
+		if err != nil {
+			return false, err
+		}
+		return true, c.cache.Set(ctx, key, data, 0)
+	}
+}
+
+// prefetched returns a provider's answer for a filename cached by Prefetch,
+// for the platform of opts.PlatformID or, without one, the platform detected
+// from the filename. Identifications with hashes, a trace or an ambiguity
+// handler aren't answered from the cache, as Prefetch doesn't use them.
+func (c *Client) prefetched(ctx context.Context, name, filename string, opts IdentifyOptions) (*GameResult, bool) {
+	if c.cache == nil || opts.Hashes != nil || opts.Trace || opts.OnAmbiguous != nil {
+		return nil, false
+	}
+	slug := platform.DetectFromPath(filename)
+	if opts.PlatformID != nil {
+		slug = platform.SlugFromProviderID(name, *opts.PlatformID)
+	}
+	return c.cachedResult(ctx, prefetchKey(name, slug, filename))
+}
+
+// cachedResult returns a result cached as JSON, which may be nil.
+func (c *Client) cachedResult(ctx context.Context, key string) (*GameResult, bool) {
+	cached, err := c.cache.Get(ctx, key)
+	if err != nil {
+		return nil, false
+	}
+	var data []byte
+	switch v := cached.(type) {
+	case []byte:
+		data = v
+	case string:
+		data = []byte(v)
+	default:
+		return nil, false
+	}
+	// Decoding copies the result, so callers can change it
+	var result *GameResult
+	if err := json.Unmarshal(data, &result); err != nil {
+		return nil, false
+	}
+	return result, true
+}
+
+// prefetchKey returns the cache key of a provider's answer for a file on a
+// platform. Files are keyed by name, so paths to the same file share it.
+func prefetchKey(name string, slug platform.Slug, filename string) string {
+	return fmt.Sprintf("identify:%s:%s:%s", name, slug, filepath.Base(filename))
+}
+
+// sleep waits for d or until ctx is done.
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,107 @@
+package retrometadata
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/josegonzalez/retro-metadata/pkg/cache"
+	"github.com/josegonzalez/retro-metadata/pkg/platform"
+)
+
+// countingProvider counts its Identify calls, matching every file but
+// "Missing.sfc", and is rate limited for its first rateLimited calls.
+type countingProvider struct {
+	fakeProvider
+	mu          sync.Mutex
+	calls       int
+	rateLimited int
+	platformIDs []*int
+}
+
+func (p *countingProvider) Identify(ctx context.Context, filename string, opts IdentifyOptions) (*GameResult, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.calls++
+	p.platformIDs = append(p.platformIDs, opts.PlatformID)
+	if p.rateLimited > 0 {
+		p.rateLimited--
+		return nil, &RateLimitError{Provider: p.name}
+	}
+	if filename == "Missing.sfc" {
+		return nil, nil
+	}
+	return &GameResult{Name: filename, Provider: p.name, MatchScore: 1, ProviderIDs: map[string]int{p.name: 1}}, nil
+}
+
+func TestClientPrefetch(t *testing.T) {
+	prefetchRetryDelay = time.Millisecond
+	defer func() { prefetchRetryDelay = time.Second }()
+
+	mobygames := &countingProvider{fakeProvider: fakeProvider{name: "mobygames"}, rateLimited: 1}
+	hltb := &countingProvider{fakeProvider: fakeProvider{name: "hltb"}}
+	for _, p := range []*countingProvider{mobygames, hltb} {
+		RegisterProvider(p.name, func(ProviderConfig, cache.Cache) (Provider, error) {
+			return p, nil
+		})
+	}
+
+	client, err := NewClient(WithMobyGames("key"), WithHLTB())
+	if err != nil {
+		t.Fatalf("NewClient() error: %v", err)
+	}
+	defer client.Close()
+
+	ctx := context.Background()
+	titles := []string{"Super Metroid (USA).sfc", "Missing.sfc"}
+	result, err := client.Prefetch(ctx, "snes", titles)
+	if err != nil {
+		t.Fatalf("Prefetch() error: %v", err)
+	}
+	if *result != (PrefetchResult{Cached: 4}) {
+		t.Errorf("Prefetch() = %+v, want 4 cached", *result)
+	}
+	// The rate limited call was retried
+	if mobygames.calls != 3 || hltb.calls != 2 {
+		t.Errorf("calls = %d, %d, want 3, 2", mobygames.calls, hltb.calls)
+	}
+	want := platform.GetMobyGamesPlatformID(platform.SlugSNES)
+	for _, id := range mobygames.platformIDs {
+		if id == nil || *id != *want {
+			t.Errorf("mobygames platform ID = %v, want %d", id, *want)
+		}
+	}
+
+	// Titles already cached aren't fetched again
+	result, err = client.Prefetch(ctx, "snes", titles)
+	if err != nil || *result != (PrefetchResult{Skipped: 4}) {
+		t.Errorf("Prefetch() again = %+v, %v, want 4 skipped", *result, err)
+	}
+
+	// Identify answers from the cache, for the platform detected from the path
+	mobygames.calls, hltb.calls = 0, 0
+	game, err := client.Identify(ctx, "roms/snes/Super Metroid (USA).sfc", IdentifyOptions{})
+	if err != nil || game.Name != "Super Metroid (USA).sfc" || game.Provider != "mobygames" {
+		t.Fatalf("Identify() = %+v, %v", game, err)
+	}
+	// Cached results are copies
+	game.ProviderIDs["igdb"] = 2
+	if _, err := client.Identify(ctx, "Missing.sfc", IdentifyOptions{}); err == nil {
+		t.Error("Identify(Missing.sfc) error = nil, want not found")
+	}
+	game, _ = client.Identify(ctx, "Super Metroid (USA).sfc", IdentifyOptions{})
+	if len(game.ProviderIDs) != 1 {
+		t.Errorf("ProviderIDs = %v, want the cached IDs", game.ProviderIDs)
+	}
+	if mobygames.calls != 0 || hltb.calls != 0 {
+		t.Errorf("calls = %d, %d, want none", mobygames.calls, hltb.calls)
+	}
+
+	// Other platforms and traced identifications ask the providers
+	_, _ = client.Identify(ctx, "Super Metroid (USA).sfc", IdentifyOptions{PlatformID: platform.GetMobyGamesPlatformID(platform.SlugGenesis)})
+	_, _ = client.Identify(ctx, "Super Metroid (USA).sfc", IdentifyOptions{Trace: true})
+	if mobygames.calls != 2 {
+		t.Errorf("mobygames calls = %d, want 2", mobygames.calls)
+	}
+}
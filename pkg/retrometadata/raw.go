@@ -0,0 +1,127 @@
+package retrometadata
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"unicode/utf8"
+)
+
+// Raw response modes.
+const (
+	// RawKeep keeps the provider payloads on results (the default)
+	RawKeep = "keep"
+	// RawOmit drops the provider payloads
+	RawOmit = "omit"
+	// RawTruncate replaces payloads larger than MaxBytes with a truncated copy
+	RawTruncate = "truncate"
+	// RawDump writes the payloads to files in Dir and leaves their path on
+	// the result, for debugging without holding them in memory
+	RawDump = "dump"
+)
+
+// DefaultRawMaxBytes is the size payloads are truncated to by default.
+const DefaultRawMaxBytes = 4096
+
+// RawConfig controls the raw provider payloads kept in GameResult.RawResponse
+// and GameMetadata.RawData, which can take a lot of memory when scanning
+// thousands of games.
+type RawConfig struct {
+	// Mode is RawKeep, RawOmit, RawTruncate or RawDump (default RawKeep)
+	Mode string `json:"mode,omitempty"`
+	// MaxBytes is the JSON size payloads are truncated to with RawTruncate
+	// (default DefaultRawMaxBytes)
+	MaxBytes int `json:"max_bytes,omitempty"`
+	// Dir is the directory payloads are written to with RawDump
+	Dir string `json:"dir,omitempty"`
+}
+
+// unsafeFilenameChars matches characters left out of dump file names.
+var unsafeFilenameChars = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// Apply omits, truncates or dumps the raw payloads of a result. With
+// RawDump, RawResponse is replaced by {"file": path} and RawData is dropped;
+// if the file can't be written the payloads are left as they are and the
+// error is returned.
+func (r RawConfig) Apply(result *GameResult) error {
+	if result == nil || (result.RawResponse == nil && result.Metadata.RawData == nil) {
+		return nil
+	}
+
+	switch r.Mode {
+	case RawOmit:
+		result.RawResponse = nil
+		result.Metadata.RawData = nil
+	case RawTruncate:
+		maxBytes := r.MaxBytes
+		if maxBytes <= 0 {
+			maxBytes = DefaultRawMaxBytes
+		}
+		result.RawResponse = truncateRaw(result.RawResponse, maxBytes)
+		result.Metadata.RawData = truncateRaw(result.Metadata.RawData, maxBytes)
+	case RawDump:
+		path, err := r.dump(result)
+		if err != nil {
+			return err
+		}
+		result.RawResponse = map[string]any{"file": path}
+		result.Metadata.RawData = nil
+	}
+	return nil
+}
+
+// dump writes the payloads of a result to a file named after its provider
+// and ID, and returns the file's path.
+func (r RawConfig) dump(result *GameResult) (string, error) {
+	if r.Dir == "" {
+		return "", fmt.Errorf("%w: raw_responses.dir is required to dump raw responses", ErrInvalidConfig)
+	}
+
+	id := result.ProviderUID
+	if id == "" && result.ProviderID != nil {
+		id = strconv.Itoa(*result.ProviderID)
+	}
+	if id == "" {
+		id = result.Name
+	}
+	name := unsafeFilenameChars.ReplaceAllString(result.Provider+"-"+id, "_") + ".json"
+
+	data, err := json.MarshalIndent(map[string]any{
+		"raw_response": result.RawResponse,
+		"raw_data":     result.Metadata.RawData,
+	}, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(r.Dir, 0o755); err != nil {
+		return "", err
+	}
+	path := filepath.Join(r.Dir, name)
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		return "", err
+	}
+	return path, nil
+}
+
+// truncateRaw returns raw if its JSON fits in maxBytes, otherwise a map with
+// the start of its JSON and its full size.
+func truncateRaw(raw map[string]any, maxBytes int) map[string]any {
+	if raw == nil {
+		return nil
+	}
+	data, err := json.Marshal(raw)
+	if err != nil || len(data) <= maxBytes {
+		return raw
+	}
+	for maxBytes > 0 && !utf8.RuneStart(data[maxBytes]) {
+		maxBytes--
+	}
+	return map[string]any{
+		"truncated": true,
+		"size":      len(data),
+		"json":      string(data[:maxBytes]),
+	}
+}
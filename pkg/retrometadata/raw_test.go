@@ -0,0 +1,94 @@
+package retrometadata
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/josegonzalez/retro-metadata/pkg/cache"
+)
+
+func rawResult() *GameResult {
+	id := 1018
+	raw := map[string]any{"name": "Super Mario World", "summary": strings.Repeat("a", 100)}
+	return &GameResult{
+		Name:        "Super Mario World",
+		Provider:    "igdb",
+		ProviderID:  &id,
+		RawResponse: raw,
+		Metadata:    GameMetadata{RawData: raw},
+	}
+}
+
+func TestRawConfigApply(t *testing.T) {
+	result := rawResult()
+	if err := (RawConfig{}).Apply(result); err != nil || result.RawResponse == nil {
+		t.Errorf("Keep: RawResponse = %v, %v; want the payload kept", result.RawResponse, err)
+	}
+
+	result = rawResult()
+	if err := (RawConfig{Mode: RawOmit}).Apply(result); err != nil || result.RawResponse != nil || result.Metadata.RawData != nil {
+		t.Errorf("Omit: RawResponse = %v, RawData = %v, %v", result.RawResponse, result.Metadata.RawData, err)
+	}
+
+	result = rawResult()
+	if err := (RawConfig{Mode: RawTruncate, MaxBytes: 20}).Apply(result); err != nil {
+		t.Fatal(err)
+	}
+	if result.RawResponse["truncated"] != true || len(result.RawResponse["json"].(string)) != 20 {
+		t.Errorf("Truncate: RawResponse = %v", result.RawResponse)
+	}
+
+	dir := t.TempDir()
+	result = rawResult()
+	if err := (RawConfig{Mode: RawDump, Dir: dir}).Apply(result); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, "igdb-1018.json")
+	if result.RawResponse["file"] != path || result.Metadata.RawData != nil {
+		t.Errorf("Dump: RawResponse = %v, RawData = %v", result.RawResponse, result.Metadata.RawData)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var dumped map[string]map[string]any
+	if err := json.Unmarshal(data, &dumped); err != nil || dumped["raw_response"]["name"] != "Super Mario World" {
+		t.Errorf("Dumped %s, %v", data, err)
+	}
+
+	// Without a directory the payloads are kept
+	result = rawResult()
+	if err := (RawConfig{Mode: RawDump}).Apply(result); err == nil || result.RawResponse["name"] == nil {
+		t.Errorf("Dump without a directory: %v, RawResponse = %v", err, result.RawResponse)
+	}
+}
+
+// rawProvider returns results with raw payloads.
+type rawProvider struct {
+	fakeProvider
+}
+
+func (p *rawProvider) Identify(context.Context, string, IdentifyOptions) (*GameResult, error) {
+	return rawResult(), nil
+}
+
+func TestClientRawResponses(t *testing.T) {
+	RegisterProvider("mobygames", func(ProviderConfig, cache.Cache) (Provider, error) {
+		return &rawProvider{fakeProvider{name: "mobygames"}}, nil
+	})
+
+	client, err := NewClient(WithMobyGames("key"), WithRawResponses(RawConfig{Mode: RawOmit}))
+	if err != nil {
+		t.Fatalf("NewClient() error: %v", err)
+	}
+	defer client.Close()
+
+	result, err := client.Identify(context.Background(), "Super Mario World.sfc", IdentifyOptions{})
+	if err != nil || result.RawResponse != nil || result.Metadata.RawData != nil {
+		t.Errorf("Identify() = %+v, %v; want the raw payloads omitted", result, err)
+	}
+}
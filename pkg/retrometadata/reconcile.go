@@ -0,0 +1,113 @@
+package retrometadata
+
+import (
+	"context"
+	"errors"
+	"maps"
+)
+
+// IDLinker is an optional interface for providers that can find a game's
+// IDs from the IDs other providers gave it, without searching by name.
+type IDLinker interface {
+	Provider
+
+	// LinkIDs returns the IDs the provider knows for the game with the
+	// given IDs: its own, and other providers' or stores' it links to. It
+	// returns nil maps for games it doesn't know.
+	LinkIDs(ctx context.Context, ids map[string]int, uids map[string]string) (map[string]int, map[string]string, error)
+}
+
+// ReconcileIDs adds the IDs other providers have for the game in result to
+// its ProviderIDs and ProviderUIDs, asking the providers implementing
+// IDLinker until none finds another. IDs already on the result are kept.
+// Errors are returned joined, after adding the IDs the other providers
+// found.
+func (c *Client) ReconcileIDs(ctx context.Context, result *GameResult) error {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.reconcile(ctx, result)
+}
+
+// reconcile implements ReconcileIDs. Callers must hold c.mu.
+func (c *Client) reconcile(ctx context.Context, result *GameResult) error {
+	if result == nil {
+		return nil
+	}
+	if result.ProviderIDs == nil {
+		result.ProviderIDs = make(map[string]int)
+	}
+	if result.ProviderUIDs == nil {
+		result.ProviderUIDs = make(map[string]string)
+	}
+	if result.ProviderID != nil && result.Provider != "" {
+		addID(result.ProviderIDs, result.Provider, *result.ProviderID)
+	}
+	if result.ProviderUID != "" && result.Provider != "" {
+		addID(result.ProviderUIDs, result.Provider, result.ProviderUID)
+	}
+
+	var linkers []IDLinker
+	for _, name := range c.selectProviders(nil, nil) {
+		if linker, ok := c.providers[name].(IDLinker); ok {
+			linkers = append(linkers, linker)
+		}
+	}
+
+	// Each linker is asked again while the others find IDs it hasn't seen
+	asked := make(map[string]int, len(linkers))
+	var errs []error
+	for {
+		found := false
+		for _, linker := range linkers {
+			known := len(result.ProviderIDs) + len(result.ProviderUIDs)
+			if count, ok := asked[linker.Name()]; ok && count == known {
+				continue
+			}
+			asked[linker.Name()] = known
+
+			if err := c.acquire(ctx); err != nil {
+				return errors.Join(append(errs, err)...)
+			}
+			ids, uids, err := linker.LinkIDs(ctx, maps.Clone(result.ProviderIDs), maps.Clone(result.ProviderUIDs))
+			c.release()
+			if err != nil {
+				errs = append(errs, &ProviderError{Provider: linker.Name(), Err: err})
+				continue
+			}
+			for name, id := range ids {
+				found = addID(result.ProviderIDs, name, id) || found
+			}
+			for name, uid := range uids {
+				found = addID(result.ProviderUIDs, name, uid) || found
+			}
+		}
+		if !found {
+			break
+		}
+	}
+
+	if len(result.ProviderUIDs) == 0 {
+		result.ProviderUIDs = nil
+	}
+	return errors.Join(errs...)
+}
+
+// addID adds an ID that isn't known yet, reporting whether it did.
+func addID[T comparable](ids map[string]T, name string, id T) bool {
+	var zero T
+	if _, ok := ids[name]; ok || id == zero {
+		return false
+	}
+	ids[name] = id
+	return true
+}
+
+// identified finishes a match: its IDs are reconciled if
+// Config.ReconcileIDs is set, and the RawResponses config is applied.
+// Callers must hold c.mu.
+func (c *Client) identified(ctx context.Context, result *GameResult) *GameResult {
+	if c.config.ReconcileIDs {
+		_ = c.reconcile(ctx, result)
+	}
+	return c.withRaw(result)
+}
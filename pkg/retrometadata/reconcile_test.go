@@ -0,0 +1,115 @@
+package retrometadata
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/josegonzalez/retro-metadata/pkg/cache"
+)
+
+// linkProvider links the IDs in links: a provider ID to the IDs it links to.
+type linkProvider struct {
+	fakeProvider
+	links map[string]map[string]int
+	calls int
+}
+
+func (p *linkProvider) Identify(ctx context.Context, filename string, opts IdentifyOptions) (*GameResult, error) {
+	result, err := p.fakeProvider.Identify(ctx, filename, opts)
+	id := 7
+	result.ProviderID = &id
+	return result, err
+}
+
+func (p *linkProvider) LinkIDs(_ context.Context, ids map[string]int, _ map[string]string) (map[string]int, map[string]string, error) {
+	p.calls++
+	for name, id := range ids {
+		if linked, ok := p.links[name]; ok && linked[name] == id {
+			return linked, map[string]string{"epic": "uid"}, nil
+		}
+	}
+	return nil, nil, nil
+}
+
+func TestClientReconcileIDs(t *testing.T) {
+	// mobygames knows its game by its own ID, and hltb by the steam ID
+	// mobygames links to
+	mobygames := &linkProvider{fakeProvider: fakeProvider{name: "mobygames"}, links: map[string]map[string]int{
+		"mobygames": {"mobygames": 7, "steam": 292030},
+	}}
+	hltb := &linkProvider{fakeProvider: fakeProvider{name: "hltb"}, links: map[string]map[string]int{
+		"steam": {"steam": 1, "hltb": 42},
+	}}
+	for _, p := range []*linkProvider{mobygames, hltb} {
+		RegisterProvider(p.name, func(ProviderConfig, cache.Cache) (Provider, error) {
+			return p, nil
+		})
+	}
+
+	client, err := NewClient(WithMobyGames("key"), WithHLTB(), WithReconcileIDs())
+	if err != nil {
+		t.Fatalf("NewClient() error: %v", err)
+	}
+	defer client.Close()
+
+	result, err := client.Identify(context.Background(), "Game.sfc", IdentifyOptions{})
+	if err != nil {
+		t.Fatalf("Identify() error: %v", err)
+	}
+	// IDs already known aren't replaced
+	want := map[string]int{"mobygames": 7, "steam": 292030}
+	for name, id := range want {
+		if result.ProviderIDs[name] != id {
+			t.Errorf("ProviderIDs[%s] = %d, want %d", name, result.ProviderIDs[name], id)
+		}
+	}
+	if len(result.ProviderIDs) != 2 || result.ProviderUIDs["epic"] != "uid" {
+		t.Errorf("ProviderIDs = %v, UIDs = %v", result.ProviderIDs, result.ProviderUIDs)
+	}
+
+	// Linkers are asked again once others find new IDs
+	hltb.links["steam"]["steam"] = 292030
+	mobygames.calls, hltb.calls = 0, 0
+	result = &GameResult{Provider: "mobygames", ProviderID: result.ProviderID}
+	if err := client.ReconcileIDs(context.Background(), result); err != nil {
+		t.Fatalf("ReconcileIDs() error: %v", err)
+	}
+	if result.ProviderIDs["hltb"] != 42 || result.ProviderIDs["steam"] != 292030 {
+		t.Errorf("ProviderIDs = %v, want the hltb ID linked through steam", result.ProviderIDs)
+	}
+	if mobygames.calls != 2 || hltb.calls != 2 {
+		t.Errorf("LinkIDs calls = %d, %d; want 2 each", mobygames.calls, hltb.calls)
+	}
+}
+
+// failingLinker fails every LinkIDs call.
+type failingLinker struct {
+	fakeProvider
+}
+
+func (p *failingLinker) LinkIDs(context.Context, map[string]int, map[string]string) (map[string]int, map[string]string, error) {
+	return nil, nil, errors.New("unavailable")
+}
+
+func TestClientReconcileIDsError(t *testing.T) {
+	RegisterProvider("mobygames", func(ProviderConfig, cache.Cache) (Provider, error) {
+		return &failingLinker{fakeProvider{name: "mobygames"}}, nil
+	})
+
+	client, err := NewClient(WithMobyGames("key"))
+	if err != nil {
+		t.Fatalf("NewClient() error: %v", err)
+	}
+	defer client.Close()
+
+	id := 1
+	result := &GameResult{Provider: "igdb", ProviderID: &id}
+	var providerErr *ProviderError
+	if err := client.ReconcileIDs(context.Background(), result); !errors.As(err, &providerErr) || providerErr.Provider != "mobygames" {
+		t.Errorf("ReconcileIDs() error = %v, want a mobygames ProviderError", err)
+	}
+	if result.ProviderIDs["igdb"] != 1 {
+		t.Errorf("ProviderIDs = %v, want the result's own ID", result.ProviderIDs)
+	}
+}
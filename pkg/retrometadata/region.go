@@ -0,0 +1,189 @@
+package retrometadata
+
+import "strings"
+
+// Region is a normalized release region code (e.g., "us", "eu", "jp").
+type Region string
+
+// Release regions.
+const (
+	RegionUnknown     Region = ""
+	RegionUSA         Region = "us"
+	RegionWorld       Region = "wor"
+	RegionEurope      Region = "eu"
+	RegionJapan       Region = "jp"
+	RegionKorea       Region = "kr"
+	RegionChina       Region = "cn"
+	RegionTaiwan      Region = "tw"
+	RegionAsia        Region = "as"
+	RegionAustralia   Region = "au"
+	RegionBrazil      Region = "br"
+	RegionCanada      Region = "ca"
+	RegionFrance      Region = "fr"
+	RegionGermany     Region = "de"
+	RegionItaly       Region = "it"
+	RegionSpain       Region = "es"
+	RegionNetherlands Region = "nl"
+	RegionSweden      Region = "se"
+	RegionRussia      Region = "ru"
+)
+
+// regionAliases maps region names and codes used by naming conventions and
+// providers to normalized regions.
+var regionAliases = map[string]Region{
+	"usa": RegionUSA, "u": RegionUSA, "us": RegionUSA, "america": RegionUSA, "na": RegionUSA,
+	"world": RegionWorld, "w": RegionWorld, "wor": RegionWorld,
+	"europe": RegionEurope, "e": RegionEurope, "eu": RegionEurope, "eur": RegionEurope, "pal": RegionEurope,
+	"japan": RegionJapan, "j": RegionJapan, "jp": RegionJapan, "jpn": RegionJapan, "jap": RegionJapan,
+	"korea": RegionKorea, "k": RegionKorea, "kr": RegionKorea, "kor": RegionKorea,
+	"china": RegionChina, "ch": RegionChina, "cn": RegionChina, "chn": RegionChina,
+	"taiwan": RegionTaiwan, "tw": RegionTaiwan,
+	"asia": RegionAsia, "as": RegionAsia,
+	"australia": RegionAustralia, "au": RegionAustralia,
+	"brazil": RegionBrazil, "br": RegionBrazil,
+	"canada": RegionCanada, "ca": RegionCanada,
+	"france": RegionFrance, "fr": RegionFrance,
+	"germany": RegionGermany, "de": RegionGermany, "ger": RegionGermany,
+	"italy": RegionItaly, "it": RegionItaly,
+	"spain": RegionSpain, "es": RegionSpain, "spa": RegionSpain,
+	"netherlands": RegionNetherlands, "nl": RegionNetherlands,
+	"sweden": RegionSweden, "se": RegionSweden,
+	"russia": RegionRussia, "ru": RegionRussia,
+}
+
+// ParseRegion normalizes a region name or code (e.g., "USA", "Europe", "jp").
+// Returns false if the value isn't a known region.
+func ParseRegion(s string) (Region, bool) {
+	region, ok := regionAliases[strings.ToLower(strings.TrimSpace(s))]
+	return region, ok
+}
+
+// ParseRegions normalizes a list of region codes, dropping unknown values.
+// It is used to convert Config.RegionPriority.
+func ParseRegions(values []string) []Region {
+	regions := make([]Region, 0, len(values))
+	for _, v := range values {
+		if region, ok := ParseRegion(v); ok {
+			regions = append(regions, region)
+		}
+	}
+	return regions
+}
+
+// Language is a normalized ISO 639-1 language code (e.g., "en", "ja").
+type Language string
+
+// Languages.
+const (
+	LanguageUnknown    Language = ""
+	LanguageEnglish    Language = "en"
+	LanguageJapanese   Language = "ja"
+	LanguageFrench     Language = "fr"
+	LanguageGerman     Language = "de"
+	LanguageSpanish    Language = "es"
+	LanguageItalian    Language = "it"
+	LanguageDutch      Language = "nl"
+	LanguagePortuguese Language = "pt"
+	LanguageSwedish    Language = "sv"
+	LanguageNorwegian  Language = "no"
+	LanguageDanish     Language = "da"
+	LanguageFinnish    Language = "fi"
+	LanguageKorean     Language = "ko"
+	LanguageChinese    Language = "zh"
+	LanguageRussian    Language = "ru"
+	LanguagePolish     Language = "pl"
+	LanguageCatalan    Language = "ca"
+	LanguageGreek      Language = "el"
+	LanguageTurkish    Language = "tr"
+	LanguageCzech      Language = "cs"
+	LanguageHungarian  Language = "hu"
+	LanguageArabic     Language = "ar"
+	LanguageHebrew     Language = "he"
+	LanguageCroatian   Language = "hr"
+)
+
+// languageAliases maps language names and codes to normalized languages.
+var languageAliases = map[string]Language{
+	"en": LanguageEnglish, "english": LanguageEnglish,
+	"ja": LanguageJapanese, "japanese": LanguageJapanese,
+	"fr": LanguageFrench, "french": LanguageFrench,
+	"de": LanguageGerman, "german": LanguageGerman,
+	"es": LanguageSpanish, "spanish": LanguageSpanish,
+	"it": LanguageItalian, "italian": LanguageItalian,
+	"nl": LanguageDutch, "dutch": LanguageDutch,
+	"pt": LanguagePortuguese, "portuguese": LanguagePortuguese,
+	"sv": LanguageSwedish, "swedish": LanguageSwedish,
+	"no": LanguageNorwegian, "norwegian": LanguageNorwegian,
+	"da": LanguageDanish, "danish": LanguageDanish,
+	"fi": LanguageFinnish, "finnish": LanguageFinnish,
+	"ko": LanguageKorean, "korean": LanguageKorean,
+	"zh": LanguageChinese, "chinese": LanguageChinese,
+	"ru": LanguageRussian, "russian": LanguageRussian,
+	"pl": LanguagePolish, "polish": LanguagePolish,
+	"ca": LanguageCatalan, "catalan": LanguageCatalan,
+	"el": LanguageGreek, "greek": LanguageGreek,
+	"tr": LanguageTurkish, "turkish": LanguageTurkish,
+	"cs": LanguageCzech, "czech": LanguageCzech,
+	"hu": LanguageHungarian, "hungarian": LanguageHungarian,
+	"ar": LanguageArabic, "arabic": LanguageArabic,
+	"he": LanguageHebrew, "hebrew": LanguageHebrew,
+	"hr": LanguageCroatian, "croatian": LanguageCroatian,
+}
+
+// ParseLanguage normalizes a language name or code (e.g., "En", "japanese").
+// Returns false if the value isn't a known language.
+func ParseLanguage(s string) (Language, bool) {
+	language, ok := languageAliases[strings.ToLower(strings.TrimSpace(s))]
+	return language, ok
+}
+
+// ParseLocale returns the language of a locale such as "fr-CA", "pt_BR" or
+// "ja". Returns false if the language isn't known.
+func ParseLocale(locale string) (Language, bool) {
+	code, _, _ := strings.Cut(strings.ReplaceAll(strings.TrimSpace(locale), "_", "-"), "-")
+	return ParseLanguage(code)
+}
+
+// LanguagePriority returns the languages to prefer for localized text with a
+// locale: the locale's language, then English as the fallback every provider
+// has. Without a known locale it is just English.
+func LanguagePriority(locale string) []Language {
+	language, ok := ParseLocale(locale)
+	if !ok || language == LanguageEnglish {
+		return []Language{LanguageEnglish}
+	}
+	return []Language{language, LanguageEnglish}
+}
+
+// SelectPreferred returns the available value that ranks highest in priority.
+// If none of the available values are in priority, the first available value
+// is returned, and the zero value if nothing is available.
+//
+// It works with both regions and languages:
+//
+//	region := retrometadata.SelectPreferred(regions, retrometadata.ParseRegions(config.RegionPriority))
+func SelectPreferred[T ~string](available, priority []T) T {
+	for _, preferred := range priority {
+		for _, v := range available {
+			if v == preferred {
+				return v
+			}
+		}
+	}
+	if len(available) > 0 {
+		return available[0]
+	}
+	var zero T
+	return zero
+}
+
+// PriorityRank returns the position of v in priority, or len(priority) if it
+// isn't listed, so that lower ranks are preferred when sorting.
+func PriorityRank[T ~string](v T, priority []T) int {
+	for i, preferred := range priority {
+		if v == preferred {
+			return i
+		}
+	}
+	return len(priority)
+}
@@ -0,0 +1,156 @@
+package retrometadata
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+)
+
+// Relation types of RelatedGame.
+const (
+	RelationExpansion = "expansion"
+	RelationDLC       = "dlc"
+	RelationRemaster  = "remaster"
+	RelationRemake    = "remake"
+	RelationExpanded  = "expanded"
+	RelationPort      = "port"
+	RelationSimilar   = "similar"
+)
+
+// RelatedResult is a related game with its full details.
+type RelatedResult struct {
+	// Related is the related game as the result listed it
+	Related RelatedGame `json:"related"`
+	// Result is the related game's details from its provider
+	Result *GameResult `json:"result"`
+}
+
+// related returns the related games of a result, by relation type.
+func related(result *GameResult) map[string][]RelatedGame {
+	m := result.Metadata
+	return map[string][]RelatedGame{
+		RelationExpansion: m.Expansions,
+		RelationDLC:       m.DLCs,
+		RelationRemaster:  m.Remasters,
+		RelationRemake:    m.Remakes,
+		RelationExpanded:  m.ExpandedGames,
+		RelationPort:      m.Ports,
+		RelationSimilar:   m.SimilarGames,
+	}
+}
+
+// relationOrder is the order ExpandRelated lists relation types in.
+var relationOrder = []string{
+	RelationExpansion, RelationDLC, RelationRemaster, RelationRemake,
+	RelationExpanded, RelationPort, RelationSimilar,
+}
+
+// ExpandRelated fetches the full details of a result's related games of the
+// given relation types (e.g. RelationDLC, RelationRemake), or of every type
+// if none are given. Related games are fetched by ID from the provider that
+// listed them, and cached, so expanding them again doesn't ask the provider.
+// Related games the provider doesn't find are left out; the error joins
+// those of the failed fetches.
+func (c *Client) ExpandRelated(ctx context.Context, result *GameResult, kinds ...string) ([]RelatedResult, error) {
+	return c.expandRelated(ctx, result, false, kinds)
+}
+
+// ExpandRelatedOnPlatform expands related games like ExpandRelated, keeping
+// only those released on one of the result's platforms, e.g. the DLCs of
+// the version being played. If the result's platforms aren't known, none
+// are left out.
+func (c *Client) ExpandRelatedOnPlatform(ctx context.Context, result *GameResult, kinds ...string) ([]RelatedResult, error) {
+	return c.expandRelated(ctx, result, true, kinds)
+}
+
+// expandRelated implements ExpandRelated and ExpandRelatedOnPlatform.
+func (c *Client) expandRelated(ctx context.Context, result *GameResult, samePlatform bool, kinds []string) ([]RelatedResult, error) {
+	if result == nil {
+		return nil, nil
+	}
+	if len(kinds) == 0 {
+		kinds = relationOrder
+	}
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	byKind := related(result)
+	var expanded []RelatedResult
+	var errs []error
+	for _, kind := range relationOrder {
+		if !slices.Contains(kinds, kind) {
+			continue
+		}
+		for _, game := range byKind[kind] {
+			if game.RelationType == "" {
+				game.RelationType = kind
+			}
+			full, err := c.relatedResult(ctx, result.Provider, game)
+			if err != nil {
+				errs = append(errs, err)
+				continue
+			}
+			if full == nil || (samePlatform && !sharesPlatform(result, full)) {
+				continue
+			}
+			expanded = append(expanded, RelatedResult{Related: game, Result: c.withRaw(full)})
+		}
+	}
+	return expanded, errors.Join(errs...)
+}
+
+// relatedResult fetches a related game from the provider that listed it,
+// or the cache. Callers must hold c.mu.
+func (c *Client) relatedResult(ctx context.Context, providerName string, game RelatedGame) (*GameResult, error) {
+	if game.Provider != "" {
+		providerName = game.Provider
+	}
+	p, ok := c.providers[providerName]
+	if !ok {
+		return nil, &ProviderError{Provider: providerName, Err: ErrProviderNotFound}
+	}
+
+	key := fmt.Sprintf("related:%s:%d", providerName, game.ID)
+	if c.cache != nil {
+		if result, ok := c.cachedResult(ctx, key); ok {
+			return result, nil
+		}
+	}
+
+	if err := c.acquire(ctx); err != nil {
+		return nil, err
+	}
+	result, err := p.GetByID(ctx, game.ID)
+	c.release()
+	if err != nil {
+		return nil, err
+	}
+
+	if c.cache != nil {
+		// A nil result is cached too, so a related game the provider
+		// doesn't have isn't asked for again
+		if data, err := json.Marshal(result); err == nil {
+			_ = c.cache.Set(ctx, key, data, 0)
+		}
+	}
+	return result, nil
+}
+
+// sharesPlatform reports whether a related game was released on one of a
+// result's platforms, or either's platforms aren't known.
+func sharesPlatform(result, related *GameResult) bool {
+	if len(result.Metadata.Platforms) == 0 || len(related.Metadata.Platforms) == 0 {
+		return true
+	}
+	for _, p := range related.Metadata.Platforms {
+		if slices.ContainsFunc(result.Metadata.Platforms, func(q Platform) bool {
+			return p.Slug != "" && p.Slug == q.Slug || p.Name == q.Name
+		}) {
+			return true
+		}
+	}
+	return false
+}
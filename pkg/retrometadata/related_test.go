@@ -0,0 +1,63 @@
+package retrometadata
+
+import (
+	"context"
+	"testing"
+
+	"github.com/josegonzalez/retro-metadata/pkg/cache"
+)
+
+// relatedProvider is a fakeProvider returning games by ID.
+type relatedProvider struct {
+	fakeProvider
+	games   map[int]*GameResult
+	fetched int
+}
+
+func (p *relatedProvider) GetByID(_ context.Context, id int) (*GameResult, error) {
+	p.fetched++
+	return p.games[id], nil
+}
+
+func TestClientExpandRelated(t *testing.T) {
+	snes := []Platform{{Slug: "snes", Name: "Super Nintendo Entertainment System"}}
+	p := &relatedProvider{fakeProvider: fakeProvider{name: "mobygames"}, games: map[int]*GameResult{
+		2: {Name: "Game DLC", Provider: "mobygames", Metadata: GameMetadata{Platforms: snes}},
+		3: {Name: "Game Remake", Provider: "mobygames", Metadata: GameMetadata{Platforms: []Platform{{Slug: "switch", Name: "Nintendo Switch"}}}},
+	}}
+	RegisterProvider("mobygames", func(ProviderConfig, cache.Cache) (Provider, error) { return p, nil })
+	client, err := NewClient(WithMobyGames("key"))
+	if err != nil {
+		t.Fatalf("NewClient() error: %v", err)
+	}
+	defer client.Close()
+	ctx := context.Background()
+
+	result := &GameResult{Name: "Game", Provider: "mobygames", Metadata: GameMetadata{
+		Platforms:    snes,
+		DLCs:         []RelatedGame{{ID: 2, Name: "Game DLC", RelationType: RelationDLC, Provider: "mobygames"}},
+		Remakes:      []RelatedGame{{ID: 3, Name: "Game Remake"}},
+		SimilarGames: []RelatedGame{{ID: 4, Name: "Unknown"}},
+	}}
+
+	expanded, err := client.ExpandRelated(ctx, result, RelationRemake, RelationDLC)
+	if err != nil {
+		t.Fatalf("ExpandRelated() error: %v", err)
+	}
+	if len(expanded) != 2 || expanded[0].Result.Name != "Game DLC" || expanded[1].Result.Name != "Game Remake" || expanded[1].Related.RelationType != RelationRemake {
+		t.Errorf("ExpandRelated() = %+v", expanded)
+	}
+
+	// Fetched games are cached, including those not found
+	expanded, err = client.ExpandRelatedOnPlatform(ctx, result)
+	if err != nil {
+		t.Fatalf("ExpandRelatedOnPlatform() error: %v", err)
+	}
+	if len(expanded) != 1 || expanded[0].Result.Name != "Game DLC" {
+		t.Errorf("ExpandRelatedOnPlatform() = %+v, want the SNES DLC", expanded)
+	}
+	_, _ = client.ExpandRelated(ctx, result)
+	if p.fetched != 3 {
+		t.Errorf("GetByID called %d times, want 3 (then cached)", p.fetched)
+	}
+}
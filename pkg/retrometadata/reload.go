@@ -0,0 +1,211 @@
+package retrometadata
+
+import (
+	"reflect"
+	"slices"
+	"sort"
+	"sync"
+)
+
+// Provider states reported by ProviderChange.
+const (
+	ProviderEnabled      = "enabled"
+	ProviderDisabled     = "disabled"
+	ProviderReconfigured = "reconfigured"
+)
+
+// ProviderChange is a provider being enabled, disabled or reconfigured
+// while the client is running.
+type ProviderChange struct {
+	// Provider is the provider's name
+	Provider string `json:"provider"`
+	// State is ProviderEnabled, ProviderDisabled or ProviderReconfigured
+	State string `json:"state"`
+}
+
+// OnProviderChange registers a handler called after UpdateConfig,
+// EnableProvider or DisableProvider change a provider. Handlers are called
+// synchronously, after the change is applied, and may use the client.
+func (c *Client) OnProviderChange(handler func(ProviderChange)) {
+	c.changeMu.Lock()
+	defer c.changeMu.Unlock()
+	c.changeHandlers = append(c.changeHandlers, handler)
+}
+
+// UpdateConfig applies options to the client's configuration while it's
+// running, e.g. to rotate credentials. Providers whose configuration changed
+// are recreated, newly enabled ones created and disabled ones closed; the
+// others, and their state, are kept. Secret references in credentials are
+// resolved again, so calling it without options picks up rotated secrets.
+// Calls in progress finish with the old configuration, including those that
+// outlived their soft deadline (see Config.ProviderDeadline); UpdateConfig
+// returns once those of the providers it replaced or closed are done. The
+// cache and MaxConcurrentRequests can't be changed and keep their values.
+//
+// If the configuration is invalid or a changed provider fails to
+// initialize, nothing is changed and the error is returned.
+func (c *Client) UpdateConfig(opts ...Option) error {
+	c.mu.Lock()
+	config := c.config
+	for _, opt := range opts {
+		opt(&config)
+	}
+	config.Cache = c.config.Cache
+	config.MaxConcurrentRequests = c.config.MaxConcurrentRequests
+
+	var changes []ProviderChange
+	var retired []retiredProvider
+	err := prepareConfig(&config)
+	if err == nil {
+		changes, retired, err = c.applyConfig(config)
+	}
+	c.mu.Unlock()
+
+	closeRetired(retired)
+	c.notify(changes)
+	return err
+}
+
+// EnableProvider enables a provider, creating it from its configuration.
+// It does nothing if the provider is already enabled.
+func (c *Client) EnableProvider(name string) error {
+	return c.setProviderEnabled(name, true)
+}
+
+// DisableProvider disables a provider, closing it once its calls in progress
+// finish. It does nothing if the provider is already disabled.
+func (c *Client) DisableProvider(name string) error {
+	return c.setProviderEnabled(name, false)
+}
+
+// setProviderEnabled implements EnableProvider and DisableProvider.
+func (c *Client) setProviderEnabled(name string, enabled bool) error {
+	providerRegistry.mu.RLock()
+	_, registered := providerRegistry.factories[name]
+	providerRegistry.mu.RUnlock()
+
+	c.mu.Lock()
+	config := c.config
+	pc := config.GetProviderConfig(name)
+	if pc == nil || !registered {
+		c.mu.Unlock()
+		return &ProviderError{Provider: name, Err: ErrProviderNotFound}
+	}
+	pc.Enabled = enabled
+	changes, retired, err := c.applyConfig(config)
+	c.mu.Unlock()
+
+	closeRetired(retired)
+	c.notify(changes)
+	return err
+}
+
+// retiredProvider is a provider replaced or disabled by applyConfig, to be
+// closed once its calls in progress finish.
+type retiredProvider struct {
+	provider Provider
+	calls    *sync.WaitGroup
+}
+
+// closeRetired closes the retired providers once their calls in progress
+// finish. It must be called without holding c.mu, as calls that outlived
+// their soft deadline may still need the client.
+func closeRetired(retired []retiredProvider) {
+	for _, r := range retired {
+		r.calls.Wait()
+		_ = r.provider.Close()
+	}
+}
+
+// applyConfig replaces the client's configuration, recreating the providers
+// whose configuration changed. It returns the providers it replaced or
+// disabled, which the caller closes with closeRetired after releasing c.mu.
+// Callers must hold c.mu for writing.
+func (c *Client) applyConfig(config Config) ([]ProviderChange, []retiredProvider, error) {
+	enabled := config.GetEnabledProviders()
+	configs := make(map[string]ProviderConfig)
+	created := make(map[string]Provider)
+	var changes []ProviderChange
+	fail := func(err error) ([]ProviderChange, []retiredProvider, error) {
+		for _, p := range created {
+			_ = p.Close()
+		}
+		return nil, nil, err
+	}
+	for _, name := range enabled {
+		pc, ok, err := providerConfig(name, config)
+		if err != nil {
+			return fail(err)
+		}
+		if !ok {
+			continue
+		}
+		configs[name] = pc
+		previous, attempted := c.providerConfigs[name]
+		unchanged := attempted && reflect.DeepEqual(pc, previous)
+		_, running := c.providers[name]
+		if running && unchanged {
+			continue
+		}
+
+		p, err := c.newProvider(name, pc)
+		if err != nil {
+			if unchanged {
+				// Still failing, as when the client was created
+				continue
+			}
+			return fail(&ProviderError{Provider: name, Op: "configure", Err: err})
+		}
+		if p == nil {
+			continue
+		}
+		created[name] = p
+		state := ProviderEnabled
+		if running {
+			state = ProviderReconfigured
+		}
+		changes = append(changes, ProviderChange{Provider: name, State: state})
+	}
+
+	var disabled []string
+	for name := range c.providers {
+		if !slices.Contains(enabled, name) {
+			disabled = append(disabled, name)
+		}
+	}
+	sort.Strings(disabled)
+	var retired []retiredProvider
+	for _, name := range disabled {
+		retired = append(retired, retiredProvider{provider: c.providers[name], calls: c.calls[name]})
+		delete(c.providers, name)
+		delete(c.calls, name)
+		changes = append(changes, ProviderChange{Provider: name, State: ProviderDisabled})
+	}
+	for name, p := range created {
+		if old, ok := c.providers[name]; ok {
+			retired = append(retired, retiredProvider{provider: old, calls: c.calls[name]})
+		}
+		c.providers[name] = p
+		c.calls[name] = new(sync.WaitGroup)
+	}
+
+	c.config = config
+	c.providerConfigs = configs
+	return changes, retired, nil
+}
+
+// notify calls the OnProviderChange handlers for each change.
+func (c *Client) notify(changes []ProviderChange) {
+	if len(changes) == 0 {
+		return
+	}
+	c.changeMu.Lock()
+	handlers := slices.Clone(c.changeHandlers)
+	c.changeMu.Unlock()
+
+	for _, change := range changes {
+		for _, handler := range handlers {
+			handler(change)
+		}
+	}
+}
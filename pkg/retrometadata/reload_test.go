@@ -0,0 +1,255 @@
+package retrometadata
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/josegonzalez/retro-metadata/pkg/cache"
+)
+
+// closableProvider is a fakeProvider recording whether it was closed.
+type closableProvider struct {
+	fakeProvider
+	closed bool
+}
+
+func (p *closableProvider) Close() error {
+	p.closed = true
+	return nil
+}
+
+func TestClientUpdateConfig(t *testing.T) {
+	var mu sync.Mutex
+	created := map[string][]*closableProvider{}
+	for _, name := range []string{"mobygames", "hltb"} {
+		RegisterProvider(name, func(config ProviderConfig, _ cache.Cache) (Provider, error) {
+			if config.GetCredential("api_key") == "bad" {
+				return nil, errors.New("invalid credentials")
+			}
+			p := &closableProvider{fakeProvider: fakeProvider{name: name}}
+			mu.Lock()
+			created[name] = append(created[name], p)
+			mu.Unlock()
+			return p, nil
+		})
+	}
+
+	client, err := NewClient(WithMobyGames("key"))
+	if err != nil {
+		t.Fatalf("NewClient() error: %v", err)
+	}
+	defer client.Close()
+	var changes []ProviderChange
+	client.OnProviderChange(func(change ProviderChange) {
+		changes = append(changes, change)
+	})
+
+	// Rotating credentials recreates only the changed provider
+	if err := client.UpdateConfig(WithMobyGames("rotated"), WithHLTB()); err != nil {
+		t.Fatalf("UpdateConfig() error: %v", err)
+	}
+	if len(created["mobygames"]) != 2 || !created["mobygames"][0].closed || created["mobygames"][1].closed {
+		t.Errorf("mobygames providers = %+v, want the old one closed and a new one", created["mobygames"])
+	}
+	want := []ProviderChange{{"mobygames", ProviderReconfigured}, {"hltb", ProviderEnabled}}
+	if len(changes) != 2 || changes[0] != want[0] || changes[1] != want[1] {
+		t.Errorf("changes = %+v, want %+v", changes, want)
+	}
+
+	// A failing provider leaves everything as it was
+	changes = nil
+	if err := client.UpdateConfig(WithMobyGames("bad")); err == nil {
+		t.Error("UpdateConfig() with bad credentials succeeded")
+	}
+	if p, _ := client.GetProvider("mobygames"); p != created["mobygames"][1] || len(changes) != 0 {
+		t.Errorf("GetProvider() = %p, changes = %+v after a failed update, want the provider kept", p, changes)
+	}
+
+	if err := client.DisableProvider("mobygames"); err != nil {
+		t.Fatalf("DisableProvider() error: %v", err)
+	}
+	if _, ok := client.GetProvider("mobygames"); ok || !created["mobygames"][1].closed {
+		t.Error("DisableProvider() kept the provider")
+	}
+	if err := client.EnableProvider("mobygames"); err != nil {
+		t.Fatalf("EnableProvider() error: %v", err)
+	}
+	if providers := client.Providers(); len(providers) != 2 {
+		t.Errorf("Providers() = %v, want both providers", providers)
+	}
+	want = []ProviderChange{{"mobygames", ProviderDisabled}, {"mobygames", ProviderEnabled}}
+	if len(changes) != 2 || changes[0] != want[0] || changes[1] != want[1] {
+		t.Errorf("changes = %+v, want %+v", changes, want)
+	}
+
+	if err := client.EnableProvider("nonexistent"); !errors.Is(err, ErrProviderNotFound) {
+		t.Errorf("EnableProvider(nonexistent) error = %v, want ErrProviderNotFound", err)
+	}
+}
+
+func TestClientUpdateConfigConcurrent(t *testing.T) {
+	RegisterProvider("hltb", func(ProviderConfig, cache.Cache) (Provider, error) {
+		return &fakeProvider{name: "hltb"}, nil
+	})
+	client, err := NewClient(WithHLTB())
+	if err != nil {
+		t.Fatalf("NewClient() error: %v", err)
+	}
+	defer client.Close()
+
+	ctx := context.Background()
+	var wg sync.WaitGroup
+	for range 4 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range 50 {
+				_, _ = client.Search(ctx, "Game", SearchOptions{})
+			}
+		}()
+	}
+	for range 50 {
+		_ = client.DisableProvider("hltb")
+		_ = client.EnableProvider("hltb")
+	}
+	wg.Wait()
+
+	if _, ok := client.GetProvider("hltb"); !ok {
+		t.Error("GetProvider(hltb) = false, want the provider enabled")
+	}
+}
+
+// slowClosableProvider is a slowProvider recording whether it was closed.
+type slowClosableProvider struct {
+	*slowProvider
+	closed atomic.Bool
+}
+
+func (p *slowClosableProvider) Close() error {
+	p.closed.Store(true)
+	return nil
+}
+
+func TestClientUpdateConfigBackgroundCalls(t *testing.T) {
+	slow := &slowClosableProvider{slowProvider: &slowProvider{
+		fakeProvider: fakeProvider{name: "mobygames"},
+		release:      make(chan struct{}),
+		finished:     make(chan error, 1),
+	}}
+	RegisterProvider("mobygames", func(ProviderConfig, cache.Cache) (Provider, error) {
+		return slow, nil
+	})
+	client, err := NewClient(WithMobyGames("key"), WithProviderDeadline(20*time.Millisecond))
+	if err != nil {
+		t.Fatalf("NewClient() error: %v", err)
+	}
+	defer client.Close()
+
+	// The call misses its deadline and carries on in the background
+	if result, _ := client.Identify(context.Background(), "Game.sfc", IdentifyOptions{}); result != nil {
+		t.Fatalf("Identify() = %+v, want no result", result)
+	}
+	if err := client.UpdateConfig(WithMobyGames("key"), WithProviderDeadline(20*time.Millisecond), WithMinMatchScore(0.5)); err != nil {
+		t.Fatalf("UpdateConfig() error: %v", err)
+	}
+
+	// The provider isn't closed until its background call finishes
+	disabled := make(chan error, 1)
+	go func() { disabled <- client.DisableProvider("mobygames") }()
+	select {
+	case err := <-disabled:
+		t.Fatalf("DisableProvider() = %v before the background call finished", err)
+	case <-time.After(50 * time.Millisecond):
+	}
+	if slow.closed.Load() {
+		t.Fatal("provider closed before its background call finished")
+	}
+
+	close(slow.release)
+	if err := <-slow.finished; err != nil {
+		t.Errorf("Background call error: %v", err)
+	}
+	if err := <-disabled; err != nil {
+		t.Fatalf("DisableProvider() error: %v", err)
+	}
+	if !slow.closed.Load() {
+		t.Error("provider not closed after DisableProvider")
+	}
+}
+
+// checkedProvider is a fakeProvider failing calls made after it was closed.
+type checkedProvider struct {
+	fakeProvider
+	closed atomic.Bool
+}
+
+func (p *checkedProvider) GetByID(_ context.Context, id int) (*GameResult, error) {
+	if p.closed.Load() {
+		return nil, errors.New("provider used after Close")
+	}
+	time.Sleep(time.Millisecond)
+	if p.closed.Load() {
+		return nil, errors.New("provider closed during a call")
+	}
+	return &GameResult{ProviderID: &id, Provider: p.name, RawResponse: map[string]any{"id": id}}, nil
+}
+
+func (p *checkedProvider) Close() error {
+	p.closed.Store(true)
+	return nil
+}
+
+func TestClientGetByIDDuringUpdateConfig(t *testing.T) {
+	RegisterProvider("mobygames", func(ProviderConfig, cache.Cache) (Provider, error) {
+		return &checkedProvider{fakeProvider: fakeProvider{name: "mobygames"}}, nil
+	})
+	client, err := NewClient(WithMobyGames("key"))
+	if err != nil {
+		t.Fatalf("NewClient() error: %v", err)
+	}
+	defer client.Close()
+
+	ctx := context.Background()
+	stop := make(chan struct{})
+	var wg sync.WaitGroup
+	errs := make(chan error, 4)
+	for range 4 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; ; i++ {
+				select {
+				case <-stop:
+					return
+				default:
+				}
+				if _, err := client.GetByID(ctx, "mobygames", i); err != nil {
+					errs <- err
+					return
+				}
+			}
+		}()
+	}
+	for i := range 50 {
+		time.Sleep(time.Millisecond)
+		// Recreate the provider and change the raw response handling
+		mode := RawKeep
+		if i%2 == 0 {
+			mode = RawOmit
+		}
+		if err := client.UpdateConfig(WithMobyGames(fmt.Sprintf("key-%d", i)), WithRawResponses(RawConfig{Mode: mode})); err != nil {
+			t.Fatalf("UpdateConfig() error: %v", err)
+		}
+	}
+	close(stop)
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Errorf("GetByID() error: %v", err)
+	}
+}
@@ -0,0 +1,59 @@
+package retrometadata
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+)
+
+// SchemaVersion is the version of the stored GameResult JSON MarshalResult
+// writes. It only changes when a field is renamed, removed or changes type;
+// new fields are added to the current version, and readers ignore the
+// fields they don't know.
+const SchemaVersion = 1
+
+// storedResult is the stored JSON of a GameResult: its JSON fields after
+// the schema version.
+type storedResult struct {
+	SchemaVersion int `json:"schema_version"`
+	*GameResult
+}
+
+// MarshalResult returns the canonical JSON of a result, for storing it,
+// e.g. in a library database. It's the result's JSON without the raw
+// provider data and match trace, which are for debugging, and with a
+// schema_version field. The JSON of equal results is the same: fields are
+// in declaration order, map keys are sorted, and HTML characters aren't
+// escaped.
+func MarshalResult(result *GameResult) ([]byte, error) {
+	if result == nil {
+		return []byte("null"), nil
+	}
+	stored := *result
+	stored.RawResponse = nil
+	stored.Metadata.RawData = nil
+	stored.MatchTrace = nil
+
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(storedResult{SchemaVersion: SchemaVersion, GameResult: &stored}); err != nil {
+		return nil, err
+	}
+	return bytes.TrimSuffix(buf.Bytes(), []byte("\n")), nil
+}
+
+// UnmarshalResult decodes a result stored by MarshalResult, or by
+// json.Marshal before there was a schema version. Fields it doesn't know,
+// like those added in later versions, are ignored. It returns nil for
+// "null".
+func UnmarshalResult(data []byte) (*GameResult, error) {
+	stored := storedResult{GameResult: &GameResult{}}
+	if err := json.Unmarshal(data, &stored); err != nil {
+		return nil, fmt.Errorf("decoding stored result: %w", err)
+	}
+	if bytes.Equal(bytes.TrimSpace(data), []byte("null")) {
+		return nil, nil
+	}
+	return stored.GameResult, nil
+}
@@ -0,0 +1,74 @@
+package retrometadata
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestMarshalResult(t *testing.T) {
+	id, year := 1, 1991
+	result := &GameResult{
+		Name:        "Mario & Luigi",
+		Provider:    "igdb",
+		ProviderID:  &id,
+		ProviderIDs: map[string]int{"steam": 2, "igdb": 1},
+		Metadata:    GameMetadata{ReleaseYear: &year, RawData: map[string]any{"id": 1}},
+		MatchTrace:  &MatchTrace{Filename: "Mario.sfc"},
+		RawResponse: map[string]any{"id": 1},
+	}
+
+	data, err := MarshalResult(result)
+	if err != nil {
+		t.Fatalf("MarshalResult() error: %v", err)
+	}
+	got := string(data)
+	if !strings.HasPrefix(got, `{"schema_version":1,"name":"Mario & Luigi",`) {
+		t.Errorf("MarshalResult() = %s, want the schema version first and no HTML escaping", got)
+	}
+	if !strings.Contains(got, `"provider_ids":{"igdb":1,"steam":2}`) {
+		t.Errorf("MarshalResult() = %s, want sorted provider IDs", got)
+	}
+	for _, field := range []string{"raw_response", "raw_data", "match_trace"} {
+		if strings.Contains(got, field) {
+			t.Errorf("MarshalResult() = %s, want no %s", got, field)
+		}
+	}
+	if result.RawResponse == nil || result.MatchTrace == nil {
+		t.Error("MarshalResult() changed its input")
+	}
+
+	decoded, err := UnmarshalResult(data)
+	if err != nil {
+		t.Fatalf("UnmarshalResult() error: %v", err)
+	}
+	want := *result
+	want.Metadata.RawData, want.MatchTrace, want.RawResponse = nil, nil, nil
+	if !reflect.DeepEqual(*decoded, want) {
+		t.Errorf("UnmarshalResult() = %+v, want %+v", *decoded, want)
+	}
+	if again, _ := MarshalResult(decoded); string(again) != got {
+		t.Errorf("Re-marshalled result = %s, want %s", again, got)
+	}
+}
+
+func TestUnmarshalResultVersions(t *testing.T) {
+	// Results stored before schema versions, and by later versions with
+	// fields this one doesn't know, decode
+	for _, data := range []string{
+		`{"name":"Tetris","provider":"igdb"}`,
+		`{"schema_version":2,"name":"Tetris","provider":"igdb","new_field":{"x":1}}`,
+	} {
+		result, err := UnmarshalResult([]byte(data))
+		if err != nil || result.Name != "Tetris" || result.Provider != "igdb" {
+			t.Errorf("UnmarshalResult(%s) = %+v, %v", data, result, err)
+		}
+	}
+
+	if result, err := UnmarshalResult([]byte("null")); result != nil || err != nil {
+		t.Errorf("UnmarshalResult(null) = %+v, %v; want nil", result, err)
+	}
+	if _, err := UnmarshalResult([]byte(`{"name":1}`)); err == nil {
+		t.Error("UnmarshalResult() with a mistyped field: want an error")
+	}
+}
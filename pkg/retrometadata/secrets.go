@@ -0,0 +1,61 @@
+package retrometadata
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// SecretResolver returns the secret a credential reference names, e.g.
+// "secret/moby" for "vault:secret/moby" with a resolver registered for the
+// "vault" scheme (see WithSecretResolver).
+type SecretResolver func(ref string) (string, error)
+
+// resolveSecret returns the value of a credential: the credential itself,
+// or the secret it references as "env:NAME" (an environment variable),
+// "file:PATH" (a file's contents, without a trailing newline, as with
+// Docker secrets) or "scheme:ref" for a scheme with a resolver. Values
+// without a known scheme are used as is, so passwords may contain colons.
+func resolveSecret(value string, resolvers map[string]SecretResolver) (string, error) {
+	scheme, ref, ok := strings.Cut(value, ":")
+	if !ok {
+		return value, nil
+	}
+	if resolve, ok := resolvers[scheme]; ok {
+		return resolve(ref)
+	}
+
+	switch scheme {
+	case "env":
+		secret, ok := os.LookupEnv(ref)
+		if !ok {
+			return "", fmt.Errorf("environment variable %s is not set", ref)
+		}
+		return secret, nil
+	case "file":
+		data, err := os.ReadFile(ref)
+		if err != nil {
+			return "", err
+		}
+		return strings.TrimRight(string(data), "\r\n"), nil
+	default:
+		return value, nil
+	}
+}
+
+// resolveCredentials returns a copy of credentials with their secret
+// references resolved.
+func resolveCredentials(credentials map[string]string, resolvers map[string]SecretResolver) (map[string]string, error) {
+	if credentials == nil {
+		return nil, nil
+	}
+	resolved := make(map[string]string, len(credentials))
+	for key, value := range credentials {
+		secret, err := resolveSecret(value, resolvers)
+		if err != nil {
+			return nil, fmt.Errorf("credential %s: %w", key, err)
+		}
+		resolved[key] = secret
+	}
+	return resolved, nil
+}
@@ -0,0 +1,76 @@
+package retrometadata
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/josegonzalez/retro-metadata/pkg/cache"
+)
+
+func TestResolveSecret(t *testing.T) {
+	t.Setenv("RETRO_METADATA_TEST_KEY", "from-env")
+	secretPath := filepath.Join(t.TempDir(), "moby_key")
+	if err := os.WriteFile(secretPath, []byte("from-file\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	resolvers := map[string]SecretResolver{
+		"vault": func(ref string) (string, error) { return "vault:" + ref, nil },
+	}
+
+	tests := []struct {
+		value    string
+		expected string
+		wantErr  bool
+	}{
+		{"plain-key", "plain-key", false},
+		{"pass:word", "pass:word", false},
+		{"env:RETRO_METADATA_TEST_KEY", "from-env", false},
+		{"env:RETRO_METADATA_TEST_MISSING", "", true},
+		{"file:" + secretPath, "from-file", false},
+		{"file:" + secretPath + ".missing", "", true},
+		{"vault:secret/moby", "vault:secret/moby", false},
+	}
+	for _, tt := range tests {
+		got, err := resolveSecret(tt.value, resolvers)
+		if (err != nil) != tt.wantErr || got != tt.expected {
+			t.Errorf("resolveSecret(%q) = %q, %v; want %q, error %v", tt.value, got, err, tt.expected, tt.wantErr)
+		}
+	}
+}
+
+func TestClientSecrets(t *testing.T) {
+	var configs []ProviderConfig
+	RegisterProvider("mobygames", func(config ProviderConfig, _ cache.Cache) (Provider, error) {
+		configs = append(configs, config)
+		return &fakeProvider{name: "mobygames"}, nil
+	})
+
+	secretPath := filepath.Join(t.TempDir(), "moby_key")
+	if err := os.WriteFile(secretPath, []byte("first\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	client, err := NewClient(WithMobyGames("file:" + secretPath))
+	if err != nil {
+		t.Fatalf("NewClient() error: %v", err)
+	}
+	defer client.Close()
+	if len(configs) != 1 || configs[0].GetCredential("api_key") != "first" {
+		t.Fatalf("provider configs = %+v, want the key read from the file", configs)
+	}
+
+	// Updating without changes only recreates the provider if the secret changed
+	if err := client.UpdateConfig(); err != nil || len(configs) != 1 {
+		t.Errorf("UpdateConfig() = %v with %d providers created, want the provider kept", err, len(configs))
+	}
+	if err := os.WriteFile(secretPath, []byte("rotated\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := client.UpdateConfig(); err != nil || len(configs) != 2 || configs[1].GetCredential("api_key") != "rotated" {
+		t.Errorf("UpdateConfig() = %v, configs = %+v, want the rotated key", err, configs)
+	}
+
+	if _, err := NewClient(WithMobyGames("env:RETRO_METADATA_TEST_MISSING")); err == nil {
+		t.Error("NewClient() with a missing secret succeeded")
+	}
+}
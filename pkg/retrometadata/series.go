@@ -0,0 +1,178 @@
+package retrometadata
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"slices"
+)
+
+// SeriesKind is the kind of a series of games.
+type SeriesKind string
+
+// Series kinds.
+const (
+	// SeriesFranchise is a franchise, like "Mario", spanning several series
+	SeriesFranchise SeriesKind = "franchise"
+	// SeriesCollection is a series of games, like "Super Mario Land"
+	SeriesCollection SeriesKind = "collection"
+)
+
+// Series is a franchise or collection and its games.
+type Series struct {
+	// Name is the series name
+	Name string `json:"name"`
+	// Kind is whether it's a franchise or a collection
+	Kind SeriesKind `json:"kind"`
+	// ProviderIDs maps provider names to the series' IDs
+	ProviderIDs map[string]int `json:"provider_ids,omitempty"`
+	// Games are the games in the series, by release year then name; games
+	// without a known year come last
+	Games []SeriesGame `json:"games"`
+}
+
+// SeriesGame is a game in a series.
+type SeriesGame struct {
+	// Name is the game name
+	Name string `json:"name"`
+	// ReleaseYear is the release year if known
+	ReleaseYear *int `json:"release_year,omitempty"`
+	// Platforms are the platforms the game was released on
+	Platforms []string `json:"platforms,omitempty"`
+	// ProviderIDs maps provider names to the game's IDs
+	ProviderIDs map[string]int `json:"provider_ids,omitempty"`
+}
+
+// SeriesQuery finds a series: by a provider's ID for it if Provider is
+// set, otherwise by name.
+type SeriesQuery struct {
+	// Name is the series name
+	Name string
+	// Provider is the provider ID belongs to
+	Provider string
+	// ID is the provider's ID for the series
+	ID int
+}
+
+// SeriesProvider is an optional interface for providers that list the games
+// of franchises and collections.
+type SeriesProvider interface {
+	Provider
+
+	// GetSeries returns a franchise or collection with the provider's ID,
+	// or the name if id is 0. It returns nil if the provider doesn't know
+	// it.
+	GetSeries(ctx context.Context, kind SeriesKind, name string, id int) (*Series, error)
+}
+
+// GetFranchise returns the games of a franchise, from every provider
+// implementing SeriesProvider, for "complete the set" features. The same
+// game from several providers is listed once, with each provider's ID (see
+// ClusterResults). A franchise found by a provider's ID is looked up by its
+// name with the other providers. It returns nil if no provider knows the
+// franchise, with the provider errors joined.
+func (c *Client) GetFranchise(ctx context.Context, q SeriesQuery) (*Series, error) {
+	return c.getSeries(ctx, SeriesFranchise, q)
+}
+
+// GetCollection returns the games of a collection, like GetFranchise.
+func (c *Client) GetCollection(ctx context.Context, q SeriesQuery) (*Series, error) {
+	return c.getSeries(ctx, SeriesCollection, q)
+}
+
+// getSeries implements GetFranchise and GetCollection.
+func (c *Client) getSeries(ctx context.Context, kind SeriesKind, q SeriesQuery) (*Series, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	var providers []SeriesProvider
+	for _, name := range c.selectProviders(nil, nil) {
+		if p, ok := c.providers[name].(SeriesProvider); ok {
+			providers = append(providers, p)
+		}
+	}
+
+	var found []*Series
+	var errs []error
+	get := func(p SeriesProvider, name string, id int) {
+		if err := c.acquire(ctx); err != nil {
+			errs = append(errs, err)
+			return
+		}
+		series, err := p.GetSeries(ctx, kind, name, id)
+		c.release()
+		if err != nil {
+			errs = append(errs, NewProviderError(p.Name(), "get "+string(kind), err))
+		} else if series != nil {
+			found = append(found, series)
+		}
+	}
+
+	name := q.Name
+	if q.Provider != "" {
+		p, ok := c.providers[q.Provider]
+		if !ok {
+			return nil, &ProviderError{Provider: q.Provider, Err: ErrProviderNotFound}
+		}
+		seriesProvider, ok := p.(SeriesProvider)
+		if !ok {
+			return nil, &ProviderError{Provider: q.Provider, Op: "get " + string(kind), Err: ErrNotSupported}
+		}
+		get(seriesProvider, "", q.ID)
+		if len(found) == 0 {
+			return nil, errors.Join(errs...)
+		}
+		name = found[0].Name
+		providers = slices.DeleteFunc(providers, func(p SeriesProvider) bool { return p.Name() == q.Provider })
+	}
+	for _, p := range providers {
+		get(p, name, 0)
+	}
+	if len(found) == 0 {
+		return nil, errors.Join(errs...)
+	}
+	return mergeSeries(kind, found), nil
+}
+
+// mergeSeries combines the series providers found, listing each game once.
+func mergeSeries(kind SeriesKind, found []*Series) *Series {
+	merged := &Series{Name: found[0].Name, Kind: kind, ProviderIDs: make(map[string]int)}
+	var results []SearchResult
+	for _, series := range found {
+		for provider, id := range series.ProviderIDs {
+			merged.ProviderIDs[provider] = id
+		}
+		for _, game := range series.Games {
+			for provider, id := range game.ProviderIDs {
+				results = append(results, SearchResult{
+					Name:        game.Name,
+					Provider:    provider,
+					ProviderID:  id,
+					Platforms:   game.Platforms,
+					ReleaseYear: game.ReleaseYear,
+				})
+			}
+		}
+	}
+
+	for _, cluster := range ClusterResults(results) {
+		merged.Games = append(merged.Games, SeriesGame{
+			Name:        cluster.Results[0].Name,
+			ReleaseYear: cluster.ReleaseYear,
+			Platforms:   cluster.Platforms,
+			ProviderIDs: cluster.ProviderIDs,
+		})
+	}
+	slices.SortStableFunc(merged.Games, func(a, b SeriesGame) int {
+		switch {
+		case a.ReleaseYear == nil && b.ReleaseYear == nil:
+			return cmp.Compare(a.Name, b.Name)
+		case a.ReleaseYear == nil:
+			return 1
+		case b.ReleaseYear == nil:
+			return -1
+		}
+		return cmp.Or(cmp.Compare(*a.ReleaseYear, *b.ReleaseYear), cmp.Compare(a.Name, b.Name))
+	})
+	return merged
+}
@@ -0,0 +1,93 @@
+package retrometadata
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/josegonzalez/retro-metadata/pkg/cache"
+)
+
+// seriesProvider is a fakeProvider knowing one series.
+type seriesProvider struct {
+	fakeProvider
+	series *Series
+	asked  []string
+}
+
+func (p *seriesProvider) GetSeries(_ context.Context, kind SeriesKind, name string, id int) (*Series, error) {
+	p.asked = append(p.asked, name)
+	if kind != p.series.Kind || (id != 0 && id != p.series.ProviderIDs[p.name]) || (id == 0 && name != p.series.Name) {
+		return nil, nil
+	}
+	return p.series, nil
+}
+
+func TestClientGetFranchise(t *testing.T) {
+	year := func(y int) *int { return &y }
+	moby := &seriesProvider{fakeProvider: fakeProvider{name: "mobygames"}, series: &Series{
+		Name:        "Metroid",
+		Kind:        SeriesFranchise,
+		ProviderIDs: map[string]int{"mobygames": 7},
+		Games: []SeriesGame{
+			{Name: "Super Metroid", ReleaseYear: year(1994), ProviderIDs: map[string]int{"mobygames": 2}},
+			{Name: "Metroid Dread", ProviderIDs: map[string]int{"mobygames": 3}},
+			{Name: "Metroid", ReleaseYear: year(1986), ProviderIDs: map[string]int{"mobygames": 1}},
+		},
+	}}
+	hltb := &seriesProvider{fakeProvider: fakeProvider{name: "hltb"}, series: &Series{
+		Name:        "Metroid",
+		Kind:        SeriesFranchise,
+		ProviderIDs: map[string]int{"hltb": 70},
+		Games: []SeriesGame{
+			{Name: "Super Metroid", ReleaseYear: year(1994), ProviderIDs: map[string]int{"hltb": 20}},
+		},
+	}}
+	RegisterProvider("mobygames", func(ProviderConfig, cache.Cache) (Provider, error) { return moby, nil })
+	RegisterProvider("hltb", func(ProviderConfig, cache.Cache) (Provider, error) { return hltb, nil })
+	client, err := NewClient(WithMobyGames("key"), WithHLTB())
+	if err != nil {
+		t.Fatalf("NewClient() error: %v", err)
+	}
+	defer client.Close()
+	ctx := context.Background()
+
+	series, err := client.GetFranchise(ctx, SeriesQuery{Provider: "mobygames", ID: 7})
+	if err != nil || series == nil {
+		t.Fatalf("GetFranchise() = %+v, %v", series, err)
+	}
+	if series.ProviderIDs["mobygames"] != 7 || series.ProviderIDs["hltb"] != 70 || len(series.Games) != 3 {
+		t.Fatalf("GetFranchise() = %+v", series)
+	}
+	if names := []string{series.Games[0].Name, series.Games[1].Name, series.Games[2].Name}; names[0] != "Metroid" || names[1] != "Super Metroid" || names[2] != "Metroid Dread" {
+		t.Errorf("Games = %q, want by year with unknown years last", names)
+	}
+	if ids := series.Games[1].ProviderIDs; ids["mobygames"] != 2 || ids["hltb"] != 20 {
+		t.Errorf("Super Metroid ProviderIDs = %v, want both providers'", ids)
+	}
+	if len(hltb.asked) != 1 || hltb.asked[0] != "Metroid" {
+		t.Errorf("hltb asked for %q, want the franchise's name", hltb.asked)
+	}
+
+	if series, err := client.GetCollection(ctx, SeriesQuery{Name: "Metroid"}); series != nil || err != nil {
+		t.Errorf("GetCollection() = %+v, %v, want nothing", series, err)
+	}
+	if _, err := client.GetFranchise(ctx, SeriesQuery{Provider: "igdb", ID: 1}); !errors.Is(err, ErrProviderNotFound) {
+		t.Errorf("GetFranchise(igdb) error = %v, want ErrProviderNotFound", err)
+	}
+}
+
+func TestClientGetFranchiseNotSupported(t *testing.T) {
+	RegisterProvider("mobygames", func(ProviderConfig, cache.Cache) (Provider, error) {
+		return &fakeProvider{name: "mobygames"}, nil
+	})
+	client, err := NewClient(WithMobyGames("key"))
+	if err != nil {
+		t.Fatalf("NewClient() error: %v", err)
+	}
+	defer client.Close()
+
+	if _, err := client.GetFranchise(context.Background(), SeriesQuery{Provider: "mobygames", ID: 1}); !errors.Is(err, ErrNotSupported) {
+		t.Errorf("GetFranchise() error = %v, want ErrNotSupported", err)
+	}
+}
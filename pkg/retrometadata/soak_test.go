@@ -0,0 +1,235 @@
+//go:build soak
+
+package retrometadata
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"math/rand"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/josegonzalez/retro-metadata/pkg/cache"
+)
+
+// The soak test drives the client with many concurrent identify requests
+// against mock providers that inject latency and errors. Run it before
+// releases with:
+//
+//	go test -tags soak -run TestSoak -v ./pkg/retrometadata -soak.requests=50000
+var (
+	soakRequests    = flag.Int("soak.requests", 5000, "number of identify requests")
+	soakWorkers     = flag.Int("soak.workers", 64, "number of concurrent callers")
+	soakLatency     = flag.Duration("soak.latency", 2*time.Millisecond, "maximum injected provider latency")
+	soakErrorRate   = flag.Float64("soak.error-rate", 0.2, "fraction of primary provider calls that fail")
+	soakTimeoutRate = flag.Float64("soak.timeout-rate", 0.05, "fraction of requests with an already tight deadline")
+	soakHeapGrowth  = flag.Int64("soak.max-heap-growth", 32<<20, "maximum heap growth in bytes after the run")
+)
+
+// soakProvider is a mock provider with injected latency and failures. It
+// records the peak number of concurrent calls.
+type soakProvider struct {
+	name      string
+	errorRate float64
+	latency   time.Duration
+
+	mu       sync.Mutex
+	rand     *rand.Rand
+	calls    atomic.Int64
+	failures atomic.Int64
+	inFlight atomic.Int64
+	peak     atomic.Int64
+}
+
+func newSoakProvider(name string, errorRate float64, latency time.Duration, seed int64) *soakProvider {
+	return &soakProvider{
+		name:      name,
+		errorRate: errorRate,
+		latency:   latency,
+		rand:      rand.New(rand.NewSource(seed)),
+	}
+}
+
+// roll returns a random latency and whether the call fails.
+func (p *soakProvider) roll() (time.Duration, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	var latency time.Duration
+	if p.latency > 0 {
+		latency = time.Duration(p.rand.Int63n(int64(p.latency)))
+	}
+	return latency, p.rand.Float64() < p.errorRate
+}
+
+func (p *soakProvider) call(ctx context.Context) error {
+	p.calls.Add(1)
+	n := p.inFlight.Add(1)
+	defer p.inFlight.Add(-1)
+	for {
+		peak := p.peak.Load()
+		if n <= peak || p.peak.CompareAndSwap(peak, n) {
+			break
+		}
+	}
+
+	latency, fail := p.roll()
+	timer := time.NewTimer(latency)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+	}
+
+	if fail {
+		p.failures.Add(1)
+		if latency%2 == 0 {
+			return &RateLimitError{Provider: p.name, RetryAfter: 1}
+		}
+		return &ProviderError{Provider: p.name, Err: ErrProviderConnection}
+	}
+	return nil
+}
+
+func (p *soakProvider) Name() string { return p.name }
+
+func (p *soakProvider) Search(ctx context.Context, query string, _ SearchOptions) ([]SearchResult, error) {
+	if err := p.call(ctx); err != nil {
+		return nil, err
+	}
+	return []SearchResult{{Name: query, Provider: p.name}}, nil
+}
+
+func (p *soakProvider) GetByID(_ context.Context, _ int) (*GameResult, error) { return nil, nil }
+
+func (p *soakProvider) Identify(ctx context.Context, filename string, _ IdentifyOptions) (*GameResult, error) {
+	if err := p.call(ctx); err != nil {
+		return nil, err
+	}
+	return &GameResult{Name: filename, Provider: p.name, RawResponse: map[string]any{"filename": filename}}, nil
+}
+
+func (p *soakProvider) Heartbeat(_ context.Context) error { return nil }
+
+func (p *soakProvider) Close() error { return nil }
+
+func heapInUse() uint64 {
+	runtime.GC()
+	var stats runtime.MemStats
+	runtime.ReadMemStats(&stats)
+	return stats.HeapInuse
+}
+
+func TestSoakIdentify(t *testing.T) {
+	primary := newSoakProvider("mobygames", *soakErrorRate, *soakLatency, 1)
+	fallback := newSoakProvider("hltb", 0, *soakLatency, 2)
+	for _, p := range []*soakProvider{primary, fallback} {
+		RegisterProvider(p.name, func(ProviderConfig, cache.Cache) (Provider, error) {
+			return p, nil
+		})
+	}
+
+	client, err := NewClient(WithMobyGames("key"), WithHLTB())
+	if err != nil {
+		t.Fatalf("NewClient() error: %v", err)
+	}
+	defer client.Close()
+
+	goroutines := runtime.NumGoroutine()
+	heapBefore := heapInUse()
+	start := time.Now()
+
+	var found, notFound, unexpected atomic.Int64
+	requests := make(chan int)
+	var wg sync.WaitGroup
+	for range *soakWorkers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range requests {
+				ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+				// Every 1/timeout-rate-th request gets a deadline shorter than the provider latency
+				if *soakTimeoutRate > 0 && i%int(1 / *soakTimeoutRate) == 0 {
+					cancel()
+					ctx, cancel = context.WithTimeout(context.Background(), time.Microsecond)
+				}
+
+				filename := fmt.Sprintf("Game %d.sfc", i)
+				result, err := client.Identify(ctx, filename, IdentifyOptions{})
+				cancel()
+
+				var notFoundErr *GameNotFoundError
+				switch {
+				case err == nil && result != nil && result.Name == filename:
+					found.Add(1)
+				case errors.As(err, &notFoundErr):
+					notFound.Add(1)
+				default:
+					unexpected.Add(1)
+				}
+			}
+		}()
+	}
+
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+	for i := range *soakRequests {
+		requests <- i
+	}
+	close(requests)
+
+	select {
+	case <-done:
+	case <-time.After(10 * time.Minute):
+		t.Fatal("soak run didn't finish; a provider call is likely stuck")
+	}
+	elapsed := time.Since(start)
+
+	if total := found.Load() + notFound.Load() + unexpected.Load(); total != int64(*soakRequests) {
+		t.Errorf("got %d responses for %d requests", total, *soakRequests)
+	}
+	if unexpected.Load() > 0 {
+		t.Errorf("%d requests returned an unexpected result or error", unexpected.Load())
+	}
+	// The fallback never fails, so only cancelled requests can miss
+	if maxMissed := int64(float64(*soakRequests)**soakTimeoutRate) + 1; notFound.Load() > maxMissed {
+		t.Errorf("%d requests weren't identified, expected at most %d", notFound.Load(), maxMissed)
+	}
+	for _, p := range []*soakProvider{primary, fallback} {
+		if limit := int64(client.config.MaxConcurrentRequests); p.peak.Load() > limit {
+			t.Errorf("%s peaked at %d concurrent calls, limit %d", p.name, p.peak.Load(), limit)
+		}
+	}
+	if fallbackCalls := fallback.calls.Load(); fallbackCalls < primary.failures.Load() {
+		t.Errorf("fallback provider called %d times for %d primary failures", fallbackCalls, primary.failures.Load())
+	}
+
+	// Give cancelled timers a moment to unwind before counting goroutines
+	deadline := time.Now().Add(5 * time.Second)
+	for runtime.NumGoroutine() > goroutines && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if n := runtime.NumGoroutine(); n > goroutines {
+		t.Errorf("goroutines leaked: %d before, %d after", goroutines, n)
+	}
+
+	heapAfter := heapInUse()
+	if growth := int64(heapAfter) - int64(heapBefore); growth > *soakHeapGrowth {
+		t.Errorf("heap grew by %d bytes, limit %d", growth, *soakHeapGrowth)
+	}
+
+	t.Logf("%d requests in %v (%.0f req/s): %d found, %d not found",
+		*soakRequests, elapsed, float64(*soakRequests)/elapsed.Seconds(), found.Load(), notFound.Load())
+	t.Logf("provider calls: %s=%d (%d failed, peak %d concurrent), %s=%d (peak %d concurrent); max_concurrent_requests=%d",
+		primary.name, primary.calls.Load(), primary.failures.Load(), primary.peak.Load(),
+		fallback.name, fallback.calls.Load(), fallback.peak.Load(), client.config.MaxConcurrentRequests)
+	t.Logf("heap in use: %d -> %d bytes", heapBefore, heapAfter)
+}
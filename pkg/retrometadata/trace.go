@@ -0,0 +1,117 @@
+package retrometadata
+
+import (
+	"context"
+	"sync"
+
+	"github.com/josegonzalez/retro-metadata/pkg/matching"
+)
+
+// MatchTrace explains how a game was identified: the fuzzy matches each
+// provider ran, with the cleaned search terms, the normalization steps that
+// fired and the best scoring candidates. It's set on GameResult.MatchTrace
+// when IdentifyOptions.Trace is set.
+type MatchTrace struct {
+	// Filename is the filename being identified
+	Filename string `json:"filename,omitempty"`
+	// Providers are the providers asked, in the order they answered
+	Providers []ProviderTrace `json:"providers"`
+
+	mu sync.Mutex
+}
+
+// ProviderTrace is one provider's part of a MatchTrace.
+type ProviderTrace struct {
+	// Provider is the provider name
+	Provider string `json:"provider"`
+	// Matches are the fuzzy matches the provider ran
+	Matches []matching.Trace `json:"matches,omitempty"`
+	// Result is the name of the game the provider returned, if any
+	Result string `json:"result,omitempty"`
+	// Error is the error the provider returned, if any
+	Error string `json:"error,omitempty"`
+}
+
+// provider returns the trace of a provider, adding it if needed. The caller
+// holds t.mu.
+func (t *MatchTrace) provider(name string) *ProviderTrace {
+	for i := range t.Providers {
+		if t.Providers[i].Provider == name {
+			return &t.Providers[i]
+		}
+	}
+	t.Providers = append(t.Providers, ProviderTrace{Provider: name})
+	return &t.Providers[len(t.Providers)-1]
+}
+
+// AddMatch records a fuzzy match run by a provider. It does nothing on a nil
+// trace.
+func (t *MatchTrace) AddMatch(provider string, match matching.Trace) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	p := t.provider(provider)
+	p.Matches = append(p.Matches, match)
+}
+
+// addOutcome records what a provider returned. It does nothing on a nil
+// trace.
+func (t *MatchTrace) addOutcome(provider string, result *GameResult, err error) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	p := t.provider(provider)
+	if result != nil {
+		p.Result = result.Name
+	}
+	if err != nil {
+		p.Error = err.Error()
+	}
+}
+
+// snapshot returns a copy of the trace, or nil for a nil trace.
+func (t *MatchTrace) snapshot() *MatchTrace {
+	if t == nil {
+		return nil
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	providers := make([]ProviderTrace, len(t.Providers))
+	for i, p := range t.Providers {
+		p.Matches = append([]matching.Trace(nil), p.Matches...)
+		providers[i] = p
+	}
+	return &MatchTrace{Filename: t.Filename, Providers: providers}
+}
+
+type traceKey struct{}
+
+type noCacheWritesKey struct{}
+
+// WithMatchTrace returns a context recording the fuzzy matches providers run
+// into trace.
+func WithMatchTrace(ctx context.Context, trace *MatchTrace) context.Context {
+	return context.WithValue(ctx, traceKey{}, trace)
+}
+
+// MatchTraceFromContext returns the trace set with WithMatchTrace, or nil.
+func MatchTraceFromContext(ctx context.Context) *MatchTrace {
+	trace, _ := ctx.Value(traceKey{}).(*MatchTrace)
+	return trace
+}
+
+// WithoutCacheWrites returns a context in which providers read their cache
+// but don't write to it, so requests leave no trace in it.
+func WithoutCacheWrites(ctx context.Context) context.Context {
+	return context.WithValue(ctx, noCacheWritesKey{}, true)
+}
+
+// CacheWritesDisabled reports whether ctx was returned by WithoutCacheWrites.
+func CacheWritesDisabled(ctx context.Context) bool {
+	disabled, _ := ctx.Value(noCacheWritesKey{}).(bool)
+	return disabled
+}
@@ -0,0 +1,74 @@
+package retrometadata
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/josegonzalez/retro-metadata/pkg/cache"
+	"github.com/josegonzalez/retro-metadata/pkg/matching"
+)
+
+// matchingProvider fuzzy matches filenames against its games, recording the
+// match in the context's trace and resolving ambiguous matches like the real
+// providers.
+type matchingProvider struct {
+	fakeProvider
+	games       []string
+	cacheWrites bool
+}
+
+func (p *matchingProvider) Identify(ctx context.Context, filename string, _ IdentifyOptions) (*GameResult, error) {
+	p.cacheWrites = !CacheWritesDisabled(ctx)
+	opts := matching.DefaultFindBestMatchOptions()
+	opts.Trace = &matching.Trace{}
+	matching.FindBestMatch(filename, p.games, opts)
+	MatchTraceFromContext(ctx).AddMatch(p.name, *opts.Trace)
+	if match, score := ResolveAmbiguity(ctx, p.name, *opts.Trace); match != "" {
+		return &GameResult{Name: match, Provider: p.name, MatchScore: score}, nil
+	}
+	return nil, nil
+}
+
+func TestMatchTrace(t *testing.T) {
+	moby := &matchingProvider{fakeProvider: fakeProvider{name: "mobygames"}, games: []string{"Tetris"}}
+	hltb := &matchingProvider{fakeProvider: fakeProvider{name: "hltb"}, games: []string{"Super Mario World", "Super Mario Land"}}
+	RegisterProvider("mobygames", func(ProviderConfig, cache.Cache) (Provider, error) { return moby, nil })
+	RegisterProvider("hltb", func(ProviderConfig, cache.Cache) (Provider, error) { return hltb, nil })
+
+	config := DefaultConfig()
+	config.MobyGames = ProviderConfig{Enabled: true, Priority: 1, Credentials: map[string]string{"api_key": "key"}}
+	config.HLTB = ProviderConfig{Enabled: true, Priority: 2}
+	client, err := NewClient(WithConfig(config))
+	if err != nil {
+		t.Fatalf("NewClient() error: %v", err)
+	}
+	defer client.Close()
+
+	ctx := context.Background()
+	result, err := client.Identify(ctx, "Super Mario World", IdentifyOptions{})
+	if err != nil || result.MatchTrace != nil {
+		t.Errorf("Identify() without Trace = %+v, %v; want no trace", result, err)
+	}
+
+	result, err = client.Identify(ctx, "Super Mario World (USA)", IdentifyOptions{Trace: true})
+	if err != nil || result.MatchTrace == nil {
+		t.Fatalf("Identify() with Trace = %+v, %v; want a trace", result, err)
+	}
+	trace := result.MatchTrace
+	if len(trace.Providers) != 2 || trace.Providers[0].Provider != "mobygames" || trace.Providers[0].Result != "" {
+		t.Errorf("trace providers = %+v, want mobygames without a result then hltb", trace.Providers)
+	}
+	if hltbTrace := trace.Providers[1]; hltbTrace.Result != "Super Mario World" || len(hltbTrace.Matches) != 1 || hltbTrace.Matches[0].CandidateCount != 2 {
+		t.Errorf("hltb trace = %+v, want a match among 2 candidates", hltbTrace)
+	}
+
+	// Explaining a missing match still returns the trace, without cache writes
+	result, trace, err = client.ExplainIdentify(ctx, "Sonic the Hedgehog", IdentifyOptions{})
+	if !errors.Is(err, ErrGameNotFound) || result != nil || trace == nil || len(trace.Providers) != 2 {
+		t.Errorf("ExplainIdentify() = %+v, %+v, %v; want not found with a trace of both providers", result, trace, err)
+	}
+	if moby.cacheWrites || hltb.cacheWrites {
+		t.Error("ExplainIdentify() allowed cache writes")
+	}
+}
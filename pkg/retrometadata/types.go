@@ -2,7 +2,11 @@
 // from various providers like IGDB, MobyGames, ScreenScraper, and more.
 package retrometadata
 
-import "time"
+import (
+	"slices"
+	"strconv"
+	"time"
+)
 
 // Platform represents a gaming platform.
 type Platform struct {
@@ -70,6 +74,50 @@ type Artwork struct {
 	LogoURL string `json:"logo_url,omitempty"`
 	// BackgroundURL is the URL to a background image
 	BackgroundURL string `json:"background_url,omitempty"`
+	// ManualURL is the URL to the game manual, usually a PDF
+	ManualURL string `json:"manual_url,omitempty"`
+	// MapURLs is a list of game map URLs
+	MapURLs []string `json:"map_urls,omitempty"`
+	// Media is every media file the provider offers, including regional
+	// variants and types without a dedicated field (e.g., 3D boxes, videos, manuals)
+	Media []Media `json:"media,omitempty"`
+}
+
+// Media is a single media file for a game.
+type Media struct {
+	// Type is the provider's media type (e.g., "box-3D", "video", "manuel")
+	Type string `json:"type"`
+	// URL is the media URL
+	URL string `json:"url"`
+	// Region is the region the media is for, if it's regional (e.g., "us", "eu")
+	Region string `json:"region,omitempty"`
+	// Format is the file format (e.g., "png", "mp4", "pdf")
+	Format string `json:"format,omitempty"`
+}
+
+// Video types.
+const (
+	VideoTrailer  = "trailer"
+	VideoGameplay = "gameplay"
+)
+
+// Video is a game video, like a trailer.
+type Video struct {
+	// Provider is the provider name this came from
+	Provider string `json:"provider,omitempty"`
+	// Type is the kind of video (VideoTrailer or VideoGameplay)
+	Type string `json:"type"`
+	// URL is the video URL, a YouTube page for YouTube videos
+	URL string `json:"url"`
+	// YouTubeID is the YouTube video ID, for videos hosted on YouTube
+	YouTubeID string `json:"youtube_id,omitempty"`
+	// Duration is the length in seconds, if known
+	Duration int `json:"duration,omitempty"`
+}
+
+// YouTubeURL returns the URL of a YouTube video.
+func YouTubeURL(id string) string {
+	return "https://www.youtube.com/watch?v=" + id
 }
 
 // GameMetadata contains extended metadata for a game.
@@ -80,8 +128,13 @@ type GameMetadata struct {
 	AggregatedRating *float64 `json:"aggregated_rating,omitempty"`
 	// FirstReleaseDate is the Unix timestamp of first release
 	FirstReleaseDate *int64 `json:"first_release_date,omitempty"`
+	// Releases is the game's release in each region and on each platform,
+	// for providers that list them
+	Releases []Release `json:"releases,omitempty"`
 	// YouTubeVideoID is the YouTube video ID for trailer
 	YouTubeVideoID string `json:"youtube_video_id,omitempty"`
+	// Videos is every video the provider has, trailers first
+	Videos []Video `json:"videos,omitempty"`
 	// Genres is a list of genre names
 	Genres []string `json:"genres,omitempty"`
 	// Franchises is a list of franchise names
@@ -92,8 +145,19 @@ type GameMetadata struct {
 	Collections []string `json:"collections,omitempty"`
 	// Companies is a list of companies involved
 	Companies []string `json:"companies,omitempty"`
+	// InvolvedCompanies is the companies involved with their roles, for
+	// providers that know them
+	InvolvedCompanies []Company `json:"involved_companies,omitempty"`
 	// GameModes is a list of game modes
 	GameModes []string `json:"game_modes,omitempty"`
+	// Themes is a list of themes (e.g., "Fantasy", "Science fiction")
+	Themes []string `json:"themes,omitempty"`
+	// Keywords is a list of descriptive keywords
+	Keywords []string `json:"keywords,omitempty"`
+	// PlayerPerspectives is a list of camera perspectives (e.g., "Side view")
+	PlayerPerspectives []string `json:"player_perspectives,omitempty"`
+	// Languages is the languages the game supports
+	Languages []Language `json:"languages,omitempty"`
 	// AgeRatings is a list of age ratings
 	AgeRatings []AgeRating `json:"age_ratings,omitempty"`
 	// Platforms is a list of platforms
@@ -139,8 +203,16 @@ type GameResult struct {
 	ProviderID *int `json:"provider_id,omitempty"`
 	// ProviderIDs maps provider names to IDs
 	ProviderIDs map[string]int `json:"provider_ids,omitempty"`
+	// ProviderUID is the provider-specific ID for providers whose IDs aren't
+	// integers (e.g., Flashpoint UUIDs); ProviderID is nil for them
+	ProviderUID string `json:"provider_uid,omitempty"`
+	// ProviderUIDs maps provider names to string IDs
+	ProviderUIDs map[string]string `json:"provider_uids,omitempty"`
 	// Slug is the URL-friendly slug
 	Slug string `json:"slug,omitempty"`
+	// Language is the language of the localized Name or Summary the provider
+	// chose for the preferred locale, if the provider has localized text
+	Language Language `json:"language,omitempty"`
 	// Artwork is the game artwork URLs
 	Artwork Artwork `json:"artwork"`
 	// Metadata is the extended metadata
@@ -149,10 +221,189 @@ type GameResult struct {
 	MatchScore float64 `json:"match_score,omitempty"`
 	// MatchType is the type of match (hash+filename, hash, filename, etc.)
 	MatchType string `json:"match_type,omitempty"`
+	// MatchTrace explains the match, if IdentifyOptions.Trace was set
+	MatchTrace *MatchTrace `json:"match_trace,omitempty"`
+	// Signatures contains the known-good dump signatures matched by the file hashes
+	Signatures *Signatures `json:"signatures,omitempty"`
+	// Discs contains the individual discs for multi-disc games
+	Discs []Disc `json:"discs,omitempty"`
+	// Achievements lists the game's achievements, if the provider has them
+	// and they weren't skipped (see Config.SkipAchievements)
+	Achievements []Achievement `json:"achievements,omitempty"`
 	// RawResponse is the raw provider response for debugging
 	RawResponse map[string]any `json:"raw_response,omitempty"`
 }
 
+// Release is a release of a game in a region.
+type Release struct {
+	// Region is the release region, RegionUnknown if the provider's isn't
+	// recognized
+	Region Region `json:"region,omitempty"`
+	// Date is the Unix timestamp of the release, 0 if only the year is known
+	Date int64 `json:"date,omitempty"`
+	// Year is the release year
+	Year int `json:"year"`
+	// PlatformSlug is the universal slug of the release's platform, if known
+	PlatformSlug string `json:"platform_slug,omitempty"`
+}
+
+// ReleaseIn returns the earliest release in the first of regions with one,
+// e.g. for the regions of a ROM's filename tags, or nil if there's none.
+func (m *GameMetadata) ReleaseIn(regions ...Region) *Release {
+	for _, region := range regions {
+		var earliest *Release
+		for i, r := range m.Releases {
+			if r.Region == region && (earliest == nil || r.Year < earliest.Year || r.Year == earliest.Year && r.Date < earliest.Date) {
+				earliest = &m.Releases[i]
+			}
+		}
+		if earliest != nil {
+			return earliest
+		}
+	}
+	return nil
+}
+
+// Company is a company involved in a game and its roles.
+type Company struct {
+	// Name is the company name
+	Name string `json:"name"`
+	// Developer is whether it developed the game
+	Developer bool `json:"developer,omitempty"`
+	// Publisher is whether it published the game
+	Publisher bool `json:"publisher,omitempty"`
+	// Porting is whether it ported the game to a platform
+	Porting bool `json:"porting,omitempty"`
+	// Supporting is whether it supported the development, e.g. with art
+	Supporting bool `json:"supporting,omitempty"`
+}
+
+// AddCompany adds a company with its roles, combining them with those of a
+// company with the same name added before. The company is added to the
+// Companies list too, and is the Developer or Publisher if there isn't one
+// yet.
+func (m *GameMetadata) AddCompany(company Company) {
+	if company.Name == "" {
+		return
+	}
+	if i := slices.IndexFunc(m.InvolvedCompanies, func(c Company) bool { return c.Name == company.Name }); i >= 0 {
+		c := &m.InvolvedCompanies[i]
+		c.Developer = c.Developer || company.Developer
+		c.Publisher = c.Publisher || company.Publisher
+		c.Porting = c.Porting || company.Porting
+		c.Supporting = c.Supporting || company.Supporting
+	} else {
+		m.InvolvedCompanies = append(m.InvolvedCompanies, company)
+	}
+	if !slices.Contains(m.Companies, company.Name) {
+		m.Companies = append(m.Companies, company.Name)
+	}
+	if company.Developer && m.Developer == "" {
+		m.Developer = company.Name
+	}
+	if company.Publisher && m.Publisher == "" {
+		m.Publisher = company.Name
+	}
+}
+
+// Achievement is a game achievement.
+type Achievement struct {
+	// ID is the provider-specific achievement ID
+	ID string `json:"id"`
+	// Provider is the provider name this came from
+	Provider string `json:"provider,omitempty"`
+	// Title is the achievement title
+	Title string `json:"title"`
+	// Description is what unlocks the achievement
+	Description string `json:"description,omitempty"`
+	// Points is the achievement's point value, if the provider scores them
+	Points int `json:"points,omitempty"`
+	// Type is the provider's achievement type (e.g., "progression", "missable")
+	Type string `json:"type,omitempty"`
+	// BadgeURL is the URL to the unlocked badge image
+	BadgeURL string `json:"badge_url,omitempty"`
+	// BadgeLockedURL is the URL to the locked badge image
+	BadgeLockedURL string `json:"badge_locked_url,omitempty"`
+	// Unlocks is the number of players who unlocked it
+	Unlocks int `json:"unlocks,omitempty"`
+	// UnlocksHardcore is the number who unlocked it in hardcore mode
+	UnlocksHardcore int `json:"unlocks_hardcore,omitempty"`
+	// UnlockRate is the fraction of the game's players who unlocked it
+	// (0-1), if known
+	UnlockRate *float64 `json:"unlock_rate,omitempty"`
+}
+
+// Disc is a single disc of a multi-disc game.
+type Disc struct {
+	// Number is the disc number, starting at 1
+	Number int `json:"number"`
+	// Filename is the disc's file path
+	Filename string `json:"filename"`
+	// Hashes contains the disc's file hashes, if known
+	Hashes *FileHashes `json:"hashes,omitempty"`
+}
+
+// Signature database names.
+const (
+	SignatureSourceNoIntro           = "No-Intro"
+	SignatureSourceRedump            = "Redump"
+	SignatureSourceTOSEC             = "TOSEC"
+	SignatureSourceMAMEArcade        = "MAME Arcade"
+	SignatureSourceMAMEMess          = "MAME MESS"
+	SignatureSourceFBNeo             = "FBNeo"
+	SignatureSourceWHDLoad           = "WHDLoad"
+	SignatureSourcePureDOS           = "PureDOS"
+	SignatureSourceRetroAchievements = "RetroAchievements"
+)
+
+// SignatureMatch is a match against a single signature database.
+type SignatureMatch struct {
+	// Source is the signature database (No-Intro, Redump, TOSEC, etc.)
+	Source string `json:"source"`
+	// Name is the name of the matched dump in the signature database
+	Name string `json:"name,omitempty"`
+	// Revision is the revision or version of the matched dump (e.g., "Rev 1", "v1.1")
+	Revision string `json:"revision,omitempty"`
+	// Labels are tags the source attaches to the dump (e.g., "nointro", "rapatches")
+	Labels []string `json:"labels,omitempty"`
+}
+
+// Signatures contains the known-good dump signatures matched by a file.
+type Signatures struct {
+	// Matches is the list of matched signatures in source priority order
+	Matches []SignatureMatch `json:"matches,omitempty"`
+}
+
+// Has returns true if the signatures include a match from the given source.
+func (s *Signatures) Has(source string) bool {
+	return s.Get(source) != nil
+}
+
+// Get returns the match for the given source, or nil if there is none.
+func (s *Signatures) Get(source string) *SignatureMatch {
+	if s == nil {
+		return nil
+	}
+	for i := range s.Matches {
+		if s.Matches[i].Source == source {
+			return &s.Matches[i]
+		}
+	}
+	return nil
+}
+
+// Sources returns the names of all matched signature sources.
+func (s *Signatures) Sources() []string {
+	if s == nil {
+		return nil
+	}
+	sources := make([]string, 0, len(s.Matches))
+	for _, m := range s.Matches {
+		sources = append(sources, m.Source)
+	}
+	return sources
+}
+
 // CoverURL returns the cover URL for convenience.
 func (g *GameResult) CoverURL() string {
 	return g.Artwork.CoverURL
@@ -172,6 +423,9 @@ type SearchResult struct {
 	Provider string `json:"provider"`
 	// ProviderID is the provider-specific ID
 	ProviderID int `json:"provider_id"`
+	// ProviderUID is the provider-specific ID for providers whose IDs aren't
+	// integers (e.g., Flashpoint UUIDs); ProviderID is 0 for them
+	ProviderUID string `json:"provider_uid,omitempty"`
 	// Slug is the URL-friendly slug
 	Slug string `json:"slug,omitempty"`
 	// CoverURL is the URL to cover art thumbnail
@@ -192,6 +446,64 @@ type SearchOptions struct {
 	Limit int
 	// MinScore is the minimum similarity score for fuzzy matching
 	MinScore float64
+	// Offset is the number of results to skip
+	Offset int
+	// Page is the 1-based page of Limit results to return, used instead of Offset if set
+	Page int
+	// Cursor is the SearchPage.Cursor of the previous page, used instead of Offset and Page if set
+	Cursor string
+	// Providers restricts the search to these providers, if not empty
+	Providers []string
+	// ExcludeProviders skips these providers
+	ExcludeProviders []string
+}
+
+// StartOffset returns the number of results to skip, from Cursor, Page or
+// Offset in that order of precedence.
+func (o SearchOptions) StartOffset() int {
+	if o.Cursor != "" {
+		if offset, err := strconv.Atoi(o.Cursor); err == nil && offset >= 0 {
+			return offset
+		}
+	}
+	if o.Page > 0 {
+		return (o.Page - 1) * o.Limit
+	}
+	return max(o.Offset, 0)
+}
+
+// SearchPage is one page of search results.
+type SearchPage struct {
+	// Results are the results on this page
+	Results []SearchResult `json:"results"`
+	// Offset is the number of results before this page
+	Offset int `json:"offset"`
+	// Total is the total number of matching results, or -1 if the provider doesn't report it
+	Total int `json:"total"`
+	// HasMore indicates more results are available after this page
+	HasMore bool `json:"has_more"`
+	// Cursor is passed as SearchOptions.Cursor to fetch the next page; empty on the last page
+	Cursor string `json:"cursor,omitempty"`
+}
+
+// NewSearchPage builds a page of results fetched at offset. If total is
+// negative (unknown), a full page is assumed to have more results after it.
+func NewSearchPage(results []SearchResult, offset, limit, total int) *SearchPage {
+	page := &SearchPage{
+		Results: results,
+		Offset:  offset,
+		Total:   total,
+	}
+	if total >= 0 {
+		page.HasMore = offset+len(results) < total
+	} else {
+		page.Total = -1
+		page.HasMore = limit > 0 && len(results) >= limit
+	}
+	if page.HasMore {
+		page.Cursor = strconv.Itoa(offset + len(results))
+	}
+	return page
 }
 
 // DefaultSearchOptions returns sensible default search options.
@@ -208,6 +520,25 @@ type IdentifyOptions struct {
 	PlatformID *int
 	// Hashes contains file hashes for hash-based identification
 	Hashes *FileHashes
+	// Serial is the game's serial, like SLUS-12345, for providers that look
+	// games up by serial. Client.Identify extracts it from the filename if
+	// empty (see serials.Extract)
+	Serial string
+	// Providers restricts identification to these providers, if not empty
+	Providers []string
+	// ExcludeProviders skips these providers
+	ExcludeProviders []string
+	// Trace sets GameResult.MatchTrace on the result, explaining the match
+	Trace bool
+	// OnAmbiguous, if set, is asked to choose when several candidates score
+	// close to the best match, instead of taking the best one
+	OnAmbiguous AmbiguityHandler
+	// AmbiguityMargin is how close to the best score a candidate must be to
+	// be ambiguous (default DefaultAmbiguityMargin)
+	AmbiguityMargin float64
+	// MaxAmbiguousCandidates is the most candidates passed to OnAmbiguous
+	// (default DefaultMaxAmbiguousCandidates)
+	MaxAmbiguousCandidates int
 }
 
 // FileHashes contains various hash values for a ROM file.
@@ -216,6 +547,9 @@ type FileHashes struct {
 	SHA1   string `json:"sha1,omitempty"`
 	CRC32  string `json:"crc32,omitempty"`
 	SHA256 string `json:"sha256,omitempty"`
+	// Size is the size in bytes of the hashed game data, which for disc images
+	// is the data track rather than the container (see identify.ExpectedSize)
+	Size int64 `json:"size,omitempty"`
 }
 
 // ProviderStatus represents the health status of a provider.
@@ -0,0 +1,36 @@
+package retrometadata
+
+import "testing"
+
+func TestAddCompany(t *testing.T) {
+	var m GameMetadata
+	m.AddCompany(Company{Name: "Sega", Publisher: true})
+	m.AddCompany(Company{Name: "Sonic Team", Developer: true})
+	m.AddCompany(Company{Name: "Sega", Developer: true})
+	m.AddCompany(Company{Name: "Tiertex", Porting: true})
+	m.AddCompany(Company{})
+
+	if len(m.Companies) != 3 || m.Companies[2] != "Tiertex" {
+		t.Errorf("Companies = %q", m.Companies)
+	}
+	if m.Developer != "Sonic Team" || m.Publisher != "Sega" {
+		t.Errorf("Developer, Publisher = %q, %q; want the first of each", m.Developer, m.Publisher)
+	}
+	if len(m.InvolvedCompanies) != 3 || !m.InvolvedCompanies[0].Developer || !m.InvolvedCompanies[0].Publisher || !m.InvolvedCompanies[2].Porting {
+		t.Errorf("InvolvedCompanies = %+v, want roles combined by name", m.InvolvedCompanies)
+	}
+}
+
+func TestReleaseIn(t *testing.T) {
+	m := GameMetadata{Releases: []Release{
+		{Region: RegionJapan, Year: 1991, Date: 677548800},
+		{Region: RegionUSA, Year: 1991, Date: 679881600, PlatformSlug: "genesis"},
+		{Region: RegionUSA, Year: 2006, PlatformSlug: "wii"},
+	}}
+	if r := m.ReleaseIn(RegionEurope, RegionUSA); r == nil || r.PlatformSlug != "genesis" {
+		t.Errorf("ReleaseIn(eu, us) = %+v, want the earliest US release", r)
+	}
+	if r := m.ReleaseIn(RegionKorea); r != nil {
+		t.Errorf("ReleaseIn(kr) = %+v, want none", r)
+	}
+}
@@ -0,0 +1,171 @@
+package romheader
+
+import (
+	"encoding/binary"
+	"io"
+	"strings"
+
+	"github.com/josegonzalez/retro-metadata/pkg/platform"
+)
+
+const (
+	// copierHeaderSize is the size of the header copiers prepend to SNES
+	// ROMs, detected from ROM sizes that aren't a multiple of 1024
+	copierHeaderSize = 512
+	// snesHeaderSize is the size of the SNES header, from the title to the
+	// interrupt vectors
+	snesHeaderSize = 0x40
+	// snesExtendedSize is the size of the extended header before the title,
+	// holding the game code
+	snesExtendedSize = 0x10
+
+	// gbaFixedValue is at 0xB2 in every GBA header
+	gbaFixedValue = 0x96
+	// ndsHeaderCRCSize is the size of the NDS header covered by its CRC
+	ndsHeaderCRCSize = 0x15E
+)
+
+// snesHeaderOffsets are the offsets of the LoROM, HiROM and ExHiROM headers.
+var snesHeaderOffsets = []int64{0x7FC0, 0xFFC0, 0x40FFC0}
+
+// n64Magics are the first bytes of N64 ROMs in each byte order, with the
+// size of the groups whose bytes are reversed to get big-endian data.
+var n64Magics = map[uint32]int{
+	0x80371240: 1, // big-endian (.z64)
+	0x37804012: 2, // byte-swapped (.v64)
+	0x40123780: 4, // little-endian (.n64)
+}
+
+// readSNES reads the header whose checksum and complement add up to 0xFFFF.
+func readSNES(r io.ReaderAt, size int64) (*Header, error) {
+	var base int64
+	if size%1024 == copierHeaderSize {
+		base = copierHeaderSize
+	}
+
+	for _, offset := range snesHeaderOffsets {
+		buf, err := readAt(r, base+offset-snesExtendedSize, snesExtendedSize+snesHeaderSize)
+		if err != nil {
+			continue
+		}
+		extended, header := buf[:snesExtendedSize], buf[snesExtendedSize:]
+		complement := binary.LittleEndian.Uint16(header[0x1C:])
+		checksum := binary.LittleEndian.Uint16(header[0x1E:])
+		if checksum+complement != 0xFFFF {
+			continue
+		}
+		title := text(header[:21])
+		if title == "" {
+			continue
+		}
+		result := &Header{Platform: platform.SlugSNES, Title: title}
+		// A developer ID of 0x33 marks the extended header with a game code
+		if header[0x1A] == 0x33 {
+			result.Code = code(extended[2:6])
+		}
+		return result, nil
+	}
+	return nil, ErrUnsupported
+}
+
+// readGenesis reads the header after the "SEGA" console name at 0x100,
+// preferring the overseas title to the domestic one.
+func readGenesis(r io.ReaderAt, _ int64) (*Header, error) {
+	header, err := readAt(r, 0x100, 0x100)
+	if err != nil {
+		return nil, err
+	}
+	if !strings.HasPrefix(text(header[:0x10]), "SEGA") {
+		return nil, ErrUnsupported
+	}
+
+	title := text(header[0x50:0x80])
+	if title == "" {
+		title = text(header[0x20:0x50])
+	}
+	if title == "" {
+		return nil, ErrUnsupported
+	}
+	return &Header{Platform: platform.SlugGenesis, Title: title, Code: text(header[0x80:0x8E])}, nil
+}
+
+// readGBA reads the cartridge header, validated by its fixed value and
+// complement check.
+func readGBA(r io.ReaderAt, _ int64) (*Header, error) {
+	header, err := readAt(r, 0xA0, 0x20)
+	if err != nil {
+		return nil, err
+	}
+	if header[0x12] != gbaFixedValue {
+		return nil, ErrUnsupported
+	}
+	var sum byte
+	for _, b := range header[:0x1D] {
+		sum -= b
+	}
+	if sum-0x19 != header[0x1D] {
+		return nil, ErrUnsupported
+	}
+
+	title := text(header[:12])
+	if title == "" {
+		return nil, ErrUnsupported
+	}
+	return &Header{Platform: platform.SlugGBA, Title: title, Code: code(header[12:16])}, nil
+}
+
+// readNDS reads the cartridge header, validated by its CRC.
+func readNDS(r io.ReaderAt, _ int64) (*Header, error) {
+	header, err := readAt(r, 0, ndsHeaderCRCSize+2)
+	if err != nil {
+		return nil, err
+	}
+	if crc16(header[:ndsHeaderCRCSize]) != binary.LittleEndian.Uint16(header[ndsHeaderCRCSize:]) {
+		return nil, ErrUnsupported
+	}
+
+	title := text(header[:12])
+	if title == "" {
+		return nil, ErrUnsupported
+	}
+	return &Header{Platform: platform.SlugNDS, Title: title, Code: code(header[12:16])}, nil
+}
+
+// readN64 reads the header in any of the byte orders N64 ROMs are dumped in.
+func readN64(r io.ReaderAt, _ int64) (*Header, error) {
+	header, err := readAt(r, 0, 0x40)
+	if err != nil {
+		return nil, err
+	}
+	group, ok := n64Magics[binary.BigEndian.Uint32(header)]
+	if !ok {
+		return nil, ErrUnsupported
+	}
+	for i := 0; i < len(header); i += group {
+		for j, k := i, i+group-1; j < k; j, k = j+1, k-1 {
+			header[j], header[k] = header[k], header[j]
+		}
+	}
+
+	title := text(header[0x20:0x34])
+	if title == "" {
+		return nil, ErrUnsupported
+	}
+	return &Header{Platform: platform.SlugN64, Title: title, Code: code(header[0x3B:0x3F])}, nil
+}
+
+// crc16 is the CRC-16/MODBUS checksum NDS headers use.
+func crc16(data []byte) uint16 {
+	crc := uint16(0xFFFF)
+	for _, b := range data {
+		crc ^= uint16(b)
+		for range 8 {
+			if crc&1 != 0 {
+				crc = crc>>1 ^ 0xA001
+			} else {
+				crc >>= 1
+			}
+		}
+	}
+	return crc
+}
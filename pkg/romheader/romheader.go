@@ -0,0 +1,140 @@
+// Package romheader reads the internal titles and product codes cartridge
+// ROMs carry in their headers, for identifying games whose filenames don't
+// match any database name.
+//
+// Supported ROMs:
+//   - SNES: the LoROM, HiROM or ExHiROM header, with or without a 512 byte
+//     copier header
+//   - Genesis / Mega Drive: the header at 0x100, in raw (not interleaved
+//     .smd) dumps
+//   - Game Boy Advance: the cartridge header at 0xA0
+//   - Nintendo DS: the cartridge header at 0x00
+//   - Nintendo 64: the header at 0x20, in big-endian (.z64), byte-swapped
+//     (.v64) or little-endian (.n64) dumps
+//
+// Internal titles are upper case and often abbreviated, like "ZELDA" or
+// "SUPER MARIOWORLD", so they are a fallback search term rather than a
+// name to show.
+package romheader
+
+import (
+	"errors"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/josegonzalez/retro-metadata/pkg/platform"
+)
+
+// ErrUnsupported is returned for files without a header Read recognizes.
+var ErrUnsupported = errors.New("unsupported rom header")
+
+// Header is what a ROM records about itself.
+type Header struct {
+	// Platform is the platform the ROM is for
+	Platform platform.Slug `json:"platform"`
+	// Title is the internal title, with runs of spaces collapsed
+	Title string `json:"title"`
+	// Code is the product or game code, like AXVE or GM 00001009-00, for
+	// ROMs that record one
+	Code string `json:"code,omitempty"`
+}
+
+// parser reads the header of a ROM of size bytes, returning ErrUnsupported
+// if it doesn't have a valid one.
+type parser func(r io.ReaderAt, size int64) (*Header, error)
+
+// parsers are the header parsers of each platform.
+var parsers = map[platform.Slug]parser{
+	platform.SlugSNES:    readSNES,
+	platform.SlugGenesis: readGenesis,
+	platform.SlugGBA:     readGBA,
+	platform.SlugNDS:     readNDS,
+	platform.SlugN64:     readN64,
+}
+
+// order is the order parsers are tried in when the platform isn't known,
+// strictest validation first.
+var order = []platform.Slug{
+	platform.SlugNDS,
+	platform.SlugGBA,
+	platform.SlugN64,
+	platform.SlugGenesis,
+	platform.SlugSNES,
+}
+
+// Platforms returns the platforms whose headers can be read, in the order
+// they are tried.
+func Platforms() []platform.Slug {
+	return append([]platform.Slug(nil), order...)
+}
+
+// Read returns the header of the ROM at path, for the platform detected
+// from its path, or trying every platform if none is detected.
+func Read(path string) (*Header, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return nil, err
+	}
+	return ReadFrom(file, info.Size(), platform.DetectFromPath(path))
+}
+
+// ReadFrom returns the header of a ROM of size bytes for a platform. With an
+// empty platform, or one whose headers aren't supported, every supported
+// platform is tried.
+func ReadFrom(r io.ReaderAt, size int64, slug platform.Slug) (*Header, error) {
+	if parse, ok := parsers[slug]; ok {
+		return parse(r, size)
+	}
+	for _, slug := range order {
+		if header, err := parsers[slug](r, size); !errors.Is(err, ErrUnsupported) {
+			return header, err
+		}
+	}
+	return nil, ErrUnsupported
+}
+
+// readAt reads size bytes at offset, returning ErrUnsupported if the ROM is
+// too short.
+func readAt(r io.ReaderAt, offset, size int64) ([]byte, error) {
+	buf := make([]byte, size)
+	n, err := r.ReadAt(buf, offset)
+	if n < len(buf) {
+		if err == nil || errors.Is(err, io.EOF) {
+			return nil, ErrUnsupported
+		}
+		return nil, err
+	}
+	return buf, nil
+}
+
+// text returns the printable ASCII text in a header field, with other bytes
+// as spaces and runs of spaces collapsed.
+func text(b []byte) string {
+	var sb strings.Builder
+	for _, c := range b {
+		if c == 0 {
+			break
+		}
+		if c < 0x20 || c > 0x7E {
+			c = ' '
+		}
+		sb.WriteByte(c)
+	}
+	return strings.Join(strings.Fields(sb.String()), " ")
+}
+
+// code returns a product code field if it's all letters and digits.
+func code(b []byte) string {
+	s := text(b)
+	if len(s) != len(b) || strings.Trim(s, "ABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789") != "" {
+		return ""
+	}
+	return s
+}
@@ -0,0 +1,123 @@
+package romheader
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/josegonzalez/retro-metadata/pkg/platform"
+)
+
+func snesROM(title string, copier bool) []byte {
+	rom := make([]byte, 0x8000)
+	header := rom[0x7FC0:]
+	copy(header, title)
+	binary.LittleEndian.PutUint16(header[0x1C:], 0x5A5A)
+	binary.LittleEndian.PutUint16(header[0x1E:], 0xA5A5)
+	if copier {
+		rom = append(make([]byte, copierHeaderSize), rom...)
+	}
+	return rom
+}
+
+func genesisROM() []byte {
+	rom := make([]byte, 0x200)
+	copy(rom[0x100:], "SEGA MEGA DRIVE ")
+	copy(rom[0x120:], "SONIC THE               HEDGEHOG")
+	copy(rom[0x150:], "SONIC THE               HEDGEHOG")
+	copy(rom[0x180:], "GM 00001009-00")
+	return rom
+}
+
+func gbaROM() []byte {
+	rom := make([]byte, 0xC0)
+	copy(rom[0xA0:], "POKEMON RUBY")
+	copy(rom[0xAC:], "AXVE")
+	rom[0xB2] = gbaFixedValue
+	var sum byte
+	for _, b := range rom[0xA0:0xBD] {
+		sum -= b
+	}
+	rom[0xBD] = sum - 0x19
+	return rom
+}
+
+func ndsROM() []byte {
+	rom := make([]byte, 0x200)
+	copy(rom, "NEW MARIO")
+	copy(rom[12:], "A2DE")
+	binary.LittleEndian.PutUint16(rom[ndsHeaderCRCSize:], crc16(rom[:ndsHeaderCRCSize]))
+	return rom
+}
+
+// n64ROM returns a big-endian N64 ROM, or one with bytes reversed in groups
+// of swap bytes.
+func n64ROM(swap int) []byte {
+	rom := make([]byte, 0x40)
+	binary.BigEndian.PutUint32(rom, 0x80371240)
+	copy(rom[0x20:], "SUPER MARIO 64      ")
+	copy(rom[0x3B:], "NSME")
+	for i := 0; i < len(rom); i += swap {
+		for j, k := i, i+swap-1; j < k; j, k = j+1, k-1 {
+			rom[j], rom[k] = rom[k], rom[j]
+		}
+	}
+	return rom
+}
+
+func TestReadFrom(t *testing.T) {
+	tests := []struct {
+		name string
+		rom  []byte
+		slug platform.Slug
+		want Header
+	}{
+		{"snes", snesROM("SUPER MARIOWORLD", false), platform.SlugSNES, Header{Platform: platform.SlugSNES, Title: "SUPER MARIOWORLD"}},
+		{"snes copier header", snesROM("ZELDA", true), platform.SlugSNES, Header{Platform: platform.SlugSNES, Title: "ZELDA"}},
+		{"genesis", genesisROM(), "", Header{Platform: platform.SlugGenesis, Title: "SONIC THE HEDGEHOG", Code: "GM 00001009-00"}},
+		{"gba", gbaROM(), "", Header{Platform: platform.SlugGBA, Title: "POKEMON RUBY", Code: "AXVE"}},
+		{"nds", ndsROM(), "", Header{Platform: platform.SlugNDS, Title: "NEW MARIO", Code: "A2DE"}},
+		{"n64 z64", n64ROM(1), "", Header{Platform: platform.SlugN64, Title: "SUPER MARIO 64", Code: "NSME"}},
+		{"n64 v64", n64ROM(2), "", Header{Platform: platform.SlugN64, Title: "SUPER MARIO 64", Code: "NSME"}},
+		{"n64 n64", n64ROM(4), platform.SlugN64, Header{Platform: platform.SlugN64, Title: "SUPER MARIO 64", Code: "NSME"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ReadFrom(bytes.NewReader(tt.rom), int64(len(tt.rom)), tt.slug)
+			if err != nil {
+				t.Fatalf("ReadFrom() error = %v", err)
+			}
+			if *got != tt.want {
+				t.Errorf("ReadFrom() = %+v, want %+v", *got, tt.want)
+			}
+		})
+	}
+
+	// A GBA ROM read as SNES has no valid header
+	rom := gbaROM()
+	if _, err := ReadFrom(bytes.NewReader(rom), int64(len(rom)), platform.SlugSNES); !errors.Is(err, ErrUnsupported) {
+		t.Errorf("ReadFrom(gba as snes) error = %v, want ErrUnsupported", err)
+	}
+	blank := make([]byte, 0x10000)
+	if _, err := ReadFrom(bytes.NewReader(blank), int64(len(blank)), ""); !errors.Is(err, ErrUnsupported) {
+		t.Errorf("ReadFrom(blank) error = %v, want ErrUnsupported", err)
+	}
+}
+
+func TestRead(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "Pokemon Ruby.gba")
+	if err := os.WriteFile(path, gbaROM(), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	header, err := Read(path)
+	if err != nil {
+		t.Fatalf("Read() error = %v", err)
+	}
+	if header.Title != "POKEMON RUBY" || header.Platform != platform.SlugGBA {
+		t.Errorf("Read() = %+v", *header)
+	}
+}
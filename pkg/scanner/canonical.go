@@ -0,0 +1,93 @@
+package scanner
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/josegonzalez/retro-metadata/pkg/identify"
+	"github.com/josegonzalez/retro-metadata/pkg/retrometadata"
+	"github.com/josegonzalez/retro-metadata/pkg/verify"
+)
+
+// DefaultCanonicalMinScore is the match score a fuzzy provider match needs
+// for its signature name to be trusted by PlanCanonicalRenames.
+const DefaultCanonicalMinScore = 0.95
+
+// CanonicalOptions configures PlanCanonicalRenames and PlanVerifiedRenames.
+type CanonicalOptions struct {
+	// Source is the signature database whose dump names files are renamed
+	// to. Empty uses retrometadata.SignatureSourceNoIntro
+	Source string
+	// MinScore is the match score fuzzy provider matches need; hash matches
+	// and overrides are always trusted. 0 uses DefaultCanonicalMinScore
+	MinScore float64
+}
+
+func (o CanonicalOptions) source() string {
+	if o.Source == "" {
+		return retrometadata.SignatureSourceNoIntro
+	}
+	return o.Source
+}
+
+// PlanVerifiedRenames proposes renaming verified files to the exact name of
+// the dump they matched, e.g. "Super Mario World (USA).sfc", keeping their
+// extensions. Files that didn't match a dump from the source are skipped, as
+// are renames that would collide (see PlanRenames).
+func PlanVerifiedRenames(results []verify.Result, opts CanonicalOptions) *RenamePlan {
+	r := newRenamer()
+	for _, result := range results {
+		if result.Error != "" || !result.IsGoodDump() {
+			r.skip(result.Path, "not a verified dump")
+			continue
+		}
+		r.proposeCanonical(result.Path, result.Signatures, opts.source())
+	}
+	return r.plan
+}
+
+// PlanCanonicalRenames proposes renaming identified files to the exact name
+// of the dump their game's signatures matched, e.g. "Super Mario World
+// (USA).sfc", keeping their extensions. Only hash matches, overrides and
+// matches scoring at least opts.MinScore are trusted. Multi-disc sets and
+// playlists are skipped, as their signatures name a single file, as are
+// renames that would collide (see PlanRenames).
+func PlanCanonicalRenames(results []identify.BatchResult, opts CanonicalOptions) *RenamePlan {
+	minScore := opts.MinScore
+	if minScore == 0 {
+		minScore = DefaultCanonicalMinScore
+	}
+
+	r := newRenamer()
+	for _, result := range results {
+		group, game := result.Group, result.Result
+		switch {
+		case result.Err != nil || game == nil:
+			r.skip(group.Filename, "not identified")
+		case group.IsMultiDisc() || identify.IsPlaylist(group.Filename):
+			r.skip(group.Filename, "multi-file games aren't renamed")
+		case game.MatchType != "hash" && game.MatchType != retrometadata.MatchTypeOverride && game.MatchScore < minScore:
+			r.skip(group.Filename, fmt.Sprintf("match score %.2f below %.2f", game.MatchScore, minScore))
+		default:
+			r.proposeCanonical(group.Filename, game.Signatures, opts.source())
+		}
+	}
+	return r.plan
+}
+
+// proposeCanonical proposes renaming path to the name of its signature from
+// source.
+func (r *renamer) proposeCanonical(path string, signatures *retrometadata.Signatures, source string) {
+	match := signatures.Get(source)
+	if match == nil || match.Name == "" {
+		r.skip(path, fmt.Sprintf("no %s name", source))
+		return
+	}
+	name := match.Name
+	if strings.ContainsAny(name, `/\`) {
+		r.skip(path, fmt.Sprintf("invalid %s name %q", source, name))
+		return
+	}
+	r.propose(path, filepath.Join(filepath.Dir(path), name+filepath.Ext(path)))
+}
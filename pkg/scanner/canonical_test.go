@@ -0,0 +1,219 @@
+package scanner
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/josegonzalez/retro-metadata/pkg/identify"
+	"github.com/josegonzalez/retro-metadata/pkg/retrometadata"
+	"github.com/josegonzalez/retro-metadata/pkg/verify"
+)
+
+// noIntro returns signatures naming a No-Intro dump.
+func noIntro(name string) *retrometadata.Signatures {
+	return &retrometadata.Signatures{Matches: []retrometadata.SignatureMatch{
+		{Source: retrometadata.SignatureSourceNoIntro, Name: name},
+	}}
+}
+
+func writeFiles(t *testing.T, root string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(root, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestPlanVerifiedRenames(t *testing.T) {
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{
+		"smw.sfc":                   "smw",
+		"smw copy.sfc":              "smw",
+		"Super Metroid (USA).sfc":   "metroid",
+		"metroid.smc":               "other metroid",
+		"Super Metroid (Japan).sfc": "taken",
+		"metroid-jp.sfc":            "jp",
+		"hack.sfc":                  "hack",
+	})
+	path := func(name string) string { return filepath.Join(root, name) }
+
+	results := []verify.Result{
+		{Path: path("smw.sfc"), Status: verify.StatusVerified, Signatures: noIntro("Super Mario World (USA)")},
+		{Path: path("smw copy.sfc"), Status: verify.StatusVerified, Signatures: noIntro("Super Mario World (USA)")},
+		{Path: path("Super Metroid (USA).sfc"), Status: verify.StatusVerified, Signatures: noIntro("Super Metroid (USA)")},
+		{Path: path("metroid.smc"), Status: verify.StatusVerified, Signatures: noIntro("Super Metroid (USA)")},
+		{Path: path("metroid-jp.sfc"), Status: verify.StatusVerified, Signatures: noIntro("Super Metroid (Japan)")},
+		{Path: path("hack.sfc"), Status: verify.StatusModified},
+	}
+	plan := PlanVerifiedRenames(results, CanonicalOptions{})
+
+	wantRenames := []Rename{
+		{From: path("smw.sfc"), To: path("Super Mario World (USA).sfc")},
+		// The extension is kept, so this isn't a collision
+		{From: path("metroid.smc"), To: path("Super Metroid (USA).smc")},
+	}
+	if !reflect.DeepEqual(plan.Renames, wantRenames) {
+		t.Errorf("Renames = %+v, want %+v", plan.Renames, wantRenames)
+	}
+	wantSkipped := []SkippedFile{
+		{Path: path("smw copy.sfc"), Reason: "another file is renamed to Super Mario World (USA).sfc"},
+		{Path: path("Super Metroid (USA).sfc"), Reason: "already named"},
+		{Path: path("metroid-jp.sfc"), Reason: "Super Metroid (Japan).sfc already exists"},
+		{Path: path("hack.sfc"), Reason: "not a verified dump"},
+	}
+	if !reflect.DeepEqual(plan.Skipped, wantSkipped) {
+		t.Errorf("Skipped = %+v, want %+v", plan.Skipped, wantSkipped)
+	}
+
+	// An existing file with the same contents is a duplicate
+	writeFiles(t, root, map[string]string{"Super Mario World (USA).sfc": "smw"})
+	plan = PlanVerifiedRenames(results[:1], CanonicalOptions{})
+	if len(plan.Skipped) != 1 || plan.Skipped[0].Reason != "duplicate of Super Mario World (USA).sfc" {
+		t.Errorf("Skipped = %+v, want a duplicate", plan.Skipped)
+	}
+}
+
+func TestPlanCanonicalRenames(t *testing.T) {
+	root := t.TempDir()
+	path := func(name string) string { return filepath.Join(root, name) }
+	writeFiles(t, root, map[string]string{"a.sfc": "a", "b.sfc": "b", "c.sfc": "c"})
+
+	results := []identify.BatchResult{
+		{Group: identify.DiscGroup{Filename: path("a.sfc")}, Result: &retrometadata.GameResult{MatchType: "hash", Signatures: noIntro("Game A (USA)")}},
+		{Group: identify.DiscGroup{Filename: path("b.sfc")}, Result: &retrometadata.GameResult{MatchType: "filename", MatchScore: 0.8, Signatures: noIntro("Game B (USA)")}},
+		{Group: identify.DiscGroup{Filename: path("c.sfc")}, Result: &retrometadata.GameResult{MatchType: "filename", MatchScore: 0.99}},
+	}
+	plan := PlanCanonicalRenames(results, CanonicalOptions{})
+	if want := []Rename{{From: path("a.sfc"), To: path("Game A (USA).sfc")}}; !reflect.DeepEqual(plan.Renames, want) {
+		t.Errorf("Renames = %+v, want %+v", plan.Renames, want)
+	}
+	wantSkipped := []SkippedFile{
+		{Path: path("b.sfc"), Reason: "match score 0.80 below 0.95"},
+		{Path: path("c.sfc"), Reason: "no No-Intro name"},
+	}
+	if !reflect.DeepEqual(plan.Skipped, wantSkipped) {
+		t.Errorf("Skipped = %+v, want %+v", plan.Skipped, wantSkipped)
+	}
+
+	if plan = PlanCanonicalRenames(results[1:2], CanonicalOptions{MinScore: 0.75}); len(plan.Renames) != 1 {
+		t.Errorf("Renames with a lower MinScore = %+v, want b.sfc renamed", plan.Renames)
+	}
+}
+
+func TestJournalUndo(t *testing.T) {
+	root := t.TempDir()
+	path := func(name string) string { return filepath.Join(root, name) }
+	writeFiles(t, root, map[string]string{"a.sfc": "a", "b.sfc": "b"})
+	journal := path("renames.jsonl")
+
+	plan := &RenamePlan{Renames: []Rename{
+		{From: path("a.sfc"), To: path("Game A (USA).sfc")},
+		{From: path("b.sfc"), To: path("Game B (USA).sfc")},
+	}}
+	if err := plan.ApplyWithJournal(journal); err != nil {
+		t.Fatalf("ApplyWithJournal() error: %v", err)
+	}
+	entries, err := ReadJournal(journal)
+	if err != nil || len(entries) != 2 || entries[0].Rename != plan.Renames[0] || entries[0].RenamedAt.IsZero() {
+		t.Fatalf("ReadJournal() = %+v, %v", entries, err)
+	}
+
+	// A rename whose original name was taken again is kept for a retry
+	writeFiles(t, root, map[string]string{"b.sfc": "new b"})
+	if err := UndoJournal(journal, root); err == nil {
+		t.Error("UndoJournal() with a taken name succeeded")
+	}
+	if !exists(path("a.sfc")) || exists(path("Game A (USA).sfc")) {
+		t.Error("UndoJournal() didn't revert a.sfc")
+	}
+	if entries, _ := ReadJournal(journal); len(entries) != 1 || entries[0].From != path("b.sfc") {
+		t.Errorf("journal after a partial undo = %+v, want only b.sfc", entries)
+	}
+
+	if err := os.Remove(path("b.sfc")); err != nil {
+		t.Fatal(err)
+	}
+	if err := UndoJournal(journal, root); err != nil {
+		t.Fatalf("UndoJournal() error: %v", err)
+	}
+	if !exists(path("b.sfc")) || exists(journal) {
+		t.Error("UndoJournal() didn't revert b.sfc and remove the journal")
+	}
+}
+
+func TestJournalFailedRename(t *testing.T) {
+	root := t.TempDir()
+	path := func(name string) string { return filepath.Join(root, name) }
+	writeFiles(t, root, map[string]string{"a.sfc": "a", "b.sfc": "b"})
+	journal := path("renames.jsonl")
+
+	// The second rename fails, as its name is too long, but it was already
+	// journaled
+	plan := &RenamePlan{Renames: []Rename{
+		{From: path("a.sfc"), To: path("Game A (USA).sfc")},
+		{From: path("b.sfc"), To: path(strings.Repeat("Game B ", 64) + "(USA).sfc")},
+	}}
+	if err := plan.ApplyWithJournal(journal); err == nil {
+		t.Fatal("ApplyWithJournal() with a name too long succeeded")
+	}
+	if entries, err := ReadJournal(journal); err != nil || len(entries) != 2 {
+		t.Fatalf("ReadJournal() = %+v, %v; want both renames", entries, err)
+	}
+
+	// Undoing drops the rename that never happened
+	if err := UndoJournal(journal, root); err != nil {
+		t.Fatalf("UndoJournal() error: %v", err)
+	}
+	if !exists(path("a.sfc")) || !exists(path("b.sfc")) || exists(journal) {
+		t.Error("UndoJournal() didn't revert a.sfc and remove the journal")
+	}
+}
+
+func TestJournalInvalid(t *testing.T) {
+	root := t.TempDir()
+	path := func(name string) string { return filepath.Join(root, name) }
+	writeFiles(t, root, map[string]string{"Game A (USA).sfc": "a"})
+	outside := t.TempDir()
+	writeFiles(t, outside, map[string]string{"Game B (USA).sfc": "b"})
+	entry := func(from, to string) string {
+		line, err := json.Marshal(JournalEntry{Rename: Rename{From: from, To: to}, RenamedAt: time.Now()})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(line) + "\n"
+	}
+	valid := entry(path("a.sfc"), path("Game A (USA).sfc"))
+
+	tests := []struct {
+		name    string
+		journal string
+	}{
+		{"truncated", valid + valid[:len(valid)/2]},
+		{"corrupt", valid + "not json\n"},
+		{"incomplete", valid + `{"from":"` + path("b.sfc") + `"}` + "\n"},
+		{"other directory", valid + entry(path("b.sfc"), path("sub/Game B (USA).sfc"))},
+		{"dot dot", valid + entry(path("b.sfc"), root+string(filepath.Separator)+"..")},
+		{"outside root", valid + entry(filepath.Join(outside, "b.sfc"), filepath.Join(outside, "Game B (USA).sfc"))},
+		{"escaping root", valid + entry(path("../"+filepath.Base(outside)+"/b.sfc"), root+"/../"+filepath.Base(outside)+"/Game B (USA).sfc")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			journal := filepath.Join(t.TempDir(), "renames.jsonl")
+			if err := os.WriteFile(journal, []byte(tt.journal), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			if err := UndoJournal(journal, root); err == nil {
+				t.Error("UndoJournal() succeeded")
+			}
+			if exists(path("a.sfc")) || exists(filepath.Join(outside, "b.sfc")) || !exists(journal) {
+				t.Error("UndoJournal() reverted renames of an invalid journal")
+			}
+		})
+	}
+}
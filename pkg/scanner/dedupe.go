@@ -0,0 +1,342 @@
+package scanner
+
+import (
+	"archive/zip"
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/josegonzalez/retro-metadata/pkg/filename"
+	"github.com/josegonzalez/retro-metadata/pkg/identify"
+	"github.com/josegonzalez/retro-metadata/pkg/internal/hashing"
+)
+
+// Copy is a copy of a ROM: a file, or a file in a zip archive.
+type Copy struct {
+	// Path is the file's path
+	Path string `json:"path"`
+	// Member is the name of the ROM in the zip archive at Path, if any
+	Member string `json:"member,omitempty"`
+}
+
+// String returns the copy's path, followed by its member name if any.
+func (c Copy) String() string {
+	if c.Member == "" {
+		return c.Path
+	}
+	return c.Path + ":" + c.Member
+}
+
+// DuplicateSet is a ROM with several identical copies.
+type DuplicateSet struct {
+	// SHA1 is the SHA1 of the ROM's contents
+	SHA1 string `json:"sha1"`
+	// Size is the ROM's size in bytes
+	Size int64 `json:"size"`
+	// Copies are the ROM's copies, sorted by path
+	Copies []Copy `json:"copies"`
+}
+
+// Wasted returns the space taken by every copy but one.
+func (s DuplicateSet) Wasted() int64 {
+	return s.Size * int64(len(s.Copies)-1)
+}
+
+// Variant is one release of a game in a VariantGroup.
+type Variant struct {
+	// Path is the game's file as scanned: the scan root joined with the
+	// file's path below it, so it's absolute only if the root was. Any disc
+	// tag is removed for multi-disc sets
+	Path string `json:"path"`
+	// Region is the region code from the filename, if any
+	Region string `json:"region,omitempty"`
+	// Version is the version tag from the filename, like "Rev 1", if any
+	Version string `json:"version,omitempty"`
+	// DevStatus is the development status from the filename, like "beta", if any
+	DevStatus string `json:"dev_status,omitempty"`
+	// SHA1 is the SHA1 of the file, or of the first disc, if it was hashed.
+	// For zip archives it's the one their DuplicateSet has
+	SHA1 string `json:"sha1,omitempty"`
+}
+
+// VariantGroup is a game with several different files, like releases for
+// other regions or revisions.
+type VariantGroup struct {
+	// Game is the name of the identified game
+	Game string `json:"game"`
+	// Provider is the provider the game was identified by
+	Provider string `json:"provider"`
+	// ID is the game's ID with the provider, if it has one
+	ID string `json:"id,omitempty"`
+	// Variants are the game's files, in scan order
+	Variants []Variant `json:"variants"`
+}
+
+// DedupeReport lists the duplicate ROMs of a library.
+type DedupeReport struct {
+	// Duplicates are the ROMs with identical copies, sorted by path
+	Duplicates []DuplicateSet `json:"duplicates"`
+	// Variants are the games with several different files
+	Variants []VariantGroup `json:"variants"`
+	// Wasted is the space taken by identical copies, in bytes
+	Wasted int64 `json:"wasted"`
+	// Unreadable are the files that couldn't be hashed
+	Unreadable []SkippedFile `json:"unreadable,omitempty"`
+}
+
+// Deduplicate finds the duplicates among identification results, like those
+// of identify.Pipeline.IdentifyBatch.
+//
+// Identical ROMs are found by content, wherever they are: files are hashed
+// (reusing the hashes of single-file groups), as are the ROMs of zip archives
+// holding a single file, so "Game.zip" is a copy of a "Game.sfc" it contains.
+// Zip archives holding several files, like arcade sets, are compared as a
+// whole by the hashes of their files. Every disc of a multi-disc set is
+// compared; playlists are not, as they reference files by name.
+//
+// Near-duplicates are games identified more than once with different
+// contents, grouped by their provider ID, or by name when they have none.
+// Games identified by different providers are grouped separately.
+//
+// Cancelling ctx stops the hashing and returns the context error.
+func Deduplicate(ctx context.Context, results []identify.BatchResult) (*DedupeReport, error) {
+	report := &DedupeReport{}
+	sets := make(map[string]*DuplicateSet)
+	// sums are the SHA1s of the hashed files, by path
+	sums := make(map[string]string)
+	for _, result := range results {
+		for _, path := range groupFiles(result.Group) {
+			if err := ctx.Err(); err != nil {
+				return nil, err
+			}
+			var found hashedCopy
+			var err error
+			if !result.Group.IsMultiDisc() && result.Group.Hashes != nil && result.Group.Hashes.SHA1 != "" && !isZip(path) {
+				found, err = statCopy(path, result.Group.Hashes.SHA1)
+			} else {
+				found, err = hashCopy(ctx, path)
+			}
+			if ctxErr := ctx.Err(); ctxErr != nil {
+				return nil, ctxErr
+			}
+			if err != nil {
+				report.Unreadable = append(report.Unreadable, SkippedFile{Path: path, Reason: err.Error()})
+				continue
+			}
+
+			sums[path] = found.sha1
+			set, ok := sets[found.sha1]
+			if !ok {
+				set = &DuplicateSet{SHA1: found.sha1, Size: found.size}
+				sets[found.sha1] = set
+			}
+			set.Copies = append(set.Copies, found.Copy)
+		}
+	}
+
+	for _, set := range sets {
+		if len(set.Copies) < 2 {
+			continue
+		}
+		sort.Slice(set.Copies, func(i, j int) bool { return set.Copies[i].String() < set.Copies[j].String() })
+		report.Duplicates = append(report.Duplicates, *set)
+		report.Wasted += set.Wasted()
+	}
+	sort.Slice(report.Duplicates, func(i, j int) bool {
+		return report.Duplicates[i].Copies[0].String() < report.Duplicates[j].Copies[0].String()
+	})
+
+	report.Variants = variantGroups(results, sums)
+	return report, nil
+}
+
+// variantGroups groups identified games with different contents, using the
+// SHA1s of their files.
+func variantGroups(results []identify.BatchResult, sums map[string]string) []VariantGroup {
+	var keys []string
+	games := make(map[string]*VariantGroup)
+	contents := make(map[string]map[string]bool)
+	for _, result := range results {
+		game := result.Result
+		if result.Err != nil || game == nil {
+			continue
+		}
+		provider, id := taggedID(game, "")
+		key := provider + ":" + id
+		if id == "" {
+			key = provider + ":name:" + strings.ToLower(game.Name)
+		}
+		group, ok := games[key]
+		if !ok {
+			group = &VariantGroup{Game: game.Name, Provider: provider, ID: id}
+			games[key] = group
+			contents[key] = make(map[string]bool)
+			keys = append(keys, key)
+		}
+
+		parsed := filename.ParseNoIntroFilename(filepath.Base(result.Group.Filename))
+		variant := Variant{
+			Path:      result.Group.Filename,
+			Region:    parsed.Region,
+			Version:   parsed.Version,
+			DevStatus: parsed.DevStatus,
+		}
+		if paths := groupFiles(result.Group); len(paths) > 0 {
+			variant.SHA1 = sums[paths[0]]
+		}
+		group.Variants = append(group.Variants, variant)
+		// Files that weren't hashed can't be told apart, so count as different
+		content := variant.SHA1
+		if content == "" {
+			content = variant.Path
+		}
+		contents[key][content] = true
+	}
+
+	var groups []VariantGroup
+	for _, key := range keys {
+		if len(contents[key]) > 1 {
+			groups = append(groups, *games[key])
+		}
+	}
+	return groups
+}
+
+// groupFiles returns the files of a group compared for identical copies.
+func groupFiles(group identify.DiscGroup) []string {
+	if identify.IsPlaylist(group.Filename) {
+		return nil
+	}
+	if !group.IsMultiDisc() {
+		return []string{group.Filename}
+	}
+	paths := make([]string, 0, len(group.Discs))
+	for _, disc := range group.Discs {
+		if !identify.IsPlaylist(disc.Filename) {
+			paths = append(paths, disc.Filename)
+		}
+	}
+	return paths
+}
+
+// hashedCopy is a copy with its contents' SHA1 and size.
+type hashedCopy struct {
+	Copy
+	sha1 string
+	size int64
+}
+
+// statCopy returns the copy at path, whose SHA1 is known.
+func statCopy(path, sha1 string) (hashedCopy, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return hashedCopy{}, err
+	}
+	return hashedCopy{Copy: Copy{Path: path}, sha1: strings.ToLower(sha1), size: info.Size()}, nil
+}
+
+// hashCopy hashes the copy at path: the file, or the ROMs of a zip archive.
+func hashCopy(ctx context.Context, path string) (hashedCopy, error) {
+	if isZip(path) {
+		return hashZip(ctx, path)
+	}
+	hashes, err := hashing.ComputeFileHashesContext(ctx, path)
+	if err != nil {
+		return hashedCopy{}, err
+	}
+	return statCopy(path, hashes.SHA1)
+}
+
+// hashZip hashes the ROMs of a zip archive. An archive holding a single ROM
+// is a copy of it; one holding several has the SHA1 of their sorted SHA1s.
+func hashZip(ctx context.Context, path string) (hashedCopy, error) {
+	archive, err := zip.OpenReader(path)
+	if err != nil {
+		return hashedCopy{}, fmt.Errorf("opening zip archive: %w", err)
+	}
+	defer archive.Close()
+
+	var members []*zip.File
+	for _, member := range archive.File {
+		if !member.FileInfo().IsDir() {
+			members = append(members, member)
+		}
+	}
+	if len(members) == 0 {
+		return hashedCopy{}, fmt.Errorf("zip archive is empty")
+	}
+
+	var sums []string
+	var size int64
+	for _, member := range members {
+		if err := ctx.Err(); err != nil {
+			return hashedCopy{}, err
+		}
+		sum, err := hashZipMember(member)
+		if err != nil {
+			return hashedCopy{}, fmt.Errorf("%s: %w", member.Name, err)
+		}
+		sums = append(sums, sum)
+		size += int64(member.UncompressedSize64)
+	}
+
+	if len(members) == 1 {
+		return hashedCopy{Copy: Copy{Path: path, Member: members[0].Name}, sha1: sums[0], size: size}, nil
+	}
+	sort.Strings(sums)
+	set := sha1.Sum([]byte(strings.Join(sums, "\n")))
+	return hashedCopy{Copy: Copy{Path: path}, sha1: hex.EncodeToString(set[:]), size: size}, nil
+}
+
+// hashZipMember returns the SHA1 of a file in a zip archive.
+func hashZipMember(member *zip.File) (string, error) {
+	reader, err := member.Open()
+	if err != nil {
+		return "", err
+	}
+	defer reader.Close()
+	return hashing.ComputeSHA1FromReader(reader)
+}
+
+// isZip reports whether path is a zip archive.
+func isZip(path string) bool {
+	return strings.EqualFold(filepath.Ext(path), ".zip")
+}
+
+// Print writes the report, one block per duplicate ROM and per game with
+// variants.
+func (r *DedupeReport) Print(w io.Writer) error {
+	for _, set := range r.Duplicates {
+		if _, err := fmt.Fprintf(w, "identical %s (%d copies, %d bytes each)\n", set.SHA1, len(set.Copies), set.Size); err != nil {
+			return err
+		}
+		for _, c := range set.Copies {
+			if _, err := fmt.Fprintf(w, "  %s\n", c); err != nil {
+				return err
+			}
+		}
+	}
+	for _, group := range r.Variants {
+		if _, err := fmt.Fprintf(w, "variants  %s (%d files)\n", group.Game, len(group.Variants)); err != nil {
+			return err
+		}
+		for _, variant := range group.Variants {
+			if _, err := fmt.Fprintf(w, "  %s\n", variant.Path); err != nil {
+				return err
+			}
+		}
+	}
+	for _, skipped := range r.Unreadable {
+		if _, err := fmt.Fprintf(w, "unreadable %s: %s\n", skipped.Path, skipped.Reason); err != nil {
+			return err
+		}
+	}
+	_, err := fmt.Fprintf(w, "%d identical ROMs wasting %d bytes, %d games with variants\n", len(r.Duplicates), r.Wasted, len(r.Variants))
+	return err
+}
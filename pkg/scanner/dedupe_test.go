@@ -0,0 +1,138 @@
+package scanner
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/josegonzalez/retro-metadata/pkg/identify"
+	"github.com/josegonzalez/retro-metadata/pkg/retrometadata"
+)
+
+func writeZip(t *testing.T, path string, files map[string]string) {
+	t.Helper()
+	var buf bytes.Buffer
+	archive := zip.NewWriter(&buf)
+	for name, content := range files {
+		w, err := archive.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.Write([]byte(content))
+	}
+	if err := archive.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, buf.Bytes(), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestDeduplicate(t *testing.T) {
+	root := t.TempDir()
+	for _, dir := range []string{"snes", "backup", "arcade"} {
+		if err := os.Mkdir(filepath.Join(root, dir), 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	path := func(name string) string { return filepath.Join(root, name) }
+	writeFiles(t, root, map[string]string{
+		"snes/Super Metroid (USA).sfc":       "metroid usa",
+		"backup/metroid.sfc":                 "metroid usa",
+		"snes/Super Metroid (Japan).sfc":     "metroid japan",
+		"snes/Super Mario World (USA).sfc":   "smw",
+		"snes/Super Mario World (Rev 1).sfc": "smw rev 1",
+	})
+	writeZip(t, path("backup/Super Metroid.zip"), map[string]string{"Super Metroid (USA).sfc": "metroid usa"})
+	writeZip(t, path("arcade/pacman.zip"), map[string]string{"a.rom": "a", "b.rom": "b"})
+	writeZip(t, path("backup/pacman.zip"), map[string]string{"b.rom": "b", "a.rom": "a"})
+
+	id := func(n int) *int { return &n }
+	metroid := &retrometadata.GameResult{Provider: "igdb", ProviderID: id(1103), Name: "Super Metroid"}
+	smw := &retrometadata.GameResult{Provider: "igdb", ProviderID: id(1070), Name: "Super Mario World"}
+	results := []identify.BatchResult{
+		{Group: identify.DiscGroup{Filename: path("arcade/pacman.zip")}},
+		{Group: identify.DiscGroup{Filename: path("backup/Super Metroid.zip")}, Result: metroid},
+		{Group: identify.DiscGroup{Filename: path("backup/metroid.sfc")}, Result: metroid},
+		{Group: identify.DiscGroup{Filename: path("backup/pacman.zip")}},
+		{Group: identify.DiscGroup{Filename: path("snes/Super Mario World (Rev 1).sfc")}, Result: smw},
+		{Group: identify.DiscGroup{Filename: path("snes/Super Mario World (USA).sfc")}, Result: smw},
+		{Group: identify.DiscGroup{Filename: path("snes/Super Metroid (Japan).sfc")}, Result: metroid},
+		{Group: identify.DiscGroup{Filename: path("snes/Super Metroid (USA).sfc")}, Result: metroid},
+		{Group: identify.DiscGroup{Filename: path("snes/missing.sfc")}},
+	}
+
+	report, err := Deduplicate(context.Background(), results)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var duplicates [][]Copy
+	for _, set := range report.Duplicates {
+		duplicates = append(duplicates, set.Copies)
+	}
+	wantDuplicates := [][]Copy{
+		{{Path: path("arcade/pacman.zip")}, {Path: path("backup/pacman.zip")}},
+		{
+			{Path: path("backup/Super Metroid.zip"), Member: "Super Metroid (USA).sfc"},
+			{Path: path("backup/metroid.sfc")},
+			{Path: path("snes/Super Metroid (USA).sfc")},
+		},
+	}
+	if !reflect.DeepEqual(duplicates, wantDuplicates) {
+		t.Errorf("Duplicates = %+v, want %+v", duplicates, wantDuplicates)
+	}
+	if want := int64(2 + 2*len("metroid usa")); report.Wasted != want {
+		t.Errorf("Wasted = %d, want %d", report.Wasted, want)
+	}
+
+	if len(report.Variants) != 2 {
+		t.Fatalf("Variants = %+v, want 2 games", report.Variants)
+	}
+	metroids := report.Variants[0]
+	if metroids.Game != "Super Metroid" || metroids.ID != "1103" || len(metroids.Variants) != 4 {
+		t.Errorf("Variants[0] = %+v, want the 4 Super Metroid files", metroids)
+	}
+	if region := metroids.Variants[2].Region; region != "jp" {
+		t.Errorf("Region = %q, want jp", region)
+	}
+	if version := report.Variants[1].Variants[0].Version; version != "Rev 1" {
+		t.Errorf("Version = %q, want Rev 1", version)
+	}
+
+	if len(report.Unreadable) != 1 || report.Unreadable[0].Path != path("snes/missing.sfc") {
+		t.Errorf("Unreadable = %+v, want the missing file", report.Unreadable)
+	}
+
+	var out strings.Builder
+	if err := report.Print(&out); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out.String(), "2 identical ROMs wasting 24 bytes, 2 games with variants") {
+		t.Errorf("Print() = %q", out.String())
+	}
+}
+
+func TestDeduplicateIdenticalVariants(t *testing.T) {
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{"a.sfc": "same", "b.sfc": "same"})
+	game := &retrometadata.GameResult{Provider: "igdb", Name: "Game"}
+	results := []identify.BatchResult{
+		{Group: identify.DiscGroup{Filename: filepath.Join(root, "a.sfc")}, Result: game},
+		{Group: identify.DiscGroup{Filename: filepath.Join(root, "b.sfc")}, Result: game},
+	}
+
+	report, err := Deduplicate(context.Background(), results)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Identical copies aren't variants of each other
+	if len(report.Duplicates) != 1 || len(report.Variants) != 0 {
+		t.Errorf("report = %+v, want one duplicate and no variants", report)
+	}
+}
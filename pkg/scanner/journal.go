@@ -0,0 +1,165 @@
+package scanner
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+// JournalEntry is a rename recorded in a journal file.
+type JournalEntry struct {
+	Rename
+	// RenamedAt is when the file was renamed
+	RenamedAt time.Time `json:"renamed_at"`
+}
+
+// ApplyWithJournal renames the files like Apply, appending each rename to
+// the journal file at path before it's made, one JSON object per line, so
+// UndoJournal can revert them, even after an interrupted run. A rename that
+// fails stays in the journal, and UndoJournal drops it.
+func (p *RenamePlan) ApplyWithJournal(path string) error {
+	journal, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return err
+	}
+	encoder := json.NewEncoder(journal)
+
+	err = p.apply(func(rename Rename) error {
+		if err := encoder.Encode(JournalEntry{Rename: rename, RenamedAt: time.Now().UTC()}); err != nil {
+			return fmt.Errorf("writing journal: %w", err)
+		}
+		return journal.Sync()
+	})
+	if closeErr := journal.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// ReadJournal returns the renames recorded in a journal file, oldest first.
+// A corrupt or truncated record, or one that doesn't rename a file within its
+// directory, fails the whole journal.
+func ReadJournal(path string) ([]JournalEntry, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var entries []JournalEntry
+	lines := bufio.NewScanner(file)
+	lines.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for line := 1; lines.Scan(); line++ {
+		if len(lines.Bytes()) == 0 {
+			continue
+		}
+		var entry JournalEntry
+		if err := json.Unmarshal(lines.Bytes(), &entry); err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", path, line, err)
+		}
+		if err := entry.check(); err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", path, line, err)
+		}
+		entries = append(entries, entry)
+	}
+	return entries, lines.Err()
+}
+
+// UndoJournal reverts the renames recorded in a journal file of files under
+// the directory root, newest first. Nothing is reverted if the journal can't
+// be read or renames a file outside root. Renames that were never made, their
+// file still at its original name, are dropped. Renames whose file was moved
+// again, or whose original name was taken since, are left alone and reported.
+// The journal is removed once every rename is reverted, and rewritten with
+// the ones that weren't otherwise, so the undo can be retried.
+func UndoJournal(path, root string) error {
+	entries, err := ReadJournal(path)
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		if !within(root, entry.From) || !within(root, entry.To) {
+			return fmt.Errorf("%s: %s is outside %s", path, entry.From, root)
+		}
+	}
+
+	var errs []error
+	var remaining []JournalEntry
+	for _, entry := range slices.Backward(entries) {
+		switch {
+		case !exists(entry.To) && exists(entry.From):
+			continue
+		case !exists(entry.To):
+			err = fmt.Errorf("undoing %s: %s no longer exists", entry.From, entry.To)
+		case exists(entry.From) && !sameFile(entry.From, entry.To):
+			err = fmt.Errorf("undoing %s: it exists again", entry.From)
+		default:
+			err = os.Rename(entry.To, entry.From)
+		}
+		if err != nil {
+			errs = append(errs, err)
+			remaining = append(remaining, entry)
+		}
+	}
+
+	if len(remaining) == 0 {
+		return errors.Join(append(errs, os.Remove(path))...)
+	}
+	slices.Reverse(remaining)
+	return errors.Join(append(errs, writeJournal(path, remaining))...)
+}
+
+// check returns an error unless the entry is complete and renames a file
+// within its directory, as rename plans do.
+func (e JournalEntry) check() error {
+	switch {
+	case e.From == "" || e.To == "" || e.RenamedAt.IsZero():
+		return errors.New("incomplete journal entry")
+	case filepath.Dir(e.From) != filepath.Dir(e.To):
+		return fmt.Errorf("%s is renamed out of its directory", e.From)
+	}
+	for _, name := range []string{filepath.Base(e.From), filepath.Base(e.To)} {
+		if name == "." || name == ".." || name == string(filepath.Separator) {
+			return fmt.Errorf("invalid file name %q", name)
+		}
+	}
+	return nil
+}
+
+// within reports whether path is inside the directory root.
+func within(root, path string) bool {
+	root, err := filepath.Abs(root)
+	if err != nil {
+		return false
+	}
+	path, err = filepath.Abs(path)
+	if err != nil {
+		return false
+	}
+	rel, err := filepath.Rel(root, path)
+	return err == nil && rel != "." && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// writeJournal replaces a journal file with entries.
+func writeJournal(path string, entries []JournalEntry) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	encoder := json.NewEncoder(file)
+	for _, entry := range entries {
+		if err = encoder.Encode(entry); err != nil {
+			break
+		}
+	}
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
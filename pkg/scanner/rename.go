@@ -0,0 +1,219 @@
+package scanner
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"github.com/josegonzalez/retro-metadata/pkg/filename"
+	"github.com/josegonzalez/retro-metadata/pkg/identify"
+	"github.com/josegonzalez/retro-metadata/pkg/internal/hashing"
+	"github.com/josegonzalez/retro-metadata/pkg/retrometadata"
+)
+
+// Rename is a proposed rename of a ROM file.
+type Rename struct {
+	// From is the file's current path
+	From string `json:"from"`
+	// To is the proposed path
+	To string `json:"to"`
+}
+
+// SkippedFile is a file a RenamePlan leaves alone.
+type SkippedFile struct {
+	// Path is the file's path
+	Path string `json:"path"`
+	// Reason explains why it isn't renamed
+	Reason string `json:"reason"`
+}
+
+// RenamePlan proposes renaming identified ROM files to their canonical
+// names, "Clean Name (Region) (igdb-1234).ext" (see filename.CleanAndTag).
+type RenamePlan struct {
+	// Renames are the proposed renames
+	Renames []Rename `json:"renames"`
+	// Skipped are the files that aren't renamed
+	Skipped []SkippedFile `json:"skipped,omitempty"`
+}
+
+// RenameOptions configures PlanRenames.
+type RenameOptions struct {
+	// Provider is the provider whose ID tags the files. Empty uses the
+	// provider each game was identified by
+	Provider string
+	// KeepTitles keeps the titles of the filenames instead of using the
+	// names of the identified games
+	KeepTitles bool
+}
+
+// PlanRenames proposes renames for identification results. Files that
+// weren't identified, have no ID for the provider, are already named, or are
+// part of a playlist (which references them by name) are skipped, as are
+// renames onto existing files or onto the target of another rename. Nothing
+// is renamed until Apply.
+func PlanRenames(results []identify.BatchResult, opts RenameOptions) *RenamePlan {
+	r := newRenamer()
+	for _, result := range results {
+		group := result.Group
+		switch {
+		case result.Err != nil || result.Result == nil:
+			r.skip(group.Filename, "not identified")
+			continue
+		case identify.IsPlaylist(group.Filename):
+			r.skip(group.Filename, "playlists reference their files by name")
+			continue
+		}
+
+		provider, id := taggedID(result.Result, opts.Provider)
+		if id == "" {
+			r.skip(group.Filename, fmt.Sprintf("no %s ID", provider))
+			continue
+		}
+		title := result.Result.Name
+		if opts.KeepTitles {
+			title = ""
+		}
+
+		paths := []string{group.Filename}
+		if group.IsMultiDisc() {
+			paths = paths[:0]
+			for _, disc := range group.Discs {
+				paths = append(paths, disc.Filename)
+			}
+		}
+		for _, path := range paths {
+			r.propose(path, filename.CleanAndTag(path, title, provider, id))
+		}
+	}
+	return r.plan
+}
+
+// renamer builds a RenamePlan, skipping renames that would collide.
+type renamer struct {
+	plan    *RenamePlan
+	targets map[string]bool
+}
+
+func newRenamer() *renamer {
+	return &renamer{plan: &RenamePlan{}, targets: make(map[string]bool)}
+}
+
+func (r *renamer) skip(path, reason string) {
+	r.plan.Skipped = append(r.plan.Skipped, SkippedFile{Path: path, Reason: reason})
+}
+
+// propose adds the rename of from to to, unless it's named so already or
+// to is taken: by another rename of the plan, or by an existing file, which
+// is reported as a duplicate if it has the same contents.
+func (r *renamer) propose(from, to string) {
+	switch {
+	case to == from:
+		r.skip(from, "already named")
+	case r.targets[to]:
+		r.skip(from, fmt.Sprintf("another file is renamed to %s", filepath.Base(to)))
+	case exists(to) && !sameFile(from, to):
+		if sameContents(from, to) {
+			r.skip(from, fmt.Sprintf("duplicate of %s", filepath.Base(to)))
+		} else {
+			r.skip(from, fmt.Sprintf("%s already exists", filepath.Base(to)))
+		}
+	default:
+		r.targets[to] = true
+		r.plan.Renames = append(r.plan.Renames, Rename{From: from, To: to})
+	}
+}
+
+// taggedID returns the provider whose ID tags a game, and the game's ID with
+// it, or "" if the game has none.
+func taggedID(game *retrometadata.GameResult, provider string) (string, string) {
+	if provider == "" || provider == game.Provider {
+		if game.ProviderUID != "" {
+			return game.Provider, game.ProviderUID
+		}
+		if game.ProviderID != nil {
+			return game.Provider, strconv.Itoa(*game.ProviderID)
+		}
+		if provider == "" {
+			return game.Provider, ""
+		}
+	}
+	if uid, ok := game.ProviderUIDs[provider]; ok && uid != "" {
+		return provider, uid
+	}
+	if id, ok := game.ProviderIDs[provider]; ok {
+		return provider, strconv.Itoa(id)
+	}
+	return provider, ""
+}
+
+// exists reports whether a file exists at path.
+func exists(path string) bool {
+	_, err := os.Lstat(path)
+	return err == nil
+}
+
+// sameFile reports whether two paths are the same file, as they are for a
+// rename changing only the case on a case-insensitive filesystem.
+func sameFile(a, b string) bool {
+	infoA, errA := os.Stat(a)
+	infoB, errB := os.Stat(b)
+	return errA == nil && errB == nil && os.SameFile(infoA, infoB)
+}
+
+// sameContents reports whether two files have the same contents.
+func sameContents(a, b string) bool {
+	infoA, errA := os.Stat(a)
+	infoB, errB := os.Stat(b)
+	if errA != nil || errB != nil || infoA.Size() != infoB.Size() {
+		return false
+	}
+	hashA, errA := hashing.ComputeSHA1(a)
+	hashB, errB := hashing.ComputeSHA1(b)
+	return errA == nil && errB == nil && hashA == hashB
+}
+
+// Print writes the plan as a dry run, one "rename" or "skip" line per file.
+func (p *RenamePlan) Print(w io.Writer) error {
+	for _, rename := range p.Renames {
+		if _, err := fmt.Fprintf(w, "rename %s -> %s\n", rename.From, filepath.Base(rename.To)); err != nil {
+			return err
+		}
+	}
+	for _, skipped := range p.Skipped {
+		if _, err := fmt.Fprintf(w, "skip   %s: %s\n", skipped.Path, skipped.Reason); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Apply renames the files. Renames whose target was created since the plan
+// was made are skipped rather than overwriting it; the errors of every
+// failed rename are returned together.
+func (p *RenamePlan) Apply() error {
+	return p.apply(nil)
+}
+
+// apply implements Apply, calling before ahead of each rename. An error from
+// before stops the renames.
+func (p *RenamePlan) apply(before func(Rename) error) error {
+	var errs []error
+	for _, rename := range p.Renames {
+		if exists(rename.To) && !sameFile(rename.From, rename.To) {
+			errs = append(errs, fmt.Errorf("renaming %s: %s already exists", rename.From, rename.To))
+			continue
+		}
+		if before != nil {
+			if err := before(rename); err != nil {
+				return errors.Join(append(errs, err)...)
+			}
+		}
+		if err := os.Rename(rename.From, rename.To); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
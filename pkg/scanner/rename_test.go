@@ -0,0 +1,100 @@
+package scanner
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/josegonzalez/retro-metadata/pkg/identify"
+	"github.com/josegonzalez/retro-metadata/pkg/retrometadata"
+)
+
+func TestPlanRenames(t *testing.T) {
+	root := t.TempDir()
+	for _, name := range []string{
+		"smw (U) [!].sfc",
+		"Super Metroid (USA) (igdb-1103).sfc",
+		"FF7 (USA) (Disc 1).bin",
+		"FF7 (USA) (Disc 2).bin",
+		"Game.m3u",
+		"Mystery.sfc",
+		"Taken (igdb-3).sfc",
+		"Other.sfc",
+	} {
+		if err := os.WriteFile(filepath.Join(root, name), []byte(name), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	path := func(name string) string { return filepath.Join(root, name) }
+	id := func(n int) *int { return &n }
+
+	results := []identify.BatchResult{
+		{Group: identify.DiscGroup{Filename: path("smw (U) [!].sfc")}, Result: &retrometadata.GameResult{Name: "Super Mario World", Provider: "igdb", ProviderID: id(1070)}},
+		{Group: identify.DiscGroup{Filename: path("Super Metroid (USA) (igdb-1103).sfc")}, Result: &retrometadata.GameResult{Name: "Super Metroid", Provider: "igdb", ProviderID: id(1103)}},
+		{Group: identify.DiscGroup{Filename: path("FF7 (USA).bin"), Discs: []retrometadata.Disc{
+			{Number: 1, Filename: path("FF7 (USA) (Disc 1).bin")},
+			{Number: 2, Filename: path("FF7 (USA) (Disc 2).bin")},
+		}}, Result: &retrometadata.GameResult{Name: "Final Fantasy VII", Provider: "igdb", ProviderID: id(427)}},
+		{Group: identify.DiscGroup{Filename: path("Game.m3u")}, Result: &retrometadata.GameResult{Name: "Game", Provider: "igdb", ProviderID: id(1)}},
+		{Group: identify.DiscGroup{Filename: path("Mystery.sfc")}, Err: errors.New("not found")},
+		{Group: identify.DiscGroup{Filename: path("Other.sfc")}, Result: &retrometadata.GameResult{Name: "Taken", Provider: "igdb", ProviderID: id(3)}},
+	}
+
+	plan := PlanRenames(results, RenameOptions{})
+	wantRenames := []Rename{
+		{From: path("smw (U) [!].sfc"), To: path("Super Mario World (U) (igdb-1070).sfc")},
+		{From: path("FF7 (USA) (Disc 1).bin"), To: path("Final Fantasy VII (USA) (Disc 1) (igdb-427).bin")},
+		{From: path("FF7 (USA) (Disc 2).bin"), To: path("Final Fantasy VII (USA) (Disc 2) (igdb-427).bin")},
+	}
+	if !reflect.DeepEqual(plan.Renames, wantRenames) {
+		t.Errorf("Renames = %+v, want %+v", plan.Renames, wantRenames)
+	}
+	var skipped []string
+	for _, s := range plan.Skipped {
+		skipped = append(skipped, filepath.Base(s.Path)+": "+s.Reason)
+	}
+	wantSkipped := []string{
+		"Super Metroid (USA) (igdb-1103).sfc: already named",
+		"Game.m3u: playlists reference their files by name",
+		"Mystery.sfc: not identified",
+		"Other.sfc: Taken (igdb-3).sfc already exists",
+	}
+	if !reflect.DeepEqual(skipped, wantSkipped) {
+		t.Errorf("Skipped = %q, want %q", skipped, wantSkipped)
+	}
+
+	// Tagging with another provider uses the game's ID with it
+	results[0].Result.ProviderIDs = map[string]int{"mobygames": 5}
+	plan = PlanRenames(results[:2], RenameOptions{Provider: "mobygames", KeepTitles: true})
+	if len(plan.Renames) != 1 || plan.Renames[0].To != path("smw (U) (moby-5).sfc") {
+		t.Errorf("Renames with another provider = %+v", plan.Renames)
+	}
+	if len(plan.Skipped) != 1 || plan.Skipped[0].Reason != "no mobygames ID" {
+		t.Errorf("Skipped with another provider = %+v", plan.Skipped)
+	}
+
+	// A dry run prints the plan without renaming anything
+	var out strings.Builder
+	plan = PlanRenames(results, RenameOptions{})
+	if err := plan.Print(&out); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out.String(), "rename "+path("smw (U) [!].sfc")+" -> Super Mario World (U) (igdb-1070).sfc\n") {
+		t.Errorf("Print() = %q", out.String())
+	}
+	if _, err := os.Stat(path("smw (U) [!].sfc")); err != nil {
+		t.Error("Print() renamed files")
+	}
+
+	if err := plan.Apply(); err != nil {
+		t.Fatalf("Apply() error: %v", err)
+	}
+	for _, rename := range plan.Renames {
+		if _, err := os.Stat(rename.To); err != nil {
+			t.Errorf("Apply() didn't rename %s", rename.From)
+		}
+	}
+}
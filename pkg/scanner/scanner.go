@@ -0,0 +1,327 @@
+// Package scanner finds and hashes ROM files in a directory tree.
+//
+// Scanning collapses multi-disc sets and m3u/cue/gdi playlists into a single
+// identify.DiscGroup per game, ready to be passed to an identify.Pipeline.
+package scanner
+
+import (
+	"context"
+	"io/fs"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/josegonzalez/retro-metadata/pkg/identify"
+	"github.com/josegonzalez/retro-metadata/pkg/internal/hashing"
+	"github.com/josegonzalez/retro-metadata/pkg/platform"
+	"github.com/josegonzalez/retro-metadata/pkg/progress"
+	"github.com/josegonzalez/retro-metadata/pkg/retrometadata"
+)
+
+// DefaultExtensions are the file extensions recognized as ROMs by default.
+var DefaultExtensions = []string{
+	// Nintendo
+	"nes", "unf", "unif", "fds", "sfc", "smc", "fig", "swc", "n64", "v64", "z64",
+	"gb", "gbc", "gba", "nds", "3ds", "cia",
+	// Sega
+	"md", "gen", "smd", "32x", "sms", "gg",
+	// Atari
+	"a26", "a52", "a78", "j64", "jag", "lnx",
+	// NEC, SNK, Bandai and others
+	"pce", "sgx", "ws", "wsc", "ngp", "ngc", "vec", "col", "int",
+	// Computers
+	"rom", "mx1", "mx2", "tap", "tzx", "z80", "sna", "dsk", "cdt", "d64", "t64", "prg", "crt", "adf", "ipf", "dms",
+	// Disc images and playlists
+	"iso", "bin", "cue", "gdi", "cdi", "chd", "pbp", "cso", "m3u",
+	// Archives (arcade sets)
+	"zip",
+}
+
+// DefaultCheckpointInterval is the number of files processed between checkpoints.
+const DefaultCheckpointInterval = 100
+
+// Scanner finds and hashes ROM files.
+type Scanner struct {
+	extensions         map[string]bool
+	hash               bool
+	progress           progress.Progress
+	checkpoint         func(State)
+	checkpointInterval int
+	prefetcher         Prefetcher
+}
+
+// State is the progress of a scan. It is passed to the checkpoint callback
+// so an interrupted scan can be persisted and continued later with Resume.
+type State struct {
+	// Root is the scanned directory
+	Root string `json:"root"`
+	// Checkpoint records how many files were processed and the last one
+	Checkpoint progress.Checkpoint `json:"checkpoint"`
+	// Groups are the playlist groups found so far
+	Groups []identify.DiscGroup `json:"groups,omitempty"`
+	// Files are the other files found so far, grouped when the scan finishes
+	Files []identify.File `json:"files,omitempty"`
+}
+
+// Option is a functional option for Scanner.
+type Option func(*Scanner)
+
+// WithExtensions sets the file extensions (without the dot) recognized as ROMs.
+func WithExtensions(extensions ...string) Option {
+	return func(s *Scanner) {
+		s.extensions = make(map[string]bool, len(extensions))
+		for _, ext := range extensions {
+			s.extensions[strings.ToLower(strings.TrimPrefix(ext, "."))] = true
+		}
+	}
+}
+
+// WithHashing enables or disables hashing of the scanned files.
+func WithHashing(enabled bool) Option {
+	return func(s *Scanner) {
+		s.hash = enabled
+	}
+}
+
+// WithProgress sets the progress reporter.
+func WithProgress(p progress.Progress) Option {
+	return func(s *Scanner) {
+		s.progress = p
+	}
+}
+
+// WithCheckpoint sets a callback that receives the scan state every interval
+// files and when the scan is cancelled. A zero interval uses
+// DefaultCheckpointInterval.
+func WithCheckpoint(interval int, fn func(State)) Option {
+	return func(s *Scanner) {
+		if interval <= 0 {
+			interval = DefaultCheckpointInterval
+		}
+		s.checkpoint = fn
+		s.checkpointInterval = interval
+	}
+}
+
+// Prefetcher warms a metadata cache for titles on a platform, like
+// retrometadata.Client.Prefetch.
+type Prefetcher interface {
+	Prefetch(ctx context.Context, platformSlug string, titles []string) (*retrometadata.PrefetchResult, error)
+}
+
+// WithPrefetch warms p's cache with the scanned games once a scan finishes,
+// so identifying them later doesn't wait on the providers. Games are grouped
+// by the platform detected from their path; games whose platform isn't
+// detected aren't prefetched.
+func WithPrefetch(p Prefetcher) Option {
+	return func(s *Scanner) {
+		s.prefetcher = p
+	}
+}
+
+// New creates a new Scanner. Files are hashed by default.
+func New(opts ...Option) *Scanner {
+	s := &Scanner{
+		hash:               true,
+		progress:           progress.Noop{},
+		checkpointInterval: DefaultCheckpointInterval,
+	}
+	WithExtensions(DefaultExtensions...)(s)
+
+	for _, opt := range opts {
+		opt(s)
+	}
+	s.progress = progress.OrNoop(s.progress)
+
+	return s
+}
+
+// Scan walks root and returns one group per game.
+//
+// Playlists are resolved to the data they reference and the referenced tracks
+// are not reported separately. Other files are grouped by disc number.
+//
+// Cancelling ctx stops the scan between files, or part way through hashing a
+// large file, and returns the context error.
+func (s *Scanner) Scan(ctx context.Context, root string) ([]identify.DiscGroup, error) {
+	return s.Resume(ctx, State{Root: root})
+}
+
+// Resume continues a scan from a state received by the checkpoint callback.
+// Files up to and including the checkpoint cursor are not scanned again.
+func (s *Scanner) Resume(ctx context.Context, state State) ([]identify.DiscGroup, error) {
+	paths, err := s.findFiles(ctx, state.Root)
+	if err != nil {
+		return nil, err
+	}
+
+	state.Checkpoint.Operation = "scan"
+	state.Checkpoint.Total = len(paths)
+	state.Groups = slices.Clone(state.Groups)
+	state.Files = slices.Clone(state.Files)
+
+	s.progress.Start(len(paths))
+	defer s.progress.Done()
+
+	// Files referenced by playlists are part of the playlist's game
+	referenced := make(map[string]bool)
+	for _, path := range paths {
+		if !identify.IsPlaylist(path) {
+			continue
+		}
+		refs, err := identify.ParsePlaylist(path)
+		if err != nil {
+			continue
+		}
+		for _, ref := range refs {
+			referenced[ref] = true
+			// Cue sheets referenced by an m3u reference their own tracks
+			if identify.IsPlaylist(ref) {
+				tracks, _ := identify.ParsePlaylist(ref)
+				for _, track := range tracks {
+					referenced[track] = true
+				}
+			}
+		}
+	}
+
+	// Paths are sorted, so everything up to the cursor was already processed
+	start := 0
+	if cursor := state.Checkpoint.Cursor; cursor != "" {
+		start = sort.Search(len(paths), func(i int) bool { return paths[i] > cursor })
+	}
+	state.Checkpoint.Completed = start
+	s.progress.Increment(start)
+
+	for i, path := range paths[start:] {
+		if err := ctx.Err(); err != nil {
+			s.emitCheckpoint(state)
+			return nil, err
+		}
+		s.progress.SetStatus(filepath.Base(path))
+
+		switch {
+		case referenced[path]:
+			// Covered by the playlist that references it
+		case identify.IsPlaylist(path):
+			group := identify.DiscGroup{Filename: path}
+			if s.hash {
+				if resolved, err := identify.ResolvePlaylist(path); err == nil {
+					group = resolved
+				}
+			}
+			state.Groups = append(state.Groups, group)
+		default:
+			file := identify.File{Path: path}
+			if s.hash {
+				hashes, err := hashFile(ctx, path)
+				if ctxErr := ctx.Err(); ctxErr != nil {
+					s.emitCheckpoint(state)
+					return nil, ctxErr
+				}
+				if err == nil {
+					file.Hashes = hashes
+				}
+			}
+			state.Files = append(state.Files, file)
+		}
+
+		state.Checkpoint.Completed++
+		state.Checkpoint.Cursor = path
+		s.progress.Increment(1)
+
+		if (i+1)%s.checkpointInterval == 0 {
+			s.emitCheckpoint(state)
+		}
+	}
+
+	groups := append(state.Groups, identify.GroupDiscs(state.Files)...)
+	s.prefetch(ctx, groups)
+	return groups, nil
+}
+
+// prefetch warms the prefetcher's cache with the scanned games, if there is
+// a prefetcher. Prefetching is best effort: it stops if ctx is done, and
+// provider errors are ignored.
+func (s *Scanner) prefetch(ctx context.Context, groups []identify.DiscGroup) {
+	if s.prefetcher == nil {
+		return
+	}
+	titles := make(map[platform.Slug][]string)
+	var slugs []platform.Slug
+	for _, group := range groups {
+		slug := platform.DetectFromPath(group.Filename)
+		if !slug.IsValid() {
+			continue
+		}
+		if _, ok := titles[slug]; !ok {
+			slugs = append(slugs, slug)
+		}
+		titles[slug] = append(titles[slug], group.Filename)
+	}
+	for _, slug := range slugs {
+		if ctx.Err() != nil {
+			return
+		}
+		_, _ = s.prefetcher.Prefetch(ctx, string(slug), titles[slug])
+	}
+}
+
+// emitCheckpoint passes a copy of the state to the checkpoint callback, if any.
+func (s *Scanner) emitCheckpoint(state State) {
+	if s.checkpoint == nil {
+		return
+	}
+	state.Groups = slices.Clone(state.Groups)
+	state.Files = slices.Clone(state.Files)
+	s.checkpoint(state)
+}
+
+// findFiles returns the sorted paths of every ROM file under root.
+func (s *Scanner) findFiles(ctx context.Context, root string) ([]string, error) {
+	var paths []string
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if path != root && strings.HasPrefix(d.Name(), ".") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if s.extensions[strings.ToLower(strings.TrimPrefix(filepath.Ext(path), "."))] {
+			paths = append(paths, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Strings(paths)
+	return paths, nil
+}
+
+// hashFile computes the hashes of a file.
+func hashFile(ctx context.Context, path string) (*retrometadata.FileHashes, error) {
+	fileHashes, err := hashing.ComputeFileHashesContext(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+
+	hashes := &retrometadata.FileHashes{
+		MD5:    fileHashes.MD5,
+		SHA1:   fileHashes.SHA1,
+		CRC32:  fileHashes.CRC32,
+		SHA256: fileHashes.SHA256,
+	}
+	if size, err := identify.ExpectedSize(path); err == nil {
+		hashes.Size = size
+	}
+	return hashes, nil
+}
@@ -0,0 +1,148 @@
+package scanner
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/josegonzalez/retro-metadata/pkg/progress"
+	"github.com/josegonzalez/retro-metadata/pkg/retrometadata"
+)
+
+func TestScan(t *testing.T) {
+	root := t.TempDir()
+	files := map[string]string{
+		"snes/Super Mario World (USA).sfc":    "smw",
+		"psx/Game (Disc 1).bin":               "disc1",
+		"psx/Game (Disc 1).cue":               "FILE \"Game (Disc 1).bin\" BINARY\n",
+		"psx/Other (USA) (Disc 1).chd":        "other1",
+		"psx/Other (USA) (Disc 2).chd":        "other2",
+		"psx/readme.txt":                      "ignored",
+		".hidden/Super Mario World (USA).sfc": "hidden",
+	}
+	for name, content := range files {
+		path := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tracker := progress.NewTracker()
+	groups, err := New(WithProgress(tracker)).Scan(context.Background(), root)
+	if err != nil {
+		t.Fatalf("Scan() error: %v", err)
+	}
+
+	byName := make(map[string]int)
+	for i, group := range groups {
+		rel, _ := filepath.Rel(root, group.Filename)
+		byName[rel] = i
+	}
+	if len(groups) != 3 {
+		t.Fatalf("Scan() returned %d groups, expected 3: %v", len(groups), byName)
+	}
+
+	cue, ok := byName["psx/Game (Disc 1).cue"]
+	if !ok || groups[cue].Hashes == nil {
+		t.Errorf("Expected cue sheet group with data track hashes, got %v", byName)
+	}
+
+	other, ok := byName["psx/Other (USA).chd"]
+	if !ok || len(groups[other].Discs) != 2 {
+		t.Errorf("Expected multi-disc group for Other, got %v", byName)
+	}
+
+	if _, ok := byName["snes/Super Mario World (USA).sfc"]; !ok {
+		t.Errorf("Expected single file group, got %v", byName)
+	}
+
+	if snapshot := tracker.Snapshot(); snapshot.Total != 5 || snapshot.Completed != 5 || !snapshot.Done {
+		t.Errorf("Unexpected progress snapshot: %+v", snapshot)
+	}
+}
+
+func TestScanCheckpointResume(t *testing.T) {
+	root := t.TempDir()
+	for _, name := range []string{"a.sfc", "b.sfc", "c.sfc", "d.sfc", "e.sfc"} {
+		if err := os.WriteFile(filepath.Join(root, name), []byte(name), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Cancel the scan from the second checkpoint, as a GUI would
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var states []State
+	scanner := New(WithCheckpoint(1, func(state State) {
+		states = append(states, state)
+		if len(states) == 2 {
+			cancel()
+		}
+	}))
+
+	if _, err := scanner.Scan(ctx, root); err != context.Canceled {
+		t.Fatalf("Scan() error = %v, expected context.Canceled", err)
+	}
+	saved := states[len(states)-1]
+	if saved.Checkpoint.Completed != 2 || saved.Checkpoint.Total != 5 || len(saved.Files) != 2 {
+		t.Fatalf("Unexpected checkpoint state: %+v", saved)
+	}
+	if filepath.Base(saved.Checkpoint.Cursor) != "b.sfc" {
+		t.Errorf("Checkpoint cursor = %q, expected b.sfc", saved.Checkpoint.Cursor)
+	}
+
+	groups, err := New().Resume(context.Background(), saved)
+	if err != nil {
+		t.Fatalf("Resume() error: %v", err)
+	}
+	if len(groups) != 5 {
+		t.Fatalf("Resume() returned %d groups, expected 5", len(groups))
+	}
+	for _, group := range groups {
+		if group.Hashes == nil {
+			t.Errorf("Expected hashes for %s", group.Filename)
+		}
+	}
+}
+
+// recordingPrefetcher records the titles it's asked to prefetch.
+type recordingPrefetcher struct {
+	titles map[string][]string
+}
+
+func (p *recordingPrefetcher) Prefetch(_ context.Context, platformSlug string, titles []string) (*retrometadata.PrefetchResult, error) {
+	p.titles[platformSlug] = append(p.titles[platformSlug], titles...)
+	return &retrometadata.PrefetchResult{Cached: len(titles)}, nil
+}
+
+func TestScanPrefetch(t *testing.T) {
+	root := t.TempDir()
+	for _, name := range []string{"snes/Super Metroid (USA).sfc", "snes/Chrono Trigger (USA).sfc", "psx/Game.chd", "misc/unknown.zip"} {
+		path := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(name), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	prefetcher := &recordingPrefetcher{titles: make(map[string][]string)}
+	groups, err := New(WithHashing(false), WithPrefetch(prefetcher)).Scan(context.Background(), root)
+	if err != nil || len(groups) != 4 {
+		t.Fatalf("Scan() = %d groups, %v", len(groups), err)
+	}
+
+	want := map[string][]string{
+		"snes": {filepath.Join(root, "snes/Chrono Trigger (USA).sfc"), filepath.Join(root, "snes/Super Metroid (USA).sfc")},
+		"psx":  {filepath.Join(root, "psx/Game.chd")},
+	}
+	if !reflect.DeepEqual(prefetcher.titles, want) {
+		t.Errorf("prefetched %v, want %v", prefetcher.titles, want)
+	}
+}
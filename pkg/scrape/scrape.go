@@ -0,0 +1,415 @@
+// Package scrape connects scanning, identification, artwork downloads and
+// exports into a single "scrape and save" pipeline.
+//
+// A Pipeline scans a ROM directory, identifies every new or changed ROM,
+// optionally downloads its artwork, and writes the results to one or more
+// sinks. Sinks are written as the scrape goes, so an interrupted run keeps
+// what it scraped, and with a state file the next run picks up where it left
+// off instead of identifying every ROM again.
+package scrape
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/josegonzalez/retro-metadata/pkg/artwork"
+	"github.com/josegonzalez/retro-metadata/pkg/events"
+	"github.com/josegonzalez/retro-metadata/pkg/export"
+	"github.com/josegonzalez/retro-metadata/pkg/identify"
+	"github.com/josegonzalez/retro-metadata/pkg/normalize"
+	"github.com/josegonzalez/retro-metadata/pkg/progress"
+	"github.com/josegonzalez/retro-metadata/pkg/retrometadata"
+	"github.com/josegonzalez/retro-metadata/pkg/scanner"
+)
+
+// DefaultFlushInterval is the number of ROMs identified between sink writes.
+const DefaultFlushInterval = 50
+
+// Result is the outcome of scraping a single ROM.
+type Result struct {
+	// Entry is the ROM and its identification result
+	Entry export.Entry
+	// Artwork is the artwork that was downloaded, if artwork is enabled
+	Artwork *artwork.Plan
+	// ArtworkErr is the error from planning or downloading artwork, if any
+	ArtworkErr error
+}
+
+// Report summarizes a scrape.
+type Report struct {
+	// Root is the scraped directory
+	Root string `json:"root"`
+	// Scanned is the number of ROMs found
+	Scanned int `json:"scanned"`
+	// Unchanged is the number of ROMs skipped because they were already
+	// identified and haven't changed
+	Unchanged int `json:"unchanged"`
+	// Identified is the number of ROMs identified in this run
+	Identified int `json:"identified"`
+	// Failed is the number of ROMs that couldn't be identified
+	Failed int `json:"failed"`
+	// ArtworkFailed is the number of identified ROMs whose artwork failed
+	ArtworkFailed int `json:"artwork_failed"`
+	// Exports is the report from the last write of each sink
+	Exports []*export.ExportReport `json:"exports,omitempty"`
+}
+
+// Pipeline scrapes ROM directories.
+type Pipeline struct {
+	client        *retrometadata.Client
+	scanner       *scanner.Scanner
+	identifier    *identify.Pipeline
+	options       retrometadata.IdentifyOptions
+	planner       *artwork.Planner
+	sinks         []Sink
+	statePath     string
+	unmatchedPath string
+	concurrency   int
+	flushInterval int
+	progress      progress.Progress
+	onResult      func(Result)
+	events        *events.Bus
+	normalize     bool
+	raw           retrometadata.RawConfig
+}
+
+// Option is a functional option for Pipeline.
+type Option func(*Pipeline)
+
+// WithScanner sets the scanner used to find ROMs.
+func WithScanner(s *scanner.Scanner) Option {
+	return func(p *Pipeline) {
+		p.scanner = s
+	}
+}
+
+// WithIdentifier sets the identification pipeline.
+func WithIdentifier(identifier *identify.Pipeline) Option {
+	return func(p *Pipeline) {
+		p.identifier = identifier
+	}
+}
+
+// WithIdentifyOptions sets the options passed to the providers.
+func WithIdentifyOptions(opts retrometadata.IdentifyOptions) Option {
+	return func(p *Pipeline) {
+		p.options = opts
+	}
+}
+
+// WithArtwork enables artwork downloads, planned by planner.
+func WithArtwork(planner *artwork.Planner) Option {
+	return func(p *Pipeline) {
+		p.planner = planner
+	}
+}
+
+// WithSinks adds sinks the results are written to.
+func WithSinks(sinks ...Sink) Option {
+	return func(p *Pipeline) {
+		p.sinks = append(p.sinks, sinks...)
+	}
+}
+
+// WithStateFile sets a file the scrape state is saved to, so later runs skip
+// ROMs that were already identified and haven't changed.
+func WithStateFile(path string) Option {
+	return func(p *Pipeline) {
+		p.statePath = path
+	}
+}
+
+// WithUnmatchedReport sets a file listing the ROMs that couldn't be
+// identified, with the search terms tried and the best candidates, for
+// manual review. It's written as CSV if path has a .csv extension and as
+// JSON otherwise, each flush interval and when the run ends.
+func WithUnmatchedReport(path string) Option {
+	return func(p *Pipeline) {
+		p.unmatchedPath = path
+	}
+}
+
+// WithConcurrency sets the number of ROMs identified at once (default 1).
+// Each ROM takes one of the client's request slots while it's identified,
+// so the client's MaxConcurrentRequests limits it too.
+func WithConcurrency(n int) Option {
+	return func(p *Pipeline) {
+		p.concurrency = n
+	}
+}
+
+// WithFlushInterval sets the number of ROMs identified between sink and
+// state writes. A zero interval uses DefaultFlushInterval.
+func WithFlushInterval(n int) Option {
+	return func(p *Pipeline) {
+		p.flushInterval = n
+	}
+}
+
+// WithProgress sets the progress reporter for identification.
+func WithProgress(prog progress.Progress) Option {
+	return func(p *Pipeline) {
+		p.progress = prog
+	}
+}
+
+// WithResultCallback sets a function called with the result of each ROM as
+// it's scraped. It is called from the Run goroutine and should return quickly.
+func WithResultCallback(fn func(Result)) Option {
+	return func(p *Pipeline) {
+		p.onResult = fn
+	}
+}
+
+// WithEvents publishes a GameIdentified event for each identified ROM, a
+// ScanFinished event with the Report when a run finishes, and a
+// ProviderFailing event when a provider fails
+// events.DefaultFailureThreshold times in a row.
+func WithEvents(bus *events.Bus) Option {
+	return func(p *Pipeline) {
+		p.events = bus
+	}
+}
+
+// WithNormalization maps the genres, game modes and age ratings of every
+// identified ROM to the canonical vocabulary of the normalize package before
+// it's saved.
+func WithNormalization() Option {
+	return func(p *Pipeline) {
+		p.normalize = true
+	}
+}
+
+// WithRawResponses sets how the raw provider payloads of identified ROMs
+// are kept. By default they're omitted, since a scrape can hold thousands of
+// results; payloads that can't be dumped are kept.
+func WithRawResponses(raw retrometadata.RawConfig) Option {
+	return func(p *Pipeline) {
+		p.raw = raw
+	}
+}
+
+// New creates a pipeline identifying ROMs with the client's providers.
+// By default ROMs are found with scanner.New and identified with
+// identify.DefaultPipeline; artwork isn't downloaded and nothing is saved
+// unless sinks are added.
+func New(client *retrometadata.Client, opts ...Option) *Pipeline {
+	p := &Pipeline{
+		client:        client,
+		concurrency:   1,
+		flushInterval: DefaultFlushInterval,
+		raw:           retrometadata.RawConfig{Mode: retrometadata.RawOmit},
+	}
+
+	for _, opt := range opts {
+		opt(p)
+	}
+	if p.scanner == nil {
+		p.scanner = scanner.New()
+	}
+	if p.identifier == nil {
+		p.identifier = identify.DefaultPipeline()
+	}
+	if p.events != nil {
+		monitor := events.NewProviderMonitor(p.events, events.DefaultFailureThreshold)
+		p.identifier = p.identifier.Clone().Observe(monitor.Observe)
+	}
+	if p.flushInterval <= 0 {
+		p.flushInterval = DefaultFlushInterval
+	}
+	p.progress = progress.OrNoop(p.progress)
+
+	return p
+}
+
+// Run scrapes the ROMs in root.
+//
+// ROMs in the state file that were identified and haven't changed since are
+// not identified again; ROMs that failed are retried. Sinks receive every
+// identified ROM found in root, and are rewritten each flush interval and
+// when the run ends. Cancelling ctx stops the run after saving what was
+// scraped so far, and returns the context error.
+func (p *Pipeline) Run(ctx context.Context, root string) (*Report, error) {
+	state, err := p.loadState(root)
+	if err != nil {
+		return nil, err
+	}
+
+	groups, err := p.scanner.Scan(ctx, root)
+	if err != nil {
+		return nil, fmt.Errorf("scanning %s: %w", root, err)
+	}
+
+	report := &Report{Root: root, Scanned: len(groups)}
+	var unmatched []Unmatched
+	current := make(map[string]bool, len(groups))
+	var pending []identify.DiscGroup
+	for _, group := range groups {
+		current[group.Filename] = true
+		if state.unchanged(group) {
+			report.Unchanged++
+			continue
+		}
+		pending = append(pending, group)
+	}
+
+	p.progress.Start(len(pending))
+	defer p.progress.Done()
+
+	for start := 0; start < len(pending); start += p.flushInterval {
+		chunk := pending[start:min(start+p.flushInterval, len(pending))]
+		results := p.identifier.IdentifyBatchWithClient(ctx, p.client, chunk, identify.BatchOptions{
+			Options:     p.options,
+			Concurrency: p.concurrency,
+			Progress:    chunkProgress{p.progress},
+			Trace:       p.unmatchedPath != "",
+		})
+		if ctx.Err() != nil {
+			// Results cut short by the cancellation are left for the next run
+			results = completed(results)
+		}
+
+		for i, entry := range export.EntriesFromBatch(results) {
+			result := Result{Entry: entry}
+			if entry.Game != nil {
+				report.Identified++
+				_ = p.raw.Apply(entry.Game)
+				if p.normalize {
+					normalize.Result(entry.Game)
+				}
+				p.events.Publish(events.New(events.GameIdentified, events.GameFromResult(entry.Path, entry.Game)))
+				result.Artwork, result.ArtworkErr = p.downloadArtwork(ctx, entry)
+				if result.ArtworkErr != nil {
+					report.ArtworkFailed++
+				}
+			} else {
+				report.Failed++
+				unmatched = append(unmatched, newUnmatched(results[i]))
+			}
+			state.record(results[i].Group, entry)
+			if p.onResult != nil {
+				p.onResult(result)
+			}
+		}
+
+		if err := p.flush(ctx, state, current, report, unmatched); err != nil {
+			return report, err
+		}
+		if err := ctx.Err(); err != nil {
+			return report, err
+		}
+	}
+
+	if len(pending) == 0 {
+		if err := p.flush(ctx, state, current, report, unmatched); err != nil {
+			return report, err
+		}
+	}
+	p.events.Publish(events.New(events.ScanFinished, report))
+	return report, nil
+}
+
+// downloadArtwork downloads the artwork of an identified ROM.
+func (p *Pipeline) downloadArtwork(ctx context.Context, entry export.Entry) (*artwork.Plan, error) {
+	if p.planner == nil {
+		return nil, nil
+	}
+	plan, err := p.planner.PlanGame(ctx, entry.Game, filepath.Base(entry.Path))
+	if err != nil {
+		return nil, err
+	}
+	return plan, p.planner.DownloadPlan(ctx, plan)
+}
+
+// flush writes every identified ROM still in the scanned directory to the
+// sinks, then saves the state and the unmatched report. Sink writes are not cancelled by ctx, so the
+// results of a cancelled run are still saved.
+func (p *Pipeline) flush(ctx context.Context, state *State, current map[string]bool, report *Report, unmatched []Unmatched) error {
+	entries := state.entries(current)
+	writeCtx := context.WithoutCancel(ctx)
+
+	report.Exports = report.Exports[:0]
+	var errs []error
+	for _, sink := range p.sinks {
+		exportReport, err := sink.Write(writeCtx, entries)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("writing %s: %w", sink.Name(), err))
+			continue
+		}
+		report.Exports = append(report.Exports, exportReport)
+	}
+
+	if p.statePath != "" {
+		if err := state.save(p.statePath); err != nil {
+			errs = append(errs, fmt.Errorf("saving state: %w", err))
+		}
+	}
+	if p.unmatchedPath != "" {
+		if err := writeUnmatched(p.unmatchedPath, unmatched); err != nil {
+			errs = append(errs, fmt.Errorf("writing unmatched report: %w", err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// loadState reads the state file, or starts a new state if there's none or
+// it's for another directory.
+func (p *Pipeline) loadState(root string) (*State, error) {
+	if p.statePath == "" {
+		return newState(root), nil
+	}
+
+	state, err := LoadState(p.statePath)
+	if errors.Is(err, os.ErrNotExist) {
+		return newState(root), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if state.Root != root {
+		return newState(root), nil
+	}
+	return state, nil
+}
+
+// completed returns the results that finished before a cancellation.
+func completed(results []identify.BatchResult) []identify.BatchResult {
+	var done []identify.BatchResult
+	for _, r := range results {
+		if r.Result != nil || (r.Err != nil && !errors.Is(r.Err, context.Canceled) && !errors.Is(r.Err, context.DeadlineExceeded)) {
+			done = append(done, r)
+		}
+	}
+	return done
+}
+
+// sortedEntries returns entries sorted by path.
+func sortedEntries(byPath map[string]export.Entry) []export.Entry {
+	paths := make([]string, 0, len(byPath))
+	for path := range byPath {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	entries := make([]export.Entry, len(paths))
+	for i, path := range paths {
+		entries[i] = byPath[path]
+	}
+	return entries
+}
+
+// chunkProgress forwards increments and status to the pipeline's progress,
+// so the batches of a run report as a single operation.
+type chunkProgress struct {
+	progress.Progress
+}
+
+// Start does nothing; the run was started with the total of every chunk.
+func (chunkProgress) Start(int) {}
+
+// Done does nothing; the run is done when its last chunk is.
+func (chunkProgress) Done() {}
@@ -0,0 +1,305 @@
+package scrape
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/josegonzalez/retro-metadata/pkg/cache"
+	"github.com/josegonzalez/retro-metadata/pkg/events"
+	"github.com/josegonzalez/retro-metadata/pkg/export"
+	"github.com/josegonzalez/retro-metadata/pkg/filename"
+	"github.com/josegonzalez/retro-metadata/pkg/identify"
+	"github.com/josegonzalez/retro-metadata/pkg/matching"
+	"github.com/josegonzalez/retro-metadata/pkg/provider"
+	"github.com/josegonzalez/retro-metadata/pkg/retrometadata"
+	"github.com/josegonzalez/retro-metadata/pkg/scanner"
+)
+
+// countingProvider identifies every file as a game named after it, and
+// counts its Identify calls.
+type countingProvider struct {
+	calls atomic.Int32
+}
+
+func (p *countingProvider) Name() string { return "mobygames" }
+
+func (p *countingProvider) Search(context.Context, string, retrometadata.SearchOptions) ([]retrometadata.SearchResult, error) {
+	return nil, nil
+}
+
+func (p *countingProvider) GetByID(context.Context, int) (*retrometadata.GameResult, error) {
+	return nil, nil
+}
+
+func (p *countingProvider) Identify(_ context.Context, filename string, _ retrometadata.IdentifyOptions) (*retrometadata.GameResult, error) {
+	p.calls.Add(1)
+	return &retrometadata.GameResult{Name: filename, Provider: "mobygames"}, nil
+}
+
+func (p *countingProvider) Heartbeat(context.Context) error { return nil }
+
+func (p *countingProvider) Close() error { return nil }
+
+func TestPipelineRun(t *testing.T) {
+	provider := &countingProvider{}
+	retrometadata.RegisterProvider("mobygames", func(retrometadata.ProviderConfig, cache.Cache) (retrometadata.Provider, error) {
+		return provider, nil
+	})
+	client, err := retrometadata.NewClient(retrometadata.WithMobyGames("key"))
+	if err != nil {
+		t.Fatalf("NewClient() error: %v", err)
+	}
+	defer client.Close()
+
+	roms := t.TempDir()
+	for _, name := range []string{"Game A.sfc", "Game B.sfc", "Game C.sfc"} {
+		if err := os.WriteFile(filepath.Join(roms, name), []byte(name), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	out := t.TempDir()
+	jsonPath := filepath.Join(out, "games.json")
+
+	var results int
+	published := map[events.Type]int{}
+	bus := events.NewBus()
+	bus.Subscribe(func(e events.Event) { published[e.Type]++ })
+	newPipeline := func() *Pipeline {
+		return New(client,
+			WithScanner(scanner.New(scanner.WithHashing(false))),
+			WithSinks(FileSink(jsonPath, export.NewJSONExporter())),
+			WithStateFile(filepath.Join(out, "state.json")),
+			WithFlushInterval(2),
+			WithResultCallback(func(Result) { results++ }),
+			WithEvents(bus),
+		)
+	}
+	ctx := context.Background()
+
+	report, err := newPipeline().Run(ctx, roms)
+	if err != nil {
+		t.Fatalf("Run() error: %v", err)
+	}
+	if report.Scanned != 3 || report.Identified != 3 || report.Unchanged != 0 || results != 3 {
+		t.Errorf("Unexpected first run report: %+v (%d results)", report, results)
+	}
+	if len(report.Exports) != 1 || report.Exports[0].Written != 3 {
+		t.Errorf("Exports = %+v, expected 3 games written", report.Exports)
+	}
+	if published[events.GameIdentified] != 3 || published[events.ScanFinished] != 1 {
+		t.Errorf("Published events = %v, expected 3 identified and 1 finished", published)
+	}
+
+	var exported []json.RawMessage
+	data, err := os.ReadFile(jsonPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal(data, &exported); err != nil || len(exported) != 3 {
+		t.Errorf("Expected 3 games in %s, got %s", jsonPath, data)
+	}
+
+	// A second run only identifies changed ROMs, and still exports every game
+	calls := provider.calls.Load()
+	changed := filepath.Join(roms, "Game B.sfc")
+	later := time.Now().Add(time.Hour)
+	if err := os.Chtimes(changed, later, later); err != nil {
+		t.Fatal(err)
+	}
+
+	report, err = newPipeline().Run(ctx, roms)
+	if err != nil {
+		t.Fatalf("Run() error: %v", err)
+	}
+	if report.Unchanged != 2 || report.Identified != 1 {
+		t.Errorf("Unexpected resumed run report: %+v", report)
+	}
+	if provider.calls.Load() == calls {
+		t.Error("Expected the changed ROM to be identified again")
+	}
+	if report.Exports[0].Written != 3 {
+		t.Errorf("Written = %d, expected unchanged games to still be exported", report.Exports[0].Written)
+	}
+}
+
+// matchingProvider fuzzy matches cleaned filenames against a few games.
+type matchingProvider struct {
+	countingProvider
+}
+
+func (p *matchingProvider) Identify(ctx context.Context, name string, _ retrometadata.IdentifyOptions) (*retrometadata.GameResult, error) {
+	opts := matching.DefaultFindBestMatchOptions()
+	opts.MinSimilarityScore = 0.5
+	games := []string{"Sonic the Hedgehog", "Streets of Rage"}
+	match, score := provider.FindBestMatch(ctx, p.Name(), filename.CleanFilename(name, true), games, opts)
+	if match == "" {
+		return nil, nil
+	}
+	return &retrometadata.GameResult{Name: match, Provider: p.Name(), MatchScore: score}, nil
+}
+
+func TestPipelineUnmatchedReport(t *testing.T) {
+	retrometadata.RegisterProvider("mobygames", func(retrometadata.ProviderConfig, cache.Cache) (retrometadata.Provider, error) {
+		return &matchingProvider{}, nil
+	})
+	client, err := retrometadata.NewClient(retrometadata.WithMobyGames("key"), retrometadata.WithMinMatchScore(0.9))
+	if err != nil {
+		t.Fatalf("NewClient() error: %v", err)
+	}
+	defer client.Close()
+
+	roms := t.TempDir()
+	for _, name := range []string{"Sonic the Hedgehog (USA).md", "Sonic Spinball (USA).md", "Zzyzx.md"} {
+		if err := os.WriteFile(filepath.Join(roms, name), []byte(name), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, ext := range []string{".json", ".csv"} {
+		path := filepath.Join(t.TempDir(), "unmatched"+ext)
+		pipeline := New(client,
+			WithScanner(scanner.New(scanner.WithHashing(false))),
+			WithIdentifier(identify.NewPipeline(identify.FilenameStep{})),
+			WithUnmatchedReport(path),
+		)
+		report, err := pipeline.Run(context.Background(), roms)
+		if err != nil {
+			t.Fatalf("Run() error: %v", err)
+		}
+		if report.Identified != 1 || report.Failed != 2 {
+			t.Errorf("report = %+v, want 1 identified and the low confidence match failed", report)
+		}
+
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ext == ".csv" {
+			records, err := csv.NewReader(bytes.NewReader(data)).ReadAll()
+			if err != nil || len(records) != 3 || records[0][0] != "path" {
+				t.Errorf("CSV report = %q, %v; want a header and 2 rows", records, err)
+			}
+			continue
+		}
+
+		var unmatched []Unmatched
+		if err := json.Unmarshal(data, &unmatched); err != nil || len(unmatched) != 2 {
+			t.Fatalf("JSON report = %s, %v; want 2 unmatched ROMs", data, err)
+		}
+		spinball := unmatched[0]
+		if filepath.Base(spinball.Path) != "Sonic Spinball (USA).md" || len(spinball.SearchTerms) != 1 || spinball.SearchTerms[0] != "Sonic Spinball" {
+			t.Errorf("unmatched[0] = %+v, want Sonic Spinball with its search term", spinball)
+		}
+		if len(spinball.Candidates) != 2 || spinball.Candidates[0].Name != "Sonic the Hedgehog" || spinball.Candidates[0].Provider != "mobygames" {
+			t.Errorf("unmatched[0].Candidates = %+v, want Sonic the Hedgehog first", spinball.Candidates)
+		}
+	}
+}
+
+// concurrentProvider is a countingProvider recording the most Identify
+// calls it had in progress at once.
+type concurrentProvider struct {
+	countingProvider
+	running atomic.Int32
+	most    atomic.Int32
+}
+
+func (p *concurrentProvider) Identify(ctx context.Context, filename string, opts retrometadata.IdentifyOptions) (*retrometadata.GameResult, error) {
+	running := p.running.Add(1)
+	defer p.running.Add(-1)
+	for most := p.most.Load(); running > most && !p.most.CompareAndSwap(most, running); most = p.most.Load() {
+	}
+	time.Sleep(5 * time.Millisecond)
+	return p.countingProvider.Identify(ctx, filename, opts)
+}
+
+func TestPipelineMaxConcurrentRequests(t *testing.T) {
+	provider := &concurrentProvider{}
+	retrometadata.RegisterProvider("mobygames", func(retrometadata.ProviderConfig, cache.Cache) (retrometadata.Provider, error) {
+		return provider, nil
+	})
+	client, err := retrometadata.NewClient(retrometadata.WithMobyGames("key"), retrometadata.WithMaxConcurrentRequests(1))
+	if err != nil {
+		t.Fatalf("NewClient() error: %v", err)
+	}
+	defer client.Close()
+
+	roms := t.TempDir()
+	for _, name := range []string{"Game A.sfc", "Game B.sfc", "Game C.sfc", "Game D.sfc"} {
+		if err := os.WriteFile(filepath.Join(roms, name), []byte(name), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	pipeline := New(client,
+		WithScanner(scanner.New(scanner.WithHashing(false))),
+		WithIdentifier(identify.NewPipeline(identify.FilenameStep{})),
+		WithConcurrency(4),
+	)
+	report, err := pipeline.Run(context.Background(), roms)
+	if err != nil {
+		t.Fatalf("Run() error: %v", err)
+	}
+	if report.Identified != 4 {
+		t.Errorf("report = %+v, want 4 identified", report)
+	}
+	if most := provider.most.Load(); most != 1 {
+		t.Errorf("%d provider calls at once, want 1 with MaxConcurrentRequests(1)", most)
+	}
+}
+
+// idProvider is a countingProvider returning a game for any ID.
+type idProvider struct {
+	countingProvider
+}
+
+func (p *idProvider) GetByID(_ context.Context, id int) (*retrometadata.GameResult, error) {
+	return &retrometadata.GameResult{Name: "Pinned Game", Provider: "mobygames", ProviderID: &id}, nil
+}
+
+func TestPipelineOverrides(t *testing.T) {
+	provider := &idProvider{}
+	retrometadata.RegisterProvider("mobygames", func(retrometadata.ProviderConfig, cache.Cache) (retrometadata.Provider, error) {
+		return provider, nil
+	})
+	overrides := retrometadata.Overrides{Filenames: map[string]retrometadata.Override{
+		"Pinned.sfc": {Provider: "mobygames", ID: "42"},
+	}}
+	client, err := retrometadata.NewClient(retrometadata.WithMobyGames("key"), retrometadata.WithOverrides(overrides))
+	if err != nil {
+		t.Fatalf("NewClient() error: %v", err)
+	}
+	defer client.Close()
+
+	roms := t.TempDir()
+	for _, name := range []string{"Pinned.sfc", "Other.sfc"} {
+		if err := os.WriteFile(filepath.Join(roms, name), []byte(name), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	games := map[string]*retrometadata.GameResult{}
+	pipeline := New(client,
+		WithScanner(scanner.New(scanner.WithHashing(false))),
+		WithResultCallback(func(r Result) { games[filepath.Base(r.Entry.Path)] = r.Entry.Game }),
+	)
+	if _, err := pipeline.Run(context.Background(), roms); err != nil {
+		t.Fatalf("Run() error: %v", err)
+	}
+	if game := games["Pinned.sfc"]; game == nil || game.MatchType != retrometadata.MatchTypeOverride {
+		t.Errorf("Pinned.sfc = %+v, want it identified by its override", game)
+	}
+	if game := games["Other.sfc"]; game == nil || game.MatchType == retrometadata.MatchTypeOverride {
+		t.Errorf("Other.sfc = %+v, want it identified by the provider", game)
+	}
+	if calls := provider.calls.Load(); calls != 1 {
+		t.Errorf("Identify() called %d times, want only for the file without an override", calls)
+	}
+}
@@ -0,0 +1,65 @@
+package scrape
+
+import (
+	"context"
+
+	"github.com/josegonzalez/retro-metadata/pkg/export"
+)
+
+// Sink receives the scraped ROMs of a pipeline.
+//
+// Write is called with every identified or failed ROM scraped so far, each
+// flush interval and when the run ends, so sinks should replace what they
+// wrote before rather than append to it. Implement Sink to save results
+// somewhere other than a file, such as a library database.
+type Sink interface {
+	// Name returns the sink name, used in errors.
+	Name() string
+
+	// Write saves entries, skipping entries that can't be saved.
+	Write(ctx context.Context, entries []export.Entry) (*export.ExportReport, error)
+}
+
+// fileSink writes entries to a file with an exporter.
+type fileSink struct {
+	path     string
+	exporter export.Exporter
+}
+
+// FileSink returns a sink that writes entries to path with exporter,
+// replacing the file on every write.
+func FileSink(path string, exporter export.Exporter) Sink {
+	return &fileSink{path: path, exporter: exporter}
+}
+
+// Name returns the exporter name.
+func (s *fileSink) Name() string {
+	return s.exporter.Name()
+}
+
+// Write exports entries to the file.
+func (s *fileSink) Write(ctx context.Context, entries []export.Entry) (*export.ExportReport, error) {
+	return export.WriteFile(ctx, s.path, s.exporter, entries)
+}
+
+// gamelistSink merges entries into a gamelist.xml.
+type gamelistSink struct {
+	path     string
+	exporter *export.GamelistExporter
+}
+
+// GamelistSink returns a sink that merges entries into the gamelist.xml at
+// path, keeping the games and tags it already has.
+func GamelistSink(path string, exporter *export.GamelistExporter) Sink {
+	return &gamelistSink{path: path, exporter: exporter}
+}
+
+// Name returns the exporter name.
+func (s *gamelistSink) Name() string {
+	return s.exporter.Name()
+}
+
+// Write merges entries into the gamelist.
+func (s *gamelistSink) Write(ctx context.Context, entries []export.Entry) (*export.ExportReport, error) {
+	return export.UpdateGamelist(ctx, s.path, s.exporter, entries)
+}
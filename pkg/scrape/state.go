@@ -0,0 +1,147 @@
+package scrape
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/josegonzalez/retro-metadata/pkg/export"
+	"github.com/josegonzalez/retro-metadata/pkg/identify"
+	"github.com/josegonzalez/retro-metadata/pkg/retrometadata"
+)
+
+// stateVersion is bumped when the state file format changes.
+const stateVersion = 1
+
+// State records what a pipeline scraped, so it can resume.
+type State struct {
+	// Version is the state file format version
+	Version int `json:"version"`
+	// Root is the scraped directory
+	Root string `json:"root"`
+	// ROMs maps each scraped ROM path to its result
+	ROMs map[string]ROMState `json:"roms"`
+}
+
+// ROMState is the scrape result of a single ROM.
+type ROMState struct {
+	// ModTime is the ROM's modification time when it was scraped
+	ModTime time.Time `json:"mod_time"`
+	// Size is the ROM's size in bytes when it was scraped
+	Size int64 `json:"size"`
+	// Game is the identified game, or nil if identification failed
+	Game *retrometadata.GameResult `json:"game,omitempty"`
+	// Error is the identification error, if any
+	Error string `json:"error,omitempty"`
+	// Hashes are the ROM's hashes, if known
+	Hashes *retrometadata.FileHashes `json:"hashes,omitempty"`
+}
+
+// newState creates an empty state for root.
+func newState(root string) *State {
+	return &State{Version: stateVersion, Root: root, ROMs: make(map[string]ROMState)}
+}
+
+// LoadState reads a state file saved by a pipeline.
+func LoadState(path string) (*State, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	state := &State{}
+	if err := json.Unmarshal(data, state); err != nil {
+		return nil, fmt.Errorf("reading %s: %w", path, err)
+	}
+	if state.Version != stateVersion {
+		return newState(state.Root), nil
+	}
+	if state.ROMs == nil {
+		state.ROMs = make(map[string]ROMState)
+	}
+	return state, nil
+}
+
+// save writes the state to path.
+func (s *State) save(path string) error {
+	data, err := json.Marshal(s)
+	if err != nil {
+		return err
+	}
+	return writeFile(path, data)
+}
+
+// writeFile replaces the file at path with data, through a temporary file
+// so readers never see it half written.
+func writeFile(path string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	_, err = tmp.Write(data)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// unchanged reports whether a ROM was identified and hasn't changed since.
+func (s *State) unchanged(group identify.DiscGroup) bool {
+	rom, ok := s.ROMs[group.Filename]
+	if !ok || rom.Game == nil {
+		return false
+	}
+	modTime, size := stat(group)
+	return modTime.Equal(rom.ModTime) && size == rom.Size
+}
+
+// record stores the scrape result of a ROM.
+func (s *State) record(group identify.DiscGroup, entry export.Entry) {
+	modTime, size := stat(group)
+	rom := ROMState{ModTime: modTime, Size: size, Game: entry.Game, Hashes: entry.Hashes}
+	if entry.Err != nil {
+		rom.Error = entry.Err.Error()
+	}
+	s.ROMs[group.Filename] = rom
+}
+
+// entries returns the export entries of every ROM in current, sorted by path.
+func (s *State) entries(current map[string]bool) []export.Entry {
+	byPath := make(map[string]export.Entry, len(current))
+	for path, rom := range s.ROMs {
+		if !current[path] {
+			continue
+		}
+		entry := export.Entry{Path: path, Game: rom.Game, Hashes: rom.Hashes}
+		if rom.Error != "" {
+			entry.Err = errors.New(rom.Error)
+		}
+		byPath[path] = entry
+	}
+	return sortedEntries(byPath)
+}
+
+// stat returns the modification time and size of a group's file. Multi-disc
+// sets, whose group filename doesn't exist, use their first disc.
+func stat(group identify.DiscGroup) (time.Time, int64) {
+	path := group.Filename
+	if group.IsMultiDisc() {
+		path = group.Discs[0].Filename
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return time.Time{}, 0
+	}
+	return info.ModTime(), info.Size()
+}
@@ -0,0 +1,125 @@
+package scrape
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/josegonzalez/retro-metadata/pkg/identify"
+	"github.com/josegonzalez/retro-metadata/pkg/retrometadata"
+)
+
+// MaxUnmatchedCandidates is the number of best candidates listed for each
+// unmatched ROM.
+const MaxUnmatchedCandidates = 5
+
+// Unmatched is a ROM that couldn't be identified, with what was tried, for
+// manual review.
+type Unmatched struct {
+	// Path is the ROM file path
+	Path string `json:"path"`
+	// Error is the identification error
+	Error string `json:"error,omitempty"`
+	// SearchTerms are the terms providers fuzzy matched
+	SearchTerms []string `json:"search_terms,omitempty"`
+	// Candidates are the best scoring candidates, best first, including
+	// matches rejected for scoring below the client's MinMatchScore
+	Candidates []Candidate `json:"candidates,omitempty"`
+}
+
+// Candidate is a game a ROM was compared with.
+type Candidate struct {
+	Provider string  `json:"provider"`
+	Name     string  `json:"name"`
+	Score    float64 `json:"score"`
+}
+
+// String returns the candidate as "name (provider, score)".
+func (c Candidate) String() string {
+	return fmt.Sprintf("%s (%s, %.2f)", c.Name, c.Provider, c.Score)
+}
+
+// newUnmatched describes a ROM that wasn't identified from its batch result.
+func newUnmatched(result identify.BatchResult) Unmatched {
+	unmatched := Unmatched{Path: result.Group.Filename}
+	if result.Err != nil {
+		unmatched.Error = result.Err.Error()
+	}
+
+	var candidates []Candidate
+	var low *retrometadata.LowConfidenceError
+	if errors.As(result.Err, &low) {
+		candidates = append(candidates, Candidate{Provider: low.Result.Provider, Name: low.Result.Name, Score: low.Result.MatchScore})
+	}
+	if result.Trace != nil {
+		terms := make(map[string]bool)
+		for _, provider := range result.Trace.Providers {
+			for _, match := range provider.Matches {
+				if !terms[match.SearchTerm] {
+					terms[match.SearchTerm] = true
+					unmatched.SearchTerms = append(unmatched.SearchTerms, match.SearchTerm)
+				}
+				for _, candidate := range match.Candidates {
+					candidates = append(candidates, Candidate{Provider: provider.Provider, Name: candidate.Name, Score: candidate.Score})
+				}
+			}
+		}
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].Score > candidates[j].Score
+	})
+	seen := make(map[Candidate]bool)
+	for _, candidate := range candidates {
+		key := Candidate{Provider: candidate.Provider, Name: candidate.Name}
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		unmatched.Candidates = append(unmatched.Candidates, candidate)
+		if len(unmatched.Candidates) == MaxUnmatchedCandidates {
+			break
+		}
+	}
+	return unmatched
+}
+
+// writeUnmatched writes the unmatched report to path, as CSV if it has a
+// .csv extension and JSON otherwise.
+func writeUnmatched(path string, unmatched []Unmatched) error {
+	sort.Slice(unmatched, func(i, j int) bool {
+		return unmatched[i].Path < unmatched[j].Path
+	})
+
+	if !strings.EqualFold(filepath.Ext(path), ".csv") {
+		if unmatched == nil {
+			unmatched = []Unmatched{}
+		}
+		data, err := json.MarshalIndent(unmatched, "", "  ")
+		if err != nil {
+			return err
+		}
+		return writeFile(path, append(data, '\n'))
+	}
+
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	w.Write([]string{"path", "error", "search_terms", "candidates"})
+	for _, u := range unmatched {
+		candidates := make([]string, len(u.Candidates))
+		for i, candidate := range u.Candidates {
+			candidates[i] = candidate.String()
+		}
+		w.Write([]string{u.Path, u.Error, strings.Join(u.SearchTerms, "; "), strings.Join(candidates, "; ")})
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return err
+	}
+	return writeFile(path, buf.Bytes())
+}
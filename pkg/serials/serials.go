@@ -0,0 +1,135 @@
+// Package serials extracts product codes ("serials") from ROM filenames,
+// such as SLUS-12345 for PlayStation games or GALE01 for GameCube games.
+//
+// Serials identify a release exactly, so providers that can look games up
+// by serial prefer them to names. Each platform's serials have their own
+// format; Extract is given the platform when it's known, and otherwise only
+// recognizes formats that can't be mistaken for part of a title.
+package serials
+
+import (
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/josegonzalez/retro-metadata/pkg/platform"
+)
+
+// Extractor returns the serial in a filename, in its canonical form, or ""
+// if it has none.
+type Extractor func(name string) string
+
+var (
+	// sonyPattern matches PlayStation, PS2 and PSP serials like SLUS-12345,
+	// SCUS_97328 or SLUS_123.45 (the PS2 OPL format)
+	sonyPattern = regexp.MustCompile(`(?i)(?:^|[^A-Z])([A-Z]{4})[_-](\d{3})\.?(\d{2})(?:\D|$)`)
+
+	// segaPattern matches Saturn and Dreamcast serials like T-12705H,
+	// MK-81086, GS-9001 or HDR-0001, with an optional -50 region suffix
+	segaPattern = regexp.MustCompile(`(?i)(?:^|[^A-Z0-9])((?:MK|GS|HDR)-\d{4,5}|T-\d{3,5}[A-Z]?)(?:-\d{2})?(?:[^A-Z0-9]|$)`)
+
+	// gameCubePattern and wiiPattern match disc IDs like GALE01 and RMGE01:
+	// a console letter, a two character game code, a region letter and a
+	// two character maker code with at least one digit
+	gameCubePattern = regexp.MustCompile(`(?:^|[^A-Za-z0-9])([GDPU][A-Z0-9]{2}[DEFHIJKLMNPSUWXYZ](?:[0-9][A-Z0-9]|[A-Z][0-9]))(?:[^A-Za-z0-9]|$)`)
+	wiiPattern      = regexp.MustCompile(`(?:^|[^A-Za-z0-9])([RSH][A-Z0-9]{2}[DEFHIJKLMNPSUWXYZ](?:[0-9][A-Z0-9]|[A-Z][0-9]))(?:[^A-Za-z0-9]|$)`)
+
+	// n3dsProductPattern matches 3DS product codes like CTR-P-AXCE
+	n3dsProductPattern = regexp.MustCompile(`(?i)(?:^|[^A-Z0-9])((?:CTR|KTR)-[A-Z]-[A-Z0-9]{4})(?:[^A-Z0-9]|$)`)
+	// n3dsTitlePattern matches 3DS title IDs like 0004000000030800
+	n3dsTitlePattern = regexp.MustCompile(`(?i)(?:^|[^0-9A-F])(0004000[0-9A-F]{9})(?:[^0-9A-F]|$)`)
+
+	// switchProductPattern matches Switch product codes like LA-H-AAAAA
+	switchProductPattern = regexp.MustCompile(`(?i)(?:^|[^A-Z0-9])((?:LA|HAC)-[A-Z]-[A-Z0-9]{5})(?:[^A-Z0-9]|$)`)
+	// switchTitlePattern matches Switch title IDs like 01007EF00011E000
+	switchTitlePattern = regexp.MustCompile(`(?i)(?:^|[^0-9A-F])(01[0-9A-F]{14})(?:[^0-9A-F]|$)`)
+)
+
+// extractors are the serial extractors of each platform.
+var extractors = map[platform.Slug]Extractor{
+	platform.SlugPSX:            sony,
+	platform.SlugPS2:            sony,
+	platform.SlugPSP:            sony,
+	platform.SlugSaturn:         sega,
+	platform.SlugDC:             sega,
+	platform.SlugNGC:            first(gameCubePattern),
+	platform.SlugWii:            first(wiiPattern),
+	platform.SlugN3DS:           n3ds,
+	platform.SlugNewNintendo3DS: n3ds,
+	platform.SlugSwitch:         nintendoSwitch,
+}
+
+// unambiguous are the extractors whose formats are only used by serials,
+// tried when the platform isn't known.
+var unambiguous = []Extractor{sony, first(n3dsProductPattern), first(switchProductPattern)}
+
+// Extract returns the serial in a filename for a platform, or "" if it has
+// none. With an empty platform, or one without serials, only PlayStation
+// serials and 3DS and Switch product codes are recognized.
+func Extract(name string, slug platform.Slug) string {
+	if extractor, ok := extractors[slug]; ok {
+		return extractor(name)
+	}
+	if extractor, ok := extractors[slug.Parent()]; ok {
+		return extractor(name)
+	}
+	for _, extractor := range unambiguous {
+		if serial := extractor(name); serial != "" {
+			return serial
+		}
+	}
+	return ""
+}
+
+// Platforms returns the platforms with serial extractors, sorted.
+func Platforms() []platform.Slug {
+	slugs := make([]platform.Slug, 0, len(extractors))
+	for slug := range extractors {
+		slugs = append(slugs, slug)
+	}
+	slices.Sort(slugs)
+	return slugs
+}
+
+// first returns an extractor returning the first match of pattern's first
+// group, upper cased.
+func first(pattern *regexp.Regexp) Extractor {
+	return func(name string) string {
+		match := pattern.FindStringSubmatch(name)
+		if len(match) < 2 {
+			return ""
+		}
+		return strings.ToUpper(match[1])
+	}
+}
+
+// sony extracts PlayStation serials, normalized to SLUS-12345.
+func sony(name string) string {
+	match := sonyPattern.FindStringSubmatch(name)
+	if len(match) < 4 {
+		return ""
+	}
+	return strings.ToUpper(match[1]) + "-" + match[2] + match[3]
+}
+
+// sega extracts Saturn and Dreamcast serials, without region suffixes.
+func sega(name string) string {
+	return first(segaPattern)(name)
+}
+
+// n3ds extracts 3DS product codes, or title IDs if there's none.
+func n3ds(name string) string {
+	if serial := first(n3dsProductPattern)(name); serial != "" {
+		return serial
+	}
+	return first(n3dsTitlePattern)(name)
+}
+
+// nintendoSwitch extracts Switch product codes, or title IDs if there's
+// none.
+func nintendoSwitch(name string) string {
+	if serial := first(switchProductPattern)(name); serial != "" {
+		return serial
+	}
+	return first(switchTitlePattern)(name)
+}
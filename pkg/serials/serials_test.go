@@ -0,0 +1,40 @@
+package serials
+
+import (
+	"testing"
+
+	"github.com/josegonzalez/retro-metadata/pkg/platform"
+)
+
+func TestExtract(t *testing.T) {
+	tests := []struct {
+		name     string
+		platform platform.Slug
+		want     string
+	}{
+		{"Final Fantasy VII (USA) [SCUS-94163].bin", platform.SlugPSX, "SCUS-94163"},
+		{"SLUS_123.45.Game.iso", platform.SlugPS2, "SLUS-12345"},
+		{"Game_slus-21234.iso", "", "SLUS-21234"},
+		{"Patapon (USA) [UCUS-98711].iso", platform.SlugPSP, "UCUS-98711"},
+		{"Nights into Dreams (USA) (MK-81020).cue", platform.SlugSaturn, "MK-81020"},
+		{"Panzer Dragoon Saga [T-12705H-50].cue", platform.SlugSaturn, "T-12705H"},
+		{"Shenmue (T-9501N).gdi", platform.SlugDC, "T-9501N"},
+		{"Shenmue (T-9501N).gdi", "", ""},
+		{"Zelda - Wind Waker [GZLE01].iso", platform.SlugNGC, "GZLE01"},
+		{"PUZZLE LEAGUE.iso", platform.SlugNGC, ""},
+		{"Super Mario Galaxy [RMGE01].wbfs", platform.SlugWii, "RMGE01"},
+		{"Zelda - Wind Waker [GZLE01].iso", platform.SlugWii, ""},
+		{"Pokemon X (CTR-P-EKJA).3ds", "", "CTR-P-EKJA"},
+		{"Super Mario 3D Land [0004000000054000].cia", platform.SlugN3DS, "0004000000054000"},
+		{"Zelda BotW [LA-H-AAAAA].xci", "", "LA-H-AAAAA"},
+		{"Zelda BotW [01007EF00011E000].nsp", platform.SlugSwitch, "01007EF00011E000"},
+		{"Zelda BotW [01007EF00011E000].nsp", "", ""},
+		{"Super Mario World (USA).sfc", platform.SlugSNES, ""},
+	}
+
+	for _, tt := range tests {
+		if got := Extract(tt.name, tt.platform); got != tt.want {
+			t.Errorf("Extract(%q, %q) = %q, want %q", tt.name, tt.platform, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,404 @@
+// Package server exposes a Client over a small HTTP and JSON API, so programs
+// in other languages can use the providers and the shared cache.
+//
+// Endpoints:
+//
+//	GET  /v1/search?q=<query>[&platform=<slug>][&provider=<name>...][&limit=<n>][&cursor=<c>]
+//	POST /v1/identify                      {"filename", "platform", "hashes", "providers", "exclude_providers"}
+//	GET  /v1/games/{provider}/{id}
+//	GET  /v1/games/{provider}/{id}/artwork
+//	GET  /v1/providers
+//
+// Requests are authenticated with an API key, sent as "Authorization: Bearer
+// <key>" or "X-API-Key: <key>". Errors are returned as {"error": "..."} with
+// a status code for their kind: 404 for unknown games and providers, 429 for
+// rate limits (with Retry-After), 502 for provider failures, 504 for
+// requests cancelled or timed out, and 500 for configuration and cache
+// errors.
+package server
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/josegonzalez/retro-metadata/pkg/events"
+	"github.com/josegonzalez/retro-metadata/pkg/identify"
+	"github.com/josegonzalez/retro-metadata/pkg/platform"
+	"github.com/josegonzalez/retro-metadata/pkg/retrometadata"
+)
+
+// maxBodySize is the largest request body accepted.
+const maxBodySize = 1 << 20
+
+// Server serves a Client over HTTP.
+type Server struct {
+	client     *retrometadata.Client
+	identifier *identify.Pipeline
+	apiKeys    [][]byte
+	mux        *http.ServeMux
+	events     *events.Bus
+	monitor    *events.ProviderMonitor
+}
+
+// Option is a functional option for Server.
+type Option func(*Server)
+
+// WithAPIKeys sets the API keys accepted by the server. Without keys, every
+// request is accepted; only do this when the server isn't reachable by others.
+func WithAPIKeys(keys ...string) Option {
+	return func(s *Server) {
+		for _, key := range keys {
+			if key != "" {
+				s.apiKeys = append(s.apiKeys, []byte(key))
+			}
+		}
+	}
+}
+
+// WithIdentifier sets the identification pipeline used by /v1/identify.
+func WithIdentifier(identifier *identify.Pipeline) Option {
+	return func(s *Server) {
+		s.identifier = identifier
+	}
+}
+
+// WithEvents publishes a GameIdentified event for each game identified with
+// /v1/identify, a ProviderFailing event when a provider fails
+// events.DefaultFailureThreshold times in a row, and a ProviderChanged event
+// when the client's providers change.
+func WithEvents(bus *events.Bus) Option {
+	return func(s *Server) {
+		s.events = bus
+	}
+}
+
+// New creates a server for client. Identification uses
+// identify.DefaultPipeline unless set with WithIdentifier.
+func New(client *retrometadata.Client, opts ...Option) *Server {
+	s := &Server{
+		client:     client,
+		identifier: identify.DefaultPipeline(),
+		mux:        http.NewServeMux(),
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	if s.events != nil {
+		s.monitor = events.NewProviderMonitor(s.events, events.DefaultFailureThreshold)
+		s.identifier = s.identifier.Clone().Observe(s.monitor.Observe)
+		client.OnProviderChange(func(change retrometadata.ProviderChange) {
+			s.events.Publish(events.New(events.ProviderChanged, change))
+		})
+	}
+
+	s.mux.HandleFunc("GET /v1/search", s.handleSearch)
+	s.mux.HandleFunc("POST /v1/identify", s.handleIdentify)
+	s.mux.HandleFunc("GET /v1/games/{provider}/{id}", s.handleGame)
+	s.mux.HandleFunc("GET /v1/games/{provider}/{id}/artwork", s.handleArtwork)
+	s.mux.HandleFunc("GET /v1/providers", s.handleProviders)
+	return s
+}
+
+// ServeHTTP authenticates a request and routes it to its handler.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !s.authorized(r) {
+		w.Header().Set("WWW-Authenticate", `Bearer realm="retro-metadata"`)
+		writeError(w, http.StatusUnauthorized, "invalid or missing API key")
+		return
+	}
+	s.mux.ServeHTTP(w, r)
+}
+
+// authorized reports whether a request has a valid API key.
+func (s *Server) authorized(r *http.Request) bool {
+	if len(s.apiKeys) == 0 {
+		return true
+	}
+
+	key := r.Header.Get("X-API-Key")
+	if auth := r.Header.Get("Authorization"); key == "" && strings.HasPrefix(auth, "Bearer ") {
+		key = strings.TrimPrefix(auth, "Bearer ")
+	}
+	if key == "" {
+		return false
+	}
+
+	// Every key is compared so the response time doesn't reveal which matched
+	valid := 0
+	for _, apiKey := range s.apiKeys {
+		valid |= subtle.ConstantTimeCompare([]byte(key), apiKey)
+	}
+	return valid == 1
+}
+
+// searchResponse is the response of /v1/search.
+type searchResponse struct {
+	Results []retrometadata.SearchResult `json:"results"`
+	HasMore bool                         `json:"has_more,omitempty"`
+	Cursor  string                       `json:"cursor,omitempty"`
+}
+
+// handleSearch searches every selected provider, or pages through a single
+// provider's results when one provider and a cursor or offset are given.
+func (s *Server) handleSearch(w http.ResponseWriter, r *http.Request) {
+	params := r.URL.Query()
+	query := params.Get("q")
+	if query == "" {
+		writeError(w, http.StatusBadRequest, "missing query parameter q")
+		return
+	}
+
+	opts := retrometadata.SearchOptions{
+		Providers:        params["provider"],
+		ExcludeProviders: params["exclude_provider"],
+		Cursor:           params.Get("cursor"),
+	}
+	var err error
+	if opts.Limit, err = intParam(params.Get("limit")); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid limit")
+		return
+	}
+	if opts.Offset, err = intParam(params.Get("offset")); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid offset")
+		return
+	}
+
+	slug := platform.Slug(params.Get("platform"))
+	if slug != "" && !slug.IsValid() {
+		writeError(w, http.StatusBadRequest, "unknown platform: "+string(slug))
+		return
+	}
+
+	if len(opts.Providers) == 1 && (opts.Cursor != "" || opts.Offset > 0) {
+		if slug != "" {
+			if opts.PlatformID = platform.GetPlatformID(opts.Providers[0], slug); opts.PlatformID == nil {
+				writeJSON(w, http.StatusOK, searchResponse{Results: []retrometadata.SearchResult{}})
+				return
+			}
+		}
+		page, err := s.client.SearchPage(r.Context(), query, opts)
+		if err != nil {
+			writeClientError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, searchResponse{Results: page.Results, HasMore: page.HasMore, Cursor: page.Cursor})
+		return
+	}
+
+	results, err := s.search(r.Context(), query, slug, opts)
+	if err != nil {
+		writeClientError(w, err)
+		return
+	}
+	if results == nil {
+		results = []retrometadata.SearchResult{}
+	}
+	writeJSON(w, http.StatusOK, searchResponse{Results: results})
+}
+
+// search searches the selected providers. Platform IDs are provider-specific,
+// so with a platform each provider is searched with its own ID, and providers
+// that don't list the platform are skipped.
+func (s *Server) search(ctx context.Context, query string, slug platform.Slug, opts retrometadata.SearchOptions) ([]retrometadata.SearchResult, error) {
+	if slug == "" {
+		return s.client.Search(ctx, query, opts)
+	}
+
+	limit := opts.Limit
+	if limit == 0 {
+		limit = 10
+	}
+
+	var results []retrometadata.SearchResult
+	for _, name := range s.providerNames(opts.Providers, opts.ExcludeProviders) {
+		providerOpts := opts
+		providerOpts.Providers = []string{name}
+		if providerOpts.PlatformID = platform.GetPlatformID(name, slug); providerOpts.PlatformID == nil {
+			continue
+		}
+		found, err := s.client.Search(ctx, query, providerOpts)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, found...)
+		if len(results) >= limit {
+			return results[:limit], nil
+		}
+	}
+	return results, nil
+}
+
+// identifyRequest is the body of /v1/identify.
+type identifyRequest struct {
+	Filename         string                    `json:"filename"`
+	Platform         string                    `json:"platform,omitempty"`
+	Hashes           *retrometadata.FileHashes `json:"hashes,omitempty"`
+	Providers        []string                  `json:"providers,omitempty"`
+	ExcludeProviders []string                  `json:"exclude_providers,omitempty"`
+	// Trace adds the match trace to the result
+	Trace bool `json:"trace,omitempty"`
+}
+
+// handleIdentify identifies a ROM with the identification pipeline, from
+// its file name and hashes alone.
+func (s *Server) handleIdentify(w http.ResponseWriter, r *http.Request) {
+	var body identifyRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxBodySize)).Decode(&body); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body: "+err.Error())
+		return
+	}
+	if body.Filename == "" && body.Hashes == nil {
+		writeError(w, http.StatusBadRequest, "filename or hashes required")
+		return
+	}
+
+	slug := platform.Slug(body.Platform)
+	if slug != "" && !slug.IsValid() {
+		writeError(w, http.StatusBadRequest, "unknown platform: "+body.Platform)
+		return
+	}
+
+	// The filename is only matched by name: the server never opens files
+	// named by clients
+	name := body.Filename
+	if name != "" {
+		name = filepath.Base(name)
+	}
+
+	ctx := r.Context()
+	var trace *retrometadata.MatchTrace
+	if body.Trace {
+		trace = &retrometadata.MatchTrace{Filename: name}
+		ctx = retrometadata.WithMatchTrace(ctx, trace)
+	}
+
+	result, err := s.identifier.IdentifyWithClient(ctx, s.client, identify.Request{
+		Filename: name,
+		Hashes:   body.Hashes,
+		Platform: slug,
+		Options: retrometadata.IdentifyOptions{
+			Providers:        body.Providers,
+			ExcludeProviders: body.ExcludeProviders,
+		},
+		NameOnly: true,
+	})
+	if err == nil && result == nil {
+		err = &retrometadata.GameNotFoundError{SearchTerm: name}
+	}
+	if err != nil {
+		writeClientError(w, err)
+		return
+	}
+	s.events.Publish(events.New(events.GameIdentified, events.GameFromResult(name, result)))
+	result.MatchTrace = trace
+	writeJSON(w, http.StatusOK, result)
+}
+
+// handleGame returns a game by provider ID.
+func (s *Server) handleGame(w http.ResponseWriter, r *http.Request) {
+	result, err := s.game(r)
+	if err != nil {
+		writeClientError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, result)
+}
+
+// handleArtwork returns the artwork of a game by provider ID.
+func (s *Server) handleArtwork(w http.ResponseWriter, r *http.Request) {
+	result, err := s.game(r)
+	if err != nil {
+		writeClientError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, result.Artwork)
+}
+
+// game looks up the game named by the {provider} and {id} path values.
+func (s *Server) game(r *http.Request) (*retrometadata.GameResult, error) {
+	provider, id := r.PathValue("provider"), r.PathValue("id")
+	result, err := s.client.GetByUID(r.Context(), provider, id)
+	if s.monitor != nil && !errors.Is(err, retrometadata.ErrGameNotFound) && !errors.Is(err, retrometadata.ErrProviderNotFound) {
+		s.monitor.Observe("get", provider, err)
+	}
+	if err == nil && result == nil {
+		err = &retrometadata.GameNotFoundError{SearchTerm: id, Provider: provider}
+	}
+	return result, err
+}
+
+// handleProviders returns the status of every enabled provider.
+func (s *Server) handleProviders(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, s.client.Heartbeat(r.Context()))
+}
+
+// providerNames returns the enabled providers allowed by include and
+// exclude, in priority order.
+func (s *Server) providerNames(include, exclude []string) []string {
+	var names []string
+	for _, p := range s.client.Providers() {
+		name := p.Name()
+		if len(include) > 0 && !slices.Contains(include, name) {
+			continue
+		}
+		if slices.Contains(exclude, name) {
+			continue
+		}
+		names = append(names, name)
+	}
+	return names
+}
+
+// intParam parses an optional non-negative integer query parameter.
+func intParam(value string) (int, error) {
+	if value == "" {
+		return 0, nil
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 0 {
+		return 0, errors.New("invalid integer")
+	}
+	return n, nil
+}
+
+// writeClientError writes an error from the client with the status code for
+// its kind. Errors the client doesn't raise itself come from the providers,
+// and are reported as 502 Bad Gateway.
+func writeClientError(w http.ResponseWriter, err error) {
+	var rateLimit *retrometadata.RateLimitError
+	switch {
+	case errors.Is(err, retrometadata.ErrGameNotFound), errors.Is(err, retrometadata.ErrProviderNotFound):
+		writeError(w, http.StatusNotFound, err.Error())
+	case errors.As(err, &rateLimit):
+		if rateLimit.RetryAfter > 0 {
+			w.Header().Set("Retry-After", strconv.Itoa(rateLimit.RetryAfter))
+		}
+		writeError(w, http.StatusTooManyRequests, err.Error())
+	case errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded):
+		writeError(w, http.StatusGatewayTimeout, err.Error())
+	case errors.Is(err, retrometadata.ErrInvalidConfig), errors.Is(err, retrometadata.ErrCacheOperation):
+		writeError(w, http.StatusInternalServerError, err.Error())
+	default:
+		// Anything else failed upstream, in a provider or its connection
+		writeError(w, http.StatusBadGateway, err.Error())
+	}
+}
+
+// writeError writes a JSON error response.
+func writeError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, map[string]string{"error": message})
+}
+
+// writeJSON writes a JSON response.
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,210 @@
+package server
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/josegonzalez/retro-metadata/pkg/cache"
+	"github.com/josegonzalez/retro-metadata/pkg/identify"
+	"github.com/josegonzalez/retro-metadata/pkg/retrometadata"
+)
+
+// fakeProvider knows a single game, ID 1018, and fails to get ID 500.
+type fakeProvider struct{}
+
+func (fakeProvider) Name() string { return "mobygames" }
+
+func (fakeProvider) Search(_ context.Context, query string, opts retrometadata.SearchOptions) ([]retrometadata.SearchResult, error) {
+	return []retrometadata.SearchResult{{Name: query, Provider: "mobygames", ProviderID: 1018}}, nil
+}
+
+func (fakeProvider) GetByID(_ context.Context, id int) (*retrometadata.GameResult, error) {
+	if id == 500 {
+		return nil, errors.New("unexpected response")
+	}
+	if id != 1018 {
+		return nil, nil
+	}
+	return &retrometadata.GameResult{
+		Name:     "Super Mario World",
+		Provider: "mobygames",
+		Artwork:  retrometadata.Artwork{CoverURL: "https://example.com/cover.png"},
+	}, nil
+}
+
+func (fakeProvider) Identify(_ context.Context, filename string, _ retrometadata.IdentifyOptions) (*retrometadata.GameResult, error) {
+	if !strings.HasPrefix(filename, "Super Mario World") {
+		return nil, nil
+	}
+	return &retrometadata.GameResult{Name: "Super Mario World", Provider: "mobygames"}, nil
+}
+
+func (fakeProvider) Heartbeat(context.Context) error { return nil }
+
+func (fakeProvider) Close() error { return nil }
+
+func newTestServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	retrometadata.RegisterProvider("mobygames", func(retrometadata.ProviderConfig, cache.Cache) (retrometadata.Provider, error) {
+		return fakeProvider{}, nil
+	})
+	client, err := retrometadata.NewClient(retrometadata.WithMobyGames("key"))
+	if err != nil {
+		t.Fatalf("NewClient() error: %v", err)
+	}
+	t.Cleanup(func() { client.Close() })
+
+	ts := httptest.NewServer(New(client, WithAPIKeys("secret")))
+	t.Cleanup(ts.Close)
+	return ts
+}
+
+func do(t *testing.T, method, url, body string, v any) int {
+	t.Helper()
+	req, err := http.NewRequest(method, url, strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Authorization", "Bearer secret")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if v != nil {
+		if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+			t.Fatalf("%s %s: decoding response: %v", method, url, err)
+		}
+	}
+	return resp.StatusCode
+}
+
+func TestServerAuth(t *testing.T) {
+	ts := newTestServer(t)
+
+	resp, err := http.Get(ts.URL + "/v1/providers")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("Request without a key = %d, expected 401", resp.StatusCode)
+	}
+
+	req, _ := http.NewRequest(http.MethodGet, ts.URL+"/v1/providers", nil)
+	req.Header.Set("X-API-Key", "secret")
+	resp, err = http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("Request with X-API-Key = %d, expected 200", resp.StatusCode)
+	}
+}
+
+func TestServerEndpoints(t *testing.T) {
+	ts := newTestServer(t)
+
+	var search searchResponse
+	if status := do(t, http.MethodGet, ts.URL+"/v1/search?q=Mario&platform=snes", "", &search); status != http.StatusOK {
+		t.Fatalf("search = %d, expected 200", status)
+	}
+	if len(search.Results) != 1 || search.Results[0].ProviderID != 1018 {
+		t.Errorf("search results = %+v", search.Results)
+	}
+	if status := do(t, http.MethodGet, ts.URL+"/v1/search?q=Mario&platform=bogus", "", nil); status != http.StatusBadRequest {
+		t.Errorf("search with an unknown platform = %d, expected 400", status)
+	}
+
+	var game retrometadata.GameResult
+	if status := do(t, http.MethodGet, ts.URL+"/v1/games/mobygames/1018", "", &game); status != http.StatusOK || game.Name != "Super Mario World" {
+		t.Errorf("game = %d %+v", status, game)
+	}
+	var artwork retrometadata.Artwork
+	if status := do(t, http.MethodGet, ts.URL+"/v1/games/mobygames/1018/artwork", "", &artwork); status != http.StatusOK || artwork.CoverURL == "" {
+		t.Errorf("artwork = %d %+v", status, artwork)
+	}
+
+	var errBody map[string]string
+	if status := do(t, http.MethodGet, ts.URL+"/v1/games/mobygames/1", "", &errBody); status != http.StatusNotFound || errBody["error"] == "" {
+		t.Errorf("missing game = %d %v, expected 404 with an error", status, errBody)
+	}
+	if status := do(t, http.MethodGet, ts.URL+"/v1/games/igdb/1018", "", nil); status != http.StatusNotFound {
+		t.Errorf("unconfigured provider = %d, expected 404", status)
+	}
+	if status := do(t, http.MethodGet, ts.URL+"/v1/games/mobygames/500", "", nil); status != http.StatusBadGateway {
+		t.Errorf("provider failure = %d, expected 502", status)
+	}
+
+	var identified retrometadata.GameResult
+	status := do(t, http.MethodPost, ts.URL+"/v1/identify", `{"filename": "Super Mario World (USA).sfc", "platform": "snes"}`, &identified)
+	if status != http.StatusOK || identified.Name != "Super Mario World" {
+		t.Errorf("identify = %d %+v", status, identified)
+	}
+	if status := do(t, http.MethodPost, ts.URL+"/v1/identify", `{}`, nil); status != http.StatusBadRequest {
+		t.Errorf("identify without a filename = %d, expected 400", status)
+	}
+}
+
+// recordingStep records the requests it's given, and finds nothing.
+type recordingStep struct {
+	requests *[]identify.Request
+}
+
+func (recordingStep) Name() string { return "recording" }
+
+func (s recordingStep) Identify(_ context.Context, _ retrometadata.Provider, req identify.Request) (*retrometadata.GameResult, error) {
+	*s.requests = append(*s.requests, req)
+	return nil, nil
+}
+
+func TestServerIdentifyDoesNotOpenFiles(t *testing.T) {
+	retrometadata.RegisterProvider("mobygames", func(retrometadata.ProviderConfig, cache.Cache) (retrometadata.Provider, error) {
+		return fakeProvider{}, nil
+	})
+	client, err := retrometadata.NewClient(retrometadata.WithMobyGames("key"))
+	if err != nil {
+		t.Fatalf("NewClient() error: %v", err)
+	}
+	defer client.Close()
+
+	var requests []identify.Request
+	pipeline := identify.NewPipeline(recordingStep{&requests}, identify.HeaderStep{})
+	ts := httptest.NewServer(New(client, WithAPIKeys("secret"), WithIdentifier(pipeline)))
+	defer ts.Close()
+
+	// A big-endian N64 ROM header titled SUPER MARIO 64, which the header
+	// step would search for if it read the file
+	rom := make([]byte, 0x40)
+	binary.BigEndian.PutUint32(rom, 0x80371240)
+	copy(rom[0x20:], "SUPER MARIO 64")
+	path := filepath.Join(t.TempDir(), "rom001.z64")
+	if err := os.WriteFile(path, rom, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, filename := range []string{"/etc/passwd", path} {
+		body, _ := json.Marshal(map[string]string{"filename": filename})
+		if status := do(t, http.MethodPost, ts.URL+"/v1/identify", string(body), nil); status != http.StatusNotFound {
+			t.Errorf("identify %s = %d, expected 404 without reading the file", filename, status)
+		}
+	}
+	if len(requests) != 2 {
+		t.Fatalf("%d requests identified, expected 2", len(requests))
+	}
+	for i, want := range []string{"passwd", "rom001.z64"} {
+		if req := requests[i]; req.Filename != want || !req.NameOnly {
+			t.Errorf("requests[%d] = (%q, NameOnly %v), expected (%q, true)", i, req.Filename, req.NameOnly, want)
+		}
+	}
+}
@@ -0,0 +1,211 @@
+// Package providertest provides a mock metadata provider and fixture
+// servers, so applications built on the client can be tested without
+// network access or credentials.
+package providertest
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/josegonzalez/retro-metadata/pkg/cache"
+	"github.com/josegonzalez/retro-metadata/pkg/filename"
+	"github.com/josegonzalez/retro-metadata/pkg/retrometadata"
+)
+
+// Provider methods, for Provider.Errors, Latencies and Calls.
+const (
+	MethodSearch         = "Search"
+	MethodGetByID        = "GetByID"
+	MethodGetByUID       = "GetByUID"
+	MethodIdentify       = "Identify"
+	MethodIdentifyByHash = "IdentifyByHash"
+	MethodHeartbeat      = "Heartbeat"
+)
+
+// Provider is a retrometadata.Provider answering from canned games. Set its fields
+// before handing it to a client; they mustn't change while it's in use.
+type Provider struct {
+	// ProviderName is the name the provider is registered under
+	ProviderName string
+	// Games are the games the provider knows. Search returns the games whose
+	// name contains the query, GetByID and GetByUID look them up by
+	// ProviderID and ProviderUID, and Identify returns the game named like
+	// the cleaned filename
+	Games []retrometadata.GameResult
+	// SearchResults, if set, are returned by every search instead
+	SearchResults []retrometadata.SearchResult
+	// Hashes maps lower case MD5, SHA1, CRC32 or SHA256 hashes to the names of games
+	// returned by IdentifyByHash
+	Hashes map[string]string
+	// Latency delays every call, unless Latencies sets the method's own
+	Latency time.Duration
+	// Latencies delays calls to a method (MethodSearch, ...)
+	Latencies map[string]time.Duration
+	// Errors makes calls to a method fail with an error
+	Errors map[string]error
+
+	mu    sync.Mutex
+	calls map[string]int
+}
+
+// New creates a provider named name knowing games. Games
+// without a Provider get name.
+func New(name string, games ...retrometadata.GameResult) *Provider {
+	for i := range games {
+		if games[i].Provider == "" {
+			games[i].Provider = name
+		}
+	}
+	return &Provider{ProviderName: name, Games: games}
+}
+
+// Register registers the provider with the client's provider registry under
+// its name, replacing the real provider. Clients created afterwards with the
+// provider enabled use it.
+func (p *Provider) Register() {
+	retrometadata.RegisterProvider(p.ProviderName, func(retrometadata.ProviderConfig, cache.Cache) (retrometadata.Provider, error) {
+		return p, nil
+	})
+}
+
+// Calls returns the number of calls made to a method.
+func (p *Provider) Calls(method string) int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.calls[method]
+}
+
+// call records a call to a method, waits for its latency and returns its
+// configured error.
+func (p *Provider) call(ctx context.Context, method string) error {
+	p.mu.Lock()
+	if p.calls == nil {
+		p.calls = make(map[string]int)
+	}
+	p.calls[method]++
+	p.mu.Unlock()
+
+	latency := p.Latency
+	if d, ok := p.Latencies[method]; ok {
+		latency = d
+	}
+	if latency > 0 {
+		timer := time.NewTimer(latency)
+		defer timer.Stop()
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return p.Errors[method]
+}
+
+// find returns a copy of the first game matching match, or nil.
+func (p *Provider) find(match func(*retrometadata.GameResult) bool) *retrometadata.GameResult {
+	for i := range p.Games {
+		if match(&p.Games[i]) {
+			game := p.Games[i]
+			return &game
+		}
+	}
+	return nil
+}
+
+// Name returns the provider name.
+func (p *Provider) Name() string {
+	return p.ProviderName
+}
+
+// Search returns SearchResults, or the games whose name contains query.
+func (p *Provider) Search(ctx context.Context, query string, opts retrometadata.SearchOptions) ([]retrometadata.SearchResult, error) {
+	if err := p.call(ctx, MethodSearch); err != nil {
+		return nil, err
+	}
+	if p.SearchResults != nil {
+		return p.SearchResults, nil
+	}
+
+	var results []retrometadata.SearchResult
+	query = strings.ToLower(query)
+	for _, game := range p.Games {
+		if !strings.Contains(strings.ToLower(game.Name), query) {
+			continue
+		}
+		result := retrometadata.SearchResult{
+			Name:        game.Name,
+			Provider:    game.Provider,
+			ProviderUID: game.ProviderUID,
+			CoverURL:    game.Artwork.CoverURL,
+			ReleaseYear: game.Metadata.ReleaseYear,
+		}
+		if game.ProviderID != nil {
+			result.ProviderID = *game.ProviderID
+		}
+		results = append(results, result)
+		if opts.Limit > 0 && len(results) == opts.Limit {
+			break
+		}
+	}
+	return results, nil
+}
+
+// GetByID returns the game with ProviderID gameID, or nil.
+func (p *Provider) GetByID(ctx context.Context, gameID int) (*retrometadata.GameResult, error) {
+	if err := p.call(ctx, MethodGetByID); err != nil {
+		return nil, err
+	}
+	return p.find(func(game *retrometadata.GameResult) bool {
+		return game.ProviderID != nil && *game.ProviderID == gameID
+	}), nil
+}
+
+// GetByUID returns the game with ProviderUID uid, or with ProviderID uid,
+// or nil.
+func (p *Provider) GetByUID(ctx context.Context, uid string) (*retrometadata.GameResult, error) {
+	if err := p.call(ctx, MethodGetByUID); err != nil {
+		return nil, err
+	}
+	return p.find(func(game *retrometadata.GameResult) bool {
+		return game.ProviderUID == uid || (game.ProviderID != nil && strconv.Itoa(*game.ProviderID) == uid)
+	}), nil
+}
+
+// Identify returns the game named like the cleaned filename, or nil.
+func (p *Provider) Identify(ctx context.Context, name string, opts retrometadata.IdentifyOptions) (*retrometadata.GameResult, error) {
+	if err := p.call(ctx, MethodIdentify); err != nil {
+		return nil, err
+	}
+	cleaned := filename.CleanFilename(name, true)
+	return p.find(func(game *retrometadata.GameResult) bool {
+		return strings.EqualFold(game.Name, cleaned)
+	}), nil
+}
+
+// IdentifyByHash returns the game one of the hashes maps to in Hashes, or nil.
+func (p *Provider) IdentifyByHash(ctx context.Context, hashes retrometadata.FileHashes, opts retrometadata.IdentifyOptions) (*retrometadata.GameResult, error) {
+	if err := p.call(ctx, MethodIdentifyByHash); err != nil {
+		return nil, err
+	}
+	for _, hash := range []string{hashes.MD5, hashes.SHA1, hashes.CRC32, hashes.SHA256} {
+		name, ok := p.Hashes[strings.ToLower(hash)]
+		if hash == "" || !ok {
+			continue
+		}
+		return p.find(func(game *retrometadata.GameResult) bool { return game.Name == name }), nil
+	}
+	return nil, nil
+}
+
+// Heartbeat returns the configured Heartbeat error.
+func (p *Provider) Heartbeat(ctx context.Context) error {
+	return p.call(ctx, MethodHeartbeat)
+}
+
+// Close does nothing.
+func (p *Provider) Close() error {
+	return nil
+}
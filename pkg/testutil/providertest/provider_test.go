@@ -0,0 +1,99 @@
+package providertest
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/josegonzalez/retro-metadata/pkg/provider/igdb"
+	"github.com/josegonzalez/retro-metadata/pkg/provider/mobygames"
+	"github.com/josegonzalez/retro-metadata/pkg/retrometadata"
+)
+
+func TestProvider(t *testing.T) {
+	id := 1018
+	p := New("mobygames",
+		retrometadata.GameResult{Name: "Super Mario World", ProviderID: &id},
+		retrometadata.GameResult{Name: "Super Mario Kart"},
+	)
+	p.Hashes = map[string]string{"cdd3c8c37322978ca8669b34bc89c804": "Super Mario World"}
+
+	ctx := context.Background()
+	results, err := p.Search(ctx, "mario", retrometadata.SearchOptions{Limit: 1})
+	if err != nil || len(results) != 1 || results[0].ProviderID != id || results[0].Provider != "mobygames" {
+		t.Errorf("Search() = %+v, %v; want Super Mario World", results, err)
+	}
+	if game, err := p.GetByUID(ctx, "1018"); err != nil || game == nil || game.Name != "Super Mario World" {
+		t.Errorf("GetByUID() = %+v, %v", game, err)
+	}
+	if game, err := p.Identify(ctx, "Super Mario Kart (USA).sfc", retrometadata.IdentifyOptions{}); err != nil || game == nil || game.Name != "Super Mario Kart" {
+		t.Errorf("Identify() = %+v, %v", game, err)
+	}
+	hashes := retrometadata.FileHashes{MD5: "CDD3C8C37322978CA8669B34BC89C804"}
+	if game, err := p.IdentifyByHash(ctx, hashes, retrometadata.IdentifyOptions{}); err != nil || game == nil || game.Name != "Super Mario World" {
+		t.Errorf("IdentifyByHash() = %+v, %v", game, err)
+	}
+	if p.Calls(MethodSearch) != 1 || p.Calls(MethodGetByID) != 0 {
+		t.Errorf("Calls = %d, %d; want 1, 0", p.Calls(MethodSearch), p.Calls(MethodGetByID))
+	}
+
+	// Errors and latencies are per method
+	p.Errors = map[string]error{MethodSearch: retrometadata.ErrProviderRateLimit}
+	p.Latencies = map[string]time.Duration{MethodIdentify: time.Hour}
+	if _, err := p.Search(ctx, "mario", retrometadata.SearchOptions{}); !errors.Is(err, retrometadata.ErrProviderRateLimit) {
+		t.Errorf("Search() error = %v, want ErrProviderRateLimit", err)
+	}
+	timeout, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+	if _, err := p.Identify(timeout, "Super Mario Kart.sfc", retrometadata.IdentifyOptions{}); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Identify() error = %v, want context.DeadlineExceeded", err)
+	}
+}
+
+func TestProviderWithClient(t *testing.T) {
+	p := New("mobygames", retrometadata.GameResult{Name: "Super Mario World"})
+	p.Register()
+
+	config := retrometadata.DefaultConfig()
+	config.MobyGames = retrometadata.ProviderConfig{Enabled: true, Credentials: map[string]string{"api_key": "key"}}
+	client, err := retrometadata.NewClient(retrometadata.WithConfig(config))
+	if err != nil {
+		t.Fatalf("NewClient() error: %v", err)
+	}
+	defer client.Close()
+
+	game, err := client.Identify(context.Background(), "Super Mario World (USA).sfc", retrometadata.IdentifyOptions{})
+	if err != nil || game == nil || game.Name != "Super Mario World" {
+		t.Errorf("Identify() = %+v, %v; want Super Mario World", game, err)
+	}
+}
+
+func TestNewServer(t *testing.T) {
+	fixtures, err := Fixtures()
+	if err != nil {
+		t.Skipf("Skipping test: %v", err)
+	}
+	server := NewServer(t, fixtures, MobyGamesRoutes...)
+
+	config := retrometadata.ProviderConfig{Enabled: true, Credentials: map[string]string{"api_key": "key"}, RateLimit: 100}
+	p, err := mobygames.NewProviderWithOptions(config, nil, mobygames.Options{BaseURL: server.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+	results, err := p.Search(context.Background(), "Legend of Zelda", retrometadata.SearchOptions{})
+	if err != nil || len(results) == 0 {
+		t.Errorf("Search() = %v, %v; want the fixture's results", results, err)
+	}
+
+	server = NewServer(t, fixtures, IGDBRoutes...)
+	config = retrometadata.ProviderConfig{Enabled: true, Credentials: map[string]string{"client_id": "id", "client_secret": "secret"}}
+	igdbProvider, err := igdb.NewProviderWithOptions(config, nil, igdb.Options{BaseURL: server.URL + "/v4", TokenURL: server.URL + "/oauth2/token"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	results, err = igdbProvider.Search(context.Background(), "Super Mario", retrometadata.SearchOptions{})
+	if err != nil || len(results) == 0 {
+		t.Errorf("IGDB Search() = %v, %v; want the fixture's results", results, err)
+	}
+}
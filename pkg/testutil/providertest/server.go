@@ -0,0 +1,98 @@
+package providertest
+
+import (
+	"errors"
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// Route is a canned response of a fixture server.
+type Route struct {
+	// Pattern is the http.ServeMux pattern of the requests answered, e.g.
+	// "GET /games/{id}"
+	Pattern string
+	// Status is the status of the response (default 200)
+	Status int
+	// Fixture is the path of the response body in the server's fixtures
+	Fixture string
+	// Body is the response body when Fixture is empty
+	Body string
+}
+
+// IGDBRoutes answers IGDB requests with the repository's IGDB fixtures.
+// Point the provider's BaseURL at the server's URL + "/v4" and its TokenURL
+// at the server's URL + "/oauth2/token".
+var IGDBRoutes = []Route{
+	{Pattern: "POST /oauth2/token", Body: `{"access_token":"test_token","expires_in":3600,"token_type":"bearer"}`},
+	{Pattern: "POST /v4/games", Fixture: "igdb/search_mario.json"},
+}
+
+// MobyGamesRoutes answers MobyGames requests with the repository's MobyGames
+// fixtures. Point the provider's BaseURL at the server's URL.
+var MobyGamesRoutes = []Route{
+	{Pattern: "GET /games", Fixture: "mobygames/search_zelda.json"},
+	{Pattern: "GET /games/564", Fixture: "mobygames/game_564.json"},
+	{Pattern: "GET /games/564/platforms/15/covers", Fixture: "mobygames/covers_564_15.json"},
+	{Pattern: "GET /games/564/platforms/15/screenshots", Fixture: "mobygames/screenshots_564_15.json"},
+}
+
+// NewServer starts a server answering requests with routes, and 404 for
+// requests no route matches. Fixtures are read from fixtures when the server
+// starts, failing the test if one is missing. The server is closed when the
+// test ends.
+func NewServer(t testing.TB, fixtures fs.FS, routes ...Route) *httptest.Server {
+	t.Helper()
+
+	mux := http.NewServeMux()
+	for _, route := range routes {
+		body := []byte(route.Body)
+		if route.Fixture != "" {
+			data, err := fs.ReadFile(fixtures, route.Fixture)
+			if err != nil {
+				t.Fatalf("loading fixture: %v", err)
+			}
+			body = data
+		}
+		status := route.Status
+		if status == 0 {
+			status = http.StatusOK
+		}
+
+		mux.HandleFunc(route.Pattern, func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", http.DetectContentType(body))
+			if len(body) > 0 && (body[0] == '{' || body[0] == '[') {
+				w.Header().Set("Content-Type", "application/json")
+			}
+			w.WriteHeader(status)
+			_, _ = w.Write(body)
+		})
+	}
+
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return server
+}
+
+// Fixtures returns the repository's testdata/fixtures directory, found by
+// walking up from the current directory.
+func Fixtures() (fs.FS, error) {
+	dir, err := os.Getwd()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		path := filepath.Join(dir, "testdata", "fixtures")
+		if info, err := os.Stat(path); err == nil && info.IsDir() {
+			return os.DirFS(path), nil
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return nil, errors.New("testdata/fixtures directory not found")
+		}
+		dir = parent
+	}
+}
@@ -0,0 +1,153 @@
+package titleinfo
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"strings"
+	"unicode/utf16"
+
+	"github.com/josegonzalez/retro-metadata/pkg/platform"
+)
+
+const (
+	// ciaHeaderSize is the size of the CIA header, including the content
+	// index
+	ciaHeaderSize = 0x2020
+	// ciaAlignment is the alignment of the sections of a CIA
+	ciaAlignment = 64
+	// metaSMDHOffset is the offset of the SMDH in a CIA's meta section
+	metaSMDHOffset = 0x400
+	// smdhSize is the size of an SMDH
+	smdhSize = 0x36C0
+	// smdhEnglish is the index of the English titles in an SMDH
+	smdhEnglish = 1
+	// mediaUnit is the unit of NCSD partition offsets
+	mediaUnit = 0x200
+)
+
+// tmdSignatureSizes are the sizes of each TMD signature type, with padding.
+var tmdSignatureSizes = map[uint32]int64{
+	0x10000: 0x200 + 0x3C, // RSA-4096 SHA-1
+	0x10001: 0x100 + 0x3C, // RSA-2048 SHA-1
+	0x10002: 0x3C + 0x40,  // ECDSA SHA-1
+	0x10003: 0x200 + 0x3C, // RSA-4096 SHA-256
+	0x10004: 0x100 + 0x3C, // RSA-2048 SHA-256
+	0x10005: 0x3C + 0x40,  // ECDSA SHA-256
+}
+
+// readCIA reads the TMD, the NCCH header of the first content and the SMDH
+// of a CIA.
+func (r Reader) readCIA(f io.ReaderAt) (*Info, error) {
+	header, err := readAt(f, 0, 0x20)
+	if err != nil {
+		return nil, err
+	}
+	if binary.LittleEndian.Uint32(header) != ciaHeaderSize {
+		return nil, ErrUnsupported
+	}
+
+	certOffset := align(ciaHeaderSize)
+	ticketOffset := certOffset + align(int64(binary.LittleEndian.Uint32(header[0x08:])))
+	tmdOffset := ticketOffset + align(int64(binary.LittleEndian.Uint32(header[0x0C:])))
+	contentOffset := tmdOffset + align(int64(binary.LittleEndian.Uint32(header[0x10:])))
+	metaSize := int64(binary.LittleEndian.Uint32(header[0x14:]))
+	metaOffset := contentOffset + align(int64(binary.LittleEndian.Uint64(header[0x18:])))
+
+	signature, err := readAt(f, tmdOffset, 4)
+	if err != nil {
+		return nil, err
+	}
+	signatureSize, ok := tmdSignatureSizes[binary.BigEndian.Uint32(signature)]
+	if !ok {
+		return nil, ErrUnsupported
+	}
+	tmd, err := readAt(f, tmdOffset+4+signatureSize+0x4C, 8)
+	if err != nil {
+		return nil, err
+	}
+
+	info := &Info{Platform: platform.SlugN3DS, TitleID: titleID(base3DSTitleID(binary.BigEndian.Uint64(tmd)))}
+	if ncch, err := readNCCH(f, contentOffset); err == nil {
+		info.ProductCode = ncch.ProductCode
+	}
+	if metaSize >= metaSMDHOffset+smdhSize {
+		info.Name = readSMDH(f, metaOffset+metaSMDHOffset)
+	}
+	return info, nil
+}
+
+// readNCSD reads the NCCH header of the first partition of a 3DS game card
+// image.
+func (r Reader) readNCSD(f io.ReaderAt) (*Info, error) {
+	header, err := readAt(f, 0x100, 0x28)
+	if err != nil {
+		return nil, err
+	}
+	if string(header[:4]) != "NCSD" {
+		return nil, ErrUnsupported
+	}
+	info, err := readNCCH(f, int64(binary.LittleEndian.Uint32(header[0x20:]))*mediaUnit)
+	if err != nil {
+		return nil, err
+	}
+	if info.TitleID == titleID(0) {
+		info.TitleID = titleID(base3DSTitleID(binary.LittleEndian.Uint64(header[0x08:])))
+	}
+	return info, nil
+}
+
+// readNCCH reads the program ID and product code from the NCCH header at
+// offset, which isn't encrypted even in encrypted dumps.
+func readNCCH(f io.ReaderAt, offset int64) (*Info, error) {
+	header, err := readAt(f, offset, 0x160)
+	if err != nil {
+		return nil, err
+	}
+	if string(header[0x100:0x104]) != "NCCH" {
+		return nil, ErrUnsupported
+	}
+	code := header[0x150:0x160]
+	if i := bytes.IndexByte(code, 0); i >= 0 {
+		code = code[:i]
+	}
+	return &Info{
+		Platform:    platform.SlugN3DS,
+		TitleID:     titleID(base3DSTitleID(binary.LittleEndian.Uint64(header[0x118:]))),
+		ProductCode: strings.TrimSpace(string(code)),
+	}, nil
+}
+
+// readSMDH returns the English short description, the name, of the SMDH
+// at offset.
+func readSMDH(f io.ReaderAt, offset int64) string {
+	smdh, err := readAt(f, offset, 0x8+(smdhEnglish+1)*0x200)
+	if err != nil || string(smdh[:4]) != "SMDH" {
+		return ""
+	}
+	name := smdh[0x8+smdhEnglish*0x200:][:0x80]
+	units := make([]uint16, 0, len(name)/2)
+	for i := 0; i < len(name); i += 2 {
+		unit := binary.LittleEndian.Uint16(name[i:])
+		if unit == 0 {
+			break
+		}
+		units = append(units, unit)
+	}
+	return strings.TrimSpace(string(utf16.Decode(units)))
+}
+
+// base3DSTitleID returns the application title ID of an update or DLC
+// title ID.
+func base3DSTitleID(id uint64) uint64 {
+	switch id >> 32 {
+	case 0x0004000E, 0x0004008C:
+		return 0x00040000<<32 | id&0xFFFFFFFF
+	}
+	return id
+}
+
+// align rounds a CIA section size up to ciaAlignment.
+func align(size int64) int64 {
+	return (size + ciaAlignment - 1) &^ (ciaAlignment - 1)
+}
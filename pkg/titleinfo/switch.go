@@ -0,0 +1,284 @@
+package titleinfo
+
+import (
+	"crypto/aes"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/xml"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/josegonzalez/retro-metadata/pkg/platform"
+)
+
+const (
+	// maxEntries and maxStringTable cap the partitions read from a dump
+	maxEntries     = 4096
+	maxStringTable = 1 << 20
+	// maxXMLSize caps the cnmt.xml and nacp.xml files read from a dump
+	maxXMLSize = 1 << 20
+
+	// xciHeaderOffset is the offset of the "HEAD" magic in XCI files
+	xciHeaderOffset = 0x100
+	// ncaHeaderSize is the encrypted part of the NCA header read, holding
+	// the program ID
+	ncaHeaderSize = 0x400
+	// ncaSectorSize is the XTS sector size of NCA headers
+	ncaSectorSize = 0x200
+
+	// baseTitleMask clears the bits distinguishing an application's update
+	// (0x800) and DLC (0x1000 and up) title IDs from its own
+	baseTitleMask = 0x1FFF
+)
+
+// partitionFormat is a PFS0 or HFS0 partition format.
+type partitionFormat struct {
+	magic     string
+	entrySize int64
+}
+
+var (
+	pfs0 = partitionFormat{magic: "PFS0", entrySize: 0x18}
+	hfs0 = partitionFormat{magic: "HFS0", entrySize: 0x40}
+)
+
+// entry is a file in a partition.
+type entry struct {
+	name   string
+	offset int64
+	size   int64
+}
+
+// readPartition returns the files in the partition at offset.
+func readPartition(r io.ReaderAt, offset int64, format partitionFormat) ([]entry, error) {
+	header, err := readAt(r, offset, 0x10)
+	if err != nil {
+		return nil, err
+	}
+	if string(header[:4]) != format.magic {
+		return nil, ErrUnsupported
+	}
+	count := int64(binary.LittleEndian.Uint32(header[4:]))
+	stringTableSize := int64(binary.LittleEndian.Uint32(header[8:]))
+	if count > maxEntries || stringTableSize > maxStringTable {
+		return nil, ErrUnsupported
+	}
+
+	table, err := readAt(r, offset+0x10, count*format.entrySize+stringTableSize)
+	if err != nil {
+		return nil, err
+	}
+	names := table[count*format.entrySize:]
+	dataOffset := offset + 0x10 + int64(len(table))
+
+	entries := make([]entry, 0, count)
+	for i := int64(0); i < count; i++ {
+		e := table[i*format.entrySize:]
+		nameOffset := int(binary.LittleEndian.Uint32(e[16:]))
+		if nameOffset >= len(names) {
+			return nil, ErrUnsupported
+		}
+		name := names[nameOffset:]
+		if end := strings.IndexByte(string(name), 0); end >= 0 {
+			name = name[:end]
+		}
+		entries = append(entries, entry{
+			name:   string(name),
+			offset: dataOffset + int64(binary.LittleEndian.Uint64(e)),
+			size:   int64(binary.LittleEndian.Uint64(e[8:])),
+		})
+	}
+	return entries, nil
+}
+
+// contentMeta is the part of a cnmt.xml read.
+type contentMeta struct {
+	ID string `xml:"Id"`
+}
+
+// nacp is the part of a nacp.xml read.
+type nacp struct {
+	Titles []struct {
+		Language string `xml:"Language"`
+		Name     string `xml:"Name"`
+	} `xml:"Title"`
+}
+
+// name returns the American or British English name, or the first one.
+func (n nacp) name() string {
+	for _, language := range []string{"AmericanEnglish", "BritishEnglish"} {
+		for _, title := range n.Titles {
+			if title.Language == language && title.Name != "" {
+				return strings.TrimSpace(title.Name)
+			}
+		}
+	}
+	for _, title := range n.Titles {
+		if title.Name != "" {
+			return strings.TrimSpace(title.Name)
+		}
+	}
+	return ""
+}
+
+// readNSP reads an NSP, a PFS0 partition of NCAs with a ticket and
+// sometimes XML metadata.
+func (r Reader) readNSP(f io.ReaderAt) (*Info, error) {
+	entries, err := readPartition(f, 0, pfs0)
+	if err != nil {
+		return nil, err
+	}
+
+	info := &Info{Platform: platform.SlugSwitch}
+	for _, e := range entries {
+		name := strings.ToLower(e.name)
+		switch {
+		case strings.HasSuffix(name, ".cnmt.xml") && info.TitleID == "":
+			var meta contentMeta
+			if readXML(f, e, &meta) {
+				if id, err := strconv.ParseUint(strings.TrimPrefix(strings.ToLower(meta.ID), "0x"), 16, 64); err == nil {
+					info.TitleID = titleID(id &^ baseTitleMask)
+				}
+			}
+		case strings.HasSuffix(name, ".tik") && info.TitleID == "":
+			// Tickets are named by rights ID, the title ID and key generation
+			rightsID := strings.TrimSuffix(name, ".tik")
+			if _, err := hex.DecodeString(rightsID); err == nil && len(rightsID) == 32 {
+				id, _ := strconv.ParseUint(rightsID[:16], 16, 64)
+				info.TitleID = titleID(id &^ baseTitleMask)
+			}
+		case strings.HasSuffix(name, ".nacp.xml") && info.Name == "":
+			var control nacp
+			if readXML(f, e, &control) {
+				info.Name = control.name()
+			}
+		}
+	}
+
+	if info.TitleID == "" {
+		id, err := r.ncaTitleID(f, entries)
+		if err != nil {
+			return nil, err
+		}
+		info.TitleID = id
+	}
+	return info, nil
+}
+
+// readXCI reads the NCAs in the secure partition of an XCI game card image.
+func (r Reader) readXCI(f io.ReaderAt) (*Info, error) {
+	header, err := readAt(f, xciHeaderOffset, 0x40)
+	if err != nil {
+		return nil, err
+	}
+	if string(header[:4]) != "HEAD" {
+		return nil, ErrUnsupported
+	}
+	root, err := readPartition(f, int64(binary.LittleEndian.Uint64(header[0x30:])), hfs0)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, partition := range root {
+		if partition.name != "secure" {
+			continue
+		}
+		entries, err := readPartition(f, partition.offset, hfs0)
+		if err != nil {
+			return nil, err
+		}
+		id, err := r.ncaTitleID(f, entries)
+		if err != nil {
+			return nil, err
+		}
+		return &Info{Platform: platform.SlugSwitch, TitleID: id}, nil
+	}
+	return nil, ErrNoTitleID
+}
+
+// ncaTitleID returns the base title ID from the first NCA header that
+// decrypts with the header key.
+func (r Reader) ncaTitleID(f io.ReaderAt, entries []entry) (string, error) {
+	hasNCA := false
+	for _, e := range entries {
+		if !strings.HasSuffix(strings.ToLower(e.name), ".nca") || e.size < ncaHeaderSize {
+			continue
+		}
+		hasNCA = true
+		if len(r.HeaderKey) != 32 {
+			return "", ErrEncrypted
+		}
+		header, err := readAt(f, e.offset, ncaHeaderSize)
+		if err != nil {
+			return "", err
+		}
+		if err := decryptXTS(r.HeaderKey, header, ncaSectorSize); err != nil {
+			return "", err
+		}
+		if magic := string(header[0x200:0x204]); magic != "NCA3" && magic != "NCA2" {
+			continue
+		}
+		return titleID(binary.LittleEndian.Uint64(header[0x210:]) &^ baseTitleMask), nil
+	}
+	if hasNCA {
+		return "", ErrEncrypted
+	}
+	return "", ErrNoTitleID
+}
+
+// readXML unmarshals a partition file as XML, reporting whether it could.
+func readXML(f io.ReaderAt, e entry, v any) bool {
+	if e.size > maxXMLSize {
+		return false
+	}
+	data, err := readAt(f, e.offset, e.size)
+	return err == nil && xml.Unmarshal(data, v) == nil
+}
+
+// decryptXTS decrypts data in place with AES-128-XTS, numbering sectors
+// from 0 with big-endian tweaks as Nintendo does.
+func decryptXTS(key, data []byte, sectorSize int) error {
+	dataCipher, err := aes.NewCipher(key[:16])
+	if err != nil {
+		return err
+	}
+	tweakCipher, err := aes.NewCipher(key[16:])
+	if err != nil {
+		return err
+	}
+
+	tweak := make([]byte, aes.BlockSize)
+	for sector := 0; sector*sectorSize < len(data); sector++ {
+		clear(tweak)
+		binary.BigEndian.PutUint64(tweak[8:], uint64(sector))
+		tweakCipher.Encrypt(tweak, tweak)
+
+		end := min((sector+1)*sectorSize, len(data))
+		for i := sector * sectorSize; i+aes.BlockSize <= end; i += aes.BlockSize {
+			block := data[i : i+aes.BlockSize]
+			for j := range block {
+				block[j] ^= tweak[j]
+			}
+			dataCipher.Decrypt(block, block)
+			for j := range block {
+				block[j] ^= tweak[j]
+			}
+			multiplyTweak(tweak)
+		}
+	}
+	return nil
+}
+
+// multiplyTweak multiplies an XTS tweak by x in GF(2^128).
+func multiplyTweak(tweak []byte) {
+	var carry byte
+	for i := range tweak {
+		next := tweak[i] >> 7
+		tweak[i] = tweak[i]<<1 | carry
+		carry = next
+	}
+	if carry != 0 {
+		tweak[0] ^= 0x87
+	}
+}
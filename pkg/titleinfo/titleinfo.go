@@ -0,0 +1,152 @@
+// Package titleinfo reads the title IDs, product codes and names Switch and
+// 3DS dumps record about themselves, for identifying files named by hash or
+// scene release.
+//
+// Supported dumps:
+//   - Switch .nsp: the title ID from the ticket or cnmt.xml, and the name
+//     from the nacp.xml some dumps include
+//   - Switch .nsp and .xci: the title ID from the NCA headers, which are
+//     encrypted; reading them needs the header key from a prod.keys file
+//   - 3DS .cia: the title ID from the TMD, the product code from the NCCH
+//     header and the name from the SMDH in the meta section
+//   - 3DS .3ds/.cci: the title ID and product code from the NCCH header
+//
+// Names inside NCAs and ExeFS are encrypted with keys that vary per title,
+// and aren't read.
+package titleinfo
+
+import (
+	"bufio"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/josegonzalez/retro-metadata/pkg/platform"
+)
+
+var (
+	// ErrUnsupported is returned for files that aren't dumps of a supported
+	// format.
+	ErrUnsupported = errors.New("unsupported title container")
+
+	// ErrEncrypted is returned for dumps whose title ID is only in
+	// encrypted NCA headers, when no header key was given.
+	ErrEncrypted = errors.New("title container needs a header key")
+
+	// ErrNoTitleID is returned for dumps that don't record a title ID.
+	ErrNoTitleID = errors.New("title container has no title id")
+)
+
+// Info is what a dump records about itself.
+type Info struct {
+	// Platform is the platform the dump is for
+	Platform platform.Slug `json:"platform"`
+	// TitleID is the title ID, as 16 upper case hex digits
+	TitleID string `json:"title_id"`
+	// ProductCode is the product code, like CTR-P-AXCE, for dumps that
+	// record one
+	ProductCode string `json:"product_code,omitempty"`
+	// Name is the English name, for dumps that record one
+	Name string `json:"name,omitempty"`
+}
+
+// Serial returns the product code, or the title ID if there's none, in the
+// form serials.Extract returns.
+func (i *Info) Serial() string {
+	if i.ProductCode != "" {
+		return i.ProductCode
+	}
+	return i.TitleID
+}
+
+// readers are the readers of each file extension.
+var readers = map[string]func(r Reader, f io.ReaderAt) (*Info, error){
+	".nsp": Reader.readNSP,
+	".xci": Reader.readXCI,
+	".cia": Reader.readCIA,
+	".3ds": Reader.readNCSD,
+	".cci": Reader.readNCSD,
+}
+
+// IsContainer reports whether path has the extension of a dump Read
+// supports.
+func IsContainer(path string) bool {
+	_, ok := readers[strings.ToLower(filepath.Ext(path))]
+	return ok
+}
+
+// Reader reads dumps, decrypting NCA headers if HeaderKey is set.
+type Reader struct {
+	// HeaderKey is the 32 byte Switch NCA header key; see LoadHeaderKey
+	HeaderKey []byte
+}
+
+// Read returns what the dump at path records about itself, without a
+// header key.
+func Read(path string) (*Info, error) {
+	return Reader{}.Read(path)
+}
+
+// Read returns what the dump at path records about itself.
+func (r Reader) Read(path string) (*Info, error) {
+	read, ok := readers[strings.ToLower(filepath.Ext(path))]
+	if !ok {
+		return nil, ErrUnsupported
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return read(r, file)
+}
+
+// LoadHeaderKey reads the header_key from a prod.keys file, as dumped by
+// Lockpick.
+func LoadHeaderKey(path string) ([]byte, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		name, value, ok := strings.Cut(scanner.Text(), "=")
+		if !ok || strings.TrimSpace(name) != "header_key" {
+			continue
+		}
+		key, err := hex.DecodeString(strings.TrimSpace(value))
+		if err != nil || len(key) != 32 {
+			return nil, fmt.Errorf("invalid header_key in %s", path)
+		}
+		return key, nil
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return nil, fmt.Errorf("no header_key in %s", path)
+}
+
+// readAt reads size bytes at offset, returning ErrUnsupported if the file
+// is too short.
+func readAt(r io.ReaderAt, offset, size int64) ([]byte, error) {
+	buf := make([]byte, size)
+	n, err := r.ReadAt(buf, offset)
+	if n < len(buf) {
+		if err == nil || errors.Is(err, io.EOF) {
+			return nil, ErrUnsupported
+		}
+		return nil, err
+	}
+	return buf, nil
+}
+
+// titleID formats a title ID as 16 upper case hex digits.
+func titleID(id uint64) string {
+	return fmt.Sprintf("%016X", id)
+}
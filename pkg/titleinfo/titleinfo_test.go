@@ -0,0 +1,223 @@
+package titleinfo
+
+import (
+	"bytes"
+	"crypto/aes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"unicode/utf16"
+
+	"github.com/josegonzalez/retro-metadata/pkg/platform"
+)
+
+type file struct {
+	name string
+	data []byte
+}
+
+// buildPartition builds a PFS0 or HFS0 partition holding files.
+func buildPartition(format partitionFormat, files []file) []byte {
+	var names, data []byte
+	entries := make([]byte, int64(len(files))*format.entrySize)
+	for i, f := range files {
+		e := entries[int64(i)*format.entrySize:]
+		binary.LittleEndian.PutUint64(e, uint64(len(data)))
+		binary.LittleEndian.PutUint64(e[8:], uint64(len(f.data)))
+		binary.LittleEndian.PutUint32(e[16:], uint32(len(names)))
+		names = append(append(names, f.name...), 0)
+		data = append(data, f.data...)
+	}
+	header := make([]byte, 0x10)
+	copy(header, format.magic)
+	binary.LittleEndian.PutUint32(header[4:], uint32(len(files)))
+	binary.LittleEndian.PutUint32(header[8:], uint32(len(names)))
+	return bytes.Join([][]byte{header, entries, names, data}, nil)
+}
+
+// encryptXTS is the inverse of decryptXTS.
+func encryptXTS(key, data []byte, sectorSize int) {
+	dataCipher, _ := aes.NewCipher(key[:16])
+	tweakCipher, _ := aes.NewCipher(key[16:])
+	tweak := make([]byte, aes.BlockSize)
+	for sector := 0; sector*sectorSize < len(data); sector++ {
+		clear(tweak)
+		binary.BigEndian.PutUint64(tweak[8:], uint64(sector))
+		tweakCipher.Encrypt(tweak, tweak)
+		for i := sector * sectorSize; i < (sector+1)*sectorSize; i += aes.BlockSize {
+			block := data[i : i+aes.BlockSize]
+			for j := range block {
+				block[j] ^= tweak[j]
+			}
+			dataCipher.Encrypt(block, block)
+			for j := range block {
+				block[j] ^= tweak[j]
+			}
+			multiplyTweak(tweak)
+		}
+	}
+}
+
+// ncaHeader returns an NCA header for a program ID, encrypted with key.
+func ncaHeader(key []byte, programID uint64) []byte {
+	header := make([]byte, ncaHeaderSize)
+	copy(header[0x200:], "NCA3")
+	binary.LittleEndian.PutUint64(header[0x210:], programID)
+	encryptXTS(key, header, ncaSectorSize)
+	return header
+}
+
+func buildXCI(secure []file) []byte {
+	root := buildPartition(hfs0, []file{{name: "secure", data: buildPartition(hfs0, secure)}})
+	xci := make([]byte, 0x200)
+	copy(xci[xciHeaderOffset:], "HEAD")
+	binary.LittleEndian.PutUint64(xci[xciHeaderOffset+0x30:], uint64(len(xci)))
+	return append(xci, root...)
+}
+
+func buildCIA(titleID uint64, productCode, name string) []byte {
+	cia := make([]byte, align(ciaHeaderSize))
+	binary.LittleEndian.PutUint32(cia, ciaHeaderSize)
+
+	tmd := make([]byte, 4+0x100+0x3C+0x100)
+	binary.BigEndian.PutUint32(tmd, 0x10004)
+	binary.BigEndian.PutUint64(tmd[4+0x100+0x3C+0x4C:], titleID)
+	binary.LittleEndian.PutUint32(cia[0x10:], uint32(len(tmd)))
+	cia = append(cia, make([]byte, align(int64(len(tmd))))...)
+	copy(cia[align(ciaHeaderSize):], tmd)
+
+	ncch := make([]byte, 0x200)
+	copy(ncch[0x100:], "NCCH")
+	binary.LittleEndian.PutUint64(ncch[0x118:], titleID)
+	copy(ncch[0x150:], productCode)
+	binary.LittleEndian.PutUint64(cia[0x18:], uint64(len(ncch)))
+	cia = append(cia, ncch...)
+
+	meta := make([]byte, metaSMDHOffset+smdhSize)
+	smdh := meta[metaSMDHOffset:]
+	copy(smdh, "SMDH")
+	for i, unit := range utf16.Encode([]rune(name)) {
+		binary.LittleEndian.PutUint16(smdh[0x8+smdhEnglish*0x200+2*i:], unit)
+	}
+	binary.LittleEndian.PutUint32(cia[0x14:], uint32(len(meta)))
+	return append(cia, meta...)
+}
+
+func TestReaders(t *testing.T) {
+	key := bytes.Repeat([]byte{0x42, 0x17}, 16)
+	nacpXML := `<?xml version="1.0" encoding="utf-8"?>
+<Application>
+  <Title><Language>Japanese</Language><Name>ゼルダの伝説</Name></Title>
+  <Title><Language>AmericanEnglish</Language><Name>The Legend of Zelda: Breath of the Wild</Name></Title>
+</Application>`
+	cnmtXML := `<ContentMeta><Type>Patch</Type><Id>0x01007ef00011e800</Id></ContentMeta>`
+
+	tests := []struct {
+		name    string
+		read    func(Reader, []byte) (*Info, error)
+		reader  Reader
+		data    []byte
+		want    *Info
+		wantErr error
+	}{
+		{
+			"nsp ticket and nacp", readBytes(Reader.readNSP), Reader{},
+			buildPartition(pfs0, []file{
+				{"0123456789abcdef0123456789abcdef.nca", make([]byte, ncaHeaderSize)},
+				{"01007ef00011e0000000000000000004.tik", make([]byte, 0x2C0)},
+				{"0123456789abcdef0123456789abcdef.nacp.xml", []byte(nacpXML)},
+			}),
+			&Info{Platform: platform.SlugSwitch, TitleID: "01007EF00011E000", Name: "The Legend of Zelda: Breath of the Wild"}, nil,
+		},
+		{
+			"nsp update cnmt", readBytes(Reader.readNSP), Reader{},
+			buildPartition(pfs0, []file{{"abcdef.cnmt.xml", []byte(cnmtXML)}}),
+			&Info{Platform: platform.SlugSwitch, TitleID: "01007EF00011E000"}, nil,
+		},
+		{
+			"nsp nca without key", readBytes(Reader.readNSP), Reader{},
+			buildPartition(pfs0, []file{{"abcdef.nca", ncaHeader(key, 0x0100000000010000)}}),
+			nil, ErrEncrypted,
+		},
+		{
+			"xci with key", readBytes(Reader.readXCI), Reader{HeaderKey: key},
+			buildXCI([]file{{"abcdef.nca", ncaHeader(key, 0x0100000000010000)}}),
+			&Info{Platform: platform.SlugSwitch, TitleID: "0100000000010000"}, nil,
+		},
+		{
+			"xci without key", readBytes(Reader.readXCI), Reader{},
+			buildXCI([]file{{"abcdef.nca", ncaHeader(key, 0x0100000000010000)}}),
+			nil, ErrEncrypted,
+		},
+		{
+			"cia", readBytes(Reader.readCIA), Reader{},
+			buildCIA(0x0004000000030800, "CTR-P-AMKE", "Mario Kart 7"),
+			&Info{Platform: platform.SlugN3DS, TitleID: "0004000000030800", ProductCode: "CTR-P-AMKE", Name: "Mario Kart 7"}, nil,
+		},
+		{
+			"not a container", readBytes(Reader.readNSP), Reader{},
+			make([]byte, 0x1000),
+			nil, ErrUnsupported,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.read(tt.reader, tt.data)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("read error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.want != nil && *got != *tt.want {
+				t.Errorf("read = %+v, want %+v", *got, *tt.want)
+			}
+		})
+	}
+}
+
+// readBytes adapts a reader method to read a byte slice.
+func readBytes(read func(Reader, io.ReaderAt) (*Info, error)) func(Reader, []byte) (*Info, error) {
+	return func(r Reader, data []byte) (*Info, error) {
+		return read(r, bytes.NewReader(data))
+	}
+}
+
+func TestRead(t *testing.T) {
+	dir := t.TempDir()
+
+	ncsd := make([]byte, 0x200)
+	copy(ncsd[0x100:], "NCSD")
+	binary.LittleEndian.PutUint32(ncsd[0x120:], 1)
+	ncch := make([]byte, 0x200)
+	copy(ncch[0x100:], "NCCH")
+	binary.LittleEndian.PutUint64(ncch[0x118:], 0x0004000000055D00)
+	copy(ncch[0x150:], "CTR-P-ECRE")
+	path := filepath.Join(dir, "abc-pkmnx.3ds")
+	if err := os.WriteFile(path, append(ncsd, ncch...), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	info, err := Read(path)
+	if err != nil {
+		t.Fatalf("Read() error = %v", err)
+	}
+	if info.TitleID != "0004000000055D00" || info.Serial() != "CTR-P-ECRE" {
+		t.Errorf("Read() = %+v", *info)
+	}
+
+	if _, err := Read(filepath.Join(dir, "game.zip")); !errors.Is(err, ErrUnsupported) {
+		t.Errorf("Read(.zip) error = %v, want ErrUnsupported", err)
+	}
+
+	keys := filepath.Join(dir, "prod.keys")
+	if err := os.WriteFile(keys, []byte("aes_kek_generation_source = 00\nheader_key = "+
+		"4217421742174217421742174217421742174217421742174217421742174217\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	key, err := LoadHeaderKey(keys)
+	if err != nil || !bytes.Equal(key, bytes.Repeat([]byte{0x42, 0x17}, 16)) {
+		t.Errorf("LoadHeaderKey() = %x, %v", key, err)
+	}
+}
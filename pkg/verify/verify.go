@@ -0,0 +1,235 @@
+// Package verify provides ROM integrity verification against known-good dump signatures.
+//
+// Files are hashed and looked up against a signature source such as Hasheous,
+// which aggregates No-Intro, Redump and TOSEC datfiles, or a local datfile. Files that don't match
+// any known good dump are classified as bad, trimmed, overdumped or modified
+// using their dump flags and size.
+package verify
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/josegonzalez/retro-metadata/pkg/filename"
+	"github.com/josegonzalez/retro-metadata/pkg/internal/hashing"
+	"github.com/josegonzalez/retro-metadata/pkg/progress"
+	"github.com/josegonzalez/retro-metadata/pkg/retrometadata"
+)
+
+// Status is the verification status of a ROM file.
+type Status string
+
+// Verification statuses.
+const (
+	// StatusVerified indicates the file matches a known good dump
+	StatusVerified Status = "verified"
+	// StatusBadDump indicates the file is flagged as a bad dump
+	StatusBadDump Status = "bad_dump"
+	// StatusTrimmed indicates the file is smaller than the expected dump size
+	StatusTrimmed Status = "trimmed"
+	// StatusOverdumped indicates the file contains more data than the original media
+	StatusOverdumped Status = "overdumped"
+	// StatusModified indicates the file is a hack, trainer, fix or translation
+	StatusModified Status = "modified"
+	// StatusUnknown indicates the file doesn't match any known dump and can't be classified
+	StatusUnknown Status = "unknown"
+)
+
+// SignatureMatcher looks up file hashes against known-good dump signatures.
+type SignatureMatcher interface {
+	// MatchSignatures returns the signatures matching the hashes.
+	// A nil or empty result means no known good dump matches.
+	MatchSignatures(ctx context.Context, hashes retrometadata.FileHashes) (*retrometadata.Signatures, error)
+}
+
+// Result is the verification result for a single file.
+type Result struct {
+	// Path is the path of the verified file
+	Path string `json:"path"`
+	// Size is the file size in bytes
+	Size int64 `json:"size"`
+	// Hashes are the computed file hashes
+	Hashes retrometadata.FileHashes `json:"hashes"`
+	// Status is the verification status
+	Status Status `json:"status"`
+	// Source is the signature source of the first match, if verified
+	Source string `json:"source,omitempty"`
+	// MatchedName is the dump name of the first match, if verified
+	MatchedName string `json:"matched_name,omitempty"`
+	// Signatures is every signature that matched the file
+	Signatures *retrometadata.Signatures `json:"signatures,omitempty"`
+	// Reason explains the status for files that aren't verified
+	Reason string `json:"reason,omitempty"`
+	// Error contains the error message if verification failed
+	Error string `json:"error,omitempty"`
+}
+
+// IsGoodDump returns true if the file matches a known good dump.
+func (r *Result) IsGoodDump() bool {
+	return r.Status == StatusVerified
+}
+
+// Report is a verification report for a set of files.
+type Report struct {
+	// Results is the per-file verification results
+	Results []Result `json:"results"`
+	// Counts is the number of files per status
+	Counts map[Status]int `json:"counts"`
+	// Errors is the number of files that couldn't be verified
+	Errors int `json:"errors"`
+}
+
+// BadDumps returns the results for files that don't match a known good dump.
+func (r *Report) BadDumps() []Result {
+	var bad []Result
+	for _, result := range r.Results {
+		if result.Error == "" && !result.IsGoodDump() {
+			bad = append(bad, result)
+		}
+	}
+	return bad
+}
+
+// Verifier verifies ROM files against a signature matcher.
+type Verifier struct {
+	matcher  SignatureMatcher
+	progress progress.Progress
+}
+
+// NewVerifier creates a new Verifier.
+func NewVerifier(matcher SignatureMatcher) *Verifier {
+	return &Verifier{matcher: matcher, progress: progress.Noop{}}
+}
+
+// SetProgress sets the progress reporter used by VerifyFiles.
+func (v *Verifier) SetProgress(p progress.Progress) {
+	v.progress = progress.OrNoop(p)
+}
+
+// VerifyFile hashes a file and verifies it against known good dumps.
+func (v *Verifier) VerifyFile(ctx context.Context, path string) (*Result, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("stat file: %w", err)
+	}
+
+	fileHashes, err := hashing.ComputeFileHashes(path)
+	if err != nil {
+		return nil, err
+	}
+
+	hashes := retrometadata.FileHashes{
+		MD5:    fileHashes.MD5,
+		SHA1:   fileHashes.SHA1,
+		CRC32:  fileHashes.CRC32,
+		SHA256: fileHashes.SHA256,
+	}
+
+	return v.Verify(ctx, path, info.Size(), hashes)
+}
+
+// Verify verifies precomputed hashes for a file against known good dumps.
+func (v *Verifier) Verify(ctx context.Context, path string, size int64, hashes retrometadata.FileHashes) (*Result, error) {
+	result := &Result{
+		Path:   path,
+		Size:   size,
+		Hashes: hashes,
+	}
+
+	signatures, err := v.matcher.MatchSignatures(ctx, hashes)
+	if err != nil {
+		return nil, err
+	}
+
+	if signatures != nil && len(signatures.Matches) > 0 {
+		result.Status = StatusVerified
+		result.Signatures = signatures
+		result.Source = signatures.Matches[0].Source
+		result.MatchedName = signatures.Matches[0].Name
+		return result, nil
+	}
+
+	result.Status, result.Reason = Classify(path, size)
+	return result, nil
+}
+
+// VerifyFiles verifies a list of files and returns a report.
+// Files that fail to verify are recorded in the report rather than aborting.
+func (v *Verifier) VerifyFiles(ctx context.Context, paths []string) (*Report, error) {
+	report := &Report{
+		Counts: make(map[Status]int),
+	}
+
+	v.progress.Start(len(paths))
+	defer v.progress.Done()
+
+	for _, path := range paths {
+		if err := ctx.Err(); err != nil {
+			return report, err
+		}
+		v.progress.SetStatus(filepath.Base(path))
+
+		result, err := v.VerifyFile(ctx, path)
+		v.progress.Increment(1)
+		if err != nil {
+			report.Results = append(report.Results, Result{Path: path, Error: err.Error()})
+			report.Errors++
+			continue
+		}
+
+		report.Results = append(report.Results, *result)
+		report.Counts[result.Status]++
+	}
+
+	return report, nil
+}
+
+var (
+	// badDumpPattern matches GoodTools bad dump flags like [b] or [b1]
+	badDumpPattern = regexp.MustCompile(`\[b\d*\]`)
+
+	// overdumpPattern matches GoodTools overdump flags like [o] or [o2]
+	overdumpPattern = regexp.MustCompile(`\[o\d*\]`)
+
+	// modifiedPattern matches hack, trainer, fixed and translation flags
+	modifiedPattern = regexp.MustCompile(`(?i)\[(h|t|f|T[+-])[^\]]*\]|\((hack|translated)\)`)
+)
+
+// trimmableExtensions are cartridge formats whose good dumps are always a power
+// of two in size, so a smaller odd-sized file indicates the padding was trimmed.
+var trimmableExtensions = map[string]bool{
+	"gba": true,
+	"nds": true,
+	"dsi": true,
+	"3ds": true,
+	"xci": true,
+}
+
+// Classify determines why a file that doesn't match any known good dump is bad,
+// based on its dump flags and size. It returns the status and a human-readable reason.
+func Classify(path string, size int64) (Status, string) {
+	name := filepath.Base(strings.TrimSpace(path))
+
+	switch {
+	case badDumpPattern.MatchString(name):
+		return StatusBadDump, "filename is flagged as a bad dump"
+	case overdumpPattern.MatchString(name):
+		return StatusOverdumped, "filename is flagged as an overdump"
+	case modifiedPattern.MatchString(name):
+		return StatusModified, "filename is flagged as a hack, trainer, fix or translation"
+	}
+
+	if trimmableExtensions[filename.GetFileExtension(name)] && size > 0 && !isPowerOfTwo(size) {
+		return StatusTrimmed, fmt.Sprintf("cartridge size %d is not a power of two", size)
+	}
+
+	return StatusUnknown, "hashes do not match any known good dump"
+}
+
+func isPowerOfTwo(n int64) bool {
+	return n > 0 && n&(n-1) == 0
+}
@@ -0,0 +1,65 @@
+package verify
+
+import (
+	"context"
+	"testing"
+
+	"github.com/josegonzalez/retro-metadata/pkg/retrometadata"
+)
+
+type fakeMatcher struct {
+	matches map[string]*retrometadata.Signatures
+}
+
+func (m *fakeMatcher) MatchSignatures(_ context.Context, hashes retrometadata.FileHashes) (*retrometadata.Signatures, error) {
+	return m.matches[hashes.MD5], nil
+}
+
+func TestClassify(t *testing.T) {
+	tests := []struct {
+		path     string
+		size     int64
+		expected Status
+	}{
+		{"roms/Super Mario World (U) [b1].smc", 524288, StatusBadDump},
+		{"roms/Sonic the Hedgehog (W) [o1].bin", 1048576, StatusOverdumped},
+		{"roms/Super Metroid (U) [h1C].smc", 3145728, StatusModified},
+		{"roms/Mother 3 (J) [T+Eng1.2].gba", 33554432, StatusModified},
+		{"roms/Pokemon Emerald (USA).gba", 15000000, StatusTrimmed},
+		{"roms/Pokemon Emerald (USA).gba", 16777216, StatusUnknown},
+		{"roms/[b1] folder/Tetris (World).gb", 32768, StatusUnknown},
+	}
+
+	for _, tt := range tests {
+		status, _ := Classify(tt.path, tt.size)
+		if status != tt.expected {
+			t.Errorf("Classify(%q, %d) = %q, expected %q", tt.path, tt.size, status, tt.expected)
+		}
+	}
+}
+
+func TestVerify(t *testing.T) {
+	matcher := &fakeMatcher{matches: map[string]*retrometadata.Signatures{
+		"good": {Matches: []retrometadata.SignatureMatch{
+			{Source: retrometadata.SignatureSourceNoIntro, Name: "Tetris (World)"},
+		}},
+	}}
+	verifier := NewVerifier(matcher)
+	ctx := context.Background()
+
+	result, err := verifier.Verify(ctx, "Tetris (World).gb", 32768, retrometadata.FileHashes{MD5: "good"})
+	if err != nil {
+		t.Fatalf("Verify() error: %v", err)
+	}
+	if !result.IsGoodDump() || result.Source != retrometadata.SignatureSourceNoIntro || result.MatchedName != "Tetris (World)" {
+		t.Errorf("Verify() = %+v, expected verified No-Intro match", result)
+	}
+
+	result, err = verifier.Verify(ctx, "Tetris (World) [b2].gb", 32768, retrometadata.FileHashes{MD5: "bad"})
+	if err != nil {
+		t.Fatalf("Verify() error: %v", err)
+	}
+	if result.Status != StatusBadDump || result.Reason == "" {
+		t.Errorf("Verify() = %+v, expected bad dump with reason", result)
+	}
+}